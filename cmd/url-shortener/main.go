@@ -2,38 +2,105 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"golang.org/x/exp/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	adminOwner "url-shortener/internal/http-server/handlers/admin/owner"
+	adminStatus "url-shortener/internal/http-server/handlers/admin/status"
+	adminToken "url-shortener/internal/http-server/handlers/admin/token"
+	"url-shortener/internal/http-server/handlers/health"
+	"url-shortener/internal/http-server/handlers/metrics"
+	"url-shortener/internal/http-server/handlers/openapi"
+	"url-shortener/internal/http-server/handlers/robots"
+	exportAnalytics "url-shortener/internal/http-server/handlers/url/analytics/export"
+	"url-shortener/internal/http-server/handlers/url/broken"
+	"url-shortener/internal/http-server/handlers/url/card"
+	"url-shortener/internal/http-server/handlers/url/check"
+	"url-shortener/internal/http-server/handlers/url/count"
 	deleteURL "url-shortener/internal/http-server/handlers/url/delete"
+	"url-shortener/internal/http-server/handlers/url/extend"
+	"url-shortener/internal/http-server/handlers/url/link"
+	"url-shortener/internal/http-server/handlers/url/lookup"
+	"url-shortener/internal/http-server/handlers/url/public"
+	"url-shortener/internal/http-server/handlers/url/purge"
+	"url-shortener/internal/http-server/handlers/url/qrbatch"
 	"url-shortener/internal/http-server/handlers/url/redirect"
+	refreshPreview "url-shortener/internal/http-server/handlers/url/refreshpreview"
+	"url-shortener/internal/http-server/handlers/url/regenerate"
+	"url-shortener/internal/http-server/handlers/url/resolve"
+	"url-shortener/internal/http-server/handlers/url/restore"
 	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/handlers/url/stale"
+	"url-shortener/internal/http-server/handlers/url/stats"
+	"url-shortener/internal/http-server/handlers/url/suggest"
+	listTags "url-shortener/internal/http-server/handlers/url/tags/list"
+	updateTags "url-shortener/internal/http-server/handlers/url/tags/update"
+	"url-shortener/internal/http-server/handlers/url/target"
+	"url-shortener/internal/http-server/handlers/url/timeseries"
+	"url-shortener/internal/http-server/handlers/url/trash"
+	updateURL "url-shortener/internal/http-server/handlers/url/update"
+	"url-shortener/internal/http-server/handlers/url/visibility"
 	deleteUser "url-shortener/internal/http-server/handlers/user/delete"
+	"url-shortener/internal/http-server/handlers/user/export"
 	"url-shortener/internal/http-server/handlers/user/login"
+	"url-shortener/internal/http-server/handlers/user/logins"
+	claimPrefix "url-shortener/internal/http-server/handlers/user/prefixes/claim"
+	listSessions "url-shortener/internal/http-server/handlers/user/sessions/list"
+	revokeSession "url-shortener/internal/http-server/handlers/user/sessions/revoke"
+	getSettings "url-shortener/internal/http-server/handlers/user/settings/get"
+	updateSettings "url-shortener/internal/http-server/handlers/user/settings/update"
+	transferURLs "url-shortener/internal/http-server/handlers/user/transfer"
+	"url-shortener/internal/lib/aliasgroup"
+	"url-shortener/internal/lib/aliaslimit"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/linkcheck"
+	libMetrics "url-shortener/internal/lib/metrics"
+	"url-shortener/internal/lib/preview"
+	"url-shortener/internal/lib/ttllimit"
+	"url-shortener/internal/lib/urlnorm"
+	"url-shortener/internal/linkhealth"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/healthcheck"
 	"url-shortener/internal/storage/mongodb"
 	"url-shortener/internal/storage/multiStorage"
+	"url-shortener/internal/trashpurge"
 
 	"url-shortener/internal/config"
 	"url-shortener/internal/http-server/handlers/user/register"
+	"url-shortener/internal/http-server/middleware/aliascheck"
+	"url-shortener/internal/http-server/middleware/aliasnorm"
 	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/http-server/middleware/bodylog"
+	"url-shortener/internal/http-server/middleware/contenttype"
+	"url-shortener/internal/http-server/middleware/cors"
+	"url-shortener/internal/http-server/middleware/idempotency"
+	"url-shortener/internal/http-server/middleware/limiter"
 	mwLogger "url-shortener/internal/http-server/middleware/logger"
+	"url-shortener/internal/http-server/middleware/realip"
+	"url-shortener/internal/http-server/middleware/replay"
+	"url-shortener/internal/http-server/middleware/routepolicy"
+	"url-shortener/internal/lib/blacklist"
+	"url-shortener/internal/lib/botfilter"
+	"url-shortener/internal/lib/forward"
 	"url-shortener/internal/lib/logger/handlers/slogpretty"
 	"url-shortener/internal/lib/logger/sl"
 	"url-shortener/internal/storage/sqlite"
 )
 
-const (
-	envLocal = "local"
-	envDev   = "dev"
-	envProd  = "prod"
-)
-
 func main() {
+	migrateOnly := flag.Bool("migrate-only", false, "apply storage schema migrations and exit without starting the server")
+	flag.Parse()
+
 	cfg := config.MustLoad()
 	log := setupLogger(cfg.Env)
 	log.Info(
@@ -42,64 +109,520 @@ func main() {
 	)
 	log.Debug("debug messages are enabled")
 
+	// Отдельный шаг деплоя: схема обновляется до выкатки новой версии сервиса
+	if *migrateOnly {
+		if err := migrate(context.Background(), log, cfg); err != nil {
+			log.Error("migration failed", sl.Err(err))
+			os.Exit(1)
+		}
+		log.Info("migration finished, exiting")
+		return
+	}
+
+	auth.JWTSecret = []byte(cfg.JWTSecret)
+	auth.JWTKeyID = cfg.JWTKeyID
+	auth.TokenTTL = cfg.JWTTTL
+	auth.MaxTokenLifetime = cfg.JWTMaxLifetime
+	if cfg.JWTMaxLifetime > 0 && cfg.JWTTTL > cfg.JWTMaxLifetime {
+		log.Warn("jwt_ttl exceeds jwt_max_lifetime, tokens are issued for jwt_max_lifetime",
+			slog.Duration("jwt_ttl", cfg.JWTTTL), slog.Duration("jwt_max_lifetime", cfg.JWTMaxLifetime))
+	}
+	auth.PreviousKeys = make(map[string]auth.VerificationKey, len(cfg.JWTPreviousKeys))
+	for _, key := range cfg.JWTPreviousKeys {
+		auth.PreviousKeys[key.ID] = auth.VerificationKey{Secret: []byte(key.Secret), Until: key.Until}
+	}
+	auth.PasswordPepper = []byte(cfg.PasswordPepper)
+	auth.Admins = cfg.Admins
+	if cfg.NicknameHashing.Enabled {
+		// Токены выдаются на каноническую форму никнейма, и список сравнивается с ней же
+		auth.Admins = make([]string, 0, len(cfg.Admins))
+		for _, admin := range cfg.Admins {
+			auth.Admins = append(auth.Admins, storage.CanonicalNickname(admin))
+		}
+	}
+	if len(cfg.AuthSchemes) > 0 {
+		auth.AuthSchemes = cfg.AuthSchemes
+	}
+	auth.AllowRawToken = cfg.AllowRawToken
+	access.HideForeign = cfg.HideForeignLinks
+	resp.Style = cfg.ResponseStyle
+	auth.Sessions = auth.NewSessionStore(cfg.MaxSessionsPerUser)
+
+	var err error
+
+	// Режим хранения, основная база и формат ошибок проверены в config.Load
+	log.Info("storage mode", slog.String("storage_mode", cfg.StorageMode), slog.String("primary_store", cfg.PrimaryStore))
+
+	// Никнеймы хэшируются одинаково в обеих базах
+	var nicknameHashKey []byte
+	if cfg.NicknameHashing.Enabled {
+		if cfg.NicknameHashing.Key == "" {
+			log.Error("nickname_hashing.key is required when nickname hashing is enabled")
+			os.Exit(1)
+		}
+		nicknameHashKey = []byte(cfg.NicknameHashing.Key)
+	}
+
+	// Инициализация SQLite
+	var sqliteDB *sqlite.Storage
+	if cfg.StorageMode != storage.ModeMongo {
+		sqliteDB, err = sqlite.New(cfg.StoragePath)
+		if err != nil {
+			log.Error("failed to init SQLite", sl.Err(err))
+			os.Exit(1)
+		}
+
+		sqliteDB.SetRetry(sqlite.RetryConfig{
+			Attempts: cfg.SQLiteRetry.Attempts,
+			Backoff:  cfg.SQLiteRetry.Backoff,
+		})
+
+		if err := sqliteDB.UseCaseInsensitiveAliases(cfg.CaseInsensitiveAliases); err != nil {
+			log.Error("failed to configure alias case mode in SQLite", sl.Err(err))
+			os.Exit(1)
+		}
+		sqliteDB.UseHashedNicknames(nicknameHashKey)
+	}
+
 	// Инициализация MongoDB
-	mongoDB, err := mongodb.NewClient(context.Background(), cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.Database, cfg.AuthDB, cfg.URI)
+	var mongoDB *mongodb.Storage
+	if cfg.StorageMode != storage.ModeSQLite {
+		mongoDB, err = mongodb.NewClient(context.Background(), cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.Database, cfg.AuthDB, cfg.URI)
+		if err != nil {
+			log.Error("failed to init MongoDB", sl.Err(err))
+			os.Exit(1)
+		}
+
+		if err := mongoDB.UseCaseInsensitiveAliases(context.Background(), cfg.CaseInsensitiveAliases); err != nil {
+			log.Error("failed to configure alias case mode in MongoDB", sl.Err(err))
+			os.Exit(1)
+		}
+		mongoDB.UseHashedNicknames(nicknameHashKey)
+
+		// Без индексов работают проверки перед вставкой, поэтому ошибка не фатальна
+		if err := mongoDB.EnsureIndexes(context.Background()); err != nil {
+			log.Warn("failed to create unique indexes in MongoDB", sl.Err(err))
+		}
+	}
+
+	var appStorage *multiStorage.DualStorage
+	switch cfg.StorageMode {
+	case storage.ModeSQLite:
+		appStorage = multiStorage.NewSQLiteStorage(sqliteDB)
+	case storage.ModeMongo:
+		appStorage = multiStorage.NewMongoStorage(mongoDB)
+	default:
+		appStorage = multiStorage.NewDualStorage(sqliteDB, mongoDB)
+		appStorage.SetPrimary(cfg.PrimaryStore)
+		appStorage.SetStrictNotFound(cfg.AmbiguousNotFound == "unavailable")
+	}
+
+	// Счётчик id в MongoDB должен быть выше id уже зарегистрированных пользователей
+	if err := appStorage.SeedUserIDs(context.Background(), log); err != nil {
+		log.Error("failed to seed user id counter in MongoDB", sl.Err(err))
+		os.Exit(1)
+	}
+
+	healthCtx, stopHealthCheck := context.WithCancel(context.Background())
+	defer stopHealthCheck()
+
+	// Фоновая проверка MongoDB: пока она недоступна, сервис работает только с SQLite.
+	// Переключаться есть куда только в режиме dual.
+	if cfg.StorageMode == storage.ModeDual {
+		mongoChecker := healthcheck.New(
+			log,
+			mongoDB,
+			cfg.MongoDB.HealthCheckInterval,
+			cfg.MongoDB.HealthCheckFailures,
+			func(up bool) { appStorage.SetDegraded(!up) },
+		)
+		go mongoChecker.Run(healthCtx)
+	}
+
+	// RealIP доверяет заголовкам только от прокси из конфига
+	realIP, err := realip.New(cfg.HTTPServer.TrustedProxies)
 	if err != nil {
-		log.Error("failed to init MongoDB", sl.Err(err))
+		log.Error("failed to init RealIP middleware", sl.Err(err))
 		os.Exit(1)
 	}
 
-	// Инициализация SQLite
-	sqliteDB, err := sqlite.New(cfg.StoragePath)
+	// CORS с credentials нельзя совмещать с origin "*" - такой конфиг отклоняется при старте
+	corsMiddleware, err := cors.New(cors.Config{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	})
+	if err != nil {
+		log.Error("failed to init CORS middleware", sl.Err(err))
+		os.Exit(1)
+	}
+	// Для маршрутов с cors: any - любой origin, но без credentials
+	corsAny, err := cors.New(cors.Config{
+		AllowedOrigins: []string{"*"},
+		MaxAge:         cfg.CORS.MaxAge,
+	})
 	if err != nil {
-		log.Error("failed to init SQLite", sl.Err(err))
+		log.Error("failed to init CORS middleware", sl.Err(err))
 		os.Exit(1)
 	}
 
-	multiStorage := multiStorage.NewDualStorage(sqliteDB, mongoDB)
+	// Какие маршруты требуют токен и какой CORS у них действует, задаётся здесь, а не при регистрации
+	policyRules := routepolicy.Defaults()
+	for _, rule := range cfg.RoutePolicy {
+		policyRules = append(policyRules, routepolicy.Rule{
+			Pattern: rule.Pattern,
+			Auth:    rule.Auth,
+			CORS:    rule.CORS,
+		})
+	}
+	routePolicy, err := routepolicy.New(policyRules)
+	if err != nil {
+		log.Error("invalid route_policy", sl.Err(err))
+		os.Exit(1)
+	}
 
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
-	router.Use(middleware.RealIP)
-	router.Use(middleware.Logger)
+	router.Use(realIP)
+	// Построчный лог запросов по умолчанию выключен в prod (см. config.RequestLogging)
+	if *cfg.RequestLogging {
+		router.Use(middleware.Logger)
+	}
 	router.Use(mwLogger.New(log))
+	if cfg.BodyLogging.Enabled {
+		router.Use(bodylog.New(log, cfg.BodyLogging.MaxBytes))
+	}
 	router.Use(middleware.Recoverer)
-	router.Use(middleware.URLFormat)
+	router.Use(routePolicy.CORS(router, corsMiddleware, corsAny))
+	// Превышение частоты одним клиентом - 429, общая перегрузка сервера - 503
+	router.Use(limiter.NewRate(log, limiter.RateConfig{
+		Name:   "ip",
+		Limit:  cfg.RateLimit.PerIP,
+		Window: cfg.RateLimit.Window,
+		Key:    limiter.ByIP,
+	}))
+	router.Use(limiter.New(log, limiter.Config{
+		MaxInFlight:  cfg.HTTPServer.MaxInFlight,
+		QueueTimeout: cfg.HTTPServer.QueueTimeout,
+		RetryAfter:   cfg.HTTPServer.RetryAfter,
+	}))
+	if cfg.StripSlashes {
+		router.Use(middleware.StripSlashes)
+	}
+	// URLFormat обрезает alias с точкой, поэтому включается только явно (см. config.URLFormat)
+	if cfg.URLFormat {
+		router.Use(middleware.URLFormat)
+	}
+	router.Use(routePolicy.Auth(router, func(next http.Handler) http.Handler {
+		return auth.TokenAuthMiddleware(next)
+	}))
+
+	// Защита от повторной отправки перехваченных запросов включается через конфиг
+	writeGuard := func(next http.Handler) http.Handler { return next }
+	if cfg.ReplayProtection.Enabled {
+		nonces := replay.NewNonceStore(2 * cfg.ReplayProtection.Window)
+		writeGuard = replay.New(log, cfg.ReplayProtection.Window, []byte(cfg.ReplayProtection.Secret), nonces)
+	}
+
+	// Повтор сохранения с тем же Idempotency-Key не создаёт вторую ссылку
+	idempotent := func(next http.Handler) http.Handler { return next }
+	if cfg.Idempotency.TTL > 0 {
+		idempotencyStore := idempotency.NewStore(cfg.Idempotency.TTL)
+		go idempotencyStore.Run(healthCtx)
+		idempotent = idempotency.New(log, idempotencyStore, cfg.Idempotency.MaxBodyBytes)
+	}
+
+	// Счётчики сервиса, отдаются на /metrics
+	metricsRegistry := libMetrics.NewRegistry()
+	aliasMetrics := libMetrics.NewAliasGeneration(metricsRegistry)
+	// Записи, принятые только одной базой: в деградированном режиме это записи без MongoDB
+	partialWrites := metricsRegistry.Counter("storage_partial_writes_total", "Writes stored in only one of the two databases.")
+	appStorage.SetWriteObserver(func(r storage.WriteResult) {
+		if r.Partial() {
+			partialWrites.Inc()
+		}
+	})
+
+	// JSON-эндпоинты с телом запроса принимают только application/json
+	requireJSON := contenttype.New(log)
+	aliasGrouping := aliasgroup.Grouping{
+		Size:        cfg.AliasGeneration.GroupSize,
+		Significant: cfg.AliasGeneration.GroupSignificant,
+	}
+	// Сгруппированный alias приводится к хранимому виду до проверки контрольного символа
+	normalizeAlias := aliasnorm.New(aliasGrouping)
+	// Alias с неверным контрольным символом отсекаются до обращения к хранилищу
+	checkAlias := aliascheck.New(log, cfg.AliasGeneration.Checksum, cfg.CaseInsensitiveAliases)
+	// Лимит переходов по одной ссылке, чтобы одна популярная ссылка не забирала все ресурсы
+	aliasRate := limiter.NewRate(log, limiter.RateConfig{
+		Name:   "alias",
+		Limit:  cfg.RateLimit.PerAlias,
+		Window: cfg.RateLimit.Window,
+		Key:    limiter.ByAlias,
+	})
+
+	if cfg.AliasGeneration.Hash && cfg.AliasGeneration.Salt == "" {
+		log.Error("alias_generation.salt is required when hash aliases are enabled")
+		os.Exit(1)
+	}
+
+	aliasLimit := aliaslimit.Limit{Max: cfg.MaxAliasLength, Checksum: cfg.AliasGeneration.Checksum, Grouping: aliasGrouping}
+	// Значимые дефисы групп тоже занимают место в пределе
+	if aliasLimit.Clamp(cfg.AliasGeneration.Length) < cfg.AliasGeneration.Length {
+		log.Error("max_alias_length is too small for alias_generation.length",
+			slog.Int("max_alias_length", cfg.MaxAliasLength),
+			slog.Int("length", cfg.AliasGeneration.Length),
+		)
+		os.Exit(1)
+	}
+
+	aliasBlacklist := blacklist.Default()
+	if cfg.AliasGeneration.BlacklistPath != "" {
+		aliasBlacklist, err = blacklist.Load(cfg.AliasGeneration.BlacklistPath)
+		if err != nil {
+			log.Error("failed to load alias blacklist", sl.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	// Срок жизни ссылки проверяется одинаково при сохранении и продлении
+	ttlLimit := ttllimit.Limit{
+		Min:          cfg.MinURLTTL,
+		Max:          cfg.MaxURLTTL,
+		DenyNoExpiry: !*cfg.AllowNeverExpire,
+	}
+	if cfg.DefaultURLTTL > 0 {
+		if err := ttlLimit.Check(cfg.DefaultURLTTL); err != nil {
+			log.Error("default url ttl is out of range", slog.Duration("default_url_ttl", cfg.DefaultURLTTL), sl.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	saveOptions := save.Options{
+		DefaultTTL:           cfg.DefaultURLTTL,
+		TTL:                  ttlLimit,
+		AliasLength:          cfg.AliasGeneration.Length,
+		MinAliasLength:       cfg.AliasGeneration.MinLength,
+		MaxAliasLength:       cfg.AliasGeneration.MaxLength,
+		AliasCeiling:         cfg.MaxAliasLength,
+		CollisionProbes:      cfg.AliasGeneration.CollisionProbes,
+		MinCustomAliasLength: cfg.AliasGeneration.MinCustomLength,
+		Blacklist:            aliasBlacklist,
+		Checksum:             cfg.AliasGeneration.Checksum,
+		ChecksumFoldCase:     cfg.CaseInsensitiveAliases,
+		MaxTags:              cfg.MaxTagsPerURL,
+		MaxDescriptionLength: cfg.MaxDescriptionLength,
+		HashAliases:          cfg.AliasGeneration.Hash,
+		AliasSalt:            cfg.AliasGeneration.Salt,
+		Grouping:             aliasGrouping,
+		Metrics:              aliasMetrics,
+		Duplicates:           appStorage,
+		AllowDuplicateURLs:   *cfg.AllowDuplicateURLs,
+		RejectDottedAliases:  cfg.URLFormat,
+		Normalization: urlnorm.Options{
+			LowercaseHost:      cfg.URLNormalization.LowercaseHost,
+			StripWWW:           cfg.URLNormalization.StripWWW,
+			StripFragment:      cfg.URLNormalization.StripFragment,
+			StripTrailingSlash: cfg.URLNormalization.StripTrailingSlash,
+		},
+	}
+	if cfg.AliasGeneration.Sequence {
+		saveOptions.Sequence = appStorage
+	}
+
+	// Новый alias генерируется по тем же правилам, что и при сохранении
+	regenerateOptions := regenerate.Options{
+		AliasLength:      cfg.AliasGeneration.Length,
+		Checksum:         cfg.AliasGeneration.Checksum,
+		ChecksumFoldCase: cfg.CaseInsensitiveAliases,
+		Blacklist:        aliasBlacklist,
+		AliasCeiling:     cfg.MaxAliasLength,
+		Grouping:         aliasGrouping,
+		Metrics:          aliasMetrics,
+	}
+
+	linkOptions := link.Options{
+		AliasLength:      cfg.AliasGeneration.Length,
+		Checksum:         cfg.AliasGeneration.Checksum,
+		ChecksumFoldCase: cfg.CaseInsensitiveAliases,
+		Blacklist:        aliasBlacklist,
+		AliasCeiling:     cfg.MaxAliasLength,
+		Grouping:         aliasGrouping,
+		Metrics:          aliasMetrics,
+	}
+
+	// Все запросы к целям ссылок идут через клиент, который не ходит во внутреннюю сеть
+	linkClient := linkcheck.NewClient(cfg.LinkCheck.Timeout, cfg.LinkCheck.MaxRedirects, cfg.LinkCheck.AllowPrivateNetworks)
+	linkChecker := linkcheck.New(linkClient, cfg.LinkCheck.CacheTTL)
+
+	// Фоновая проверка ссылок включается явно: она обращается к чужим сайтам
+	if cfg.LinkHealth.Enabled {
+		scanner := linkhealth.New(
+			log,
+			appStorage,
+			linkcheck.New(linkClient, 0),
+			linkcheck.NewRobots(linkClient, cfg.LinkHealth.RobotsCacheTTL),
+			linkhealth.Options{
+				Interval:     cfg.LinkHealth.Interval,
+				RecheckAfter: cfg.LinkHealth.RecheckAfter,
+				BatchSize:    cfg.LinkHealth.BatchSize,
+				Concurrency:  cfg.LinkHealth.Concurrency,
+				Delay:        cfg.LinkHealth.Delay,
+			},
+		)
+		go scanner.Run(healthCtx)
+	}
+
+	// Ссылки с истёкшим сроком восстановления удаляются из корзины окончательно, освобождая alias
+	trashPurger := trashpurge.New(log, appStorage, cfg.TrashRestoreWindow, cfg.TrashPurgeInterval)
+	go trashPurger.Run(healthCtx)
+
+	cardOptions := card.Options{
+		BaseURL:        cfg.BaseURL,
+		PreviewTimeout: cfg.Preview.Timeout,
+	}
+	titleFetcher := preview.New(
+		linkcheck.NewClient(cfg.Preview.Timeout, cfg.LinkCheck.MaxRedirects, cfg.LinkCheck.AllowPrivateNetworks),
+		cfg.Preview.MaxBytes,
+	)
+	// Обновление превью ходит на чужие сайты, поэтому его частота ограничена на пользователя
+	previewRefreshRate := limiter.NewRate(log, limiter.RateConfig{
+		Name:   "user",
+		Limit:  cfg.Preview.RefreshLimit,
+		Window: cfg.Preview.RefreshWindow,
+		Key:    limiter.ByUser,
+	})
+
+	readiness := &health.Readiness{}
+
+	apiSpec, err := openapi.New(openapi.Spec())
+	if err != nil {
+		log.Error("failed to build openapi spec", sl.Err(err))
+		os.Exit(1)
+	}
 
 	router.Route("/", func(r chi.Router) {
-		r.Post("/register", register.New(log, multiStorage))
-		r.Post("/login", login.New(log, multiStorage))
-		r.Post("/url/save", auth.TokenAuthMiddleware(save.New(log, multiStorage)))
-		r.Delete("/url/{alias}", auth.TokenAuthMiddleware(deleteURL.New(log, multiStorage)))
-		r.Delete("/user/{nickname}", auth.TokenAuthMiddleware(deleteUser.New(log, multiStorage)))
+		r.Get("/healthz", health.Live())
+		r.Get("/readyz", health.Ready(log, readiness))
+		// Отчёт содержит ошибки баз и пингует их, поэтому он только для администраторов
+		r.Get("/health/detail", auth.AdminOnly(health.Detail(log, appStorage, cfg.HTTPServer.HealthDetailTimeout)))
+		r.Get("/metrics", metrics.New(log, metricsRegistry))
+		r.Get("/robots.txt", robots.New(cfg.RobotsTxt))
+		r.Get("/openapi.json", apiSpec)
+		r.With(requireJSON).Post("/register", register.New(log, appStorage))
+		r.With(requireJSON).Post("/login", login.New(log, appStorage, login.Options{
+			Events:             appStorage,
+			KeepEvents:         cfg.MaxLoginEvents,
+			CanonicalNicknames: cfg.NicknameHashing.Enabled,
+		}))
+		r.With(requireJSON, writeGuard, idempotent).Post("/url/save", save.New(log, appStorage, saveOptions))
+		r.With(requireJSON).Post("/url/resolve", resolve.New(log, appStorage))
+		r.Get("/url/tags", listTags.New(log, appStorage, cfg.MaxTagFacets))
+		r.With(requireJSON, writeGuard).Post("/url/tags", updateTags.New(log, appStorage, cfg.MaxTagsPerURL))
+		r.Get("/url/count", count.New(log, appStorage))
+		r.Get("/url/stats", stats.New(log, appStorage))
+		r.Get("/url/lookup", lookup.New(log, appStorage, lookup.Options{
+			BaseURL:       cfg.BaseURL,
+			Normalization: saveOptions.Normalization,
+		}))
+		r.Get("/url/analytics/export", exportAnalytics.New(log, appStorage))
+		r.Get("/url/broken", broken.New(log, appStorage))
+		r.Get("/url/stale", stale.New(log, appStorage))
+		r.Get("/url/suggest", suggest.New(log, appStorage, aliasBlacklist, aliasLimit))
+		r.Get("/url/qr-batch", qrbatch.New(log, appStorage, cfg.BaseURL))
+		r.With(requireJSON, writeGuard).Post("/url/{alias}/extend", extend.New(log, appStorage, ttlLimit))
+		r.With(writeGuard).Post("/url/{alias}/regenerate", regenerate.New(log, appStorage, regenerateOptions))
+		r.With(writeGuard).Post("/url/{alias}/link", link.New(log, appStorage, linkOptions))
+		r.With(requireJSON, writeGuard).Patch("/url/{alias}/visibility", visibility.New(log, appStorage))
+		r.Get("/url/{alias}/card", card.New(log, appStorage, titleFetcher, cardOptions))
+		r.Get("/url/{alias}/check", check.New(log, appStorage, linkChecker))
+		r.With(previewRefreshRate, writeGuard).Post("/url/{alias}/refresh-preview", refreshPreview.New(log, appStorage, titleFetcher, refreshPreview.Options{
+			Timeout: cfg.Preview.Timeout,
+		}))
+		r.Get("/url/{alias}/timeseries", timeseries.New(log, appStorage))
+		r.Get("/url/trash", trash.New(log, appStorage, cfg.TrashRestoreWindow))
+		r.With(writeGuard).Delete("/url/trash", purge.New(log, appStorage))
+		r.With(writeGuard).Post("/url/{alias}/restore", restore.New(log, appStorage, cfg.TrashRestoreWindow))
+		r.With(requireJSON, writeGuard).Patch("/url/{alias}", updateURL.New(log, appStorage, cfg.MaxDescriptionLength))
+		r.With(writeGuard).Delete("/url/{alias}", deleteURL.New(log, appStorage))
+		r.Get("/user/settings", getSettings.New(log, appStorage))
+		r.With(requireJSON).Patch("/user/settings", updateSettings.New(log, appStorage))
+		r.Get("/user/sessions", listSessions.New(log, auth.Sessions))
+		r.Get("/user/logins", logins.New(log, appStorage))
+		r.Delete("/user/sessions/{id}", revokeSession.New(log, auth.Sessions))
+		if cfg.AliasPrefixes.Enabled {
+			r.With(requireJSON, writeGuard).Post("/user/prefixes", claimPrefix.New(log, appStorage, claimPrefix.Options{
+				MinLength:  cfg.AliasPrefixes.MinLength,
+				MaxPerUser: cfg.AliasPrefixes.MaxPerUser,
+			}))
+		}
+		r.Get("/user/export", export.New(log, appStorage))
+		r.With(writeGuard).Delete("/user/{nickname}", deleteUser.New(log, appStorage))
+		r.With(requireJSON, writeGuard).Post("/user/{nickname}/transfer-urls", transferURLs.New(log, appStorage))
 	})
-	router.Get("/redirect/{alias}", auth.TokenAuthMiddleware(redirect.New(log, multiStorage)))
+	router.Get("/admin/status", auth.AdminOnly(adminStatus.New(log, appStorage)))
+	router.Get("/admin/url/{alias}/owner", auth.AdminOnly(adminOwner.New(log, appStorage)))
+	router.With(requireJSON).Post("/admin/token/inspect", auth.AdminOnly(adminToken.New(log)))
+	router.With(normalizeAlias, checkAlias, aliasRate).Get("/redirect/{alias}", redirect.New(log, appStorage, cfg.RedirectErrorFormat))
+	// Публичные ссылки открываются без авторизации (правило /r/* в routepolicy.Defaults)
+	publicOptions := public.Options{
+		ErrorFormat:  cfg.RedirectErrorFormat,
+		CacheControl: *cfg.RedirectCacheControl,
+	}
+	if cfg.BotFilter.Enabled {
+		publicOptions.Bots = botfilter.Default()
+		if len(cfg.BotFilter.Patterns) > 0 {
+			publicOptions.Bots = botfilter.New(cfg.BotFilter.Patterns...)
+		}
+	}
+	for _, p := range cfg.ForwardParams {
+		publicOptions.Forward = append(publicOptions.Forward, forward.Rule{
+			Query:    p.Query,
+			Header:   p.Header,
+			Param:    p.Param,
+			Override: p.Override,
+		})
+	}
+	if err := publicOptions.Forward.Validate(); err != nil {
+		log.Error("invalid forward_params", sl.Err(err))
+		os.Exit(1)
+	}
+	router.With(normalizeAlias, checkAlias, aliasRate).Get("/r/{alias}", public.New(log, appStorage, publicOptions))
+	router.With(normalizeAlias, checkAlias, aliasRate).Get("/r/{alias}/*", public.New(log, appStorage, publicOptions))
+	// Вне /r/, чтобы не отнимать подпуть target у wildcard-ссылок
+	router.With(normalizeAlias, checkAlias, aliasRate).Get("/target/{alias}", target.New(log, appStorage))
 
 	log.Info("starting server", slog.String("address", cfg.Address))
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
-	srv := &http.Server{
-		Addr:         cfg.Address,
-		Handler:      router,
-		ReadTimeout:  cfg.HTTPServer.Timeout,
-		WriteTimeout: cfg.HTTPServer.Timeout,
-		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
-	}
+	srv := cfg.HTTPServer.NewServer(router)
 
-	go func() {
-		if err := srv.ListenAndServe(); err != nil {
-			log.Error("failed to start server")
-		}
-	}()
+	serveErr, err := startServer(srv)
+	if err != nil {
+		log.Error("failed to start server", slog.String("address", srv.Addr), sl.Err(err))
+		os.Exit(1)
+	}
 
 	log.Info("server started")
 
-	<-done
+	select {
+	case <-done:
+	case err := <-serveErr:
+		log.Error("server stopped unexpectedly", sl.Err(err))
+		os.Exit(1)
+	}
 	log.Info("stopping server")
 
+	// Сначала перестаём принимать новый трафик, затем дожидаемся текущих запросов
+	readiness.SetDraining()
+	if cfg.HTTPServer.DrainDelay > 0 {
+		log.Info("draining", slog.Duration("delay", cfg.HTTPServer.DrainDelay))
+		time.Sleep(cfg.HTTPServer.DrainDelay)
+	}
+
 	// TODO: move timeout to config
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -115,17 +638,81 @@ func main() {
 	log.Info("server stopped")
 }
 
+// migrate применяет схему баз режима cfg.StorageMode: таблицы и колонки SQLite,
+// индексы MongoDB. Сервер не запускается. В отличие от обычного старта,
+// ошибка создания индексов MongoDB здесь фатальна.
+func migrate(ctx context.Context, log *slog.Logger, cfg *config.Config) error {
+	if cfg.StorageMode != storage.ModeMongo {
+		sqliteDB, err := sqlite.New(cfg.StoragePath)
+		if err != nil {
+			return fmt.Errorf("init SQLite: %w", err)
+		}
+		defer sqliteDB.Close()
+
+		if err := sqliteDB.UseCaseInsensitiveAliases(cfg.CaseInsensitiveAliases); err != nil {
+			return fmt.Errorf("configure alias case mode in SQLite: %w", err)
+		}
+
+		version, err := sqliteDB.Version()
+		if err != nil {
+			return fmt.Errorf("read SQLite schema version: %w", err)
+		}
+		log.Info("SQLite schema is up to date", slog.String("path", cfg.StoragePath), slog.Int("schema_version", version))
+	}
+
+	if cfg.StorageMode != storage.ModeSQLite {
+		mongoDB, err := mongodb.NewClient(ctx, cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.Database, cfg.AuthDB, cfg.URI)
+		if err != nil {
+			return fmt.Errorf("init MongoDB: %w", err)
+		}
+
+		if err := mongoDB.UseCaseInsensitiveAliases(ctx, cfg.CaseInsensitiveAliases); err != nil {
+			return fmt.Errorf("configure alias case mode in MongoDB: %w", err)
+		}
+		if err := mongoDB.EnsureIndexes(ctx); err != nil {
+			return fmt.Errorf("create MongoDB indexes: %w", err)
+		}
+		log.Info("MongoDB indexes are up to date", slog.String("database", cfg.Database))
+	}
+
+	return nil
+}
+
+// startServer занимает адрес сервера и обслуживает запросы в фоне. Ошибка привязки
+// (порт занят, нет прав) возвращается сразу. Ошибка, прервавшая обслуживание позже,
+// приходит в канал; штатная остановка через Shutdown ошибкой не считается.
+func startServer(srv *http.Server) (<-chan error, error) {
+	addr := srv.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+
+	return serveErr, nil
+}
+
 func setupLogger(env string) *slog.Logger {
 	var log *slog.Logger
 
 	switch env {
-	case envLocal:
+	case config.EnvLocal:
 		log = setupPrettySlog()
-	case envDev:
+	case config.EnvDev:
 		log = slog.New(
 			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
 		)
-	case envProd:
+	case config.EnvProd:
 		log = slog.New(
 			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
 		)
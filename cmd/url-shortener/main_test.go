@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/sqlite"
+)
+
+func TestStartServer_AddressInUse(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+
+	// Порт уже занят - ошибка возвращается сразу, а не теряется в горутине
+	serveErr, err := startServer(&http.Server{Addr: busy.Addr().String()})
+	require.Error(t, err)
+	require.Nil(t, serveErr)
+}
+
+func TestStartServer_Shutdown(t *testing.T) {
+	srv := &http.Server{
+		Addr: "127.0.0.1:0",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	}
+
+	serveErr, err := startServer(srv)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, srv.Shutdown(ctx))
+
+	// Штатная остановка не считается ошибкой
+	select {
+	case err := <-serveErr:
+		t.Fatalf("unexpected serve error: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMigrate_SQLiteOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	// Адрес сервера свободен: migrate не должен его занимать
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	cfg := &config.Config{StoragePath: path, StorageMode: storage.ModeSQLite}
+	cfg.HTTPServer.Address = addr
+
+	require.NoError(t, migrate(context.Background(), slogdiscard.NewDiscardLogger(), cfg))
+
+	// Версия схемы поднялась с нуля у новой базы
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var version int
+	require.NoError(t, db.QueryRow("PRAGMA user_version").Scan(&version))
+	require.Equal(t, sqlite.SchemaVersion, version)
+
+	var tables int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'urls'").Scan(&tables))
+	require.Equal(t, 1, tables)
+
+	// Сервер не запущен
+	ln, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	require.NoError(t, ln.Close())
+}
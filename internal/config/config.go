@@ -0,0 +1,625 @@
+// Package config загружает настройки сервиса.
+//
+// Каждое поле можно задать переменной окружения с префиксом URL_SHORTENER_: имя поля верхнего
+// уровня - URL_SHORTENER_ и ключ yaml в верхнем регистре (URL_SHORTENER_JWT_SECRET), поля секции -
+// с именем секции (URL_SHORTENER_HTTP_SERVER_ADDRESS, URL_SHORTENER_ALIAS_GENERATION_LENGTH).
+// Списки перечисляются через запятую, forward_params задаётся JSON-массивом. Прежние имена без
+// префикса (JWT_SECRET, MONGODB_URI, ALIAS_SALT и др.) по-прежнему читаются, если нет нового.
+//
+// Приоритет: переменная окружения > файл CONFIG_PATH > значение по умолчанию. Файл необязателен:
+// без CONFIG_PATH конфиг собирается только из окружения. Значение по умолчанию подставляется,
+// если поле не задано в окружении, а в файле не задано или равно нулевому значению.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+// Окружения, для которых отличаются значения по умолчанию
+const (
+	EnvLocal = "local"
+	EnvDev   = "dev"
+	EnvProd  = "prod"
+)
+
+type Config struct {
+	Env         string `yaml:"env" env:"URL_SHORTENER_ENV" env-default:"local"`
+	StoragePath string `yaml:"storage_path" env:"URL_SHORTENER_STORAGE_PATH" env-required:"true"`
+	JWTSecret   string `yaml:"jwt_secret" env:"URL_SHORTENER_JWT_SECRET,JWT_SECRET" env-required:"true"`
+	BaseURL     string `yaml:"base_url" env:"URL_SHORTENER_BASE_URL" env-default:"http://localhost:8080"`
+	// JWTKeyID - kid ключа jwt_secret в заголовке новых токенов. Нужен для ротации: после смены
+	// jwt_secret прежний ключ переносится в jwt_previous_keys под своим kid.
+	JWTKeyID string `yaml:"jwt_key_id" env:"URL_SHORTENER_JWT_KEY_ID"`
+	// JWTPreviousKeys - прежние ключи подписи, которыми только проверяются ещё не истёкшие токены
+	JWTPreviousKeys JWTKeys `yaml:"jwt_previous_keys" env:"URL_SHORTENER_JWT_PREVIOUS_KEYS"`
+	// JWTTTL - срок действия новых токенов
+	JWTTTL time.Duration `yaml:"jwt_ttl" env:"URL_SHORTENER_JWT_TTL" env-default:"5m"`
+	// JWTMaxLifetime - жёсткий предел срока действия токена: jwt_ttl урезается до него, а токены
+	// с более долгим сроком не принимаются, даже если подписаны верно. 0 - без предела.
+	JWTMaxLifetime time.Duration `yaml:"jwt_max_lifetime" env:"URL_SHORTENER_JWT_MAX_LIFETIME" env-default:"24h"`
+	// PasswordPepper - секрет, которым пароль подписывается (HMAC) перед хэшированием; пусто - не используется.
+	// Смена значения делает недействительными все сохранённые пароли.
+	PasswordPepper string `yaml:"password_pepper" env:"URL_SHORTENER_PASSWORD_PEPPER,PASSWORD_PEPPER"`
+	// StorageMode - используемые базы: dual (SQLite + MongoDB), sqlite или mongo
+	StorageMode string `yaml:"storage_mode" env:"URL_SHORTENER_STORAGE_MODE,STORAGE_MODE" env-default:"dual"`
+	// PrimaryStore - основная база режима dual: sqlite или mongo. В неё запись идёт первой,
+	// из неё первым идёт чтение, она выдаёт id пользователей; при сбое второй базы запись в основной откатывается.
+	PrimaryStore string `yaml:"primary_store" env:"URL_SHORTENER_PRIMARY_STORE,PRIMARY_STORE" env-default:"sqlite"`
+	// AmbiguousNotFound - ответ чтения в режиме dual, когда одна база дала сбой, а другая запись не нашла:
+	// unavailable - 503, запрос стоит повторить; not_found - 404, как если бы записи не было.
+	AmbiguousNotFound string `yaml:"ambiguous_not_found" env:"URL_SHORTENER_AMBIGUOUS_NOT_FOUND" env-default:"unavailable"`
+	// DefaultURLTTL - срок жизни ссылки, если он не указан при сохранении. 0 - бессрочно.
+	DefaultURLTTL time.Duration `yaml:"default_url_ttl" env:"URL_SHORTENER_DEFAULT_URL_TTL" env-default:"0s"`
+	// MinURLTTL и MaxURLTTL - допустимый срок жизни ссылки при сохранении и продлении. 0 - без ограничения.
+	MinURLTTL time.Duration `yaml:"min_url_ttl" env:"URL_SHORTENER_MIN_URL_TTL" env-default:"0s"`
+	MaxURLTTL time.Duration `yaml:"max_url_ttl" env:"URL_SHORTENER_MAX_URL_TTL" env-default:"8760h"`
+	// AllowNeverExpire - разрешены бессрочные ссылки. Если запрещены, а default_url_ttl = 0,
+	// срок придётся указывать в каждом запросе. Не задан - разрешены.
+	AllowNeverExpire *bool `yaml:"allow_never_expire"`
+	// RedirectCacheControl - Cache-Control публичных редиректов. Не задан - по умолчанию для env
+	// (в prod ссылки не кэшируются без перепроверки, в local/dev заголовок не выставляется).
+	RedirectCacheControl *string `yaml:"redirect_cache_control"`
+	// RobotsTxt - содержимое /robots.txt. Пусто - запрет обхода /r/ и /redirect/.
+	RobotsTxt string `yaml:"robots_txt" env:"URL_SHORTENER_ROBOTS_TXT"`
+	// RequestLogging - текстовый лог каждого запроса (chi middleware.Logger) в дополнение к структурному.
+	// Не задан - по умолчанию для env (выключен в prod).
+	RequestLogging *bool `yaml:"request_logging"`
+	// RedirectErrorFormat - формат ошибок при переходе по ссылке, если клиент не прислал Accept: json или html
+	RedirectErrorFormat string `yaml:"redirect_error_format" env:"URL_SHORTENER_REDIRECT_ERROR_FORMAT" env-default:"json"`
+	// ResponseStyle - вид JSON-ответов API: mixed - как раньше, у части ответов есть поле status;
+	// envelope - всегда {status, data, error, code}; bare - только данные или error и code.
+	ResponseStyle string `yaml:"response_style" env:"URL_SHORTENER_RESPONSE_STYLE" env-default:"mixed"`
+	// URLFormat - chi middleware.URLFormat: расширение последнего сегмента пути отрезается при выборе маршрута.
+	// Формат никто не читает, а alias с точкой он обрезает (/redirect/v1.2 открывает v1), поэтому
+	// по умолчанию выключен. Если включён, свои alias с точкой отклоняются при сохранении.
+	URLFormat bool `yaml:"url_format" env:"URL_SHORTENER_URL_FORMAT" env-default:"false"`
+	// StripSlashes - завершающий "/" пути не мешает выбору маршрута: /redirect/abc/ открывает abc
+	StripSlashes bool `yaml:"strip_slashes" env:"URL_SHORTENER_STRIP_SLASHES" env-default:"false"`
+	// CaseInsensitiveAliases - alias сохраняют регистр, но уникальны и открываются без его учёта
+	CaseInsensitiveAliases bool `yaml:"case_insensitive_aliases" env:"URL_SHORTENER_CASE_INSENSITIVE_ALIASES" env-default:"false"`
+	// MaxAliasLength - общий предел длины любого alias вместе с контрольным символом: случайного,
+	// из хэша, выросшего при коллизиях и своего. Свой alias длиннее отклоняется. 0 - без предела.
+	MaxAliasLength int `yaml:"max_alias_length" env:"URL_SHORTENER_MAX_ALIAS_LENGTH" env-default:"32"`
+	// AllowDuplicateURLs - можно ли сократить адрес, который у пользователя уже есть. Если нельзя,
+	// сохранение без alias возвращает существующую ссылку, а со своим alias - 409.
+	// Пользователь может переопределить значение в /user/settings.
+	// Не задан - разрешено.
+	AllowDuplicateURLs *bool `yaml:"allow_duplicate_urls"`
+	// MaxTagsPerURL - максимум меток у одной ссылки; 0 - без ограничения
+	MaxTagsPerURL int `yaml:"max_tags_per_url" env:"URL_SHORTENER_MAX_TAGS_PER_URL" env-default:"10"`
+	// MaxDescriptionLength - максимум символов в описании ссылки; 0 - без ограничения
+	MaxDescriptionLength int `yaml:"max_description_length" env:"URL_SHORTENER_MAX_DESCRIPTION_LENGTH" env-default:"500"`
+	// MaxTagFacets - сколько самых частых меток отдаёт GET /url/tags; 0 - все
+	MaxTagFacets int `yaml:"max_tag_facets" env:"URL_SHORTENER_MAX_TAG_FACETS" env-default:"100"`
+	// MaxLoginEvents - сколько последних попыток входа хранится у пользователя (GET /user/logins); 0 - все
+	MaxLoginEvents int `yaml:"max_login_events" env:"URL_SHORTENER_MAX_LOGIN_EVENTS" env-default:"50"`
+	// MaxSessionsPerUser - максимум одновременно действующих токенов пользователя; при превышении
+	// отзывается самый старый. 0 - без ограничения.
+	MaxSessionsPerUser int `yaml:"max_sessions_per_user" env:"URL_SHORTENER_MAX_SESSIONS_PER_USER" env-default:"5"`
+	// TrashRestoreWindow - сколько удалённую ссылку можно восстановить (POST /url/{alias}/restore);
+	// после этого она не показывается в /url/trash и удаляется окончательно
+	TrashRestoreWindow time.Duration `yaml:"trash_restore_window" env:"URL_SHORTENER_TRASH_RESTORE_WINDOW" env-default:"720h"`
+	// TrashPurgeInterval - как часто из корзины удаляются ссылки с истёкшим TrashRestoreWindow
+	TrashPurgeInterval time.Duration `yaml:"trash_purge_interval" env:"URL_SHORTENER_TRASH_PURGE_INTERVAL" env-default:"1h"`
+	// AuthSchemes - допустимые схемы заголовка Authorization (Bearer, token), без учёта регистра
+	AuthSchemes []string `yaml:"auth_schemes" env:"URL_SHORTENER_AUTH_SCHEMES,AUTH_SCHEMES" env-default:"Bearer"`
+	// AllowRawToken - принимать заголовок Authorization с токеном без схемы
+	AllowRawToken bool `yaml:"allow_raw_token" env:"URL_SHORTENER_ALLOW_RAW_TOKEN" env-default:"false"`
+	// HideForeignLinks - отвечать на чужой alias так же, как на несуществующий (404 вместо 403),
+	// чтобы по ответам нельзя было узнать, какие alias заняты. Админские запросы не затрагивает.
+	HideForeignLinks bool `yaml:"hide_foreign_links" env:"URL_SHORTENER_HIDE_FOREIGN_LINKS" env-default:"false"`
+	// Admins - никнеймы пользователей с доступом к /admin
+	Admins           []string `yaml:"admins" env:"URL_SHORTENER_ADMINS,ADMINS"`
+	HTTPServer       `yaml:"http_server"`
+	MongoDB          `yaml:"mongodb"`
+	ReplayProtection `yaml:"replay_protection"`
+	AliasGeneration  `yaml:"alias_generation"`
+	SQLiteRetry      `yaml:"sqlite_retry"`
+	CORS             `yaml:"cors"`
+	NicknameHashing  `yaml:"nickname_hashing"`
+	LinkCheck        `yaml:"link_check"`
+	RateLimit        `yaml:"rate_limit"`
+	LinkHealth       `yaml:"link_health"`
+	Preview          `yaml:"preview"`
+	AliasPrefixes    `yaml:"alias_prefixes"`
+	Idempotency      `yaml:"idempotency"`
+	BodyLogging      `yaml:"body_logging"`
+	BotFilter        `yaml:"bot_filter"`
+	URLNormalization `yaml:"url_normalization"`
+	// ForwardParams - значения запроса, которые добавляются к адресу публичного редиректа
+	// (партнёрские метки, utm_*). Остальные параметры запроса к цели не передаются.
+	ForwardParams ForwardParams `yaml:"forward_params" env:"URL_SHORTENER_FORWARD_PARAMS"`
+	// RoutePolicy - авторизация и CORS отдельных маршрутов поверх встроенных исключений
+	// (служебные маршруты, вход, публичные редиректы). Маршрут без правила требует токен.
+	RoutePolicy RoutePolicy `yaml:"route_policy" env:"URL_SHORTENER_ROUTE_POLICY"`
+}
+
+type HTTPServer struct {
+	Address     string        `yaml:"address" env:"URL_SHORTENER_HTTP_SERVER_ADDRESS" env-default:"localhost:8080"`
+	Timeout     time.Duration `yaml:"timeout" env:"URL_SHORTENER_HTTP_SERVER_TIMEOUT" env-default:"4s"`
+	IdleTimeout time.Duration `yaml:"idle_timeout" env:"URL_SHORTENER_HTTP_SERVER_IDLE_TIMEOUT" env-default:"60s"`
+	// TrustedProxies - CIDR/IP прокси, которым разрешено передавать X-Forwarded-For / X-Real-IP
+	TrustedProxies []string `yaml:"trusted_proxies" env:"URL_SHORTENER_HTTP_SERVER_TRUSTED_PROXIES,HTTP_SERVER_TRUSTED_PROXIES"`
+	// MaxInFlight - максимум одновременно обрабатываемых запросов; 0 - без ограничения
+	MaxInFlight int `yaml:"max_in_flight" env:"URL_SHORTENER_HTTP_SERVER_MAX_IN_FLIGHT" env-default:"0"`
+	// QueueTimeout - сколько запрос ждёт свободного слота; 0 - сразу 503
+	QueueTimeout time.Duration `yaml:"queue_timeout" env:"URL_SHORTENER_HTTP_SERVER_QUEUE_TIMEOUT" env-default:"0s"`
+	// RetryAfter - подсказка клиенту в заголовке Retry-After при перегрузке
+	RetryAfter time.Duration `yaml:"retry_after" env:"URL_SHORTENER_HTTP_SERVER_RETRY_AFTER" env-default:"1s"`
+	// DrainDelay - пауза между переводом /readyz в 503 и остановкой сервера,
+	// за которую балансировщик успевает убрать экземпляр из ротации
+	DrainDelay time.Duration `yaml:"drain_delay" env:"URL_SHORTENER_HTTP_SERVER_DRAIN_DELAY" env-default:"0s"`
+	// HealthDetailTimeout - предел проверки баз в /health/detail; не ответившая за это время база отмечается timed_out
+	HealthDetailTimeout time.Duration `yaml:"health_detail_timeout" env:"URL_SHORTENER_HTTP_SERVER_HEALTH_DETAIL_TIMEOUT" env-default:"1s"`
+	// MaxHeaderBytes - предел размера заголовков запроса; при превышении сервер отвечает 431
+	MaxHeaderBytes int `yaml:"max_header_bytes" env:"URL_SHORTENER_HTTP_SERVER_MAX_HEADER_BYTES" env-default:"65536"`
+}
+
+// NewServer собирает http.Server с таймаутами и лимитами из конфига.
+// Expect: 100-continue net/http обрабатывает сам: 100 Continue отправляется, только когда
+// обработчик начинает читать тело, поэтому запрос, отклонённый до этого (401, 415, 503),
+// не заставляет клиента передавать тело. Другие значения Expect получают 417.
+func (s HTTPServer) NewServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:           s.Address,
+		Handler:        handler,
+		ReadTimeout:    s.Timeout,
+		WriteTimeout:   s.Timeout,
+		IdleTimeout:    s.IdleTimeout,
+		MaxHeaderBytes: s.MaxHeaderBytes,
+	}
+}
+
+type MongoDB struct {
+	Host     string `yaml:"host" env:"URL_SHORTENER_MONGODB_HOST" env-default:"localhost"`
+	Port     string `yaml:"port" env:"URL_SHORTENER_MONGODB_PORT" env-default:"27017"`
+	Username string `yaml:"username" env:"URL_SHORTENER_MONGODB_USERNAME"`
+	Password string `yaml:"password" env:"URL_SHORTENER_MONGODB_PASSWORD,MONGODB_PASSWORD"`
+	Database string `yaml:"database" env:"URL_SHORTENER_MONGODB_DATABASE" env-default:"url-shortener"`
+	AuthDB   string `yaml:"auth_db" env:"URL_SHORTENER_MONGODB_AUTH_DB"`
+	URI      string `yaml:"uri" env:"URL_SHORTENER_MONGODB_URI,MONGODB_URI"`
+	// HealthCheckInterval - период проверки соединения с MongoDB
+	HealthCheckInterval time.Duration `yaml:"health_check_interval" env:"URL_SHORTENER_MONGODB_HEALTH_CHECK_INTERVAL" env-default:"10s"`
+	// HealthCheckFailures - число неудачных проверок подряд до перехода в деградированный режим
+	HealthCheckFailures int `yaml:"health_check_failures" env:"URL_SHORTENER_MONGODB_HEALTH_CHECK_FAILURES" env-default:"3"`
+}
+
+// URLNormalization - правки адреса перед сохранением, включаются по отдельности.
+// По умолчанию адрес сохраняется как прислан.
+type URLNormalization struct {
+	// LowercaseHost - хост в нижнем регистре; регистр пути не меняется никогда
+	LowercaseHost bool `yaml:"lowercase_host" env:"URL_SHORTENER_URL_NORMALIZATION_LOWERCASE_HOST" env-default:"false"`
+	// StripWWW - убрать "www." в начале хоста
+	StripWWW bool `yaml:"strip_www" env:"URL_SHORTENER_URL_NORMALIZATION_STRIP_WWW" env-default:"false"`
+	// StripFragment - убрать #фрагмент
+	StripFragment bool `yaml:"strip_fragment" env:"URL_SHORTENER_URL_NORMALIZATION_STRIP_FRAGMENT" env-default:"false"`
+	// StripTrailingSlash - убрать завершающие "/" пути
+	StripTrailingSlash bool `yaml:"strip_trailing_slash" env:"URL_SHORTENER_URL_NORMALIZATION_STRIP_TRAILING_SLASH" env-default:"false"`
+}
+
+// ReplayProtection включает проверку подписанных nonce и timestamp на запросах, изменяющих данные
+type ReplayProtection struct {
+	Enabled bool `yaml:"enabled" env:"URL_SHORTENER_REPLAY_PROTECTION_ENABLED" env-default:"false"`
+	// Window - допустимое расхождение между временем клиента и сервера
+	Window time.Duration `yaml:"window" env:"URL_SHORTENER_REPLAY_PROTECTION_WINDOW" env-default:"5m"`
+	// Secret - ключ HMAC, которым клиент подписывает метод, путь, тело, nonce и timestamp запроса
+	Secret string `yaml:"secret" env:"URL_SHORTENER_REPLAY_PROTECTION_SECRET"`
+}
+
+// AliasGeneration - параметры генерации случайных alias
+type AliasGeneration struct {
+	Length int `yaml:"length" env:"URL_SHORTENER_ALIAS_GENERATION_LENGTH" env-default:"6"`
+	// MinLength - нижняя граница для персональной длины alias пользователя
+	MinLength int `yaml:"min_length" env:"URL_SHORTENER_ALIAS_GENERATION_MIN_LENGTH" env-default:"4"`
+	// MaxLength - предел, до которого длина растёт при частых коллизиях
+	MaxLength int `yaml:"max_length" env:"URL_SHORTENER_ALIAS_GENERATION_MAX_LENGTH" env-default:"10"`
+	// CollisionProbes - число коллизий подряд на одной длине до её увеличения
+	CollisionProbes int `yaml:"collision_probes" env:"URL_SHORTENER_ALIAS_GENERATION_COLLISION_PROBES" env-default:"3"`
+	// MinCustomLength - минимальная длина alias, который пользователь задаёт сам
+	MinCustomLength int `yaml:"min_custom_length" env:"URL_SHORTENER_ALIAS_GENERATION_MIN_CUSTOM_LENGTH" env-default:"3"`
+	// Checksum - добавлять к alias контрольный символ, чтобы отсекать опечатки до похода в базу.
+	// Ссылки, созданные до включения, перестанут открываться. С case_insensitive_aliases
+	// символ считается без учёта регистра, и смена одной из настроек ломает старые ссылки.
+	Checksum bool `yaml:"checksum" env:"URL_SHORTENER_ALIAS_GENERATION_CHECKSUM" env-default:"false"`
+	// Hash - случайный alias сначала вычисляется из адреса и Salt; при коллизии берётся случайный
+	Hash bool `yaml:"hash" env:"URL_SHORTENER_ALIAS_GENERATION_HASH" env-default:"false"`
+	// Salt - секрет для alias из хэша. Смена соли меняет только alias, созданные после неё.
+	Salt string `yaml:"salt" env:"URL_SHORTENER_ALIAS_GENERATION_SALT,ALIAS_SALT"`
+	// BlacklistPath - файл с дополнительными запрещёнными словами, по одному на строку
+	BlacklistPath string `yaml:"blacklist_path" env:"URL_SHORTENER_ALIAS_GENERATION_BLACKLIST_PATH,ALIAS_BLACKLIST_PATH"`
+	// GroupSize - сгенерированный alias разбивается дефисами на группы такой длины (ab3-f9k); 0 - не разбивается.
+	// Длина alias и max_alias_length считаются без дефисов.
+	GroupSize int `yaml:"group_size" env:"URL_SHORTENER_ALIAS_GENERATION_GROUP_SIZE" env-default:"0"`
+	// GroupSignificant - дефисы хранятся в alias и обязательны при переходе. Иначе alias хранится
+	// без них, группы только показываются в ответе, а переход открывается в обоих видах.
+	GroupSignificant bool `yaml:"group_significant" env:"URL_SHORTENER_ALIAS_GENERATION_GROUP_SIGNIFICANT" env-default:"false"`
+	// Sequence - alias ссылки без своего alias - base62 от id строки в SQLite: самый короткий
+	// и без коллизий. Длины и hash при этом используются только как запасной путь. Не работает в storage_mode mongo.
+	Sequence bool `yaml:"sequence" env:"URL_SHORTENER_ALIAS_GENERATION_SEQUENCE" env-default:"false"`
+}
+
+// SQLiteRetry - повтор записей в SQLite, получивших SQLITE_BUSY или SQLITE_LOCKED
+type SQLiteRetry struct {
+	// Attempts - число попыток, включая первую; 1 - без повторов
+	Attempts int `yaml:"attempts" env:"URL_SHORTENER_SQLITE_RETRY_ATTEMPTS" env-default:"3"`
+	// Backoff - пауза перед первым повтором, дальше растёт линейно
+	Backoff time.Duration `yaml:"backoff" env:"URL_SHORTENER_SQLITE_RETRY_BACKOFF" env-default:"20ms"`
+}
+
+// NicknameHashing - хранение никнеймов в виде хэша. Включать только на новой базе:
+// пользователи, сохранённые в другом режиме, перестают находиться.
+type NicknameHashing struct {
+	Enabled bool `yaml:"enabled" env:"URL_SHORTENER_NICKNAME_HASHING_ENABLED" env-default:"false"`
+	// Key - секрет HMAC для никнеймов
+	Key string `yaml:"key" env:"URL_SHORTENER_NICKNAME_HASHING_KEY,NICKNAME_HASH_KEY"`
+}
+
+// CORS - доступ к API из браузера с других origin. Пустой AllowedOrigins - CORS выключен.
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowed_origins" env:"URL_SHORTENER_CORS_ALLOWED_ORIGINS,CORS_ALLOWED_ORIGINS"`
+	// AllowCredentials - разрешить cookie и Authorization; несовместимо с origin "*"
+	AllowCredentials bool `yaml:"allow_credentials" env:"URL_SHORTENER_CORS_ALLOW_CREDENTIALS" env-default:"false"`
+	// MaxAge - сколько браузер кэширует ответ на preflight; 0 - не указывать
+	MaxAge time.Duration `yaml:"max_age" env:"URL_SHORTENER_CORS_MAX_AGE" env-default:"0s"`
+}
+
+// RateLimit - ограничение частоты запросов: сверх лимита клиент получает 429.
+// 0 запросов - соответствующее ограничение выключено.
+type RateLimit struct {
+	// PerIP - запросов с одного адреса за Window
+	PerIP int `yaml:"per_ip" env:"URL_SHORTENER_RATE_LIMIT_PER_IP" env-default:"0"`
+	// PerAlias - переходов по одной ссылке за Window
+	PerAlias int           `yaml:"per_alias" env:"URL_SHORTENER_RATE_LIMIT_PER_ALIAS" env-default:"0"`
+	Window   time.Duration `yaml:"window" env:"URL_SHORTENER_RATE_LIMIT_WINDOW" env-default:"1m"`
+}
+
+// LinkCheck - проверка доступности целевых URL (GET /url/{alias}/check)
+type LinkCheck struct {
+	// Timeout - предел на весь запрос к цели вместе с редиректами
+	Timeout      time.Duration `yaml:"timeout" env:"URL_SHORTENER_LINK_CHECK_TIMEOUT" env-default:"5s"`
+	MaxRedirects int           `yaml:"max_redirects" env:"URL_SHORTENER_LINK_CHECK_MAX_REDIRECTS" env-default:"3"`
+	// CacheTTL - сколько результат проверки переиспользуется; 0 - без кэша
+	CacheTTL time.Duration `yaml:"cache_ttl" env:"URL_SHORTENER_LINK_CHECK_CACHE_TTL" env-default:"1m"`
+	// AllowPrivateNetworks - разрешить запросы к loopback, частным и link-local адресам
+	// (169.254.169.254 и т.п.). По умолчанию запрещены на каждом переходе, чтобы через
+	// сохранённые ссылки нельзя было изучать внутреннюю сеть. Включать только для интранета.
+	AllowPrivateNetworks bool `yaml:"allow_private_networks" env:"URL_SHORTENER_LINK_CHECK_ALLOW_PRIVATE_NETWORKS" env-default:"false"`
+}
+
+// LinkHealth - фоновая проверка целей всех ссылок (GET /url/broken). Выключена по умолчанию.
+// Таймаут и число редиректов берутся из LinkCheck.
+type LinkHealth struct {
+	Enabled bool `yaml:"enabled" env:"URL_SHORTENER_LINK_HEALTH_ENABLED,LINK_HEALTH_ENABLED" env-default:"false"`
+	// Interval - пауза между пачками проверок
+	Interval time.Duration `yaml:"interval" env:"URL_SHORTENER_LINK_HEALTH_INTERVAL" env-default:"1m"`
+	// RecheckAfter - через сколько ссылка проверяется повторно
+	RecheckAfter time.Duration `yaml:"recheck_after" env:"URL_SHORTENER_LINK_HEALTH_RECHECK_AFTER" env-default:"24h"`
+	BatchSize    int           `yaml:"batch_size" env:"URL_SHORTENER_LINK_HEALTH_BATCH_SIZE" env-default:"50"`
+	// Concurrency - сколько целей запрашивается одновременно
+	Concurrency int `yaml:"concurrency" env:"URL_SHORTENER_LINK_HEALTH_CONCURRENCY" env-default:"2"`
+	// Delay - минимальная пауза между двумя запросами к целям
+	Delay time.Duration `yaml:"delay" env:"URL_SHORTENER_LINK_HEALTH_DELAY" env-default:"1s"`
+	// RobotsCacheTTL - сколько хранятся правила robots.txt сайта
+	RobotsCacheTTL time.Duration `yaml:"robots_cache_ttl" env:"URL_SHORTENER_LINK_HEALTH_ROBOTS_CACHE_TTL" env-default:"1h"`
+}
+
+// Preview - получение заголовка целевой страницы для карточки ссылки (GET /url/{alias}/card)
+// Число редиректов и доступ к частным адресам берутся из LinkCheck.
+type Preview struct {
+	Timeout time.Duration `yaml:"timeout" env:"URL_SHORTENER_PREVIEW_TIMEOUT" env-default:"2s"`
+	// MaxBytes - сколько байт страницы читается в поисках <title>
+	MaxBytes int64 `yaml:"max_bytes" env:"URL_SHORTENER_PREVIEW_MAX_BYTES" env-default:"262144"`
+	// RefreshLimit - сколько раз пользователь может обновить превью за RefreshWindow; 0 - без ограничения
+	RefreshLimit  int           `yaml:"refresh_limit" env:"URL_SHORTENER_PREVIEW_REFRESH_LIMIT" env-default:"10"`
+	RefreshWindow time.Duration `yaml:"refresh_window" env:"URL_SHORTENER_PREVIEW_REFRESH_WINDOW" env-default:"1m"`
+}
+
+// AliasPrefixes - префиксы alias, которые пользователь может закрепить за собой (POST /user/prefixes).
+// Alias под чужим префиксом не создаётся ни своим, ни случайным.
+type AliasPrefixes struct {
+	Enabled bool `yaml:"enabled" env:"URL_SHORTENER_ALIAS_PREFIXES_ENABLED" env-default:"false"`
+	// MinLength - минимальная длина префикса
+	MinLength int `yaml:"min_length" env:"URL_SHORTENER_ALIAS_PREFIXES_MIN_LENGTH" env-default:"3"`
+	// MaxPerUser - сколько префиксов может занять один пользователь; 0 - без ограничения
+	MaxPerUser int `yaml:"max_per_user" env:"URL_SHORTENER_ALIAS_PREFIXES_MAX_PER_USER" env-default:"1"`
+}
+
+// Idempotency - повтор POST /url/save с тем же заголовком Idempotency-Key возвращает
+// исходный ответ вместо новой ссылки
+type Idempotency struct {
+	// TTL - сколько хранится ответ по ключу; 0 - заголовок не учитывается
+	TTL time.Duration `yaml:"ttl" env:"URL_SHORTENER_IDEMPOTENCY_TTL" env-default:"24h"`
+	// MaxBodyBytes - предел тела запроса с ключом: оно читается целиком, чтобы сравнить повтор с оригиналом
+	MaxBodyBytes int64 `yaml:"max_body_bytes" env:"URL_SHORTENER_IDEMPOTENCY_MAX_BODY_BYTES" env-default:"1048576"`
+}
+
+// BodyLogging - запись тел JSON-запросов и ответов в лог для отладки интеграций.
+// Пишется на уровне debug, пароли и токены закрываются.
+type BodyLogging struct {
+	Enabled bool `yaml:"enabled" env:"URL_SHORTENER_BODY_LOGGING_ENABLED" env-default:"false"`
+	// MaxBytes - тело длиннее не логируется, только его размер
+	MaxBytes int `yaml:"max_bytes" env:"URL_SHORTENER_BODY_LOGGING_MAX_BYTES" env-default:"4096"`
+}
+
+// BotFilter - переходы по публичным ссылкам от роботов (поисковики, предпросмотр в мессенджерах)
+// не учитываются в статистике, редирект они получают как обычно. Выключен по умолчанию.
+type BotFilter struct {
+	Enabled bool `yaml:"enabled" env:"URL_SHORTENER_BOT_FILTER_ENABLED" env-default:"false"`
+	// Patterns - подстроки User-Agent роботов без учёта регистра; пусто - встроенный список
+	Patterns []string `yaml:"patterns" env:"URL_SHORTENER_BOT_FILTER_PATTERNS"`
+}
+
+// ForwardParam - правило переноса одного значения запроса в query адреса назначения
+type ForwardParam struct {
+	// Query и Header - откуда берётся значение: параметр запроса или заголовок; задаётся одно из них
+	Query  string `yaml:"query"`
+	Header string `yaml:"header"`
+	// Param - имя параметра у цели; пусто - как Query. Для заголовка обязателен.
+	Param string `yaml:"param"`
+	// Override - заменять параметр, уже заданный в адресе цели; по умолчанию он сохраняется
+	Override bool `yaml:"override"`
+}
+
+// ForwardParams - список правил forward_params. Из окружения читается JSON-массивом:
+// [{"query":"ref"},{"header":"X-Campaign","param":"campaign"}]
+type ForwardParams []ForwardParam
+
+// SetValue разбирает значение переменной окружения
+func (p *ForwardParams) SetValue(value string) error {
+	var params []ForwardParam
+	if err := json.Unmarshal([]byte(value), &params); err != nil {
+		return fmt.Errorf("forward_params must be a JSON array: %w", err)
+	}
+
+	*p = params
+
+	return nil
+}
+
+// JWTKey - прежний ключ подписи токенов
+type JWTKey struct {
+	// ID - kid, с которым ключ выпускал токены
+	ID     string `yaml:"id"`
+	Secret string `yaml:"secret"`
+	// Until - после этого момента ключ не принимается; не задан - пока ключ в списке
+	Until time.Time `yaml:"until"`
+}
+
+// JWTKeys - список jwt_previous_keys. Из окружения читается JSON-массивом:
+// [{"id":"2024-01","secret":"...","until":"2024-02-01T00:00:00Z"}]
+type JWTKeys []JWTKey
+
+// SetValue разбирает значение переменной окружения
+func (k *JWTKeys) SetValue(value string) error {
+	var keys []JWTKey
+	if err := json.Unmarshal([]byte(value), &keys); err != nil {
+		return fmt.Errorf("jwt_previous_keys must be a JSON array: %w", err)
+	}
+
+	*k = keys
+
+	return nil
+}
+
+// RouteRule - политика маршрута или группы маршрутов
+type RouteRule struct {
+	// Pattern - шаблон маршрута chi ("/url/{alias}/check") или префикс со звёздочкой ("/r/*")
+	Pattern string `yaml:"pattern"`
+	// Auth - required или none; пусто - required
+	Auth string `yaml:"auth"`
+	// CORS - default (настройки cors), any (любой origin без credentials) или none; пусто - default
+	CORS string `yaml:"cors"`
+}
+
+// RoutePolicy - список правил route_policy. Из окружения читается JSON-массивом:
+// [{"pattern":"/metrics","auth":"required"},{"pattern":"/r/*","auth":"none","cors":"any"}]
+type RoutePolicy []RouteRule
+
+// SetValue разбирает значение переменной окружения
+func (p *RoutePolicy) SetValue(value string) error {
+	var rules []RouteRule
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return fmt.Errorf("route_policy must be a JSON array: %w", err)
+	}
+
+	*p = rules
+
+	return nil
+}
+
+// Переменные окружения для полей-указателей: cleanenv их не разбирает
+const (
+	envRedirectCacheControl = "URL_SHORTENER_REDIRECT_CACHE_CONTROL"
+	envRequestLogging       = "URL_SHORTENER_REQUEST_LOGGING"
+	envAllowDuplicateURLs   = "URL_SHORTENER_ALLOW_DUPLICATE_URLS"
+	envAllowNeverExpire     = "URL_SHORTENER_ALLOW_NEVER_EXPIRE"
+)
+
+// MustLoad загружает конфиг из файла CONFIG_PATH, если он задан, и из окружения
+func MustLoad() *Config {
+	cfg, err := Load(os.Getenv("CONFIG_PATH"))
+	if err != nil {
+		log.Fatalf("cannot read config: %s", err)
+	}
+
+	return cfg
+}
+
+// Load читает конфиг из файла path и переменных окружения и проверяет результат.
+// Пустой path - только окружение.
+func Load(path string) (*Config, error) {
+	var cfg Config
+
+	if path != "" {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, fmt.Errorf("config file does not exist: %s", path)
+		}
+
+		if err := cleanenv.ReadConfig(path, &cfg); err != nil {
+			return nil, err
+		}
+	} else if err := cleanenv.ReadEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.readPointerEnv(); err != nil {
+		return nil, err
+	}
+
+	cfg.applyEnvDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// readPointerEnv читает из окружения поля, у которых «не задано» отличается от пустого значения
+func (c *Config) readPointerEnv() error {
+	if value, ok := os.LookupEnv(envRedirectCacheControl); ok {
+		c.RedirectCacheControl = &value
+	}
+
+	flags := []struct {
+		env   string
+		field **bool
+	}{
+		{envRequestLogging, &c.RequestLogging},
+		{envAllowDuplicateURLs, &c.AllowDuplicateURLs},
+		{envAllowNeverExpire, &c.AllowNeverExpire},
+	}
+	for _, flag := range flags {
+		value, ok := os.LookupEnv(flag.env)
+		if !ok {
+			continue
+		}
+
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", flag.env, err)
+		}
+		*flag.field = &parsed
+	}
+
+	return nil
+}
+
+// Validate проверяет согласованность итогового конфига после слияния файла и окружения
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.StorageMode {
+	case "dual", "sqlite", "mongo":
+	default:
+		errs = append(errs, fmt.Errorf("unknown storage_mode %q", c.StorageMode))
+	}
+	if c.PrimaryStore != "sqlite" && c.PrimaryStore != "mongo" {
+		errs = append(errs, fmt.Errorf("unknown primary_store %q", c.PrimaryStore))
+	}
+	if c.AmbiguousNotFound != "unavailable" && c.AmbiguousNotFound != "not_found" {
+		errs = append(errs, fmt.Errorf("unknown ambiguous_not_found %q", c.AmbiguousNotFound))
+	}
+	if c.ResponseStyle != "mixed" && c.ResponseStyle != "envelope" && c.ResponseStyle != "bare" {
+		errs = append(errs, fmt.Errorf("unknown response_style %q", c.ResponseStyle))
+	}
+	if c.RedirectErrorFormat != "json" && c.RedirectErrorFormat != "html" {
+		errs = append(errs, fmt.Errorf("unknown redirect_error_format %q", c.RedirectErrorFormat))
+	}
+	if c.HTTPServer.Address == "" {
+		errs = append(errs, errors.New("http_server.address is empty"))
+	}
+	if c.MinURLTTL > 0 && c.MaxURLTTL > 0 && c.MinURLTTL > c.MaxURLTTL {
+		errs = append(errs, fmt.Errorf("min_url_ttl %s exceeds max_url_ttl %s", c.MinURLTTL, c.MaxURLTTL))
+	}
+	if c.AliasGeneration.GroupSize < 0 {
+		errs = append(errs, errors.New("alias_generation.group_size must not be negative"))
+	}
+	if c.Idempotency.TTL > 0 && c.Idempotency.MaxBodyBytes <= 0 {
+		errs = append(errs, errors.New("idempotency.max_body_bytes must be positive"))
+	}
+	if c.ReplayProtection.Enabled && c.ReplayProtection.Secret == "" {
+		errs = append(errs, errors.New("replay_protection.secret is required when replay protection is enabled"))
+	}
+	if c.LinkHealth.Enabled && c.LinkHealth.Interval <= 0 {
+		errs = append(errs, errors.New("link_health.interval must be positive"))
+	}
+	if c.TrashRestoreWindow <= 0 {
+		errs = append(errs, errors.New("trash_restore_window must be positive"))
+	}
+	if c.TrashPurgeInterval <= 0 {
+		errs = append(errs, errors.New("trash_purge_interval must be positive"))
+	}
+	if c.JWTTTL <= 0 {
+		errs = append(errs, errors.New("jwt_ttl must be positive"))
+	}
+	if c.JWTMaxLifetime < 0 {
+		errs = append(errs, errors.New("jwt_max_lifetime must not be negative"))
+	}
+	seenKeys := map[string]bool{c.JWTKeyID: c.JWTKeyID != ""}
+	for _, key := range c.JWTPreviousKeys {
+		switch {
+		case key.ID == "" || key.Secret == "":
+			errs = append(errs, errors.New("jwt_previous_keys entries need id and secret"))
+		case seenKeys[key.ID]:
+			errs = append(errs, fmt.Errorf("jwt key id %q is used more than once", key.ID))
+		}
+		seenKeys[key.ID] = true
+	}
+	if c.AliasGeneration.Sequence && c.StorageMode == "mongo" {
+		errs = append(errs, errors.New("alias_generation.sequence requires SQLite, storage_mode mongo has none"))
+	}
+	// В base62 есть заглавные буквы: "aB" и "Ab" совпали бы в индексе без учёта регистра
+	if c.AliasGeneration.Sequence && c.CaseInsensitiveAliases {
+		errs = append(errs, errors.New("alias_generation.sequence cannot be combined with case_insensitive_aliases"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// envDefaults - значения, зависящие от окружения. Неизвестное окружение получает
+// настройки prod, как и логгер в main.
+type envDefaults struct {
+	redirectCacheControl string
+	requestLogging       bool
+}
+
+func defaultsFor(env string) envDefaults {
+	switch env {
+	case EnvLocal, EnvDev:
+		return envDefaults{redirectCacheControl: "", requestLogging: true}
+	default:
+		return envDefaults{redirectCacheControl: "private, no-cache", requestLogging: false}
+	}
+}
+
+// applyEnvDefaults заполняет незаданные в конфиге поля значениями для текущего окружения.
+// Явно заданные значения, в том числе пустые и false, не меняются.
+func (c *Config) applyEnvDefaults() {
+	defaults := defaultsFor(c.Env)
+
+	if c.RedirectCacheControl == nil {
+		c.RedirectCacheControl = &defaults.redirectCacheControl
+	}
+	if c.RequestLogging == nil {
+		c.RequestLogging = &defaults.requestLogging
+	}
+	// Повторы и бессрочные ссылки разрешены во всех окружениях
+	if c.AllowDuplicateURLs == nil {
+		allow := true
+		c.AllowDuplicateURLs = &allow
+	}
+	if c.AllowNeverExpire == nil {
+		allow := true
+		c.AllowNeverExpire = &allow
+	}
+}
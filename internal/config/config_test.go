@@ -0,0 +1,305 @@
+package config
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyEnvDefaults(t *testing.T) {
+	cases := []struct {
+		env          string
+		cacheControl string
+		logging      bool
+	}{
+		{env: EnvLocal, cacheControl: "", logging: true},
+		{env: EnvDev, cacheControl: "", logging: true},
+		{env: EnvProd, cacheControl: "private, no-cache", logging: false},
+		{env: "staging", cacheControl: "private, no-cache", logging: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.env, func(t *testing.T) {
+			cfg := Config{Env: tc.env}
+			cfg.applyEnvDefaults()
+
+			require.Equal(t, tc.cacheControl, *cfg.RedirectCacheControl)
+			require.Equal(t, tc.logging, *cfg.RequestLogging)
+		})
+	}
+}
+
+func TestApplyEnvDefaults_ExplicitOverride(t *testing.T) {
+	cacheControl := "public, max-age=60"
+	logging := true
+	cfg := Config{Env: EnvProd, RedirectCacheControl: &cacheControl, RequestLogging: &logging}
+
+	cfg.applyEnvDefaults()
+
+	require.Equal(t, "public, max-age=60", *cfg.RedirectCacheControl)
+	require.True(t, *cfg.RequestLogging)
+}
+
+func TestMustLoad_EnvDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+env: "prod"
+storage_path: "./storage.db"
+jwt_secret: "secret"
+redirect_cache_control: ""
+request_logging: true
+`), 0o600))
+	t.Setenv("CONFIG_PATH", path)
+
+	cfg := MustLoad()
+
+	// Явно заданные значения важнее значений prod, даже пустая строка
+	require.Equal(t, "", *cfg.RedirectCacheControl)
+	require.True(t, *cfg.RequestLogging)
+}
+
+func TestHTTPServer_NewServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+storage_path: "./storage.db"
+jwt_secret: "secret"
+http_server:
+  address: "localhost:9090"
+  max_header_bytes: 4096
+`), 0o600))
+	t.Setenv("CONFIG_PATH", path)
+
+	cfg := MustLoad()
+	handler := http.NewServeMux()
+
+	srv := cfg.HTTPServer.NewServer(handler)
+
+	require.Equal(t, 4096, srv.MaxHeaderBytes)
+	require.Equal(t, "localhost:9090", srv.Addr)
+	require.Equal(t, cfg.HTTPServer.Timeout, srv.ReadTimeout)
+	require.Equal(t, handler, srv.Handler)
+}
+
+func TestHTTPServer_MaxHeaderBytesDefault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+storage_path: "./storage.db"
+jwt_secret: "secret"
+`), 0o600))
+	t.Setenv("CONFIG_PATH", path)
+
+	cfg := MustLoad()
+
+	require.Equal(t, 65536, cfg.HTTPServer.NewServer(nil).MaxHeaderBytes)
+}
+
+func TestLoad_EnvOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+storage_path: "./storage.db"
+jwt_secret: "file-secret"
+base_url: "http://file.example"
+http_server:
+  address: "localhost:9090"
+  timeout: 10s
+alias_generation:
+  length: 8
+`), 0o600))
+
+	t.Setenv("URL_SHORTENER_BASE_URL", "https://env.example")
+	t.Setenv("URL_SHORTENER_HTTP_SERVER_ADDRESS", ":8000")
+	t.Setenv("URL_SHORTENER_ALIAS_GENERATION_LENGTH", "7")
+	t.Setenv("URL_SHORTENER_ADMINS", "root,ops")
+	// Прежнее имя без префикса по-прежнему читается
+	t.Setenv("JWT_SECRET", "legacy-secret")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	// Окружение важнее файла
+	require.Equal(t, "https://env.example", cfg.BaseURL)
+	require.Equal(t, ":8000", cfg.HTTPServer.Address)
+	require.Equal(t, 7, cfg.AliasGeneration.Length)
+	require.Equal(t, []string{"root", "ops"}, cfg.Admins)
+	require.Equal(t, "legacy-secret", cfg.JWTSecret)
+	// Файл важнее значения по умолчанию
+	require.Equal(t, 10*time.Second, cfg.HTTPServer.Timeout)
+	// Значение по умолчанию, если поле нигде не задано
+	require.Equal(t, 32, cfg.MaxAliasLength)
+
+	// Новое имя важнее прежнего
+	t.Setenv("URL_SHORTENER_JWT_SECRET", "env-secret")
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "env-secret", cfg.JWTSecret)
+}
+
+func TestLoad_EnvOnly(t *testing.T) {
+	t.Setenv("URL_SHORTENER_STORAGE_PATH", "./storage.db")
+	t.Setenv("URL_SHORTENER_JWT_SECRET", "secret")
+	t.Setenv("URL_SHORTENER_ENV", EnvProd)
+	t.Setenv("URL_SHORTENER_REQUEST_LOGGING", "true")
+	t.Setenv("URL_SHORTENER_FORWARD_PARAMS", `[{"query":"ref"},{"header":"X-Campaign","param":"campaign"}]`)
+	t.Setenv("URL_SHORTENER_ROUTE_POLICY", `[{"pattern":"/metrics","auth":"required"}]`)
+
+	cfg, err := Load("")
+	require.NoError(t, err)
+
+	require.Equal(t, "./storage.db", cfg.StoragePath)
+	require.Equal(t, "localhost:8080", cfg.HTTPServer.Address)
+	require.True(t, *cfg.RequestLogging)
+	// Не заданное поле получает значение для prod
+	require.Equal(t, "private, no-cache", *cfg.RedirectCacheControl)
+	require.Equal(t, ForwardParams{{Query: "ref"}, {Header: "X-Campaign", Param: "campaign"}}, cfg.ForwardParams)
+	require.Equal(t, RoutePolicy{{Pattern: "/metrics", Auth: "required"}}, cfg.RoutePolicy)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	t.Setenv("URL_SHORTENER_STORAGE_PATH", "./storage.db")
+	t.Setenv("URL_SHORTENER_JWT_SECRET", "secret")
+
+	t.Run("Missing required", func(t *testing.T) {
+		t.Setenv("URL_SHORTENER_JWT_SECRET", "")
+		os.Unsetenv("URL_SHORTENER_JWT_SECRET")
+
+		_, err := Load("")
+		require.Error(t, err)
+	})
+
+	t.Run("Unknown storage mode", func(t *testing.T) {
+		t.Setenv("URL_SHORTENER_STORAGE_MODE", "redis")
+
+		_, err := Load("")
+		require.ErrorContains(t, err, "storage_mode")
+	})
+
+	t.Run("Duplicate jwt key id", func(t *testing.T) {
+		t.Setenv("URL_SHORTENER_JWT_KEY_ID", "k2")
+		t.Setenv("URL_SHORTENER_JWT_PREVIOUS_KEYS", `[{"id":"k1","secret":"old"},{"id":"k2","secret":"older"}]`)
+
+		_, err := Load("")
+		require.ErrorContains(t, err, `jwt key id "k2"`)
+	})
+
+	t.Run("Non-positive link health interval", func(t *testing.T) {
+		t.Setenv("URL_SHORTENER_LINK_HEALTH_ENABLED", "true")
+		t.Setenv("URL_SHORTENER_LINK_HEALTH_INTERVAL", "0s")
+
+		_, err := Load("")
+		require.ErrorContains(t, err, "link_health.interval")
+	})
+
+	t.Run("Replay protection without secret", func(t *testing.T) {
+		t.Setenv("URL_SHORTENER_REPLAY_PROTECTION_ENABLED", "true")
+
+		_, err := Load("")
+		require.ErrorContains(t, err, "replay_protection.secret")
+	})
+
+	t.Run("Non-positive trash purge interval", func(t *testing.T) {
+		t.Setenv("URL_SHORTENER_TRASH_PURGE_INTERVAL", "0s")
+
+		_, err := Load("")
+		require.ErrorContains(t, err, "trash_purge_interval")
+	})
+
+	t.Run("Non-positive jwt ttl", func(t *testing.T) {
+		t.Setenv("URL_SHORTENER_JWT_TTL", "0s")
+
+		_, err := Load("")
+		require.ErrorContains(t, err, "jwt_ttl")
+	})
+
+	t.Run("Unknown ambiguous not found", func(t *testing.T) {
+		t.Setenv("URL_SHORTENER_AMBIGUOUS_NOT_FOUND", "retry")
+
+		_, err := Load("")
+		require.ErrorContains(t, err, "ambiguous_not_found")
+	})
+
+	t.Run("Unknown response style", func(t *testing.T) {
+		t.Setenv("URL_SHORTENER_RESPONSE_STYLE", "wrapped")
+
+		_, err := Load("")
+		require.ErrorContains(t, err, "response_style")
+	})
+
+	t.Run("TTL bounds", func(t *testing.T) {
+		t.Setenv("URL_SHORTENER_MIN_URL_TTL", "48h")
+		t.Setenv("URL_SHORTENER_MAX_URL_TTL", "24h")
+
+		_, err := Load("")
+		require.ErrorContains(t, err, "min_url_ttl")
+	})
+
+	t.Run("Sequence aliases without SQLite", func(t *testing.T) {
+		t.Setenv("URL_SHORTENER_STORAGE_MODE", "mongo")
+		t.Setenv("URL_SHORTENER_ALIAS_GENERATION_SEQUENCE", "true")
+
+		_, err := Load("")
+		require.ErrorContains(t, err, "alias_generation.sequence")
+	})
+
+	t.Run("Sequence aliases with case-insensitive aliases", func(t *testing.T) {
+		t.Setenv("URL_SHORTENER_ALIAS_GENERATION_SEQUENCE", "true")
+		t.Setenv("URL_SHORTENER_CASE_INSENSITIVE_ALIASES", "true")
+
+		_, err := Load("")
+		require.ErrorContains(t, err, "case_insensitive_aliases")
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		require.ErrorContains(t, err, "does not exist")
+	})
+}
+
+func TestLoad_AllowNeverExpire(t *testing.T) {
+	t.Setenv("URL_SHORTENER_STORAGE_PATH", "./storage.db")
+	t.Setenv("URL_SHORTENER_JWT_SECRET", "secret")
+
+	cfg, err := Load("")
+	require.NoError(t, err)
+	require.True(t, *cfg.AllowNeverExpire)
+
+	// false в файле не заменяется значением по умолчанию
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("allow_never_expire: false\n"), 0o600))
+
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	require.False(t, *cfg.AllowNeverExpire)
+
+	t.Setenv("URL_SHORTENER_ALLOW_NEVER_EXPIRE", "true")
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	require.True(t, *cfg.AllowNeverExpire)
+}
+
+func TestLoad_AllowDuplicateURLs(t *testing.T) {
+	t.Setenv("URL_SHORTENER_STORAGE_PATH", "./storage.db")
+	t.Setenv("URL_SHORTENER_JWT_SECRET", "secret")
+
+	cfg, err := Load("")
+	require.NoError(t, err)
+	require.True(t, *cfg.AllowDuplicateURLs)
+
+	// Явный false в файле не заменяется значением по умолчанию
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("allow_duplicate_urls: false\n"), 0o600))
+
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	require.False(t, *cfg.AllowDuplicateURLs)
+
+	t.Setenv("URL_SHORTENER_ALLOW_DUPLICATE_URLS", "true")
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	require.True(t, *cfg.AllowDuplicateURLs)
+}
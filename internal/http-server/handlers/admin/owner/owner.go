@@ -0,0 +1,87 @@
+package owner
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Alias string `json:"alias,omitempty"`
+	URL   string `json:"url,omitempty"`
+	Owner string `json:"owner,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=OwnerGetter
+type OwnerGetter interface {
+	GetLink(ctx context.Context, log *slog.Logger, alias string) (storage.URL, error)
+	GetURLOwner(ctx context.Context, log *slog.Logger, alias string) (string, error)
+}
+
+// New отдаёт владельца ссылки и адрес назначения для разбора жалоб:
+// GET /admin/url/{alias}/owner. Доступен только администраторам.
+func New(log *slog.Logger, ownerGetter OwnerGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.owner.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		link, err := ownerGetter.GetLink(r.Context(), log, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error("url not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get link", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get url"))
+			return
+		}
+
+		owner, err := ownerGetter.GetURLOwner(r.Context(), log, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url owner not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error("url not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url owner", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get url owner"))
+			return
+		}
+
+		log.Info("url owner requested", slog.String("alias", alias), slog.String("owner", owner))
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Alias:    link.Alias,
+			URL:      link.URL,
+			Owner:    owner,
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package owner_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/admin/owner"
+	"url-shortener/internal/http-server/handlers/admin/owner/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestOwnerHandler(t *testing.T) {
+	auth.Admins = []string{"admin"}
+	t.Cleanup(func() { auth.Admins = nil })
+
+	cases := []struct {
+		name     string
+		nickname string
+		alias    string
+		linkErr  error
+		respCode int
+	}{
+		{name: "Admin", nickname: "admin", alias: "abuse", respCode: http.StatusOK},
+		{name: "Unknown alias", nickname: "admin", alias: "missing", linkErr: storage.ErrURLNotFound, respCode: http.StatusNotFound},
+		{name: "Not admin", nickname: "user", alias: "abuse", respCode: http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ownerMock := mocks.NewOwnerGetter(t)
+			if tc.respCode != http.StatusForbidden {
+				ownerMock.On("GetLink", mock.Anything, mock.Anything, tc.alias).
+					Return(storage.URL{Alias: tc.alias, URL: "https://example.com/phishing"}, tc.linkErr).
+					Once()
+			}
+			if tc.respCode == http.StatusOK {
+				ownerMock.On("GetURLOwner", mock.Anything, mock.Anything, tc.alias).
+					Return("spammer", nil).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/admin/url/{alias}/owner", auth.AdminOnly(owner.New(slogdiscard.NewDiscardLogger(), ownerMock)))
+
+			req, err := http.NewRequest(http.MethodGet, "/admin/url/"+tc.alias+"/owner", nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), tc.nickname))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.respCode, rr.Code)
+			if tc.respCode != http.StatusOK {
+				require.NotContains(t, rr.Body.String(), "spammer")
+				return
+			}
+
+			var resp owner.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, "spammer", resp.Owner)
+			require.Equal(t, "https://example.com/phishing", resp.URL)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package status
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	storage.Status
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=StatusProvider
+type StatusProvider interface {
+	Status(ctx context.Context, log *slog.Logger) storage.Status
+}
+
+// New отдаёт настроенный и фактический режим хранилища, состояние каждой базы
+// и флаг деградированного режима. Доступен только администраторам.
+func New(log *slog.Logger, statusProvider StatusProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.status.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		status := statusProvider.Status(r.Context(), log)
+
+		log.Info("storage status collected",
+			slog.String("effective_mode", status.EffectiveMode),
+			slog.Bool("degraded", status.Degraded),
+		)
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Status:   status,
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package status_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/admin/status"
+	"url-shortener/internal/http-server/handlers/admin/status/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestStatusHandler(t *testing.T) {
+	auth.Admins = []string{"admin"}
+
+	degraded := storage.Status{
+		ConfiguredMode: storage.ModeDual,
+		EffectiveMode:  storage.ModeSQLite,
+		Degraded:       true,
+		Backends: map[string]storage.BackendStatus{
+			storage.ModeSQLite: {Up: true},
+			storage.ModeMongo:  {Up: false, Error: "connection refused", Breaker: "open"},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		nickname string
+		respCode int
+	}{
+		{
+			name:     "Admin",
+			nickname: "admin",
+			respCode: http.StatusOK,
+		},
+		{
+			name:     "Not admin",
+			nickname: "user",
+			respCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			statusMock := mocks.NewStatusProvider(t)
+			if tc.respCode == http.StatusOK {
+				statusMock.On("Status", mock.Anything, mock.Anything).
+					Return(degraded).
+					Once()
+			}
+
+			handler := auth.AdminOnly(status.New(slogdiscard.NewDiscardLogger(), statusMock))
+
+			req, err := http.NewRequest(http.MethodGet, "/admin/status", nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), tc.nickname))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.respCode, rr.Code)
+			if tc.respCode != http.StatusOK {
+				return
+			}
+
+			var resp status.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, storage.ModeDual, resp.ConfiguredMode)
+			require.Equal(t, storage.ModeSQLite, resp.EffectiveMode)
+			require.True(t, resp.Degraded)
+			require.False(t, resp.Backends[storage.ModeMongo].Up)
+			require.Equal(t, "open", resp.Backends[storage.ModeMongo].Breaker)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package token
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+type Request struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type Response struct {
+	resp.Response
+	// Valid - токен был бы принят при авторизации
+	Valid bool `json:"valid"`
+	// Signature - valid, invalid или unknown (токен не удалось разобрать)
+	Signature string       `json:"signature"`
+	Expired   bool         `json:"expired"`
+	Revoked   bool         `json:"revoked"`
+	Reason    string       `json:"reason,omitempty"`
+	Claims    *auth.Claims `json:"claims,omitempty"`
+}
+
+// New разбирает присланный токен для поддержки: POST /admin/token/inspect.
+// Отдаёт claims без сокрытия и отмечает подпись, срок действия и отзыв сессии.
+// Непринятый токен - тоже ответ 200: разбор удался, причина в поле reason.
+// Доступен только администраторам.
+func New(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.token.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		inspection := auth.InspectJWT(req.Token)
+
+		// Сам токен в лог не пишется: он может быть действующим
+		attrs := []any{
+			slog.Bool("valid", inspection.Valid),
+			slog.String("signature", inspection.Signature),
+			slog.Bool("expired", inspection.Expired),
+			slog.Bool("revoked", inspection.Revoked),
+		}
+		if inspection.Claims != nil {
+			attrs = append(attrs, slog.String("username", inspection.Claims.Username))
+		}
+		log.Info("token inspected", attrs...)
+
+		resp.JSON(w, r, Response{
+			Response:  resp.OK(),
+			Valid:     inspection.Valid,
+			Signature: inspection.Signature,
+			Expired:   inspection.Expired,
+			Revoked:   inspection.Revoked,
+			Reason:    inspection.Error,
+			Claims:    inspection.Claims,
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package token_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/admin/token"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+const secret = "inspect-test-secret"
+
+// signed подписывает токен пользователя alice, истекающий в exp
+func signed(t *testing.T, key string, exp time.Time) string {
+	t.Helper()
+
+	claims := &auth.Claims{
+		Username: "alice",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(exp.Add(-5 * time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(exp),
+		},
+	}
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	require.NoError(t, err)
+
+	return tokenString
+}
+
+func TestInspectHandler(t *testing.T) {
+	auth.JWTSecret = []byte(secret)
+	auth.Admins = []string{"admin"}
+	t.Cleanup(func() {
+		auth.JWTSecret = nil
+		auth.Admins = nil
+	})
+
+	cases := []struct {
+		name      string
+		nickname  string
+		token     string
+		respCode  int
+		valid     bool
+		signature string
+		expired   bool
+	}{
+		{
+			name:      "Valid",
+			nickname:  "admin",
+			token:     signed(t, secret, time.Now().Add(time.Minute)),
+			respCode:  http.StatusOK,
+			valid:     true,
+			signature: auth.SignatureValid,
+		},
+		{
+			name:      "Expired",
+			nickname:  "admin",
+			token:     signed(t, secret, time.Now().Add(-time.Minute)),
+			respCode:  http.StatusOK,
+			signature: auth.SignatureValid,
+			expired:   true,
+		},
+		{
+			name:      "Tampered",
+			nickname:  "admin",
+			token:     signed(t, "other-secret", time.Now().Add(time.Minute)),
+			respCode:  http.StatusOK,
+			signature: auth.SignatureInvalid,
+		},
+		{
+			name:      "Malformed",
+			nickname:  "admin",
+			token:     "not-a-token",
+			respCode:  http.StatusOK,
+			signature: auth.SignatureUnknown,
+		},
+		{name: "Empty token", nickname: "admin", respCode: http.StatusBadRequest},
+		{name: "Not admin", nickname: "user", token: "not-a-token", respCode: http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Post("/admin/token/inspect", auth.AdminOnly(token.New(slogdiscard.NewDiscardLogger())))
+
+			body, err := json.Marshal(token.Request{Token: tc.token})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/token/inspect", strings.NewReader(string(body)))
+			req = req.WithContext(auth.WithNickname(req.Context(), tc.nickname))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.respCode, rr.Code)
+			require.NotContains(t, rr.Body.String(), secret)
+			if tc.respCode != http.StatusOK {
+				return
+			}
+
+			var resp token.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.valid, resp.Valid)
+			require.Equal(t, tc.signature, resp.Signature)
+			require.Equal(t, tc.expired, resp.Expired)
+			if tc.valid {
+				require.Empty(t, resp.Reason)
+			} else {
+				require.NotEmpty(t, resp.Reason)
+			}
+			if tc.signature == auth.SignatureUnknown {
+				require.Nil(t, resp.Claims)
+			} else {
+				require.Equal(t, "alice", resp.Claims.Username)
+			}
+		})
+	}
+}
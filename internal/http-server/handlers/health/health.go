@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/storage"
+)
+
+// Readiness - общий флаг остановки сервиса. После SetDraining /readyz отвечает 503,
+// чтобы балансировщик перестал присылать новые запросы, пока текущие завершаются.
+type Readiness struct {
+	draining atomic.Bool
+}
+
+// SetDraining отмечает начало остановки сервиса
+func (r *Readiness) SetDraining() {
+	r.draining.Store(true)
+}
+
+// Draining сообщает, идёт ли остановка сервиса
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
+
+// Live отвечает 200, пока процесс способен обрабатывать запросы (GET /healthz)
+func Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp.JSON(w, r, resp.OK())
+	}
+}
+
+// Ready отвечает 200, пока сервис принимает новый трафик, и 503 во время остановки (GET /readyz)
+func Ready(log *slog.Logger, readiness *Readiness) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if readiness.Draining() {
+			log.Debug("readiness probe failed: shutting down")
+			render.Status(r, http.StatusServiceUnavailable)
+			resp.JSON(w, r, resp.Error("shutting down"))
+			return
+		}
+
+		resp.JSON(w, r, resp.OK())
+	}
+}
+
+// StatusProvider - хранилище, которое умеет проверить свои базы
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=StatusProvider
+type StatusProvider interface {
+	Status(ctx context.Context, log *slog.Logger) storage.Status
+}
+
+type DetailResponse struct {
+	resp.Response
+	storage.Status
+}
+
+// Detail отдаёт состояние каждой базы: задержку пинга, таймаут и состояние переключателя
+// (GET /health/detail). Проверка целиком ограничена timeout, поэтому зависшая база
+// не задерживает ответ. Это отчёт для операторов, он всегда отдаётся с 200; решение
+// о трафике по-прежнему принимается по /readyz. В отчёте есть тексты ошибок баз,
+// поэтому маршрут открыт только администраторам.
+func Detail(log *slog.Logger, statusProvider StatusProvider, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.health.Detail"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		status := statusProvider.Status(ctx, log)
+
+		log.Debug("dependency status collected",
+			slog.String("effective_mode", status.EffectiveMode),
+			slog.Bool("degraded", status.Degraded),
+		)
+
+		resp.JSON(w, r, DetailResponse{
+			Response: resp.OK(),
+			Status:   status,
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/health"
+	"url-shortener/internal/http-server/handlers/health/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestReadinessDrain(t *testing.T) {
+	readiness := &health.Readiness{}
+	live := health.Live()
+	ready := health.Ready(slogdiscard.NewDiscardLogger(), readiness)
+
+	probe := func(h http.HandlerFunc, target string) int {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		return rr.Code
+	}
+
+	require.Equal(t, http.StatusOK, probe(live, "/healthz"))
+	require.Equal(t, http.StatusOK, probe(ready, "/readyz"))
+
+	readiness.SetDraining()
+
+	require.True(t, readiness.Draining())
+	require.Equal(t, http.StatusOK, probe(live, "/healthz"))
+	require.Equal(t, http.StatusServiceUnavailable, probe(ready, "/readyz"))
+}
+
+func TestDetail(t *testing.T) {
+	sqliteLatency, mongoLatency := 0.4, 250.0
+
+	statusMock := mocks.NewStatusProvider(t)
+	// Проверка получает контекст со сроком не позже timeout
+	statusMock.On("Status", mock.MatchedBy(func(ctx context.Context) bool {
+		deadline, ok := ctx.Deadline()
+		return ok && time.Until(deadline) <= 250*time.Millisecond
+	}), mock.Anything).
+		Return(storage.Status{
+			ConfiguredMode: storage.ModeDual,
+			EffectiveMode:  storage.ModeDual,
+			Backends: map[string]storage.BackendStatus{
+				storage.ModeSQLite: {Up: true, LatencyMs: &sqliteLatency},
+				storage.ModeMongo:  {Up: false, Error: "context deadline exceeded", LatencyMs: &mongoLatency, TimedOut: true, Breaker: "closed"},
+			},
+		}).
+		Once()
+
+	rr := httptest.NewRecorder()
+	health.Detail(slogdiscard.NewDiscardLogger(), statusMock, 250*time.Millisecond).
+		ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health/detail", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp health.DetailResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	sqlite := resp.Backends[storage.ModeSQLite]
+	require.True(t, sqlite.Up)
+	require.NotNil(t, sqlite.LatencyMs)
+	require.Equal(t, 0.4, *sqlite.LatencyMs)
+	require.False(t, sqlite.TimedOut)
+
+	mongo := resp.Backends[storage.ModeMongo]
+	require.False(t, mongo.Up)
+	require.True(t, mongo.TimedOut)
+	require.NotNil(t, mongo.LatencyMs)
+	require.Equal(t, "closed", mongo.Breaker)
+}
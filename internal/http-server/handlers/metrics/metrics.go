@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"net/http"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/metrics"
+)
+
+// contentType - формат текстовой выдачи Prometheus
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// New отдаёт счётчики сервиса в текстовом формате Prometheus (GET /metrics)
+func New(log *slog.Logger, registry *metrics.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.metrics.New"
+
+		w.Header().Set("Content-Type", contentType)
+		if _, err := registry.WriteTo(w); err != nil {
+			log.Error("failed to write metrics", slog.String("op", op), sl.Err(err))
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"url-shortener/internal/http-server/handlers/admin/owner"
+	adminStatus "url-shortener/internal/http-server/handlers/admin/status"
+	adminToken "url-shortener/internal/http-server/handlers/admin/token"
+	"url-shortener/internal/http-server/handlers/health"
+	"url-shortener/internal/http-server/handlers/url/broken"
+	"url-shortener/internal/http-server/handlers/url/card"
+	"url-shortener/internal/http-server/handlers/url/check"
+	"url-shortener/internal/http-server/handlers/url/count"
+	"url-shortener/internal/http-server/handlers/url/extend"
+	"url-shortener/internal/http-server/handlers/url/link"
+	"url-shortener/internal/http-server/handlers/url/lookup"
+	"url-shortener/internal/http-server/handlers/url/purge"
+	"url-shortener/internal/http-server/handlers/url/refreshpreview"
+	"url-shortener/internal/http-server/handlers/url/regenerate"
+	"url-shortener/internal/http-server/handlers/url/resolve"
+	"url-shortener/internal/http-server/handlers/url/restore"
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/handlers/url/stale"
+	"url-shortener/internal/http-server/handlers/url/stats"
+	"url-shortener/internal/http-server/handlers/url/suggest"
+	listTags "url-shortener/internal/http-server/handlers/url/tags/list"
+	updateTags "url-shortener/internal/http-server/handlers/url/tags/update"
+	"url-shortener/internal/http-server/handlers/url/target"
+	"url-shortener/internal/http-server/handlers/url/timeseries"
+	"url-shortener/internal/http-server/handlers/url/trash"
+	updateURL "url-shortener/internal/http-server/handlers/url/update"
+	"url-shortener/internal/http-server/handlers/url/visibility"
+	deleteUser "url-shortener/internal/http-server/handlers/user/delete"
+	"url-shortener/internal/http-server/handlers/user/export"
+	"url-shortener/internal/http-server/handlers/user/login"
+	"url-shortener/internal/http-server/handlers/user/logins"
+	claimPrefix "url-shortener/internal/http-server/handlers/user/prefixes/claim"
+	"url-shortener/internal/http-server/handlers/user/register"
+	listSessions "url-shortener/internal/http-server/handlers/user/sessions/list"
+	revokeSession "url-shortener/internal/http-server/handlers/user/sessions/revoke"
+	getSettings "url-shortener/internal/http-server/handlers/user/settings/get"
+	updateSettings "url-shortener/internal/http-server/handlers/user/settings/update"
+	transferURLs "url-shortener/internal/http-server/handlers/user/transfer"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/openapi"
+)
+
+// Параметры постраничного вывода
+var pagination = []openapi.Param{
+	{Name: "limit", Type: "integer", Description: "page size"},
+	{Name: "offset", Type: "integer", Description: "number of items to skip"},
+}
+
+// Spec описывает все эндпоинты сервиса. Схемы запросов и ответов строятся из структур
+// хендлеров, поэтому при добавлении маршрута в main его нужно добавить и сюда.
+func Spec() *openapi.Document {
+	doc := openapi.New("url-shortener", "1.0.0")
+	doc.Description = "URL shortener API. Protected operations require a JWT from POST /login."
+
+	return doc.Add(
+		openapi.Operation{Method: http.MethodGet, Path: "/healthz", Summary: "Liveness probe", Response: resp.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/readyz", Summary: "Readiness probe", Response: resp.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/health/detail", Summary: "Per-dependency latency and breaker state (admin)", Auth: true, Response: health.DetailResponse{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/metrics", Summary: "Service counters in Prometheus text format", ContentType: "text/plain"},
+		openapi.Operation{Method: http.MethodGet, Path: "/robots.txt", Summary: "Crawler policy", ContentType: "text/plain"},
+		openapi.Operation{Method: http.MethodGet, Path: "/openapi.json", Summary: "This document"},
+
+		openapi.Operation{Method: http.MethodPost, Path: "/register", Summary: "Register a user", Request: register.Request{}, Response: resp.Response{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/login", Summary: "Log in and get a JWT", Request: login.Request{}, Response: login.LoginResponse{}},
+
+		openapi.Operation{Method: http.MethodPost, Path: "/url/save", Summary: "Shorten a URL", Auth: true, Request: save.Request{}, Response: save.Response{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/url/resolve", Summary: "Resolve aliases in bulk", Auth: true, Request: resolve.Request{}, Response: resolve.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/tags", Summary: "Own tags with link counts, most used first", Auth: true, Response: listTags.Response{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/url/tags", Summary: "Update tags of links", Auth: true, Request: updateTags.Request{}, Response: updateTags.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/count", Summary: "Count own links", Auth: true, Response: count.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/analytics/export", Summary: "Export click statistics of own links as CSV", Auth: true,
+			ContentType: "text/csv", Query: []openapi.Param{{Name: "tag", Description: "Only links with this tag"}}},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/stats", Summary: "Click statistics of own links", Auth: true, Response: stats.Response{},
+			Query: append([]openapi.Param{{Name: "sort", Description: "sort order"}}, pagination...)},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/lookup", Summary: "Find own short link for a URL", Auth: true, Response: lookup.Response{},
+			Query: []openapi.Param{{Name: "url", Required: true, Description: "URL to look up"}}},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/broken", Summary: "Links whose target failed the last check", Auth: true, Response: broken.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/stale", Summary: "Links without recent clicks", Auth: true, Response: stale.Response{},
+			Query: []openapi.Param{{Name: "days", Type: "integer", Description: "days without clicks"}}},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/suggest", Summary: "Suggest free aliases", Auth: true, Response: suggest.Response{},
+			Query: []openapi.Param{{Name: "alias", Required: true, Description: "wanted alias"}}},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/qr-batch", Summary: "QR codes of own links as a zip archive", Auth: true, ContentType: "application/zip",
+			Query: []openapi.Param{{Name: "alias", Description: "alias to include, may be repeated"}}},
+		openapi.Operation{Method: http.MethodPost, Path: "/url/{alias}/extend", Summary: "Extend link expiry", Auth: true, Request: extend.Request{}, Response: extend.Response{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/url/{alias}/regenerate", Summary: "Replace alias with a random one", Auth: true, Response: regenerate.Response{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/url/{alias}/link", Summary: "Add another alias for the same target", Auth: true, Response: link.Response{}},
+		openapi.Operation{Method: http.MethodPatch, Path: "/url/{alias}/visibility", Summary: "Change link visibility", Auth: true, Request: visibility.Request{}, Response: visibility.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/{alias}/card", Summary: "Link preview card", Auth: true, Response: card.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/{alias}/check", Summary: "Check link target", Auth: true, Response: check.Response{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/url/{alias}/refresh-preview", Summary: "Re-fetch stored preview metadata", Auth: true, Response: refreshpreview.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/{alias}/timeseries", Summary: "Clicks over time", Auth: true, Response: timeseries.Response{},
+			Query: []openapi.Param{
+				{Name: "bucket", Description: "hour or day"},
+				{Name: "from", Description: "RFC 3339 start of range"},
+				{Name: "to", Description: "RFC 3339 end of range"},
+			}},
+		openapi.Operation{Method: http.MethodGet, Path: "/url/trash", Summary: "Deleted links that can be restored", Auth: true, Response: trash.Response{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/url/trash", Summary: "Purge deleted links", Auth: true, Request: purge.Request{}, Response: purge.Response{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/url/{alias}/restore", Summary: "Restore a deleted link from the trash", Auth: true, Response: restore.Response{}},
+		openapi.Operation{Method: http.MethodPatch, Path: "/url/{alias}", Summary: "Change link description", Auth: true, Request: updateURL.Request{}, Response: updateURL.Response{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/url/{alias}", Summary: "Delete a link", Auth: true, Response: resp.Response{}},
+
+		openapi.Operation{Method: http.MethodGet, Path: "/user/settings", Summary: "User settings", Auth: true, Response: getSettings.Response{}},
+		openapi.Operation{Method: http.MethodPatch, Path: "/user/settings", Summary: "Update user settings", Auth: true, Request: updateSettings.Request{}, Response: updateSettings.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/user/sessions", Summary: "Active sessions", Auth: true, Response: listSessions.Response{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/user/sessions/{id}", Summary: "Revoke a session", Auth: true, Response: revokeSession.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/user/logins", Summary: "Recent login attempts", Auth: true, Response: logins.Response{}, Query: pagination},
+		openapi.Operation{Method: http.MethodPost, Path: "/user/prefixes", Summary: "Claim an alias prefix", Auth: true, Request: claimPrefix.Request{}, Response: claimPrefix.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/user/export", Summary: "Export all user data", Auth: true, Response: export.Bundle{}},
+		openapi.Operation{Method: http.MethodDelete, Path: "/user/{nickname}", Summary: "Delete a user", Auth: true, Request: deleteUser.Request{}, Response: resp.Response{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/user/{nickname}/transfer-urls", Summary: "Move all of a user's URLs to another user", Auth: true, Request: transferURLs.Request{}, Response: transferURLs.Response{}},
+
+		openapi.Operation{Method: http.MethodGet, Path: "/admin/status", Summary: "Storage status (admin)", Auth: true, Response: adminStatus.Response{}},
+		openapi.Operation{Method: http.MethodGet, Path: "/admin/url/{alias}/owner", Summary: "Owner of a link (admin)", Auth: true, Response: owner.Response{}},
+		openapi.Operation{Method: http.MethodPost, Path: "/admin/token/inspect", Summary: "Decode a token and report why it is rejected (admin)", Auth: true,
+			Request: adminToken.Request{}, Response: adminToken.Response{}},
+
+		openapi.Operation{Method: http.MethodGet, Path: "/redirect/{alias}", Summary: "Redirect to own link", Auth: true, Status: http.StatusFound},
+		openapi.Operation{Method: http.MethodGet, Path: "/r/{alias}", Summary: "Public redirect", Status: http.StatusFound,
+			Query: []openapi.Param{{Name: "preview", Description: "1 shows the target instead of redirecting"}}},
+		openapi.Operation{Method: http.MethodGet, Path: "/target/{alias}", Summary: "Target of a public link without redirect", Response: target.Response{}},
+	)
+}
+
+// New отдаёт документ OpenAPI (GET /openapi.json). Документ сериализуется один раз при создании хендлера.
+func New(doc *openapi.Document) (http.HandlerFunc, error) {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}, nil
+}
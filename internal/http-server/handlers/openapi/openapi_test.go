@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIHandler(t *testing.T) {
+	handler, err := New(Spec())
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var doc struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			SecuritySchemes map[string]map[string]any `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+
+	assert.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+
+	// Основные маршруты описаны с нужными методами
+	core := map[string]string{
+		"/register":         "post",
+		"/login":            "post",
+		"/url/save":         "post",
+		"/url/{alias}":      "delete",
+		"/redirect/{alias}": "get",
+		"/r/{alias}":        "get",
+	}
+	for path, method := range core {
+		require.Contains(t, doc.Paths, path)
+		assert.Contains(t, doc.Paths[path], method, path)
+	}
+
+	require.Contains(t, doc.Components.SecuritySchemes, "bearerAuth")
+	assert.Equal(t, "bearer", doc.Components.SecuritySchemes["bearerAuth"]["scheme"])
+
+	// Защищённые маршруты требуют токен, открытые - нет
+	assert.Contains(t, doc.Paths["/url/save"]["post"], "security")
+	assert.NotContains(t, doc.Paths["/login"]["post"], "security")
+}
@@ -0,0 +1,21 @@
+package robots
+
+import (
+	"net/http"
+)
+
+// DefaultPolicy запрещает роботам обходить короткие ссылки: каждый такой обход
+// засчитывается как переход и уводит робота на чужой сайт
+const DefaultPolicy = "User-agent: *\nDisallow: /r/\nDisallow: /redirect/\n"
+
+// New отдаёт policy как /robots.txt (GET /robots.txt). Пустая policy - DefaultPolicy.
+func New(policy string) http.HandlerFunc {
+	if policy == "" {
+		policy = DefaultPolicy
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(policy))
+	}
+}
@@ -0,0 +1,42 @@
+package robots_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/robots"
+)
+
+func TestRobots(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy string
+		want   string
+	}{
+		{
+			name: "Default policy",
+			want: "User-agent: *\nDisallow: /r/\nDisallow: /redirect/\n",
+		},
+		{
+			name:   "Configured policy",
+			policy: "User-agent: *\nDisallow: /\n",
+			want:   "User-agent: *\nDisallow: /\n",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			robots.New(tc.policy).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+			require.Equal(t, tc.want, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	tagsutil "url-shortener/internal/lib/tags"
+	"url-shortener/internal/storage"
+)
+
+// pageSize - сколько строк статистики читается из базы и отправляется клиенту за раз
+const pageSize = 500
+
+// Header - первая строка CSV
+var Header = []string{"alias", "url", "clicks", "created_at", "last_accessed_at"}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AnalyticsExporter
+type AnalyticsExporter interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetURLs(ctx context.Context, log *slog.Logger, aliases []string, userID int64) ([]storage.URL, error)
+	LinkStats(ctx context.Context, log *slog.Logger, userID int64, sort string, limit, offset int) ([]storage.LinkStats, error)
+}
+
+// New выгружает статистику всех ссылок пользователя в CSV: GET /url/analytics/export?tag=work.
+// Строки отправляются клиенту страницами по мере чтения статистики, от новых ссылок к старым:
+// к каждой странице статистики из базы дочитываются только её ссылки, вся выгрузка в памяти не держится.
+// Ошибка после начала выгрузки обрывает файл: статус уже отправлен.
+func New(log *slog.Logger, exporter AnalyticsExporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.analytics.export.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		var tag string
+		if r.URL.Query().Has("tag") {
+			normalized := tagsutil.Normalize([]string{r.URL.Query().Get("tag")})
+			if len(normalized) == 0 {
+				log.Error("empty tag filter")
+				render.Status(r, http.StatusBadRequest)
+				resp.JSON(w, r, resp.Error("tag must not be empty"))
+				return
+			}
+			tag = normalized[0]
+		}
+
+		userID, _, err := exporter.GetUserByNickname(r.Context(), log, nickname)
+		if err != nil {
+			log.Error("failed to get user by nickname", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		// Первая страница читается до отправки статуса, чтобы сбой базы отдать обычной ошибкой
+		stats, links, err := page(r.Context(), log, exporter, userID, tag, 0)
+		if err != nil {
+			log.Error("failed to read analytics page", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to export analytics"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-analytics.csv"`, nickname))
+
+		out := csv.NewWriter(w)
+		if err := out.Write(Header); err != nil {
+			log.Error("failed to write export", sl.Err(err))
+			return
+		}
+
+		rows := 0
+		for offset := 0; ; offset += pageSize {
+			if offset > 0 {
+				stats, links, err = page(r.Context(), log, exporter, userID, tag, offset)
+				if err != nil {
+					log.Error("failed to read analytics page, export is truncated", slog.Int("rows", rows), sl.Err(err))
+					return
+				}
+			}
+
+			for _, st := range stats {
+				link, ok := links[st.Alias]
+				if !ok {
+					continue
+				}
+				if err := out.Write(row(link, st)); err != nil {
+					log.Error("failed to write export", sl.Err(err))
+					return
+				}
+				rows++
+			}
+
+			out.Flush()
+			if err := out.Error(); err != nil {
+				log.Error("failed to write export", sl.Err(err))
+				return
+			}
+
+			if len(stats) < pageSize {
+				break
+			}
+		}
+
+		log.Info("analytics exported", slog.Int("rows", rows), slog.String("tag", tag))
+	}
+}
+
+// page читает страницу статистики и ссылки этой страницы, подходящие под фильтр по метке
+func page(ctx context.Context, log *slog.Logger, exporter AnalyticsExporter, userID int64, tag string, offset int) ([]storage.LinkStats, map[string]storage.URL, error) {
+	stats, err := exporter.LinkStats(ctx, log, userID, storage.StatsSortCreated, pageSize, offset)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get link stats: %w", err)
+	}
+
+	aliases := make([]string, 0, len(stats))
+	for _, st := range stats {
+		aliases = append(aliases, st.Alias)
+	}
+
+	urls, err := exporter.GetURLs(ctx, log, aliases, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get urls: %w", err)
+	}
+
+	links := make(map[string]storage.URL, len(urls))
+	for _, u := range urls {
+		if tag == "" || hasTag(u.Tags, tag) {
+			links[u.Alias] = u
+		}
+	}
+
+	return stats, links, nil
+}
+
+func row(link storage.URL, st storage.LinkStats) []string {
+	lastAccessed := ""
+	if link.LastAccessedAt != nil {
+		lastAccessed = link.LastAccessedAt.UTC().Format(time.RFC3339)
+	}
+
+	return []string{
+		cell(link.Alias),
+		cell(link.URL),
+		strconv.FormatInt(st.Clicks, 10),
+		st.CreatedAt.UTC().Format(time.RFC3339),
+		lastAccessed,
+	}
+}
+
+// cell защищает от формул: таблицы выполняют значение, начинающееся с =, +, - или @,
+// а также с табуляции или возврата каретки, за которыми может идти формула
+func cell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
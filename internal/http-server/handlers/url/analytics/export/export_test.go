@@ -0,0 +1,148 @@
+package export_test
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/analytics/export"
+	"url-shortener/internal/http-server/handlers/url/analytics/export/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestAnalyticsExportHandler(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	accessed := created.Add(time.Hour)
+
+	urls := []storage.URL{
+		{Alias: "docs", URL: "https://example.com/docs", Tags: []string{"work"}, LastAccessedAt: &accessed},
+		{Alias: "blog", URL: "https://example.com/blog"},
+		{Alias: "-x", URL: "https://example.com/x", Tags: []string{"work"}},
+	}
+	stats := []storage.LinkStats{
+		{Alias: "-x", Clicks: 0, CreatedAt: created.Add(2 * time.Hour)},
+		{Alias: "blog", Clicks: 3, CreatedAt: created.Add(time.Hour)},
+		{Alias: "docs", Clicks: 7, CreatedAt: created},
+	}
+
+	cases := []struct {
+		name   string
+		target string
+		rows   int
+		status int
+	}{
+		{name: "All links", target: "/url/analytics/export", rows: 3, status: http.StatusOK},
+		{name: "Tag filter", target: "/url/analytics/export?tag=Work", rows: 2, status: http.StatusOK},
+		{name: "Unknown tag", target: "/url/analytics/export?tag=home", rows: 0, status: http.StatusOK},
+		{name: "Empty tag", target: "/url/analytics/export?tag=", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			exporterMock := mocks.NewAnalyticsExporter(t)
+			if tc.status == http.StatusOK {
+				exporterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				exporterMock.On("LinkStats", mock.Anything, mock.Anything, int64(1), storage.StatsSortCreated, mock.Anything, 0).
+					Return(stats, nil).
+					Once()
+				exporterMock.On("GetURLs", mock.Anything, mock.Anything, []string{"-x", "blog", "docs"}, int64(1)).
+					Return(urls, nil).
+					Once()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			export.New(slogdiscard.NewDiscardLogger(), exporterMock).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+			require.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+
+			records, err := csv.NewReader(rr.Body).ReadAll()
+			require.NoError(t, err)
+			require.Equal(t, export.Header, records[0])
+			require.Len(t, records[1:], tc.rows)
+
+			if tc.rows == 3 {
+				// Значение с "-" в начале не превращается в формулу таблицы
+				require.Equal(t, []string{"'-x", "https://example.com/x", "0", "2024-05-01T14:00:00Z", ""}, records[1])
+				require.Equal(t, []string{"docs", "https://example.com/docs", "7", "2024-05-01T12:00:00Z", "2024-05-01T13:00:00Z"}, records[3])
+			}
+		})
+	}
+}
+
+func TestAnalyticsExportHandler_Pages(t *testing.T) {
+	const pageSize = 500
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	first := make([]storage.LinkStats, 0, pageSize)
+	firstURLs := make([]storage.URL, 0, pageSize)
+	firstAliases := make([]string, 0, pageSize)
+	for i := 0; i < pageSize; i++ {
+		alias := fmt.Sprintf("link%d", i)
+		first = append(first, storage.LinkStats{Alias: alias, CreatedAt: created})
+		firstURLs = append(firstURLs, storage.URL{Alias: alias, URL: "https://example.com/" + alias})
+		firstAliases = append(firstAliases, alias)
+	}
+	second := []storage.LinkStats{
+		{Alias: "tab", CreatedAt: created},
+		{Alias: "cr", CreatedAt: created},
+	}
+	secondURLs := []storage.URL{
+		{Alias: "tab", URL: "\t=cmd()"},
+		{Alias: "cr", URL: "\r=cmd()"},
+	}
+
+	exporterMock := mocks.NewAnalyticsExporter(t)
+	exporterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	exporterMock.On("LinkStats", mock.Anything, mock.Anything, int64(1), storage.StatsSortCreated, pageSize, 0).
+		Return(first, nil).
+		Once()
+	exporterMock.On("GetURLs", mock.Anything, mock.Anything, firstAliases, int64(1)).
+		Return(firstURLs, nil).
+		Once()
+	exporterMock.On("LinkStats", mock.Anything, mock.Anything, int64(1), storage.StatsSortCreated, pageSize, pageSize).
+		Return(second, nil).
+		Once()
+	exporterMock.On("GetURLs", mock.Anything, mock.Anything, []string{"tab", "cr"}, int64(1)).
+		Return(secondURLs, nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/url/analytics/export", nil)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	export.New(slogdiscard.NewDiscardLogger(), exporterMock).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records[1:], pageSize+2)
+
+	// Табуляция и возврат каретки перед формулой тоже экранируются
+	require.Equal(t, "'\t=cmd()", records[pageSize+1][1])
+	require.Equal(t, "'\r=cmd()", records[pageSize+2][1])
+}
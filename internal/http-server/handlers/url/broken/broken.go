@@ -0,0 +1,68 @@
+package broken
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	URLs []storage.URL `json:"urls"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=BrokenURLGetter
+type BrokenURLGetter interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetBrokenURLsByUser(ctx context.Context, log *slog.Logger, userID int64) ([]storage.URL, error)
+}
+
+// New отдаёт ссылки пользователя, цель которых при последней фоновой проверке
+// не ответила или ответила ошибкой: GET /url/broken
+func New(log *slog.Logger, brokenGetter BrokenURLGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.broken.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		userID, _, errGetUser := brokenGetter.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		urls, err := brokenGetter.GetBrokenURLsByUser(r.Context(), log, userID)
+		if err != nil {
+			log.Error("failed to get broken urls", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get broken urls"))
+			return
+		}
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			URLs:     urls,
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package broken_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/broken"
+	"url-shortener/internal/http-server/handlers/url/broken/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestBrokenHandler(t *testing.T) {
+	status := http.StatusNotFound
+
+	getterMock := mocks.NewBrokenURLGetter(t)
+	getterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	getterMock.On("GetBrokenURLsByUser", mock.Anything, mock.Anything, int64(1)).
+		Return([]storage.URL{{Alias: "gone", URL: "https://example.com/gone", LastStatus: &status}}, nil).
+		Once()
+
+	handler := broken.New(slogdiscard.NewDiscardLogger(), getterMock)
+
+	req, err := http.NewRequest(http.MethodGet, "/url/broken", nil)
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp broken.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp.URLs, 1)
+	require.Equal(t, "gone", resp.URLs[0].Alias)
+	require.Equal(t, http.StatusNotFound, *resp.URLs[0].LastStatus)
+}
+
+func TestBrokenHandler_Unauthorized(t *testing.T) {
+	handler := broken.New(slogdiscard.NewDiscardLogger(), mocks.NewBrokenURLGetter(t))
+
+	req, err := http.NewRequest(http.MethodGet, "/url/broken", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+}
@@ -0,0 +1,159 @@
+package card
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/qr"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/storage"
+)
+
+// Части карточки, которые могут отсутствовать в ответе
+const (
+	PartTitle  = "title"
+	PartClicks = "clicks"
+	PartQR     = "qr"
+)
+
+type Response struct {
+	resp.Response
+	Alias    string `json:"alias"`
+	ShortURL string `json:"short_url"`
+	URL      string `json:"url"`
+	Title    string `json:"title,omitempty"`
+	Clicks   *int64 `json:"clicks,omitempty"`
+	// QR - PNG с QR-кодом короткой ссылки в base64
+	QR string `json:"qr,omitempty"`
+	// Missing - части карточки, которые не удалось получить
+	Missing []string `json:"missing,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=CardSource
+type CardSource interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetLink(ctx context.Context, log *slog.Logger, alias string) (storage.URL, error)
+	CountClicks(ctx context.Context, log *slog.Logger, alias string) (int64, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TitleFetcher
+type TitleFetcher interface {
+	Title(ctx context.Context, target string) (string, error)
+}
+
+// Options - параметры карточки ссылки
+type Options struct {
+	BaseURL string
+	// PreviewTimeout - предел на получение заголовка страницы; 0 - без отдельного предела
+	PreviewTimeout time.Duration
+}
+
+// New отдаёт владельцу всё для экрана «поделиться» одним запросом: GET /url/{alias}/card.
+// Сбой заголовка, счётчика или QR не роняет ответ - часть перечисляется в missing.
+func New(log *slog.Logger, source CardSource, titles TitleFetcher, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.card.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		userID, _, errGetUser := source.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		// Карточка доступна только владельцу
+		link, err := source.GetLink(r.Context(), log, alias)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			log.Info("url not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error(access.NotFoundMessage))
+			return
+		case err != nil:
+			log.Error("failed to get url", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get url"))
+			return
+		}
+
+		if err := access.Check(link.UserID, userID); err != nil {
+			access.Deny(w, r, log, nickname, alias)
+			return
+		}
+
+		res := Response{
+			Response: resp.OK(),
+			Alias:    link.Alias,
+			ShortURL: shorturl.Build(opts.BaseURL, link.Alias),
+			URL:      link.URL,
+		}
+
+		if title, err := fetchTitle(r.Context(), titles, link.URL, opts.PreviewTimeout); err != nil {
+			log.Warn("failed to fetch page title", slog.String("alias", alias), sl.Err(err))
+			res.Missing = append(res.Missing, PartTitle)
+		} else {
+			res.Title = title
+		}
+
+		if clicks, err := source.CountClicks(r.Context(), log, link.Alias); err != nil {
+			log.Warn("failed to count clicks", slog.String("alias", alias), sl.Err(err))
+			res.Missing = append(res.Missing, PartClicks)
+		} else {
+			res.Clicks = &clicks
+		}
+
+		if png, err := qr.PNG(res.ShortURL, qr.DefaultSize); err != nil {
+			log.Warn("failed to render qr code", slog.String("alias", alias), sl.Err(err))
+			res.Missing = append(res.Missing, PartQR)
+		} else {
+			res.QR = base64.StdEncoding.EncodeToString(png)
+		}
+
+		resp.JSON(w, r, res)
+	}
+}
+
+// fetchTitle ограничивает получение заголовка, чтобы медленная цель не задерживала карточку
+func fetchTitle(ctx context.Context, titles TitleFetcher, target string, timeout time.Duration) (string, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return titles.Title(ctx, target)
+}
@@ -0,0 +1,164 @@
+package card_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/card"
+	"url-shortener/internal/http-server/handlers/url/card/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+func serve(t *testing.T, source *mocks.CardSource, titles *mocks.TitleFetcher) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/card", card.New(slogdiscard.NewDiscardLogger(), source, titles, card.Options{
+		BaseURL:        "https://sho.rt",
+		PreviewTimeout: time.Second,
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/url/test_alias/card", nil)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func ownedSource(t *testing.T) *mocks.CardSource {
+	t.Helper()
+
+	source := mocks.NewCardSource(t)
+	source.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	source.On("GetLink", mock.Anything, mock.Anything, "test_alias").
+		Return(storage.URL{Alias: "test_alias", URL: "https://example.com/page", UserID: 1}, nil).
+		Once()
+
+	return source
+}
+
+func decode(t *testing.T, rr *httptest.ResponseRecorder) card.Response {
+	t.Helper()
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp card.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func requireQR(t *testing.T, resp card.Response) {
+	t.Helper()
+
+	png, err := base64.StdEncoding.DecodeString(resp.QR)
+	require.NoError(t, err)
+	require.Equal(t, pngHeader, png[:len(pngHeader)])
+}
+
+func TestCardHandler(t *testing.T) {
+	source := ownedSource(t)
+	source.On("CountClicks", mock.Anything, mock.Anything, "test_alias").
+		Return(int64(7), nil).
+		Once()
+
+	titles := mocks.NewTitleFetcher(t)
+	titles.On("Title", mock.Anything, "https://example.com/page").
+		Return("Example page", nil).
+		Once()
+
+	resp := decode(t, serve(t, source, titles))
+
+	require.Equal(t, "test_alias", resp.Alias)
+	require.Equal(t, "https://sho.rt/redirect/test_alias", resp.ShortURL)
+	require.Equal(t, "https://example.com/page", resp.URL)
+	require.Equal(t, "Example page", resp.Title)
+	require.Equal(t, int64(7), *resp.Clicks)
+	require.Empty(t, resp.Missing)
+	requireQR(t, resp)
+}
+
+func TestCardHandler_PreviewFails(t *testing.T) {
+	source := ownedSource(t)
+	source.On("CountClicks", mock.Anything, mock.Anything, "test_alias").
+		Return(int64(3), nil).
+		Once()
+
+	titles := mocks.NewTitleFetcher(t)
+	titles.On("Title", mock.Anything, "https://example.com/page").
+		Return("", errors.New("context deadline exceeded")).
+		Once()
+
+	resp := decode(t, serve(t, source, titles))
+
+	// Без заголовка карточка всё равно содержит ссылку, счётчик и QR
+	require.Empty(t, resp.Title)
+	require.Equal(t, []string{card.PartTitle}, resp.Missing)
+	require.Equal(t, "https://sho.rt/redirect/test_alias", resp.ShortURL)
+	require.Equal(t, "https://example.com/page", resp.URL)
+	require.Equal(t, int64(3), *resp.Clicks)
+	requireQR(t, resp)
+}
+
+func TestCardHandler_ClicksFail(t *testing.T) {
+	source := ownedSource(t)
+	source.On("CountClicks", mock.Anything, mock.Anything, "test_alias").
+		Return(int64(0), errors.New("database is locked")).
+		Once()
+
+	titles := mocks.NewTitleFetcher(t)
+	titles.On("Title", mock.Anything, "https://example.com/page").
+		Return("Example page", nil).
+		Once()
+
+	resp := decode(t, serve(t, source, titles))
+
+	require.Nil(t, resp.Clicks)
+	require.Equal(t, []string{card.PartClicks}, resp.Missing)
+	requireQR(t, resp)
+}
+
+func TestCardHandler_NotOwned(t *testing.T) {
+	source := mocks.NewCardSource(t)
+	source.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	source.On("GetLink", mock.Anything, mock.Anything, "test_alias").
+		Return(storage.URL{Alias: "test_alias", URL: "https://example.com/page", UserID: 2}, nil).
+		Once()
+
+	rr := serve(t, source, mocks.NewTitleFetcher(t))
+
+	require.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestCardHandler_NotFound(t *testing.T) {
+	source := mocks.NewCardSource(t)
+	source.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	source.On("GetLink", mock.Anything, mock.Anything, "test_alias").
+		Return(storage.URL{}, storage.ErrURLNotFound).
+		Once()
+
+	rr := serve(t, source, mocks.NewTitleFetcher(t))
+
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
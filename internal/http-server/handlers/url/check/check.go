@@ -0,0 +1,125 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/linkcheck"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Alias string `json:"alias"`
+	URL   string `json:"url"`
+	// TargetStatus - HTTP-статус ответа цели; 0, если ответа не было
+	TargetStatus int  `json:"target_status"`
+	Reachable    bool `json:"reachable"`
+	// CheckError - категория сбоя (linkcheck.Error*), если ответа не было. Текст ошибки сети
+	// не отдаётся: по нему можно изучать сеть, из которой идёт проверка.
+	CheckError string    `json:"check_error,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
+type URLGetter interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetURL(ctx context.Context, log *slog.Logger, alias string, userID int64) (string, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LinkChecker
+type LinkChecker interface {
+	Check(ctx context.Context, target string) linkcheck.Result
+}
+
+// New проверяет доступность целевого URL ссылки владельца: GET /url/{alias}/check.
+// Пользователь не перенаправляется; сетевые ошибки отдаются как reachable: false, а не 500.
+func New(log *slog.Logger, urlGetter URLGetter, checker LinkChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.check.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		userID, _, errGetUser := urlGetter.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		target, err := urlGetter.GetURL(r.Context(), log, alias, userID)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrUnavailable):
+				log.Warn("url lookup is ambiguous", slog.String("alias", alias), sl.Err(err))
+				render.Status(r, http.StatusServiceUnavailable)
+				resp.JSON(w, r, resp.Error("temporarily unavailable"))
+			case errors.Is(err, storage.ErrURLNotFound):
+				log.Info("url not found", slog.String("alias", alias))
+				render.Status(r, http.StatusNotFound)
+				resp.JSON(w, r, resp.Error("not found"))
+			case access.Denied(err):
+				access.Deny(w, r, log, nickname, alias)
+			case errors.Is(err, storage.ErrURLExpired):
+				log.Info("url expired", slog.String("alias", alias))
+				render.Status(r, http.StatusGone)
+				resp.JSON(w, r, resp.Error("url expired"))
+			default:
+				log.Error("failed to get url", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				resp.JSON(w, r, resp.Error("internal error"))
+			}
+			return
+		}
+
+		result := checker.Check(r.Context(), target)
+		if !result.Reachable {
+			log.Info("target is not reachable",
+				slog.String("alias", alias),
+				slog.Int("status", result.Status),
+				slog.String("error", result.Error),
+			)
+		}
+
+		resp.JSON(w, r, Response{
+			Response:     resp.OK(),
+			Alias:        alias,
+			URL:          target,
+			TargetStatus: result.Status,
+			Reachable:    result.Reachable,
+			CheckError:   result.Error,
+			CheckedAt:    result.CheckedAt,
+		})
+	}
+}
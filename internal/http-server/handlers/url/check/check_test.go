@@ -0,0 +1,136 @@
+package check_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/check"
+	"url-shortener/internal/http-server/handlers/url/check/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/linkcheck"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func serve(t *testing.T, getter *mocks.URLGetter, checker check.LinkChecker) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/check", check.New(slogdiscard.NewDiscardLogger(), getter, checker))
+
+	req := httptest.NewRequest(http.MethodGet, "/url/test_alias/check", nil)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestCheckHandler_TargetStatus(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    int
+		reachable bool
+	}{
+		{name: "Target OK", status: http.StatusOK, reachable: true},
+		{name: "Target not found", status: http.StatusNotFound, reachable: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+			}))
+			defer target.Close()
+
+			getterMock := mocks.NewURLGetter(t)
+			getterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			getterMock.On("GetURL", mock.Anything, mock.Anything, "test_alias", int64(1)).
+				Return(target.URL, nil).
+				Once()
+
+			rr := serve(t, getterMock, linkcheck.New(linkcheck.NewClient(time.Second, 3, true), 0))
+
+			// Проверка не перенаправляет пользователя
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Empty(t, rr.Header().Get("Location"))
+
+			var resp check.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.status, resp.TargetStatus)
+			require.Equal(t, tc.reachable, resp.Reachable)
+			require.Equal(t, target.URL, resp.URL)
+		})
+	}
+}
+
+func TestCheckHandler_Unreachable(t *testing.T) {
+	target := httptest.NewServer(http.NotFoundHandler())
+	targetURL := target.URL
+	target.Close()
+
+	getterMock := mocks.NewURLGetter(t)
+	getterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	getterMock.On("GetURL", mock.Anything, mock.Anything, "test_alias", int64(1)).
+		Return(targetURL, nil).
+		Once()
+
+	rr := serve(t, getterMock, linkcheck.New(linkcheck.NewClient(time.Second, 3, true), 0))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp check.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.False(t, resp.Reachable)
+	require.Zero(t, resp.TargetStatus)
+	require.Equal(t, linkcheck.ErrorUnreachable, resp.CheckError)
+}
+
+func TestCheckHandler_StorageErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "Not found", err: storage.ErrURLNotFound, status: http.StatusNotFound},
+		{name: "Foreign link", err: storage.ErrUnauthorized, status: http.StatusForbidden},
+		{name: "Expired", err: storage.ErrURLExpired, status: http.StatusGone},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			getterMock := mocks.NewURLGetter(t)
+			getterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			getterMock.On("GetURL", mock.Anything, mock.Anything, "test_alias", int64(1)).
+				Return("", tc.err).
+				Once()
+
+			// Цель не проверяется, если ссылка недоступна пользователю
+			rr := serve(t, getterMock, mocks.NewLinkChecker(t))
+
+			require.Equal(t, tc.status, rr.Code)
+		})
+	}
+}
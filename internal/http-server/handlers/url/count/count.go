@@ -0,0 +1,66 @@
+package count
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+type Response struct {
+	resp.Response
+	Count int64 `json:"count"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLCounter
+type URLCounter interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	CountURLsByUser(ctx context.Context, log *slog.Logger, userID int64) (int64, error)
+}
+
+// New отдаёт число ссылок пользователя без самого списка: GET /url/count
+func New(log *slog.Logger, urlCounter URLCounter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.count.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		userID, _, errGetUser := urlCounter.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		count, err := urlCounter.CountURLsByUser(r.Context(), log, userID)
+		if err != nil {
+			log.Error("failed to count urls", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to count urls"))
+			return
+		}
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Count:    count,
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package count_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/count"
+	"url-shortener/internal/http-server/handlers/url/count/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestCountHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		count     int64
+		mockError error
+		status    int
+	}{
+		{name: "Success", count: 7, status: http.StatusOK},
+		{name: "No links", count: 0, status: http.StatusOK},
+		{name: "Storage error", mockError: errors.New("unexpected error"), status: http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			counterMock := mocks.NewURLCounter(t)
+			counterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			counterMock.On("CountURLsByUser", mock.Anything, mock.Anything, int64(1)).
+				Return(tc.count, tc.mockError).
+				Once()
+
+			handler := count.New(slogdiscard.NewDiscardLogger(), counterMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/url/count", nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var resp count.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.count, resp.Count)
+		})
+	}
+}
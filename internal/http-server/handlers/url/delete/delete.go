@@ -1,14 +1,18 @@
 package delete
 
 import (
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"golang.org/x/exp/slog"
 	"golang.org/x/net/context"
 	"net/http"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
 	resp "url-shortener/internal/lib/api/response"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
 )
 
 type DeleteURL interface {
@@ -25,30 +29,46 @@ func New(log *slog.Logger, deleteURL DeleteURL) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		alias := chi.URLParam(r, "alias")
-		nickname := r.Context().Value("nickname").(string)
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
 
-		if nickname == "" || alias == "" {
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
 			log.Error("params is empty")
-			render.JSON(w, r, resp.Error("empty request"))
+			resp.JSON(w, r, resp.Error("empty request"))
 			return
 		}
 
 		userID, _, errGetUser := deleteURL.GetUserByNickname(r.Context(), log, nickname)
 		if errGetUser != nil {
 			log.Error("failed to get user by nickname", sl.Err(errGetUser))
-			render.JSON(w, r, resp.Error(errGetUser.Error()))
+			resp.JSON(w, r, resp.Error(errGetUser.Error()))
 			return
 		}
 
 		errDeleteURL := deleteURL.DeleteURL(r.Context(), log, alias, userID)
+		if access.Denied(errDeleteURL) {
+			access.Deny(w, r, log, nickname, alias)
+			return
+		}
+		if errors.Is(errDeleteURL, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error(access.NotFoundMessage))
+			return
+		}
 		if errDeleteURL != nil {
 			log.Error(errDeleteURL.Error(), "error", errDeleteURL)
-			render.JSON(w, r, resp.Error(errDeleteURL.Error()))
+			resp.JSON(w, r, resp.Error(errDeleteURL.Error()))
 			return
 		}
 
 		log.Info("url delete successfully")
-		render.JSON(w, r, resp.OK())
+		resp.JSON(w, r, resp.OK())
 	}
 }
@@ -0,0 +1,76 @@
+package delete_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	deleteURL "url-shortener/internal/http-server/handlers/url/delete"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/multiStorage"
+	"url-shortener/internal/storage/sqlite"
+)
+
+// Записи хранилища должны нести request_id, выставленный middleware
+func TestDeleteURLHandler_StorageLogsRequestID(t *testing.T) {
+	sqliteDB, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	userID, err := sqliteDB.SaveUser("user", "hash")
+	require.NoError(t, err)
+	require.NoError(t, sqliteDB.SaveURL("https://example.com", "test_alias", userID, storage.URLOptions{}))
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Delete("/url/{alias}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := auth.WithNickname(r.Context(), "user")
+		deleteURL.New(log, multiStorage.NewSQLiteStorage(sqliteDB)).ServeHTTP(w, r.WithContext(ctx))
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/url/test_alias", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-42")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	found := false
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+
+		if record["msg"] == "attempting to delete URL" {
+			found = true
+			require.Equal(t, "req-42", record["request_id"])
+		}
+	}
+	require.True(t, found, "storage log record not found")
+}
+
+// Значение другого типа под ключом никнейма - это 401, а не паника
+func TestDeleteURLHandler_NicknameWrongType(t *testing.T) {
+	handler := deleteURL.New(slogdiscard.NewDiscardLogger(), nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/url/test_alias", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.NicknameKey, 42))
+
+	rr := httptest.NewRecorder()
+	require.NotPanics(t, func() { handler.ServeHTTP(rr, req) })
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+}
@@ -0,0 +1,169 @@
+package extend
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/ttllimit"
+	"url-shortener/internal/storage"
+)
+
+// Request - новый срок действия: либо ttl_seconds от текущего момента, либо абсолютный expires_at
+type Request struct {
+	TTLSeconds *int64     `json:"ttl_seconds,omitempty" validate:"omitempty,gt=0"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Alias     string     `json:"alias,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ExpiryExtender
+type ExpiryExtender interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	ExtendURLExpiry(ctx context.Context, log *slog.Logger, alias string, userID int64, expiresAt time.Time) error
+}
+
+// New продлевает срок действия ссылки владельца (POST /url/{alias}/extend).
+// Истёкшую ссылку продлить нельзя, а новый срок от текущего момента должен укладываться в limit.
+func New(log *slog.Logger, expiryExtender ExpiryExtender, limit ttllimit.Limit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.extend.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		if (req.TTLSeconds == nil) == (req.ExpiresAt == nil) {
+			log.Error("exactly one of ttl_seconds and expires_at is required")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("exactly one of ttl_seconds and expires_at is required"))
+			return
+		}
+
+		now := time.Now().UTC()
+		expiresAt := newExpiry(req, now)
+
+		if !expiresAt.After(now) {
+			log.Error("new expiry is in the past", slog.Time("expires_at", expiresAt))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("expires_at must be in the future"))
+			return
+		}
+		if err := limit.Check(expiresAt.Sub(now)); err != nil {
+			log.Error("new expiry is out of range", slog.Time("expires_at", expiresAt), sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error(limit.Message(err)))
+			return
+		}
+
+		userID, _, errGetUser := expiryExtender.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		err = expiryExtender.ExtendURLExpiry(r.Context(), log, alias, userID, expiresAt)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			log.Info("url not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error(access.NotFoundMessage))
+			return
+		case access.Denied(err):
+			access.Deny(w, r, log, nickname, alias)
+			return
+		case errors.Is(err, storage.ErrURLNoExpiry):
+			log.Info("url does not expire", slog.String("alias", alias))
+			render.Status(r, http.StatusConflict)
+			resp.JSON(w, r, resp.Error("url does not expire"))
+			return
+		case errors.Is(err, storage.ErrURLExpired):
+			log.Info("url already expired", slog.String("alias", alias))
+			render.Status(r, http.StatusGone)
+			resp.JSON(w, r, resp.Error("url has expired, restore it instead"))
+			return
+		case err != nil:
+			log.Error("failed to extend url expiry", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to extend url"))
+			return
+		}
+
+		log.Info("url expiry extended", slog.String("alias", alias), slog.Time("expires_at", expiresAt))
+
+		resp.JSON(w, r, Response{
+			Response:  resp.OK(),
+			Alias:     alias,
+			ExpiresAt: &expiresAt,
+		})
+	}
+}
+
+// newExpiry вычисляет новый срок действия из запроса
+func newExpiry(req Request, now time.Time) time.Time {
+	if req.TTLSeconds != nil {
+		return now.Add(ttllimit.Seconds(*req.TTLSeconds))
+	}
+
+	return req.ExpiresAt.UTC()
+}
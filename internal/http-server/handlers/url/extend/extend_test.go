@@ -0,0 +1,148 @@
+package extend_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/extend"
+	"url-shortener/internal/http-server/handlers/url/extend/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/ttllimit"
+	"url-shortener/internal/storage"
+)
+
+var limit = ttllimit.Limit{Min: time.Minute, Max: 30 * 24 * time.Hour}
+
+func TestExtendHandler(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		mockErr error
+		// callsStorage - запрос прошёл проверки и дошёл до хранилища
+		callsStorage bool
+		// wantTTL - ожидаемый срок от текущего момента
+		wantTTL time.Duration
+		status  int
+	}{
+		{
+			name:         "Extend live link",
+			input:        `{"ttl_seconds": 3600}`,
+			callsStorage: true,
+			wantTTL:      time.Hour,
+			status:       http.StatusOK,
+		},
+		{
+			name:         "Absolute expiry",
+			input:        `{"expires_at": "` + time.Now().Add(48*time.Hour).UTC().Format(time.RFC3339) + `"}`,
+			callsStorage: true,
+			wantTTL:      48 * time.Hour,
+			status:       http.StatusOK,
+		},
+		{
+			name:         "Expired link",
+			input:        `{"ttl_seconds": 3600}`,
+			mockErr:      storage.ErrURLExpired,
+			callsStorage: true,
+			status:       http.StatusGone,
+		},
+		{
+			name:         "Permanent link",
+			input:        `{"ttl_seconds": 3600}`,
+			mockErr:      storage.ErrURLNoExpiry,
+			callsStorage: true,
+			status:       http.StatusConflict,
+		},
+		{
+			name:         "Missing link",
+			input:        `{"ttl_seconds": 3600}`,
+			mockErr:      storage.ErrURLNotFound,
+			callsStorage: true,
+			status:       http.StatusNotFound,
+		},
+		{
+			name:         "Not owned",
+			input:        `{"ttl_seconds": 3600}`,
+			mockErr:      storage.ErrUnauthorized,
+			callsStorage: true,
+			status:       http.StatusForbidden,
+		},
+		{
+			name:   "Beyond max expiry",
+			input:  `{"ttl_seconds": 31536000}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			// 18446747674 * 1e9 переполняет int64 и без защиты давал бы допустимый срок около часа
+			name:   "Overflowing ttl",
+			input:  `{"ttl_seconds": 18446747674}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Below min expiry",
+			input:  `{"ttl_seconds": 10}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Both fields",
+			input:  `{"ttl_seconds": 60, "expires_at": "2030-01-01T00:00:00Z"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Past expiry",
+			input:  `{"expires_at": "2000-01-01T00:00:00Z"}`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var expiresAt time.Time
+			extenderMock := mocks.NewExpiryExtender(t)
+			if tc.callsStorage {
+				extenderMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				extenderMock.On("ExtendURLExpiry", mock.Anything, mock.Anything, "test_alias", int64(1), mock.AnythingOfType("time.Time")).
+					Run(func(args mock.Arguments) { expiresAt = args.Get(4).(time.Time) }).
+					Return(tc.mockErr).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/url/{alias}/extend", extend.New(slogdiscard.NewDiscardLogger(), extenderMock, limit))
+
+			req, err := http.NewRequest(http.MethodPost, "/url/test_alias/extend", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			before := time.Now()
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			require.WithinDuration(t, before.Add(tc.wantTTL), expiresAt, 2*time.Second)
+
+			var resp extend.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, "test_alias", resp.Alias)
+			require.True(t, expiresAt.Equal(*resp.ExpiresAt))
+		})
+	}
+}
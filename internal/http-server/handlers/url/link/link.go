@@ -0,0 +1,220 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/aliasgroup"
+	"url-shortener/internal/lib/aliaslimit"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/blacklist"
+	"url-shortener/internal/lib/checksum"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/metrics"
+	"url-shortener/internal/lib/random"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	// Alias - созданный дополнительный alias
+	Alias string `json:"alias,omitempty"`
+	// LinkedTo - основной alias, к которому привязан новый
+	LinkedTo string `json:"linked_to,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// Значения по умолчанию для генерации alias
+const (
+	defaultAliasLength = 6
+	defaultAttempts    = 10
+)
+
+// Options - настройки генерации дополнительного alias, те же, что при сохранении ссылки
+type Options struct {
+	// AliasLength - длина нового alias
+	AliasLength int
+	// Alphabet - символы alias; пусто - random.DefaultAlphabet
+	Alphabet string
+	// Checksum - к alias добавляется контрольный символ
+	Checksum bool
+	// ChecksumFoldCase - контрольный символ не зависит от регистра, для alias без учёта регистра
+	ChecksumFoldCase bool
+	// Blacklist - запрещённые слова
+	Blacklist *blacklist.Blacklist
+	// Attempts - сколько alias пробуется, прежде чем вернуть ошибку
+	Attempts int
+	// AliasCeiling - общий предел длины alias вместе с контрольным символом; 0 - без предела
+	AliasCeiling int
+	// Grouping - разбиение alias на группы через дефис
+	Grouping aliasgroup.Grouping
+	// Metrics - счётчики коллизий и исчерпания случайных alias; nil - не считаются
+	Metrics *metrics.AliasGeneration
+}
+
+func (o Options) withDefaults() Options {
+	if o.AliasLength <= 0 {
+		o.AliasLength = defaultAliasLength
+	}
+	o.AliasLength = aliaslimit.Limit{Max: o.AliasCeiling, Checksum: o.Checksum, Grouping: o.Grouping}.Clamp(o.AliasLength)
+	if o.Alphabet == "" {
+		o.Alphabet = random.DefaultAlphabet
+	}
+	if o.Attempts <= 0 {
+		o.Attempts = defaultAttempts
+	}
+
+	return o
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AliasLinker
+type AliasLinker interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetLink(ctx context.Context, log *slog.Logger, alias string) (storage.URL, error)
+	SaveURL(ctx context.Context, log *slog.Logger, urlToSave, alias string, userID int64, opts storage.URLOptions) error
+}
+
+// New создаёт к ссылке владельца дополнительный случайный alias: POST /url/{alias}/link.
+// Новый alias открывает тот же адрес с теми же настройками (срок, видимость, код редиректа, метки)
+// и хранит основной alias в linked_to. Дополнительный alias к дополнительному привязывается
+// к основному, цепочек не бывает. Продление, смена видимости и удаление основной ссылки
+// распространяются на её дополнительные alias в хранилище. Переходы считаются по каждому alias отдельно: статистика
+// основного alias не включает переходы по дополнительным, общее число - их сумма.
+func New(log *slog.Logger, aliasLinker AliasLinker, opts Options) http.HandlerFunc {
+	opts = opts.withDefaults()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.link.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		userID, _, errGetUser := aliasLinker.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		link, err := aliasLinker.GetLink(r.Context(), log, alias)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			log.Info("url not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error("not found"))
+			return
+		case err != nil:
+			log.Error("failed to get url", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get url"))
+			return
+		}
+
+		if err := access.Check(link.UserID, userID); err != nil {
+			access.Deny(w, r, log, nickname, alias)
+			return
+		}
+		if storage.Expired(link.ExpiresAt, time.Now()) {
+			log.Info("url already expired", slog.String("alias", alias))
+			render.Status(r, http.StatusGone)
+			resp.JSON(w, r, resp.Error("url has expired"))
+			return
+		}
+
+		canonical := link.Alias
+		if link.LinkedTo != "" {
+			canonical = link.LinkedTo
+		}
+
+		newAlias, err := linkRandom(r.Context(), log, aliasLinker, link, canonical, userID, opts)
+		if err != nil {
+			log.Error("failed to link alias", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to link alias"))
+			return
+		}
+
+		log.Info("alias linked", slog.String("alias", newAlias), slog.String("linked_to", canonical))
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Alias:    newAlias,
+			LinkedTo: canonical,
+			URL:      link.URL,
+		})
+	}
+}
+
+// errNoFreeAlias - за Attempts попыток не нашлось свободного alias
+var errNoFreeAlias = errors.New("no free random alias")
+
+// linkRandom сохраняет копию ссылки под случайным alias, перебирая новые при коллизиях
+func linkRandom(
+	ctx context.Context,
+	log *slog.Logger,
+	aliasLinker AliasLinker,
+	link storage.URL,
+	canonical string,
+	userID int64,
+	opts Options,
+) (string, error) {
+	urlOptions := storage.URLOptions{
+		ExpiresAt:      link.ExpiresAt,
+		Public:         link.Public,
+		Wildcard:       link.Wildcard,
+		ForwardQuery:   link.ForwardQuery,
+		RedirectStatus: link.RedirectStatus,
+		Tags:           link.Tags,
+		LinkedTo:       canonical,
+	}
+
+	for attempt := 0; attempt < opts.Attempts; attempt++ {
+		newAlias := opts.Grouping.Store(random.NewRandomStringFrom(opts.Alphabet, opts.AliasLength))
+		if opts.Checksum {
+			newAlias = checksum.Scheme{FoldCase: opts.ChecksumFoldCase}.Append(newAlias)
+		}
+		if opts.Blacklist.Contains(newAlias) {
+			continue
+		}
+
+		err := aliasLinker.SaveURL(ctx, log, link.URL, newAlias, userID, urlOptions)
+		if !storage.AliasUnavailable(err) {
+			return newAlias, err
+		}
+
+		log.Warn("random alias collision", slog.String("new_alias", newAlias))
+		opts.Metrics.Retry()
+	}
+
+	opts.Metrics.Exhaust()
+
+	return "", errNoFreeAlias
+}
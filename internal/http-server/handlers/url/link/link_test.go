@@ -0,0 +1,127 @@
+package link_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/link"
+	"url-shortener/internal/http-server/handlers/url/link/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func serve(t *testing.T, linker *mocks.AliasLinker, alias string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := chi.NewRouter()
+	r.Post("/url/{alias}/link", link.New(slogdiscard.NewDiscardLogger(), linker, link.Options{AliasLength: 8}))
+
+	req := httptest.NewRequest(http.MethodPost, "/url/"+alias+"/link", nil)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestLinkHandler(t *testing.T) {
+	const target = "https://example.com/target"
+
+	expired := time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		name     string
+		link     storage.URL
+		getErr   error
+		saveErrs []error
+		status   int
+		linkedTo string
+	}{
+		{
+			name:     "Success",
+			link:     storage.URL{Alias: "main", URL: target, UserID: 1, Public: true, Tags: []string{"work"}},
+			saveErrs: []error{nil},
+			status:   http.StatusOK,
+			linkedTo: "main",
+		},
+		{
+			name:     "Collision retried",
+			link:     storage.URL{Alias: "main", URL: target, UserID: 1},
+			saveErrs: []error{storage.ErrAliasTaken, storage.ErrURLExists, nil},
+			status:   http.StatusOK,
+			linkedTo: "main",
+		},
+		{
+			name:     "Foreign prefix retried",
+			link:     storage.URL{Alias: "main", URL: target, UserID: 1},
+			saveErrs: []error{storage.ErrPrefixTaken, nil},
+			status:   http.StatusOK,
+			linkedTo: "main",
+		},
+		{
+			name:     "Linked to a linked alias",
+			link:     storage.URL{Alias: "main", URL: target, UserID: 1, LinkedTo: "root"},
+			saveErrs: []error{nil},
+			status:   http.StatusOK,
+			linkedTo: "root",
+		},
+		{name: "Not found", getErr: storage.ErrURLNotFound, status: http.StatusNotFound},
+		{name: "Foreign link", link: storage.URL{Alias: "main", URL: target, UserID: 2}, status: http.StatusForbidden},
+		{name: "Expired", link: storage.URL{Alias: "main", URL: target, UserID: 1, ExpiresAt: &expired}, status: http.StatusGone},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			linkerMock := mocks.NewAliasLinker(t)
+			linkerMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			linkerMock.On("GetLink", mock.Anything, mock.Anything, "main").
+				Return(tc.link, tc.getErr).
+				Once()
+
+			var saved []string
+			for _, err := range tc.saveErrs {
+				linkerMock.On("SaveURL", mock.Anything, mock.Anything, target, mock.AnythingOfType("string"), int64(1), mock.Anything).
+					Run(func(args mock.Arguments) {
+						saved = append(saved, args.String(3))
+
+						// Новый alias копирует настройки ссылки и ссылается на основной
+						opts := args.Get(5).(storage.URLOptions)
+						require.Equal(t, tc.linkedTo, opts.LinkedTo)
+						require.Equal(t, tc.link.Public, opts.Public)
+						require.Equal(t, tc.link.Tags, opts.Tags)
+					}).
+					Return(err).
+					Once()
+			}
+
+			rr := serve(t, linkerMock, "main")
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var resp link.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Len(t, resp.Alias, 8)
+			require.Equal(t, saved[len(saved)-1], resp.Alias)
+			require.Equal(t, tc.linkedTo, resp.LinkedTo)
+			require.Equal(t, target, resp.URL)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/lib/urlnorm"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Alias    string `json:"alias,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AliasFinder
+type AliasFinder interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetAliasByURL(ctx context.Context, log *slog.Logger, url string, userID int64) (string, error)
+}
+
+// Options - параметры поиска ссылки по адресу
+type Options struct {
+	BaseURL string
+	// Normalization - те же правки адреса, что и при сохранении, иначе адрес не найдётся
+	Normalization urlnorm.Options
+}
+
+// New ищет у пользователя уже сокращённый адрес: GET /url/lookup?url=.
+// Нужен расширениям браузера, чтобы показать готовую ссылку вместо создания повтора.
+// Если адрес не сокращён, отвечает 404.
+func New(log *slog.Logger, aliasFinder AliasFinder, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.lookup.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		rawURL := strings.TrimSpace(r.URL.Query().Get("url"))
+		if err := validator.New().Var(rawURL, "required,url"); err != nil {
+			log.Info("invalid url", slog.String("url", rawURL))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("url must be a valid URL"))
+			return
+		}
+		target := opts.Normalization.Normalize(rawURL)
+
+		userID, _, errGetUser := aliasFinder.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		alias, err := aliasFinder.GetAliasByURL(r.Context(), log, target, userID)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			log.Info("url is not shortened yet", slog.String("url", target))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error("not found"))
+			return
+		case errors.Is(err, storage.ErrUnavailable):
+			log.Warn("url lookup is ambiguous", sl.Err(err))
+			render.Status(r, http.StatusServiceUnavailable)
+			resp.JSON(w, r, resp.Error("temporarily unavailable"))
+			return
+		case err != nil:
+			log.Error("failed to look up url", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to look up url"))
+			return
+		}
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Alias:    alias,
+			ShortURL: shorturl.Build(opts.BaseURL, alias),
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package lookup_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/lookup"
+	"url-shortener/internal/http-server/handlers/url/lookup/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/urlnorm"
+	"url-shortener/internal/storage"
+)
+
+func TestLookupHandler(t *testing.T) {
+	cases := []struct {
+		name string
+		url  string
+		// stored - адрес, который ищется в хранилище; пусто - запрос до хранилища не дошёл
+		stored  string
+		alias   string
+		mockErr error
+		status  int
+	}{
+		{
+			name:   "Already shortened",
+			url:    "https://Example.com/docs",
+			stored: "https://example.com/docs",
+			alias:  "docs",
+			status: http.StatusOK,
+		},
+		{
+			name:    "Not shortened",
+			url:     "https://example.com/new",
+			stored:  "https://example.com/new",
+			mockErr: storage.ErrURLNotFound,
+			status:  http.StatusNotFound,
+		},
+		{
+			name:    "Ambiguous",
+			url:     "https://example.com/new",
+			stored:  "https://example.com/new",
+			mockErr: storage.ErrUnavailable,
+			status:  http.StatusServiceUnavailable,
+		},
+		{
+			name:   "Missing url",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Invalid url",
+			url:    "not a url",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			finderMock := mocks.NewAliasFinder(t)
+			if tc.stored != "" {
+				finderMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				finderMock.On("GetAliasByURL", mock.Anything, mock.Anything, tc.stored, int64(1)).
+					Return(tc.alias, tc.mockErr).
+					Once()
+			}
+
+			handler := lookup.New(slogdiscard.NewDiscardLogger(), finderMock, lookup.Options{
+				BaseURL:       "https://sho.rt",
+				Normalization: urlnorm.Options{LowercaseHost: true},
+			})
+
+			req, err := http.NewRequest(http.MethodGet, "/url/lookup?url="+url.QueryEscape(tc.url), nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var resp lookup.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, "docs", resp.Alias)
+			require.Equal(t, "https://sho.rt/redirect/docs", resp.ShortURL)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package public
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/api/errorpage"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/botfilter"
+	"url-shortener/internal/lib/forward"
+	"url-shortener/internal/lib/interstitial"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LinkGetter
+type LinkGetter interface {
+	GetLink(ctx context.Context, log *slog.Logger, alias string) (storage.URL, error)
+	RecordClick(ctx context.Context, log *slog.Logger, alias string) error
+	GetUserSettings(ctx context.Context, log *slog.Logger, userID int64) (storage.UserSettings, error)
+}
+
+// previewTemplate - промежуточная страница перед переходом, если у владельца ссылки нет своей
+// (redirect_template в настройках). html/template экранирует адрес и в тексте, и в href,
+// поэтому подставить разметку через URL нельзя.
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Redirect preview</title></head>
+<body>
+<p>This link leads to:</p>
+<p><code>{{.URL}}</code></p>
+<p><a href="{{.URL}}" rel="noopener noreferrer">Continue</a></p>
+</body>
+</html>
+`))
+
+// Options - настройки обработчика публичных ссылок
+type Options struct {
+	// ErrorFormat - формат ошибок, если клиент не прислал Accept: json или html
+	ErrorFormat string
+	// CacheControl - заголовок Cache-Control для редиректов; пусто - не выставляется
+	CacheControl string
+	// Bots - переходы с User-Agent робота не учитываются в статистике; nil - учитываются все
+	Bots *botfilter.Filter
+	// Forward - параметры запроса и заголовки, которые добавляются к адресу любой публичной
+	// ссылки (ref, utm_source); остальные не передаются, если у ссылки нет forward_query
+	Forward forward.Rules
+}
+
+// robotsTag запрещает поисковикам индексировать короткие ссылки и страницы предпросмотра
+const robotsTag = "noindex"
+
+// New открывает публичную ссылку без авторизации: GET /r/{alias} перенаправляет
+// сразу, а с ?preview=1 показывает страницу с адресом назначения - свою страницу
+// владельца ссылки или страницу по умолчанию.
+// Для wildcard-ссылок маршрут /r/{alias}/* добавляет остаток пути и query к адресу.
+// Приватные, истёкшие и несуществующие ссылки одинаково отдают 404.
+// Все ответы помечаются X-Robots-Tag: noindex.
+// Ошибки отдаются в JSON или HTML в зависимости от Accept; без Accept - в формате opts.ErrorFormat.
+func New(log *slog.Logger, linkGetter LinkGetter, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.public.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		w.Header().Set("X-Robots-Tag", robotsTag)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		link, err := linkGetter.GetLink(r.Context(), log, alias)
+		if errors.Is(err, storage.ErrUnavailable) {
+			log.Warn("link lookup is ambiguous", slog.String("alias", alias), sl.Err(err))
+			errorpage.Write(w, r, http.StatusServiceUnavailable, "temporarily unavailable", opts.ErrorFormat)
+			return
+		}
+		if err != nil && !errors.Is(err, storage.ErrURLNotFound) {
+			log.Error("failed to get link", sl.Err(err))
+			errorpage.Write(w, r, http.StatusInternalServerError, "failed to get url", opts.ErrorFormat)
+			return
+		}
+		subPath := chi.URLParam(r, "*")
+		if err != nil || !link.Public || storage.Expired(link.ExpiresAt, time.Now()) || (subPath != "" && !link.Wildcard) {
+			log.Info("public link not found", slog.String("alias", alias))
+			errorpage.Write(w, r, http.StatusNotFound, "not found", opts.ErrorFormat)
+			return
+		}
+
+		query := r.URL.Query()
+		preview := query.Get("preview") == "1"
+		query.Del("preview")
+
+		dest, err := destination(link, subPath, query)
+		if err == nil {
+			dest, err = opts.Forward.Apply(dest, query, r.Header)
+		}
+		if err != nil {
+			log.Error("failed to build destination", slog.String("url", link.URL), sl.Err(err))
+			errorpage.Write(w, r, http.StatusInternalServerError, "failed to get url", opts.ErrorFormat)
+			return
+		}
+
+		if preview {
+			data := interstitial.Data{URL: dest, Alias: link.Alias}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+			if page, ok := customPreview(r.Context(), log, linkGetter, link.UserID, data); ok {
+				w.Header().Set("Content-Security-Policy", interstitial.CSP)
+				_, _ = w.Write(page)
+				return
+			}
+
+			if err := previewTemplate.Execute(w, data); err != nil {
+				log.Error("failed to render preview", sl.Err(err))
+			}
+			return
+		}
+
+		log.Info("got url", slog.String("url", dest))
+
+		// Статистика не должна мешать переходу
+		if opts.Bots.IsBot(r.UserAgent()) {
+			log.Debug("bot click is not counted", slog.String("user_agent", r.UserAgent()))
+		} else if err := linkGetter.RecordClick(r.Context(), log, alias); err != nil {
+			log.Error("failed to record click", sl.Err(err))
+		}
+
+		status := link.RedirectStatus
+		if status == 0 {
+			status = http.StatusFound
+		}
+
+		if opts.CacheControl != "" {
+			w.Header().Set("Cache-Control", opts.CacheControl)
+		}
+
+		http.Redirect(w, r, dest, status)
+	}
+}
+
+// customPreview отрисовывает промежуточную страницу владельца ссылки. false - своей страницы нет
+// или её не удалось отрисовать (шаблон сохранён до ужесточения проверок), тогда нужна страница по умолчанию.
+func customPreview(ctx context.Context, log *slog.Logger, linkGetter LinkGetter, userID int64, data interstitial.Data) ([]byte, bool) {
+	settings, err := linkGetter.GetUserSettings(ctx, log, userID)
+	if err != nil {
+		log.Error("failed to get owner settings", sl.Err(err))
+		return nil, false
+	}
+	if settings.RedirectTemplate == "" {
+		return nil, false
+	}
+
+	tmpl, err := interstitial.Parse(settings.RedirectTemplate)
+	if err != nil {
+		log.Warn("owner redirect template is invalid, using default", slog.Int64("user_id", userID), sl.Err(err))
+		return nil, false
+	}
+
+	var page bytes.Buffer
+	if err := tmpl.Execute(&page, data); err != nil {
+		log.Warn("failed to render owner redirect template, using default", slog.Int64("user_id", userID), sl.Err(err))
+		return nil, false
+	}
+
+	return page.Bytes(), true
+}
+
+// destination строит адрес перехода. Обычная ссылка ведёт ровно на сохранённый адрес;
+// wildcard-ссылка получает остаток пути, а wildcard и forward_query - параметры запроса.
+// Параметры, уже заданные в сохранённом адресе, не перезаписываются.
+func destination(link storage.URL, subPath string, query url.Values) (string, error) {
+	if !link.Wildcard && (!link.ForwardQuery || len(query) == 0) {
+		return link.URL, nil
+	}
+
+	u, err := url.Parse(link.URL)
+	if err != nil {
+		return "", err
+	}
+
+	if subPath != "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + subPath
+		u.RawPath = ""
+	}
+
+	if len(query) > 0 {
+		q := u.Query()
+		for key, values := range query {
+			if q.Has(key) {
+				continue
+			}
+			q[key] = values
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String(), nil
+}
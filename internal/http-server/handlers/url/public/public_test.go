@@ -0,0 +1,370 @@
+package public_test
+
+import (
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/public"
+	"url-shortener/internal/http-server/handlers/url/public/mocks"
+	"url-shortener/internal/lib/api/errorpage"
+	"url-shortener/internal/lib/botfilter"
+	"url-shortener/internal/lib/forward"
+	"url-shortener/internal/lib/interstitial"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func serve(t *testing.T, link storage.URL, mockErr error, target string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	return serveWith(t, public.Options{ErrorFormat: errorpage.FormatJSON}, link, mockErr, target)
+}
+
+func serveWith(t *testing.T, opts public.Options, link storage.URL, mockErr error, target string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	linkGetterMock := mocks.NewLinkGetter(t)
+	linkGetterMock.On("GetLink", mock.Anything, mock.Anything, "test_alias").
+		Return(link, mockErr).
+		Once()
+	// Переход учитывается только при редиректе
+	linkGetterMock.On("RecordClick", mock.Anything, mock.Anything, "test_alias").
+		Return(nil).
+		Maybe()
+	// Настройки владельца нужны только странице предпросмотра
+	linkGetterMock.On("GetUserSettings", mock.Anything, mock.Anything, link.UserID).
+		Return(storage.UserSettings{}, nil).
+		Maybe()
+
+	r := chi.NewRouter()
+	r.Get("/r/{alias}", public.New(slogdiscard.NewDiscardLogger(), linkGetterMock, opts))
+	r.Get("/r/{alias}/*", public.New(slogdiscard.NewDiscardLogger(), linkGetterMock, opts))
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestPublicHandler_Redirect(t *testing.T) {
+	link := storage.URL{Alias: "test_alias", URL: "https://www.google.com/", Public: true}
+
+	rr := serve(t, link, nil, "/r/test_alias")
+
+	require.Equal(t, http.StatusFound, rr.Code)
+	require.Equal(t, "https://www.google.com/", rr.Header().Get("Location"))
+	require.Equal(t, "noindex", rr.Header().Get("X-Robots-Tag"))
+}
+
+func TestPublicHandler_CacheControl(t *testing.T) {
+	link := storage.URL{Alias: "test_alias", URL: "https://www.google.com/", Public: true}
+
+	rr := serve(t, link, nil, "/r/test_alias")
+	require.Empty(t, rr.Header().Get("Cache-Control"))
+
+	rr = serveWith(t, public.Options{CacheControl: "private, no-cache"}, link, nil, "/r/test_alias")
+	require.Equal(t, http.StatusFound, rr.Code)
+	require.Equal(t, "private, no-cache", rr.Header().Get("Cache-Control"))
+}
+
+func TestPublicHandler_RedirectStatus(t *testing.T) {
+	link := storage.URL{
+		Alias:          "test_alias",
+		URL:            "https://www.google.com/",
+		Public:         true,
+		RedirectStatus: http.StatusMovedPermanently,
+	}
+
+	rr := serve(t, link, nil, "/r/test_alias")
+
+	require.Equal(t, http.StatusMovedPermanently, rr.Code)
+	require.Equal(t, "https://www.google.com/", rr.Header().Get("Location"))
+}
+
+func TestPublicHandler_Wildcard(t *testing.T) {
+	cases := []struct {
+		name   string
+		url    string
+		target string
+		want   string
+	}{
+		{
+			name:   "Sub-path",
+			url:    "https://example.com",
+			target: "/r/test_alias/foo/bar",
+			want:   "https://example.com/foo/bar",
+		},
+		{
+			name:   "Sub-path with query",
+			url:    "https://example.com/docs/",
+			target: "/r/test_alias/foo/bar?page=2&lang=en",
+			want:   "https://example.com/docs/foo/bar?lang=en&page=2",
+		},
+		{
+			name:   "Query merged with target query",
+			url:    "https://example.com/search?src=short",
+			target: "/r/test_alias/x?q=go",
+			want:   "https://example.com/search/x?q=go&src=short",
+		},
+		{
+			name:   "Alias only",
+			url:    "https://example.com/docs",
+			target: "/r/test_alias",
+			want:   "https://example.com/docs",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			link := storage.URL{Alias: "test_alias", URL: tc.url, Public: true, Wildcard: true}
+
+			rr := serve(t, link, nil, tc.target)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			require.Equal(t, tc.want, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestPublicHandler_ExactMatch(t *testing.T) {
+	link := storage.URL{Alias: "test_alias", URL: "https://example.com", Public: true}
+
+	rr := serve(t, link, nil, "/r/test_alias/foo")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = serve(t, link, nil, "/r/test_alias?page=2")
+	require.Equal(t, http.StatusFound, rr.Code)
+	require.Equal(t, "https://example.com", rr.Header().Get("Location"))
+}
+
+func TestPublicHandler_ForwardQuery(t *testing.T) {
+	cases := []struct {
+		name         string
+		url          string
+		forwardQuery bool
+		target       string
+		want         string
+	}{
+		{
+			name:         "Forward on",
+			url:          "https://example.com/page",
+			forwardQuery: true,
+			target:       "/r/test_alias?utm_source=mail",
+			want:         "https://example.com/page?utm_source=mail",
+		},
+		{
+			name:         "Forward on, merged with target query",
+			url:          "https://example.com/page?ref=short&lang=ru",
+			forwardQuery: true,
+			target:       "/r/test_alias?lang=en&utm_source=mail",
+			want:         "https://example.com/page?lang=ru&ref=short&utm_source=mail",
+		},
+		{
+			name:   "Forward off",
+			url:    "https://example.com/page?ref=short",
+			target: "/r/test_alias?utm_source=mail",
+			want:   "https://example.com/page?ref=short",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			link := storage.URL{Alias: "test_alias", URL: tc.url, Public: true, ForwardQuery: tc.forwardQuery}
+
+			rr := serve(t, link, nil, tc.target)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			require.Equal(t, tc.want, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestPublicHandler_Preview(t *testing.T) {
+	const dest = `https://example.com/?q="><script>alert(1)</script>`
+	link := storage.URL{Alias: "test_alias", URL: dest, Public: true}
+
+	rr := serve(t, link, nil, "/r/test_alias?preview=1")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+
+	body := rr.Body.String()
+	require.NotContains(t, body, "<script>")
+	require.Contains(t, body, html.EscapeString(dest))
+}
+
+func TestPublicHandler_PreviewTemplate(t *testing.T) {
+	const dest = `https://example.com/?q="><script>alert(1)</script>`
+
+	cases := []struct {
+		name     string
+		template string
+		custom   bool
+	}{
+		{name: "Custom template", template: `<h1>Acme</h1><a href="{{.URL}}">{{.URL}}</a>`, custom: true},
+		{name: "No template"},
+		// Шаблон мог попасть в базу до появления проверки: вместо него - страница по умолчанию
+		{name: "Malicious template", template: `<h1>Acme</h1><script>steal()</script>{{.URL}}`},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			link := storage.URL{Alias: "test_alias", URL: dest, Public: true, UserID: 7}
+
+			linkGetterMock := mocks.NewLinkGetter(t)
+			linkGetterMock.On("GetLink", mock.Anything, mock.Anything, "test_alias").
+				Return(link, nil).
+				Once()
+			linkGetterMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(7)).
+				Return(storage.UserSettings{RedirectTemplate: tc.template}, nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/r/{alias}", public.New(slogdiscard.NewDiscardLogger(), linkGetterMock, public.Options{}))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/r/test_alias?preview=1", nil))
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			body := rr.Body.String()
+			require.NotContains(t, body, "<script>")
+			require.Contains(t, body, html.EscapeString(dest))
+			if tc.custom {
+				require.Contains(t, body, "<h1>Acme</h1>")
+				require.Equal(t, interstitial.CSP, rr.Header().Get("Content-Security-Policy"))
+			} else {
+				require.NotContains(t, body, "Acme")
+				require.Empty(t, rr.Header().Get("Content-Security-Policy"))
+			}
+		})
+	}
+}
+
+func TestPublicHandler_NotFound(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+
+	cases := []struct {
+		name    string
+		link    storage.URL
+		mockErr error
+	}{
+		{
+			name: "Private link",
+			link: storage.URL{Alias: "test_alias", URL: "https://www.google.com/"},
+		},
+		{
+			name: "Expired link",
+			link: storage.URL{Alias: "test_alias", URL: "https://www.google.com/", Public: true, ExpiresAt: &past},
+		},
+		{
+			name:    "Unknown alias",
+			mockErr: storage.ErrURLNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			rr := serve(t, tc.link, tc.mockErr, "/r/test_alias")
+
+			require.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	}
+}
+
+func TestPublicHandler_BotClicks(t *testing.T) {
+	link := storage.URL{Alias: "test_alias", URL: "https://www.google.com/", Public: true}
+
+	cases := []struct {
+		name      string
+		userAgent string
+		counted   bool
+	}{
+		{
+			name:      "Bot",
+			userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		},
+		{
+			name:      "Browser",
+			userAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0",
+			counted:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			linkGetterMock := mocks.NewLinkGetter(t)
+			linkGetterMock.On("GetLink", mock.Anything, mock.Anything, "test_alias").
+				Return(link, nil).
+				Once()
+			if tc.counted {
+				linkGetterMock.On("RecordClick", mock.Anything, mock.Anything, "test_alias").
+					Return(nil).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/r/{alias}", public.New(slogdiscard.NewDiscardLogger(), linkGetterMock, public.Options{Bots: botfilter.Default()}))
+
+			req := httptest.NewRequest(http.MethodGet, "/r/test_alias", nil)
+			req.Header.Set("User-Agent", tc.userAgent)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			// Робот всё равно получает редирект
+			require.Equal(t, http.StatusFound, rr.Code)
+			require.Equal(t, "https://www.google.com/", rr.Header().Get("Location"))
+			if !tc.counted {
+				linkGetterMock.AssertNotCalled(t, "RecordClick", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestPublicHandler_ForwardParams(t *testing.T) {
+	link := storage.URL{Alias: "test_alias", URL: "https://example.com/page?utm_source=site", Public: true}
+
+	linkGetterMock := mocks.NewLinkGetter(t)
+	linkGetterMock.On("GetLink", mock.Anything, mock.Anything, "test_alias").
+		Return(link, nil).
+		Once()
+	linkGetterMock.On("RecordClick", mock.Anything, mock.Anything, "test_alias").
+		Return(nil).
+		Once()
+
+	opts := public.Options{Forward: forward.Rules{
+		{Query: "ref"},
+		{Query: "utm_source"},
+		{Header: "X-Campaign", Param: "campaign"},
+	}}
+
+	r := chi.NewRouter()
+	r.Get("/r/{alias}", public.New(slogdiscard.NewDiscardLogger(), linkGetterMock, opts))
+
+	req := httptest.NewRequest(http.MethodGet, "/r/test_alias?ref=partner%201&utm_source=mail&session=secret", nil)
+	req.Header.Set("X-Campaign", "spring")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	// ref и заголовок добавлены, utm_source цели не перезаписан, session не передан
+	require.Equal(t, http.StatusFound, rr.Code)
+	require.Equal(t, "https://example.com/page?utm_source=site&campaign=spring&ref=partner+1", rr.Header().Get("Location"))
+}
@@ -0,0 +1,100 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// Request - подтверждение очистки корзины текущим паролем
+type Request struct {
+	Password string `json:"password"`
+}
+
+type Response struct {
+	resp.Response
+	Count int64 `json:"count"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TrashPurger
+type TrashPurger interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	PurgeDeletedURLs(ctx context.Context, log *slog.Logger, userID int64) (int64, error)
+}
+
+// New окончательно удаляет все ссылки пользователя из корзины: DELETE /url/trash
+func New(log *slog.Logger, trashPurger TrashPurger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.purge.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		// Очистка корзины необратима, поэтому, как и при удалении аккаунта, требуем текущий пароль
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.Password == "" {
+			log.Error("password is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("password is required"))
+			return
+		}
+
+		userID, passwordHash, errGetUser := trashPurger.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		if !auth.CheckPasswordHash(req.Password, passwordHash) {
+			log.Error("wrong password on trash purge", slog.String("nickname", nickname))
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("wrong password"))
+			return
+		}
+
+		count, err := trashPurger.PurgeDeletedURLs(r.Context(), log, userID)
+		if err != nil {
+			log.Error("failed to purge deleted urls", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to purge deleted urls"))
+			return
+		}
+
+		log.Info("trash purged", slog.String("nickname", nickname), slog.Int64("count", count))
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Count:    count,
+		})
+	}
+}
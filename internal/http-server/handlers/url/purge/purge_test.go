@@ -0,0 +1,88 @@
+package purge_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"url-shortener/internal/http-server/handlers/url/purge"
+	"url-shortener/internal/http-server/handlers/url/purge/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestPurgeHandler(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		body      string
+		status    int
+		respError string
+		purges    bool
+	}{
+		{
+			name:   "Correct password",
+			body:   `{"password": "secret"}`,
+			status: http.StatusOK,
+			purges: true,
+		},
+		{
+			name:      "Wrong password",
+			body:      `{"password": "wrong"}`,
+			status:    http.StatusUnauthorized,
+			respError: "wrong password",
+		},
+		{
+			name:      "Missing password",
+			body:      "",
+			status:    http.StatusBadRequest,
+			respError: "password is required",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			purgerMock := mocks.NewTrashPurger(t)
+			if tc.body != "" {
+				purgerMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), string(hash), nil).
+					Once()
+			}
+			if tc.purges {
+				purgerMock.On("PurgeDeletedURLs", mock.Anything, mock.Anything, int64(1)).
+					Return(int64(3), nil).
+					Once()
+			}
+
+			handler := purge.New(slogdiscard.NewDiscardLogger(), purgerMock)
+
+			req, err := http.NewRequest(http.MethodDelete, "/url/trash", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			var body purge.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			require.Equal(t, tc.respError, body.Error)
+			if tc.purges {
+				require.Equal(t, int64(3), body.Count)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package qrbatch
+
+import (
+	"archive/zip"
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/qr"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/storage"
+)
+
+// maxLinks ограничивает количество QR-кодов в одном архиве
+const maxLinks = 100
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLLister
+type URLLister interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetURLsByUser(ctx context.Context, log *slog.Logger, userID int64) ([]storage.URL, error)
+}
+
+// New отдаёт ZIP-архив с QR-кодами {alias}.png для всех ссылок пользователя
+// или только для перечисленных в параметрах ?alias=a&alias=b.
+func New(log *slog.Logger, urlLister URLLister, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.qrbatch.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		userID, _, errGetUser := urlLister.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		urls, err := urlLister.GetURLsByUser(r.Context(), log, userID)
+		if err != nil {
+			log.Error("failed to list urls", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to list urls"))
+			return
+		}
+
+		urls = filter(urls, r.URL.Query()["alias"])
+
+		if len(urls) > maxLinks {
+			log.Error("too many links requested", slog.Int("count", len(urls)))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("too many links, filter them with the alias parameter"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="qr-codes.zip"`)
+
+		// Архив пишется потоково: после первого байта статус ответа уже не поменять,
+		// поэтому ошибки дальше только логируются
+		zw := zip.NewWriter(w)
+
+		for _, u := range urls {
+			png, err := qr.PNG(shorturl.Build(baseURL, u.Alias), qr.DefaultSize)
+			if err != nil {
+				log.Error("failed to render qr code", slog.String("alias", u.Alias), sl.Err(err))
+				return
+			}
+
+			f, err := zw.Create(u.Alias + ".png")
+			if err != nil {
+				log.Error("failed to add file to archive", slog.String("alias", u.Alias), sl.Err(err))
+				return
+			}
+
+			if _, err := f.Write(png); err != nil {
+				log.Error("failed to write qr code", slog.String("alias", u.Alias), sl.Err(err))
+				return
+			}
+		}
+
+		if err := zw.Close(); err != nil {
+			log.Error("failed to finish archive", sl.Err(err))
+			return
+		}
+
+		log.Info("qr codes archive sent", slog.Int("count", len(urls)))
+	}
+}
+
+// filter оставляет только ссылки с указанными alias. Пустой список - все ссылки.
+func filter(urls []storage.URL, aliases []string) []storage.URL {
+	if len(aliases) == 0 {
+		return urls
+	}
+
+	wanted := make(map[string]struct{}, len(aliases))
+	for _, a := range aliases {
+		wanted[a] = struct{}{}
+	}
+
+	res := make([]storage.URL, 0, len(aliases))
+	for _, u := range urls {
+		if _, ok := wanted[u.Alias]; ok {
+			res = append(res, u)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,93 @@
+package qrbatch_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/qrbatch"
+	"url-shortener/internal/http-server/handlers/url/qrbatch/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestQRBatchHandler(t *testing.T) {
+	urls := []storage.URL{
+		{Alias: "first", URL: "https://google.com"},
+		{Alias: "second", URL: "https://example.com"},
+		{Alias: "third", URL: "https://example.org"},
+	}
+
+	cases := []struct {
+		name  string
+		query string
+		files []string
+	}{
+		{
+			name:  "All links",
+			query: "",
+			files: []string{"first.png", "second.png", "third.png"},
+		},
+		{
+			name:  "Filtered links",
+			query: "?alias=first&alias=third&alias=unknown",
+			files: []string{"first.png", "third.png"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			listerMock := mocks.NewURLLister(t)
+			listerMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			listerMock.On("GetURLsByUser", mock.Anything, mock.Anything, int64(1)).
+				Return(urls, nil).
+				Once()
+
+			handler := qrbatch.New(slogdiscard.NewDiscardLogger(), listerMock, "http://localhost:8082")
+
+			req, err := http.NewRequest(http.MethodGet, "/url/qr-batch"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+
+			zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+			require.NoError(t, err)
+
+			var names []string
+			for _, f := range zr.File {
+				names = append(names, f.Name)
+
+				rc, err := f.Open()
+				require.NoError(t, err)
+				content, err := io.ReadAll(rc)
+				require.NoError(t, err)
+				require.NoError(t, rc.Close())
+
+				require.NotEmpty(t, content)
+				require.True(t, bytes.HasPrefix(content, []byte("\x89PNG")), "%s is not a PNG", f.Name)
+			}
+			sort.Strings(names)
+
+			require.Equal(t, tc.files, names)
+		})
+	}
+}
@@ -2,30 +2,62 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	slog "golang.org/x/exp/slog"
+
+	storage "url-shortener/internal/storage"
+)
 
 // URLGetter is an autogenerated mock type for the URLGetter type
 type URLGetter struct {
 	mock.Mock
 }
 
-// GetURL provides a mock function with given fields: alias
-func (_m *URLGetter) GetURL(alias string) (string, error) {
-	ret := _m.Called(alias)
+// GetLink provides a mock function with given fields: ctx, log, alias
+func (_m *URLGetter) GetLink(ctx context.Context, log *slog.Logger, alias string) (storage.URL, error) {
+	ret := _m.Called(ctx, log, alias)
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string) (storage.URL, error)); ok {
+		return rf(ctx, log, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string) storage.URL); ok {
+		r0 = rf(ctx, log, alias)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, string) error); ok {
+		r1 = rf(ctx, log, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetURL provides a mock function with given fields: ctx, log, alias, userID
+func (_m *URLGetter) GetURL(ctx context.Context, log *slog.Logger, alias string, userID int64) (string, error) {
+	ret := _m.Called(ctx, log, alias, userID)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(alias)
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string, int64) (string, error)); ok {
+		return rf(ctx, log, alias, userID)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string, int64) string); ok {
+		r0 = rf(ctx, log, alias, userID)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(alias)
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, string, int64) error); ok {
+		r1 = rf(ctx, log, alias, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -33,6 +65,51 @@ func (_m *URLGetter) GetURL(alias string) (string, error) {
 	return r0, r1
 }
 
+// GetUserByNickname provides a mock function with given fields: ctx, log, nickname
+func (_m *URLGetter) GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error) {
+	ret := _m.Called(ctx, log, nickname)
+
+	var r0 int64
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string) (int64, string, error)); ok {
+		return rf(ctx, log, nickname)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string) int64); ok {
+		r0 = rf(ctx, log, nickname)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, string) string); ok {
+		r1 = rf(ctx, log, nickname)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *slog.Logger, string) error); ok {
+		r2 = rf(ctx, log, nickname)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RecordClick provides a mock function with given fields: ctx, log, alias
+func (_m *URLGetter) RecordClick(ctx context.Context, log *slog.Logger, alias string) error {
+	ret := _m.Called(ctx, log, alias)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string) error); ok {
+		r0 = rf(ctx, log, alias)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewURLGetter interface {
 	mock.TestingT
 	Cleanup(func())
@@ -1,6 +1,7 @@
 package redirect
 
 import (
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -8,8 +9,12 @@ import (
 	"golang.org/x/net/context"
 	"net/http"
 
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
+	"url-shortener/internal/lib/api/errorpage"
 	resp "url-shortener/internal/lib/api/response"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
 )
 
 // URLGetter is an interface for getting url by alias.
@@ -17,10 +22,15 @@ import (
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
 type URLGetter interface {
 	GetURL(ctx context.Context, log *slog.Logger, alias string, userID int64) (string, error)
+	GetLink(ctx context.Context, log *slog.Logger, alias string) (storage.URL, error)
 	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	RecordClick(ctx context.Context, log *slog.Logger, alias string) error
 }
 
-func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
+// New перенаправляет владельца ссылки на сохранённый адрес с кодом редиректа ссылки, как /r/{alias};
+// без сохранённого кода - 302. Ошибки хранилища отдаются в JSON или HTML в зависимости от Accept;
+// без Accept - в формате errorFormat.
+func New(log *slog.Logger, urlGetter URLGetter, errorFormat string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.redirect.New"
 
@@ -29,32 +39,70 @@ func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		alias := chi.URLParam(r, "alias")
-		nickname := r.Context().Value("nickname").(string)
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
 
-		if nickname == "" || alias == "" {
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
 			log.Error("params is empty")
-			render.JSON(w, r, resp.Error("empty request"))
+			resp.JSON(w, r, resp.Error("empty request"))
 			return
 		}
 
 		userID, _, errGetUser := urlGetter.GetUserByNickname(r.Context(), log, nickname)
 		if errGetUser != nil {
 			log.Error("failed to get user by nickname", sl.Err(errGetUser))
-			render.JSON(w, r, resp.Error(errGetUser.Error()))
+			errorpage.Write(w, r, http.StatusInternalServerError, "failed to get user", errorFormat)
 			return
 		}
 
 		resURL, errGetURL := urlGetter.GetURL(r.Context(), log, alias, userID)
+		if errors.Is(errGetURL, storage.ErrURLExpired) {
+			log.Info("url expired", slog.String("alias", alias))
+			errorpage.Write(w, r, http.StatusGone, "url expired", errorFormat)
+			return
+		}
+		if access.Denied(errGetURL) {
+			access.DenyPage(w, r, log, nickname, alias, errorFormat)
+			return
+		}
+		if errors.Is(errGetURL, storage.ErrUnavailable) {
+			log.Warn("url lookup is ambiguous", slog.String("alias", alias), sl.Err(errGetURL))
+			errorpage.Write(w, r, http.StatusServiceUnavailable, "temporarily unavailable", errorFormat)
+			return
+		}
+		if errors.Is(errGetURL, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", alias))
+			errorpage.Write(w, r, http.StatusNotFound, "url not found", errorFormat)
+			return
+		}
 		if errGetURL != nil {
 			log.Error("failed to get url", sl.Err(errGetURL))
-			render.JSON(w, r, resp.Error(errGetURL.Error()))
+			errorpage.Write(w, r, http.StatusInternalServerError, "failed to get url", errorFormat)
 			return
 		}
 
 		log.Info("got url", slog.String("url", resURL))
 
+		// Статистика не должна мешать переходу
+		if err := urlGetter.RecordClick(r.Context(), log, alias); err != nil {
+			log.Error("failed to record click", sl.Err(err))
+		}
+
+		// Код редиректа хранится в ссылке; если её не удалось перечитать, переход всё равно выполняется
+		status := http.StatusFound
+		if link, err := urlGetter.GetLink(r.Context(), log, alias); err != nil {
+			log.Warn("failed to get redirect status", slog.String("alias", alias), sl.Err(err))
+		} else if link.RedirectStatus != 0 {
+			status = link.RedirectStatus
+		}
+
 		// redirect to found url
-		http.Redirect(w, r, resURL, http.StatusFound)
+		http.Redirect(w, r, resURL, status)
 	}
 }
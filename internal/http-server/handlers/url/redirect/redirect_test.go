@@ -1,49 +1,87 @@
 package redirect_test
 
 import (
+	"errors"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"url-shortener/internal/http-server/handlers/url/redirect"
 	"url-shortener/internal/http-server/handlers/url/redirect/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"url-shortener/internal/lib/api"
+	"url-shortener/internal/lib/api/errorpage"
 	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
 )
 
-func TestSaveHandler(t *testing.T) {
+// withNickname заменяет TokenAuthMiddleware в тестах
+func withNickname(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(auth.WithNickname(r.Context(), "user")))
+	})
+}
+
+func TestRedirectHandler(t *testing.T) {
 	cases := []struct {
 		name      string
 		alias     string
 		url       string
-		respError string
+		status    int
 		mockError error
 	}{
 		{
-			name:  "Success",
-			alias: "test_alias",
-			url:   "https://www.google.com/",
+			name:   "Success",
+			alias:  "test_alias",
+			url:    "https://www.google.com/",
+			status: http.StatusFound,
+		},
+		{
+			name:      "Expired",
+			alias:     "old_alias",
+			status:    http.StatusGone,
+			mockError: storage.ErrURLExpired,
 		},
 	}
 
 	for _, tc := range cases {
+		tc := tc
+
 		t.Run(tc.name, func(t *testing.T) {
 			urlGetterMock := mocks.NewURLGetter(t)
-
-			if tc.respError == "" || tc.mockError != nil {
-				urlGetterMock.On("GetURL", tc.alias).
-					Return(tc.url, tc.mockError).Once()
+			urlGetterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).Once()
+			urlGetterMock.On("GetURL", mock.Anything, mock.Anything, tc.alias, int64(1)).
+				Return(tc.url, tc.mockError).Once()
+			if tc.mockError == nil {
+				urlGetterMock.On("RecordClick", mock.Anything, mock.Anything, tc.alias).
+					Return(nil).Once()
+				urlGetterMock.On("GetLink", mock.Anything, mock.Anything, tc.alias).
+					Return(storage.URL{Alias: tc.alias, URL: tc.url}, nil).Once()
 			}
 
 			r := chi.NewRouter()
-			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock))
+			r.With(withNickname).Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, errorpage.FormatJSON))
 
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 
+			if tc.status != http.StatusFound {
+				resp, err := http.Get(ts.URL + "/" + tc.alias)
+				require.NoError(t, err)
+				defer resp.Body.Close()
+
+				assert.Equal(t, tc.status, resp.StatusCode)
+				return
+			}
+
 			redirectedToURL, err := api.GetRedirect(ts.URL + "/" + tc.alias)
 			require.NoError(t, err)
 
@@ -52,3 +90,167 @@ func TestSaveHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRedirectHandler_ErrorFormat(t *testing.T) {
+	cases := []struct {
+		name        string
+		accept      string
+		def         string
+		contentType string
+	}{
+		{name: "JSON Accept", accept: "application/json", def: errorpage.FormatHTML, contentType: "application/json"},
+		{name: "HTML Accept", accept: "text/html", def: errorpage.FormatJSON, contentType: "text/html"},
+		{name: "No Accept, JSON default", def: errorpage.FormatJSON, contentType: "application/json"},
+		{name: "No Accept, HTML default", def: errorpage.FormatHTML, contentType: "text/html"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).Once()
+			urlGetterMock.On("GetURL", mock.Anything, mock.Anything, "missing", int64(1)).
+				Return("", storage.ErrURLNotFound).Once()
+
+			r := chi.NewRouter()
+			r.With(withNickname).Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, tc.def))
+
+			req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusNotFound, rr.Code)
+			require.True(t, strings.HasPrefix(rr.Header().Get("Content-Type"), tc.contentType), rr.Header().Get("Content-Type"))
+			require.Contains(t, rr.Body.String(), "url not found")
+		})
+	}
+}
+
+func TestRedirectHandler_NotFound(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "Both stores not found", err: storage.ErrURLNotFound, status: http.StatusNotFound},
+		{
+			name:   "Transient error then not found",
+			err:    errors.Join(storage.ErrUnavailable, errors.New("database is locked")),
+			status: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).Once()
+			urlGetterMock.On("GetURL", mock.Anything, mock.Anything, "missing", int64(1)).
+				Return("", tc.err).Once()
+
+			r := chi.NewRouter()
+			r.With(withNickname).Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, errorpage.FormatJSON))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+			require.Equal(t, tc.status, rr.Code)
+		})
+	}
+}
+
+func TestRedirectHandler_DottedAlias(t *testing.T) {
+	cases := []struct {
+		name       string
+		middleware []func(http.Handler) http.Handler
+		path       string
+		// alias - с каким alias маршрут вызывает обработчик
+		alias string
+	}{
+		{name: "Plain router", path: "/v1.2", alias: "v1.2"},
+		{name: "Strip slashes", middleware: []func(http.Handler) http.Handler{middleware.StripSlashes}, path: "/v1.2/", alias: "v1.2"},
+		// Поэтому URLFormat выключен по умолчанию, а при включённом alias с точкой не сохраняются
+		{name: "URLFormat truncates", middleware: []func(http.Handler) http.Handler{middleware.URLFormat}, path: "/v1.2", alias: "v1"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).Once()
+			urlGetterMock.On("GetURL", mock.Anything, mock.Anything, tc.alias, int64(1)).
+				Return("https://example.com/release", nil).Once()
+			urlGetterMock.On("RecordClick", mock.Anything, mock.Anything, tc.alias).
+				Return(nil).Once()
+			urlGetterMock.On("GetLink", mock.Anything, mock.Anything, tc.alias).
+				Return(storage.URL{Alias: tc.alias}, nil).Once()
+
+			r := chi.NewRouter()
+			r.Use(tc.middleware...)
+			r.With(withNickname).Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, errorpage.FormatJSON))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			require.Equal(t, "https://example.com/release", rr.Header().Get("Location"))
+		})
+	}
+}
+
+// Код редиректа берётся из ссылки, как у публичного /r/{alias}
+func TestRedirectHandler_RedirectStatus(t *testing.T) {
+	cases := []struct {
+		name    string
+		link    storage.URL
+		linkErr error
+		status  int
+	}{
+		{name: "Permanent link", link: storage.URL{Alias: "docs", RedirectStatus: http.StatusMovedPermanently}, status: http.StatusMovedPermanently},
+		{name: "No stored status", link: storage.URL{Alias: "docs"}, status: http.StatusFound},
+		{name: "Link reread fails", linkErr: errors.New("db is down"), status: http.StatusFound},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).Once()
+			urlGetterMock.On("GetURL", mock.Anything, mock.Anything, "docs", int64(1)).
+				Return("https://example.com/docs", nil).Once()
+			urlGetterMock.On("RecordClick", mock.Anything, mock.Anything, "docs").
+				Return(nil).Once()
+			urlGetterMock.On("GetLink", mock.Anything, mock.Anything, "docs").
+				Return(tc.link, tc.linkErr).Once()
+
+			r := chi.NewRouter()
+			r.With(withNickname).Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, errorpage.FormatJSON))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+			require.Equal(t, tc.status, rr.Code)
+			require.Equal(t, "https://example.com/docs", rr.Header().Get("Location"))
+		})
+	}
+}
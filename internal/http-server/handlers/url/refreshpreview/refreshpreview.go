@@ -0,0 +1,153 @@
+package refreshpreview
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/preview"
+	"url-shortener/internal/storage"
+)
+
+// CodePreviewUnavailable - страницу-цель не удалось загрузить или в ней нет метаданных;
+// сохранённое превью не меняется
+const CodePreviewUnavailable = "preview_unavailable"
+
+type Response struct {
+	resp.Response
+	Alias   string           `json:"alias,omitempty"`
+	Preview *storage.Preview `json:"preview,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=PreviewStore
+type PreviewStore interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetURLs(ctx context.Context, log *slog.Logger, aliases []string, userID int64) ([]storage.URL, error)
+	SetURLPreview(ctx context.Context, log *slog.Logger, alias string, userID int64, preview storage.Preview) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=MetadataFetcher
+type MetadataFetcher interface {
+	Metadata(ctx context.Context, target string) (preview.Metadata, error)
+}
+
+// Options - параметры обновления превью
+type Options struct {
+	// Timeout - предел на загрузку страницы-цели; 0 - без отдельного предела
+	Timeout time.Duration
+}
+
+// New заново загружает метаданные страницы-цели и сохраняет их: POST /url/{alias}/refresh-preview.
+// Доступно только владельцу; чужой alias неотличим от несуществующего. Частоту ограничивает
+// middleware маршрута, чтобы загрузчик нельзя было использовать для нагрузки на чужие сайты.
+func New(log *slog.Logger, store PreviewStore, fetcher MetadataFetcher, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.refreshpreview.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		userID, _, errGetUser := store.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		owned, err := store.GetURLs(r.Context(), log, []string{alias}, userID)
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get url"))
+			return
+		}
+		if len(owned) == 0 {
+			log.Info("url not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error("url not found"))
+			return
+		}
+		link := owned[0]
+
+		meta, err := fetchMetadata(r.Context(), fetcher, link.URL, opts.Timeout)
+		if err != nil {
+			log.Warn("failed to fetch preview metadata", slog.String("alias", alias), sl.Err(err))
+			render.Status(r, http.StatusBadGateway)
+			resp.JSON(w, r, resp.ErrorWithCode("failed to fetch target page", CodePreviewUnavailable))
+			return
+		}
+
+		refreshed := storage.Preview{
+			Title:       meta.Title,
+			Description: meta.Description,
+			Image:       meta.Image,
+			FetchedAt:   time.Now().UTC(),
+		}
+
+		err = store.SetURLPreview(r.Context(), log, link.Alias, userID, refreshed)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			// Ссылку удалили, пока загружалась страница
+			log.Info("url not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error("url not found"))
+			return
+		case access.Denied(err):
+			access.Deny(w, r, log, nickname, alias)
+			return
+		case err != nil:
+			log.Error("failed to save preview", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to save preview"))
+			return
+		}
+
+		log.Info("url preview refreshed", slog.String("alias", alias))
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Alias:    link.Alias,
+			Preview:  &refreshed,
+		})
+	}
+}
+
+// fetchMetadata ограничивает загрузку страницы, чтобы медленная цель не держала запрос
+func fetchMetadata(ctx context.Context, fetcher MetadataFetcher, target string, timeout time.Duration) (preview.Metadata, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return fetcher.Metadata(ctx, target)
+}
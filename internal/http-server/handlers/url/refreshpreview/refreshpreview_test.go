@@ -0,0 +1,120 @@
+package refreshpreview_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/refreshpreview"
+	"url-shortener/internal/http-server/handlers/url/refreshpreview/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/linkcheck"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/preview"
+	"url-shortener/internal/storage"
+)
+
+func serve(t *testing.T, store refreshpreview.PreviewStore, fetcher refreshpreview.MetadataFetcher, alias string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := chi.NewRouter()
+	r.Post("/url/{alias}/refresh-preview", refreshpreview.New(slogdiscard.NewDiscardLogger(), store, fetcher, refreshpreview.Options{
+		Timeout: time.Second,
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/url/"+alias+"/refresh-preview", nil)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestRefreshPreview_TitleChanges(t *testing.T) {
+	// Заголовок страницы меняется между запросами
+	var version atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<html><head><title>Version %d</title><meta name="description" content="Page"></head></html>`, version.Add(1))
+	}))
+	t.Cleanup(target.Close)
+
+	var stored []storage.Preview
+	store := mocks.NewPreviewStore(t)
+	store.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil)
+	store.On("GetURLs", mock.Anything, mock.Anything, []string{"test_alias"}, int64(1)).
+		Return([]storage.URL{{Alias: "test_alias", URL: target.URL}}, nil)
+	store.On("SetURLPreview", mock.Anything, mock.Anything, "test_alias", int64(1), mock.Anything).
+		Run(func(args mock.Arguments) {
+			stored = append(stored, args.Get(4).(storage.Preview))
+		}).
+		Return(nil).
+		Twice()
+
+	fetcher := preview.New(linkcheck.NewClient(time.Second, 3, true), 1<<20)
+
+	for i, want := range []string{"Version 1", "Version 2"} {
+		rr := serve(t, store, fetcher, "test_alias")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var resp refreshpreview.Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, want, resp.Preview.Title)
+		require.Equal(t, "Page", resp.Preview.Description)
+
+		require.Len(t, stored, i+1)
+		require.Equal(t, want, stored[i].Title)
+		require.False(t, stored[i].FetchedAt.IsZero())
+	}
+}
+
+func TestRefreshPreview_Errors(t *testing.T) {
+	t.Run("Not owned", func(t *testing.T) {
+		store := mocks.NewPreviewStore(t)
+		store.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+			Return(int64(1), "", nil).
+			Once()
+		store.On("GetURLs", mock.Anything, mock.Anything, []string{"other"}, int64(1)).
+			Return([]storage.URL{}, nil).
+			Once()
+
+		rr := serve(t, store, mocks.NewMetadataFetcher(t), "other")
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Target unavailable", func(t *testing.T) {
+		store := mocks.NewPreviewStore(t)
+		store.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+			Return(int64(1), "", nil).
+			Once()
+		store.On("GetURLs", mock.Anything, mock.Anything, []string{"test_alias"}, int64(1)).
+			Return([]storage.URL{{Alias: "test_alias", URL: "https://example.com"}}, nil).
+			Once()
+
+		fetcher := mocks.NewMetadataFetcher(t)
+		fetcher.On("Metadata", mock.Anything, "https://example.com").
+			Return(preview.Metadata{}, preview.ErrBadStatus).
+			Once()
+
+		rr := serve(t, store, fetcher, "test_alias")
+
+		// Сохранённое превью не затирается
+		require.Equal(t, http.StatusBadGateway, rr.Code)
+		store.AssertNotCalled(t, "SetURLPreview", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+		var resp refreshpreview.Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, refreshpreview.CodePreviewUnavailable, resp.Code)
+	})
+}
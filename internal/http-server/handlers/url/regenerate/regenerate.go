@@ -0,0 +1,178 @@
+package regenerate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/aliasgroup"
+	"url-shortener/internal/lib/aliaslimit"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/blacklist"
+	"url-shortener/internal/lib/checksum"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/metrics"
+	"url-shortener/internal/lib/random"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Alias    string `json:"alias,omitempty"`
+	OldAlias string `json:"old_alias,omitempty"`
+}
+
+// Значения по умолчанию для генерации alias
+const (
+	defaultAliasLength = 6
+	defaultAttempts    = 10
+)
+
+// Options - настройки генерации нового alias, те же, что при сохранении ссылки
+type Options struct {
+	// AliasLength - длина нового alias
+	AliasLength int
+	// Alphabet - символы alias; пусто - random.DefaultAlphabet
+	Alphabet string
+	// Checksum - к alias добавляется контрольный символ
+	Checksum bool
+	// ChecksumFoldCase - контрольный символ не зависит от регистра, для alias без учёта регистра
+	ChecksumFoldCase bool
+	// Blacklist - запрещённые слова
+	Blacklist *blacklist.Blacklist
+	// Attempts - сколько alias пробуется, прежде чем вернуть ошибку
+	Attempts int
+	// AliasCeiling - общий предел длины alias вместе с контрольным символом; 0 - без предела
+	AliasCeiling int
+	// Grouping - разбиение alias на группы через дефис
+	Grouping aliasgroup.Grouping
+	// Metrics - счётчики коллизий и исчерпания случайных alias; nil - не считаются
+	Metrics *metrics.AliasGeneration
+}
+
+func (o Options) withDefaults() Options {
+	if o.AliasLength <= 0 {
+		o.AliasLength = defaultAliasLength
+	}
+	o.AliasLength = aliaslimit.Limit{Max: o.AliasCeiling, Checksum: o.Checksum, Grouping: o.Grouping}.Clamp(o.AliasLength)
+	if o.Alphabet == "" {
+		o.Alphabet = random.DefaultAlphabet
+	}
+	if o.Attempts <= 0 {
+		o.Attempts = defaultAttempts
+	}
+
+	return o
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AliasRenamer
+type AliasRenamer interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	RenameAlias(ctx context.Context, log *slog.Logger, alias, newAlias string, userID int64) error
+}
+
+// New заменяет alias ссылки владельца на новый случайный: POST /url/{alias}/regenerate.
+// Адрес, метки и статистика переходов сохраняются, старый alias перестаёт открываться.
+func New(log *slog.Logger, aliasRenamer AliasRenamer, opts Options) http.HandlerFunc {
+	opts = opts.withDefaults()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.regenerate.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		userID, _, errGetUser := aliasRenamer.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		newAlias, err := renameToRandom(r.Context(), log, aliasRenamer, alias, userID, opts)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			log.Info("url not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error("not found"))
+			return
+		case access.Denied(err):
+			access.Deny(w, r, log, nickname, alias)
+			return
+		case err != nil:
+			log.Error("failed to regenerate alias", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to regenerate alias"))
+			return
+		}
+
+		log.Info("alias regenerated", slog.String("alias", alias), slog.String("new_alias", newAlias))
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Alias:    newAlias,
+			OldAlias: alias,
+		})
+	}
+}
+
+// errNoFreeAlias - за Attempts попыток не нашлось свободного alias
+var errNoFreeAlias = errors.New("no free random alias")
+
+// renameToRandom переименовывает ссылку в случайный alias, перебирая новые при коллизиях
+func renameToRandom(
+	ctx context.Context,
+	log *slog.Logger,
+	aliasRenamer AliasRenamer,
+	alias string,
+	userID int64,
+	opts Options,
+) (string, error) {
+	for attempt := 0; attempt < opts.Attempts; attempt++ {
+		newAlias := opts.Grouping.Store(random.NewRandomStringFrom(opts.Alphabet, opts.AliasLength))
+		if opts.Checksum {
+			newAlias = checksum.Scheme{FoldCase: opts.ChecksumFoldCase}.Append(newAlias)
+		}
+		if newAlias == alias || opts.Blacklist.Contains(newAlias) {
+			continue
+		}
+
+		err := aliasRenamer.RenameAlias(ctx, log, alias, newAlias, userID)
+		if !storage.AliasUnavailable(err) {
+			return newAlias, err
+		}
+
+		log.Warn("random alias collision", slog.String("new_alias", newAlias))
+		opts.Metrics.Retry()
+	}
+
+	opts.Metrics.Exhaust()
+
+	return "", errNoFreeAlias
+}
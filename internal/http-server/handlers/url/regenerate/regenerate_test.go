@@ -0,0 +1,110 @@
+package regenerate_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/regenerate"
+	"url-shortener/internal/http-server/handlers/url/regenerate/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func serve(t *testing.T, renamer *mocks.AliasRenamer) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := chi.NewRouter()
+	r.Post("/url/{alias}/regenerate", regenerate.New(slogdiscard.NewDiscardLogger(), renamer, regenerate.Options{AliasLength: 8}))
+
+	req := httptest.NewRequest(http.MethodPost, "/url/old_alias/regenerate", nil)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestRegenerateHandler(t *testing.T) {
+	cases := []struct {
+		name   string
+		errs   []error
+		status int
+	}{
+		{name: "Success", errs: []error{nil}, status: http.StatusOK},
+		{name: "Collision retried", errs: []error{storage.ErrAliasTaken, storage.ErrURLExists, nil}, status: http.StatusOK},
+		{name: "Foreign prefix retried", errs: []error{storage.ErrPrefixTaken, nil}, status: http.StatusOK},
+		{name: "Not found", errs: []error{storage.ErrURLNotFound}, status: http.StatusNotFound},
+		{name: "Foreign link", errs: []error{storage.ErrUnauthorized}, status: http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			renamerMock := mocks.NewAliasRenamer(t)
+			renamerMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+
+			var newAliases []string
+			for _, err := range tc.errs {
+				renamerMock.On("RenameAlias", mock.Anything, mock.Anything, "old_alias", mock.AnythingOfType("string"), int64(1)).
+					Run(func(args mock.Arguments) { newAliases = append(newAliases, args.String(3)) }).
+					Return(err).
+					Once()
+			}
+
+			rr := serve(t, renamerMock)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var resp regenerate.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, "old_alias", resp.OldAlias)
+			require.Len(t, resp.Alias, 8)
+			require.Equal(t, newAliases[len(newAliases)-1], resp.Alias)
+		})
+	}
+}
+
+func TestRegenerateHandler_AliasCeiling(t *testing.T) {
+	renamerMock := mocks.NewAliasRenamer(t)
+	renamerMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	renamerMock.On("RenameAlias", mock.Anything, mock.Anything, "old_alias", mock.AnythingOfType("string"), int64(1)).
+		Return(nil).
+		Once()
+
+	r := chi.NewRouter()
+	r.Post("/url/{alias}/regenerate", regenerate.New(slogdiscard.NewDiscardLogger(), renamerMock, regenerate.Options{
+		AliasLength:  12,
+		Checksum:     true,
+		AliasCeiling: 8,
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/url/old_alias/regenerate", nil)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp regenerate.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp.Alias, 8)
+}
@@ -0,0 +1,138 @@
+package resolve
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// maxAliases ограничивает размер одного пакета
+const maxAliases = 100
+
+type Request struct {
+	Aliases []string `json:"aliases" validate:"required,min=1,dive,required"`
+}
+
+// Result - адрес ссылки или причина, по которой его не удалось получить
+type Result struct {
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Results map[string]Result `json:"results,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLResolver
+type URLResolver interface {
+	GetURLs(ctx context.Context, log *slog.Logger, aliases []string, userID int64) ([]storage.URL, error)
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+}
+
+// New возвращает адреса нескольких ссылок пользователя за один запрос.
+// Чужие и несуществующие alias одинаково получают ошибку "not found".
+func New(log *slog.Logger, urlResolver URLResolver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.resolve.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		if len(req.Aliases) > maxAliases {
+			log.Error("too many aliases requested", slog.Int("count", len(req.Aliases)))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("too many aliases"))
+			return
+		}
+
+		userID, _, errGetUser := urlResolver.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		urls, err := urlResolver.GetURLs(r.Context(), log, req.Aliases, userID)
+		if err != nil {
+			log.Error("failed to resolve urls", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to resolve urls"))
+			return
+		}
+
+		found := make(map[string]storage.URL, len(urls))
+		for _, u := range urls {
+			found[u.Alias] = u
+		}
+
+		now := time.Now()
+		results := make(map[string]Result, len(req.Aliases))
+		for _, alias := range req.Aliases {
+			u, ok := found[alias]
+			switch {
+			case !ok:
+				results[alias] = Result{Error: "not found"}
+			case storage.Expired(u.ExpiresAt, now):
+				results[alias] = Result{Error: "expired"}
+			default:
+				results[alias] = Result{URL: u.URL}
+			}
+		}
+
+		log.Info("urls resolved", slog.Int("requested", len(req.Aliases)), slog.Int("found", len(urls)))
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Results:  results,
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package resolve_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/resolve"
+	"url-shortener/internal/http-server/handlers/url/resolve/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func newRequest(t *testing.T, body string) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, "/url/resolve", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+
+	return req.WithContext(auth.WithNickname(req.Context(), "user"))
+}
+
+func TestResolveHandler(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	aliases := []string{"owned", "foreign", "missing", "expired"}
+
+	resolverMock := mocks.NewURLResolver(t)
+	resolverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	// Хранилище возвращает только ссылки пользователя: чужой и несуществующий alias в ответ не попадают
+	resolverMock.On("GetURLs", mock.Anything, mock.Anything, aliases, int64(1)).
+		Return([]storage.URL{
+			{Alias: "owned", URL: "https://google.com"},
+			{Alias: "expired", URL: "https://example.com", ExpiresAt: &past},
+		}, nil).
+		Once()
+
+	handler := resolve.New(slogdiscard.NewDiscardLogger(), resolverMock)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newRequest(t, `{"aliases": ["owned", "foreign", "missing", "expired"]}`))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp resolve.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	require.Equal(t, map[string]resolve.Result{
+		"owned":   {URL: "https://google.com"},
+		"foreign": {Error: "not found"},
+		"missing": {Error: "not found"},
+		"expired": {Error: "expired"},
+	}, resp.Results)
+}
+
+func TestResolveHandler_BatchTooLarge(t *testing.T) {
+	aliases := make([]string, 101)
+	for i := range aliases {
+		aliases[i] = fmt.Sprintf("%q", fmt.Sprintf("a%d", i))
+	}
+
+	handler := resolve.New(slogdiscard.NewDiscardLogger(), mocks.NewURLResolver(t))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newRequest(t, `{"aliases": [`+strings.Join(aliases, ",")+`]}`))
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,91 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Alias string `json:"alias,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLRestorer
+type URLRestorer interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	RestoreURL(ctx context.Context, log *slog.Logger, alias string, userID int64, deletedAfter time.Time) error
+}
+
+// New возвращает ссылку владельца из корзины (POST /url/{alias}/restore).
+// Ссылку, удалённую раньше чем restoreWindow назад, восстановить нельзя - для неё 404.
+func New(log *slog.Logger, urlRestorer URLRestorer, restoreWindow time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.restore.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		userID, _, errGetUser := urlRestorer.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		err := urlRestorer.RestoreURL(r.Context(), log, alias, userID, time.Now().Add(-restoreWindow))
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			log.Info("url not found in trash", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error(access.NotFoundMessage))
+			return
+		case access.Denied(err):
+			access.Deny(w, r, log, nickname, alias)
+			return
+		case err != nil:
+			log.Error("failed to restore url", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to restore url"))
+			return
+		}
+
+		log.Info("url restored", slog.String("alias", alias))
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Alias:    alias,
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package restore_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/restore"
+	"url-shortener/internal/http-server/handlers/url/restore/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+const window = 72 * time.Hour
+
+func TestRestoreHandler(t *testing.T) {
+	cases := []struct {
+		name    string
+		mockErr error
+		status  int
+	}{
+		{
+			name:   "Restore deleted link",
+			status: http.StatusOK,
+		},
+		{
+			name:    "Not in trash or restore window expired",
+			mockErr: storage.ErrURLNotFound,
+			status:  http.StatusNotFound,
+		},
+		{
+			name:    "Not owned",
+			mockErr: storage.ErrUnauthorized,
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "Storage error",
+			mockErr: errors.New("db is down"),
+			status:  http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var deletedAfter time.Time
+			restorerMock := mocks.NewURLRestorer(t)
+			restorerMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			restorerMock.On("RestoreURL", mock.Anything, mock.Anything, "test_alias", int64(1), mock.AnythingOfType("time.Time")).
+				Run(func(args mock.Arguments) { deletedAfter = args.Get(4).(time.Time) }).
+				Return(tc.mockErr).
+				Once()
+
+			r := chi.NewRouter()
+			r.Post("/url/{alias}/restore", restore.New(slogdiscard.NewDiscardLogger(), restorerMock, window))
+
+			req, err := http.NewRequest(http.MethodPost, "/url/test_alias/restore", nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			before := time.Now()
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			// Восстановить можно только ссылку, удалённую в пределах окна
+			require.WithinDuration(t, before.Add(-window), deletedAfter, 2*time.Second)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var resp restore.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, "test_alias", resp.Alias)
+		})
+	}
+}
+
+func TestRestoreHandler_Unauthorized(t *testing.T) {
+	r := chi.NewRouter()
+	r.Post("/url/{alias}/restore", restore.New(slogdiscard.NewDiscardLogger(), nil, window))
+
+	req := httptest.NewRequest(http.MethodPost, "/url/test_alias/restore", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+}
@@ -2,30 +2,83 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	slog "golang.org/x/exp/slog"
+
+	storage "url-shortener/internal/storage"
+)
 
 // URLSaver is an autogenerated mock type for the URLSaver type
 type URLSaver struct {
 	mock.Mock
 }
 
-// SaveURL provides a mock function with given fields: urlToSave, alias
-func (_m *URLSaver) SaveURL(urlToSave string, alias string, userID string) (int64, error) {
-	ret := _m.Called(urlToSave, alias)
+// SaveURL provides a mock function with given fields: ctx, log, urlToSave, alias, userID, opts
+func (_m *URLSaver) SaveURL(ctx context.Context, log *slog.Logger, urlToSave string, alias string, userID int64, opts storage.URLOptions) error {
+	ret := _m.Called(ctx, log, urlToSave, alias, userID, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string, string, int64, storage.URLOptions) error); ok {
+		r0 = rf(ctx, log, urlToSave, alias, userID, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetUserByNickname provides a mock function with given fields: ctx, log, nickname
+func (_m *URLSaver) GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error) {
+	ret := _m.Called(ctx, log, nickname)
 
 	var r0 int64
-	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (int64, error)); ok {
-		return rf(urlToSave, alias)
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string) (int64, string, error)); ok {
+		return rf(ctx, log, nickname)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) int64); ok {
-		r0 = rf(urlToSave, alias)
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string) int64); ok {
+		r0 = rf(ctx, log, nickname)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(urlToSave, alias)
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, string) string); ok {
+		r1 = rf(ctx, log, nickname)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *slog.Logger, string) error); ok {
+		r2 = rf(ctx, log, nickname)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetUserSettings provides a mock function with given fields: ctx, log, userID
+func (_m *URLSaver) GetUserSettings(ctx context.Context, log *slog.Logger, userID int64) (storage.UserSettings, error) {
+	ret := _m.Called(ctx, log, userID)
+
+	var r0 storage.UserSettings
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int64) (storage.UserSettings, error)); ok {
+		return rf(ctx, log, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int64) storage.UserSettings); ok {
+		r0 = rf(ctx, log, userID)
+	} else {
+		r0 = ret.Get(0).(storage.UserSettings)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, int64) error); ok {
+		r1 = rf(ctx, log, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
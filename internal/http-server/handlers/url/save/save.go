@@ -3,40 +3,212 @@ package save
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 	"golang.org/x/exp/slog"
 
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/aliasgroup"
+	"url-shortener/internal/lib/aliaslimit"
+	"url-shortener/internal/lib/api/access"
 	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/base62"
+	"url-shortener/internal/lib/blacklist"
+	"url-shortener/internal/lib/checksum"
+	"url-shortener/internal/lib/hashalias"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/metrics"
 	"url-shortener/internal/lib/random"
+	tagsutil "url-shortener/internal/lib/tags"
+	"url-shortener/internal/lib/ttllimit"
+	"url-shortener/internal/lib/urlnorm"
 	"url-shortener/internal/storage"
 )
 
 type Request struct {
 	URL   string `json:"url" validate:"required,url"`
 	Alias string `json:"alias,omitempty"`
+	// TTLSeconds - срок жизни ссылки в секундах. Не задан - действует срок по умолчанию, 0 - бессрочно.
+	TTLSeconds *int64 `json:"ttl_seconds,omitempty" validate:"omitempty,gte=0"`
+	// Public - ссылка открывается без авторизации через /r/{alias}
+	Public bool `json:"public,omitempty"`
+	// Wildcard - публичная ссылка пробрасывает остаток пути и query: /r/{alias}/a/b?x=1 -> {url}/a/b?x=1
+	Wildcard bool `json:"wildcard,omitempty"`
+	// ForwardQuery - публичная ссылка добавляет query из запроса к сохранённому адресу
+	ForwardQuery bool `json:"forward_query,omitempty"`
+	// Tags - метки ссылки. Сохраняются в нижнем регистре, без пробелов по краям и повторов.
+	Tags []string `json:"tags,omitempty"`
+	// Description - заметка о том, зачем нужна ссылка. Пробелы по краям не сохраняются.
+	Description string `json:"description,omitempty"`
 }
 
 type Response struct {
 	resp.Response
 	Alias string `json:"alias,omitempty"`
+	// DisplayAlias - сгенерированный alias, разбитый на группы для чтения, если дефисы не входят в alias
+	DisplayAlias string   `json:"display_alias,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	// Existing - адрес уже был сокращён пользователем, новая ссылка не создана, а alias - существующий
+	Existing bool `json:"existing,omitempty"`
 }
 
-// TODO: move to config if needed
-const aliasLength = 6
+// Значения по умолчанию для генерации alias
+const (
+	defaultAliasLength     = 6
+	defaultMinAliasLength  = 4
+	defaultCollisionProbes = 3
+)
+
+// Options - настройки сохранения ссылок
+type Options struct {
+	// DefaultTTL применяется, если в запросе нет ttl_seconds. 0 - бессрочно.
+	DefaultTTL time.Duration
+	// TTL - допустимый срок жизни ссылки. Проверяется итоговый срок, в том числе взятый по умолчанию.
+	TTL ttllimit.Limit
+	// AliasLength - начальная длина случайного alias
+	AliasLength int
+	// MinAliasLength - нижняя граница для персональной длины alias пользователя
+	MinAliasLength int
+	// MaxAliasLength - предел, до которого растёт длина случайного alias при коллизиях
+	MaxAliasLength int
+	// AliasCeiling - общий предел длины любого alias вместе с контрольным символом:
+	// длины генерации урезаются до него, свой alias длиннее отклоняется. 0 - без предела.
+	AliasCeiling int
+	// CollisionProbes - сколько коллизий подряд допускается на одной длине до её увеличения
+	CollisionProbes int
+	// MinCustomAliasLength - минимальная длина своего alias; 0 - без ограничения.
+	// На администраторов не распространяется.
+	MinCustomAliasLength int
+	// Blacklist - запрещённые слова. Проверяются и в своих, и в случайных alias.
+	Blacklist *blacklist.Blacklist
+	// Alphabet - символы случайного alias; пусто - random.DefaultAlphabet
+	Alphabet string
+	// MaxTags - максимум меток у ссылки после нормализации; 0 - без ограничения
+	MaxTags int
+	// MaxDescriptionLength - максимум символов в описании ссылки; 0 - без ограничения
+	MaxDescriptionLength int
+	// Checksum - к каждому alias, и случайному, и своему, добавляется контрольный символ
+	Checksum bool
+	// ChecksumFoldCase - контрольный символ не зависит от регистра, для alias без учёта регистра
+	ChecksumFoldCase bool
+	// HashAliases - первым пробуется alias, вычисленный из адреса и AliasSalt;
+	// при коллизии используется случайный
+	HashAliases bool
+	// AliasSalt - секрет, без которого нельзя заранее вычислить alias для известного адреса
+	AliasSalt string
+	// Grouping - разбиение сгенерированных alias на группы через дефис; свои alias не разбиваются
+	Grouping aliasgroup.Grouping
+	// Duplicates - поиск уже сокращённого адреса для политики повторов. nil - адрес можно сокращать повторно.
+	Duplicates DuplicateFinder
+	// AllowDuplicateURLs - политика повторов для пользователей без своей настройки allow_duplicate_urls.
+	// Если повторы запрещены, запрос без alias получает существующую ссылку, а со своим alias - 409.
+	AllowDuplicateURLs bool
+	// Metrics - счётчики коллизий и исчерпания случайных alias; nil - не считаются
+	Metrics *metrics.AliasGeneration
+	// Sequence - alias генерируется как base62 от id строки: самый короткий и без повторов.
+	// Alias длиннее AliasCeiling не выдаётся: после такого id работают хэш и случайные alias.
+	// Длины и Alphabet к нему не применяются, контрольный символ, группы и чёрный список - применяются.
+	// Если такой alias занят или запрещён, берётся хэш или случайный. nil - режим выключен.
+	Sequence SequenceSaver
+	// Normalization - правки адреса перед проверкой повторов и сохранением; по умолчанию адрес не меняется
+	Normalization urlnorm.Options
+	// RejectDottedAliases - свой alias с точкой отклоняется: при включённом URLFormat
+	// расширение отрезается от пути и такой alias по ссылке не открыть
+	RejectDottedAliases bool
+}
+
+func (o Options) withDefaults() Options {
+	if o.AliasLength <= 0 {
+		o.AliasLength = defaultAliasLength
+	}
+	o.AliasLength = o.aliasLimit().Clamp(o.AliasLength)
+	if o.MinAliasLength <= 0 {
+		o.MinAliasLength = defaultMinAliasLength
+	}
+	if o.MinAliasLength > o.AliasLength {
+		o.MinAliasLength = o.AliasLength
+	}
+	if o.MaxAliasLength < o.AliasLength {
+		o.MaxAliasLength = o.AliasLength
+	}
+	o.MaxAliasLength = o.aliasLimit().Clamp(o.MaxAliasLength)
+	if o.CollisionProbes <= 0 {
+		o.CollisionProbes = defaultCollisionProbes
+	}
+	if o.Alphabet == "" {
+		o.Alphabet = random.DefaultAlphabet
+	}
+
+	return o
+}
+
+func (o Options) aliasLimit() aliaslimit.Limit {
+	return aliaslimit.Limit{Max: o.AliasCeiling, Checksum: o.Checksum, Grouping: o.Grouping}
+}
+
+// checksumScheme - схема контрольного символа с учётом регистра alias
+func (o Options) checksumScheme() checksum.Scheme {
+	return checksum.Scheme{FoldCase: o.ChecksumFoldCase}
+}
+
+// Коды ошибок для конфликтов alias
+const (
+	CodeAliasExists   = "alias_exists"
+	CodeAliasTaken    = "alias_taken"
+	CodeAliasReserved = "alias_reserved"
+	CodeAliasTooShort = "alias_too_short"
+	CodeAliasTooLong  = "alias_too_long"
+	CodePrefixClaimed = "prefix_claimed"
+	CodeTooManyTags   = "too_many_tags"
+	CodeTTLOutOfRange = "ttl_out_of_range"
+	// CodeExpiryRequired - бессрочные ссылки запрещены, а срок не задан ни в запросе, ни по умолчанию
+	CodeExpiryRequired = "expiry_required"
+	// CodeURLDuplicate - адрес уже сокращён пользователем, а повторы ему запрещены
+	CodeURLDuplicate = "url_duplicate"
+	// CodeAliasGrouped - свой alias выглядит как сгенерированный с группами и не откроется,
+	// потому что дефисы в таких alias отбрасываются
+	CodeAliasGrouped = "alias_grouped"
+	// CodeDescriptionTooLong - описание длиннее Options.MaxDescriptionLength
+	CodeDescriptionTooLong = "description_too_long"
+	// CodeAliasDotted - свой alias содержит точку, а Options.RejectDottedAliases её запрещает
+	CodeAliasDotted = "alias_dotted"
+)
+
+// blacklistRetries - сколько раз случайный alias перегенерируется, если попал в чёрный список
+const blacklistRetries = 100
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLSaver
 type URLSaver interface {
-	SaveURL(ctx context.Context, log *slog.Logger, urlToSave, alias string, userID int64) error
+	SaveURL(ctx context.Context, log *slog.Logger, urlToSave, alias string, userID int64, opts storage.URLOptions) error
 	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetUserSettings(ctx context.Context, log *slog.Logger, userID int64) (storage.UserSettings, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=DuplicateFinder
+type DuplicateFinder interface {
+	GetAliasByURL(ctx context.Context, log *slog.Logger, url string, userID int64) (string, error)
 }
 
-func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=SequenceSaver
+type SequenceSaver interface {
+	SaveURLWithSequenceAlias(ctx context.Context, log *slog.Logger, urlToSave string, userID int64, opts storage.URLOptions, aliasFor func(id int64) (string, bool)) (string, error)
+}
+
+// errAliasSpaceExhausted - случайный alias не удалось подобрать даже на максимальной длине
+var errAliasSpaceExhausted = errors.New("no free random alias up to max length")
+
+// New сохраняет ссылку. Без alias в запросе генерируется случайный.
+func New(log *slog.Logger, urlSaver URLSaver, opts Options) http.HandlerFunc {
+	opts = opts.withDefaults()
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.user.register.New"
 
@@ -53,73 +225,456 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 			// Обработаем её отдельно
 			log.Error("request body is empty")
 
-			render.JSON(w, r, resp.Error("empty request"))
+			resp.JSON(w, r, resp.Error("empty request"))
 
 			return
 		}
 		if err != nil {
 			log.Error("failed to decode request body", sl.Err(err))
 
-			render.JSON(w, r, resp.Error("failed to decode request"))
+			resp.JSON(w, r, resp.Error("failed to decode request"))
 
 			return
 		}
 
 		log.Info("request body decoded", slog.Any("request", req))
 
+		// Пробелы по краям не сохраняются: alias из одних пробелов считается незаданным,
+		// а пустой после обрезки url не проходит валидацию
+		req.URL = strings.TrimSpace(req.URL)
+		req.Alias = strings.TrimSpace(req.Alias)
+
 		if err := validator.New().Struct(req); err != nil {
 			validateErr := err.(validator.ValidationErrors)
 
 			log.Error("invalid request", sl.Err(err))
 
-			render.JSON(w, r, resp.ValidationError(validateErr))
+			resp.JSON(w, r, resp.ValidationError(validateErr))
 
 			return
 		}
 
-		alias := req.Alias
-		if alias == "" {
-			alias = random.NewRandomString(aliasLength)
+		req.URL = opts.Normalization.Normalize(req.URL)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+		if req.Alias != "" && opts.Blacklist.Contains(req.Alias) {
+			log.Info("alias is blacklisted", slog.String("alias", req.Alias))
+
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ErrorWithCode("alias is reserved", CodeAliasReserved))
+
+			return
+		}
+
+		if req.Alias != "" && utf8.RuneCountInString(req.Alias) < opts.MinCustomAliasLength && !auth.IsAdmin(nickname) {
+			log.Info("custom alias is too short", slog.String("alias", req.Alias))
+
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ErrorWithCode(
+				fmt.Sprintf("alias must be at least %d characters long", opts.MinCustomAliasLength),
+				CodeAliasTooShort,
+			))
+
+			return
 		}
-		nickname := r.Context().Value("nickname").(string)
 
-		if nickname == "" || alias == "" {
-			log.Error("params is empty")
-			render.JSON(w, r, resp.Error("empty request"))
+		// Предел общий для всех, включая администраторов: он защищает индекс по alias
+		if req.Alias != "" && !opts.aliasLimit().Allows(req.Alias) {
+			log.Info("custom alias is too long", slog.String("alias", req.Alias))
+
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ErrorWithCode(
+				fmt.Sprintf("alias must be at most %d characters long", opts.aliasLimit().Body()),
+				CodeAliasTooLong,
+			))
+
 			return
 		}
+
+		if req.Alias != "" && opts.RejectDottedAliases && strings.Contains(req.Alias, ".") {
+			log.Info("custom alias contains a dot", slog.String("alias", req.Alias))
+
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ErrorWithCode("alias must not contain dots", CodeAliasDotted))
+
+			return
+		}
+
+		tags := tagsutil.Normalize(req.Tags)
+		if opts.MaxTags > 0 && len(tags) > opts.MaxTags {
+			log.Info("too many tags", slog.Int("tags", len(tags)))
+
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ErrorWithCode(
+				fmt.Sprintf("a link can have at most %d tags", opts.MaxTags),
+				CodeTooManyTags,
+			))
+
+			return
+		}
+
+		description := strings.TrimSpace(req.Description)
+		if opts.MaxDescriptionLength > 0 && utf8.RuneCountInString(description) > opts.MaxDescriptionLength {
+			log.Info("description is too long", slog.Int("length", utf8.RuneCountInString(description)))
+
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ErrorWithCode(
+				fmt.Sprintf("description must be at most %d characters long", opts.MaxDescriptionLength),
+				CodeDescriptionTooLong,
+			))
+
+			return
+		}
+
 		userID, _, errGetUser := urlSaver.GetUserByNickname(r.Context(), log, nickname)
 		if errGetUser != nil {
 			log.Error("failed to get user by nickname", sl.Err(errGetUser))
-			render.JSON(w, r, resp.Error(errGetUser.Error()))
+			resp.JSON(w, r, resp.Error(errGetUser.Error()))
+			return
+		}
+
+		settings, errSettings := urlSaver.GetUserSettings(r.Context(), log, userID)
+		if errSettings != nil {
+			// Без настроек ссылку всё равно можно сохранить с глобальными значениями
+			log.Error("failed to get user settings", sl.Err(errSettings))
+			settings = storage.UserSettings{}
+		}
+
+		defaultTTL := opts.DefaultTTL
+		if settings.DefaultTTLSeconds != nil {
+			defaultTTL = ttllimit.Seconds(*settings.DefaultTTLSeconds)
+		}
+
+		ttl := linkTTL(req.TTLSeconds, defaultTTL)
+		if err := opts.TTL.Check(ttl); err != nil {
+			log.Info("ttl is out of range", slog.Duration("ttl", ttl), sl.Err(err))
+
+			code := CodeTTLOutOfRange
+			if errors.Is(err, ttllimit.ErrNoExpiry) {
+				code = CodeExpiryRequired
+			}
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ErrorWithCode(opts.TTL.Message(err), code))
+
+			return
+		}
+
+		urlOpts := storage.URLOptions{
+			ExpiresAt:    expiresAt(ttl, time.Now().UTC()),
+			Public:       req.Public,
+			Wildcard:     req.Wildcard,
+			ForwardQuery: req.ForwardQuery,
+			Tags:         tags,
+			Description:  description,
+		}
+		if settings.DefaultRedirectStatus != nil {
+			urlOpts.RedirectStatus = *settings.DefaultRedirectStatus
+		}
+
+		existing, err := duplicateAlias(r.Context(), log, opts, settings, req.URL, userID)
+		if err != nil {
+			log.Error("failed to look up existing url", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to add url"))
+
+			return
+		}
+		if existing != "" && req.Alias != "" {
+			log.Info("url is already shortened", slog.String("alias", existing))
+
+			render.Status(r, http.StatusConflict)
+			resp.JSON(w, r, Response{
+				Response: resp.ErrorWithCode("you have already shortened this url", CodeURLDuplicate),
+				Alias:    existing,
+			})
+
+			return
+		}
+		if existing != "" {
+			log.Info("url is already shortened, returning existing alias", slog.String("alias", existing))
+
+			display := ""
+			if opts.Grouping.Display(existing) != existing {
+				display = opts.Grouping.Display(existing)
+			}
+
+			resp.JSON(w, r, Response{
+				Response:     resp.OK(),
+				Alias:        existing,
+				DisplayAlias: display,
+				Existing:     true,
+			})
+
 			return
 		}
 
-		errSaveURL := urlSaver.SaveURL(r.Context(), log, req.URL, alias, userID)
+		alias := req.Alias
+		if alias != "" && opts.Checksum {
+			alias = opts.checksumScheme().Append(alias)
+		}
+
+		if alias != "" && opts.Grouping.Ambiguous(alias) {
+			log.Info("custom alias looks like a grouped one", slog.String("alias", alias))
+
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ErrorWithCode("alias must not look like a hyphen-grouped generated alias", CodeAliasGrouped))
+
+			return
+		}
+
+		var (
+			errSaveURL error
+			generated  = alias == ""
+		)
+		if generated {
+			length := aliasLength(settings, opts)
+			alias, errSaveURL = saveWithRandomAlias(r.Context(), log, urlSaver, req.URL, userID, urlOpts, length, opts)
+		} else {
+			errSaveURL = urlSaver.SaveURL(r.Context(), log, req.URL, alias, userID, urlOpts)
+		}
 		if errors.Is(errSaveURL, storage.ErrURLExists) {
 			log.Info("url already exists", slog.String("url", req.URL))
 
-			render.JSON(w, r, resp.Error("url already exists"))
+			render.Status(r, http.StatusConflict)
+			resp.JSON(w, r, resp.ErrorWithCode("you have already created this alias", CodeAliasExists))
 
 			return
 		}
-		if err != nil {
-			log.Error("failed to add url", sl.Err(err))
+		if errors.Is(errSaveURL, storage.ErrAliasTaken) {
+			log.Info("alias is taken by another user", slog.String("alias", alias))
+
+			render.Status(r, http.StatusConflict)
+			resp.JSON(w, r, resp.ErrorWithCode("alias is taken by another user", CodeAliasTaken))
+
+			return
+		}
+		// Префиксы проверяет хранилище при записи, вместе с занятостью alias
+		if errors.Is(errSaveURL, storage.ErrPrefixTaken) {
+			access.Audit(log, r, nickname, alias)
+
+			render.Status(r, http.StatusForbidden)
+			resp.JSON(w, r, resp.ErrorWithCode("alias prefix is claimed by another user", CodePrefixClaimed))
+
+			return
+		}
+		if errSaveURL != nil {
+			log.Error("failed to add url", sl.Err(errSaveURL))
 
-			render.JSON(w, r, resp.Error(err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to add url"))
 
 			return
 		}
 
 		log.Info("url added")
 
-		responseOK(w, r, alias)
+		display := ""
+		if generated && opts.Grouping.Display(alias) != alias {
+			display = opts.Grouping.Display(alias)
+		}
+
+		responseOK(w, r, alias, display, tags)
+	}
+}
+
+// aliasLength выбирает начальную длину случайного alias: персональная настройка пользователя,
+// ограниченная [MinAliasLength, MaxAliasLength], или глобальное значение
+func aliasLength(settings storage.UserSettings, opts Options) int {
+	if settings.DefaultAliasLength == nil {
+		return opts.AliasLength
+	}
+
+	length := *settings.DefaultAliasLength
+	if length < opts.MinAliasLength {
+		length = opts.MinAliasLength
+	}
+	if length > opts.MaxAliasLength {
+		length = opts.MaxAliasLength
+	}
+
+	return length
+}
+
+// saveWithRandomAlias сохраняет ссылку под случайным alias длины length. Если CollisionProbes попыток
+// подряд натыкаются на занятый alias, длина увеличивается на единицу, но не больше MaxAliasLength.
+// С Sequence сначала пробуется alias из id строки, с HashAliases - alias из хэша адреса.
+func saveWithRandomAlias(
+	ctx context.Context,
+	log *slog.Logger,
+	urlSaver URLSaver,
+	urlToSave string,
+	userID int64,
+	urlOpts storage.URLOptions,
+	length int,
+	opts Options,
+) (string, error) {
+	if opts.Sequence != nil {
+		alias, err := opts.Sequence.SaveURLWithSequenceAlias(ctx, log, urlToSave, userID, urlOpts, func(id int64) (string, bool) {
+			return sequenceAlias(id, opts)
+		})
+		if !errors.Is(err, storage.ErrURLExists) && !errors.Is(err, storage.ErrAliasTaken) {
+			return alias, err
+		}
+
+		log.Warn("sequence alias is unavailable, falling back", sl.Err(err))
+		opts.Metrics.Retry()
+	}
+
+	if opts.HashAliases {
+		if alias, ok := hashAlias(urlToSave, length, opts); ok {
+			saved, err := saveGenerated(ctx, log, urlSaver, urlToSave, alias, userID, urlOpts, opts)
+			if saved || err != nil {
+				return alias, err
+			}
+
+			log.Warn("hash alias collision, falling back to random", slog.String("alias", alias))
+			opts.Metrics.Retry()
+		}
 	}
+
+	for {
+		for probe := 0; probe < opts.CollisionProbes; probe++ {
+			alias, ok := randomAlias(length, opts)
+			if !ok {
+				log.Warn("random aliases keep hitting blacklist", slog.Int("length", length))
+				continue
+			}
+
+			saved, err := saveGenerated(ctx, log, urlSaver, urlToSave, alias, userID, urlOpts, opts)
+			if saved || err != nil {
+				return alias, err
+			}
+
+			log.Warn("random alias collision", slog.String("alias", alias), slog.Int("length", length))
+			opts.Metrics.Retry()
+		}
+
+		if length >= opts.MaxAliasLength {
+			opts.Metrics.Exhaust()
+			return "", errAliasSpaceExhausted
+		}
+
+		length++
+		log.Warn("too many alias collisions, increasing length", slog.Int("length", length))
+	}
+}
+
+// saveGenerated сохраняет ссылку под сгенерированным alias. false без ошибки - коллизия:
+// alias занят или попадает под чужой префикс, и нужно пробовать другой.
+func saveGenerated(
+	ctx context.Context,
+	log *slog.Logger,
+	urlSaver URLSaver,
+	urlToSave, alias string,
+	userID int64,
+	urlOpts storage.URLOptions,
+	opts Options,
+) (bool, error) {
+	err := urlSaver.SaveURL(ctx, log, urlToSave, alias, userID, urlOpts)
+	if storage.AliasUnavailable(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// duplicateAlias возвращает alias, под которым пользователь уже сократил адрес, если повторы
+// ему запрещены. "" - повторы разрешены или адрес ещё не сокращался.
+func duplicateAlias(
+	ctx context.Context,
+	log *slog.Logger,
+	opts Options,
+	settings storage.UserSettings,
+	url string,
+	userID int64,
+) (string, error) {
+	allow := opts.AllowDuplicateURLs
+	if settings.AllowDuplicateURLs != nil {
+		allow = *settings.AllowDuplicateURLs
+	}
+	if allow || opts.Duplicates == nil {
+		return "", nil
+	}
+
+	alias, err := opts.Duplicates.GetAliasByURL(ctx, log, url, userID)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		return "", nil
+	}
+
+	return alias, err
+}
+
+// randomAlias генерирует случайный alias, которого нет в чёрном списке.
+// false - за blacklistRetries попыток подходящий alias не нашёлся.
+func randomAlias(length int, opts Options) (string, bool) {
+	for i := 0; i < blacklistRetries; i++ {
+		alias := opts.Grouping.Store(random.NewRandomStringFrom(opts.Alphabet, length))
+		if opts.Checksum {
+			alias = opts.checksumScheme().Append(alias)
+		}
+		if !opts.Blacklist.Contains(alias) {
+			return alias, true
+		}
+	}
+
+	return "", false
+}
+
+// sequenceAlias вычисляет alias из id строки. false - alias попал в чёрный список
+// или вместе с дефисами групп не укладывается в AliasCeiling.
+func sequenceAlias(id int64, opts Options) (string, bool) {
+	alias := opts.Grouping.Store(base62.Encode(id))
+	if !opts.aliasLimit().Allows(alias) {
+		return "", false
+	}
+	if opts.Checksum {
+		alias = opts.checksumScheme().Append(alias)
+	}
+
+	return alias, !opts.Blacklist.Contains(alias)
+}
+
+// hashAlias вычисляет alias из адреса и соли. false - alias попал в чёрный список.
+func hashAlias(urlToSave string, length int, opts Options) (string, bool) {
+	alias := opts.Grouping.Store(hashalias.New(opts.AliasSalt, urlToSave, opts.Alphabet, length))
+	if opts.Checksum {
+		alias = opts.checksumScheme().Append(alias)
+	}
+
+	return alias, !opts.Blacklist.Contains(alias)
+}
+
+// linkTTL - срок жизни ссылки: явный ttl из запроса важнее значения по умолчанию. 0 - бессрочно.
+func linkTTL(ttlSeconds *int64, defaultTTL time.Duration) time.Duration {
+	if ttlSeconds != nil {
+		return ttllimit.Seconds(*ttlSeconds)
+	}
+
+	return defaultTTL
+}
+
+// expiresAt вычисляет момент истечения ссылки со сроком жизни ttl; nil - бессрочно
+func expiresAt(ttl time.Duration, now time.Time) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+
+	t := now.Add(ttl)
+	return &t
 }
 
-func responseOK(w http.ResponseWriter, r *http.Request, alias string) {
-	render.JSON(w, r, Response{
-		Response: resp.OK(),
-		Alias:    alias,
+func responseOK(w http.ResponseWriter, r *http.Request, alias, display string, tags []string) {
+	resp.JSON(w, r, Response{
+		Response:     resp.OK(),
+		Alias:        alias,
+		DisplayAlias: display,
+		Tags:         tags,
 	})
 }
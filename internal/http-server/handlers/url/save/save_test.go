@@ -2,19 +2,33 @@ package save_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
 
 	"url-shortener/internal/http-server/handlers/url/save"
 	"url-shortener/internal/http-server/handlers/url/save/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/aliasgroup"
+	"url-shortener/internal/lib/blacklist"
+	"url-shortener/internal/lib/checksum"
+	"url-shortener/internal/lib/hashalias"
 	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/metrics"
+	"url-shortener/internal/lib/random"
+	"url-shortener/internal/lib/ttllimit"
+	"url-shortener/internal/lib/urlnorm"
+	"url-shortener/internal/storage"
 )
 
 func TestSaveHandler(t *testing.T) {
@@ -23,37 +37,62 @@ func TestSaveHandler(t *testing.T) {
 		alias     string
 		url       string
 		respError string
+		respCode  string
+		status    int
 		mockError error
 	}{
 		{
-			name:  "Success",
-			alias: "test_alias",
-			url:   "https://google.com",
+			name:   "Success",
+			alias:  "test_alias",
+			url:    "https://google.com",
+			status: http.StatusOK,
 		},
 		{
-			name:  "Empty alias",
-			alias: "",
-			url:   "https://google.com",
+			name:   "Empty alias",
+			alias:  "",
+			url:    "https://google.com",
+			status: http.StatusOK,
 		},
 		{
 			name:      "Empty URL",
 			url:       "",
 			alias:     "some_alias",
 			respError: "field URL is a required field",
+			status:    http.StatusOK,
 		},
 		{
 			name:      "Invalid URL",
 			url:       "some invalid URL",
 			alias:     "some_alias",
 			respError: "field URL is not a valid URL",
+			status:    http.StatusOK,
 		},
 		{
 			name:      "SaveURL Error",
 			alias:     "test_alias",
 			url:       "https://google.com",
 			respError: "failed to add url",
+			status:    http.StatusInternalServerError,
 			mockError: errors.New("unexpected error"),
 		},
+		{
+			name:      "Own duplicate",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			respError: "you have already created this alias",
+			respCode:  save.CodeAliasExists,
+			status:    http.StatusConflict,
+			mockError: storage.ErrURLExists,
+		},
+		{
+			name:      "Foreign conflict",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			respError: "alias is taken by another user",
+			respCode:  save.CodeAliasTaken,
+			status:    http.StatusConflict,
+			mockError: storage.ErrAliasTaken,
+		},
 	}
 
 	for _, tc := range cases {
@@ -65,22 +104,29 @@ func TestSaveHandler(t *testing.T) {
 			urlSaverMock := mocks.NewURLSaver(t)
 
 			if tc.respError == "" || tc.mockError != nil {
-				urlSaverMock.On("SaveURL", tc.url, mock.AnythingOfType("string")).
-					Return(int64(1), tc.mockError).
+				urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+					Return(storage.UserSettings{}, nil).
+					Maybe()
+				urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, tc.url, mock.AnythingOfType("string"), int64(1), storage.URLOptions{}).
+					Return(tc.mockError).
 					Once()
 			}
 
-			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock)
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{})
 
 			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, tc.url, tc.alias)
 
 			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
 			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
 
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
 
-			require.Equal(t, rr.Code, http.StatusOK)
+			require.Equal(t, tc.status, rr.Code)
 
 			body := rr.Body.String()
 
@@ -89,8 +135,1388 @@ func TestSaveHandler(t *testing.T) {
 			require.NoError(t, json.Unmarshal([]byte(body), &resp))
 
 			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.respCode, resp.Code)
 
 			// TODO: add more checks
 		})
 	}
 }
+
+func TestSaveHandler_Expiry(t *testing.T) {
+	const defaultTTL = 24 * time.Hour
+	userTTL := int64(3600)
+
+	cases := []struct {
+		name       string
+		defaultTTL time.Duration
+		settings   storage.UserSettings
+		input      string
+		// wantTTL - ожидаемый срок жизни ссылки, 0 - бессрочная
+		wantTTL time.Duration
+	}{
+		{
+			name:       "Default applied",
+			defaultTTL: defaultTTL,
+			input:      `{"url": "https://google.com", "alias": "a"}`,
+			wantTTL:    defaultTTL,
+		},
+		{
+			name:       "Per-request override",
+			defaultTTL: defaultTTL,
+			input:      `{"url": "https://google.com", "alias": "a", "ttl_seconds": 60}`,
+			wantTTL:    time.Minute,
+		},
+		{
+			name:       "Explicit never",
+			defaultTTL: defaultTTL,
+			input:      `{"url": "https://google.com", "alias": "a", "ttl_seconds": 0}`,
+		},
+		{
+			name:  "No default",
+			input: `{"url": "https://google.com", "alias": "a"}`,
+		},
+		{
+			name:       "User default overrides global",
+			defaultTTL: defaultTTL,
+			settings:   storage.UserSettings{DefaultTTLSeconds: &userTTL},
+			input:      `{"url": "https://google.com", "alias": "a"}`,
+			wantTTL:    time.Hour,
+		},
+		{
+			name:       "Per-request override of user default",
+			defaultTTL: defaultTTL,
+			settings:   storage.UserSettings{DefaultTTLSeconds: &userTTL},
+			input:      `{"url": "https://google.com", "alias": "a", "ttl_seconds": 60}`,
+			wantTTL:    time.Minute,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+				Return(tc.settings, nil).
+				Once()
+
+			var opts storage.URLOptions
+			urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", "a", int64(1), mock.Anything).
+				Run(func(args mock.Arguments) { opts = args.Get(5).(storage.URLOptions) }).
+				Return(nil).
+				Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{DefaultTTL: tc.defaultTTL})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			before := time.Now()
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			if tc.wantTTL == 0 {
+				require.Nil(t, opts.ExpiresAt)
+				return
+			}
+
+			require.NotNil(t, opts.ExpiresAt)
+			require.WithinDuration(t, before.Add(tc.wantTTL), *opts.ExpiresAt, time.Second)
+		})
+	}
+}
+
+func TestSaveHandler_TTLLimit(t *testing.T) {
+	limit := ttllimit.Limit{Min: time.Minute, Max: 30 * 24 * time.Hour}
+
+	cases := []struct {
+		name       string
+		limit      ttllimit.Limit
+		defaultTTL time.Duration
+		input      string
+		status     int
+		respCode   string
+	}{
+		{
+			name:     "Below min",
+			limit:    limit,
+			input:    `{"url": "https://google.com", "alias": "a", "ttl_seconds": 1}`,
+			status:   http.StatusBadRequest,
+			respCode: save.CodeTTLOutOfRange,
+		},
+		{
+			name:     "Above max",
+			limit:    limit,
+			input:    `{"url": "https://google.com", "alias": "a", "ttl_seconds": 3153600000}`,
+			status:   http.StatusBadRequest,
+			respCode: save.CodeTTLOutOfRange,
+		},
+		{
+			// Без защиты от переполнения срок стал бы отрицательным, то есть бессрочным
+			name:     "Overflowing ttl",
+			limit:    limit,
+			input:    `{"url": "https://google.com", "alias": "a", "ttl_seconds": 9223372036854775807}`,
+			status:   http.StatusBadRequest,
+			respCode: save.CodeTTLOutOfRange,
+		},
+		{
+			name:   "In range",
+			limit:  limit,
+			input:  `{"url": "https://google.com", "alias": "a", "ttl_seconds": 3600}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "Never expire allowed",
+			limit:  limit,
+			input:  `{"url": "https://google.com", "alias": "a", "ttl_seconds": 0}`,
+			status: http.StatusOK,
+		},
+		{
+			name:     "Never expire denied",
+			limit:    ttllimit.Limit{DenyNoExpiry: true},
+			input:    `{"url": "https://google.com", "alias": "a", "ttl_seconds": 0}`,
+			status:   http.StatusBadRequest,
+			respCode: save.CodeExpiryRequired,
+		},
+		{
+			name:     "Never expire denied without default",
+			limit:    ttllimit.Limit{DenyNoExpiry: true},
+			input:    `{"url": "https://google.com", "alias": "a"}`,
+			status:   http.StatusBadRequest,
+			respCode: save.CodeExpiryRequired,
+		},
+		{
+			name:       "Never expire denied with default",
+			limit:      ttllimit.Limit{DenyNoExpiry: true},
+			defaultTTL: time.Hour,
+			input:      `{"url": "https://google.com", "alias": "a"}`,
+			status:     http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+				Return(storage.UserSettings{}, nil).
+				Once()
+			if tc.status == http.StatusOK {
+				urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", "a", int64(1), mock.Anything).
+					Return(nil).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{DefaultTTL: tc.defaultTTL, TTL: tc.limit})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respCode, resp.Code)
+		})
+	}
+}
+
+func TestSaveHandler_AliasLengthGrowsOnCollisions(t *testing.T) {
+	const length = 4
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+		Return(storage.UserSettings{}, nil).
+		Maybe()
+
+	// Все alias длины N заняты, alias длины N+1 свободны
+	urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com",
+		mock.MatchedBy(func(alias string) bool { return len(alias) == length }), int64(1), storage.URLOptions{}).
+		Return(storage.ErrAliasTaken).
+		Times(2)
+	urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com",
+		mock.MatchedBy(func(alias string) bool { return len(alias) == length+1 }), int64(1), storage.URLOptions{}).
+		Return(nil).
+		Once()
+
+	generation := metrics.NewAliasGeneration(metrics.NewRegistry())
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{
+		AliasLength:     length,
+		MaxAliasLength:  length + 2,
+		CollisionProbes: 2,
+		Metrics:         generation,
+	})
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp save.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp.Alias, length+1)
+
+	// Каждая коллизия учтена как повтор, исчерпания не было
+	require.EqualValues(t, 2, generation.Retries.Value())
+	require.Zero(t, generation.Exhausted.Value())
+}
+
+func TestSaveHandler_AliasLengthBounded(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+		Return(storage.UserSettings{}, nil).
+		Maybe()
+	urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", mock.Anything, int64(1), storage.URLOptions{}).
+		Return(storage.ErrAliasTaken).
+		Times(2)
+
+	generation := metrics.NewAliasGeneration(metrics.NewRegistry())
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{
+		AliasLength:     4,
+		MaxAliasLength:  4,
+		CollisionProbes: 2,
+		Metrics:         generation,
+	})
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+	require.EqualValues(t, 2, generation.Retries.Value())
+	require.EqualValues(t, 1, generation.Exhausted.Value())
+}
+
+func TestSaveHandler_UserAliasLength(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	cases := []struct {
+		name       string
+		preference *int
+		wantLength int
+	}{
+		{
+			name:       "Global default",
+			wantLength: 6,
+		},
+		{
+			name:       "Preference honored",
+			preference: intPtr(8),
+			wantLength: 8,
+		},
+		{
+			name:       "Clamped to max",
+			preference: intPtr(50),
+			wantLength: 10,
+		},
+		{
+			name:       "Clamped to min",
+			preference: intPtr(1),
+			wantLength: 4,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+				Return(storage.UserSettings{DefaultAliasLength: tc.preference}, nil).
+				Once()
+			urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", mock.Anything, int64(1), storage.URLOptions{}).
+				Return(nil).
+				Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{
+				AliasLength:    6,
+				MinAliasLength: 4,
+				MaxAliasLength: 10,
+			})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Len(t, resp.Alias, tc.wantLength)
+		})
+	}
+}
+
+func TestSaveHandler_RandomAliasSkipsBlacklist(t *testing.T) {
+	// Из алфавита "ab" получаются aa, ab, ba и bb, разрешён только aa
+	bl := blacklist.New("ab", "ba", "bb")
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil)
+	urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+		Return(storage.UserSettings{}, nil)
+
+	var aliases []string
+	urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", mock.Anything, int64(1), storage.URLOptions{}).
+		Run(func(args mock.Arguments) { aliases = append(aliases, args.String(3)) }).
+		Return(nil)
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{
+		AliasLength: 2,
+		Blacklist:   bl,
+		Alphabet:    "ab",
+	})
+
+	for i := 0; i < 50; i++ {
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+		require.NoError(t, err)
+		req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	require.Len(t, aliases, 50)
+	for _, alias := range aliases {
+		require.Equal(t, "aa", alias)
+	}
+}
+
+func TestSaveHandler_CustomAliasBlacklisted(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{Blacklist: blacklist.Default()})
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com", "alias": "Admin"}`)))
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Contains(t, rr.Body.String(), save.CodeAliasReserved)
+}
+
+func TestSaveHandler_CustomAliasDotted(t *testing.T) {
+	t.Run("Rejected", func(t *testing.T) {
+		// Запрос отклоняется до обращения к хранилищу
+		urlSaverMock := mocks.NewURLSaver(t)
+
+		handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{RejectDottedAliases: true})
+
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com", "alias": "v1.2"}`)))
+		require.NoError(t, err)
+		req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), save.CodeAliasDotted)
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		urlSaverMock := mocks.NewURLSaver(t)
+		urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+			Return(int64(1), "", nil).
+			Once()
+		urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+			Return(storage.UserSettings{}, nil).
+			Once()
+		urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", "v1.2", int64(1), storage.URLOptions{}).
+			Return(nil).
+			Once()
+
+		handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{})
+
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com", "alias": "v1.2"}`)))
+		require.NoError(t, err)
+		req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+}
+
+func TestSaveHandler_CustomAliasGrouped(t *testing.T) {
+	cases := []struct {
+		name   string
+		opts   save.Options
+		alias  string
+		status int
+	}{
+		{name: "Looks generated", opts: save.Options{Grouping: aliasgroup.Grouping{Size: 3}}, alias: "abc-def", status: http.StatusBadRequest},
+		{name: "Other hyphens", opts: save.Options{Grouping: aliasgroup.Grouping{Size: 3}}, alias: "my-link", status: http.StatusOK},
+		{name: "Hyphens significant", opts: save.Options{Grouping: aliasgroup.Grouping{Size: 3, Significant: true}}, alias: "abc-def", status: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+				Return(storage.UserSettings{}, nil).
+				Once()
+			if tc.status == http.StatusOK {
+				// Свой alias сохраняется как есть, без разбиения на группы
+				urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", tc.alias, int64(1), mock.Anything).
+					Return(nil).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, tc.opts)
+
+			body := fmt.Sprintf(`{"url": "https://google.com", "alias": %q}`, tc.alias)
+			req := httptest.NewRequest(http.MethodPost, "/save", strings.NewReader(body))
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+			if tc.status == http.StatusBadRequest {
+				require.Contains(t, rr.Body.String(), save.CodeAliasGrouped)
+			}
+		})
+	}
+}
+
+func TestSaveHandler_MinCustomAliasLength(t *testing.T) {
+	auth.Admins = []string{"admin"}
+	t.Cleanup(func() { auth.Admins = nil })
+
+	cases := []struct {
+		name     string
+		nickname string
+		alias    string
+		status   int
+	}{
+		{name: "Too short", nickname: "user", alias: "ab", status: http.StatusBadRequest},
+		{name: "Long enough", nickname: "user", alias: "abc", status: http.StatusOK},
+		{name: "Multibyte characters count once", nickname: "user", alias: "ёжи", status: http.StatusOK},
+		{name: "Admin bypass", nickname: "admin", alias: "a", status: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			if tc.status == http.StatusOK {
+				urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, tc.nickname).
+					Return(int64(1), "", nil).
+					Once()
+				urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+					Return(storage.UserSettings{}, nil).
+					Once()
+				urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", tc.alias, int64(1), storage.URLOptions{}).
+					Return(nil).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{MinCustomAliasLength: 3})
+
+			input := fmt.Sprintf(`{"url": "https://google.com", "alias": %q}`, tc.alias)
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), tc.nickname))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status == http.StatusBadRequest {
+				require.Contains(t, rr.Body.String(), save.CodeAliasTooShort)
+			}
+		})
+	}
+}
+
+func TestSaveHandler_Checksum(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		foldCase bool
+	}{
+		{name: "Random alias", input: `{"url": "https://google.com"}`},
+		{name: "Custom alias", input: `{"url": "https://google.com", "alias": "mylink"}`},
+		{name: "Custom alias, case folded", input: `{"url": "https://google.com", "alias": "MyLink"}`, foldCase: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			scheme := checksum.Scheme{FoldCase: tc.foldCase}
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+				Return(storage.UserSettings{}, nil).
+				Once()
+			urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com",
+				mock.MatchedBy(scheme.Valid), int64(1), storage.URLOptions{}).
+				Return(nil).
+				Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{
+				Checksum:         true,
+				ChecksumFoldCase: tc.foldCase,
+			})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.True(t, scheme.Valid(resp.Alias))
+			if tc.foldCase {
+				require.True(t, scheme.Valid(strings.ToLower(resp.Alias)))
+			}
+		})
+	}
+}
+
+func TestSaveHandler_Tags(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		wantTags []string
+		status   int
+	}{
+		{
+			name:     "Normalized and deduplicated",
+			input:    `{"url": "https://google.com", "alias": "abc", "tags": ["Work", "work ", " Home", ""]}`,
+			wantTags: []string{"home", "work"},
+			status:   http.StatusOK,
+		},
+		{
+			name:     "Duplicates do not count towards limit",
+			input:    `{"url": "https://google.com", "alias": "abc", "tags": ["a", "A", "b", "c"]}`,
+			wantTags: []string{"a", "b", "c"},
+			status:   http.StatusOK,
+		},
+		{
+			name:   "Over limit",
+			input:  `{"url": "https://google.com", "alias": "abc", "tags": ["a", "b", "c", "d"]}`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			if tc.status == http.StatusOK {
+				urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+					Return(storage.UserSettings{}, nil).
+					Once()
+				urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", "abc", int64(1),
+					storage.URLOptions{Tags: tc.wantTags}).
+					Return(nil).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{MaxTags: 3})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				require.Contains(t, rr.Body.String(), save.CodeTooManyTags)
+				return
+			}
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantTags, resp.Tags)
+		})
+	}
+}
+
+func TestSaveHandler_Trim(t *testing.T) {
+	cases := []struct {
+		name      string
+		input     string
+		url       string
+		alias     string
+		respError string
+	}{
+		{
+			name:  "Whitespace-only alias",
+			input: `{"url": "https://google.com", "alias": "   "}`,
+			url:   "https://google.com",
+		},
+		{
+			name:  "Padded URL and alias",
+			input: `{"url": "  https://google.com\t", "alias": " my_alias "}`,
+			url:   "https://google.com",
+			alias: "my_alias",
+		},
+		{
+			name:      "Whitespace-only URL",
+			input:     `{"url": "   ", "alias": "my_alias"}`,
+			respError: "field URL is a required field",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+
+			var savedAlias string
+			if tc.respError == "" {
+				urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+					Return(storage.UserSettings{}, nil).
+					Once()
+				urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, tc.url, mock.AnythingOfType("string"), int64(1), storage.URLOptions{}).
+					Run(func(args mock.Arguments) { savedAlias = args.String(3) }).
+					Return(nil).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError != "" {
+				return
+			}
+
+			if tc.alias != "" {
+				require.Equal(t, tc.alias, savedAlias)
+			} else {
+				// Вместо пробелов сгенерирован случайный alias
+				require.Len(t, savedAlias, 6)
+				require.Equal(t, strings.TrimSpace(savedAlias), savedAlias)
+			}
+			require.Equal(t, savedAlias, resp.Alias)
+		})
+	}
+}
+
+func TestSaveHandler_Normalization(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+		Return(storage.UserSettings{}, nil).
+		Once()
+	// Регистр пути сохраняется: включены только правки хоста
+	urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://example.com/Docs/#top", "docs", int64(1), storage.URLOptions{}).
+		Return(nil).
+		Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{
+		Normalization: urlnorm.Options{LowercaseHost: true, StripWWW: true},
+	})
+
+	input := `{"url": "https://WWW.Example.com/Docs/#top", "alias": "docs"}`
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestSaveHandler_Description(t *testing.T) {
+	t.Run("Saved trimmed", func(t *testing.T) {
+		urlSaverMock := mocks.NewURLSaver(t)
+		urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+			Return(int64(1), "", nil).
+			Once()
+		urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+			Return(storage.UserSettings{}, nil).
+			Once()
+		urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://example.com", "docs", int64(1), storage.URLOptions{Description: "team docs"}).
+			Return(nil).
+			Once()
+
+		handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{MaxDescriptionLength: 9})
+
+		input := `{"url": "https://example.com", "alias": "docs", "description": "  team docs  "}`
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
+	t.Run("Too long", func(t *testing.T) {
+		// Запрос отклоняется до обращения к хранилищу
+		urlSaverMock := mocks.NewURLSaver(t)
+
+		handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{MaxDescriptionLength: 8})
+
+		input := `{"url": "https://example.com", "alias": "docs", "description": "team docs"}`
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var resp save.Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, save.CodeDescriptionTooLong, resp.Code)
+	})
+}
+
+func TestSaveHandler_HashAlias(t *testing.T) {
+	const url = "https://google.com"
+
+	saveWithSalt := func(t *testing.T, salt string, collision bool) string {
+		t.Helper()
+
+		urlSaverMock := mocks.NewURLSaver(t)
+		urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+			Return(int64(1), "", nil).
+			Once()
+		urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+			Return(storage.UserSettings{}, nil).
+			Once()
+
+		hashed := hashalias.New(salt, url, random.DefaultAlphabet, 6)
+		if collision {
+			urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, url, hashed, int64(1), storage.URLOptions{}).
+				Return(storage.ErrAliasTaken).
+				Once()
+			urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, url, mock.MatchedBy(func(alias string) bool { return alias != hashed }), int64(1), storage.URLOptions{}).
+				Return(nil).
+				Once()
+		} else {
+			urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, url, hashed, int64(1), storage.URLOptions{}).
+				Return(nil).
+				Once()
+		}
+
+		handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{HashAliases: true, AliasSalt: salt})
+
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "`+url+`"}`)))
+		require.NoError(t, err)
+		req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var resp save.Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Empty(t, resp.Error)
+
+		return resp.Alias
+	}
+
+	t.Run("Stable under the same salt", func(t *testing.T) {
+		require.Equal(t, saveWithSalt(t, "salt-one", false), saveWithSalt(t, "salt-one", false))
+	})
+
+	t.Run("Different under different salts", func(t *testing.T) {
+		require.NotEqual(t, saveWithSalt(t, "salt-one", false), saveWithSalt(t, "salt-two", false))
+	})
+
+	t.Run("Collision falls back to random", func(t *testing.T) {
+		alias := saveWithSalt(t, "salt-one", true)
+		require.NotEqual(t, hashalias.New("salt-one", url, random.DefaultAlphabet, 6), alias)
+	})
+}
+
+func TestSaveHandler_AliasCeilingCustom(t *testing.T) {
+	auth.Admins = []string{"admin"}
+	t.Cleanup(func() { auth.Admins = nil })
+
+	cases := []struct {
+		name     string
+		nickname string
+		alias    string
+		checksum bool
+		status   int
+	}{
+		{name: "At ceiling", nickname: "user", alias: "abcdefgh", status: http.StatusOK},
+		{name: "Over ceiling", nickname: "user", alias: "abcdefghi", status: http.StatusBadRequest},
+		{name: "Admin over ceiling", nickname: "admin", alias: "abcdefghi", status: http.StatusBadRequest},
+		{name: "Checksum character counts", nickname: "user", alias: "abcdefgh", checksum: true, status: http.StatusBadRequest},
+		{name: "Checksum fits", nickname: "user", alias: "abcdefg", checksum: true, status: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			if tc.status == http.StatusOK {
+				urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, tc.nickname).
+					Return(int64(1), "", nil).
+					Once()
+				urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+					Return(storage.UserSettings{}, nil).
+					Once()
+				urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com",
+					mock.MatchedBy(func(alias string) bool { return len(alias) <= 8 }), int64(1), storage.URLOptions{}).
+					Return(nil).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{
+				AliasCeiling: 8,
+				Checksum:     tc.checksum,
+			})
+
+			input := fmt.Sprintf(`{"url": "https://google.com", "alias": %q}`, tc.alias)
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), tc.nickname))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status == http.StatusBadRequest {
+				require.Contains(t, rr.Body.String(), save.CodeAliasTooLong)
+			}
+		})
+	}
+}
+
+func TestSaveHandler_AliasCeilingGenerated(t *testing.T) {
+	long := 20
+
+	cases := []struct {
+		name     string
+		opts     save.Options
+		settings storage.UserSettings
+	}{
+		{name: "Random", opts: save.Options{AliasLength: 12}},
+		{name: "Random with checksum", opts: save.Options{AliasLength: 12, Checksum: true}},
+		{name: "Hash", opts: save.Options{AliasLength: 12, HashAliases: true, AliasSalt: "salt"}},
+		{name: "User preference", opts: save.Options{AliasLength: 6, MaxAliasLength: 30}, settings: storage.UserSettings{DefaultAliasLength: &long}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+				Return(tc.settings, nil).
+				Once()
+			urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", mock.Anything, int64(1), storage.URLOptions{}).
+				Return(nil).
+				Once()
+
+			opts := tc.opts
+			opts.AliasCeiling = 8
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, opts)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Len(t, resp.Alias, 8)
+		})
+	}
+}
+
+func TestSaveHandler_AliasCeilingStopsGrowth(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+		Return(storage.UserSettings{}, nil).
+		Once()
+
+	// Все alias заняты: длина растёт от 6 до предела 7, а не до MaxAliasLength
+	var lengths []int
+	urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", mock.Anything, int64(1), storage.URLOptions{}).
+		Run(func(args mock.Arguments) { lengths = append(lengths, len(args.String(3))) }).
+		Return(storage.ErrAliasTaken)
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{
+		AliasLength:     6,
+		MaxAliasLength:  10,
+		AliasCeiling:    7,
+		CollisionProbes: 1,
+	})
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+	require.Equal(t, []int{6, 7}, lengths)
+}
+
+// prefixOwners - занятые префиксы и их владельцы
+type prefixOwners map[string]int64
+
+// saveURL отклоняет alias под чужим префиксом, как это делает хранилище
+func (p prefixOwners) saveURL(_ context.Context, _ *slog.Logger, _, alias string, userID int64, _ storage.URLOptions) error {
+	for _, candidate := range storage.PrefixCandidates(alias) {
+		if ownerID, ok := p[candidate]; ok && ownerID != userID {
+			return storage.ErrPrefixTaken
+		}
+	}
+
+	return nil
+}
+
+func TestSaveHandler_PrefixCustom(t *testing.T) {
+	prefixes := prefixOwners{"acme": 2}
+
+	cases := []struct {
+		name   string
+		userID int64
+		alias  string
+		status int
+	}{
+		{name: "Owner of prefix", userID: 2, alias: "acme-docs", status: http.StatusOK},
+		{name: "Non-owner blocked", userID: 1, alias: "acme-docs", status: http.StatusForbidden},
+		{name: "Prefix is case-insensitive", userID: 1, alias: "ACME-docs", status: http.StatusForbidden},
+		{name: "Unclaimed prefix is open", userID: 1, alias: "other-docs", status: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(tc.userID, "", nil).
+				Once()
+			urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, tc.userID).
+				Return(storage.UserSettings{}, nil).
+				Once()
+			urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", tc.alias, tc.userID, storage.URLOptions{}).
+				Return(prefixes.saveURL).
+				Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{})
+
+			input := fmt.Sprintf(`{"url": "https://google.com", "alias": "%s"}`, tc.alias)
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status == http.StatusForbidden {
+				var resp save.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.Equal(t, save.CodePrefixClaimed, resp.Code)
+			}
+		})
+	}
+}
+
+func TestSaveHandler_RandomAliasSkipsForeignPrefix(t *testing.T) {
+	// Из алфавита "ab" половина alias начинается с чужого префикса "b"
+	prefixes := prefixOwners{"b": 2}
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil)
+	urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+		Return(storage.UserSettings{}, nil)
+
+	var aliases []string
+	urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", mock.Anything, int64(1), storage.URLOptions{}).
+		Return(func(ctx context.Context, log *slog.Logger, url, alias string, userID int64, opts storage.URLOptions) error {
+			err := prefixes.saveURL(ctx, log, url, alias, userID, opts)
+			if err == nil {
+				aliases = append(aliases, alias)
+			}
+			return err
+		})
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{
+		AliasLength:     2,
+		MaxAliasLength:  8,
+		CollisionProbes: 10,
+		Alphabet:        "ab",
+	})
+
+	for i := 0; i < 30; i++ {
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+		require.NoError(t, err)
+		req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	require.Len(t, aliases, 30)
+	for _, alias := range aliases {
+		require.True(t, strings.HasPrefix(alias, "a"), alias)
+	}
+}
+
+func TestSaveHandler_DuplicateURLs(t *testing.T) {
+	allow, deny := true, false
+
+	cases := []struct {
+		name     string
+		global   bool
+		personal *bool
+		alias    string
+		// existing - alias, под которым адрес уже сокращён; пусто - не сокращался
+		existing string
+		// lookup - политика запрещает повторы, и хранилище опрашивается
+		lookup   bool
+		saved    bool
+		status   int
+		code     string
+		wantResp string
+	}{
+		{
+			name:   "Allowed globally",
+			global: true,
+			saved:  true,
+			status: http.StatusOK,
+		},
+		{
+			name:     "Denied, existing alias returned",
+			existing: "old_alias",
+			lookup:   true,
+			status:   http.StatusOK,
+			wantResp: "old_alias",
+		},
+		{
+			name:     "Denied, custom alias conflicts",
+			alias:    "new_alias",
+			existing: "old_alias",
+			lookup:   true,
+			status:   http.StatusConflict,
+			code:     save.CodeURLDuplicate,
+			wantResp: "old_alias",
+		},
+		{
+			name:   "Denied, first shortening",
+			lookup: true,
+			saved:  true,
+			status: http.StatusOK,
+		},
+		{
+			name:     "Allowed by user setting",
+			personal: &allow,
+			existing: "old_alias",
+			saved:    true,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "Denied by user setting",
+			global:   true,
+			personal: &deny,
+			existing: "old_alias",
+			lookup:   true,
+			status:   http.StatusOK,
+			wantResp: "old_alias",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+				Return(storage.UserSettings{AllowDuplicateURLs: tc.personal}, nil).
+				Once()
+			if tc.saved {
+				urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", mock.Anything, int64(1), mock.Anything).
+					Return(nil).
+					Once()
+			}
+
+			finderMock := mocks.NewDuplicateFinder(t)
+			if tc.lookup {
+				var err error
+				if tc.existing == "" {
+					err = storage.ErrURLNotFound
+				}
+				finderMock.On("GetAliasByURL", mock.Anything, mock.Anything, "https://google.com", int64(1)).
+					Return(tc.existing, err).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{
+				Duplicates:         finderMock,
+				AllowDuplicateURLs: tc.global,
+			})
+
+			body := fmt.Sprintf(`{"url": "https://google.com", "alias": %q}`, tc.alias)
+			req := httptest.NewRequest(http.MethodPost, "/save", strings.NewReader(body))
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.code, resp.Code)
+			if tc.wantResp != "" {
+				require.Equal(t, tc.wantResp, resp.Alias)
+				require.Equal(t, tc.status == http.StatusOK, resp.Existing)
+			}
+			if !tc.lookup {
+				finderMock.AssertNotCalled(t, "GetAliasByURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestSaveHandler_SequenceAliasCeiling(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+		Return(storage.UserSettings{}, nil).
+		Once()
+	urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", mock.Anything, int64(1), mock.Anything).
+		Return(nil).
+		Once()
+
+	sequenceMock := mocks.NewSequenceSaver(t)
+	sequenceMock.On("SaveURLWithSequenceAlias", mock.Anything, mock.Anything, "https://google.com", int64(1), mock.Anything, mock.Anything).
+		Return(func(_ context.Context, _ *slog.Logger, _ string, _ int64, _ storage.URLOptions, aliasFor func(int64) (string, bool)) (string, error) {
+			// 62^4 в base62 - пять символов, больше предела
+			_, ok := aliasFor(62 * 62 * 62 * 62)
+			require.False(t, ok)
+			return "", storage.ErrURLExists
+		}).
+		Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{
+		Sequence:     sequenceMock,
+		AliasLength:  4,
+		AliasCeiling: 4,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/save", strings.NewReader(`{"url": "https://google.com"}`))
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var resp save.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.LessOrEqual(t, len(resp.Alias), 4)
+}
+
+func TestSaveHandler_SequenceAliases(t *testing.T) {
+	cases := []struct {
+		name string
+		// seqErr - ошибка хранилища; nil - alias из id сохранён
+		seqErr    error
+		checksum  bool
+		fallback  bool
+		wantAlias string
+	}{
+		{
+			name:      "Alias from row id",
+			wantAlias: "11",
+		},
+		{
+			name:      "Checksum appended",
+			checksum:  true,
+			wantAlias: checksum.Append("11"),
+		},
+		{
+			name:     "Taken, falls back to random",
+			seqErr:   storage.ErrURLExists,
+			fallback: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+				Return(storage.UserSettings{}, nil).
+				Once()
+			if tc.fallback {
+				urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", mock.Anything, int64(1), mock.Anything).
+					Return(nil).
+					Once()
+			}
+
+			sequenceMock := mocks.NewSequenceSaver(t)
+			sequenceMock.On("SaveURLWithSequenceAlias", mock.Anything, mock.Anything, "https://google.com", int64(1), mock.Anything, mock.Anything).
+				Return(func(_ context.Context, _ *slog.Logger, _ string, _ int64, _ storage.URLOptions, aliasFor func(int64) (string, bool)) (string, error) {
+					if tc.seqErr != nil {
+						return "", tc.seqErr
+					}
+					// Строка получила id 63
+					alias, ok := aliasFor(63)
+					require.True(t, ok)
+					return alias, nil
+				}).
+				Once()
+
+			aliasMetrics := metrics.NewAliasGeneration(metrics.NewRegistry())
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{
+				Sequence: sequenceMock,
+				Checksum: tc.checksum,
+				Metrics:  aliasMetrics,
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/save", strings.NewReader(`{"url": "https://google.com"}`))
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			if tc.fallback {
+				require.NotEmpty(t, resp.Alias)
+				require.Equal(t, int64(1), aliasMetrics.Retries.Value())
+				return
+			}
+			require.Equal(t, tc.wantAlias, resp.Alias)
+			require.Zero(t, aliasMetrics.Retries.Value())
+			urlSaverMock.AssertNotCalled(t, "SaveURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package stale
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	defaultDays = 90
+	maxDays     = 3650
+)
+
+type Response struct {
+	resp.Response
+	Days int           `json:"days,omitempty"`
+	URLs []storage.URL `json:"urls,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=StaleURLGetter
+type StaleURLGetter interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetStaleURLs(ctx context.Context, log *slog.Logger, userID int64, olderThan time.Time) ([]storage.URL, error)
+}
+
+// New отдаёт ссылки пользователя, по которым не переходили последние days дней:
+// GET /url/stale?days=90. Ссылки без переходов попадают в список, если созданы раньше этого срока.
+func New(log *slog.Logger, staleGetter StaleURLGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.stale.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		days := defaultDays
+		if value := r.URL.Query().Get("days"); value != "" {
+			var err error
+			days, err = strconv.Atoi(value)
+			if err != nil || days < 1 || days > maxDays {
+				log.Error("invalid days", slog.String("days", value))
+				render.Status(r, http.StatusBadRequest)
+				resp.JSON(w, r, resp.Error("days must be between 1 and "+strconv.Itoa(maxDays)))
+				return
+			}
+		}
+
+		userID, _, errGetUser := staleGetter.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		olderThan := time.Now().UTC().AddDate(0, 0, -days)
+
+		urls, err := staleGetter.GetStaleURLs(r.Context(), log, userID, olderThan)
+		if err != nil {
+			log.Error("failed to get stale urls", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get stale urls"))
+			return
+		}
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Days:     days,
+			URLs:     urls,
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package stale_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/stale"
+	"url-shortener/internal/http-server/handlers/url/stale/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestStaleHandler(t *testing.T) {
+	lastAccess := time.Now().UTC().AddDate(0, 0, -200)
+	seeded := []storage.URL{{Alias: "old", URL: "https://example.com", LastAccessedAt: &lastAccess}}
+
+	cases := []struct {
+		name   string
+		query  string
+		days   int
+		status int
+	}{
+		{name: "Default window", query: "", days: 90, status: http.StatusOK},
+		{name: "Custom window", query: "?days=30", days: 30, status: http.StatusOK},
+		{name: "Zero days", query: "?days=0", status: http.StatusBadRequest},
+		{name: "Not a number", query: "?days=month", status: http.StatusBadRequest},
+		{name: "Too many days", query: "?days=100000", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			getterMock := mocks.NewStaleURLGetter(t)
+			if tc.status == http.StatusOK {
+				getterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				expected := time.Now().UTC().AddDate(0, 0, -tc.days)
+				getterMock.On("GetStaleURLs", mock.Anything, mock.Anything, int64(1), mock.MatchedBy(func(olderThan time.Time) bool {
+					return olderThan.Sub(expected).Abs() < time.Minute
+				})).
+					Return(seeded, nil).
+					Once()
+			}
+
+			handler := stale.New(slogdiscard.NewDiscardLogger(), getterMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/url/stale"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var resp stale.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.days, resp.Days)
+			require.Len(t, resp.URLs, 1)
+			require.Equal(t, "old", resp.URLs[0].Alias)
+		})
+	}
+}
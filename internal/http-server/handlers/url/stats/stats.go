@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 100
+)
+
+type Response struct {
+	resp.Response
+	Sort   string              `json:"sort,omitempty"`
+	Limit  int                 `json:"limit,omitempty"`
+	Offset int                 `json:"offset"`
+	Stats  []storage.LinkStats `json:"stats,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=StatsGetter
+type StatsGetter interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	LinkStats(ctx context.Context, log *slog.Logger, userID int64, sort string, limit, offset int) ([]storage.LinkStats, error)
+}
+
+// New отдаёт статистику всех ссылок пользователя одним запросом:
+// GET /url/stats?sort=clicks&limit=50&offset=0. По умолчанию ссылки идут от новых к старым.
+func New(log *slog.Logger, statsGetter StatsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.stats.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		query := r.URL.Query()
+
+		sort := query.Get("sort")
+		if sort == "" {
+			sort = storage.StatsSortCreated
+		}
+		if sort != storage.StatsSortCreated && sort != storage.StatsSortClicks {
+			log.Error("invalid sort", slog.String("sort", sort))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("sort must be created_at or clicks"))
+			return
+		}
+
+		limit, err := intParam(query.Get("limit"), defaultLimit)
+		if err != nil || limit < 1 || limit > maxLimit {
+			log.Error("invalid limit", slog.String("limit", query.Get("limit")))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("limit must be between 1 and "+strconv.Itoa(maxLimit)))
+			return
+		}
+
+		offset, err := intParam(query.Get("offset"), 0)
+		if err != nil || offset < 0 {
+			log.Error("invalid offset", slog.String("offset", query.Get("offset")))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("offset must be a non-negative number"))
+			return
+		}
+
+		userID, _, errGetUser := statsGetter.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		stats, err := statsGetter.LinkStats(r.Context(), log, userID, sort, limit, offset)
+		if err != nil {
+			log.Error("failed to get link stats", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get stats"))
+			return
+		}
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Sort:     sort,
+			Limit:    limit,
+			Offset:   offset,
+			Stats:    stats,
+		})
+	}
+}
+
+// intParam разбирает числовой параметр запроса, подставляя def для пустого значения
+func intParam(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,83 @@
+package stats_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/stats"
+	"url-shortener/internal/http-server/handlers/url/stats/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestStatsHandler(t *testing.T) {
+	lastClick := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	seeded := []storage.LinkStats{
+		{Alias: "second", Clicks: 2, CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), LastClickAt: &lastClick},
+		{Alias: "first", Clicks: 0, CreatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	cases := []struct {
+		name   string
+		query  string
+		sort   string
+		limit  int
+		offset int
+		status int
+	}{
+		{name: "Defaults", query: "", sort: storage.StatsSortCreated, limit: 50, status: http.StatusOK},
+		{name: "Sort by clicks", query: "?sort=clicks", sort: storage.StatsSortClicks, limit: 50, status: http.StatusOK},
+		{name: "Page", query: "?limit=10&offset=20", sort: storage.StatsSortCreated, limit: 10, offset: 20, status: http.StatusOK},
+		{name: "Unknown sort", query: "?sort=url", status: http.StatusBadRequest},
+		{name: "Limit too large", query: "?limit=1000", status: http.StatusBadRequest},
+		{name: "Negative offset", query: "?offset=-1", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			getterMock := mocks.NewStatsGetter(t)
+			if tc.status == http.StatusOK {
+				getterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				getterMock.On("LinkStats", mock.Anything, mock.Anything, int64(1), tc.sort, tc.limit, tc.offset).
+					Return(seeded, nil).
+					Once()
+			}
+
+			handler := stats.New(slogdiscard.NewDiscardLogger(), getterMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/url/stats"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var resp stats.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.sort, resp.Sort)
+			require.Len(t, resp.Stats, 2)
+			require.Equal(t, "second", resp.Stats[0].Alias)
+			require.Equal(t, int64(2), resp.Stats[0].Clicks)
+			require.True(t, lastClick.Equal(*resp.Stats[0].LastClickAt))
+			require.Nil(t, resp.Stats[1].LastClickAt)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package suggest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/aliaslimit"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/blacklist"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/random"
+)
+
+type Response struct {
+	resp.Response
+	Alias       string   `json:"alias"`
+	Available   bool     `json:"available"`
+	Suggestions []string `json:"suggestions"`
+}
+
+const (
+	// maxSuggestions ограничивает количество вариантов в ответе
+	maxSuggestions = 5
+	// maxAttempts ограничивает количество проверок в хранилище на один запрос
+	maxAttempts = 20
+	// randomSuffixLength - длина случайного суффикса для вариантов вида foo-xk3
+	randomSuffixLength = 3
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AliasChecker
+type AliasChecker interface {
+	AliasExists(ctx context.Context, log *slog.Logger, alias string) (bool, error)
+}
+
+// New проверяет, свободен ли alias, и предлагает свободные варианты. Alias длиннее
+// общего предела limit считается недоступным, варианты длиннее него не предлагаются.
+func New(log *slog.Logger, aliasChecker AliasChecker, bl *blacklist.Blacklist, limit aliaslimit.Limit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.suggest.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := r.URL.Query().Get("alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		taken, err := aliasChecker.AliasExists(r.Context(), log, alias)
+		if err != nil {
+			log.Error("failed to check alias", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to check alias"))
+			return
+		}
+
+		suggestions, err := Suggest(r.Context(), log, aliasChecker, bl, limit, alias)
+		if err != nil {
+			log.Error("failed to suggest aliases", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to suggest aliases"))
+			return
+		}
+
+		log.Info("aliases suggested", slog.String("alias", alias), slog.Int("count", len(suggestions)))
+
+		resp.JSON(w, r, Response{
+			Response:    resp.OK(),
+			Alias:       alias,
+			Available:   !taken && limit.Allows(alias),
+			Suggestions: suggestions,
+		})
+	}
+}
+
+// Suggest возвращает до maxSuggestions свободных вариантов alias на основе base,
+// делая не более maxAttempts проверок в хранилище. Варианты из чёрного списка и длиннее limit пропускаются.
+func Suggest(
+	ctx context.Context,
+	log *slog.Logger,
+	aliasChecker AliasChecker,
+	bl *blacklist.Blacklist,
+	limit aliaslimit.Limit,
+	base string,
+) ([]string, error) {
+	suggestions := make([]string, 0, maxSuggestions)
+	seen := make(map[string]struct{}, maxAttempts)
+
+	for attempt := 0; attempt < maxAttempts && len(suggestions) < maxSuggestions; attempt++ {
+		candidate := candidate(base, attempt)
+		if _, ok := seen[candidate]; ok {
+			continue
+		}
+		seen[candidate] = struct{}{}
+
+		if bl.Contains(candidate) || !limit.Allows(candidate) {
+			continue
+		}
+
+		exists, err := aliasChecker.AliasExists(ctx, log, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+
+	return suggestions, nil
+}
+
+// candidate чередует варианты: foo2, foo-1, foo-<random>, foo3, foo-2, ...
+func candidate(base string, attempt int) string {
+	n := attempt/3 + 1
+
+	switch attempt % 3 {
+	case 0:
+		return base + strconv.Itoa(n+1)
+	case 1:
+		return base + "-" + strconv.Itoa(n)
+	default:
+		return base + "-" + random.NewRandomString(randomSuffixLength)
+	}
+}
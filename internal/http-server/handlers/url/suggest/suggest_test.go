@@ -0,0 +1,135 @@
+package suggest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/handlers/url/suggest"
+	"url-shortener/internal/http-server/handlers/url/suggest/mocks"
+	"url-shortener/internal/lib/aliaslimit"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestSuggestHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		alias     string
+		taken     map[string]bool
+		available bool
+		respError string
+		respCode  int
+	}{
+		{
+			name:      "Base taken",
+			alias:     "foo",
+			taken:     map[string]bool{"foo": true, "foo2": true, "foo-2": true},
+			available: false,
+			respCode:  http.StatusOK,
+		},
+		{
+			name:      "Base free",
+			alias:     "bar",
+			taken:     map[string]bool{},
+			available: true,
+			respCode:  http.StatusOK,
+		},
+		{
+			name:      "Empty alias",
+			alias:     "",
+			respError: "empty request",
+			respCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			aliasCheckerMock := mocks.NewAliasChecker(t)
+
+			if tc.respError == "" {
+				aliasCheckerMock.On("AliasExists", mock.Anything, mock.Anything, mock.AnythingOfType("string")).
+					Return(func(_ context.Context, _ *slog.Logger, alias string) bool {
+						return tc.taken[alias]
+					}, nil)
+			}
+
+			handler := suggest.New(slogdiscard.NewDiscardLogger(), aliasCheckerMock, nil, aliaslimit.Limit{})
+
+			req, err := http.NewRequest(http.MethodGet, "/url/suggest?alias="+tc.alias, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.respCode, rr.Code)
+
+			var resp suggest.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError != "" {
+				return
+			}
+
+			require.Equal(t, tc.available, resp.Available)
+			require.NotEmpty(t, resp.Suggestions)
+			require.LessOrEqual(t, len(resp.Suggestions), 5)
+
+			for _, s := range resp.Suggestions {
+				require.False(t, tc.taken[s], "suggested alias %q is taken", s)
+				require.NotEqual(t, tc.alias, s)
+			}
+		})
+	}
+}
+
+func TestSuggest_AliasCeiling(t *testing.T) {
+	aliasCheckerMock := mocks.NewAliasChecker(t)
+	aliasCheckerMock.On("AliasExists", mock.Anything, mock.Anything, mock.AnythingOfType("string")).
+		Return(false, nil)
+
+	// С контрольным символом на свой alias остаётся 7 символов: foo-xk3 подходит, foo-xk3x нет
+	limit := aliaslimit.Limit{Max: 8, Checksum: true}
+
+	suggestions, err := suggest.Suggest(context.Background(), slogdiscard.NewDiscardLogger(), aliasCheckerMock, nil, limit, "foo-x")
+	require.NoError(t, err)
+
+	for _, s := range suggestions {
+		require.LessOrEqual(t, len(s), 7, "suggested alias %q is over the ceiling", s)
+	}
+}
+
+func TestSuggestHandler_AliasOverCeiling(t *testing.T) {
+	aliasCheckerMock := mocks.NewAliasChecker(t)
+	aliasCheckerMock.On("AliasExists", mock.Anything, mock.Anything, mock.AnythingOfType("string")).
+		Return(false, nil)
+
+	handler := suggest.New(slogdiscard.NewDiscardLogger(), aliasCheckerMock, nil, aliaslimit.Limit{Max: 4})
+
+	req, err := http.NewRequest(http.MethodGet, "/url/suggest?alias=toolong", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp suggest.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	// Свободный, но слишком длинный alias недоступен
+	require.False(t, resp.Available)
+	require.Empty(t, resp.Suggestions)
+}
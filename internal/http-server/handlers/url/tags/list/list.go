@@ -0,0 +1,68 @@
+package list
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Tags []storage.TagCount `json:"tags"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TagCounter
+type TagCounter interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetTagCounts(ctx context.Context, log *slog.Logger, userID int64, limit int) ([]storage.TagCount, error)
+}
+
+// New отдаёт метки пользователя с числом ссылок для боковой панели: GET /url/tags.
+// Метки идут от частых к редким, maxTags - сколько меток вернуть (0 - все).
+func New(log *slog.Logger, tagCounter TagCounter, maxTags int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.tags.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		userID, _, errGetUser := tagCounter.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		tags, err := tagCounter.GetTagCounts(r.Context(), log, userID, maxTags)
+		if err != nil {
+			log.Error("failed to count tags", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get tags"))
+			return
+		}
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Tags:     tags,
+		})
+	}
+}
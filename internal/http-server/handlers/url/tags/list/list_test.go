@@ -0,0 +1,75 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/tags/list"
+	"url-shortener/internal/http-server/handlers/url/tags/list/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestListHandler(t *testing.T) {
+	counted := []storage.TagCount{
+		{Tag: "go", Count: 3},
+		{Tag: "work", Count: 3},
+		{Tag: "home", Count: 1},
+	}
+
+	cases := []struct {
+		name     string
+		nickname string
+		countErr error
+		status   int
+	}{
+		{name: "Success", nickname: "user", status: http.StatusOK},
+		{name: "Storage error", nickname: "user", countErr: errors.New("boom"), status: http.StatusInternalServerError},
+		{name: "Unauthorized", status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			counterMock := mocks.NewTagCounter(t)
+			if tc.nickname != "" {
+				counterMock.On("GetUserByNickname", mock.Anything, mock.Anything, tc.nickname).
+					Return(int64(1), "", nil).
+					Once()
+				counterMock.On("GetTagCounts", mock.Anything, mock.Anything, int64(1), 20).
+					Return(counted, tc.countErr).
+					Once()
+			}
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), counterMock, 20)
+
+			req, err := http.NewRequest(http.MethodGet, "/url/tags", nil)
+			require.NoError(t, err)
+			if tc.nickname != "" {
+				req = req.WithContext(auth.WithNickname(req.Context(), tc.nickname))
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var resp list.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, counted, resp.Tags)
+		})
+	}
+}
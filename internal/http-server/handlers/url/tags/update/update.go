@@ -0,0 +1,185 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/tags"
+	"url-shortener/internal/storage"
+)
+
+// maxAliases ограничивает размер одного пакета
+const maxAliases = 100
+
+type Request struct {
+	Aliases []string `json:"aliases" validate:"required,min=1,dive,required"`
+	Add     []string `json:"add,omitempty"`
+	Remove  []string `json:"remove,omitempty"`
+}
+
+// Result - новые метки ссылки или причина, по которой их не удалось изменить
+type Result struct {
+	Tags  []string `json:"tags,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Results map[string]Result `json:"results,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TagsUpdater
+type TagsUpdater interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	UpdateURLTags(ctx context.Context, log *slog.Logger, aliases []string, userID int64, add, remove []string, maxTags int) (map[string]storage.TagUpdate, error)
+}
+
+// New добавляет и убирает метки у нескольких ссылок пользователя за один запрос (POST /url/tags).
+// Метки нормализуются так же, как при сохранении, и для каждой ссылки действует лимит maxTags.
+func New(log *slog.Logger, tagsUpdater TagsUpdater, maxTags int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.tags.update.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		if len(req.Aliases) > maxAliases {
+			log.Error("too many aliases requested", slog.Int("count", len(req.Aliases)))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("too many aliases"))
+			return
+		}
+
+		add := tags.Normalize(req.Add)
+		remove := tags.Normalize(req.Remove)
+		if len(add) == 0 && len(remove) == 0 {
+			log.Error("no tags to change")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("add or remove is required"))
+			return
+		}
+		if tag, ok := overlap(add, remove); ok {
+			log.Error("tag is both added and removed", slog.String("tag", tag))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error(fmt.Sprintf("tag %q is in both add and remove", tag)))
+			return
+		}
+
+		userID, _, errGetUser := tagsUpdater.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		updates, err := tagsUpdater.UpdateURLTags(r.Context(), log, uniqueAliases(req.Aliases), userID, add, remove, maxTags)
+		if err != nil {
+			log.Error("failed to update tags", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to update tags"))
+			return
+		}
+
+		results := make(map[string]Result, len(updates))
+		for alias, update := range updates {
+			switch {
+			case errors.Is(update.Err, storage.ErrURLNotFound):
+				results[alias] = Result{Error: "not found"}
+			case access.Denied(update.Err):
+				access.Audit(log, r, nickname, alias)
+				results[alias] = Result{Error: access.Refusal()}
+			case errors.Is(update.Err, storage.ErrTooManyTags):
+				results[alias] = Result{Tags: update.Tags, Error: "too many tags"}
+			case update.Err != nil:
+				results[alias] = Result{Error: "failed to update tags"}
+			default:
+				results[alias] = Result{Tags: update.Tags}
+			}
+		}
+
+		log.Info("tags updated", slog.Int("aliases", len(results)))
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Results:  results,
+		})
+	}
+}
+
+// overlap возвращает первую метку, которая есть и в add, и в remove
+func overlap(add, remove []string) (string, bool) {
+	added := make(map[string]struct{}, len(add))
+	for _, tag := range add {
+		added[tag] = struct{}{}
+	}
+	for _, tag := range remove {
+		if _, ok := added[tag]; ok {
+			return tag, true
+		}
+	}
+
+	return "", false
+}
+
+// uniqueAliases убирает повторы, сохраняя порядок
+func uniqueAliases(aliases []string) []string {
+	seen := make(map[string]struct{}, len(aliases))
+	unique := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		if _, ok := seen[alias]; ok {
+			continue
+		}
+		seen[alias] = struct{}{}
+		unique = append(unique, alias)
+	}
+
+	return unique
+}
@@ -0,0 +1,88 @@
+package update_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/tags/update"
+	"url-shortener/internal/http-server/handlers/url/tags/update/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestUpdateTagsHandler(t *testing.T) {
+	updaterMock := mocks.NewTagsUpdater(t)
+	updaterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	// Метки нормализованы, повторы alias убраны до обращения к хранилищу
+	updaterMock.On("UpdateURLTags", mock.Anything, mock.Anything,
+		[]string{"mine", "foreign", "missing", "full"}, int64(1), []string{"work"}, []string{"old"}, 2).
+		Return(map[string]storage.TagUpdate{
+			"mine":    {Tags: []string{"home", "work"}},
+			"foreign": {Err: storage.ErrUnauthorized},
+			"missing": {Err: storage.ErrURLNotFound},
+			"full":    {Tags: []string{"a", "b"}, Err: storage.ErrTooManyTags},
+		}, nil).
+		Once()
+
+	handler := update.New(slogdiscard.NewDiscardLogger(), updaterMock, 2)
+
+	input := `{"aliases": ["mine", "foreign", "missing", "full", "mine"], "add": ["Work "], "remove": ["OLD"]}`
+	req, err := http.NewRequest(http.MethodPost, "/url/tags", bytes.NewReader([]byte(input)))
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp update.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, map[string]update.Result{
+		"mine":    {Tags: []string{"home", "work"}},
+		"foreign": {Error: "forbidden"},
+		"missing": {Error: "not found"},
+		"full":    {Tags: []string{"a", "b"}, Error: "too many tags"},
+	}, resp.Results)
+}
+
+func TestUpdateTagsHandler_NothingToChange(t *testing.T) {
+	updaterMock := mocks.NewTagsUpdater(t)
+
+	handler := update.New(slogdiscard.NewDiscardLogger(), updaterMock, 2)
+
+	req, err := http.NewRequest(http.MethodPost, "/url/tags", bytes.NewReader([]byte(`{"aliases": ["mine"], "add": [" "]}`)))
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestUpdateTagsHandler_AddAndRemoveOverlap(t *testing.T) {
+	updaterMock := mocks.NewTagsUpdater(t)
+
+	handler := update.New(slogdiscard.NewDiscardLogger(), updaterMock, 2)
+
+	input := `{"aliases": ["mine"], "add": ["work", "home"], "remove": ["Work"]}`
+	req, err := http.NewRequest(http.MethodPost, "/url/tags", bytes.NewReader([]byte(input)))
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Contains(t, rr.Body.String(), "work")
+}
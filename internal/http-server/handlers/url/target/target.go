@@ -0,0 +1,76 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	URL string `json:"url,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LinkGetter
+type LinkGetter interface {
+	GetLink(ctx context.Context, log *slog.Logger, alias string) (storage.URL, error)
+}
+
+// robotsTag - адрес назначения не должен попадать в поисковую выдачу под адресом сервиса
+const robotsTag = "noindex"
+
+// New отдаёт адрес назначения публичной ссылки в JSON без редиректа и без авторизации:
+// GET /target/{alias}. Нужен ботам и клиентам, которые показывают ссылку, а не открывают её,
+// поэтому переход не засчитывается. Приватные, истёкшие и несуществующие ссылки одинаково отдают 404.
+// Маршрут вынесен из /r/, где /r/{alias}/target - обычный подпуть wildcard-ссылки.
+func New(log *slog.Logger, linkGetter LinkGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.target.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		w.Header().Set("X-Robots-Tag", robotsTag)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		link, err := linkGetter.GetLink(r.Context(), log, alias)
+		if err != nil && !errors.Is(err, storage.ErrURLNotFound) {
+			log.Error("failed to get link", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get url"))
+			return
+		}
+		if err != nil || !link.Public || storage.Expired(link.ExpiresAt, time.Now()) {
+			log.Info("public link not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error("not found"))
+			return
+		}
+
+		log.Info("got target", slog.String("alias", alias))
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			URL:      link.URL,
+		})
+	}
+}
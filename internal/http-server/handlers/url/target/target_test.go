@@ -0,0 +1,86 @@
+package target_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/target"
+	"url-shortener/internal/http-server/handlers/url/target/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestTargetHandler(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		name    string
+		link    storage.URL
+		mockErr error
+		status  int
+		url     string
+	}{
+		{
+			name:   "Public",
+			link:   storage.URL{Alias: "test_alias", URL: "https://example.com/page", Public: true},
+			status: http.StatusOK,
+			url:    "https://example.com/page",
+		},
+		{
+			name:   "Private",
+			link:   storage.URL{Alias: "test_alias", URL: "https://example.com/page"},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "Expired",
+			link:   storage.URL{Alias: "test_alias", URL: "https://example.com/page", Public: true, ExpiresAt: &expired},
+			status: http.StatusNotFound,
+		},
+		{
+			name:    "Missing",
+			mockErr: storage.ErrURLNotFound,
+			status:  http.StatusNotFound,
+		},
+		{
+			name:    "Storage error",
+			mockErr: errors.New("unexpected error"),
+			status:  http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Переходы не засчитываются: у мока нет RecordClick
+			linkGetterMock := mocks.NewLinkGetter(t)
+			linkGetterMock.On("GetLink", mock.Anything, mock.Anything, "test_alias").
+				Return(tc.link, tc.mockErr).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/target/{alias}", target.New(slogdiscard.NewDiscardLogger(), linkGetterMock))
+
+			req := httptest.NewRequest(http.MethodGet, "/target/test_alias", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			require.Equal(t, "noindex", rr.Header().Get("X-Robots-Tag"))
+
+			var resp target.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.url, resp.URL)
+		})
+	}
+}
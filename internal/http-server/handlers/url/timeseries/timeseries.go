@@ -0,0 +1,197 @@
+package timeseries
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	// maxBuckets ограничивает размер ответа
+	maxBuckets = 1000
+	// defaultBuckets - сколько интервалов показывать, если from не задан
+	defaultBuckets = 30
+)
+
+type Response struct {
+	resp.Response
+	Alias   string                `json:"alias,omitempty"`
+	Bucket  string                `json:"bucket,omitempty"`
+	From    time.Time             `json:"from,omitempty"`
+	To      time.Time             `json:"to,omitempty"`
+	Buckets []storage.ClickBucket `json:"buckets,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ClickCounter
+type ClickCounter interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetLink(ctx context.Context, log *slog.Logger, alias string) (storage.URL, error)
+	ClickTimeseries(ctx context.Context, log *slog.Logger, alias string, from, to time.Time, bucket time.Duration) ([]storage.ClickBucket, error)
+}
+
+// New отдаёт владельцу число переходов по ссылке, сгруппированное по интервалам:
+// GET /url/{alias}/timeseries?from=&to=&bucket=day. from и to принимаются в RFC 3339
+// или как дата 2006-01-02; интервалы без переходов заполняются нулями.
+func New(log *slog.Logger, clickCounter ClickCounter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.timeseries.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		query := r.URL.Query()
+
+		bucket := query.Get("bucket")
+		if bucket == "" {
+			bucket = storage.BucketDay
+		}
+		size, ok := storage.BucketSizes[bucket]
+		if !ok {
+			log.Error("invalid bucket", slog.String("bucket", bucket))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("bucket must be hour or day"))
+			return
+		}
+
+		to := time.Now().UTC()
+		if v := query.Get("to"); v != "" {
+			parsed, err := parseTime(v)
+			if err != nil {
+				log.Error("invalid to", slog.String("to", v), sl.Err(err))
+				render.Status(r, http.StatusBadRequest)
+				resp.JSON(w, r, resp.Error("invalid to, use RFC 3339 or YYYY-MM-DD"))
+				return
+			}
+			to = parsed
+		}
+
+		from := to.Add(-defaultBuckets * size)
+		if v := query.Get("from"); v != "" {
+			parsed, err := parseTime(v)
+			if err != nil {
+				log.Error("invalid from", slog.String("from", v), sl.Err(err))
+				render.Status(r, http.StatusBadRequest)
+				resp.JSON(w, r, resp.Error("invalid from, use RFC 3339 or YYYY-MM-DD"))
+				return
+			}
+			from = parsed
+		}
+		from = truncate(from, size)
+
+		if !from.Before(to) {
+			log.Error("invalid time range", slog.Time("from", from), slog.Time("to", to))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("from must be before to"))
+			return
+		}
+		if to.Sub(from) > maxBuckets*size {
+			log.Error("time range is too large", slog.Time("from", from), slog.Time("to", to))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("time range is too large for this bucket"))
+			return
+		}
+
+		userID, _, errGetUser := clickCounter.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		// Статистика доступна только владельцу
+		link, err := clickCounter.GetLink(r.Context(), log, alias)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			log.Info("url not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error(access.NotFoundMessage))
+			return
+		case err != nil:
+			log.Error("failed to get url", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get url"))
+			return
+		}
+
+		if err := access.Check(link.UserID, userID); err != nil {
+			access.Deny(w, r, log, nickname, alias)
+			return
+		}
+
+		counted, err := clickCounter.ClickTimeseries(r.Context(), log, alias, from, to, size)
+		if err != nil {
+			log.Error("failed to count clicks", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to count clicks"))
+			return
+		}
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Alias:    alias,
+			Bucket:   bucket,
+			From:     from,
+			To:       to,
+			Buckets:  fill(from, to, size, counted),
+		})
+	}
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+
+	return time.Parse("2006-01-02", v)
+}
+
+// truncate выравнивает время по границе интервала, отсчитывая от unix-эпохи в UTC
+func truncate(t time.Time, size time.Duration) time.Time {
+	return time.Unix(0, 0).UTC().Add(t.Sub(time.Unix(0, 0)).Truncate(size))
+}
+
+// fill превращает разреженный результат хранилища в непрерывный ряд с нулями
+func fill(from, to time.Time, size time.Duration, counted []storage.ClickBucket) []storage.ClickBucket {
+	clicks := make(map[int64]int64, len(counted))
+	for _, b := range counted {
+		clicks[b.Start.Unix()] = b.Clicks
+	}
+
+	buckets := make([]storage.ClickBucket, 0, int(to.Sub(from)/size)+1)
+	for start := from; start.Before(to); start = start.Add(size) {
+		buckets = append(buckets, storage.ClickBucket{Start: start, Clicks: clicks[start.Unix()]})
+	}
+
+	return buckets
+}
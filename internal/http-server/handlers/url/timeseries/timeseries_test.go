@@ -0,0 +1,130 @@
+package timeseries_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/timeseries"
+	"url-shortener/internal/http-server/handlers/url/timeseries/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func serve(t *testing.T, counter *mocks.ClickCounter, target string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/timeseries", timeseries.New(slogdiscard.NewDiscardLogger(), counter))
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func day(d int) time.Time {
+	return time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestTimeseriesHandler_ZeroFilled(t *testing.T) {
+	counter := mocks.NewClickCounter(t)
+	counter.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	counter.On("GetLink", mock.Anything, mock.Anything, "test_alias").
+		Return(storage.URL{Alias: "test_alias", UserID: 1}, nil).
+		Once()
+	counter.On("ClickTimeseries", mock.Anything, mock.Anything, "test_alias", day(1), day(5), 24*time.Hour).
+		Return([]storage.ClickBucket{
+			{Start: day(1), Clicks: 3},
+			{Start: day(3), Clicks: 1},
+		}, nil).
+		Once()
+
+	rr := serve(t, counter, "/url/test_alias/timeseries?from=2024-01-01&to=2024-01-05&bucket=day")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp timeseries.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	require.Equal(t, []storage.ClickBucket{
+		{Start: day(1), Clicks: 3},
+		{Start: day(2), Clicks: 0},
+		{Start: day(3), Clicks: 1},
+		{Start: day(4), Clicks: 0},
+	}, resp.Buckets)
+}
+
+func TestTimeseriesHandler_Validation(t *testing.T) {
+	cases := []struct {
+		name   string
+		target string
+	}{
+		{
+			name:   "Unknown bucket",
+			target: "/url/test_alias/timeseries?bucket=minute",
+		},
+		{
+			name:   "Invalid from",
+			target: "/url/test_alias/timeseries?from=yesterday",
+		},
+		{
+			name:   "Empty range",
+			target: "/url/test_alias/timeseries?from=2024-01-05&to=2024-01-01",
+		},
+		{
+			name:   "Too many buckets",
+			target: "/url/test_alias/timeseries?from=2020-01-01&to=2024-01-01&bucket=hour",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			rr := serve(t, mocks.NewClickCounter(t), tc.target)
+
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestTimeseriesHandler_NotOwner(t *testing.T) {
+	counter := mocks.NewClickCounter(t)
+	counter.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	counter.On("GetLink", mock.Anything, mock.Anything, "foreign").
+		Return(storage.URL{Alias: "foreign", UserID: 2}, nil).
+		Once()
+
+	rr := serve(t, counter, "/url/foreign/timeseries?from=2024-01-01&to=2024-01-05")
+
+	require.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestTimeseriesHandler_NotFound(t *testing.T) {
+	counter := mocks.NewClickCounter(t)
+	counter.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	counter.On("GetLink", mock.Anything, mock.Anything, "missing").
+		Return(storage.URL{}, storage.ErrURLNotFound).
+		Once()
+
+	rr := serve(t, counter, "/url/missing/timeseries?from=2024-01-01&to=2024-01-05")
+
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
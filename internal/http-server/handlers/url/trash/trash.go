@@ -0,0 +1,97 @@
+package trash
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// Item - ссылка в корзине и оставшееся время на её восстановление
+type Item struct {
+	storage.URL
+	RestoreUntil       time.Time `json:"restore_until"`
+	RestoreSecondsLeft int64     `json:"restore_seconds_left"`
+}
+
+type Response struct {
+	resp.Response
+	URLs []Item `json:"urls"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=DeletedURLGetter
+type DeletedURLGetter interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetDeletedURLsByUser(ctx context.Context, log *slog.Logger, userID int64) ([]storage.URL, error)
+}
+
+// New отдаёт удалённые, но ещё не очищенные ссылки пользователя: GET /url/trash.
+// Ссылки, у которых истёк restoreWindow, не показываются: восстановить их уже нельзя,
+// а из базы их удаляет trashpurge.Purger.
+func New(log *slog.Logger, deletedGetter DeletedURLGetter, restoreWindow time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.trash.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		userID, _, errGetUser := deletedGetter.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		urls, err := deletedGetter.GetDeletedURLsByUser(r.Context(), log, userID)
+		if err != nil {
+			log.Error("failed to get deleted urls", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get deleted urls"))
+			return
+		}
+
+		now := time.Now()
+		items := make([]Item, 0, len(urls))
+		for _, u := range urls {
+			if u.DeletedAt == nil {
+				continue
+			}
+
+			restoreUntil := u.DeletedAt.Add(restoreWindow)
+			left := restoreUntil.Sub(now)
+			if left <= 0 {
+				continue
+			}
+
+			items = append(items, Item{
+				URL:                u,
+				RestoreUntil:       restoreUntil.UTC(),
+				RestoreSecondsLeft: int64(left / time.Second),
+			})
+		}
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			URLs:     items,
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package trash_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/trash"
+	"url-shortener/internal/http-server/handlers/url/trash/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestTrashHandler(t *testing.T) {
+	recent := time.Now().Add(-time.Hour)
+	old := time.Now().Add(-48 * time.Hour)
+
+	getterMock := mocks.NewDeletedURLGetter(t)
+	getterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	getterMock.On("GetDeletedURLsByUser", mock.Anything, mock.Anything, int64(1)).
+		Return([]storage.URL{
+			{Alias: "recent", URL: "https://example.com/recent", DeletedAt: &recent},
+			{Alias: "old", URL: "https://example.com/old", DeletedAt: &old},
+		}, nil).
+		Once()
+
+	handler := trash.New(slogdiscard.NewDiscardLogger(), getterMock, 24*time.Hour)
+
+	req, err := http.NewRequest(http.MethodGet, "/url/trash", nil)
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp trash.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	// Ссылка за пределами окна восстановления не показывается
+	require.Len(t, resp.URLs, 1)
+	item := resp.URLs[0]
+	require.Equal(t, "recent", item.Alias)
+	require.NotNil(t, item.DeletedAt)
+	require.WithinDuration(t, recent.Add(24*time.Hour), item.RestoreUntil, time.Second)
+	require.InDelta(t, int64(23*time.Hour/time.Second), item.RestoreSecondsLeft, 5)
+}
@@ -0,0 +1,142 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// CodeDescriptionTooLong - описание длиннее допустимого, как у POST /url/save
+const CodeDescriptionTooLong = "description_too_long"
+
+// Request - изменяемые поля ссылки. Указатель отличает отсутствующее поле от пустой строки,
+// которая удаляет описание.
+type Request struct {
+	Description *string `json:"description" validate:"required"`
+}
+
+type Response struct {
+	resp.Response
+	Alias       string `json:"alias,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLUpdater
+type URLUpdater interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	UpdateURLDescription(ctx context.Context, log *slog.Logger, alias string, userID int64, description string) error
+}
+
+// New меняет описание ссылки владельца: PATCH /url/{alias}. Пробелы по краям не сохраняются,
+// maxDescriptionLength - максимум символов в описании (0 - без ограничения).
+func New(log *slog.Logger, urlUpdater URLUpdater, maxDescriptionLength int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		description := strings.TrimSpace(*req.Description)
+		if maxDescriptionLength > 0 && utf8.RuneCountInString(description) > maxDescriptionLength {
+			log.Info("description is too long", slog.Int("length", utf8.RuneCountInString(description)))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ErrorWithCode(
+				fmt.Sprintf("description must be at most %d characters long", maxDescriptionLength),
+				CodeDescriptionTooLong,
+			))
+			return
+		}
+
+		userID, _, errGetUser := urlUpdater.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		err = urlUpdater.UpdateURLDescription(r.Context(), log, alias, userID, description)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			log.Info("url not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error(access.NotFoundMessage))
+			return
+		case access.Denied(err):
+			access.Deny(w, r, log, nickname, alias)
+			return
+		case err != nil:
+			log.Error("failed to update url description", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to update url"))
+			return
+		}
+
+		log.Info("url description updated", slog.String("alias", alias))
+
+		resp.JSON(w, r, Response{
+			Response:    resp.OK(),
+			Alias:       alias,
+			Description: description,
+		})
+	}
+}
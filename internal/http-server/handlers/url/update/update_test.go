@@ -0,0 +1,121 @@
+package update_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/update"
+	"url-shortener/internal/http-server/handlers/url/update/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestUpdateHandler(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		// stored - описание, переданное в хранилище; nil - запрос до хранилища не дошёл
+		stored  *string
+		mockErr error
+		status  int
+		code    string
+	}{
+		{
+			name:   "Set description",
+			input:  `{"description": "  campaign landing for Q3  "}`,
+			stored: ptr("campaign landing for Q3"),
+			status: http.StatusOK,
+		},
+		{
+			name:   "Clear description",
+			input:  `{"description": ""}`,
+			stored: ptr(""),
+			status: http.StatusOK,
+		},
+		{
+			name:   "Multibyte at limit",
+			input:  `{"description": "ссылка для всех и каждой"}`,
+			stored: ptr("ссылка для всех и каждой"),
+			status: http.StatusOK,
+		},
+		{
+			name:   "Too long",
+			input:  `{"description": "this description is far too long"}`,
+			status: http.StatusBadRequest,
+			code:   update.CodeDescriptionTooLong,
+		},
+		{
+			name:    "Not owner",
+			input:   `{"description": "mine"}`,
+			stored:  ptr("mine"),
+			mockErr: storage.ErrUnauthorized,
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "Unknown alias",
+			input:   `{"description": "mine"}`,
+			stored:  ptr("mine"),
+			mockErr: storage.ErrURLNotFound,
+			status:  http.StatusNotFound,
+		},
+		{
+			name:   "Missing field",
+			input:  `{}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Empty body",
+			input:  ``,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			updaterMock := mocks.NewURLUpdater(t)
+			if tc.stored != nil {
+				updaterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				updaterMock.On("UpdateURLDescription", mock.Anything, mock.Anything, "test_alias", int64(1), *tc.stored).
+					Return(tc.mockErr).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Patch("/url/{alias}", update.New(slogdiscard.NewDiscardLogger(), updaterMock, 24))
+
+			req, err := http.NewRequest(http.MethodPatch, "/url/test_alias", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code, rr.Body.String())
+
+			var resp update.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.code, resp.Code)
+			if tc.status == http.StatusOK {
+				require.Equal(t, *tc.stored, resp.Description)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,124 @@
+package visibility
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// Request - новая видимость ссылки. Указатель отличает отсутствующее поле от false.
+type Request struct {
+	IsPublic *bool `json:"is_public" validate:"required"`
+}
+
+type Response struct {
+	resp.Response
+	Alias    string `json:"alias,omitempty"`
+	IsPublic bool   `json:"is_public"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=VisibilitySetter
+type VisibilitySetter interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	SetURLVisibility(ctx context.Context, log *slog.Logger, alias string, isPublic bool, userID int64) error
+}
+
+// New делает ссылку владельца публичной или приватной: PATCH /url/{alias}/visibility.
+// Публичная ссылка открывается без авторизации через /r/{alias}, приватная там отдаёт 404.
+func New(log *slog.Logger, visibilitySetter VisibilitySetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.visibility.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		userID, _, errGetUser := visibilitySetter.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		err = visibilitySetter.SetURLVisibility(r.Context(), log, alias, *req.IsPublic, userID)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			log.Info("url not found", slog.String("alias", alias))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error("not found"))
+			return
+		case access.Denied(err):
+			access.Deny(w, r, log, nickname, alias)
+			return
+		case err != nil:
+			log.Error("failed to set url visibility", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to set visibility"))
+			return
+		}
+
+		log.Info("url visibility changed", slog.String("alias", alias), slog.Bool("is_public", *req.IsPublic))
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Alias:    alias,
+			IsPublic: *req.IsPublic,
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package visibility_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/handlers/url/public"
+	"url-shortener/internal/http-server/handlers/url/visibility"
+	"url-shortener/internal/http-server/handlers/url/visibility/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestVisibilityHandler(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		mockErr error
+		// callsStorage - запрос прошёл проверки и дошёл до хранилища
+		callsStorage bool
+		status       int
+	}{
+		{
+			name:         "Make public",
+			input:        `{"is_public": true}`,
+			callsStorage: true,
+			status:       http.StatusOK,
+		},
+		{
+			name:         "Make private",
+			input:        `{"is_public": false}`,
+			callsStorage: true,
+			status:       http.StatusOK,
+		},
+		{
+			name:         "Not owner",
+			input:        `{"is_public": true}`,
+			mockErr:      storage.ErrUnauthorized,
+			callsStorage: true,
+			status:       http.StatusForbidden,
+		},
+		{
+			name:         "Unknown alias",
+			input:        `{"is_public": true}`,
+			mockErr:      storage.ErrURLNotFound,
+			callsStorage: true,
+			status:       http.StatusNotFound,
+		},
+		{
+			name:   "Missing field",
+			input:  `{}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Empty body",
+			input:  ``,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			setterMock := mocks.NewVisibilitySetter(t)
+			if tc.callsStorage {
+				setterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				setterMock.On("SetURLVisibility", mock.Anything, mock.Anything, "test_alias", mock.AnythingOfType("bool"), int64(1)).
+					Return(tc.mockErr).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Patch("/url/{alias}/visibility", visibility.New(slogdiscard.NewDiscardLogger(), setterMock))
+
+			req, err := http.NewRequest(http.MethodPatch, "/url/test_alias/visibility", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+		})
+	}
+}
+
+// linkStore - хранилище одной ссылки, общее для смены видимости и публичного перехода
+type linkStore struct {
+	link storage.URL
+}
+
+func (s *linkStore) GetUserByNickname(_ context.Context, _ *slog.Logger, _ string) (int64, string, error) {
+	return 1, "", nil
+}
+
+func (s *linkStore) SetURLVisibility(_ context.Context, _ *slog.Logger, alias string, isPublic bool, userID int64) error {
+	if alias != s.link.Alias {
+		return storage.ErrURLNotFound
+	}
+	if userID != s.link.UserID {
+		return storage.ErrUnauthorized
+	}
+	s.link.Public = isPublic
+	return nil
+}
+
+func (s *linkStore) GetLink(_ context.Context, _ *slog.Logger, alias string) (storage.URL, error) {
+	if alias != s.link.Alias {
+		return storage.URL{}, storage.ErrURLNotFound
+	}
+	return s.link, nil
+}
+
+func (s *linkStore) RecordClick(_ context.Context, _ *slog.Logger, _ string) error {
+	return nil
+}
+
+func (s *linkStore) GetUserSettings(_ context.Context, _ *slog.Logger, _ int64) (storage.UserSettings, error) {
+	return storage.UserSettings{}, nil
+}
+
+func TestVisibilityToggle_PublicRoute(t *testing.T) {
+	store := &linkStore{link: storage.URL{Alias: "test_alias", URL: "https://example.com", UserID: 1}}
+	log := slogdiscard.NewDiscardLogger()
+
+	r := chi.NewRouter()
+	r.Patch("/url/{alias}/visibility", visibility.New(log, store))
+	r.Get("/r/{alias}", public.New(log, store, public.Options{}))
+
+	toggle := func(isPublic bool) {
+		body, err := json.Marshal(map[string]bool{"is_public": isPublic})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPatch, "/url/test_alias/visibility", bytes.NewReader(body))
+		req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp visibility.Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, isPublic, resp.IsPublic)
+	}
+	open := func() int {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/r/test_alias", nil))
+		return rr.Code
+	}
+
+	require.Equal(t, http.StatusNotFound, open())
+
+	toggle(true)
+	require.Equal(t, http.StatusFound, open())
+
+	toggle(false)
+	require.Equal(t, http.StatusNotFound, open())
+}
@@ -1,17 +1,28 @@
 package delete
 
 import (
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"golang.org/x/exp/slog"
 	"golang.org/x/net/context"
+	"io"
 	"net/http"
+	"url-shortener/internal/http-server/middleware/auth"
 	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
 )
 
+// Request - подтверждение удаления аккаунта текущим паролем
+type Request struct {
+	Password string `json:"password"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=DeleteUser
 type DeleteUser interface {
 	DeleteUserByNickname(ctx context.Context, log *slog.Logger, nickname string) error
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
 }
 
 func New(log *slog.Logger, deleteUser DeleteUser) http.HandlerFunc {
@@ -27,23 +38,56 @@ func New(log *slog.Logger, deleteUser DeleteUser) http.HandlerFunc {
 		nickname := chi.URLParam(r, "nickname")
 
 		// Получаем никнейм пользователя из контекста (из токена авторизации)
-		authNickname, ok := r.Context().Value("nickname").(string)
+		authNickname, ok := auth.NicknameFromContext(r.Context())
 		if !ok {
 			log.Error("failed to get authorized user nickname from context")
-			render.JSON(w, r, resp.Error("unauthorized request"))
+			resp.JSON(w, r, resp.Error("unauthorized request"))
 			return
 		}
 
 		// Проверяем, что переданный в запросе nickname совпадает с ником из токена авторизации
 		if nickname != authNickname {
 			log.Error("unauthorized attempt to delete another user's account", slog.String("authNickname", authNickname), slog.String("nickname", nickname))
-			render.JSON(w, r, resp.Error("unauthorized action"))
+			resp.JSON(w, r, resp.Error("unauthorized action"))
 			return
 		}
 
 		if nickname == "" {
 			log.Error("nickname is empty")
-			render.JSON(w, r, resp.Error("empty request"))
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		// Удаление аккаунта необратимо, поэтому одного токена недостаточно - требуем текущий пароль
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.Password == "" {
+			log.Error("password is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("password is required"))
+			return
+		}
+
+		_, passwordHash, errGetUser := deleteUser.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		if !auth.CheckPasswordHash(req.Password, passwordHash) {
+			log.Error("wrong password on account deletion", slog.String("nickname", nickname))
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("wrong password"))
 			return
 		}
 
@@ -51,11 +95,11 @@ func New(log *slog.Logger, deleteUser DeleteUser) http.HandlerFunc {
 		errDeleteUser := deleteUser.DeleteUserByNickname(r.Context(), log, nickname)
 		if errDeleteUser != nil {
 			log.Error(errDeleteUser.Error(), "error", errDeleteUser)
-			render.JSON(w, r, resp.Error(errDeleteUser.Error()))
+			resp.JSON(w, r, resp.Error(errDeleteUser.Error()))
 			return
 		}
 
 		log.Info("user deleted successfully", slog.String("nickname", nickname))
-		render.JSON(w, r, resp.OK())
+		resp.JSON(w, r, resp.OK())
 	}
 }
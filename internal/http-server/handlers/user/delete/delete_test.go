@@ -0,0 +1,91 @@
+package delete_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	deleteUser "url-shortener/internal/http-server/handlers/user/delete"
+	"url-shortener/internal/http-server/handlers/user/delete/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestDeleteUserHandler(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		body      string
+		status    int
+		respError string
+		deletes   bool
+	}{
+		{
+			name:    "Correct password",
+			body:    `{"password": "secret"}`,
+			status:  http.StatusOK,
+			deletes: true,
+		},
+		{
+			name:      "Wrong password",
+			body:      `{"password": "wrong"}`,
+			status:    http.StatusUnauthorized,
+			respError: "wrong password",
+		},
+		{
+			name:      "Missing password",
+			body:      "",
+			status:    http.StatusBadRequest,
+			respError: "password is required",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deleteUserMock := mocks.NewDeleteUser(t)
+
+			if tc.body != "" {
+				deleteUserMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), string(hash), nil).
+					Once()
+			}
+			if tc.deletes {
+				deleteUserMock.On("DeleteUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(nil).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Delete("/user/{nickname}", func(w http.ResponseWriter, r *http.Request) {
+				ctx := auth.WithNickname(r.Context(), "user")
+				deleteUser.New(slogdiscard.NewDiscardLogger(), deleteUserMock).ServeHTTP(w, r.WithContext(ctx))
+			})
+
+			req, err := http.NewRequest(http.MethodDelete, "/user/user", strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			var body resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			require.Equal(t, tc.respError, body.Error)
+		})
+	}
+}
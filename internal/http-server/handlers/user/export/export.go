@@ -0,0 +1,82 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// Bundle - все данные пользователя, выгружаемые по запросу. Хэш пароля не выгружается.
+type Bundle struct {
+	Profile    storage.User  `json:"profile"`
+	URLs       []storage.URL `json:"urls"`
+	ExportedAt time.Time     `json:"exported_at"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AccountExporter
+type AccountExporter interface {
+	GetUser(ctx context.Context, log *slog.Logger, nickname string) (storage.User, error)
+	GetURLsByUser(ctx context.Context, log *slog.Logger, userID int64) ([]storage.URL, error)
+}
+
+func New(log *slog.Logger, exporter AccountExporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.user.export.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		user, err := exporter.GetUser(r.Context(), log, nickname)
+		if err != nil {
+			log.Error("failed to get user", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to export account"))
+			return
+		}
+
+		urls, err := exporter.GetURLsByUser(r.Context(), log, user.ID)
+		if err != nil {
+			log.Error("failed to list urls", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to export account"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-export.json"`, nickname))
+
+		// Пишем ответ сразу в ResponseWriter, не собирая его целиком в памяти
+		err = json.NewEncoder(w).Encode(Bundle{
+			Profile:    user,
+			URLs:       urls,
+			ExportedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			log.Error("failed to write export", sl.Err(err))
+			return
+		}
+
+		log.Info("account exported", slog.String("nickname", nickname), slog.Int("urls", len(urls)))
+	}
+}
@@ -0,0 +1,61 @@
+package export_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/user/export"
+	"url-shortener/internal/http-server/handlers/user/export/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestExportHandler(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	urls := []storage.URL{
+		{Alias: "first", URL: "https://google.com", CreatedAt: createdAt},
+		{Alias: "second", URL: "https://example.com", CreatedAt: createdAt},
+	}
+
+	exporterMock := mocks.NewAccountExporter(t)
+	exporterMock.On("GetUser", mock.Anything, mock.Anything, "user").
+		Return(storage.User{ID: 7, Nickname: "user", CreatedAt: createdAt}, nil).
+		Once()
+	exporterMock.On("GetURLsByUser", mock.Anything, mock.Anything, int64(7)).
+		Return(urls, nil).
+		Once()
+
+	handler := export.New(slogdiscard.NewDiscardLogger(), exporterMock)
+
+	req, err := http.NewRequest(http.MethodGet, "/user/export", nil)
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Header().Get("Content-Disposition"), "attachment")
+
+	var bundle export.Bundle
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &bundle))
+
+	require.Equal(t, "user", bundle.Profile.Nickname)
+	require.Equal(t, urls, bundle.URLs)
+
+	// Хэш пароля и внутренний id не должны попадать в выгрузку
+	var raw struct {
+		Profile map[string]any `json:"profile"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &raw))
+	require.NotContains(t, raw.Profile, "password_hash")
+	require.NotContains(t, raw.Profile, "hash")
+	require.NotContains(t, raw.Profile, "id")
+}
@@ -8,10 +8,13 @@ import (
 	"github.com/go-playground/validator/v10"
 	"golang.org/x/exp/slog"
 	"io"
+	"net"
 	"net/http"
+	"time"
 	"url-shortener/internal/http-server/middleware/auth"
 	resp "url-shortener/internal/lib/api/response"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
 )
 
 type Request struct {
@@ -22,13 +25,37 @@ type Request struct {
 type LoginResponse struct {
 	Status string `json:"status"`
 	Token  string `json:"token"`
+	// Sessions - число активных сессий пользователя с учётом нового входа
+	Sessions int `json:"sessions,omitempty"`
 }
 
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=GetUser
 type GetUser interface {
 	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
 }
 
-func New(log *slog.Logger, getUser GetUser) http.HandlerFunc {
+// LoginRecorder сохраняет попытки входа для GET /user/logins
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LoginRecorder
+type LoginRecorder interface {
+	RecordLoginEvent(ctx context.Context, log *slog.Logger, userID int64, event storage.LoginEvent, keep int) error
+}
+
+// Options - настройки обработчика входа
+type Options struct {
+	// Events - куда записываются попытки входа; nil - не записываются
+	Events LoginRecorder
+	// KeepEvents - сколько последних попыток хранится у пользователя; 0 - все
+	KeepEvents int
+	// CanonicalNicknames - никнеймы хэшируются, и "Alice" и " alice" - один пользователь.
+	// Токен выдаётся на storage.CanonicalNickname, чтобы лимит сессий и права
+	// администратора не зависели от написания никнейма при входе.
+	CanonicalNicknames bool
+}
+
+// New выдаёт токен по никнейму и паролю. Успешные входы и неверные пароли существующих
+// пользователей записываются в opts.Events; попытки входа в несуществующий аккаунт - нет.
+func New(log *slog.Logger, getUser GetUser, opts Options) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.user.login.New"
 
@@ -45,14 +72,14 @@ func New(log *slog.Logger, getUser GetUser) http.HandlerFunc {
 			// Обработаем её отдельно
 			log.Error("request body is empty")
 
-			render.JSON(w, r, resp.Error("empty request"))
+			resp.JSON(w, r, resp.Error("empty request"))
 
 			return
 		}
 		if err != nil {
 			log.Error("failed to decode request body", sl.Err(err))
 
-			render.JSON(w, r, resp.Error("failed to decode request"))
+			resp.JSON(w, r, resp.Error("failed to decode request"))
 
 			return
 		}
@@ -64,7 +91,7 @@ func New(log *slog.Logger, getUser GetUser) http.HandlerFunc {
 
 			log.Error("invalid request", sl.Err(err))
 
-			render.JSON(w, r, resp.ValidationError(validateErr))
+			resp.JSON(w, r, resp.ValidationError(validateErr))
 
 			return
 		}
@@ -72,22 +99,60 @@ func New(log *slog.Logger, getUser GetUser) http.HandlerFunc {
 		userID, passwordHash, errGetUser := getUser.GetUserByNickname(r.Context(), log, req.Nickname)
 		if errGetUser != nil {
 			log.Error("user is not exist", "error", errGetUser)
-			render.JSON(w, r, resp.Error("User is not exist"))
+			resp.JSON(w, r, resp.Error("User is not exist"))
 			return
 		}
 
-		token, errLogin := auth.Login(req.Nickname, req.Password, passwordHash)
+		nickname := req.Nickname
+		if opts.CanonicalNicknames {
+			nickname = storage.CanonicalNickname(nickname)
+		}
+
+		from := client(r)
+
+		token, errLogin := auth.LoginFrom(nickname, req.Password, passwordHash, from)
 		if errLogin != nil {
 			log.Error("failed to login", "error", errLogin, userID)
-			render.JSON(w, r, resp.Error("Wrong login or password"))
+			recordEvent(r.Context(), log, opts, userID, from, storage.LoginFailure)
+			resp.JSON(w, r, resp.Error("Wrong login or password"))
 			return
 		}
+		recordEvent(r.Context(), log, opts, userID, from, storage.LoginSuccess)
 
 		log.Info("user login successfully")
 		response := LoginResponse{
-			Status: "success",
-			Token:  token,
+			Status:   "success",
+			Token:    token,
+			Sessions: auth.Sessions.Count(nickname),
 		}
-		render.JSON(w, r, response)
+		resp.JSON(w, r, response)
 	}
 }
+
+// recordEvent записывает попытку входа. Сбой записи не мешает входу.
+func recordEvent(ctx context.Context, log *slog.Logger, opts Options, userID int64, from auth.Client, result string) {
+	if opts.Events == nil {
+		return
+	}
+
+	event := storage.LoginEvent{
+		At:        time.Now().UTC(),
+		IP:        from.IP,
+		UserAgent: from.UserAgent,
+		Result:    result,
+	}
+	if err := opts.Events.RecordLoginEvent(ctx, log, userID, event, opts.KeepEvents); err != nil {
+		log.Error("failed to record login event", sl.Err(err))
+	}
+}
+
+// client - откуда выполнен вход, для списка сессий. RemoteAddr уже исправлен realip
+// для запросов через доверенный прокси и может быть как адресом, так и адресом с портом.
+func client(r *http.Request) auth.Client {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+
+	return auth.Client{IP: ip, UserAgent: r.UserAgent()}
+}
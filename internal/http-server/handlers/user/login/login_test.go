@@ -0,0 +1,120 @@
+package login_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/user/login"
+	"url-shortener/internal/http-server/handlers/user/login/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestLoginHandler_Events(t *testing.T) {
+	auth.JWTSecret = []byte("test-secret")
+
+	hash, err := auth.HashPassword("secret")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		password string
+		result   string
+		wantBody string
+	}{
+		{
+			name:     "Success",
+			password: "secret",
+			result:   storage.LoginSuccess,
+			wantBody: `"token"`,
+		},
+		{
+			name:     "Wrong password",
+			password: "wrong",
+			result:   storage.LoginFailure,
+			wantBody: "Wrong login or password",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			getUserMock := mocks.NewGetUser(t)
+			getUserMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), hash, nil).
+				Once()
+
+			recorderMock := mocks.NewLoginRecorder(t)
+			recorderMock.On("RecordLoginEvent", mock.Anything, mock.Anything, int64(1), mock.MatchedBy(func(e storage.LoginEvent) bool {
+				return e.Result == tc.result && e.IP == "10.0.0.1" && e.UserAgent == "test-agent" && !e.At.IsZero()
+			}), 20).
+				Return(nil).
+				Once()
+
+			handler := login.New(slogdiscard.NewDiscardLogger(), getUserMock, login.Options{Events: recorderMock, KeepEvents: 20})
+
+			body := `{"nickname": "user", "password": "` + tc.password + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader([]byte(body)))
+			req.RemoteAddr = "10.0.0.1:54321"
+			req.Header.Set("User-Agent", "test-agent")
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestLoginHandler_UnknownUserNotRecorded(t *testing.T) {
+	getUserMock := mocks.NewGetUser(t)
+	getUserMock.On("GetUserByNickname", mock.Anything, mock.Anything, "ghost").
+		Return(int64(0), "", storage.ErrUserNotFound).
+		Once()
+
+	// Вызов RecordLoginEvent провалил бы тест: у мока нет ожиданий
+	recorderMock := mocks.NewLoginRecorder(t)
+
+	handler := login.New(slogdiscard.NewDiscardLogger(), getUserMock, login.Options{Events: recorderMock})
+
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader([]byte(`{"nickname": "ghost", "password": "secret"}`)))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Contains(t, rr.Body.String(), "User is not exist")
+}
+
+func TestLoginHandler_CanonicalNickname(t *testing.T) {
+	auth.JWTSecret = []byte("test-secret")
+
+	hash, err := auth.HashPassword("secret")
+	require.NoError(t, err)
+
+	getUserMock := mocks.NewGetUser(t)
+	getUserMock.On("GetUserByNickname", mock.Anything, mock.Anything, " Alice ").
+		Return(int64(1), hash, nil).
+		Once()
+
+	handler := login.New(slogdiscard.NewDiscardLogger(), getUserMock, login.Options{CanonicalNicknames: true})
+
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader([]byte(`{"nickname": " Alice ", "password": "secret"}`)))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp login.LoginResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	// Сессия и права считаются для той же формы никнейма, что и при любом другом написании
+	nickname, err := auth.ValidateJWT(resp.Token)
+	require.NoError(t, err)
+	require.Equal(t, "alice", nickname)
+}
@@ -0,0 +1,105 @@
+package logins
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type Response struct {
+	resp.Response
+	Limit  int                  `json:"limit,omitempty"`
+	Offset int                  `json:"offset"`
+	Logins []storage.LoginEvent `json:"logins"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=LoginEventsGetter
+type LoginEventsGetter interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetLoginEvents(ctx context.Context, log *slog.Logger, userID int64, limit, offset int) ([]storage.LoginEvent, error)
+}
+
+// New отдаёт последние попытки входа в аккаунт текущего пользователя, от новых к старым:
+// GET /user/logins?limit=20&offset=0. По ним пользователь может заметить чужой вход.
+func New(log *slog.Logger, eventsGetter LoginEventsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.user.logins.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		query := r.URL.Query()
+
+		limit, err := intParam(query.Get("limit"), defaultLimit)
+		if err != nil || limit < 1 || limit > maxLimit {
+			log.Error("invalid limit", slog.String("limit", query.Get("limit")))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("limit must be between 1 and "+strconv.Itoa(maxLimit)))
+			return
+		}
+
+		offset, err := intParam(query.Get("offset"), 0)
+		if err != nil || offset < 0 {
+			log.Error("invalid offset", slog.String("offset", query.Get("offset")))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("offset must be a non-negative number"))
+			return
+		}
+
+		userID, _, errGetUser := eventsGetter.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		events, err := eventsGetter.GetLoginEvents(r.Context(), log, userID, limit, offset)
+		if err != nil {
+			log.Error("failed to get login events", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get logins"))
+			return
+		}
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Limit:    limit,
+			Offset:   offset,
+			Logins:   events,
+		})
+	}
+}
+
+// intParam разбирает числовой параметр запроса, подставляя def для пустого значения
+func intParam(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,89 @@
+package logins_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/user/logins"
+	"url-shortener/internal/http-server/handlers/user/logins/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestLoginsHandler(t *testing.T) {
+	at := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	seeded := []storage.LoginEvent{
+		{At: at, IP: "203.0.113.7", UserAgent: "curl/8.4.0", Result: storage.LoginFailure},
+		{At: at.Add(-time.Hour), IP: "10.0.0.1", UserAgent: "Firefox", Result: storage.LoginSuccess},
+	}
+
+	cases := []struct {
+		name   string
+		query  string
+		limit  int
+		offset int
+		status int
+	}{
+		{name: "Defaults", query: "", limit: 20, status: http.StatusOK},
+		{name: "Page", query: "?limit=10&offset=20", limit: 10, offset: 20, status: http.StatusOK},
+		{name: "Limit too large", query: "?limit=1000", status: http.StatusBadRequest},
+		{name: "Negative offset", query: "?offset=-1", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			getterMock := mocks.NewLoginEventsGetter(t)
+			if tc.status == http.StatusOK {
+				getterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				getterMock.On("GetLoginEvents", mock.Anything, mock.Anything, int64(1), tc.limit, tc.offset).
+					Return(seeded, nil).
+					Once()
+			}
+
+			handler := logins.New(slogdiscard.NewDiscardLogger(), getterMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/user/logins"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var resp logins.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.limit, resp.Limit)
+			require.Equal(t, tc.offset, resp.Offset)
+			require.Len(t, resp.Logins, 2)
+			require.Equal(t, storage.LoginFailure, resp.Logins[0].Result)
+			require.Equal(t, "203.0.113.7", resp.Logins[0].IP)
+			require.True(t, at.Equal(resp.Logins[0].At))
+		})
+	}
+}
+
+func TestLoginsHandler_Unauthorized(t *testing.T) {
+	handler := logins.New(slogdiscard.NewDiscardLogger(), mocks.NewLoginEventsGetter(t))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/user/logins", nil))
+
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+}
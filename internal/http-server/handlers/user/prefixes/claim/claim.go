@@ -0,0 +1,156 @@
+package claim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	Prefix string `json:"prefix" validate:"required"`
+}
+
+type Response struct {
+	resp.Response
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// Коды ошибок захвата префикса
+const (
+	CodeInvalidPrefix   = "invalid_prefix"
+	CodePrefixTaken     = "prefix_taken"
+	CodeTooManyPrefixes = "too_many_prefixes"
+)
+
+// prefixPattern - допустимые символы префикса после приведения к нижнему регистру
+var prefixPattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// Options - ограничения на захват префиксов
+type Options struct {
+	// MinLength - минимальная длина префикса, чтобы один пользователь не занял, например, все alias на "a"
+	MinLength int
+	// MaxPerUser - сколько префиксов может занять один пользователь; 0 - без ограничения
+	MaxPerUser int
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=PrefixClaimer
+type PrefixClaimer interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	ClaimPrefix(ctx context.Context, log *slog.Logger, prefix string, userID int64, maxPerUser int) error
+}
+
+// New закрепляет префикс alias за пользователем: POST /user/prefixes.
+// После этого alias, начинающиеся с префикса, может создавать только он;
+// уже существующие ссылки других пользователей продолжают работать.
+func New(log *slog.Logger, prefixClaimer PrefixClaimer, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.user.prefixes.claim.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		req.Prefix = storage.PrefixKey(req.Prefix)
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		if !prefixPattern.MatchString(req.Prefix) {
+			log.Info("invalid prefix", slog.String("prefix", req.Prefix))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ErrorWithCode("prefix may contain only letters, digits, '-' and '_'", CodeInvalidPrefix))
+			return
+		}
+		if utf8.RuneCountInString(req.Prefix) < opts.MinLength {
+			log.Info("prefix is too short", slog.String("prefix", req.Prefix))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ErrorWithCode(
+				fmt.Sprintf("prefix must be at least %d characters long", opts.MinLength),
+				CodeInvalidPrefix,
+			))
+			return
+		}
+
+		userID, _, errGetUser := prefixClaimer.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		err = prefixClaimer.ClaimPrefix(r.Context(), log, req.Prefix, userID, opts.MaxPerUser)
+		switch {
+		case errors.Is(err, storage.ErrPrefixTaken):
+			log.Info("prefix is claimed by another user", slog.String("prefix", req.Prefix))
+			render.Status(r, http.StatusConflict)
+			resp.JSON(w, r, resp.ErrorWithCode("prefix overlaps a prefix claimed by another user", CodePrefixTaken))
+			return
+		case errors.Is(err, storage.ErrTooManyPrefixes):
+			log.Info("too many prefixes", slog.Int64("userID", userID))
+			render.Status(r, http.StatusConflict)
+			resp.JSON(w, r, resp.ErrorWithCode(
+				fmt.Sprintf("a user can claim at most %d prefixes", opts.MaxPerUser),
+				CodeTooManyPrefixes,
+			))
+			return
+		case err != nil:
+			log.Error("failed to claim prefix", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to claim prefix"))
+			return
+		}
+
+		log.Info("prefix claimed", slog.String("prefix", req.Prefix))
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Prefix:   req.Prefix,
+		})
+	}
+}
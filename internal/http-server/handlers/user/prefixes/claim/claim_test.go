@@ -0,0 +1,112 @@
+package claim_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/user/prefixes/claim"
+	"url-shortener/internal/http-server/handlers/user/prefixes/claim/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestClaimHandler(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		// prefix - префикс, который должен дойти до хранилища; пусто - запрос отклоняется раньше
+		prefix  string
+		mockErr error
+		status  int
+		code    string
+	}{
+		{
+			name:   "Claim prefix",
+			input:  `{"prefix": "acme"}`,
+			prefix: "acme",
+			status: http.StatusOK,
+		},
+		{
+			name:   "Normalized",
+			input:  `{"prefix": " ACME "}`,
+			prefix: "acme",
+			status: http.StatusOK,
+		},
+		{
+			name:    "Claimed by another user",
+			input:   `{"prefix": "acme"}`,
+			prefix:  "acme",
+			mockErr: storage.ErrPrefixTaken,
+			status:  http.StatusConflict,
+			code:    claim.CodePrefixTaken,
+		},
+		{
+			name:    "Limit reached",
+			input:   `{"prefix": "acme"}`,
+			prefix:  "acme",
+			mockErr: storage.ErrTooManyPrefixes,
+			status:  http.StatusConflict,
+			code:    claim.CodeTooManyPrefixes,
+		},
+		{
+			name:   "Too short",
+			input:  `{"prefix": "ac"}`,
+			status: http.StatusBadRequest,
+			code:   claim.CodeInvalidPrefix,
+		},
+		{
+			name:   "Invalid characters",
+			input:  `{"prefix": "ac/me"}`,
+			status: http.StatusBadRequest,
+			code:   claim.CodeInvalidPrefix,
+		},
+		{
+			name:   "Empty prefix",
+			input:  `{"prefix": ""}`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			claimerMock := mocks.NewPrefixClaimer(t)
+			if tc.prefix != "" {
+				claimerMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				claimerMock.On("ClaimPrefix", mock.Anything, mock.Anything, tc.prefix, int64(1), 2).
+					Return(tc.mockErr).
+					Once()
+			}
+
+			handler := claim.New(slogdiscard.NewDiscardLogger(), claimerMock, claim.Options{MinLength: 3, MaxPerUser: 2})
+
+			req, err := http.NewRequest(http.MethodPost, "/user/prefixes", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			var resp claim.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.code, resp.Code)
+			if tc.status == http.StatusOK {
+				require.Equal(t, tc.prefix, resp.Prefix)
+			}
+		})
+	}
+}
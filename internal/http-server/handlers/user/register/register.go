@@ -41,14 +41,14 @@ func New(log *slog.Logger, userSaver UserSaver) http.HandlerFunc {
 			// Обработаем её отдельно
 			log.Error("request body is empty")
 
-			render.JSON(w, r, resp.Error("empty request"))
+			resp.JSON(w, r, resp.Error("empty request"))
 
 			return
 		}
 		if err != nil {
 			log.Error("failed to decode request body", sl.Err(err))
 
-			render.JSON(w, r, resp.Error("failed to decode request"))
+			resp.JSON(w, r, resp.Error("failed to decode request"))
 
 			return
 		}
@@ -60,7 +60,7 @@ func New(log *slog.Logger, userSaver UserSaver) http.HandlerFunc {
 
 			log.Error("invalid request", sl.Err(err))
 
-			render.JSON(w, r, resp.ValidationError(validateErr))
+			resp.JSON(w, r, resp.ValidationError(validateErr))
 
 			return
 		}
@@ -73,11 +73,11 @@ func New(log *slog.Logger, userSaver UserSaver) http.HandlerFunc {
 		errSaveUser := userSaver.SaveUser(r.Context(), log, req.Nickname, hashedPassword)
 		if errors.Is(errSaveUser, storage.ErrUserExists) {
 			log.Info("user already exists", slog.String("url", req.Nickname))
-			render.JSON(w, r, resp.Error("User already exists"))
+			resp.JSON(w, r, resp.Error("User already exists"))
 			return
 		}
 
 		log.Info("user registered successfully", slog.String("nickname", req.Nickname), slog.String("hashPassword", hashedPassword))
-		render.JSON(w, r, resp.OK())
+		resp.JSON(w, r, resp.OK())
 	}
 }
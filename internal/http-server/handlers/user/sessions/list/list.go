@@ -0,0 +1,64 @@
+package list
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// Session - активная сессия; Current отмечает ту, которой выполнен запрос
+type Session struct {
+	auth.SessionInfo
+	Current bool `json:"current"`
+}
+
+type Response struct {
+	resp.Response
+	Sessions []Session `json:"sessions"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=SessionLister
+type SessionLister interface {
+	List(nickname string) []auth.SessionInfo
+}
+
+// New отдаёт активные сессии авторизованного пользователя: GET /user/sessions
+func New(log *slog.Logger, sessionLister SessionLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.user.sessions.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		current := auth.SessionIDFromContext(r.Context())
+
+		infos := sessionLister.List(nickname)
+		sessions := make([]Session, 0, len(infos))
+		for _, info := range infos {
+			sessions = append(sessions, Session{
+				SessionInfo: info,
+				Current:     current != "" && info.ID == current,
+			})
+		}
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Sessions: sessions,
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package list_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/user/sessions/list"
+	"url-shortener/internal/http-server/handlers/user/sessions/list/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func request(t *testing.T, handler http.HandlerFunc, sessionID string) list.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, "/user/sessions", nil)
+	require.NoError(t, err)
+	ctx := auth.WithNickname(req.Context(), "user")
+	ctx = auth.WithSessionID(ctx, sessionID)
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp list.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func TestListHandler(t *testing.T) {
+	store := auth.NewSessionStore(0)
+	expiresAt := time.Now().Add(time.Hour)
+
+	store.AddFrom("user", "laptop", expiresAt, auth.Client{IP: "10.0.0.1", UserAgent: "Firefox"})
+	store.AddFrom("user", "phone", expiresAt, auth.Client{IP: "10.0.0.2", UserAgent: "Safari"})
+	store.Add("user", "cli", expiresAt)
+	store.Add("other", "foreign", expiresAt)
+
+	resp := request(t, list.New(slogdiscard.NewDiscardLogger(), store), "phone")
+
+	// Чужие сессии не показываются
+	require.Len(t, resp.Sessions, 3)
+
+	byID := make(map[string]list.Session, len(resp.Sessions))
+	for _, s := range resp.Sessions {
+		byID[s.ID] = s
+		require.WithinDuration(t, expiresAt, s.ExpiresAt, time.Second)
+		require.False(t, s.IssuedAt.IsZero())
+	}
+
+	require.True(t, byID["phone"].Current)
+	require.False(t, byID["laptop"].Current)
+	require.False(t, byID["cli"].Current)
+
+	require.Equal(t, "10.0.0.1", byID["laptop"].IP)
+	require.Equal(t, "Firefox", byID["laptop"].UserAgent)
+	require.Empty(t, byID["cli"].IP)
+}
+
+func TestListHandler_NoSessionID(t *testing.T) {
+	listerMock := mocks.NewSessionLister(t)
+	listerMock.On("List", "user").
+		Return([]auth.SessionInfo{{ID: ""}}).
+		Once()
+
+	resp := request(t, list.New(slogdiscard.NewDiscardLogger(), listerMock), "")
+
+	// Токен без id не совпадает с сессией без id
+	require.Len(t, resp.Sessions, 1)
+	require.False(t, resp.Sessions[0].Current)
+}
@@ -0,0 +1,67 @@
+package revoke
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+)
+
+type Response struct {
+	resp.Response
+	// Current - отозвана сессия, которой выполнен запрос; токен больше не действует
+	Current bool `json:"current,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=SessionRevoker
+type SessionRevoker interface {
+	Revoke(nickname, id string) bool
+}
+
+// New отзывает сессию авторизованного пользователя: DELETE /user/sessions/{id}.
+// Чужая или уже неактивная сессия неотличима от несуществующей.
+func New(log *slog.Logger, sessionRevoker SessionRevoker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.user.sessions.revoke.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			log.Error("session id is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		if !sessionRevoker.Revoke(nickname, id) {
+			log.Info("session not found", slog.String("session_id", id))
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.Error("session not found"))
+			return
+		}
+
+		log.Info("session revoked", slog.String("session_id", id))
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Current:  id == auth.SessionIDFromContext(r.Context()),
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package revoke_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/user/sessions/revoke"
+	"url-shortener/internal/http-server/handlers/user/sessions/revoke/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func serve(t *testing.T, revoker revoke.SessionRevoker, id, current string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := chi.NewRouter()
+	r.Delete("/user/sessions/{id}", revoke.New(slogdiscard.NewDiscardLogger(), revoker))
+
+	req := httptest.NewRequest(http.MethodDelete, "/user/sessions/"+id, nil)
+	ctx := auth.WithNickname(req.Context(), "user")
+	ctx = auth.WithSessionID(ctx, current)
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestRevokeHandler(t *testing.T) {
+	store := auth.NewSessionStore(0)
+	expiresAt := time.Now().Add(time.Hour)
+
+	store.Add("user", "laptop", expiresAt)
+	store.Add("user", "phone", expiresAt)
+	store.Add("user", "cli", expiresAt)
+	store.Add("other", "foreign", expiresAt)
+
+	rr := serve(t, store, "phone", "laptop")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp revoke.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.False(t, resp.Current)
+
+	// Отозвана только выбранная сессия
+	require.True(t, store.Revoked("phone"))
+	require.False(t, store.Revoked("laptop"))
+	require.False(t, store.Revoked("cli"))
+
+	ids := make([]string, 0)
+	for _, s := range store.List("user") {
+		ids = append(ids, s.ID)
+	}
+	require.Equal(t, []string{"laptop", "cli"}, ids)
+
+	// Чужую и уже отозванную сессию отозвать нельзя
+	require.Equal(t, http.StatusNotFound, serve(t, store, "foreign", "laptop").Code)
+	require.False(t, store.Revoked("foreign"))
+	require.Equal(t, http.StatusNotFound, serve(t, store, "phone", "laptop").Code)
+}
+
+func TestRevokeHandler_Current(t *testing.T) {
+	revokerMock := mocks.NewSessionRevoker(t)
+	revokerMock.On("Revoke", "user", "laptop").
+		Return(true).
+		Once()
+
+	rr := serve(t, revokerMock, "laptop", "laptop")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp revoke.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.True(t, resp.Current)
+}
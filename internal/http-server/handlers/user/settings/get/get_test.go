@@ -0,0 +1,86 @@
+package get_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/user/settings/get"
+	"url-shortener/internal/http-server/handlers/user/settings/get/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestGetSettingsHandler(t *testing.T) {
+	aliasLength := 8
+	redirectStatus := http.StatusMovedPermanently
+
+	cases := []struct {
+		name     string
+		settings storage.UserSettings
+		err      error
+		body     string
+		status   int
+	}{
+		{
+			name: "Merged settings",
+			settings: storage.UserSettings{
+				DefaultAliasLength:    &aliasLength,
+				DefaultRedirectStatus: &redirectStatus,
+			},
+			body:   `"settings":{"default_alias_length":8,"default_redirect_status":301}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "Nothing set",
+			body:   `"settings":{}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "Storage error",
+			err:    errors.New("unexpected error"),
+			body:   `"error":"failed to get settings"`,
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			getterMock := mocks.NewSettingsGetter(t)
+			getterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), "", nil).
+				Once()
+			getterMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+				Return(tc.settings, tc.err).
+				Once()
+
+			handler := get.New(slogdiscard.NewDiscardLogger(), getterMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/user/settings", nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.body)
+
+			if tc.err == nil {
+				var resp get.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.Equal(t, tc.settings, resp.Settings)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/interstitial"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// Request - изменяемые настройки. Не переданные поля остаются как есть, 0 - сброс к глобальному значению.
+type Request struct {
+	DefaultAliasLength *int `json:"default_alias_length,omitempty" validate:"omitempty,gte=0,lte=64"`
+	// DefaultRedirectStatus - код ответа для новых ссылок
+	DefaultRedirectStatus *int `json:"default_redirect_status,omitempty" validate:"omitempty,oneof=0 301 302 307 308"`
+	// DefaultTTLSeconds - срок жизни новых ссылок в секундах
+	DefaultTTLSeconds *int64 `json:"default_ttl_seconds,omitempty" validate:"omitempty,gte=0"`
+	// AllowDuplicateURLs - можно ли повторно сокращать уже сокращённый адрес. Сбросить к глобальному
+	// значению нельзя, только задать явно.
+	AllowDuplicateURLs *bool `json:"allow_duplicate_urls,omitempty"`
+	// RedirectTemplate - своя промежуточная страница публичных ссылок, "" - сброс к странице по умолчанию.
+	// Подставить можно только {{.URL}} и {{.Alias}}; скрипты, фреймы и формы не принимаются.
+	RedirectTemplate *string `json:"redirect_template,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Settings storage.UserSettings `json:"settings"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=SettingsUpdater
+type SettingsUpdater interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	GetUserSettings(ctx context.Context, log *slog.Logger, userID int64) (storage.UserSettings, error)
+	SaveUserSettings(ctx context.Context, log *slog.Logger, userID int64, settings storage.UserSettings) error
+}
+
+// New частично обновляет настройки авторизованного пользователя (PATCH /user/settings)
+func New(log *slog.Logger, settingsUpdater SettingsUpdater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.user.settings.update.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		nickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok || nickname == "" {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		if req.RedirectTemplate != nil && *req.RedirectTemplate != "" {
+			if _, err := interstitial.Parse(*req.RedirectTemplate); err != nil {
+				log.Info("redirect template rejected", sl.Err(err))
+				render.Status(r, http.StatusBadRequest)
+				resp.JSON(w, r, resp.Error("invalid redirect_template: "+err.Error()))
+				return
+			}
+		}
+
+		userID, _, errGetUser := settingsUpdater.GetUserByNickname(r.Context(), log, nickname)
+		if errGetUser != nil {
+			log.Error("failed to get user by nickname", sl.Err(errGetUser))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get user"))
+			return
+		}
+
+		settings, err := settingsUpdater.GetUserSettings(r.Context(), log, userID)
+		if err != nil {
+			log.Error("failed to get user settings", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to get settings"))
+			return
+		}
+
+		if req.DefaultAliasLength != nil {
+			settings.DefaultAliasLength = resetOnZero(*req.DefaultAliasLength)
+		}
+		if req.DefaultRedirectStatus != nil {
+			settings.DefaultRedirectStatus = resetOnZero(*req.DefaultRedirectStatus)
+		}
+		if req.DefaultTTLSeconds != nil {
+			settings.DefaultTTLSeconds = resetOnZero(*req.DefaultTTLSeconds)
+		}
+		if req.AllowDuplicateURLs != nil {
+			settings.AllowDuplicateURLs = req.AllowDuplicateURLs
+		}
+		if req.RedirectTemplate != nil {
+			settings.RedirectTemplate = *req.RedirectTemplate
+		}
+
+		if err := settingsUpdater.SaveUserSettings(r.Context(), log, userID, settings); err != nil {
+			log.Error("failed to save user settings", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to save settings"))
+			return
+		}
+
+		log.Info("user settings updated")
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Settings: settings,
+		})
+	}
+}
+
+// resetOnZero превращает 0 в nil - признак глобального значения по умолчанию
+func resetOnZero[T int | int64](v T) *T {
+	if v == 0 {
+		return nil
+	}
+
+	return &v
+}
@@ -0,0 +1,145 @@
+package update_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/user/settings/update"
+	"url-shortener/internal/http-server/handlers/user/settings/update/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestUpdateSettingsHandler(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	int64Ptr := func(v int64) *int64 { return &v }
+
+	cases := []struct {
+		name    string
+		input   string
+		current storage.UserSettings
+		want    *storage.UserSettings
+		status  int
+	}{
+		{
+			name:   "Set alias length",
+			input:  `{"default_alias_length": 8}`,
+			want:   &storage.UserSettings{DefaultAliasLength: intPtr(8)},
+			status: http.StatusOK,
+		},
+		{
+			name:    "Reset alias length",
+			input:   `{"default_alias_length": 0}`,
+			current: storage.UserSettings{DefaultAliasLength: intPtr(8)},
+			want:    &storage.UserSettings{},
+			status:  http.StatusOK,
+		},
+		{
+			name:    "Field not sent",
+			input:   `{}`,
+			current: storage.UserSettings{DefaultAliasLength: intPtr(8)},
+			want:    &storage.UserSettings{DefaultAliasLength: intPtr(8)},
+			status:  http.StatusOK,
+		},
+		{
+			name:  "Update subset of fields",
+			input: `{"default_redirect_status": 301, "default_ttl_seconds": 3600}`,
+			current: storage.UserSettings{
+				DefaultAliasLength:    intPtr(8),
+				DefaultRedirectStatus: intPtr(307),
+			},
+			want: &storage.UserSettings{
+				DefaultAliasLength:    intPtr(8),
+				DefaultRedirectStatus: intPtr(301),
+				DefaultTTLSeconds:     int64Ptr(3600),
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:    "Reset ttl",
+			input:   `{"default_ttl_seconds": 0}`,
+			current: storage.UserSettings{DefaultTTLSeconds: int64Ptr(3600)},
+			want:    &storage.UserSettings{},
+			status:  http.StatusOK,
+		},
+		{
+			name:   "Out of range",
+			input:  `{"default_alias_length": 100}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Unsupported redirect status",
+			input:  `{"default_redirect_status": 200}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Set redirect template",
+			input:  `{"redirect_template": "<p>Leaving for <a href=\"{{.URL}}\">{{.URL}}</a></p>"}`,
+			want:   &storage.UserSettings{RedirectTemplate: `<p>Leaving for <a href="{{.URL}}">{{.URL}}</a></p>`},
+			status: http.StatusOK,
+		},
+		{
+			name:    "Reset redirect template",
+			input:   `{"redirect_template": ""}`,
+			current: storage.UserSettings{RedirectTemplate: "<p>{{.URL}}</p>"},
+			want:    &storage.UserSettings{},
+			status:  http.StatusOK,
+		},
+		{
+			name:   "Malicious redirect template",
+			input:  `{"redirect_template": "<p>{{.URL}}</p><script>alert(1)</script>"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Negative ttl",
+			input:  `{"default_alias_length": 8, "default_ttl_seconds": -1}`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			updaterMock := mocks.NewSettingsUpdater(t)
+			if tc.want != nil {
+				updaterMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				updaterMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+					Return(tc.current, nil).
+					Once()
+				updaterMock.On("SaveUserSettings", mock.Anything, mock.Anything, int64(1), *tc.want).
+					Return(nil).
+					Once()
+			}
+
+			handler := update.New(slogdiscard.NewDiscardLogger(), updaterMock)
+
+			req, err := http.NewRequest(http.MethodPatch, "/user/settings", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			if tc.want == nil {
+				return
+			}
+
+			var resp update.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, *tc.want, resp.Settings)
+		})
+	}
+}
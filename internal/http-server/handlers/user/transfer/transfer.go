@@ -0,0 +1,164 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	NewOwnerNickname string `json:"new_owner_nickname" validate:"required"`
+}
+
+type Response struct {
+	resp.Response
+	// Moved - сколько ссылок передано, включая ссылки в корзине
+	Moved int64 `json:"moved"`
+}
+
+// Коды ошибок передачи ссылок
+const (
+	CodeUserNotFound      = "user_not_found"
+	CodeRecipientNotFound = "recipient_not_found"
+	CodeSameOwner         = "same_owner"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLTransferer
+type URLTransferer interface {
+	GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error)
+	TransferURLs(ctx context.Context, log *slog.Logger, fromUserID, toUserID int64) (int64, error)
+}
+
+// New передаёт все ссылки пользователя другому: POST /user/{nickname}/transfer-urls.
+// Используется перед удалением аккаунта, чтобы ссылки продолжили работать у нового владельца.
+// Передать ссылки может сам пользователь или администратор.
+func New(log *slog.Logger, urlTransferer URLTransferer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.user.transfer.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		authNickname, ok := auth.NicknameFromContext(r.Context())
+		if !ok {
+			log.Error("failed to get authorized user nickname from context")
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("unauthorized request"))
+			return
+		}
+
+		nickname := chi.URLParam(r, "nickname")
+		if nickname == "" {
+			log.Error("nickname is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+
+		if nickname != authNickname && !auth.IsAdmin(authNickname) {
+			access.Deny(w, r, log, authNickname, "user:"+nickname)
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		if req.NewOwnerNickname == nickname {
+			log.Info("transfer to the same user", slog.String("nickname", nickname))
+			render.Status(r, http.StatusBadRequest)
+			resp.JSON(w, r, resp.ErrorWithCode("new owner must be another user", CodeSameOwner))
+			return
+		}
+
+		fromUserID, ok := userID(w, r, log, urlTransferer, nickname, "user not found", CodeUserNotFound)
+		if !ok {
+			return
+		}
+		toUserID, ok := userID(w, r, log, urlTransferer, req.NewOwnerNickname, "new owner not found", CodeRecipientNotFound)
+		if !ok {
+			return
+		}
+
+		moved, err := urlTransferer.TransferURLs(r.Context(), log, fromUserID, toUserID)
+		if err != nil {
+			log.Error("failed to transfer urls", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			resp.JSON(w, r, resp.Error("failed to transfer urls"))
+			return
+		}
+
+		log.Info("urls transferred",
+			slog.String("nickname", nickname),
+			slog.String("new_owner", req.NewOwnerNickname),
+			slog.String("by", authNickname),
+			slog.Int64("moved", moved),
+		)
+
+		resp.JSON(w, r, Response{
+			Response: resp.OK(),
+			Moved:    moved,
+		})
+	}
+}
+
+// userID находит id пользователя; если его нет, отвечает 404 с кодом code
+func userID(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	urlTransferer URLTransferer,
+	nickname, notFound, code string,
+) (int64, bool) {
+	id, _, err := urlTransferer.GetUserByNickname(r.Context(), log, nickname)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		log.Info(notFound, slog.String("nickname", nickname))
+		render.Status(r, http.StatusNotFound)
+		resp.JSON(w, r, resp.ErrorWithCode(notFound, code))
+		return 0, false
+	}
+	if err != nil {
+		log.Error("failed to get user by nickname", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		resp.JSON(w, r, resp.Error("failed to get user"))
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,118 @@
+package transfer_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/user/transfer"
+	"url-shortener/internal/http-server/handlers/user/transfer/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestTransferHandler(t *testing.T) {
+	auth.Admins = []string{"admin"}
+	t.Cleanup(func() { auth.Admins = nil })
+
+	cases := []struct {
+		name   string
+		caller string
+		input  string
+		// transfer - запрос доходит до хранилища; recipientErr - ответ поиска получателя
+		transfer     bool
+		recipientErr error
+		status       int
+		code         string
+		moved        int64
+	}{
+		{
+			name:     "Self",
+			caller:   "user",
+			input:    `{"new_owner_nickname": "heir"}`,
+			transfer: true,
+			status:   http.StatusOK,
+			moved:    3,
+		},
+		{
+			name:     "Admin",
+			caller:   "admin",
+			input:    `{"new_owner_nickname": "heir"}`,
+			transfer: true,
+			status:   http.StatusOK,
+			moved:    3,
+		},
+		{
+			name:   "Another user",
+			caller: "intruder",
+			input:  `{"new_owner_nickname": "heir"}`,
+			status: http.StatusForbidden,
+		},
+		{
+			name:         "Unknown recipient",
+			caller:       "user",
+			input:        `{"new_owner_nickname": "heir"}`,
+			recipientErr: storage.ErrUserNotFound,
+			status:       http.StatusNotFound,
+			code:         transfer.CodeRecipientNotFound,
+		},
+		{
+			name:   "Same owner",
+			caller: "user",
+			input:  `{"new_owner_nickname": "user"}`,
+			status: http.StatusBadRequest,
+			code:   transfer.CodeSameOwner,
+		},
+		{
+			name:   "No recipient",
+			caller: "user",
+			input:  `{}`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			transfererMock := mocks.NewURLTransferer(t)
+			if tc.transfer || tc.recipientErr != nil {
+				transfererMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				transfererMock.On("GetUserByNickname", mock.Anything, mock.Anything, "heir").
+					Return(int64(2), "", tc.recipientErr).
+					Once()
+			}
+			if tc.transfer {
+				transfererMock.On("TransferURLs", mock.Anything, mock.Anything, int64(1), int64(2)).
+					Return(tc.moved, nil).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/user/{nickname}/transfer-urls", transfer.New(slogdiscard.NewDiscardLogger(), transfererMock))
+
+			req, err := http.NewRequest(http.MethodPost, "/user/user/transfer-urls", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithNickname(req.Context(), tc.caller))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			var resp transfer.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.code, resp.Code)
+			require.Equal(t, tc.moved, resp.Moved)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package aliascheck
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/checksum"
+)
+
+// CodeChecksumMismatch - код ошибки для alias с неверным контрольным символом
+const CodeChecksumMismatch = "checksum_mismatch"
+
+// New проверяет контрольный символ alias из параметра маршрута {alias} до обращения
+// к хранилищу. Alias с опечаткой сразу получает 404. Исправленный вариант не
+// предлагается: это мог бы оказаться чужой alias. При foldCase контрольный символ
+// не зависит от регистра, как и сами alias. При enabled = false запросы проходят без проверки.
+func New(log *slog.Logger, enabled, foldCase bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+
+		scheme := checksum.Scheme{FoldCase: foldCase}
+
+		log := log.With(
+			slog.String("component", "middleware/aliascheck"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			alias := chi.URLParam(r, "alias")
+			if alias == "" || scheme.Valid(alias) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			log.Info("alias checksum mismatch",
+				slog.String("alias", alias),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			render.Status(r, http.StatusNotFound)
+			resp.JSON(w, r, resp.ErrorWithCode("alias is mistyped", CodeChecksumMismatch))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
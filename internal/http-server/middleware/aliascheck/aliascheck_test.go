@@ -0,0 +1,79 @@
+package aliascheck_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/aliascheck"
+	"url-shortener/internal/lib/checksum"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func serve(t *testing.T, enabled, foldCase bool, alias string) (*httptest.ResponseRecorder, bool) {
+	t.Helper()
+
+	called := false
+	r := chi.NewRouter()
+	r.With(aliascheck.New(slogdiscard.NewDiscardLogger(), enabled, foldCase)).
+		Get("/r/{alias}", func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			w.WriteHeader(http.StatusFound)
+		})
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/r/"+alias, nil))
+
+	return rr, called
+}
+
+func TestAliasCheck(t *testing.T) {
+	valid := checksum.Append("abc123")
+	last := valid[len(valid)-1]
+	var mistyped string
+	if last == 'x' {
+		mistyped = valid[:len(valid)-1] + "y"
+	} else {
+		mistyped = valid[:len(valid)-1] + "x"
+	}
+
+	t.Run("Valid checksum resolves", func(t *testing.T) {
+		rr, called := serve(t, true, false, valid)
+
+		require.True(t, called)
+		require.Equal(t, http.StatusFound, rr.Code)
+	})
+
+	t.Run("Mistyped alias rejected without suggestion", func(t *testing.T) {
+		rr, called := serve(t, true, false, mistyped)
+
+		require.False(t, called)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		require.Equal(t, aliascheck.CodeChecksumMismatch, body["code"])
+		require.NotContains(t, body, "suggestion")
+	})
+
+	t.Run("Alias in another case resolves when case is folded", func(t *testing.T) {
+		folded := checksum.Scheme{FoldCase: true}.Append("AbC123")
+
+		rr, called := serve(t, true, true, strings.ToUpper(folded))
+
+		require.True(t, called)
+		require.Equal(t, http.StatusFound, rr.Code)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		rr, called := serve(t, false, false, mistyped)
+
+		require.True(t, called)
+		require.Equal(t, http.StatusFound, rr.Code)
+	})
+}
@@ -0,0 +1,35 @@
+package aliasnorm
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"url-shortener/internal/lib/aliasgroup"
+)
+
+// New приводит alias из параметра маршрута {alias} к виду, в котором он хранится:
+// если дефисы не входят в alias, сгруппированный alias (ab3-f9k) открывается так же,
+// как ab3f9k. Ставится перед проверкой контрольного символа. Если группировка
+// выключена или дефисы значимы, запросы проходят без изменений.
+func New(grouping aliasgroup.Grouping) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !grouping.Enabled() || grouping.Significant {
+			return next
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				for i, key := range rctx.URLParams.Keys {
+					if key == "alias" {
+						rctx.URLParams.Values[i] = grouping.Normalize(rctx.URLParams.Values[i])
+					}
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,102 @@
+package aliasnorm_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/redirect"
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/middleware/aliasnorm"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/aliasgroup"
+	"url-shortener/internal/lib/api/errorpage"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage/multiStorage"
+	"url-shortener/internal/storage/sqlite"
+)
+
+func withUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(auth.WithNickname(r.Context(), "user")))
+	})
+}
+
+// Сгенерированный alias разбит на группы и открывается через редирект
+func TestGroupedAliases(t *testing.T) {
+	cases := []struct {
+		name        string
+		significant bool
+	}{
+		{name: "Hyphens are part of alias", significant: true},
+		{name: "Hyphens are display only", significant: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+			require.NoError(t, err)
+			_, err = db.SaveUser("user", "hash")
+			require.NoError(t, err)
+			s := multiStorage.NewSQLiteStorage(db)
+
+			grouping := aliasgroup.Grouping{Size: 3, Significant: tc.significant}
+			log := slogdiscard.NewDiscardLogger()
+
+			r := chi.NewRouter()
+			r.Use(withUser)
+			r.Post("/url/save", save.New(log, s, save.Options{AliasLength: 6, Grouping: grouping}))
+			r.With(aliasnorm.New(grouping)).Get("/redirect/{alias}", redirect.New(log, s, errorpage.FormatJSON))
+
+			req := httptest.NewRequest(http.MethodPost, "/url/save", strings.NewReader(`{"url":"https://example.com"}`))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			shown := resp.Alias
+			if !tc.significant {
+				require.Len(t, resp.Alias, 6)
+				shown = resp.DisplayAlias
+			}
+			require.Len(t, shown, 7)
+			require.Equal(t, "-", shown[3:4])
+
+			// Открываются и показанный, и сохранённый вид
+			for _, alias := range []string{shown, resp.Alias} {
+				req = httptest.NewRequest(http.MethodGet, "/redirect/"+alias, nil)
+				rr = httptest.NewRecorder()
+				r.ServeHTTP(rr, req)
+
+				require.Equal(t, http.StatusFound, rr.Code, alias)
+				require.Equal(t, "https://example.com", rr.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestNew_CustomAliasUntouched(t *testing.T) {
+	var got string
+
+	r := chi.NewRouter()
+	r.With(aliasnorm.New(aliasgroup.Grouping{Size: 3})).Get("/r/{alias}", func(w http.ResponseWriter, r *http.Request) {
+		got = chi.URLParam(r, "alias")
+	})
+
+	for alias, want := range map[string]string{
+		"ab3-f9k": "ab3f9k",
+		"my-link": "my-link",
+		"plain":   "plain",
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/r/"+alias, nil))
+		require.Equal(t, want, got)
+	}
+}
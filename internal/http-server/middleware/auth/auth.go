@@ -1,28 +1,132 @@
 package auth
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"github.com/go-chi/render"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/net/context"
 	"net/http"
 	"strings"
 	"time"
-	"url-shortener/internal/config"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// JWTSecret - ключ подписи токенов, задаётся из конфига при старте приложения
+var JWTSecret []byte
+
+// JWTKeyID - kid ключа JWTSecret, пишется в заголовок новых токенов. Пусто - токены выпускаются без kid.
+var JWTKeyID string
+
+// VerificationKey - прежний ключ подписи: им только проверяются токены, выпущенные до ротации
+type VerificationKey struct {
+	Secret []byte
+	// Until - после этого момента токены с этим ключом не принимаются; нулевое значение - без срока
+	Until time.Time
+}
+
+// PreviousKeys - прежние ключи по kid, задаются из конфига при старте приложения.
+// Ключ, убранный из списка, перестаёт приниматься сразу.
+var PreviousKeys map[string]VerificationKey
+
+var (
+	ErrUnknownKeyID = errors.New("unknown signing key id")
+	ErrKeyRetired   = errors.New("signing key is retired")
+	// ErrTokenLifetime - срок действия токена длиннее MaxTokenLifetime или не ограничен вовсе
+	ErrTokenLifetime = errors.New("token lifetime exceeds the maximum")
 )
 
-var JWTSecret = []byte(config.MustLoad().JWTSecret)
+// TokenTTL - запрошенный срок действия новых токенов, задаётся из конфига при старте приложения
+var TokenTTL = 5 * time.Minute
+
+// MaxTokenLifetime - жёсткий предел срока действия токена: более длинный TokenTTL урезается
+// при выдаче, а токен с более долгим сроком (от nbf или iat до exp) не принимается. 0 - без предела.
+var MaxTokenLifetime = 24 * time.Hour
+
+// tokenLifetime возвращает TokenTTL, урезанный до MaxTokenLifetime
+func tokenLifetime() time.Duration {
+	if MaxTokenLifetime > 0 && TokenTTL > MaxTokenLifetime {
+		return MaxTokenLifetime
+	}
+
+	return TokenTTL
+}
+
+// checkLifetime проверяет, что срок действия токена не длиннее MaxTokenLifetime.
+// Срок отсчитывается от nbf, а если его нет - от iat.
+func checkLifetime(claims *Claims) error {
+	if MaxTokenLifetime <= 0 {
+		return nil
+	}
+
+	start := claims.NotBefore
+	if start == nil {
+		start = claims.IssuedAt
+	}
+	if start == nil || claims.ExpiresAt == nil {
+		return ErrTokenLifetime
+	}
+	if claims.ExpiresAt.Sub(start.Time) > MaxTokenLifetime {
+		return ErrTokenLifetime
+	}
+
+	return nil
+}
+
+// Admins - никнеймы администраторов, задаются из конфига при старте приложения
+var Admins []string
+
+// IsAdmin проверяет, входит ли пользователь в список администраторов
+func IsAdmin(nickname string) bool {
+	for _, admin := range Admins {
+		if admin == nickname {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PasswordPepper - секрет сервера, которым пароль подписывается перед bcrypt. Задаётся из конфига
+// при старте приложения; пустой - пароль хэшируется как есть. Смена пепера делает недействительными
+// все сохранённые хэши: пользователям придётся сменить пароль.
+var PasswordPepper []byte
+
+// AuthSchemes - допустимые схемы заголовка Authorization, сравниваются без учёта регистра.
+// Задаются из конфига при старте приложения.
+var AuthSchemes = []string{"Bearer"}
+
+// AllowRawToken - принимать заголовок Authorization с токеном без схемы
+var AllowRawToken bool
+
+// peppered возвращает base64(HMAC-SHA256(PasswordPepper, password)) - 44 байта при любой длине
+// пароля и пепера. bcrypt учитывает только первые 72 байта и отказывается хэшировать больше,
+// поэтому пепер не дописывается к паролю, а пароль сжимается HMAC.
+func peppered(password string) []byte {
+	if len(PasswordPepper) == 0 {
+		return []byte(password)
+	}
+
+	mac := hmac.New(sha256.New, PasswordPepper)
+	mac.Write([]byte(password))
+
+	return []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
 
 // Функция для хэширования пароля
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	bytes, err := bcrypt.GenerateFromPassword(peppered(password), 14)
 	return string(bytes), err
 }
 
 // Функция для проверки пароля с хэшем
 func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	err := bcrypt.CompareHashAndPassword([]byte(hash), peppered(password))
 	return err == nil
 }
 
@@ -43,58 +147,179 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// GenerateJWT выдаёт токен и регистрирует его как новую сессию пользователя в Sessions
 func GenerateJWT(username string) (string, error) {
-	expirationTime := time.Now().Add(5 * time.Minute)
+	return GenerateJWTFor(username, Client{})
+}
+
+// GenerateJWTFor выдаёт токен, запоминая в сессии, откуда выполнен вход
+func GenerateJWTFor(username string, client Client) (string, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expirationTime := now.Add(tokenLifetime())
 	claims := &Claims{
 		Username: username,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if JWTKeyID != "" {
+		token.Header["kid"] = JWTKeyID
+	}
 	tokenString, err := token.SignedString(JWTSecret)
 	if err != nil {
 		return "", err
 	}
 
+	Sessions.AddFrom(username, tokenID, expirationTime, client)
+
 	return tokenString, nil
 }
 
 // Проверка токена
 func ValidateJWT(tokenString string) (string, error) {
+	claims, err := ParseJWT(tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	return claims.Username, nil // Возвращаем имя пользователя из токена
+}
+
+// ParseJWT проверяет токен и возвращает его claims
+func ParseJWT(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
-	// Парсинг токена и проверка подписи
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		// Проверяем метод подписи
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return JWTSecret, nil // Возвращаем секретный ключ
-	})
+	// Парсинг токена, проверка подписи, exp и nbf
+	token, err := jwt.ParseWithClaims(tokenString, claims, signingKey)
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Проверка валидности токена
 	if !token.Valid {
-		return "", errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
-	return claims.Username, nil // Возвращаем имя пользователя из токена
+	if err := checkLifetime(claims); err != nil {
+		return nil, err
+	}
+
+	// Сессия могла быть вытеснена более новыми входами пользователя или отозвана
+	if claims.ID != "" && Sessions.Revoked(claims.ID) {
+		return nil, errors.New("token revoked")
+	}
+
+	return claims, nil
+}
+
+// signingKey отдаёт ключ проверки подписи, если метод подписи ожидаемый. Ключ выбирается
+// по kid: текущий (JWTKeyID) или прежний из PreviousKeys. Токен без kid проверяется текущим ключом.
+func signingKey(token *jwt.Token) (interface{}, error) {
+	// Проверяем метод подписи
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, errors.New("unexpected signing method")
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" || kid == JWTKeyID {
+		return JWTSecret, nil // Возвращаем секретный ключ
+	}
+
+	key, ok := PreviousKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKeyID, kid)
+	}
+	if !key.Until.IsZero() && time.Now().After(key.Until) {
+		return nil, fmt.Errorf("%w: %q", ErrKeyRetired, kid)
+	}
+
+	return key.Secret, nil
+}
+
+// Состояние подписи в TokenInspection
+const (
+	SignatureValid   = "valid"
+	SignatureInvalid = "invalid"
+	// SignatureUnknown - токен не удалось разобрать, до проверки подписи дело не дошло
+	SignatureUnknown = "unknown"
+)
+
+// TokenInspection - разбор токена для поддержки: claims и причины, по которым токен не принимается
+type TokenInspection struct {
+	// Claims - claims токена как есть; nil, если токен не удалось разобрать.
+	// При неверной подписи claims не заслуживают доверия.
+	Claims    *Claims
+	Signature string
+	Expired   bool
+	Revoked   bool
+	// Valid - токен прошёл бы TokenAuthMiddleware
+	Valid bool
+	// Error - ошибка проверки; пусто у валидного токена
+	Error string
+}
+
+// InspectJWT разбирает токен, даже если он не прошёл бы проверку: показывает claims и отмечает
+// подпись, срок действия и отзыв сессии. Ключ подписи в результат не попадает.
+func InspectJWT(tokenString string) TokenInspection {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, signingKey)
+
+	if errors.Is(err, jwt.ErrTokenMalformed) {
+		return TokenInspection{Signature: SignatureUnknown, Error: err.Error()}
+	}
+
+	inspection := TokenInspection{
+		Claims:    claims,
+		Signature: SignatureValid,
+		// При неверной подписи библиотека срок не проверяет, поэтому он сверяется здесь
+		Expired: claims.ExpiresAt != nil && !claims.ExpiresAt.After(time.Now()),
+		Revoked: claims.ID != "" && Sessions.Revoked(claims.ID),
+	}
+	if errors.Is(err, jwt.ErrTokenSignatureInvalid) || errors.Is(err, jwt.ErrTokenUnverifiable) {
+		inspection.Signature = SignatureInvalid
+	}
+
+	if err == nil {
+		err = checkLifetime(claims)
+	}
+
+	switch {
+	case err != nil:
+		inspection.Error = err.Error()
+	case inspection.Revoked:
+		inspection.Error = "token revoked"
+	default:
+		inspection.Valid = true
+	}
+
+	return inspection
 }
 
 // Логин с проверкой пароля и генерацией JWT токена
 func Login(username, password, hash string) (string, error) {
+	return LoginFrom(username, password, hash, Client{})
+}
+
+// LoginFrom - Login, запоминающий в сессии, откуда выполнен вход
+func LoginFrom(username, password, hash string, client Client) (string, error) {
 	// Проверяем пароль
 	if !CheckPasswordHash(password, hash) {
 		return "", fmt.Errorf("invalid password")
 	}
 
 	// Генерируем JWT токен
-	token, err := GenerateJWT(username)
+	token, err := GenerateJWTFor(username, client)
 	if err != nil {
 		return "", err
 	}
@@ -102,33 +327,114 @@ func Login(username, password, hash string) (string, error) {
 	return token, nil
 }
 
+// ctxKey - тип ключей контекста пакета: строковый ключ другого пакета с ним не совпадёт
+type ctxKey string
+
+// NicknameKey - ключ, под которым TokenAuthMiddleware кладёт никнейм в контекст
+const NicknameKey ctxKey = "nickname"
+
+// SessionIDKey - ключ, под которым TokenAuthMiddleware кладёт id текущей сессии (jti)
+const SessionIDKey ctxKey = "session_id"
+
+// WithSessionID возвращает контекст с id сессии, которой выполнен запрос
+func WithSessionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, SessionIDKey, id)
+}
+
+// SessionIDFromContext возвращает id сессии запроса; "" - токен без id
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(SessionIDKey).(string)
+
+	return id
+}
+
+// WithNickname возвращает контекст с никнеймом авторизованного пользователя
+func WithNickname(ctx context.Context, nickname string) context.Context {
+	return context.WithValue(ctx, NicknameKey, nickname)
+}
+
+// NicknameFromContext возвращает никнейм авторизованного пользователя.
+// Если никнейма нет, он пустой или под ключом лежит значение другого типа, возвращается ("", false).
+func NicknameFromContext(ctx context.Context) (string, bool) {
+	nickname, ok := ctx.Value(NicknameKey).(string)
+	if !ok || nickname == "" {
+		return "", false
+	}
+
+	return nickname, true
+}
+
 // TokenAuthMiddleware проверяет наличие и валидность Bearer токена в заголовках
 func TokenAuthMiddleware(next http.Handler) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		tokenString := r.Header.Get("Authorization")
 		if tokenString == "" {
-			http.Error(w, "Authorization header is missing", http.StatusUnauthorized)
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("Authorization header is missing"))
 			return
 		}
 
-		// Удаляем префикс "Bearer " из строки токена
-		if strings.HasPrefix(tokenString, "Bearer ") {
-			tokenString = strings.TrimPrefix(tokenString, "Bearer ")
-		} else {
-			http.Error(w, "Invalid token format", http.StatusUnauthorized)
+		// Удаляем схему из строки токена
+		tokenString, ok := tokenFromHeader(tokenString)
+		if !ok {
+			expected := make([]string, 0, len(AuthSchemes))
+			for _, scheme := range AuthSchemes {
+				expected = append(expected, scheme+" <token>")
+			}
+			if len(AuthSchemes) > 0 {
+				w.Header().Set("WWW-Authenticate", AuthSchemes[0])
+			}
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("Invalid token format: expected "+strings.Join(expected, " or ")))
 			return
 		}
 
 		// Проверяем токен
-		nickname, err := ValidateJWT(tokenString)
+		claims, err := ParseJWT(tokenString)
 		if err != nil {
-			http.Error(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+			render.Status(r, http.StatusUnauthorized)
+			resp.JSON(w, r, resp.Error("Invalid token: "+err.Error()))
 			return
 		}
+		nickname := claims.Username
 		fmt.Println(nickname)
 
-		// Добавляем имя пользователя в контекст запроса
-		ctx := context.WithValue(r.Context(), "nickname", nickname)
+		// Добавляем имя пользователя и id сессии в контекст запроса
+		ctx := WithNickname(r.Context(), nickname)
+		ctx = WithSessionID(ctx, claims.ID)
 		next.ServeHTTP(w, r.WithContext(ctx)) // Переходим к следующему обработчику с обновленным контекстом
 	})
 }
+
+// tokenFromHeader достаёт токен из заголовка Authorization. Схема должна входить в AuthSchemes;
+// токен без схемы принимается только при AllowRawToken.
+func tokenFromHeader(header string) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found {
+		return scheme, AllowRawToken && scheme != ""
+	}
+
+	for _, allowed := range AuthSchemes {
+		if strings.EqualFold(scheme, allowed) {
+			token = strings.TrimSpace(token)
+			return token, token != ""
+		}
+	}
+
+	return "", false
+}
+
+// AdminOnly пропускает запрос только от администраторов.
+// Ставится после TokenAuthMiddleware, который кладёт nickname в контекст.
+func AdminOnly(next http.Handler) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nickname, ok := NicknameFromContext(r.Context())
+		if !ok || !IsAdmin(nickname) {
+			render.Status(r, http.StatusForbidden)
+			resp.JSON(w, r, resp.Error("Admin access required"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
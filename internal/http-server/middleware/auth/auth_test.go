@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNicknameFromContext(t *testing.T) {
+	nickname, ok := NicknameFromContext(WithNickname(context.Background(), "user"))
+	require.True(t, ok)
+	require.Equal(t, "user", nickname)
+
+	cases := map[string]context.Context{
+		"Missing":     context.Background(),
+		"Empty":       WithNickname(context.Background(), ""),
+		"Wrong type":  context.WithValue(context.Background(), NicknameKey, 42),
+		"Untyped key": context.WithValue(context.Background(), "nickname", "user"),
+	}
+	for name, ctx := range cases {
+		nickname, ok := NicknameFromContext(ctx)
+		require.False(t, ok, name)
+		require.Empty(t, nickname, name)
+	}
+}
+
+func TestAdminOnly_NicknameWrongType(t *testing.T) {
+	handler := AdminOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/status", nil)
+	req = req.WithContext(context.WithValue(req.Context(), NicknameKey, 42))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestPasswordPepper(t *testing.T) {
+	defer func() { PasswordPepper = nil }()
+
+	t.Run("Empty pepper matches plain bcrypt", func(t *testing.T) {
+		PasswordPepper = nil
+
+		// Хэш, сохранённый до появления пепера
+		plain, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+		require.NoError(t, err)
+		require.True(t, CheckPasswordHash("secret", string(plain)))
+
+		hash, err := HashPassword("secret")
+		require.NoError(t, err)
+		require.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("secret")))
+	})
+
+	t.Run("Pepper round-trips", func(t *testing.T) {
+		PasswordPepper = []byte("pepper")
+
+		hash, err := HashPassword("secret")
+		require.NoError(t, err)
+		require.True(t, CheckPasswordHash("secret", hash))
+		require.False(t, CheckPasswordHash("wrong", hash))
+
+		// Без пепера хэш не подходит
+		require.Error(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("secret")))
+
+		// Смена пепера делает хэш недействительным
+		PasswordPepper = []byte("other")
+		require.False(t, CheckPasswordHash("secret", hash))
+	})
+
+	t.Run("Long password with long pepper", func(t *testing.T) {
+		PasswordPepper = bytes.Repeat([]byte("p"), 32)
+		password := strings.Repeat("a", 70)
+
+		hash, err := HashPassword(password)
+		require.NoError(t, err)
+		require.True(t, CheckPasswordHash(password, hash))
+
+		// Пароли, различающиеся за пределами 72 байт bcrypt, не совпадают
+		require.False(t, CheckPasswordHash(strings.Repeat("a", 69)+"b", hash))
+		longer := strings.Repeat("a", 100)
+		longHash, err := HashPassword(longer)
+		require.NoError(t, err)
+		require.False(t, CheckPasswordHash(strings.Repeat("a", 99)+"b", longHash))
+	})
+}
+
+func TestTokenAuthMiddleware_Schemes(t *testing.T) {
+	JWTSecret = []byte("test-secret")
+	defer func() {
+		JWTSecret = nil
+		AuthSchemes = []string{"Bearer"}
+		AllowRawToken = false
+	}()
+
+	token, err := GenerateJWT("user")
+	require.NoError(t, err)
+
+	handler := TokenAuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nickname, _ := NicknameFromContext(r.Context())
+		require.Equal(t, "user", nickname)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cases := []struct {
+		name    string
+		schemes []string
+		raw     bool
+		header  string
+		want    int
+	}{
+		{name: "Bearer", schemes: []string{"Bearer"}, header: "Bearer " + token, want: http.StatusOK},
+		{name: "Lowercase bearer", schemes: []string{"Bearer"}, header: "bearer " + token, want: http.StatusOK},
+		{name: "Token scheme not allowed", schemes: []string{"Bearer"}, header: "token " + token, want: http.StatusUnauthorized},
+		{name: "Token scheme allowed", schemes: []string{"Bearer", "token"}, header: "token " + token, want: http.StatusOK},
+		{name: "Missing prefix", schemes: []string{"Bearer"}, header: token, want: http.StatusUnauthorized},
+		{name: "Missing prefix, raw allowed", schemes: []string{"Bearer"}, raw: true, header: token, want: http.StatusOK},
+		{name: "Unknown scheme, raw allowed", schemes: []string{"Bearer"}, raw: true, header: "Basic " + token, want: http.StatusUnauthorized},
+		{name: "Scheme without token", schemes: []string{"Bearer"}, header: "Bearer ", want: http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			AuthSchemes = tc.schemes
+			AllowRawToken = tc.raw
+
+			req := httptest.NewRequest(http.MethodGet, "/url/count", nil)
+			req.Header.Set("Authorization", tc.header)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.want, rr.Code, rr.Body.String())
+			if tc.want == http.StatusUnauthorized {
+				// В ответе названа ожидаемая схема
+				var body struct {
+					Error string `json:"error"`
+				}
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+				require.Contains(t, body.Error, tc.schemes[0]+" <token>")
+				require.Equal(t, tc.schemes[0], rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	defer func() {
+		JWTSecret = nil
+		JWTKeyID = ""
+		PreviousKeys = nil
+	}()
+
+	// До ротации токены подписываются ключом k1
+	JWTSecret, JWTKeyID = []byte("secret-1"), "k1"
+	oldToken, err := GenerateJWT("user")
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(oldToken, &Claims{})
+	require.NoError(t, err)
+	require.Equal(t, "k1", parsed.Header["kid"])
+
+	// Ротация: текущий ключ k2, k1 остаётся только для проверки
+	JWTSecret, JWTKeyID = []byte("secret-2"), "k2"
+	PreviousKeys = map[string]VerificationKey{"k1": {Secret: []byte("secret-1")}}
+
+	t.Run("Current key", func(t *testing.T) {
+		token, err := GenerateJWT("user")
+		require.NoError(t, err)
+
+		nickname, err := ValidateJWT(token)
+		require.NoError(t, err)
+		require.Equal(t, "user", nickname)
+	})
+
+	t.Run("Previous key", func(t *testing.T) {
+		nickname, err := ValidateJWT(oldToken)
+		require.NoError(t, err)
+		require.Equal(t, "user", nickname)
+	})
+
+	t.Run("Retired key", func(t *testing.T) {
+		PreviousKeys = map[string]VerificationKey{"k1": {Secret: []byte("secret-1"), Until: time.Now().Add(-time.Minute)}}
+		defer func() { PreviousKeys = map[string]VerificationKey{"k1": {Secret: []byte("secret-1")}} }()
+
+		_, err := ValidateJWT(oldToken)
+		require.ErrorIs(t, err, ErrKeyRetired)
+	})
+
+	t.Run("Removed key", func(t *testing.T) {
+		PreviousKeys = nil
+		defer func() { PreviousKeys = map[string]VerificationKey{"k1": {Secret: []byte("secret-1")}} }()
+
+		_, err := ValidateJWT(oldToken)
+		require.ErrorIs(t, err, ErrUnknownKeyID)
+	})
+
+	t.Run("Unknown kid", func(t *testing.T) {
+		claims := &Claims{Username: "user", RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}}
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = "forged"
+		signed, err := token.SignedString([]byte("secret-2"))
+		require.NoError(t, err)
+
+		_, err = ValidateJWT(signed)
+		require.ErrorIs(t, err, ErrUnknownKeyID)
+	})
+}
+
+func TestJWTLifetime(t *testing.T) {
+	JWTSecret = []byte("secret")
+	defer func() {
+		JWTSecret = nil
+		TokenTTL = 5 * time.Minute
+		MaxTokenLifetime = 24 * time.Hour
+	}()
+
+	sign := func(t *testing.T, claims jwt.RegisteredClaims) string {
+		t.Helper()
+
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{Username: "user", RegisteredClaims: claims}).SignedString(JWTSecret)
+		require.NoError(t, err)
+
+		return signed
+	}
+
+	t.Run("TTL clamped", func(t *testing.T) {
+		TokenTTL, MaxTokenLifetime = 48*time.Hour, time.Hour
+
+		token, err := GenerateJWT("user")
+		require.NoError(t, err)
+
+		claims, err := ParseJWT(token)
+		require.NoError(t, err)
+		require.Equal(t, time.Hour, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+		require.Equal(t, claims.IssuedAt, claims.NotBefore)
+	})
+
+	t.Run("Used before nbf", func(t *testing.T) {
+		TokenTTL, MaxTokenLifetime = 5*time.Minute, time.Hour
+		now := time.Now()
+
+		_, err := ValidateJWT(sign(t, jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now.Add(time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(10 * time.Minute)),
+		}))
+		require.ErrorIs(t, err, jwt.ErrTokenNotValidYet)
+	})
+
+	t.Run("Lifetime over the cap", func(t *testing.T) {
+		TokenTTL, MaxTokenLifetime = 5*time.Minute, time.Hour
+		now := time.Now()
+
+		_, err := ValidateJWT(sign(t, jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(365 * 24 * time.Hour)),
+		}))
+		require.ErrorIs(t, err, ErrTokenLifetime)
+	})
+
+	t.Run("No expiry", func(t *testing.T) {
+		TokenTTL, MaxTokenLifetime = 5*time.Minute, time.Hour
+
+		_, err := ValidateJWT(sign(t, jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(time.Now())}))
+		require.ErrorIs(t, err, ErrTokenLifetime)
+
+		// Без предела срок не проверяется
+		MaxTokenLifetime = 0
+		nickname, err := ValidateJWT(sign(t, jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(time.Now())}))
+		require.NoError(t, err)
+		require.Equal(t, "user", nickname)
+	})
+}
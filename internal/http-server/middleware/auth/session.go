@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Sessions - выданные токены пользователей. nil - сессии не отслеживаются и не ограничиваются.
+// Задаётся из конфига при старте приложения.
+var Sessions *SessionStore
+
+type session struct {
+	id        string
+	issuedAt  time.Time
+	expiresAt time.Time
+	client    Client
+}
+
+// Client - откуда выполнен вход. Поля пустые, если не известны.
+type Client struct {
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// SessionInfo - активная сессия пользователя
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Client
+}
+
+// SessionStore хранит id выданных токенов (jti) по пользователям и отозванные id.
+// Хранится в памяти: после перезапуска токены, выданные раньше, не отслеживаются
+// и действуют до истечения срока.
+type SessionStore struct {
+	mu sync.Mutex
+	// max - максимум активных сессий пользователя; 0 - без ограничения
+	max int
+	// active - активные сессии пользователя, от старых к новым
+	active map[string][]session
+	// revoked - отозванные id и срок, после которого о них можно забыть
+	revoked map[string]time.Time
+	now     func() time.Time
+}
+
+// NewSessionStore создаёт хранилище сессий с ограничением max активных сессий на пользователя
+func NewSessionStore(max int) *SessionStore {
+	return &SessionStore{
+		max:     max,
+		active:  make(map[string][]session),
+		revoked: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Add регистрирует новый токен пользователя. Если активных сессий становится больше max,
+// самые старые отзываются. Возвращает число активных сессий после добавления.
+func (s *SessionStore) Add(nickname, id string, expiresAt time.Time) int {
+	return s.AddFrom(nickname, id, expiresAt, Client{})
+}
+
+// AddFrom регистрирует новый токен пользователя вместе с данными клиента, выполнившего вход
+func (s *SessionStore) AddFrom(nickname, id string, expiresAt time.Time, client Client) int {
+	if s == nil {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+
+	sessions := append(s.active[nickname], session{id: id, issuedAt: s.now(), expiresAt: expiresAt, client: client})
+	for s.max > 0 && len(sessions) > s.max {
+		s.revoked[sessions[0].id] = sessions[0].expiresAt
+		sessions = sessions[1:]
+	}
+	s.active[nickname] = sessions
+
+	return len(sessions)
+}
+
+// Revoked сообщает, отозван ли токен с данным id
+func (s *SessionStore) Revoked(id string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.revoked[id]
+
+	return ok
+}
+
+// Count возвращает число активных сессий пользователя
+func (s *SessionStore) Count(nickname string) int {
+	if s == nil {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+
+	return len(s.active[nickname])
+}
+
+// List возвращает активные сессии пользователя, от старых к новым
+func (s *SessionStore) List(nickname string) []SessionInfo {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+
+	sessions := s.active[nickname]
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, sess := range sessions {
+		infos = append(infos, SessionInfo{
+			ID:        sess.id,
+			IssuedAt:  sess.issuedAt,
+			ExpiresAt: sess.expiresAt,
+			Client:    sess.client,
+		})
+	}
+
+	return infos
+}
+
+// Revoke отзывает активную сессию пользователя. false - у пользователя нет такой сессии:
+// чужую сессию отозвать нельзя.
+func (s *SessionStore) Revoke(nickname, id string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+
+	sessions := s.active[nickname]
+	for i, sess := range sessions {
+		if sess.id != id {
+			continue
+		}
+
+		s.revoked[id] = sess.expiresAt
+		rest := append(sessions[:i:i], sessions[i+1:]...)
+		if len(rest) == 0 {
+			delete(s.active, nickname)
+		} else {
+			s.active[nickname] = rest
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// prune забывает истёкшие сессии и отозванные id истёкших токенов. Вызывается под mu.
+func (s *SessionStore) prune() {
+	now := s.now()
+
+	for nickname, sessions := range s.active {
+		alive := sessions[:0]
+		for _, sess := range sessions {
+			if now.Before(sess.expiresAt) {
+				alive = append(alive, sess)
+			}
+		}
+		if len(alive) == 0 {
+			delete(s.active, nickname)
+			continue
+		}
+		s.active[nickname] = alive
+	}
+
+	for id, expiresAt := range s.revoked {
+		if !now.Before(expiresAt) {
+			delete(s.revoked, id)
+		}
+	}
+}
+
+// newTokenID генерирует случайный id токена (jti)
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions_OldestRevoked(t *testing.T) {
+	JWTSecret = []byte("test-secret")
+	Sessions = NewSessionStore(2)
+	t.Cleanup(func() {
+		JWTSecret = nil
+		Sessions = nil
+	})
+
+	tokens := make([]string, 3)
+	for i := range tokens {
+		token, err := GenerateJWT("user")
+		require.NoError(t, err)
+		tokens[i] = token
+	}
+	// Сессии другого пользователя не вытесняют чужие
+	other, err := GenerateJWT("other")
+	require.NoError(t, err)
+
+	_, err = ValidateJWT(tokens[0])
+	require.Error(t, err)
+
+	for _, token := range append(tokens[1:], other) {
+		_, err := ValidateJWT(token)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 2, Sessions.Count("user"))
+	require.Equal(t, 1, Sessions.Count("other"))
+}
+
+func TestSessionStore_Expiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewSessionStore(1)
+	store.now = func() time.Time { return now }
+
+	require.Equal(t, 1, store.Add("user", "first", now.Add(time.Minute)))
+	require.Equal(t, 1, store.Add("user", "second", now.Add(time.Minute)))
+	require.True(t, store.Revoked("first"))
+	require.False(t, store.Revoked("second"))
+
+	// Истёкшие сессии не занимают место, а отозванные id забываются вместе с их сроком
+	now = now.Add(2 * time.Minute)
+	require.Equal(t, 0, store.Count("user"))
+	require.False(t, store.Revoked("first"))
+}
+
+func TestSessionStore_Unlimited(t *testing.T) {
+	store := NewSessionStore(0)
+	expiresAt := time.Now().Add(time.Minute)
+
+	for i, id := range []string{"a", "b", "c"} {
+		require.Equal(t, i+1, store.Add("user", id, expiresAt))
+	}
+	require.False(t, store.Revoked("a"))
+}
+
+func TestSessionStore_Revoke(t *testing.T) {
+	JWTSecret = []byte("test-secret")
+	Sessions = NewSessionStore(0)
+	t.Cleanup(func() {
+		JWTSecret = nil
+		Sessions = nil
+	})
+
+	first, err := GenerateJWTFor("user", Client{IP: "10.0.0.1", UserAgent: "Firefox"})
+	require.NoError(t, err)
+	second, err := GenerateJWT("user")
+	require.NoError(t, err)
+
+	firstClaims, err := ParseJWT(first)
+	require.NoError(t, err)
+
+	sessions := Sessions.List("user")
+	require.Len(t, sessions, 2)
+	require.Equal(t, firstClaims.ID, sessions[0].ID)
+	require.Equal(t, "10.0.0.1", sessions[0].IP)
+
+	// Чужую сессию отозвать нельзя
+	require.False(t, Sessions.Revoke("other", firstClaims.ID))
+	require.True(t, Sessions.Revoke("user", firstClaims.ID))
+	require.False(t, Sessions.Revoke("user", firstClaims.ID))
+
+	_, err = ValidateJWT(first)
+	require.Error(t, err)
+	_, err = ValidateJWT(second)
+	require.NoError(t, err)
+	require.Equal(t, 1, Sessions.Count("user"))
+}
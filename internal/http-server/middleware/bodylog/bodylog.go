@@ -0,0 +1,147 @@
+package bodylog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/exp/slog"
+)
+
+// Redacted подставляется вместо значений чувствительных полей
+const Redacted = "[REDACTED]"
+
+// sensitiveFields - поля, значения которых не попадают в лог, в любом регистре и на любой вложенности
+var sensitiveFields = map[string]struct{}{
+	"password": {},
+	"token":    {},
+}
+
+// New возвращает middleware, который пишет в лог на уровне debug тела JSON-запросов и ответов.
+// Значения password и token заменяются на Redacted. Тело, не являющееся JSON (например, PNG с QR),
+// и тело длиннее maxBytes не логируются, в лог попадает только размер.
+// Если debug в логгере выключен, запросы проходят без буферизации.
+func New(log *slog.Logger, maxBytes int) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/bodylog"),
+		)
+
+		log.Info("body logging enabled", slog.Int("max_bytes", maxBytes))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !log.Enabled(r.Context(), slog.LevelDebug) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			log := log.With(
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			if r.Body != nil && r.Body != http.NoBody {
+				// Читается только то, что может попасть в лог, и один байт сверх, чтобы узнать о превышении
+				head, err := io.ReadAll(io.LimitReader(r.Body, int64(maxBytes)+1))
+				if err != nil {
+					log.Debug("failed to read request body")
+				}
+				// Обработчик получает тело целиком: прочитанное начало и непрочитанный остаток
+				r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(head), r.Body), Closer: r.Body}
+
+				size := len(head)
+				if size > maxBytes && r.ContentLength > 0 {
+					size = int(r.ContentLength)
+				}
+				log.Debug("request body", bodyAttr(r.Header.Get("Content-Type"), head, size, maxBytes))
+			}
+
+			captured := &limitedBuffer{limit: maxBytes + 1}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(captured)
+
+			next.ServeHTTP(ww, r)
+
+			if ww.BytesWritten() > 0 {
+				log.Debug("response body",
+					slog.Int("status", ww.Status()),
+					bodyAttr(ww.Header().Get("Content-Type"), captured.buf.Bytes(), ww.BytesWritten(), maxBytes),
+				)
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// readCloser - тело запроса, собранное из уже прочитанного начала и остатка
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedBuffer сохраняет только первые limit байт ответа, остальное пропускает
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+
+	return len(p), nil
+}
+
+// bodyAttr готовит тело к записи в лог: JSON с закрытыми полями или только размер.
+// body - начало тела не длиннее maxBytes+1, size - полный размер; у длинного тела запроса
+// без Content-Length известна только нижняя граница maxBytes+1.
+func bodyAttr(contentType string, body []byte, size, maxBytes int) slog.Attr {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "application/json" || len(body) > maxBytes {
+		return slog.Group("body", slog.String("content_type", contentType), slog.Int("size", size))
+	}
+
+	var decoded any
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		// Испорченный JSON не разобрать на поля, значит, и не закрыть пароль в нём
+		return slog.Group("body", slog.String("error", "invalid json"), slog.Int("size", size))
+	}
+
+	redacted, err := json.Marshal(redact(decoded))
+	if err != nil {
+		return slog.Group("body", slog.Int("size", size))
+	}
+
+	return slog.String("body", string(redacted))
+}
+
+// redact заменяет значения чувствительных полей во всём дереве JSON
+func redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if _, ok := sensitiveFields[strings.ToLower(key)]; ok {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redact(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redact(value)
+		}
+	}
+
+	return v
+}
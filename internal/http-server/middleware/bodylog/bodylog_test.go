@@ -0,0 +1,133 @@
+package bodylog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/bodylog"
+)
+
+// entries возвращает записи JSON-лога с сообщением msg
+func entries(t *testing.T, buf *bytes.Buffer, msg string) []map[string]any {
+	t.Helper()
+
+	var found []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(line, &entry))
+		if entry["msg"] == msg {
+			found = append(found, entry)
+		}
+	}
+
+	return found
+}
+
+func serve(t *testing.T, level slog.Level, next http.HandlerFunc, body string) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))
+
+	req := httptest.NewRequest(http.MethodPost, "/user/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	bodylog.New(log, 4096)(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	return &buf
+}
+
+func TestBodyLog_RedactsPassword(t *testing.T) {
+	const input = `{"nickname": "user", "password": "s3cret", "nested": {"Token": "abc"}}`
+
+	var received string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = string(body)
+
+		render.JSON(w, r, map[string]string{"status": "OK", "token": "jwt-token"})
+	}
+
+	buf := serve(t, slog.LevelDebug, next, input)
+
+	// Обработчик получает исходное тело
+	require.Equal(t, input, received)
+	require.NotContains(t, buf.String(), "s3cret")
+	require.NotContains(t, buf.String(), "jwt-token")
+
+	requests := entries(t, buf, "request body")
+	require.Len(t, requests, 1)
+
+	var logged map[string]any
+	require.NoError(t, json.Unmarshal([]byte(requests[0]["body"].(string)), &logged))
+	require.Equal(t, "user", logged["nickname"])
+	require.Equal(t, bodylog.Redacted, logged["password"])
+	require.Equal(t, bodylog.Redacted, logged["nested"].(map[string]any)["Token"])
+
+	responses := entries(t, buf, "response body")
+	require.Len(t, responses, 1)
+	require.Contains(t, responses[0]["body"], bodylog.Redacted)
+}
+
+func TestBodyLog_SkipsBinary(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n binary")
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(png)
+	}
+
+	buf := serve(t, slog.LevelDebug, next, `{}`)
+
+	responses := entries(t, buf, "response body")
+	require.Len(t, responses, 1)
+	require.Equal(t, map[string]any{"content_type": "image/png", "size": float64(len(png))}, responses[0]["body"])
+}
+
+func TestBodyLog_DebugDisabled(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, map[string]string{"status": "OK"})
+	}
+
+	buf := serve(t, slog.LevelInfo, next, `{"password": "s3cret"}`)
+
+	require.Empty(t, entries(t, buf, "request body"))
+	require.Empty(t, entries(t, buf, "response body"))
+}
+
+func TestBodyLog_LargeBodies(t *testing.T) {
+	input := `{"url": "` + strings.Repeat("a", 8192) + `"}`
+	output := `{"data": "` + strings.Repeat("b", 8192) + `"}`
+
+	var received string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = string(body)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(output))
+	}
+
+	buf := serve(t, slog.LevelDebug, next, input)
+
+	// Обработчик получает тело целиком, хотя в лог оно не попадает
+	require.Equal(t, input, received)
+
+	requests := entries(t, buf, "request body")
+	require.Len(t, requests, 1)
+	require.Equal(t, float64(len(input)), requests[0]["body"].(map[string]any)["size"])
+
+	responses := entries(t, buf, "response body")
+	require.Len(t, responses, 1)
+	require.Equal(t, float64(len(output)), responses[0]["body"].(map[string]any)["size"])
+}
@@ -0,0 +1,42 @@
+package contenttype
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// New возвращает middleware для JSON-эндпоинтов: запрос без
+// Content-Type: application/json (параметры вроде charset допускаются)
+// получает 415 до того, как обработчик попытается разобрать тело.
+func New(log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/contenttype"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			contentType := r.Header.Get("Content-Type")
+
+			mediaType, _, err := mime.ParseMediaType(contentType)
+			if err != nil || mediaType != "application/json" {
+				log.Info("unsupported content type",
+					slog.String("content_type", contentType),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+				)
+				render.Status(r, http.StatusUnsupportedMediaType)
+				resp.JSON(w, r, resp.Error("Content-Type must be application/json"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
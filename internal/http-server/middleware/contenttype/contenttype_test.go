@@ -0,0 +1,54 @@
+package contenttype_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/contenttype"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestContentType(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		status      int
+	}{
+		{name: "JSON", contentType: "application/json", status: http.StatusOK},
+		{name: "JSON with charset", contentType: "application/json; charset=utf-8", status: http.StatusOK},
+		{name: "Upper case", contentType: "Application/JSON", status: http.StatusOK},
+		{name: "Form post", contentType: "application/x-www-form-urlencoded", status: http.StatusUnsupportedMediaType},
+		{name: "Plain text", contentType: "text/plain", status: http.StatusUnsupportedMediaType},
+		{name: "Missing", contentType: "", status: http.StatusUnsupportedMediaType},
+		{name: "Malformed", contentType: "application/json; charset", status: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			called := false
+			handler := contenttype.New(slogdiscard.NewDiscardLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/url/save", strings.NewReader(`{"url": "https://google.com"}`))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			require.Equal(t, tc.status == http.StatusOK, called)
+		})
+	}
+}
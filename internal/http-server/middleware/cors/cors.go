@@ -0,0 +1,104 @@
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	allowedMethods = "GET, POST, PATCH, DELETE"
+	allowedHeaders = "Authorization, Content-Type, Idempotency-Key, X-Request-Nonce, X-Request-Timestamp, X-Request-Signature"
+	wildcard       = "*"
+)
+
+// ErrCredentialsWildcard - браузеры не принимают Allow-Credentials вместе с Allow-Origin: *
+var ErrCredentialsWildcard = errors.New("cors: allow_credentials cannot be combined with wildcard origin")
+
+// Config - параметры CORS
+type Config struct {
+	// AllowedOrigins - origin, которым разрешены запросы из браузера; "*" - любой
+	AllowedOrigins []string
+	// AllowCredentials - браузер отправляет cookie и заголовок Authorization
+	AllowCredentials bool
+	// MaxAge - сколько браузер кэширует ответ на preflight; 0 - заголовок не выставляется
+	MaxAge time.Duration
+}
+
+// New возвращает middleware, выставляющий CORS-заголовки для разрешённых origin
+// и отвечающий на preflight-запросы. Без AllowedOrigins middleware ничего не делает.
+func New(cfg Config) (func(next http.Handler) http.Handler, error) {
+	const op = "middleware.cors.New"
+
+	allowAny := false
+	origins := make(map[string]struct{}, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.TrimSpace(origin)
+		if origin == wildcard {
+			allowAny = true
+			continue
+		}
+		origins[origin] = struct{}{}
+	}
+
+	if allowAny && cfg.AllowCredentials {
+		return nil, fmt.Errorf("%s: %w", op, ErrCredentialsWildcard)
+	}
+
+	maxAge := ""
+	if cfg.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(cfg.MaxAge / time.Second))
+	}
+
+	return func(next http.Handler) http.Handler {
+		if !allowAny && len(origins) == 0 {
+			return next
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			_, listed := origins[origin]
+			if !listed && !allowAny {
+				// Заголовки не выставляются - браузер сам заблокирует ответ
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// С credentials origin возвращается точно, без "*"
+			if allowAny && !cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", wildcard)
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Preflight обрабатывается здесь и до маршрутизатора не доходит
+			h.Set("Access-Control-Allow-Methods", allowedMethods)
+			h.Set("Access-Control-Allow-Headers", allowedHeaders)
+			if maxAge != "" {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		return http.HandlerFunc(fn)
+	}, nil
+}
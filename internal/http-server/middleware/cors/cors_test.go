@@ -0,0 +1,103 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/cors"
+)
+
+func serve(t *testing.T, cfg cors.Config, req *http.Request) (*httptest.ResponseRecorder, bool) {
+	t.Helper()
+
+	mw, err := cors.New(cfg)
+	require.NoError(t, err)
+
+	called := false
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	return rr, called
+}
+
+func TestCORS_Credentials(t *testing.T) {
+	cfg := cors.Config{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/url/count", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+
+	rr, called := serve(t, cfg, req)
+
+	require.True(t, called)
+	require.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+	require.Equal(t, "Origin", rr.Header().Get("Vary"))
+}
+
+func TestCORS_UnknownOrigin(t *testing.T) {
+	cfg := cors.Config{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true}
+
+	req := httptest.NewRequest(http.MethodGet, "/url/count", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+
+	rr, called := serve(t, cfg, req)
+
+	require.True(t, called)
+	require.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	require.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORS_Wildcard(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/url/count", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+
+	rr, _ := serve(t, cors.Config{AllowedOrigins: []string{"*"}}, req)
+
+	require.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	require.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORS_CredentialsWithWildcard(t *testing.T) {
+	_, err := cors.New(cors.Config{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	require.ErrorIs(t, err, cors.ErrCredentialsWildcard)
+}
+
+func TestCORS_PreflightMaxAge(t *testing.T) {
+	cfg := cors.Config{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	req := httptest.NewRequest(http.MethodOptions, "/url/save", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+
+	rr, called := serve(t, cfg, req)
+
+	require.False(t, called)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	require.Equal(t, "600", rr.Header().Get("Access-Control-Max-Age"))
+	require.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	require.Contains(t, rr.Header().Get("Access-Control-Allow-Methods"), http.MethodPost)
+	// Заголовки защиты от повтора запросов тоже разрешены браузеру
+	require.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), "X-Request-Nonce")
+	require.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), "X-Request-Timestamp")
+	require.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), "X-Request-Signature")
+
+	// Без MaxAge заголовок не выставляется
+	rr, _ = serve(t, cors.Config{AllowedOrigins: []string{"https://app.example.com"}}, req)
+	require.Empty(t, rr.Header().Get("Access-Control-Max-Age"))
+}
@@ -0,0 +1,241 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+)
+
+const (
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed выставляется у ответа, повторённого по ключу
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+// maxKeyLength ограничивает длину ключа, чтобы клиент не раздувал хранилище
+const maxKeyLength = 255
+
+// sweepInterval - как часто Run удаляет устаревшие ответы
+const sweepInterval = time.Minute
+
+// Коды ошибок для повторов по ключу
+const (
+	CodeInProgress = "idempotency_in_progress"
+	CodeKeyReused  = "idempotency_key_reused"
+	CodeInvalidKey = "invalid_idempotency_key"
+)
+
+// result - сохранённый ответ на запрос с ключом
+type result struct {
+	// bodyHash - хэш тела запроса: тот же ключ с другим телом - ошибка клиента
+	bodyHash  [sha256.Size]byte
+	done      bool
+	status    int
+	header    http.Header
+	body      []byte
+	expiresAt time.Time
+}
+
+// Store хранит ответы на запросы с Idempotency-Key в течение ttl.
+// Ключи разных пользователей не пересекаются.
+type Store struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	results map[string]*result
+}
+
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		ttl:     ttl,
+		results: make(map[string]*result),
+	}
+}
+
+// begin возвращает сохранённый результат по ключу или, если ключ новый, резервирует его
+// под выполняющийся запрос и возвращает nil
+func (s *Store) begin(key string, bodyHash [sha256.Size]byte, now time.Time) *result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Устаревшая запись, которую ещё не удалил Run, считается отсутствующей. Запрос,
+	// так и не завершившийся за ttl, тоже освобождает ключ.
+	if res, ok := s.results[key]; ok && !now.After(res.expiresAt) {
+		copied := *res
+		return &copied
+	}
+
+	s.results[key] = &result{bodyHash: bodyHash, expiresAt: now.Add(s.ttl)}
+
+	return nil
+}
+
+// Run удаляет устаревшие ответы каждые sweepInterval, пока не будет отменён ctx,
+// чтобы хранилище не росло бесконечно
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(time.Now())
+		}
+	}
+}
+
+// sweep удаляет ответы, срок которых истёк к моменту now
+func (s *Store) sweep(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, res := range s.results {
+		if now.After(res.expiresAt) {
+			delete(s.results, k)
+		}
+	}
+}
+
+// finish сохраняет ответ. Ответ с ошибкой сервера не сохраняется: повтор выполнится заново.
+func (s *Store) finish(key string, status int, header http.Header, body []byte, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status >= http.StatusInternalServerError {
+		delete(s.results, key)
+		return
+	}
+
+	res, ok := s.results[key]
+	if !ok {
+		return
+	}
+	res.done = true
+	res.status = status
+	res.header = header
+	res.body = body
+	res.expiresAt = now.Add(s.ttl)
+}
+
+// release освобождает ключ запроса, который так и не завершился
+func (s *Store) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if res, ok := s.results[key]; ok && !res.done {
+		delete(s.results, key)
+	}
+}
+
+// New возвращает middleware, который выполняет запрос с заголовком Idempotency-Key один раз:
+// повтор с тем же ключом получает сохранённый ответ, а не создаёт ссылку заново.
+// Ключи действуют в пределах пользователя, поэтому middleware ставится после авторизации.
+// Запросы без ключа проходят как обычно. Тело запроса с ключом читается целиком ради хэша,
+// поэтому оно ограничено maxBodyBytes: более длинное получает 413.
+func New(log *slog.Logger, store *Store, maxBodyBytes int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/idempotency"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderKey)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			log := log.With(
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			if len(key) > maxKeyLength {
+				log.Error("idempotency key is too long")
+				render.Status(r, http.StatusBadRequest)
+				resp.JSON(w, r, resp.ErrorWithCode("idempotency key is too long", CodeInvalidKey))
+				return
+			}
+
+			nickname, _ := auth.NicknameFromContext(r.Context())
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				log.Info("request body is too large", slog.Int64("limit", tooLarge.Limit))
+				render.Status(r, http.StatusRequestEntityTooLarge)
+				resp.JSON(w, r, resp.Error("request body is too large"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to read request body")
+				render.Status(r, http.StatusBadRequest)
+				resp.JSON(w, r, resp.Error("failed to read request"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scopedKey := nickname + "\x00" + key
+			bodyHash := sha256.Sum256(body)
+
+			saved := store.begin(scopedKey, bodyHash, time.Now())
+			switch {
+			case saved == nil:
+			case saved.bodyHash != bodyHash:
+				log.Info("idempotency key reused with another body")
+				render.Status(r, http.StatusUnprocessableEntity)
+				resp.JSON(w, r, resp.ErrorWithCode("idempotency key was used for another request", CodeKeyReused))
+				return
+			case !saved.done:
+				log.Info("request with idempotency key is in progress")
+				render.Status(r, http.StatusConflict)
+				resp.JSON(w, r, resp.ErrorWithCode("request with this idempotency key is in progress", CodeInProgress))
+				return
+			default:
+				log.Info("replaying response for idempotency key")
+				for name, values := range saved.header {
+					w.Header()[name] = values
+				}
+				w.Header().Set(HeaderReplayed, "true")
+				w.WriteHeader(saved.status)
+				_, _ = w.Write(saved.body)
+				return
+			}
+
+			// Если обработчик упал с паникой, ключ освобождается: иначе повторы получали бы 409 до конца ttl
+			finished := false
+			defer func() {
+				if !finished {
+					store.release(scopedKey)
+				}
+			}()
+
+			var captured bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&captured)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			store.finish(scopedKey, status, w.Header().Clone(), captured.Bytes(), time.Now())
+			finished = true
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,160 @@
+package idempotency_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/handlers/url/save/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/http-server/middleware/idempotency"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func send(t *testing.T, handler http.Handler, nickname, key, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/url/save", bytes.NewReader([]byte(body)))
+	if key != "" {
+		req.Header.Set(idempotency.HeaderKey, key)
+	}
+	req = req.WithContext(auth.WithNickname(req.Context(), nickname))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestIdempotency_SaveOnce(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+		Return(int64(1), "", nil).
+		Once()
+	urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+		Return(storage.UserSettings{}, nil).
+		Once()
+	// Случайный alias создаётся ровно один раз
+	urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://google.com", mock.Anything, int64(1), storage.URLOptions{}).
+		Return(nil).
+		Once()
+
+	handler := idempotency.New(log, idempotency.NewStore(time.Hour), 1<<20)(save.New(log, urlSaverMock, save.Options{}))
+
+	const body = `{"url": "https://google.com"}`
+
+	first := send(t, handler, "user", "key-1", body)
+	require.Equal(t, http.StatusOK, first.Code)
+	require.Empty(t, first.Header().Get(idempotency.HeaderReplayed))
+
+	second := send(t, handler, "user", "key-1", body)
+	require.Equal(t, http.StatusOK, second.Code)
+	require.Equal(t, "true", second.Header().Get(idempotency.HeaderReplayed))
+	require.Equal(t, first.Body.String(), second.Body.String())
+	require.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+}
+
+func TestIdempotency(t *testing.T) {
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+	handler := idempotency.New(slogdiscard.NewDiscardLogger(), idempotency.NewStore(time.Hour), 1<<20)(next)
+
+	t.Run("Keys are scoped per user", func(t *testing.T) {
+		calls = 0
+		require.Equal(t, http.StatusCreated, send(t, handler, "alice", "shared", "{}").Code)
+		require.Equal(t, http.StatusCreated, send(t, handler, "bob", "shared", "{}").Code)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("Key reused with another body", func(t *testing.T) {
+		calls = 0
+		require.Equal(t, http.StatusCreated, send(t, handler, "alice", "reused", `{"a": 1}`).Code)
+		require.Equal(t, http.StatusUnprocessableEntity, send(t, handler, "alice", "reused", `{"a": 2}`).Code)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("Without key", func(t *testing.T) {
+		calls = 0
+		send(t, handler, "alice", "", "{}")
+		send(t, handler, "alice", "", "{}")
+		require.Equal(t, 2, calls)
+	})
+}
+
+func TestIdempotency_ServerErrorNotStored(t *testing.T) {
+	status := http.StatusInternalServerError
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+	handler := idempotency.New(slogdiscard.NewDiscardLogger(), idempotency.NewStore(time.Hour), 1<<20)(next)
+
+	require.Equal(t, http.StatusInternalServerError, send(t, handler, "user", "retry", "{}").Code)
+
+	// Повтор после ошибки сервера выполняется заново
+	status = http.StatusOK
+	rr := send(t, handler, "user", "retry", "{}")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Empty(t, rr.Header().Get(idempotency.HeaderReplayed))
+}
+
+func TestIdempotency_BodyTooLarge(t *testing.T) {
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+	handler := idempotency.New(slogdiscard.NewDiscardLogger(), idempotency.NewStore(time.Hour), 16)(next)
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, send(t, handler, "user", "big", `{"url": "https://google.com"}`).Code)
+	require.Zero(t, calls)
+
+	// Ключ не занят: запрос с допустимым телом выполняется
+	require.Equal(t, http.StatusOK, send(t, handler, "user", "big", "{}").Code)
+	require.Equal(t, 1, calls)
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	panics := true
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if panics {
+			panic("handler failed")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	handler := idempotency.New(slogdiscard.NewDiscardLogger(), idempotency.NewStore(time.Hour), 1<<20)(next)
+
+	require.Panics(t, func() { send(t, handler, "user", "crash", "{}") })
+
+	// Повтор выполняется заново, а не получает 409
+	panics = false
+	require.Equal(t, http.StatusCreated, send(t, handler, "user", "crash", "{}").Code)
+}
+
+func TestIdempotency_ExpiredResultNotReplayed(t *testing.T) {
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+	handler := idempotency.New(slogdiscard.NewDiscardLogger(), idempotency.NewStore(time.Millisecond), 1<<20)(next)
+
+	require.Equal(t, http.StatusCreated, send(t, handler, "user", "short", "{}").Code)
+	time.Sleep(5 * time.Millisecond)
+
+	// Запись устарела, хотя фоновая очистка до неё ещё не дошла
+	rr := send(t, handler, "user", "short", "{}")
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Empty(t, rr.Header().Get(idempotency.HeaderReplayed))
+	require.Equal(t, 2, calls)
+}
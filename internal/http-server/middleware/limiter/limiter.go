@@ -0,0 +1,104 @@
+package limiter
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// Коды ошибок отказа: клиенту нужно отличать собственное превышение частоты (429, сбавить темп)
+// от перегрузки сервера (503, повторить чуть позже)
+const (
+	CodeOverloaded  = "server_overloaded"
+	CodeRateLimited = "rate_limited"
+)
+
+// Config - параметры ограничения одновременно обрабатываемых запросов
+type Config struct {
+	// MaxInFlight - максимум запросов в обработке; 0 - без ограничения
+	MaxInFlight int
+	// QueueTimeout - сколько запрос ждёт свободного слота; 0 - отказ сразу
+	QueueTimeout time.Duration
+	// RetryAfter - значение заголовка Retry-After в ответе 503
+	RetryAfter time.Duration
+}
+
+// New возвращает middleware-семафор. Когда все слоты заняты, запрос либо сразу
+// получает 503 с Retry-After, либо ждёт освобождения слота не дольше QueueTimeout.
+func New(log *slog.Logger, cfg Config) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if cfg.MaxInFlight <= 0 {
+			return next
+		}
+
+		log := log.With(
+			slog.String("component", "middleware/limiter"),
+		)
+
+		log.Info("concurrency limit enabled",
+			slog.Int("max_in_flight", cfg.MaxInFlight),
+			slog.Duration("queue_timeout", cfg.QueueTimeout),
+		)
+
+		slots := make(chan struct{}, cfg.MaxInFlight)
+		retryAfter := strconv.Itoa(retryAfterSeconds(cfg.RetryAfter))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !acquire(r, slots, cfg.QueueTimeout) {
+				log.Warn("too many requests in flight, request rejected",
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+				)
+				w.Header().Set("Retry-After", retryAfter)
+				render.Status(r, http.StatusServiceUnavailable)
+				resp.JSON(w, r, resp.ErrorWithCode("server is overloaded, try again later", CodeOverloaded))
+				return
+			}
+			defer func() { <-slots }()
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// acquire занимает слот, ожидая не дольше timeout
+func acquire(r *http.Request, slots chan struct{}, timeout time.Duration) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if timeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// retryAfterSeconds округляет интервал вверх до целых секунд, минимум 1
+func retryAfterSeconds(d time.Duration) int {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+
+	return seconds
+}
@@ -0,0 +1,135 @@
+package limiter_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/limiter"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+// errorCode возвращает код ошибки из JSON-ответа
+func errorCode(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var body resp.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+	return body.Code
+}
+
+// blockingHandler держит запросы, пока не закрыт release
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestLimiter_Reject(t *testing.T) {
+	const (
+		limit    = 2
+		requests = 5
+	)
+
+	started := make(chan struct{}, requests)
+	release := make(chan struct{})
+
+	handler := limiter.New(slogdiscard.NewDiscardLogger(), limiter.Config{
+		MaxInFlight: limit,
+		RetryAfter:  3 * time.Second,
+	})(blockingHandler(started, release))
+
+	codes := make(chan *httptest.ResponseRecorder, requests)
+	var wg sync.WaitGroup
+
+	send := func() {
+		defer wg.Done()
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		codes <- rr
+	}
+
+	// Занимаем все слоты
+	wg.Add(limit)
+	for i := 0; i < limit; i++ {
+		go send()
+	}
+	for i := 0; i < limit; i++ {
+		<-started
+	}
+
+	// Остальные запросы отклоняются, не дожидаясь освобождения
+	wg.Add(requests - limit)
+	for i := 0; i < requests-limit; i++ {
+		go send()
+	}
+	for i := 0; i < requests-limit; i++ {
+		rr := <-codes
+		require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		require.Equal(t, "3", rr.Header().Get("Retry-After"))
+		require.Equal(t, limiter.CodeOverloaded, errorCode(t, rr))
+	}
+
+	close(release)
+	wg.Wait()
+	close(codes)
+
+	for rr := range codes {
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+}
+
+func TestLimiter_Queue(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	handler := limiter.New(slogdiscard.NewDiscardLogger(), limiter.Config{
+		MaxInFlight:  1,
+		QueueTimeout: 5 * time.Second,
+	})(blockingHandler(started, release))
+
+	codes := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			codes <- rr.Code
+		}()
+	}
+
+	// Первый запрос в обработке, второй ждёт в очереди и проходит после освобождения слота
+	<-started
+	close(release)
+
+	require.Equal(t, http.StatusOK, <-codes)
+	require.Equal(t, http.StatusOK, <-codes)
+}
+
+func TestLimiter_QueueTimeout(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+
+	handler := limiter.New(slogdiscard.NewDiscardLogger(), limiter.Config{
+		MaxInFlight:  1,
+		QueueTimeout: 20 * time.Millisecond,
+	})(blockingHandler(started, release))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	<-started
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	require.Equal(t, "1", rr.Header().Get("Retry-After"))
+}
@@ -0,0 +1,152 @@
+package limiter
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// KeyFunc выделяет из запроса ключ, по которому считается частота. "" - запрос не ограничивается.
+type KeyFunc func(r *http.Request) string
+
+// ByIP - ключ по адресу клиента. Ставится после realip, чтобы за прокси считать реальный адрес.
+func ByIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
+
+// ByAlias - ключ по параметру маршрута {alias}. Ставится на маршрут через With.
+func ByAlias(r *http.Request) string {
+	return chi.URLParam(r, "alias")
+}
+
+// ByUser - ключ по никнейму авторизованного пользователя. Ставится на маршрут, требующий токен.
+func ByUser(r *http.Request) string {
+	nickname, _ := auth.NicknameFromContext(r.Context())
+	return nickname
+}
+
+// RateConfig - ограничение частоты запросов по ключу
+type RateConfig struct {
+	// Name - что ограничивается, для логов: ip, alias, user
+	Name string
+	// Limit - запросов на ключ за Window; 0 - без ограничения
+	Limit  int
+	Window time.Duration
+	Key    KeyFunc
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// rateCounter считает запросы по ключам в фиксированных окнах
+type rateCounter struct {
+	mu        sync.Mutex
+	limit     int
+	size      time.Duration
+	windows   map[string]*window
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// allow учитывает запрос и возвращает, укладывается ли он в лимит, и через сколько
+// начнётся следующее окно
+func (c *rateCounter) allow(key string) (bool, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.prune(now)
+
+	w, ok := c.windows[key]
+	if !ok || !now.Before(w.start.Add(c.size)) {
+		w = &window{start: now}
+		c.windows[key] = w
+	}
+
+	if w.count >= c.limit {
+		return false, w.start.Add(c.size).Sub(now)
+	}
+	w.count++
+
+	return true, 0
+}
+
+// prune раз в окно забывает закончившиеся окна, чтобы карта не росла. Вызывается под mu.
+func (c *rateCounter) prune(now time.Time) {
+	if now.Sub(c.lastPrune) < c.size {
+		return
+	}
+	c.lastPrune = now
+
+	for key, w := range c.windows {
+		if !now.Before(w.start.Add(c.size)) {
+			delete(c.windows, key)
+		}
+	}
+}
+
+// NewRate возвращает middleware, пропускающий не больше Limit запросов на ключ за Window.
+// Сверх лимита клиент получает 429 с Retry-After до начала следующего окна.
+func NewRate(log *slog.Logger, cfg RateConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if cfg.Limit <= 0 || cfg.Window <= 0 {
+			return next
+		}
+
+		log := log.With(
+			slog.String("component", "middleware/limiter"),
+			slog.String("limit_by", cfg.Name),
+		)
+		log.Info("rate limit enabled",
+			slog.Int("limit", cfg.Limit),
+			slog.Duration("window", cfg.Window),
+		)
+
+		counter := &rateCounter{
+			limit:   cfg.Limit,
+			size:    cfg.Window,
+			windows: make(map[string]*window),
+			now:     time.Now,
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := cfg.Key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ok, retryAfter := counter.allow(key)
+			if !ok {
+				log.Warn("rate limit exceeded, request rejected",
+					slog.String("key", key),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+				)
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+				render.Status(r, http.StatusTooManyRequests)
+				resp.JSON(w, r, resp.ErrorWithCode("too many requests, slow down", CodeRateLimited))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
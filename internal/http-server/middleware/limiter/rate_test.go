@@ -0,0 +1,96 @@
+package limiter_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/limiter"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func requestFrom(remoteAddr string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+
+	return req
+}
+
+func TestRate_PerIP(t *testing.T) {
+	handler := limiter.NewRate(slogdiscard.NewDiscardLogger(), limiter.RateConfig{
+		Name:   "ip",
+		Limit:  2,
+		Window: time.Minute,
+		Key:    limiter.ByIP,
+	})(okHandler)
+
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, requestFrom("10.0.0.1:1234"))
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	// Третий запрос с того же адреса (с другого порта) превышает лимит
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, requestFrom("10.0.0.1:5678"))
+
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, limiter.CodeRateLimited, errorCode(t, rr))
+
+	retryAfter, err := strconv.Atoi(rr.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	require.Greater(t, retryAfter, 0)
+	require.LessOrEqual(t, retryAfter, 60)
+
+	// Другой адрес считается отдельно
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, requestFrom("10.0.0.2:1234"))
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRate_PerAlias(t *testing.T) {
+	r := chi.NewRouter()
+	r.With(limiter.NewRate(slogdiscard.NewDiscardLogger(), limiter.RateConfig{
+		Name:   "alias",
+		Limit:  1,
+		Window: time.Minute,
+		Key:    limiter.ByAlias,
+	})).Get("/r/{alias}", okHandler)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	require.Equal(t, http.StatusOK, get("/r/hot").Code)
+
+	rr := get("/r/hot")
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, limiter.CodeRateLimited, errorCode(t, rr))
+	require.NotEmpty(t, rr.Header().Get("Retry-After"))
+
+	require.Equal(t, http.StatusOK, get("/r/cold").Code)
+}
+
+func TestRate_Disabled(t *testing.T) {
+	handler := limiter.NewRate(slogdiscard.NewDiscardLogger(), limiter.RateConfig{
+		Window: time.Minute,
+		Key:    limiter.ByIP,
+	})(okHandler)
+
+	for i := 0; i < 10; i++ {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, requestFrom("10.0.0.1:1234"))
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"golang.org/x/exp/slog"
 )
 
+// New логирует каждый запрос после ответа. Статус берётся из обёртки ResponseWriter,
+// поэтому в логе оказывается код, который обработчик действительно отправил.
 func New(log *slog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		log := log.With(
@@ -28,8 +30,14 @@ func New(log *slog.Logger) func(next http.Handler) http.Handler {
 
 			t1 := time.Now()
 			defer func() {
+				// Обработчик, не записавший ни заголовков, ни тела, отвечает 200
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+
 				entry.Info("request completed",
-					slog.Int("status", ww.Status()),
+					slog.Int("status", status),
 					slog.Int("bytes", ww.BytesWritten()),
 					slog.String("duration", time.Since(t1).String()),
 				)
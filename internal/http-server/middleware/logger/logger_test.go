@@ -0,0 +1,81 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	mwLogger "url-shortener/internal/http-server/middleware/logger"
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// completed возвращает запись "request completed" из JSON-лога
+func completed(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(line, &entry))
+		if entry["msg"] == "request completed" {
+			return entry
+		}
+	}
+
+	t.Fatal("request completed entry not found")
+	return nil
+}
+
+func TestLogger_Status(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+	}{
+		{
+			name: "Not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("not found"))
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "Implicit OK",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				render.JSON(w, r, resp.OK())
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:    "Empty response",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			status:  http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			log := slog.New(slog.NewJSONHandler(&buf, nil))
+
+			rr := httptest.NewRecorder()
+			mwLogger.New(log)(tc.handler).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/url/missing", nil))
+
+			require.Equal(t, tc.status, rr.Code)
+
+			entry := completed(t, &buf)
+			require.EqualValues(t, tc.status, entry["status"])
+			require.Equal(t, "/url/missing", entry["path"])
+		})
+	}
+}
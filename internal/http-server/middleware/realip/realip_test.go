@@ -0,0 +1,79 @@
+package realip_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/realip"
+)
+
+func TestRealIP(t *testing.T) {
+	cases := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		xRealIP    string
+		want       string
+	}{
+		{
+			name:       "Trusted proxy with X-Forwarded-For",
+			remoteAddr: "10.0.0.5:4321",
+			xff:        "203.0.113.7, 10.0.0.2",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Trusted proxy with X-Real-IP",
+			remoteAddr: "10.0.0.5:4321",
+			xRealIP:    "203.0.113.8",
+			want:       "203.0.113.8",
+		},
+		{
+			name:       "Untrusted peer ignores headers",
+			remoteAddr: "198.51.100.1:4321",
+			xff:        "203.0.113.7",
+			xRealIP:    "203.0.113.8",
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "Spoofed leftmost entry behind trusted proxy",
+			remoteAddr: "10.0.0.5:4321",
+			xff:        "1.2.3.4, 198.51.100.9",
+			want:       "198.51.100.9",
+		},
+	}
+
+	mw, err := realip.New([]string{"10.0.0.0/8", "127.0.0.1"})
+	require.NoError(t, err)
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.xff != "" {
+				req.Header.Set("X-Forwarded-For", tc.xff)
+			}
+			if tc.xRealIP != "" {
+				req.Header.Set("X-Real-IP", tc.xRealIP)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRealIP_InvalidProxy(t *testing.T) {
+	_, err := realip.New([]string{"not-an-ip"})
+	require.Error(t, err)
+}
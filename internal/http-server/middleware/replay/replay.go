@@ -0,0 +1,160 @@
+package replay
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+const (
+	HeaderNonce     = "X-Request-Nonce"
+	HeaderTimestamp = "X-Request-Timestamp"
+	// HeaderSignature - hex HMAC-SHA256 запроса, см. Sign
+	HeaderSignature = "X-Request-Signature"
+)
+
+// NonceStore хранит недавно использованные nonce не меньше ttl. Nonce лежат в двух поколениях:
+// раз в ttl старое поколение выбрасывается целиком, поэтому запрос не перебирает всё хранилище.
+type NonceStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	rotatedAt time.Time
+	current   map[string]struct{}
+	previous  map[string]struct{}
+}
+
+func NewNonceStore(ttl time.Duration) *NonceStore {
+	return &NonceStore{
+		ttl:      ttl,
+		current:  make(map[string]struct{}),
+		previous: make(map[string]struct{}),
+	}
+}
+
+// Use отмечает nonce как использованный. Возвращает false, если nonce уже встречался.
+func (s *NonceStore) Use(nonce string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rotatedAt.IsZero() {
+		s.rotatedAt = now
+	}
+	if now.Sub(s.rotatedAt) >= s.ttl {
+		s.previous, s.current = s.current, make(map[string]struct{})
+		// Больше двух ttl простоя - забыть и предыдущее поколение
+		if now.Sub(s.rotatedAt) >= 2*s.ttl {
+			s.previous = make(map[string]struct{})
+		}
+		s.rotatedAt = now
+	}
+
+	if _, ok := s.current[nonce]; ok {
+		return false
+	}
+	if _, ok := s.previous[nonce]; ok {
+		return false
+	}
+
+	s.current[nonce] = struct{}{}
+
+	return true
+}
+
+// Sign возвращает подпись запроса: hex HMAC-SHA256 ключом secret от метода, пути с query,
+// hex SHA-256 тела, nonce и timestamp, разделённых переводом строки.
+// Клиент отправляет её в заголовке X-Request-Signature.
+func Sign(secret []byte, method, requestURI string, body []byte, nonce, timestamp string) string {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join([]string{method, requestURI, hex.EncodeToString(bodyHash[:]), nonce, timestamp}, "\n")))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// New возвращает middleware, отклоняющий запросы без nonce/timestamp/подписи,
+// с timestamp вне окна window, с неверной подписью (см. Sign) или с уже использованным nonce.
+// Подпись проверяется до того, как nonce запоминается: чужой запрос не может израсходовать nonce.
+func New(log *slog.Logger, window time.Duration, secret []byte, store *NonceStore) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/replay"),
+		)
+
+		log.Info("replay protection enabled", slog.Duration("window", window))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			log := log.With(
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			nonce := r.Header.Get(HeaderNonce)
+			timestamp := r.Header.Get(HeaderTimestamp)
+			signature := r.Header.Get(HeaderSignature)
+			if nonce == "" || timestamp == "" || signature == "" {
+				log.Error("nonce, timestamp or signature is missing")
+				render.Status(r, http.StatusBadRequest)
+				resp.JSON(w, r, resp.Error("missing request nonce, timestamp or signature"))
+				return
+			}
+
+			unix, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				log.Error("invalid timestamp", slog.String("timestamp", timestamp))
+				render.Status(r, http.StatusBadRequest)
+				resp.JSON(w, r, resp.Error("invalid request timestamp"))
+				return
+			}
+
+			now := time.Now()
+			if diff := now.Sub(time.Unix(unix, 0)); diff > window || diff < -window {
+				log.Error("stale request", slog.Int64("timestamp", unix))
+				render.Status(r, http.StatusUnauthorized)
+				resp.JSON(w, r, resp.Error("request timestamp is out of window"))
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				log.Error("failed to read request body")
+				render.Status(r, http.StatusBadRequest)
+				resp.JSON(w, r, resp.Error("failed to read request"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			got, err := hex.DecodeString(signature)
+			want, _ := hex.DecodeString(Sign(secret, r.Method, r.URL.RequestURI(), body, nonce, timestamp))
+			if err != nil || !hmac.Equal(got, want) {
+				log.Error("invalid request signature", slog.String("nonce", nonce))
+				render.Status(r, http.StatusUnauthorized)
+				resp.JSON(w, r, resp.Error("invalid request signature"))
+				return
+			}
+
+			if !store.Use(nonce, now) {
+				log.Error("replayed request", slog.String("nonce", nonce))
+				render.Status(r, http.StatusUnauthorized)
+				resp.JSON(w, r, resp.Error("request nonce has already been used"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
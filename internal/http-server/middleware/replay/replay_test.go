@@ -0,0 +1,126 @@
+package replay_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/replay"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+var secret = []byte("replay-secret")
+
+func TestReplayMiddleware(t *testing.T) {
+	const window = time.Minute
+
+	var gotBody string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := replay.New(slogdiscard.NewDiscardLogger(), window, secret, replay.NewNonceStore(2*window))(next)
+
+	const body = `{"url":"https://example.com"}`
+
+	// newRequest подписывает запрос ключом key; пустой key - запрос без подписи
+	newRequest := func(key []byte, nonce string, ts time.Time) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/url/save", bytes.NewReader([]byte(body)))
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		req.Header.Set(replay.HeaderNonce, nonce)
+		req.Header.Set(replay.HeaderTimestamp, timestamp)
+		if key != nil {
+			req.Header.Set(replay.HeaderSignature, replay.Sign(key, http.MethodPost, "/url/save", []byte(body), nonce, timestamp))
+		}
+		return req
+	}
+
+	serve := func(req *http.Request) int {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	send := func(nonce string, ts time.Time) int {
+		return serve(newRequest(secret, nonce, ts))
+	}
+
+	t.Run("Fresh request", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, send("nonce-1", time.Now()))
+		// Обработчик получает тело целиком, хотя middleware его прочитал
+		require.Equal(t, body, gotBody)
+	})
+
+	t.Run("Replayed nonce", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, send("nonce-2", time.Now()))
+		require.Equal(t, http.StatusUnauthorized, send("nonce-2", time.Now()))
+	})
+
+	t.Run("Stale timestamp", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, send("nonce-3", time.Now().Add(-2*window)))
+	})
+
+	t.Run("Missing headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/url/save", nil)
+
+		require.Equal(t, http.StatusBadRequest, serve(req))
+	})
+
+	t.Run("Unsigned request", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, serve(newRequest(nil, "nonce-4", time.Now())))
+	})
+
+	t.Run("Wrong key", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, serve(newRequest([]byte("other-secret"), "nonce-5", time.Now())))
+	})
+
+	t.Run("Fresh nonce on a captured request", func(t *testing.T) {
+		// Перехваченная подпись не подходит к новым nonce и timestamp
+		captured := newRequest(secret, "nonce-6", time.Now())
+		req := newRequest(nil, "nonce-7", time.Now())
+		req.Header.Set(replay.HeaderSignature, captured.Header.Get(replay.HeaderSignature))
+
+		require.Equal(t, http.StatusUnauthorized, serve(req))
+	})
+
+	t.Run("Tampered body", func(t *testing.T) {
+		req := newRequest(secret, "nonce-8", time.Now())
+		req.Body = io.NopCloser(bytes.NewReader([]byte(`{"url":"https://evil.example"}`)))
+
+		require.Equal(t, http.StatusUnauthorized, serve(req))
+	})
+
+	t.Run("Tampered path", func(t *testing.T) {
+		req := newRequest(secret, "nonce-9", time.Now())
+		req.URL.Path = "/url/other"
+
+		require.Equal(t, http.StatusUnauthorized, serve(req))
+	})
+
+	t.Run("Rejected request does not use the nonce", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, serve(newRequest([]byte("other-secret"), "nonce-10", time.Now())))
+		require.Equal(t, http.StatusOK, send("nonce-10", time.Now()))
+	})
+}
+
+func TestNonceStore(t *testing.T) {
+	const ttl = time.Minute
+
+	store := replay.NewNonceStore(ttl)
+	start := time.Now()
+
+	require.True(t, store.Use("a", start))
+	require.False(t, store.Use("a", start.Add(ttl/2)))
+	// После одной смены поколения nonce ещё помнится
+	require.False(t, store.Use("a", start.Add(ttl+time.Second)))
+	// После второй - забыт
+	require.True(t, store.Use("a", start.Add(2*ttl+2*time.Second)))
+}
@@ -0,0 +1,206 @@
+package routepolicy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Значения Rule.Auth
+const (
+	// AuthRequired - запрос проходит проверку токена
+	AuthRequired = "required"
+	// AuthNone - маршрут открыт без авторизации
+	AuthNone = "none"
+)
+
+// Значения Rule.CORS
+const (
+	// CORSDefault - общие настройки cors из конфига
+	CORSDefault = "default"
+	// CORSAny - любой origin без credentials: для публичных маршрутов
+	CORSAny = "any"
+	// CORSNone - CORS-заголовки не выставляются, браузер блокирует чтение ответа с других origin
+	CORSNone = "none"
+)
+
+var (
+	ErrInvalidPattern = errors.New("route pattern must start with / and may end with a single *")
+	ErrUnknownAuth    = errors.New("unknown auth policy")
+	ErrUnknownCORS    = errors.New("unknown cors policy")
+)
+
+// Rule - политика маршрута. Pattern - шаблон маршрута chi ("/url/{alias}/check")
+// или префикс шаблонов со звёздочкой на конце ("/r/*"). Пустые Auth и CORS -
+// AuthRequired и CORSDefault.
+type Rule struct {
+	Pattern string
+	Auth    string
+	CORS    string
+}
+
+// Defaults - маршруты, открытые без авторизации: служебные, вход и регистрация, публичные редиректы.
+// Остальные маршруты требуют токен.
+func Defaults() []Rule {
+	return []Rule{
+		{Pattern: "/healthz", Auth: AuthNone},
+		{Pattern: "/readyz", Auth: AuthNone},
+		{Pattern: "/metrics", Auth: AuthNone},
+		{Pattern: "/robots.txt", Auth: AuthNone},
+		{Pattern: "/openapi.json", Auth: AuthNone},
+		{Pattern: "/register", Auth: AuthNone},
+		{Pattern: "/login", Auth: AuthNone},
+		{Pattern: "/r/*", Auth: AuthNone},
+		{Pattern: "/target/{alias}", Auth: AuthNone},
+	}
+}
+
+// Policy - политики маршрутов. Точный шаблон важнее префикса, длинный префикс - короткого.
+type Policy struct {
+	exact    map[string]Rule
+	prefixes []Rule
+}
+
+// New собирает политику из правил. Правило с уже встречавшимся шаблоном заменяет прежнее,
+// поэтому правила из конфига передаются после Defaults.
+func New(rules []Rule) (*Policy, error) {
+	const op = "middleware.routepolicy.New"
+
+	p := &Policy{exact: make(map[string]Rule, len(rules))}
+	prefixes := make(map[string]Rule)
+
+	for _, rule := range rules {
+		rule, err := normalize(rule)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q: %w", op, rule.Pattern, err)
+		}
+
+		if strings.HasSuffix(rule.Pattern, "*") {
+			prefixes[rule.Pattern] = rule
+		} else {
+			p.exact[rule.Pattern] = rule
+		}
+	}
+
+	for _, rule := range prefixes {
+		p.prefixes = append(p.prefixes, rule)
+	}
+	sort.Slice(p.prefixes, func(i, j int) bool {
+		return len(p.prefixes[i].Pattern) > len(p.prefixes[j].Pattern)
+	})
+
+	return p, nil
+}
+
+func normalize(rule Rule) (Rule, error) {
+	rule.Pattern = strings.TrimSpace(rule.Pattern)
+	if !strings.HasPrefix(rule.Pattern, "/") || strings.Contains(strings.TrimSuffix(rule.Pattern, "*"), "*") {
+		return rule, ErrInvalidPattern
+	}
+
+	switch rule.Auth {
+	case "":
+		rule.Auth = AuthRequired
+	case AuthRequired, AuthNone:
+	default:
+		return rule, fmt.Errorf("%w %q", ErrUnknownAuth, rule.Auth)
+	}
+
+	switch rule.CORS {
+	case "":
+		rule.CORS = CORSDefault
+	case CORSDefault, CORSAny, CORSNone:
+	default:
+		return rule, fmt.Errorf("%w %q", ErrUnknownCORS, rule.CORS)
+	}
+
+	return rule, nil
+}
+
+// Lookup возвращает политику шаблона маршрута. Шаблон без правила требует авторизации.
+func (p *Policy) Lookup(pattern string) Rule {
+	if rule, ok := p.exact[pattern]; ok {
+		return rule
+	}
+
+	for _, rule := range p.prefixes {
+		if strings.HasPrefix(pattern, strings.TrimSuffix(rule.Pattern, "*")) {
+			return rule
+		}
+	}
+
+	return Rule{Pattern: pattern, Auth: AuthRequired, CORS: CORSDefault}
+}
+
+// Auth возвращает middleware, который пропускает через authMW запросы к маршрутам с AuthRequired.
+// Маршрут определяется по routes до маршрутизации, поэтому middleware подключается к корневому роутеру.
+// Запрос без подходящего маршрута проходит без проверки и получает 404 или 405 от роутера.
+func (p *Policy) Auth(routes chi.Routes, authMW func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		protected := authMW(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pattern, ok := routePattern(routes, r)
+			if ok && p.Lookup(pattern).Auth == AuthRequired {
+				protected.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CORS возвращает middleware, который выбирает CORS-обработку по правилу маршрута:
+// defaultMW для CORSDefault и запросов без маршрута, anyMW для CORSAny, без обработки для CORSNone.
+// Preflight сопоставляется по методу из Access-Control-Request-Method.
+func (p *Policy) CORS(routes chi.Routes, defaultMW, anyMW func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withDefault := defaultMW(next)
+		withAny := anyMW(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pattern, ok := routePattern(routes, r)
+			if !ok {
+				withDefault.ServeHTTP(w, r)
+				return
+			}
+
+			switch p.Lookup(pattern).CORS {
+			case CORSNone:
+				next.ServeHTTP(w, r)
+			case CORSAny:
+				withAny.ServeHTTP(w, r)
+			default:
+				withDefault.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// routePattern находит шаблон маршрута, который обработает запрос
+func routePattern(routes chi.Routes, r *http.Request) (string, bool) {
+	method := r.Method
+	if method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		method = r.Header.Get("Access-Control-Request-Method")
+	}
+
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+		path = rctx.RoutePath
+	}
+
+	rctx := chi.NewRouteContext()
+	if !routes.Match(rctx, method, path) {
+		return "", false
+	}
+
+	return rctx.RoutePattern(), true
+}
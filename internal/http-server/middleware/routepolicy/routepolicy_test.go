@@ -0,0 +1,141 @@
+package routepolicy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/routepolicy"
+)
+
+// requireToken - упрощённая авторизация: без заголовка Authorization - 401
+func requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// markCORS помечает ответ, чтобы было видно, какая CORS-обработка выбрана
+func markCORS(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-CORS", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// newRouter повторяет устройство роутера из main: маршруты API в Route("/"), редиректы на корне
+func newRouter(t *testing.T, rules []routepolicy.Rule) http.Handler {
+	t.Helper()
+
+	policy, err := routepolicy.New(rules)
+	require.NoError(t, err)
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	router := chi.NewRouter()
+	router.Use(policy.CORS(router, markCORS("default"), markCORS("any")))
+	router.Use(policy.Auth(router, requireToken))
+	router.Route("/", func(r chi.Router) {
+		r.Get("/healthz", ok)
+		r.Get("/metrics", ok)
+		r.Get("/health/detail", ok)
+		r.Post("/url/save", ok)
+		r.Get("/url/{alias}/check", ok)
+	})
+	router.Get("/r/{alias}", ok)
+	router.Get("/r/{alias}/*", ok)
+	router.Get("/target/{alias}", ok)
+
+	return router
+}
+
+func serve(t *testing.T, h http.Handler, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, nil)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestAuth_Defaults(t *testing.T) {
+	router := newRouter(t, routepolicy.Defaults())
+
+	cases := []struct {
+		method string
+		target string
+		status int
+	}{
+		{http.MethodGet, "/healthz", http.StatusOK},
+		{http.MethodGet, "/metrics", http.StatusOK},
+		// Ошибки баз и режим работы видны только администраторам
+		{http.MethodGet, "/health/detail", http.StatusUnauthorized},
+		{http.MethodGet, "/r/abc", http.StatusOK},
+		{http.MethodGet, "/r/abc/a/b", http.StatusOK},
+		{http.MethodGet, "/target/abc", http.StatusOK},
+		{http.MethodPost, "/url/save", http.StatusUnauthorized},
+		{http.MethodGet, "/url/abc/check", http.StatusUnauthorized},
+		// Неизвестный маршрут получает 404, а не 401
+		{http.MethodGet, "/missing", http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		rr := serve(t, router, tc.method, tc.target)
+		require.Equal(t, tc.status, rr.Code, "%s %s", tc.method, tc.target)
+	}
+}
+
+func TestAuth_ConfigOverridesDefaults(t *testing.T) {
+	rules := append(routepolicy.Defaults(), routepolicy.Rule{Pattern: "/metrics", Auth: routepolicy.AuthRequired})
+	router := newRouter(t, rules)
+
+	require.Equal(t, http.StatusOK, serve(t, router, http.MethodGet, "/healthz").Code)
+	require.Equal(t, http.StatusUnauthorized, serve(t, router, http.MethodGet, "/metrics").Code)
+}
+
+func TestCORS(t *testing.T) {
+	rules := append(routepolicy.Defaults(),
+		routepolicy.Rule{Pattern: "/healthz", Auth: routepolicy.AuthNone, CORS: routepolicy.CORSNone},
+		routepolicy.Rule{Pattern: "/r/*", Auth: routepolicy.AuthNone, CORS: routepolicy.CORSAny},
+	)
+	router := newRouter(t, rules)
+
+	require.Empty(t, serve(t, router, http.MethodGet, "/healthz").Header().Get("X-CORS"))
+	require.Equal(t, "any", serve(t, router, http.MethodGet, "/r/abc").Header().Get("X-CORS"))
+	require.Equal(t, "default", serve(t, router, http.MethodGet, "/metrics").Header().Get("X-CORS"))
+
+	// Preflight сопоставляется по методу будущего запроса
+	req := httptest.NewRequest(http.MethodOptions, "/url/save", nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, "default", rr.Header().Get("X-CORS"))
+}
+
+func TestNew_Invalid(t *testing.T) {
+	cases := []struct {
+		name string
+		rule routepolicy.Rule
+		err  error
+	}{
+		{"Relative pattern", routepolicy.Rule{Pattern: "healthz"}, routepolicy.ErrInvalidPattern},
+		{"Star in the middle", routepolicy.Rule{Pattern: "/r/*/target"}, routepolicy.ErrInvalidPattern},
+		{"Unknown auth", routepolicy.Rule{Pattern: "/healthz", Auth: "optional"}, routepolicy.ErrUnknownAuth},
+		{"Unknown cors", routepolicy.Rule{Pattern: "/healthz", CORS: "open"}, routepolicy.ErrUnknownCORS},
+	}
+
+	for _, tc := range cases {
+		_, err := routepolicy.New([]routepolicy.Rule{tc.rule})
+		require.ErrorIs(t, err, tc.err, tc.name)
+	}
+}
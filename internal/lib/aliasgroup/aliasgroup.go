@@ -0,0 +1,94 @@
+// Package aliasgroup formats generated aliases as hyphen-separated groups of
+// characters and maps the grouped forms users type back to stored aliases.
+package aliasgroup
+
+import (
+	"strings"
+)
+
+// Separator joins alias groups.
+const Separator = "-"
+
+// Grouping splits generated aliases into hyphen-separated groups for readability,
+// e.g. "ab3f9k" becomes "ab3-f9k" with Size 3. Custom aliases are never grouped.
+type Grouping struct {
+	// Size is the number of characters per group; 0 disables grouping.
+	Size int
+	// Significant means the hyphens are stored as part of the alias and must be typed.
+	// Otherwise the alias is stored without them, the grouped form is only shown to
+	// the user, and redirects accept either form.
+	Significant bool
+}
+
+// Enabled reports whether aliases are grouped at all.
+func (g Grouping) Enabled() bool {
+	return g.Size > 0
+}
+
+// Format splits alias into groups of Size characters.
+func (g Grouping) Format(alias string) string {
+	if !g.Enabled() {
+		return alias
+	}
+
+	runes := []rune(alias)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && i%g.Size == 0 {
+			b.WriteString(Separator)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// Fit returns the largest number of characters a generated alias can have so
+// that its stored form, including significant hyphens, is at most max long.
+func (g Grouping) Fit(max int) int {
+	if !g.Enabled() || !g.Significant {
+		return max
+	}
+
+	// Every full group takes Size characters and one hyphen
+	return max - max/(g.Size+1)
+}
+
+// Store returns the form of a freshly generated alias that is saved to storage.
+func (g Grouping) Store(alias string) string {
+	if g.Significant {
+		return g.Format(alias)
+	}
+
+	return alias
+}
+
+// Display returns the form of a stored generated alias that is shown to the user.
+func (g Grouping) Display(alias string) string {
+	if g.Significant {
+		return alias
+	}
+
+	return g.Format(alias)
+}
+
+// Normalize maps an alias from a request to its stored form: when hyphens are not
+// significant, a grouped alias loses them. Other aliases are returned unchanged.
+func (g Grouping) Normalize(alias string) string {
+	if !g.Ambiguous(alias) {
+		return alias
+	}
+
+	return strings.ReplaceAll(alias, Separator, "")
+}
+
+// Ambiguous reports whether alias looks exactly like a grouped generated alias while
+// hyphens are not significant. Such a custom alias could never be opened, because
+// Normalize would strip its hyphens.
+func (g Grouping) Ambiguous(alias string) bool {
+	if !g.Enabled() || g.Significant || !strings.Contains(alias, Separator) {
+		return false
+	}
+
+	return g.Format(strings.ReplaceAll(alias, Separator, "")) == alias
+}
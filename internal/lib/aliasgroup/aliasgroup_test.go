@@ -0,0 +1,76 @@
+package aliasgroup
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		size  int
+		alias string
+		want  string
+	}{
+		{size: 0, alias: "ab3f9k", want: "ab3f9k"},
+		{size: 3, alias: "ab3f9k", want: "ab3-f9k"},
+		{size: 3, alias: "ab3f9kx", want: "ab3-f9k-x"},
+		{size: 4, alias: "ab3", want: "ab3"},
+		{size: 2, alias: "", want: ""},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, Grouping{Size: tc.size}.Format(tc.alias), "%d %q", tc.size, tc.alias)
+	}
+}
+
+func TestSignificant(t *testing.T) {
+	g := Grouping{Size: 3, Significant: true}
+
+	assert.Equal(t, "ab3-f9k", g.Store("ab3f9k"))
+	assert.Equal(t, "ab3-f9k", g.Display("ab3-f9k"))
+	// Hyphens are part of the alias: nothing is rewritten
+	assert.Equal(t, "ab3-f9k", g.Normalize("ab3-f9k"))
+	assert.Equal(t, "ab3f9k", g.Normalize("ab3f9k"))
+	assert.False(t, g.Ambiguous("ab3-f9k"))
+}
+
+func TestNotSignificant(t *testing.T) {
+	g := Grouping{Size: 3}
+
+	assert.Equal(t, "ab3f9k", g.Store("ab3f9k"))
+	assert.Equal(t, "ab3-f9k", g.Display("ab3f9k"))
+
+	assert.Equal(t, "ab3f9k", g.Normalize("ab3-f9k"))
+	assert.Equal(t, "ab3f9k", g.Normalize("ab3f9k"))
+	// Custom aliases with other hyphen placement are untouched
+	assert.Equal(t, "my-link", g.Normalize("my-link"))
+	assert.Equal(t, "ab-3f9k", g.Normalize("ab-3f9k"))
+
+	assert.True(t, g.Ambiguous("abc-def"))
+	assert.False(t, g.Ambiguous("my-link"))
+	assert.False(t, g.Ambiguous("abcdef"))
+}
+
+func TestDisabled(t *testing.T) {
+	var g Grouping
+
+	assert.Equal(t, "ab3f9k", g.Store("ab3f9k"))
+	assert.Equal(t, "ab3f9k", g.Display("ab3f9k"))
+	assert.Equal(t, "abc-def", g.Normalize("abc-def"))
+	assert.False(t, g.Ambiguous("abc-def"))
+}
+
+func TestFit(t *testing.T) {
+	g := Grouping{Size: 3, Significant: true}
+	for max := 1; max <= 20; max++ {
+		n := g.Fit(max)
+
+		assert.LessOrEqual(t, len(g.Store(strings.Repeat("a", n))), max, "max %d", max)
+		assert.Greater(t, len(g.Store(strings.Repeat("a", n+1))), max, "max %d", max)
+	}
+
+	assert.Equal(t, 8, Grouping{Size: 3}.Fit(8))
+	assert.Equal(t, 8, Grouping{}.Fit(8))
+}
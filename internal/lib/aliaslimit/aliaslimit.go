@@ -0,0 +1,54 @@
+// Package aliaslimit enforces the single upper bound on alias length shared by
+// every alias source: random, custom, hash-derived and collision-extended.
+package aliaslimit
+
+import (
+	"unicode/utf8"
+
+	"url-shortener/internal/lib/aliasgroup"
+)
+
+// Limit is the maximum alias length in characters, including the checksum
+// character when checksums are enabled. Zero means no limit.
+type Limit struct {
+	Max      int
+	Checksum bool
+	// Grouping of generated aliases; significant hyphens count towards Max.
+	Grouping aliasgroup.Grouping
+}
+
+// Body returns the maximum length of the alias body, i.e. of the part a
+// generator or user provides before the checksum character is appended.
+// Zero means no limit.
+func (l Limit) Body() int {
+	if l.Max <= 0 {
+		return 0
+	}
+	if l.Checksum {
+		return l.Max - 1
+	}
+
+	return l.Max
+}
+
+// Clamp reduces a generated alias body length so that the final alias fits
+// together with the hyphens its grouping adds.
+func (l Limit) Clamp(length int) int {
+	body := l.Body()
+	if body <= 0 {
+		return length
+	}
+	if body = l.Grouping.Fit(body); length > body {
+		return body
+	}
+
+	return length
+}
+
+// Allows reports whether an alias body, such as a user's custom alias, fits
+// once the checksum character, if enabled, is appended.
+func (l Limit) Allows(body string) bool {
+	max := l.Body()
+
+	return max <= 0 || utf8.RuneCountInString(body) <= max
+}
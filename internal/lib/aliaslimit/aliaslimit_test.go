@@ -0,0 +1,41 @@
+package aliaslimit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/aliasgroup"
+	"url-shortener/internal/lib/aliaslimit"
+)
+
+func TestLimit(t *testing.T) {
+	plain := aliaslimit.Limit{Max: 8}
+	require.Equal(t, 8, plain.Body())
+	require.Equal(t, 8, plain.Clamp(12))
+	require.Equal(t, 6, plain.Clamp(6))
+	require.True(t, plain.Allows("abcdefgh"))
+	require.False(t, plain.Allows("abcdefghi"))
+
+	withChecksum := aliaslimit.Limit{Max: 8, Checksum: true}
+	require.Equal(t, 7, withChecksum.Body())
+	require.Equal(t, 7, withChecksum.Clamp(12))
+	require.True(t, withChecksum.Allows("abcdefg"))
+	require.False(t, withChecksum.Allows("abcdefgh"))
+
+	// Length is counted in characters, not bytes.
+	require.True(t, plain.Allows("привет"))
+
+	// Significant hyphens of a generated alias take part of the limit.
+	grouped := aliaslimit.Limit{Max: 8, Grouping: aliasgroup.Grouping{Size: 3, Significant: true}}
+	require.Equal(t, 6, grouped.Clamp(12))
+	require.Equal(t, 6, grouped.Clamp(6))
+	require.Equal(t, 4, grouped.Clamp(4))
+	hidden := aliaslimit.Limit{Max: 8, Grouping: aliasgroup.Grouping{Size: 3}}
+	require.Equal(t, 8, hidden.Clamp(12))
+
+	unlimited := aliaslimit.Limit{}
+	require.Zero(t, unlimited.Body())
+	require.Equal(t, 100, unlimited.Clamp(100))
+	require.True(t, unlimited.Allows("a-very-long-alias-that-is-never-rejected"))
+}
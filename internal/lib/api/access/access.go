@@ -0,0 +1,97 @@
+// Package access centralizes the "caller owns this resource" decision for
+// URL-scoped handlers, so every endpoint denies a non-owner with the same
+// 403 response and leaves the same audit entry in the log. With HideForeign
+// set the refusal looks exactly like a missing link instead.
+package access
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/api/errorpage"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/storage"
+)
+
+// Message is the error text of every 403 response for a resource the caller does not own.
+const Message = "forbidden"
+
+// NotFoundMessage is the error text of 404 responses for a missing link. In
+// HideForeign mode links of other users are reported with it too.
+const NotFoundMessage = "not found"
+
+// pageNotFoundMessage is the text of the redirect error page for a missing link.
+const pageNotFoundMessage = "url not found"
+
+// HideForeign makes Deny and DenyPage answer 404 exactly as for a nonexistent
+// alias, so callers cannot probe which aliases exist. The audit entry is still
+// written. Admin endpoints do not go through Deny and keep the precise errors.
+var HideForeign bool
+
+// Check returns storage.ErrUnauthorized when a resource owned by ownerID is
+// requested by userID. Handlers that load the resource themselves use it
+// instead of comparing ids inline.
+func Check(ownerID, userID int64) error {
+	if ownerID != userID {
+		return storage.ErrUnauthorized
+	}
+
+	return nil
+}
+
+// Denied reports whether err means the resource belongs to another user.
+func Denied(err error) bool {
+	return errors.Is(err, storage.ErrUnauthorized)
+}
+
+// Audit records that nickname was refused access to resource. The entry is
+// emitted at warn level with audit=true so it can be filtered out of the log.
+func Audit(log *slog.Logger, r *http.Request, nickname, resource string) {
+	log.Warn("access denied",
+		slog.Bool("audit", true),
+		slog.String("nickname", nickname),
+		slog.String("resource", resource),
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+	)
+}
+
+// Refusal returns the per-item error text for a resource of another user, for
+// batch endpoints that report results instead of failing the whole request.
+func Refusal() string {
+	if HideForeign {
+		return NotFoundMessage
+	}
+
+	return Message
+}
+
+// Deny audits the refusal and writes a 403 JSON response, or a 404 one in HideForeign mode.
+func Deny(w http.ResponseWriter, r *http.Request, log *slog.Logger, nickname, resource string) {
+	Audit(log, r, nickname, resource)
+
+	if HideForeign {
+		render.Status(r, http.StatusNotFound)
+		resp.JSON(w, r, resp.Error(NotFoundMessage))
+		return
+	}
+
+	render.Status(r, http.StatusForbidden)
+	resp.JSON(w, r, resp.Error(Message))
+}
+
+// DenyPage is Deny for endpoints opened by browsers: the 403 body follows
+// errorpage content negotiation with def as the fallback format.
+func DenyPage(w http.ResponseWriter, r *http.Request, log *slog.Logger, nickname, resource, def string) {
+	Audit(log, r, nickname, resource)
+
+	if HideForeign {
+		errorpage.Write(w, r, http.StatusNotFound, pageNotFoundMessage, def)
+		return
+	}
+
+	errorpage.Write(w, r, http.StatusForbidden, Message, def)
+}
@@ -0,0 +1,199 @@
+package access_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	deleteURL "url-shortener/internal/http-server/handlers/url/delete"
+	"url-shortener/internal/http-server/handlers/url/link"
+	"url-shortener/internal/http-server/handlers/url/redirect"
+	"url-shortener/internal/http-server/handlers/url/regenerate"
+	updateTags "url-shortener/internal/http-server/handlers/url/tags/update"
+	"url-shortener/internal/http-server/handlers/url/visibility"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/access"
+	"url-shortener/internal/lib/api/errorpage"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/multiStorage"
+	"url-shortener/internal/storage/sqlite"
+)
+
+func TestCheck(t *testing.T) {
+	require.NoError(t, access.Check(1, 1))
+	require.ErrorIs(t, access.Check(1, 2), storage.ErrUnauthorized)
+
+	require.True(t, access.Denied(fmt.Errorf("op: %w", storage.ErrUnauthorized)))
+	require.False(t, access.Denied(storage.ErrURLNotFound))
+	require.False(t, access.Denied(nil))
+}
+
+// Every URL endpoint must refuse a link of another user with the same 403 and audit entry.
+func TestURLEndpointsDenyNonOwner(t *testing.T) {
+	r, db, ownerID, logs := newIntruderRouter(t)
+
+	cases := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: http.MethodGet, path: "/redirect/owned"},
+		{method: http.MethodDelete, path: "/url/owned"},
+		{method: http.MethodPost, path: "/url/owned/regenerate"},
+		{method: http.MethodPost, path: "/url/owned/link"},
+		{method: http.MethodPatch, path: "/url/owned/visibility", body: `{"is_public":true}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			logs.Reset()
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+			require.JSONEq(t, `{"status":"Error","error":"forbidden"}`, rr.Body.String())
+			requireAudit(t, logs, "owned")
+		})
+	}
+
+	// Batch tag updates report the refusal per alias
+	t.Run("POST /url/tags", func(t *testing.T) {
+		logs.Reset()
+
+		req := httptest.NewRequest(http.MethodPost, "/url/tags", strings.NewReader(`{"aliases":["owned"],"add":["work"]}`))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var resp updateTags.Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, access.Message, resp.Results["owned"].Error)
+		requireAudit(t, logs, "owned")
+	})
+
+	// The link is untouched
+	target, err := db.GetURL("owned", ownerID)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", target)
+}
+
+// In HideForeign mode a foreign alias must be indistinguishable from a missing one.
+func TestURLEndpointsHideForeign(t *testing.T) {
+	access.HideForeign = true
+	t.Cleanup(func() { access.HideForeign = false })
+
+	r, db, ownerID, logs := newIntruderRouter(t)
+
+	cases := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: http.MethodGet, path: "/redirect/%s"},
+		{method: http.MethodDelete, path: "/url/%s"},
+		{method: http.MethodPost, path: "/url/%s/regenerate"},
+		{method: http.MethodPost, path: "/url/%s/link"},
+		{method: http.MethodPatch, path: "/url/%s/visibility", body: `{"is_public":true}`},
+	}
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			logs.Reset()
+
+			foreign := serve(tc.method, fmt.Sprintf(tc.path, "owned"), tc.body)
+			requireAudit(t, logs, "owned")
+			missing := serve(tc.method, fmt.Sprintf(tc.path, "missing"), tc.body)
+
+			require.Equal(t, http.StatusNotFound, foreign.Code, foreign.Body.String())
+			require.Equal(t, missing.Code, foreign.Code)
+			require.Equal(t, missing.Body.String(), foreign.Body.String())
+		})
+	}
+
+	t.Run("POST /url/tags", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/url/tags", `{"aliases":["owned","missing"],"add":["work"]}`)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var resp updateTags.Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, access.NotFoundMessage, resp.Results["owned"].Error)
+		require.Equal(t, resp.Results["missing"], resp.Results["owned"])
+	})
+
+	target, err := db.GetURL("owned", ownerID)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", target)
+}
+
+// newIntruderRouter serves the URL endpoints to "intruder" over a store where
+// the alias "owned" belongs to "owner".
+func newIntruderRouter(t *testing.T) (*chi.Mux, *sqlite.Storage, int64, *bytes.Buffer) {
+	t.Helper()
+
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	ownerID, err := db.SaveUser("owner", "hash")
+	require.NoError(t, err)
+	_, err = db.SaveUser("intruder", "hash")
+	require.NoError(t, err)
+	require.NoError(t, db.SaveURL("https://example.com", "owned", ownerID, storage.URLOptions{}))
+
+	s := multiStorage.NewSQLiteStorage(db)
+
+	var logs bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.WithNickname(r.Context(), "intruder")))
+		})
+	})
+	r.Get("/redirect/{alias}", redirect.New(log, s, errorpage.FormatJSON))
+	r.Delete("/url/{alias}", deleteURL.New(log, s))
+	r.Post("/url/{alias}/regenerate", regenerate.New(log, s, regenerate.Options{}))
+	r.Post("/url/{alias}/link", link.New(log, s, link.Options{}))
+	r.Patch("/url/{alias}/visibility", visibility.New(log, s))
+	r.Post("/url/tags", updateTags.New(log, s, 0))
+
+	return r, db, ownerID, &logs
+}
+
+func requireAudit(t *testing.T, logs *bytes.Buffer, resource string) {
+	t.Helper()
+
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+
+		if record["msg"] == "access denied" {
+			require.Equal(t, true, record["audit"])
+			require.Equal(t, "intruder", record["nickname"])
+			require.Equal(t, resource, record["resource"])
+			return
+		}
+	}
+
+	t.Fatal("no audit entry")
+}
@@ -0,0 +1,92 @@
+// Package errorpage renders errors for endpoints that are opened both by API
+// clients and by browsers: as the usual JSON envelope or as a small HTML page.
+package errorpage
+
+import (
+	"html/template"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/render"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// Error body formats
+const (
+	FormatJSON = "json"
+	FormatHTML = "html"
+)
+
+var pageTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Status}} {{.StatusText}}</title></head>
+<body>
+<h1>{{.Status}} {{.StatusText}}</h1>
+<p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+// Negotiate picks the error body format from the Accept header. A missing header,
+// one that only has wildcards, or one that ranks JSON and HTML equally yields def.
+func Negotiate(accept, def string) string {
+	if def != FormatHTML {
+		def = FormatJSON
+	}
+
+	var jsonQ, htmlQ float64
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		switch mediaType {
+		case "application/json":
+			if q > jsonQ {
+				jsonQ = q
+			}
+		case "text/html", "application/xhtml+xml":
+			if q > htmlQ {
+				htmlQ = q
+			}
+		}
+	}
+
+	switch {
+	case jsonQ > htmlQ:
+		return FormatJSON
+	case htmlQ > jsonQ:
+		return FormatHTML
+	default:
+		return def
+	}
+}
+
+// Write responds with status and msg in the format negotiated from the request's
+// Accept header, falling back to def.
+func Write(w http.ResponseWriter, r *http.Request, status int, msg, def string) {
+	if Negotiate(r.Header.Get("Accept"), def) == FormatJSON {
+		render.Status(r, status)
+		resp.JSON(w, r, resp.Error(msg))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_ = pageTemplate.Execute(w, struct {
+		Status     int
+		StatusText string
+		Message    string
+	}{status, http.StatusText(status), msg})
+}
@@ -0,0 +1,37 @@
+package errorpage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/api/errorpage"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		name   string
+		accept string
+		def    string
+		want   string
+	}{
+		{name: "JSON", accept: "application/json", def: errorpage.FormatHTML, want: errorpage.FormatJSON},
+		{name: "HTML", accept: "text/html", def: errorpage.FormatJSON, want: errorpage.FormatHTML},
+		{name: "Browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", def: errorpage.FormatJSON, want: errorpage.FormatHTML},
+		{name: "JSON preferred by q", accept: "text/html;q=0.5, application/json", def: errorpage.FormatHTML, want: errorpage.FormatJSON},
+		{name: "No header, JSON default", accept: "", def: errorpage.FormatJSON, want: errorpage.FormatJSON},
+		{name: "No header, HTML default", accept: "", def: errorpage.FormatHTML, want: errorpage.FormatHTML},
+		{name: "Wildcard", accept: "*/*", def: errorpage.FormatHTML, want: errorpage.FormatHTML},
+		{name: "Unknown default", accept: "", def: "xml", want: errorpage.FormatJSON},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.want, errorpage.Negotiate(tc.accept, tc.def))
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// Style - стиль JSON-ответов всего API (настройка response_style), задаётся при запуске.
+// Для StyleMixed ответы уходят так, как их собрал обработчик.
+var Style = StyleMixed
+
+// JSON пишет v JSON-ответом в стиле Style (см. Reshape) с кодом из render.Status.
+// Ответы 204 и 304 уходят без тела, как требует HTTP.
+func JSON(w http.ResponseWriter, r *http.Request, v any) {
+	status, ok := r.Context().Value(render.StatusCtxKey).(int)
+	if ok && (status == http.StatusNoContent || status == http.StatusNotModified) {
+		w.WriteHeader(status)
+		return
+	}
+
+	if Style != StyleEnvelope && Style != StyleBare {
+		render.JSON(w, r, v)
+		return
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(Reshape(Style, status, body), '\n'))
+}
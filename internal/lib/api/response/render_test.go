@@ -0,0 +1,274 @@
+package response_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/save"
+	saveMocks "url-shortener/internal/http-server/handlers/url/save/mocks"
+	"url-shortener/internal/http-server/handlers/user/login"
+	loginMocks "url-shortener/internal/http-server/handlers/user/login/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestJSON_Login(t *testing.T) {
+	auth.JWTSecret = []byte("test-secret")
+
+	hash, err := auth.HashPassword("secret")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		style    string
+		password string
+		want     func(t *testing.T, body map[string]any)
+	}{
+		{
+			name:     "Mixed success",
+			style:    resp.StyleMixed,
+			password: "secret",
+			want: func(t *testing.T, body map[string]any) {
+				// Поведение по умолчанию не меняется
+				require.Equal(t, "success", body["status"])
+				require.NotEmpty(t, body["token"])
+			},
+		},
+		{
+			name:     "Envelope success",
+			style:    resp.StyleEnvelope,
+			password: "secret",
+			want: func(t *testing.T, body map[string]any) {
+				require.Equal(t, resp.StatusOK, body["status"])
+				require.NotContains(t, body, "token")
+				data, ok := body["data"].(map[string]any)
+				require.True(t, ok, body)
+				require.NotEmpty(t, data["token"])
+				require.NotContains(t, data, "status")
+			},
+		},
+		{
+			name:     "Envelope error",
+			style:    resp.StyleEnvelope,
+			password: "wrong",
+			want: func(t *testing.T, body map[string]any) {
+				require.Equal(t, map[string]any{"status": resp.StatusError, "error": "Wrong login or password"}, body)
+			},
+		},
+		{
+			name:     "Bare success",
+			style:    resp.StyleBare,
+			password: "secret",
+			want: func(t *testing.T, body map[string]any) {
+				require.NotContains(t, body, "status")
+				require.NotContains(t, body, "data")
+				require.NotEmpty(t, body["token"])
+			},
+		},
+		{
+			name:     "Bare error",
+			style:    resp.StyleBare,
+			password: "wrong",
+			want: func(t *testing.T, body map[string]any) {
+				require.Equal(t, map[string]any{"error": "Wrong login or password"}, body)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			getUserMock := loginMocks.NewGetUser(t)
+			getUserMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+				Return(int64(1), hash, nil).
+				Once()
+
+			withStyle(t, tc.style)
+			handler := login.New(slogdiscard.NewDiscardLogger(), getUserMock, login.Options{})
+
+			body := `{"nickname": "user", "password": "` + tc.password + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader([]byte(body)))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			tc.want(t, decode(t, rr))
+		})
+	}
+}
+
+func TestJSON_Save(t *testing.T) {
+	cases := []struct {
+		name   string
+		style  string
+		alias  string
+		status int
+		want   map[string]any
+	}{
+		{
+			name:   "Envelope success",
+			style:  resp.StyleEnvelope,
+			alias:  "docs",
+			status: http.StatusOK,
+			want:   map[string]any{"status": resp.StatusOK, "data": map[string]any{"alias": "docs"}},
+		},
+		{
+			name:   "Envelope error with code",
+			style:  resp.StyleEnvelope,
+			alias:  "d",
+			status: http.StatusBadRequest,
+			want: map[string]any{
+				"status": resp.StatusError,
+				"error":  "alias must be at least 2 characters long",
+				"code":   save.CodeAliasTooShort,
+			},
+		},
+		{
+			name:   "Bare success",
+			style:  resp.StyleBare,
+			alias:  "docs",
+			status: http.StatusOK,
+			want:   map[string]any{"alias": "docs"},
+		},
+		{
+			name:   "Bare error with code",
+			style:  resp.StyleBare,
+			alias:  "d",
+			status: http.StatusBadRequest,
+			want:   map[string]any{"error": "alias must be at least 2 characters long", "code": save.CodeAliasTooShort},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			urlSaverMock := saveMocks.NewURLSaver(t)
+			if tc.status == http.StatusOK {
+				urlSaverMock.On("GetUserByNickname", mock.Anything, mock.Anything, "user").
+					Return(int64(1), "", nil).
+					Once()
+				urlSaverMock.On("GetUserSettings", mock.Anything, mock.Anything, int64(1)).
+					Return(storage.UserSettings{}, nil).
+					Once()
+				urlSaverMock.On("SaveURL", mock.Anything, mock.Anything, "https://example.com", tc.alias, int64(1), storage.URLOptions{}).
+					Return(nil).
+					Once()
+			}
+
+			withStyle(t, tc.style)
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{MinCustomAliasLength: 2})
+
+			body := `{"url": "https://example.com", "alias": "` + tc.alias + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/url/save", bytes.NewReader([]byte(body)))
+			req = req.WithContext(auth.WithNickname(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			require.Equal(t, tc.want, decode(t, rr))
+		})
+	}
+}
+
+// Отказ middleware авторизации - JSON в том же стиле, что и ответы обработчиков
+func TestJSON_Unauthenticated(t *testing.T) {
+	cases := []struct {
+		name   string
+		style  string
+		handle http.Handler
+		status int
+		want   map[string]any
+	}{
+		{
+			name:   "Mixed missing token",
+			style:  resp.StyleMixed,
+			handle: auth.TokenAuthMiddleware(http.NotFoundHandler()),
+			status: http.StatusUnauthorized,
+			want:   map[string]any{"status": resp.StatusError, "error": "Authorization header is missing"},
+		},
+		{
+			name:   "Envelope missing token",
+			style:  resp.StyleEnvelope,
+			handle: auth.TokenAuthMiddleware(http.NotFoundHandler()),
+			status: http.StatusUnauthorized,
+			want:   map[string]any{"status": resp.StatusError, "error": "Authorization header is missing"},
+		},
+		{
+			name:   "Bare missing token",
+			style:  resp.StyleBare,
+			handle: auth.TokenAuthMiddleware(http.NotFoundHandler()),
+			status: http.StatusUnauthorized,
+			want:   map[string]any{"error": "Authorization header is missing"},
+		},
+		{
+			name:   "Envelope not admin",
+			style:  resp.StyleEnvelope,
+			handle: auth.AdminOnly(http.NotFoundHandler()),
+			status: http.StatusForbidden,
+			want:   map[string]any{"status": resp.StatusError, "error": "Admin access required"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			withStyle(t, tc.style)
+
+			req := httptest.NewRequest(http.MethodGet, "/url/count", nil)
+
+			rr := httptest.NewRecorder()
+			tc.handle.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+			require.Contains(t, rr.Header().Get("Content-Type"), "application/json")
+			require.Equal(t, tc.want, decode(t, rr))
+		})
+	}
+}
+
+// Ответ 204 уходит без тела в любом стиле
+func TestJSON_NoContent(t *testing.T) {
+	for _, style := range []string{resp.StyleMixed, resp.StyleEnvelope, resp.StyleBare} {
+		withStyle(t, style)
+
+		req := httptest.NewRequest(http.MethodDelete, "/url/docs", nil)
+		render.Status(req, http.StatusNoContent)
+
+		rr := httptest.NewRecorder()
+		resp.JSON(rr, req, resp.OK())
+
+		require.Equal(t, http.StatusNoContent, rr.Code, style)
+		require.Empty(t, rr.Body.String(), style)
+	}
+}
+
+// withStyle задаёт resp.Style на время теста
+func withStyle(t *testing.T, style string) {
+	t.Helper()
+
+	prev := resp.Style
+	resp.Style = style
+	t.Cleanup(func() { resp.Style = prev })
+}
+
+func decode(t *testing.T, rr *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body), rr.Body.String())
+	return body
+}
@@ -1,6 +1,7 @@
 package response
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -10,6 +11,7 @@ import (
 type Response struct {
 	Status string `json:"status"`
 	Error  string `json:"error,omitempty"`
+	Code   string `json:"code,omitempty"`
 }
 
 const (
@@ -30,6 +32,15 @@ func Error(msg string) Response {
 	}
 }
 
+// ErrorWithCode возвращает ошибку с машиночитаемым кодом для клиентов
+func ErrorWithCode(msg, code string) Response {
+	return Response{
+		Status: StatusError,
+		Error:  msg,
+		Code:   code,
+	}
+}
+
 func ValidationError(errs validator.ValidationErrors) Response {
 	var errMsgs []string
 
@@ -49,3 +60,83 @@ func ValidationError(errs validator.ValidationErrors) Response {
 		Error:  strings.Join(errMsgs, ", "),
 	}
 }
+
+// Стили JSON-ответов API (настройка response_style)
+const (
+	// StyleMixed - ответы как их пишут обработчики: где-то с полем status, где-то без
+	StyleMixed = "mixed"
+	// StyleEnvelope - всегда {status, data, error, code}: поля обработчика уходят в data
+	StyleEnvelope = "envelope"
+	// StyleBare - без обёртки: поле status убирается, ошибка остаётся в error и code
+	StyleBare = "bare"
+)
+
+// Reshape приводит JSON-ответ body с HTTP-кодом httpStatus к стилю style.
+// Ответ без поля status считается успешным, если код меньше 400.
+// Тело, которое не является JSON, возвращается как есть.
+func Reshape(style string, httpStatus int, body []byte) []byte {
+	if style != StyleEnvelope && style != StyleBare {
+		return body
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		if style == StyleBare || !json.Valid(body) {
+			return body
+		}
+		// Массив или значение целиком становится data
+		return marshal(map[string]json.RawMessage{
+			"status": statusJSON(statusFor(httpStatus)),
+			"data":   body,
+		}, body)
+	}
+
+	status := statusFor(httpStatus)
+	if raw, ok := fields["status"]; ok {
+		var s string
+		if json.Unmarshal(raw, &s) == nil && (s == StatusOK || s == StatusError) {
+			status = s
+		}
+	}
+	delete(fields, "status")
+
+	if style == StyleBare {
+		return marshal(fields, body)
+	}
+
+	envelope := map[string]json.RawMessage{"status": statusJSON(status)}
+	for _, key := range []string{"error", "code"} {
+		if raw, ok := fields[key]; ok {
+			envelope[key] = raw
+			delete(fields, key)
+		}
+	}
+	if len(fields) > 0 {
+		envelope["data"] = marshal(fields, nil)
+	}
+
+	return marshal(envelope, body)
+}
+
+func statusFor(httpStatus int) string {
+	if httpStatus >= 400 {
+		return StatusError
+	}
+
+	return StatusOK
+}
+
+func statusJSON(status string) json.RawMessage {
+	raw, _ := json.Marshal(status)
+	return raw
+}
+
+// marshal кодирует v, а при ошибке возвращает fallback
+func marshal(v any, fallback []byte) []byte {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fallback
+	}
+
+	return raw
+}
@@ -0,0 +1,23 @@
+// Package base62 encodes row ids as short aliases. Consecutive ids give distinct
+// aliases whose length never decreases, so sequential aliases stay minimal.
+package base62
+
+// Alphabet is the digit set: 0-9, then a-z, then A-Z.
+const Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Encode returns id in base 62. Negative ids are not expected and encode as "0".
+func Encode(id int64) string {
+	if id <= 0 {
+		return Alphabet[:1]
+	}
+
+	var buf [11]byte // 62^11 > max int64
+	i := len(buf)
+	for id > 0 {
+		i--
+		buf[i] = Alphabet[id%62]
+		id /= 62
+	}
+
+	return string(buf[i:])
+}
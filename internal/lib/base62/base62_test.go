@@ -0,0 +1,39 @@
+package base62_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/base62"
+)
+
+func TestEncode(t *testing.T) {
+	cases := map[int64]string{
+		0:             "0",
+		1:             "1",
+		61:            "Z",
+		62:            "10",
+		3843:          "ZZ",
+		3844:          "100",
+		math.MaxInt64: "aZl8N0y58M7",
+	}
+
+	for id, want := range cases {
+		require.Equal(t, want, base62.Encode(id), "id %d", id)
+	}
+}
+
+func TestEncode_Sequential(t *testing.T) {
+	seen := make(map[string]bool)
+	prev := ""
+	for id := int64(1); id <= 5000; id++ {
+		alias := base62.Encode(id)
+
+		require.False(t, seen[alias], "duplicate alias %q", alias)
+		require.GreaterOrEqual(t, len(alias), len(prev))
+		seen[alias] = true
+		prev = alias
+	}
+}
@@ -0,0 +1,138 @@
+package blacklist
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// minSubstringLen is the shortest word that is also rejected inside a longer alias.
+// Shorter words ("r", "qr") only block an exact match, otherwise they would
+// ban a large share of all random aliases.
+const minSubstringLen = 3
+
+// reserved are route words that must not be used as aliases. They only block
+// an exact match: "capital" or "userguide" do not collide with any route.
+var reserved = []string{
+	"r", "qr", "url", "urls", "user", "users", "admin", "login", "register",
+	"logout", "auth", "api", "health", "healthz", "readyz", "metrics", "static",
+}
+
+// profanity is a minimal built-in list of offensive words. Extend it with a file.
+var profanity = []string{
+	"fuck", "shit", "cunt", "dick", "cock", "piss", "slut", "whore", "nigger", "fag",
+}
+
+// innocent are common words that contain a profanity word but are not offensive
+// themselves. They are cut out of an alias before the substring check, so
+// "peacock" passes while "peacockshit" is still rejected.
+var innocent = []string{
+	"peacock", "cocktail", "cockpit", "cockatoo", "cockroach", "hancock", "woodcock",
+	"shuttlecock", "dickens", "scunthorpe", "shiitake",
+}
+
+// Blacklist is a case-insensitive set of words that aliases must not match.
+type Blacklist struct {
+	// words are also rejected inside a longer alias
+	words map[string]struct{}
+	// exact only reject an alias equal to the word
+	exact map[string]struct{}
+	// allowed are cut out of an alias before it is checked against words
+	allowed []string
+}
+
+// New creates a blacklist from the given words.
+func New(words ...string) *Blacklist {
+	b := &Blacklist{
+		words: make(map[string]struct{}, len(words)),
+		exact: make(map[string]struct{}),
+	}
+	b.Add(words...)
+
+	return b
+}
+
+// Default returns the built-in list of reserved route words and profanity.
+func Default() *Blacklist {
+	b := New(profanity...)
+	b.AddExact(reserved...)
+	b.allowed = innocent
+
+	return b
+}
+
+// Load returns the default list extended with words from the file at path:
+// one word per line, empty lines and lines starting with # are skipped.
+func Load(path string) (*Blacklist, error) {
+	const op = "lib.blacklist.Load"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	b := Default()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		b.Add(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return b, nil
+}
+
+// Add puts words into the blacklist.
+func (b *Blacklist) Add(words ...string) {
+	add(b.words, words)
+}
+
+// AddExact puts words into the blacklist that only block an alias equal to them.
+func (b *Blacklist) AddExact(words ...string) {
+	add(b.exact, words)
+}
+
+func add(set map[string]struct{}, words []string) {
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			set[w] = struct{}{}
+		}
+	}
+}
+
+// Contains reports whether alias is blacklisted: it equals a word, or contains
+// a word added with Add of at least minSubstringLen characters outside the
+// innocent words. A nil Blacklist contains nothing.
+func (b *Blacklist) Contains(alias string) bool {
+	if b == nil {
+		return false
+	}
+
+	alias = strings.ToLower(alias)
+	if _, ok := b.words[alias]; ok {
+		return true
+	}
+	if _, ok := b.exact[alias]; ok {
+		return true
+	}
+
+	for _, a := range b.allowed {
+		alias = strings.ReplaceAll(alias, a, " ")
+	}
+
+	for w := range b.words {
+		if len(w) >= minSubstringLen && strings.Contains(alias, w) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+package blacklist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContains(t *testing.T) {
+	b := New("r", "admin")
+
+	tests := []struct {
+		alias string
+		want  bool
+	}{
+		{alias: "r", want: true},
+		{alias: "R", want: true},
+		{alias: "abrc", want: false},
+		{alias: "admin", want: true},
+		{alias: "xAdMiNx", want: true},
+		{alias: "adm1n", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			assert.Equal(t, tt.want, b.Contains(tt.alias))
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	b := Default()
+
+	tests := []struct {
+		alias string
+		want  bool
+	}{
+		// Route words only block an exact match
+		{alias: "api", want: true},
+		{alias: "User", want: true},
+		{alias: "capital", want: false},
+		{alias: "rapid", want: false},
+		{alias: "curly", want: false},
+		{alias: "userguide", want: false},
+		{alias: "authority", want: false},
+		// Profanity is rejected inside a longer alias
+		{alias: "shit", want: true},
+		{alias: "xxshitxx", want: true},
+		// Innocent words with profanity inside them pass
+		{alias: "peacock", want: false},
+		{alias: "cocktail", want: false},
+		{alias: "CockTails", want: false},
+		{alias: "peacockshit", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			assert.Equal(t, tt.want, b.Contains(tt.alias))
+		})
+	}
+}
+
+func TestNilContainsNothing(t *testing.T) {
+	var b *Blacklist
+
+	assert.False(t, b.Contains("admin"))
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blacklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# custom words\nFooBar\n\n  spam  \n"), 0o600))
+
+	b, err := Load(path)
+	require.NoError(t, err)
+
+	assert.True(t, b.Contains("foobar"))
+	assert.True(t, b.Contains("xxspam"))
+	// Built-in words are kept
+	assert.True(t, b.Contains("login"))
+	assert.False(t, b.Contains("# custom words"))
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.txt"))
+
+	require.Error(t, err)
+}
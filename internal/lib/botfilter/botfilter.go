@@ -0,0 +1,49 @@
+package botfilter
+
+import (
+	"strings"
+)
+
+// DefaultPatterns match the User-Agent of common crawlers and link preview fetchers.
+var DefaultPatterns = []string{
+	"bot", "crawl", "spider", "slurp", "facebookexternalhit", "embedly", "preview", "headless",
+}
+
+// Filter recognizes bots by case-insensitive substrings of the User-Agent header.
+type Filter struct {
+	patterns []string
+}
+
+// New creates a filter from the given patterns. Empty patterns are ignored.
+func New(patterns ...string) *Filter {
+	f := &Filter{patterns: make([]string, 0, len(patterns))}
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p != "" {
+			f.patterns = append(f.patterns, p)
+		}
+	}
+
+	return f
+}
+
+// Default returns a filter with DefaultPatterns.
+func Default() *Filter {
+	return New(DefaultPatterns...)
+}
+
+// IsBot reports whether userAgent matches any pattern. A nil filter matches nothing.
+func (f *Filter) IsBot(userAgent string) bool {
+	if f == nil {
+		return false
+	}
+
+	userAgent = strings.ToLower(userAgent)
+	for _, p := range f.patterns {
+		if strings.Contains(userAgent, p) {
+			return true
+		}
+	}
+
+	return false
+}
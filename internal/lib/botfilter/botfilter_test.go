@@ -0,0 +1,60 @@
+package botfilter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBot(t *testing.T) {
+	cases := []struct {
+		name      string
+		filter    *Filter
+		userAgent string
+		want      bool
+	}{
+		{
+			name:      "Googlebot",
+			filter:    Default(),
+			userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want:      true,
+		},
+		{
+			name:      "Link preview",
+			filter:    Default(),
+			userAgent: "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+			want:      true,
+		},
+		{
+			name:      "Browser",
+			filter:    Default(),
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+			want:      false,
+		},
+		{
+			name:      "Custom pattern is case-insensitive",
+			filter:    New(" Curl ", ""),
+			userAgent: "curl/8.4.0",
+			want:      true,
+		},
+		{
+			name:      "Custom patterns replace defaults",
+			filter:    New("curl"),
+			userAgent: "Googlebot/2.1",
+			want:      false,
+		},
+		{
+			name:      "Nil filter",
+			userAgent: "Googlebot/2.1",
+			want:      false,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.filter.IsBot(tc.userAgent))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package checksum
+
+import (
+	"strings"
+	"unicode"
+
+	"url-shortener/internal/lib/random"
+)
+
+// alphabet is the set check characters are taken from. Characters of an alias
+// outside of it still take part in the checksum via their code point.
+const alphabet = random.DefaultAlphabet
+
+// foldedAlphabet replaces alphabet when case is folded: the lower-case letters
+// and digits of random.DefaultAlphabet.
+const foldedAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// Scheme computes and checks check characters. The zero value is case-sensitive.
+type Scheme struct {
+	// FoldCase gives an alias the same check character in whatever case it is
+	// typed, for deployments where aliases are case-insensitive. Check
+	// characters are then lower-case letters and digits, accepted in either case.
+	FoldCase bool
+}
+
+// Append returns alias followed by its check character.
+func (s Scheme) Append(alias string) string {
+	return alias + string(s.checkChar(alias))
+}
+
+// Valid reports whether the last character of alias is the check character of the rest.
+func (s Scheme) Valid(alias string) bool {
+	runes := []rune(alias)
+	if len(runes) < 2 {
+		return false
+	}
+
+	body := string(runes[:len(runes)-1])
+	last := runes[len(runes)-1]
+	if s.FoldCase {
+		last = unicode.ToLower(last)
+	}
+
+	return last == s.checkChar(body)
+}
+
+// Correct replaces the last character of alias with the check character of the rest.
+// It is the most likely intended form of an alias whose check character does not match.
+func (s Scheme) Correct(alias string) string {
+	runes := []rune(alias)
+	if len(runes) < 2 {
+		return s.Append(alias)
+	}
+
+	return s.Append(string(runes[:len(runes)-1]))
+}
+
+// Append returns alias followed by its case-sensitive check character.
+func Append(alias string) string {
+	return Scheme{}.Append(alias)
+}
+
+// Valid reports whether alias ends with its case-sensitive check character.
+func Valid(alias string) bool {
+	return Scheme{}.Valid(alias)
+}
+
+// Correct is Scheme.Correct for the case-sensitive scheme.
+func Correct(alias string) string {
+	return Scheme{}.Correct(alias)
+}
+
+// checkChar computes the Luhn mod N check character of s, which catches any single
+// mistyped character and most swaps of adjacent characters.
+func (s Scheme) checkChar(str string) rune {
+	set := alphabet
+	if s.FoldCase {
+		set = foldedAlphabet
+		str = strings.ToLower(str)
+	}
+
+	chars := []rune(set)
+	n := len(chars)
+
+	runes := []rune(str)
+	factor := 2
+	sum := 0
+	for i := len(runes) - 1; i >= 0; i-- {
+		addend := factor * codePoint(set, runes[i], n)
+		factor = 3 - factor
+		sum += addend/n + addend%n
+	}
+
+	return chars[(n-sum%n)%n]
+}
+
+func codePoint(set string, r rune, n int) int {
+	if i := strings.IndexRune(set, r); i >= 0 {
+		return i
+	}
+
+	return int(r) % n
+}
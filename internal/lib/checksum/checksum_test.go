@@ -0,0 +1,76 @@
+package checksum
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendValid(t *testing.T) {
+	for _, alias := range []string{"a", "abc123", "Zz9", "my-link", "ёжик"} {
+		t.Run(alias, func(t *testing.T) {
+			withCheck := Append(alias)
+
+			assert.Equal(t, len([]rune(alias))+1, len([]rune(withCheck)))
+			assert.True(t, Valid(withCheck))
+			assert.Equal(t, withCheck, Correct(withCheck))
+		})
+	}
+}
+
+func TestDetectsTypos(t *testing.T) {
+	withCheck := Append("abc123")
+
+	// Any single substituted character is detected
+	runes := []rune(withCheck)
+	for i := range runes {
+		for _, c := range alphabet {
+			if c == runes[i] {
+				continue
+			}
+			typo := append([]rune{}, runes...)
+			typo[i] = c
+			assert.False(t, Valid(string(typo)), "typo %q not detected", string(typo))
+		}
+	}
+
+	// Swap of adjacent characters in the body
+	assert.False(t, Valid("bac123"+withCheck[len(withCheck)-1:]))
+}
+
+func TestCorrect(t *testing.T) {
+	withCheck := Append("abc123")
+	mistyped := withCheck[:len(withCheck)-1] + "!"
+
+	assert.False(t, Valid(mistyped))
+	assert.Equal(t, withCheck, Correct(mistyped))
+}
+
+func TestTooShort(t *testing.T) {
+	assert.False(t, Valid(""))
+	assert.False(t, Valid("a"))
+}
+
+func TestFoldCase(t *testing.T) {
+	s := Scheme{FoldCase: true}
+	withCheck := s.Append("AbC12x")
+
+	assert.True(t, s.Valid(withCheck))
+	assert.True(t, s.Valid(strings.ToLower(withCheck)))
+	assert.True(t, s.Valid(strings.ToUpper(withCheck)))
+	assert.Equal(t, s.Append("abc12x"), strings.ToLower(withCheck))
+
+	// Typos are still detected
+	runes := []rune(strings.ToLower(withCheck))
+	for i := range runes {
+		for _, c := range foldedAlphabet {
+			if c == runes[i] {
+				continue
+			}
+			typo := append([]rune{}, runes...)
+			typo[i] = c
+			assert.False(t, s.Valid(string(typo)), "typo %q not detected", string(typo))
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Registrable returns the registrable domain (eTLD+1) of rawURL,
+// e.g. "example.co.uk" for "https://www.example.co.uk/path".
+// For IP hosts, hosts without a registrable part and invalid URLs it returns "".
+func Registrable(rawURL string) string {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return ""
+	}
+
+	d, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return ""
+	}
+
+	return d
+}
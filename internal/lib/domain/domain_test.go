@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistrable(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "multi-part public suffix",
+			url:  "https://www.example.co.uk/path",
+			want: "example.co.uk",
+		},
+		{
+			name: "without scheme",
+			url:  "www.example.co.uk/path",
+			want: "example.co.uk",
+		},
+		{
+			name: "subdomain",
+			url:  "https://a.b.example.com/?q=1",
+			want: "example.com",
+		},
+		{
+			name: "upper case host with port",
+			url:  "http://WWW.Example.COM:8080",
+			want: "example.com",
+		},
+		{
+			name: "IPv4 host",
+			url:  "http://127.0.0.1:8080/path",
+			want: "",
+		},
+		{
+			name: "IPv6 host",
+			url:  "http://[::1]/path",
+			want: "",
+		},
+		{
+			name: "public suffix only",
+			url:  "https://co.uk",
+			want: "",
+		},
+		{
+			name: "invalid URL",
+			url:  "http://%zz",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Registrable(tt.url))
+		})
+	}
+}
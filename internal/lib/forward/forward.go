@@ -0,0 +1,116 @@
+package forward
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidRule is returned by Validate for a rule that has no source or no target parameter.
+var ErrInvalidRule = errors.New("invalid forward rule")
+
+// Rule copies one value of the incoming request into a query parameter of the redirect target.
+type Rule struct {
+	// Query is the name of the incoming query parameter to copy.
+	Query string
+	// Header is the name of the incoming header to copy. Exactly one of Query and Header is set.
+	Header string
+	// Param is the target query parameter. Empty means the same name as Query; header rules must set it.
+	Param string
+	// Override replaces a parameter the target already has. By default the target's value wins.
+	Override bool
+}
+
+func (r Rule) param() string {
+	if r.Param != "" {
+		return r.Param
+	}
+
+	return r.Query
+}
+
+// Rules is a list of forwarding rules applied in order.
+type Rules []Rule
+
+// Validate checks that every rule has exactly one source and a target parameter.
+func (rs Rules) Validate() error {
+	for i, r := range rs {
+		if (r.Query == "") == (r.Header == "") {
+			return fmt.Errorf("%w #%d: exactly one of query and header is required", ErrInvalidRule, i)
+		}
+		if strings.TrimSpace(r.param()) == "" {
+			return fmt.Errorf("%w #%d: param is required for header %q", ErrInvalidRule, i, r.Header)
+		}
+	}
+
+	return nil
+}
+
+// Apply appends the configured values from query and header to target. Values that
+// are absent or empty in the request are skipped, and everything not covered by a
+// rule is dropped. Values are URL-encoded; an existing target parameter is kept
+// unless the rule sets Override. The target's own query is left as written, in its
+// original order and encoding, except for parameters a rule overrides.
+func (rs Rules) Apply(target string, query url.Values, header http.Header) (string, error) {
+	if len(rs) == 0 {
+		return target, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	added := url.Values{}
+
+	for _, r := range rs {
+		var value string
+		if r.Query != "" {
+			value = query.Get(r.Query)
+		} else {
+			value = header.Get(r.Header)
+		}
+		if value == "" {
+			continue
+		}
+
+		param := r.param()
+		if q.Has(param) && !r.Override {
+			continue
+		}
+
+		q.Set(param, value)
+		added.Set(param, value)
+	}
+
+	if len(added) == 0 {
+		return target, nil
+	}
+
+	pairs := withoutParams(u.RawQuery, added)
+	u.RawQuery = strings.Join(append(pairs, added.Encode()), "&")
+
+	return u.String(), nil
+}
+
+// withoutParams splits rawQuery into its key=value pairs, dropping the pairs whose
+// key is in params. The remaining pairs are returned exactly as written.
+func withoutParams(rawQuery string, params url.Values) []string {
+	var pairs []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil && params.Has(unescaped) {
+			continue
+		}
+		pairs = append(pairs, pair)
+	}
+
+	return pairs
+}
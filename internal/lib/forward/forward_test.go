@@ -0,0 +1,96 @@
+package forward
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Rules{{Query: "ref"}, {Header: "X-Campaign", Param: "campaign"}}.Validate())
+
+	for _, r := range []Rule{
+		{},
+		{Query: "ref", Header: "X-Ref"},
+		{Header: "X-Campaign"},
+	} {
+		err := Rules{r}.Validate()
+		assert.True(t, errors.Is(err, ErrInvalidRule), "%+v", r)
+	}
+}
+
+func TestApply(t *testing.T) {
+	rules := Rules{
+		{Query: "ref"},
+		{Query: "utm_source"},
+		{Query: "src", Param: "utm_medium", Override: true},
+		{Header: "X-Campaign", Param: "campaign"},
+	}
+
+	header := http.Header{}
+	header.Set("X-Campaign", "spring sale")
+
+	cases := []struct {
+		name   string
+		target string
+		query  url.Values
+		header http.Header
+		want   string
+	}{
+		{
+			name:   "Configured params appended, others dropped",
+			target: "https://example.com/page",
+			query:  url.Values{"ref": {"abc"}, "session": {"secret"}},
+			header: header,
+			want:   "https://example.com/page?campaign=spring+sale&ref=abc",
+		},
+		{
+			name:   "Existing target param kept",
+			target: "https://example.com/page?utm_source=site",
+			query:  url.Values{"utm_source": {"mail"}},
+			want:   "https://example.com/page?utm_source=site",
+		},
+		{
+			name:   "Override replaces target param",
+			target: "https://example.com/page?utm_medium=site",
+			query:  url.Values{"src": {"mail"}},
+			want:   "https://example.com/page?utm_medium=mail",
+		},
+		{
+			name:   "Existing query kept as written",
+			target: "https://example.com/page?b=2&a=1&list=x%2Cy&utm_medium=site",
+			query:  url.Values{"src": {"mail"}, "ref": {"abc"}},
+			want:   "https://example.com/page?b=2&a=1&list=x%2Cy&ref=abc&utm_medium=mail",
+		},
+		{
+			name:   "Values are encoded",
+			target: "https://example.com/",
+			query:  url.Values{"ref": {"a&b=c"}},
+			want:   "https://example.com/?ref=a%26b%3Dc",
+		},
+		{
+			name:   "Nothing to forward",
+			target: "https://example.com/page#top",
+			query:  url.Values{"other": {"x"}},
+			want:   "https://example.com/page#top",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := rules.Apply(tc.target, tc.query, tc.header)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestApply_NoRules(t *testing.T) {
+	got, err := Rules(nil).Apply("https://example.com/", url.Values{"ref": {"abc"}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/", got)
+}
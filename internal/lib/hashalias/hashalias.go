@@ -0,0 +1,38 @@
+// Package hashalias derives deterministic aliases from a URL. The hash is keyed
+// with a server-side salt, so aliases for known URLs cannot be precomputed
+// without it. Rotating the salt changes aliases generated afterwards only.
+package hashalias
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"math/big"
+)
+
+// New returns an alias of the given length over alphabet derived from
+// HMAC-SHA256(salt, input). The same salt and input always yield the same alias.
+// Lengths beyond what a single digest can encode are filled by re-hashing.
+func New(salt, input, alphabet string, length int) string {
+	chars := []rune(alphabet)
+	base := big.NewInt(int64(len(chars)))
+
+	alias := make([]rune, 0, length)
+	msg := []byte(input)
+	for len(alias) < length {
+		mac := hmac.New(sha256.New, []byte(salt))
+		mac.Write(msg)
+		digest := mac.Sum(nil)
+
+		n := new(big.Int).SetBytes(digest)
+		mod := new(big.Int)
+		// 256 bits hold at least 32 digits in any alphabet of up to 256 characters
+		for i := 0; i < 32 && len(alias) < length; i++ {
+			n.DivMod(n, base, mod)
+			alias = append(alias, chars[mod.Int64()])
+		}
+
+		msg = digest
+	}
+
+	return string(alias)
+}
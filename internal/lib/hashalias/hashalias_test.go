@@ -0,0 +1,34 @@
+package hashalias_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/hashalias"
+	"url-shortener/internal/lib/random"
+)
+
+func TestNew(t *testing.T) {
+	const url = "https://example.com/some/page"
+
+	first := hashalias.New("salt-one", url, random.DefaultAlphabet, 8)
+	again := hashalias.New("salt-one", url, random.DefaultAlphabet, 8)
+	rotated := hashalias.New("salt-two", url, random.DefaultAlphabet, 8)
+	other := hashalias.New("salt-one", "https://example.com/other", random.DefaultAlphabet, 8)
+
+	require.Equal(t, first, again)
+	require.NotEqual(t, first, rotated)
+	require.NotEqual(t, first, other)
+	require.Len(t, first, 8)
+}
+
+func TestNew_Alphabet(t *testing.T) {
+	alias := hashalias.New("salt", "https://example.com", "ab", 100)
+
+	require.Len(t, alias, 100)
+	require.Empty(t, strings.Trim(alias, "ab"))
+	// A longer alias extends the shorter one instead of being unrelated
+	require.True(t, strings.HasPrefix(alias, hashalias.New("salt", "https://example.com", "ab", 10)))
+}
@@ -0,0 +1,178 @@
+// Package interstitial parses user-supplied templates for the page shown before
+// a public redirect. The page is served from the shortener's own origin, so a
+// template may only lay out static markup around the destination: it can
+// reference {{.URL}} and {{.Alias}} and nothing else, and must not carry
+// scripts, frames, forms or other active content.
+package interstitial
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"text/template/parse"
+
+	"golang.org/x/net/html"
+)
+
+// MaxSize caps the template source in bytes.
+const MaxSize = 16 << 10
+
+// CSP is the Content-Security-Policy for pages rendered from user templates.
+// It blocks scripts, frames, forms and plugins even if something slipped past Parse.
+const CSP = "default-src 'none'; style-src 'unsafe-inline'; img-src https: data:; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+var (
+	ErrTooLarge = errors.New("template is too large")
+	ErrUnsafe   = errors.New("template contains forbidden markup")
+	ErrInvalid  = errors.New("template is invalid")
+)
+
+// Data is what a template can reference.
+type Data struct {
+	// URL is the destination of the redirect.
+	URL   string
+	Alias string
+}
+
+// forbiddenTags can run code, load other documents or submit data.
+var forbiddenTags = map[string]bool{
+	"script": true, "noscript": true, "template": true,
+	"iframe": true, "frame": true, "frameset": true, "object": true, "embed": true, "applet": true, "portal": true,
+	"base": true, "link": true, "meta": true,
+	"form": true, "input": true, "button": true, "textarea": true, "select": true,
+	"svg": true, "math": true,
+}
+
+// forbiddenAttrs load documents or change where forms go.
+var forbiddenAttrs = map[string]bool{
+	"srcdoc": true, "formaction": true, "action": true, "xlink:href": true,
+}
+
+// forbiddenValues are checked in attribute values and style sheets after lowercasing
+// and removing whitespace, which browsers ignore inside schemes.
+var forbiddenValues = []string{"javascript:", "vbscript:", "data:text/html", "expression(", "@import"}
+
+// Parse validates src and compiles it. Destinations are escaped by html/template
+// according to where {{.URL}} appears, so a link target cannot break out of its attribute.
+func Parse(src string) (*template.Template, error) {
+	if len(src) > MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d", ErrTooLarge, len(src), MaxSize)
+	}
+
+	if err := checkActions(src); err != nil {
+		return nil, err
+	}
+	if err := checkMarkup(src); err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New("interstitial").Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	// html/template reports actions in contexts it cannot escape only on execution
+	if err := tmpl.Execute(io.Discard, Data{URL: "https://example.com/", Alias: "alias"}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	return tmpl, nil
+}
+
+// checkActions allows only {{.URL}} and {{.Alias}}: no pipelines, functions,
+// conditionals or nested templates.
+func checkActions(src string) error {
+	// Functions are rejected below with a clearer error than "not defined"
+	tree := parse.New("interstitial")
+	tree.Mode = parse.SkipFuncCheck
+	trees := map[string]*parse.Tree{}
+	if _, err := tree.Parse(src, "{{", "}}", trees); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	for name, tree := range trees {
+		if name != "interstitial" {
+			return fmt.Errorf("%w: nested templates are not allowed", ErrUnsafe)
+		}
+		for _, node := range tree.Root.Nodes {
+			switch n := node.(type) {
+			case *parse.TextNode:
+			case *parse.ActionNode:
+				if !plainField(n.Pipe) {
+					return fmt.Errorf("%w: only {{.URL}} and {{.Alias}} are allowed, got %s", ErrUnsafe, n)
+				}
+			default:
+				return fmt.Errorf("%w: only {{.URL}} and {{.Alias}} are allowed, got %s", ErrUnsafe, n)
+			}
+		}
+	}
+
+	return nil
+}
+
+func plainField(pipe *parse.PipeNode) bool {
+	if pipe == nil || len(pipe.Decl) != 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+
+	field, ok := pipe.Cmds[0].Args[0].(*parse.FieldNode)
+	if !ok || len(field.Ident) != 1 {
+		return false
+	}
+
+	return field.Ident[0] == "URL" || field.Ident[0] == "Alias"
+}
+
+// checkMarkup rejects active content: forbidden tags, event handlers and script URLs.
+func checkMarkup(src string) error {
+	z := html.NewTokenizer(strings.NewReader(src))
+	inStyle := false
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if errors.Is(z.Err(), io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("%w: %v", ErrInvalid, z.Err())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			if forbiddenTags[token.Data] {
+				return fmt.Errorf("%w: <%s>", ErrUnsafe, token.Data)
+			}
+			for _, attr := range token.Attr {
+				if strings.HasPrefix(attr.Key, "on") || forbiddenAttrs[attr.Key] {
+					return fmt.Errorf("%w: attribute %s", ErrUnsafe, attr.Key)
+				}
+				if hasForbiddenValue(attr.Val) {
+					return fmt.Errorf("%w: attribute %s=%q", ErrUnsafe, attr.Key, attr.Val)
+				}
+			}
+			inStyle = token.Data == "style"
+		case html.EndTagToken:
+			inStyle = false
+		case html.TextToken:
+			if inStyle && hasForbiddenValue(string(z.Text())) {
+				return fmt.Errorf("%w: style sheet", ErrUnsafe)
+			}
+		}
+	}
+}
+
+func hasForbiddenValue(value string) bool {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(value))
+
+	for _, bad := range forbiddenValues {
+		if strings.Contains(value, bad) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+package interstitial
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	const branded = `<html><head><style>body { color: #123; }</style></head>
+<body><img src="https://cdn.example.com/logo.png"><p>{{.Alias}} leads to {{.URL}}</p><a href="{{.URL}}">Go</a></body></html>`
+
+	tmpl, err := Parse(branded)
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, tmpl.Execute(&out, Data{URL: `https://example.com/?q="><script>alert(1)</script>`, Alias: "promo"}))
+
+	require.Contains(t, out.String(), "promo leads to")
+	require.Contains(t, out.String(), `https://example.com/?q=&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;`)
+	require.NotContains(t, out.String(), "<script>")
+
+	// A script URL as the destination is neutralised in href
+	out.Reset()
+	require.NoError(t, tmpl.Execute(&out, Data{URL: "javascript:alert(1)"}))
+	require.NotContains(t, out.String(), `href="javascript:`)
+}
+
+func TestParse_Rejected(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		err  error
+	}{
+		{name: "Script tag", src: `<p>{{.URL}}</p><script>alert(1)</script>`, err: ErrUnsafe},
+		{name: "Uppercase script tag", src: `<SCRIPT>alert(1)</SCRIPT>`, err: ErrUnsafe},
+		{name: "Event handler", src: `<img src="x.png" onerror="alert(1)">`, err: ErrUnsafe},
+		{name: "Script URL", src: `<a href="javascript:alert(1)">x</a>`, err: ErrUnsafe},
+		{name: "Encoded script URL", src: `<a href="&#106;ava&#x09;script:alert(1)">x</a>`, err: ErrUnsafe},
+		{name: "Iframe", src: `<iframe src="https://evil.example"></iframe>`, err: ErrUnsafe},
+		{name: "Form", src: `<form action="https://evil.example"><input name="password"></form>`, err: ErrUnsafe},
+		{name: "Meta refresh", src: `<meta http-equiv="refresh" content="0;url=https://evil.example">`, err: ErrUnsafe},
+		{name: "Style expression", src: `<style>p { width: expression(alert(1)); }</style>`, err: ErrUnsafe},
+		{name: "Function call", src: `{{printf "%s" .URL}}`, err: ErrUnsafe},
+		{name: "Conditional", src: `{{if .URL}}x{{end}}`, err: ErrUnsafe},
+		{name: "Unknown field", src: `{{.Owner}}`, err: ErrUnsafe},
+		{name: "Nested template", src: `{{define "x"}}y{{end}}`, err: ErrUnsafe},
+		{name: "Unclosed action", src: `{{.URL`, err: ErrInvalid},
+		{name: "Too large", src: strings.Repeat("a", MaxSize+1), err: ErrTooLarge},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.src)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
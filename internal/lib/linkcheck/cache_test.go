@@ -0,0 +1,42 @@
+package linkcheck
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRobots_CacheBounded(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Expired entries go first", func(t *testing.T) {
+		r := NewRobots(nil, time.Minute)
+		for i := 0; i < maxRobotsHosts; i++ {
+			expiresAt := now.Add(time.Minute)
+			if i%2 == 0 {
+				expiresAt = now.Add(-time.Second)
+			}
+			r.cache[fmt.Sprintf("https://host%d.example", i)] = robotsEntry{expiresAt: expiresAt}
+		}
+
+		r.evict(now)
+
+		require.Len(t, r.cache, maxRobotsHosts/2)
+		for _, e := range r.cache {
+			require.True(t, now.Before(e.expiresAt))
+		}
+	})
+
+	t.Run("Full of fresh entries", func(t *testing.T) {
+		r := NewRobots(nil, time.Minute)
+		for i := 0; i < maxRobotsHosts; i++ {
+			r.cache[fmt.Sprintf("https://host%d.example", i)] = robotsEntry{expiresAt: now.Add(time.Minute)}
+		}
+
+		r.evict(now)
+
+		require.Len(t, r.cache, maxRobotsHosts-1)
+	})
+}
@@ -0,0 +1,104 @@
+package linkcheck
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned when a target resolves to an address that is
+// not on the public internet: loopback, private, link-local (including the
+// 169.254.169.254 cloud metadata endpoint) and other special-purpose ranges.
+var ErrBlockedAddress = errors.New("target address is not public")
+
+// blockedNets are special-purpose ranges the net.IP predicates do not cover.
+var blockedNets = mustParseCIDRs(
+	"0.0.0.0/8",     // "this network"
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved, including broadcast
+	"64:ff9b::/96",  // NAT64, maps onto IPv4 addresses
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+
+	return nets
+}
+
+// public reports whether ip is a globally routable unicast address.
+func public(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dialControl runs after the host name is resolved and before the connection
+// is made, so it sees the actual IP of every hop, whatever name led to it.
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !public(ip) {
+		return ErrBlockedAddress
+	}
+
+	return nil
+}
+
+// NewClient returns an HTTP client for fetching user-supplied URLs. Requests
+// time out after timeout and follow at most maxRedirects redirects. Unless
+// allowPrivate is set, the client refuses to connect to non-public addresses,
+// on the first request and on every redirect hop, so that saved links cannot
+// be used to probe the internal network.
+func NewClient(timeout time.Duration, maxRedirects int, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = dialControl
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy from the environment would receive every connection, bypassing the check.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return errTooManyRedirects
+			}
+			// Literal addresses are refused before dialing; names are checked by dialControl.
+			if ip := net.ParseIP(req.URL.Hostname()); ip != nil && !allowPrivate && !public(ip) {
+				return ErrBlockedAddress
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,170 @@
+// Package linkcheck probes link targets with a HEAD request and caches the
+// outcome for a short time, so repeated checks do not hammer the target.
+package linkcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of probing a target URL.
+type Result struct {
+	// Status is the HTTP status of the final response; 0 if no response was received.
+	Status int `json:"status,omitempty"`
+	// Reachable reports that the target answered with a non-error status (below 400).
+	Reachable bool `json:"reachable"`
+	// Error is the category of the failure when no response was received, one of
+	// the Error* constants. The underlying error text is not exposed: it would
+	// describe the network the checker runs in.
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// UserAgent identifies probes to target servers; robots.txt rules for
+// this agent take precedence over the "*" group.
+const UserAgent = "url-shortener-linkcheck"
+
+var (
+	errTooManyRedirects = errors.New("too many redirects")
+	errInvalidURL       = errors.New("invalid url")
+)
+
+// Failure categories reported in Result.Error.
+const (
+	ErrorInvalidURL       = "invalid_url"
+	ErrorBlocked          = "blocked_address"
+	ErrorTooManyRedirects = "too_many_redirects"
+	ErrorTimeout          = "timeout"
+	ErrorDNS              = "dns_error"
+	ErrorTLS              = "tls_error"
+	ErrorUnreachable      = "unreachable"
+)
+
+type entry struct {
+	result    Result
+	expiresAt time.Time
+}
+
+// Checker probes URLs. It is safe for concurrent use.
+type Checker struct {
+	client *http.Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]entry
+}
+
+// New returns a Checker that probes with client, normally one built by NewClient.
+// Results are cached for ttl; 0 disables caching.
+func New(client *http.Client, ttl time.Duration) *Checker {
+	return &Checker{
+		client: client,
+		ttl:    ttl,
+		cache:  make(map[string]entry),
+	}
+}
+
+// Check probes target, returning a cached result if a fresh one exists.
+// Network failures are reported in the result, never as an error.
+func (c *Checker) Check(ctx context.Context, target string) Result {
+	now := time.Now()
+
+	if res, ok := c.cached(target, now); ok {
+		return res
+	}
+
+	res := c.probe(ctx, target)
+	res.CheckedAt = now.UTC()
+
+	if c.ttl > 0 {
+		c.mu.Lock()
+		c.prune(now)
+		c.cache[target] = entry{result: res, expiresAt: now.Add(c.ttl)}
+		c.mu.Unlock()
+	}
+
+	return res
+}
+
+func (c *Checker) cached(target string, now time.Time) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.cache[target]
+	if !ok || !now.Before(e.expiresAt) {
+		return Result{}, false
+	}
+
+	return e.result, true
+}
+
+// prune drops expired entries so the cache does not grow without bound.
+// Callers must hold c.mu.
+func (c *Checker) prune(now time.Time) {
+	for target, e := range c.cache {
+		if !now.Before(e.expiresAt) {
+			delete(c.cache, target)
+		}
+	}
+}
+
+// probe sends HEAD and falls back to GET for servers that do not allow HEAD.
+func (c *Checker) probe(ctx context.Context, target string) Result {
+	status, err := c.do(ctx, http.MethodHead, target)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = c.do(ctx, http.MethodGet, target)
+	}
+	if err != nil {
+		return Result{Error: category(err)}
+	}
+
+	return Result{Status: status, Reachable: status < http.StatusBadRequest}
+}
+
+// category maps a probe failure to one of the Error* constants.
+func category(err error) string {
+	var (
+		netErr    net.Error
+		dnsErr    *net.DNSError
+		certErr   *tls.CertificateVerificationError
+		recordErr tls.RecordHeaderError
+	)
+
+	switch {
+	case errors.Is(err, errInvalidURL):
+		return ErrorInvalidURL
+	case errors.Is(err, ErrBlockedAddress):
+		return ErrorBlocked
+	case errors.Is(err, errTooManyRedirects):
+		return ErrorTooManyRedirects
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorTimeout
+	case errors.As(err, &dnsErr):
+		return ErrorDNS
+	case errors.As(err, &certErr), errors.As(err, &recordErr):
+		return ErrorTLS
+	default:
+		return ErrorUnreachable
+	}
+}
+
+func (c *Checker) do(ctx context.Context, method, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, errors.Join(errInvalidURL, err)
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,149 @@
+package linkcheck_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/linkcheck"
+)
+
+func TestCheck_Status(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    int
+		reachable bool
+	}{
+		{name: "OK", status: http.StatusOK, reachable: true},
+		{name: "Not found", status: http.StatusNotFound, reachable: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodHead, r.Method)
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			res := linkcheck.New(linkcheck.NewClient(time.Second, 3, true), 0).Check(context.Background(), srv.URL)
+
+			require.Equal(t, tc.status, res.Status)
+			require.Equal(t, tc.reachable, res.Reachable)
+			require.Empty(t, res.Error)
+		})
+	}
+}
+
+func TestCheck_HeadNotAllowed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	res := linkcheck.New(linkcheck.NewClient(time.Second, 3, true), 0).Check(context.Background(), srv.URL)
+
+	require.Equal(t, http.StatusOK, res.Status)
+	require.True(t, res.Reachable)
+}
+
+func TestCheck_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	target := srv.URL
+	srv.Close()
+
+	res := linkcheck.New(linkcheck.NewClient(time.Second, 3, true), 0).Check(context.Background(), target)
+
+	require.False(t, res.Reachable)
+	require.Zero(t, res.Status)
+	require.Equal(t, linkcheck.ErrorUnreachable, res.Error)
+}
+
+func TestCheck_TooManyRedirects(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, srv.URL, http.StatusFound)
+	}))
+	defer srv.Close()
+
+	res := linkcheck.New(linkcheck.NewClient(time.Second, 2, true), 0).Check(context.Background(), srv.URL)
+
+	require.False(t, res.Reachable)
+	require.Equal(t, linkcheck.ErrorTooManyRedirects, res.Error)
+}
+
+func TestCheck_Cached(t *testing.T) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	checker := linkcheck.New(linkcheck.NewClient(time.Second, 3, true), time.Minute)
+	first := checker.Check(context.Background(), srv.URL)
+	second := checker.Check(context.Background(), srv.URL)
+
+	require.Equal(t, int64(1), hits.Load())
+	require.Equal(t, first, second)
+}
+
+func TestCheck_BlocksNonPublicAddresses(t *testing.T) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	checker := linkcheck.New(linkcheck.NewClient(time.Second, 3, false), 0)
+
+	targets := []string{
+		srv.URL,
+		"http://localhost:1/",
+		"http://10.0.0.1/",
+		"http://192.168.1.1/admin",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]:1/",
+		"http://[fd00::1]/",
+		"http://100.64.0.1/",
+	}
+	for _, target := range targets {
+		res := checker.Check(context.Background(), target)
+
+		require.False(t, res.Reachable, target)
+		require.Equal(t, linkcheck.ErrorBlocked, res.Error, target)
+	}
+	require.Zero(t, hits.Load())
+}
+
+func TestCheck_BlocksRedirectToNonPublicAddress(t *testing.T) {
+	// The unguarded transport lets the test reach its loopback server; the
+	// redirect to the metadata address must still be refused by CheckRedirect.
+	client := linkcheck.NewClient(time.Second, 3, false)
+	client.Transport = http.DefaultTransport
+	checker := linkcheck.New(client, 0)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	res := checker.Check(context.Background(), srv.URL)
+
+	require.False(t, res.Reachable)
+	require.Equal(t, linkcheck.ErrorBlocked, res.Error)
+}
@@ -0,0 +1,236 @@
+package linkcheck
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRobotsSize caps how much of a robots.txt is read.
+const maxRobotsSize = 512 << 10
+
+// maxRobotsHosts caps how many hosts' rules are cached at once.
+const maxRobotsHosts = 1024
+
+// Robots reports whether a host's robots.txt allows UserAgent to fetch a URL.
+// Rules are cached per host. It is safe for concurrent use.
+type Robots struct {
+	client *http.Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]robotsEntry
+}
+
+type robotsEntry struct {
+	rules     []rule
+	expiresAt time.Time
+}
+
+type rule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// NewRobots returns a Robots that fetches robots.txt with client, normally one
+// built by NewClient, and keeps the parsed rules for ttl.
+func NewRobots(client *http.Client, ttl time.Duration) *Robots {
+	return &Robots{
+		client: client,
+		ttl:    ttl,
+		cache:  make(map[string]robotsEntry),
+	}
+}
+
+// Allowed reports whether target may be probed. A missing or unreadable
+// robots.txt allows everything: an unreachable host should still be probed
+// so that it is reported as broken.
+func (r *Robots) Allowed(ctx context.Context, target string) bool {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return true
+	}
+
+	rules := r.rules(ctx, u.Scheme+"://"+u.Host)
+
+	return allowed(rules, u.EscapedPath())
+}
+
+func (r *Robots) rules(ctx context.Context, origin string) []rule {
+	now := time.Now()
+
+	r.mu.Lock()
+	e, ok := r.cache[origin]
+	r.mu.Unlock()
+	if ok && now.Before(e.expiresAt) {
+		return e.rules
+	}
+
+	rules := r.fetch(ctx, origin)
+
+	r.mu.Lock()
+	if _, ok := r.cache[origin]; !ok && len(r.cache) >= maxRobotsHosts {
+		r.evict(now)
+	}
+	r.cache[origin] = robotsEntry{rules: rules, expiresAt: now.Add(r.ttl)}
+	r.mu.Unlock()
+
+	return rules
+}
+
+// evict makes room for a new host: it drops expired entries and, if all are
+// still fresh, an arbitrary one. It runs only when the cache is full, so the
+// scan is amortised over many fetches. Callers must hold r.mu.
+func (r *Robots) evict(now time.Time) {
+	for origin, e := range r.cache {
+		if !now.Before(e.expiresAt) {
+			delete(r.cache, origin)
+		}
+	}
+
+	for origin := range r.cache {
+		if len(r.cache) < maxRobotsHosts {
+			break
+		}
+		delete(r.cache, origin)
+	}
+}
+
+func (r *Robots) fetch(ctx context.Context, origin string) []rule {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	return parseRobots(io.LimitReader(resp.Body, maxRobotsSize))
+}
+
+// parseRobots returns the rules of the group addressed to UserAgent, or of
+// the "*" group if there is none.
+func parseRobots(body io.Reader) []rule {
+	var (
+		own, wildcard  []rule
+		hasOwn         bool
+		groupOwn       bool
+		groupAny       bool
+		inAgentsHeader bool
+	)
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive User-agent lines share one group of rules.
+			if !inAgentsHeader {
+				groupOwn, groupAny = false, false
+			}
+			inAgentsHeader = true
+
+			agent := strings.ToLower(value)
+			switch {
+			case agent == "*":
+				groupAny = true
+			case agent != "" && strings.Contains(UserAgent, agent):
+				groupOwn = true
+				hasOwn = true
+			}
+		case "allow", "disallow":
+			inAgentsHeader = false
+
+			// An empty Disallow allows everything and adds no rule.
+			if value == "" {
+				continue
+			}
+
+			rl := newRule(key == "allow", value)
+			if groupOwn {
+				own = append(own, rl)
+			}
+			if groupAny {
+				wildcard = append(wildcard, rl)
+			}
+		default:
+			inAgentsHeader = false
+		}
+	}
+
+	if hasOwn {
+		return own
+	}
+
+	return wildcard
+}
+
+func newRule(allow bool, pattern string) rule {
+	rl := rule{allow: allow, pattern: pattern}
+
+	if strings.ContainsAny(pattern, "*$") {
+		expr := regexp.QuoteMeta(strings.TrimSuffix(pattern, "$"))
+		expr = "^" + strings.ReplaceAll(expr, `\*`, ".*")
+		if strings.HasSuffix(pattern, "$") {
+			expr += "$"
+		}
+		rl.re = regexp.MustCompile(expr)
+	}
+
+	return rl
+}
+
+func (rl rule) match(path string) bool {
+	if rl.re != nil {
+		return rl.re.MatchString(path)
+	}
+
+	return strings.HasPrefix(path, rl.pattern)
+}
+
+// allowed applies the most specific (longest) matching rule; Allow wins ties.
+func allowed(rules []rule, path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	best := -1
+	result := true
+	for _, rl := range rules {
+		if !rl.match(path) {
+			continue
+		}
+		if len(rl.pattern) > best || (len(rl.pattern) == best && rl.allow) {
+			best = len(rl.pattern)
+			result = rl.allow
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,98 @@
+package linkcheck_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/linkcheck"
+)
+
+func robotsServer(t *testing.T, body string, status int) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	var fetches atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		fetches.Add(1)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &fetches
+}
+
+func TestRobots_Allowed(t *testing.T) {
+	const body = `
+User-agent: *
+Disallow: /private
+Allow: /private/open
+Disallow: /*.pdf$
+
+User-agent: other-bot
+Disallow: /
+`
+	srv, fetches := robotsServer(t, body, http.StatusOK)
+	robots := linkcheck.NewRobots(linkcheck.NewClient(time.Second, 3, true), time.Minute)
+	ctx := context.Background()
+
+	cases := []struct {
+		path    string
+		allowed bool
+	}{
+		{path: "/", allowed: true},
+		{path: "/page", allowed: true},
+		{path: "/private", allowed: false},
+		{path: "/private/secret", allowed: false},
+		{path: "/private/open/doc", allowed: true},
+		{path: "/docs/file.pdf", allowed: false},
+		{path: "/docs/file.pdf.html", allowed: true},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.allowed, robots.Allowed(ctx, srv.URL+tc.path), tc.path)
+	}
+
+	// Rules are fetched once per host.
+	require.Equal(t, int64(1), fetches.Load())
+}
+
+func TestRobots_OwnGroupTakesPrecedence(t *testing.T) {
+	const body = `
+User-agent: *
+Disallow: /
+
+User-agent: url-shortener-linkcheck
+Disallow: /admin
+`
+	srv, _ := robotsServer(t, body, http.StatusOK)
+	robots := linkcheck.NewRobots(linkcheck.NewClient(time.Second, 3, true), time.Minute)
+
+	require.True(t, robots.Allowed(context.Background(), srv.URL+"/page"))
+	require.False(t, robots.Allowed(context.Background(), srv.URL+"/admin/users"))
+}
+
+func TestRobots_MissingAllowsAll(t *testing.T) {
+	srv, _ := robotsServer(t, "", http.StatusNotFound)
+	robots := linkcheck.NewRobots(linkcheck.NewClient(time.Second, 3, true), time.Minute)
+
+	require.True(t, robots.Allowed(context.Background(), srv.URL+"/anything"))
+}
+
+func TestRobots_InternalAddressNotFetched(t *testing.T) {
+	srv, fetches := robotsServer(t, "User-agent: *\nDisallow: /\n", http.StatusOK)
+	robots := linkcheck.NewRobots(linkcheck.NewClient(time.Second, 3, false), time.Minute)
+
+	// The fetch is refused, which counts as a missing robots.txt.
+	require.True(t, robots.Allowed(context.Background(), srv.URL+"/page"))
+	require.Zero(t, fetches.Load())
+}
@@ -0,0 +1,128 @@
+// Package metrics keeps process-wide counters and renders them in the
+// Prometheus text exposition format, without pulling in a client library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing value. A nil counter ignores updates,
+// so optional metrics can be left unset in handler options and tests.
+type Counter struct {
+	name  string
+	help  string
+	value atomic.Int64
+}
+
+// Inc adds one to the counter.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add adds n to the counter.
+func (c *Counter) Add(n int64) {
+	if c == nil {
+		return
+	}
+
+	c.value.Add(n)
+}
+
+// Value returns the current counter value; zero for a nil counter.
+func (c *Counter) Value() int64 {
+	if c == nil {
+		return 0
+	}
+
+	return c.value.Load()
+}
+
+// Registry holds named counters.
+type Registry struct {
+	mu       sync.Mutex
+	counters map[string]*Counter
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{counters: make(map[string]*Counter)}
+}
+
+// Counter returns the counter registered under name, creating it on first use.
+// The help text of the first registration wins.
+func (r *Registry) Counter(name, help string) *Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.counters[name]; ok {
+		return c
+	}
+
+	c := &Counter{name: name, help: help}
+	r.counters[name] = c
+
+	return c
+}
+
+// WriteTo writes every counter in the Prometheus text format, sorted by name.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	counters := make([]*Counter, 0, len(r.counters))
+	for _, c := range r.counters {
+		counters = append(counters, c)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(counters, func(i, j int) bool { return counters[i].name < counters[j].name })
+
+	var total int64
+	for _, c := range counters {
+		n, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.Value())
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// AliasGeneration counts what random alias generation runs into. A rising
+// retry rate means the alias space is getting crowded and the default alias
+// length should grow before generation starts to exhaust.
+type AliasGeneration struct {
+	// Retries counts generated aliases that were already taken.
+	Retries *Counter
+	// Exhausted counts requests that gave up without finding a free alias.
+	Exhausted *Counter
+}
+
+// NewAliasGeneration registers the alias generation counters in r.
+func NewAliasGeneration(r *Registry) *AliasGeneration {
+	return &AliasGeneration{
+		Retries:   r.Counter("alias_generation_retries_total", "Generated aliases that collided with an existing one."),
+		Exhausted: r.Counter("alias_generation_exhausted_total", "Requests that found no free generated alias."),
+	}
+}
+
+// Retry records one collision. Safe to call on a nil receiver.
+func (m *AliasGeneration) Retry() {
+	if m == nil {
+		return
+	}
+
+	m.Retries.Inc()
+}
+
+// Exhaust records one request that ran out of aliases. Safe to call on a nil receiver.
+func (m *AliasGeneration) Exhaust() {
+	if m == nil {
+		return
+	}
+
+	m.Exhausted.Inc()
+}
@@ -0,0 +1,44 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/metrics"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	reg := metrics.NewRegistry()
+	gen := metrics.NewAliasGeneration(reg)
+
+	gen.Retry()
+	gen.Retry()
+	gen.Exhaust()
+
+	// The same name returns the same counter
+	require.Same(t, gen.Retries, reg.Counter("alias_generation_retries_total", "ignored"))
+
+	var out strings.Builder
+	_, err := reg.WriteTo(&out)
+	require.NoError(t, err)
+
+	require.Equal(t, `# HELP alias_generation_exhausted_total Requests that found no free generated alias.
+# TYPE alias_generation_exhausted_total counter
+alias_generation_exhausted_total 1
+# HELP alias_generation_retries_total Generated aliases that collided with an existing one.
+# TYPE alias_generation_retries_total counter
+alias_generation_retries_total 2
+`, out.String())
+}
+
+func TestNilCounters(t *testing.T) {
+	var gen *metrics.AliasGeneration
+	gen.Retry()
+	gen.Exhaust()
+
+	var c *metrics.Counter
+	c.Inc()
+	require.Zero(t, c.Value())
+}
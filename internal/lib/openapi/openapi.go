@@ -0,0 +1,283 @@
+// Package openapi builds a minimal OpenAPI 3 document. Request and response
+// schemas are derived from the handlers' Go structs by reflection, so the
+// document follows the code instead of being maintained by hand.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI version of generated documents.
+const Version = "3.0.3"
+
+// bearerScheme is the name of the security scheme for JWT-protected operations.
+const bearerScheme = "bearerAuth"
+
+// Param describes a query parameter. Path parameters are taken from the path itself.
+type Param struct {
+	Name        string
+	Description string
+	// Type is a JSON schema type; empty means "string".
+	Type     string
+	Required bool
+}
+
+// Operation describes one endpoint.
+type Operation struct {
+	Method  string
+	Path    string
+	Summary string
+	// Auth marks operations that require a Bearer token.
+	Auth  bool
+	Query []Param
+	// Request is a value of the JSON request body type; nil means no body.
+	Request any
+	// Response is a value of the JSON response body type. It is ignored when
+	// ContentType is set to a non-JSON type.
+	Response any
+	// ContentType of a successful response; empty means application/json.
+	ContentType string
+	// Status of a successful response; zero means 200.
+	Status int
+}
+
+// Document is an OpenAPI document. Operations are kept in the order they are added.
+type Document struct {
+	Title       string
+	Description string
+	Version     string
+	ops         []Operation
+}
+
+// New creates an empty document.
+func New(title, version string) *Document {
+	return &Document{Title: title, Version: version}
+}
+
+// Add appends operations to the document.
+func (d *Document) Add(ops ...Operation) *Document {
+	d.ops = append(d.ops, ops...)
+	return d
+}
+
+// pathParam matches chi and OpenAPI path parameters such as {alias}.
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// MarshalJSON renders the document as OpenAPI JSON.
+func (d *Document) MarshalJSON() ([]byte, error) {
+	paths := make(map[string]map[string]any)
+
+	for _, op := range d.ops {
+		item, ok := paths[op.Path]
+		if !ok {
+			item = make(map[string]any)
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = op.render()
+	}
+
+	doc := map[string]any{
+		"openapi": Version,
+		"info": map[string]any{
+			"title":       d.Title,
+			"description": d.Description,
+			"version":     d.Version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"securitySchemes": map[string]any{
+				bearerScheme: map[string]any{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+			},
+		},
+	}
+
+	return json.Marshal(doc)
+}
+
+func (op Operation) render() map[string]any {
+	out := map[string]any{
+		"summary": op.Summary,
+	}
+
+	var params []any
+	for _, m := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+		params = append(params, map[string]any{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+	for _, p := range op.Query {
+		typ := p.Type
+		if typ == "" {
+			typ = "string"
+		}
+		params = append(params, map[string]any{
+			"name":        p.Name,
+			"in":          "query",
+			"description": p.Description,
+			"required":    p.Required,
+			"schema":      map[string]any{"type": typ},
+		})
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	if op.Request != nil {
+		out["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": Schema(op.Request)},
+			},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]any{"description": http.StatusText(status)}
+	switch {
+	case op.ContentType != "" && op.ContentType != "application/json":
+		success["content"] = map[string]any{op.ContentType: map[string]any{}}
+	case op.Response != nil:
+		success["content"] = map[string]any{
+			"application/json": map[string]any{"schema": Schema(op.Response)},
+		}
+	}
+	out["responses"] = map[string]any{
+		itoa(status): success,
+	}
+
+	if op.Auth {
+		out["security"] = []any{map[string]any{bearerScheme: []string{}}}
+	}
+
+	return out
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Schema returns the JSON schema of v's type. Struct fields follow their json
+// tags; fields tagged validate:"required" are listed as required.
+func Schema(v any) map[string]any {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) map[string]any {
+	if t == nil {
+		return map[string]any{}
+	}
+
+	if t.Kind() == reflect.Pointer {
+		s := schemaOf(t.Elem())
+		s["nullable"] = true
+		return s
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return map[string]any{"type": "string", "format": "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+
+	// Interfaces and other dynamic values accept anything
+	return map[string]any{}
+}
+
+func structSchema(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	var required []string
+
+	addFields(t, props, &required)
+
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+
+	return s
+}
+
+// addFields collects the JSON properties of t, flattening untagged embedded
+// structs the way encoding/json does.
+func addFields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		props[name] = schemaOf(f.Type)
+		if hasRule(f.Tag.Get("validate"), "required") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func hasRule(validate, rule string) bool {
+	for _, r := range strings.Split(validate, ",") {
+		if r == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+func itoa(status int) string {
+	b, _ := json.Marshal(status)
+	return string(b)
+}
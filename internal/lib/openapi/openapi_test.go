@@ -0,0 +1,71 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type base struct {
+	Status string `json:"status"`
+}
+
+type request struct {
+	base
+	URL     string         `json:"url" validate:"required,url"`
+	Tags    []string       `json:"tags,omitempty"`
+	Expires *time.Time     `json:"expires_at,omitempty"`
+	Meta    map[string]int `json:"meta"`
+	Secret  string         `json:"-"`
+	hidden  string
+}
+
+func TestSchema(t *testing.T) {
+	s := Schema(request{})
+
+	assert.Equal(t, "object", s["type"])
+	assert.Equal(t, []string{"url"}, s["required"])
+
+	props := s["properties"].(map[string]any)
+	assert.Len(t, props, 5)
+	assert.Equal(t, map[string]any{"type": "string"}, props["status"], "embedded fields are flattened")
+	assert.Equal(t, map[string]any{"type": "array", "items": map[string]any{"type": "string"}}, props["tags"])
+	assert.Equal(t, map[string]any{"type": "string", "format": "date-time", "nullable": true}, props["expires_at"])
+	assert.Equal(t, map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "integer"}}, props["meta"])
+	assert.NotContains(t, props, "Secret")
+	assert.NotContains(t, props, "hidden")
+}
+
+func TestDocument(t *testing.T) {
+	doc := New("test", "1.0.0").Add(
+		Operation{Method: http.MethodPost, Path: "/items/{id}", Auth: true, Request: request{}, Response: base{}},
+		Operation{Method: http.MethodGet, Path: "/items/{id}", ContentType: "text/plain"},
+	)
+
+	raw, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	var out struct {
+		OpenAPI string                               `json:"openapi"`
+		Paths   map[string]map[string]map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &out))
+
+	assert.Equal(t, Version, out.OpenAPI)
+	require.Contains(t, out.Paths, "/items/{id}")
+
+	post := out.Paths["/items/{id}"]["post"]
+	assert.Contains(t, post, "requestBody")
+	assert.Contains(t, post, "security")
+	params := post["parameters"].([]any)
+	require.Len(t, params, 1)
+	assert.Equal(t, "path", params[0].(map[string]any)["in"])
+
+	get := out.Paths["/items/{id}"]["get"]
+	assert.NotContains(t, get, "security")
+	assert.Contains(t, get["responses"].(map[string]any)["200"].(map[string]any)["content"], "text/plain")
+}
@@ -0,0 +1,287 @@
+// Package preview fetches a target page and extracts its <title> and preview
+// metadata (description, image) for link previews. Fetches are bounded in time and size.
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// UserAgent identifies preview fetches to target servers.
+const UserAgent = "url-shortener-preview"
+
+// maxTitleLength caps the returned title, in runes.
+const maxTitleLength = 300
+
+// maxDescriptionLength caps the returned description, in runes.
+const maxDescriptionLength = 1000
+
+var (
+	ErrNotHTML   = errors.New("target is not an HTML page")
+	ErrNoTitle   = errors.New("target page has no title")
+	ErrBadStatus = errors.New("target returned an error status")
+	// ErrNoMetadata - the page head has no title, description or image.
+	ErrNoMetadata = errors.New("target page has no preview metadata")
+)
+
+// Metadata is what a link preview shows. Empty fields were not found on the page.
+type Metadata struct {
+	Title       string
+	Description string
+	// Image is an absolute URL, resolved against the page address.
+	Image string
+}
+
+// Fetcher extracts page titles. It is safe for concurrent use.
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+// New returns a Fetcher that requests pages with client and reads at most
+// maxBytes of the page body. The client should be built by linkcheck.NewClient,
+// so that pages on the internal network cannot be fetched and read back.
+func New(client *http.Client, maxBytes int64) *Fetcher {
+	return &Fetcher{
+		client:   client,
+		maxBytes: maxBytes,
+	}
+}
+
+// Title fetches target and returns the text of its <title> element.
+func (f *Fetcher) Title(ctx context.Context, target string) (string, error) {
+	const op = "lib.preview.Title"
+
+	var title string
+	err := f.fetch(ctx, target, func(body io.Reader) (err error) {
+		title, err = parseTitle(body)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return title, nil
+}
+
+// Metadata fetches target and returns its title, description and image from the
+// page head. Open Graph tags fill in what the plain <title> and description lack.
+func (f *Fetcher) Metadata(ctx context.Context, target string) (Metadata, error) {
+	const op = "lib.preview.Metadata"
+
+	var meta Metadata
+	err := f.fetch(ctx, target, func(body io.Reader) error {
+		meta = parseMetadata(body)
+		if meta == (Metadata{}) {
+			return ErrNoMetadata
+		}
+		return nil
+	})
+	if err != nil {
+		return Metadata{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if meta.Image != "" {
+		meta.Image = resolve(target, meta.Image)
+	}
+
+	return meta, nil
+}
+
+// fetch requests target and hands the size-limited HTML body to parse.
+func (f *Fetcher) fetch(ctx context.Context, target string, parse func(body io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%w: %d", ErrBadStatus, resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return fmt.Errorf("%w: %q", ErrNotHTML, mediaType)
+	}
+
+	return parse(io.LimitReader(resp.Body, f.maxBytes))
+}
+
+// parseTitle returns the first <title> of the document, with whitespace collapsed.
+func parseTitle(body io.Reader) (string, error) {
+	z := html.NewTokenizer(body)
+
+	inTitle := false
+	var b strings.Builder
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if inTitle && b.Len() > 0 {
+				return clean(b.String()), nil
+			}
+			return "", ErrNoTitle
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			if atom.Lookup(name) == atom.Title {
+				inTitle = true
+			}
+		case html.TextToken:
+			if inTitle {
+				b.Write(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				if title := clean(b.String()); title != "" {
+					return title, nil
+				}
+				return "", ErrNoTitle
+			case atom.Head:
+				return "", ErrNoTitle
+			}
+		}
+	}
+}
+
+// parseMetadata reads the document head up to </head> or <body>. The first
+// <title> and meta description win over their Open Graph counterparts.
+func parseMetadata(body io.Reader) Metadata {
+	z := html.NewTokenizer(body)
+
+	var (
+		meta              Metadata
+		ogTitle, ogDesc   string
+		inTitle, gotTitle bool
+		title             strings.Builder
+	)
+
+	finish := func() Metadata {
+		if meta.Title == "" {
+			meta.Title = clean(ogTitle)
+		}
+		if meta.Description == "" {
+			meta.Description = cleanN(ogDesc, maxDescriptionLength)
+		}
+		return meta
+	}
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if inTitle {
+				meta.Title = clean(title.String())
+			}
+			return finish()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = !gotTitle && tt == html.StartTagToken
+			case atom.Body:
+				return finish()
+			case atom.Meta:
+				if !hasAttr {
+					continue
+				}
+				key, content := metaAttrs(z)
+				switch key {
+				case "description":
+					if meta.Description == "" {
+						meta.Description = cleanN(content, maxDescriptionLength)
+					}
+				case "og:title":
+					ogTitle = content
+				case "og:description":
+					ogDesc = content
+				case "og:image":
+					if meta.Image == "" {
+						meta.Image = strings.TrimSpace(content)
+					}
+				}
+			}
+		case html.TextToken:
+			if inTitle {
+				title.Write(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				if inTitle {
+					meta.Title = clean(title.String())
+					inTitle, gotTitle = false, true
+				}
+			case atom.Head:
+				return finish()
+			}
+		}
+	}
+}
+
+// metaAttrs returns the lowercased name (or property) of a <meta> tag and its content.
+func metaAttrs(z *html.Tokenizer) (string, string) {
+	var key, content string
+	for {
+		name, value, more := z.TagAttr()
+		switch string(name) {
+		case "name", "property":
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(string(value)))
+			}
+		case "content":
+			content = string(value)
+		}
+		if !more {
+			return key, content
+		}
+	}
+}
+
+// resolve makes ref absolute against the page address. Unparsable refs are dropped.
+func resolve(target, ref string) string {
+	base, err := url.Parse(target)
+	if err != nil {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+
+	return u.String()
+}
+
+func clean(s string) string {
+	return cleanN(s, maxTitleLength)
+}
+
+// cleanN collapses whitespace and caps s at n runes.
+func cleanN(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+
+	if runes := []rune(s); len(runes) > n {
+		s = string(runes[:n])
+	}
+
+	return s
+}
@@ -0,0 +1,157 @@
+package preview_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/linkcheck"
+	"url-shortener/internal/lib/preview"
+)
+
+func serve(t *testing.T, contentType, body string, status int) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
+
+func TestTitle(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		title       string
+		err         error
+	}{
+		{
+			name:        "Title",
+			contentType: "text/html; charset=utf-8",
+			body:        "<html><head><title>\n  Example   &amp; Co\n</title></head><body>x</body></html>",
+			status:      http.StatusOK,
+			title:       "Example & Co",
+		},
+		{
+			name:        "No title",
+			contentType: "text/html",
+			body:        "<html><head></head><body><title>late</title></body></html>",
+			status:      http.StatusOK,
+			err:         preview.ErrNoTitle,
+		},
+		{
+			name:        "Not HTML",
+			contentType: "application/json",
+			body:        `{"title":"x"}`,
+			status:      http.StatusOK,
+			err:         preview.ErrNotHTML,
+		},
+		{
+			name:        "Error status",
+			contentType: "text/html",
+			body:        "<title>Not Found</title>",
+			status:      http.StatusNotFound,
+			err:         preview.ErrBadStatus,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			target := serve(t, tc.contentType, tc.body, tc.status)
+			fetcher := preview.New(linkcheck.NewClient(time.Second, 3, true), 1<<20)
+
+			title, err := fetcher.Title(context.Background(), target)
+			if tc.err != nil {
+				require.True(t, errors.Is(err, tc.err), err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.title, title)
+		})
+	}
+}
+
+func TestTitle_BodyLimit(t *testing.T) {
+	body := "<html><head>" + strings.Repeat("<meta>", 1000) + "<title>far</title></head></html>"
+	target := serve(t, "text/html", body, http.StatusOK)
+
+	_, err := preview.New(linkcheck.NewClient(time.Second, 3, true), 256).Title(context.Background(), target)
+
+	require.ErrorIs(t, err, preview.ErrNoTitle)
+}
+
+func TestMetadata(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want preview.Metadata
+		err  error
+	}{
+		{
+			name: "Plain tags",
+			body: `<html><head><title>Example</title>
+				<meta name="description" content="  An   example page ">
+				<meta property="og:image" content="/img/cover.png"></head><body></body></html>`,
+			want: preview.Metadata{Title: "Example", Description: "An example page", Image: "/img/cover.png"},
+		},
+		{
+			name: "Open Graph fills gaps",
+			body: `<head><meta property="og:title" content="OG title"><meta property="og:description" content="OG description"></head>`,
+			want: preview.Metadata{Title: "OG title", Description: "OG description"},
+		},
+		{
+			name: "Body ignored",
+			body: `<html><head></head><body><title>late</title></body></html>`,
+			err:  preview.ErrNoMetadata,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			target := serve(t, "text/html", tc.body, http.StatusOK)
+			fetcher := preview.New(linkcheck.NewClient(time.Second, 3, true), 1<<20)
+
+			meta, err := fetcher.Metadata(context.Background(), target)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			// A relative image URL is resolved against the page address
+			if tc.want.Image != "" {
+				tc.want.Image = target + tc.want.Image
+			}
+			require.Equal(t, tc.want, meta)
+		})
+	}
+}
+
+func TestFetch_InternalAddressBlocked(t *testing.T) {
+	target := serve(t, "text/html", "<html><head><title>internal</title></head></html>", http.StatusOK)
+
+	_, err := preview.New(linkcheck.NewClient(time.Second, 3, false), 1<<20).Metadata(context.Background(), target)
+
+	require.ErrorIs(t, err, linkcheck.ErrBlockedAddress)
+}
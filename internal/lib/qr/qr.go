@@ -0,0 +1,22 @@
+package qr
+
+import (
+	"fmt"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// DefaultSize is the default side of a generated QR code in pixels.
+const DefaultSize = 256
+
+// PNG renders content as a QR code PNG image with the given side in pixels.
+func PNG(content string, size int) ([]byte, error) {
+	const op = "lib.qr.PNG"
+
+	png, err := qrcode.Encode(content, qrcode.Medium, size)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return png, nil
+}
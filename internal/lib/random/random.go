@@ -5,13 +5,21 @@ import (
 	"time"
 )
 
+// DefaultAlphabet is the set of characters used by NewRandomString.
+const DefaultAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+	"abcdefghijklmnopqrstuvwxyz" +
+	"0123456789"
+
 // NewRandomString generates random string with given size.
 func NewRandomString(size int) string {
+	return NewRandomStringFrom(DefaultAlphabet, size)
+}
+
+// NewRandomStringFrom generates random string with given size from the given alphabet.
+func NewRandomStringFrom(alphabet string, size int) string {
 	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
 
-	chars := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
-		"abcdefghijklmnopqrstuvwxyz" +
-		"0123456789")
+	chars := []rune(alphabet)
 
 	b := make([]rune, size)
 	for i := range b {
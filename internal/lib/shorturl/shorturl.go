@@ -0,0 +1,11 @@
+package shorturl
+
+import "strings"
+
+// RedirectPath is the path prefix the redirect handler is mounted on.
+const RedirectPath = "/redirect/"
+
+// Build returns the full short URL for alias served from baseURL.
+func Build(baseURL, alias string) string {
+	return strings.TrimRight(baseURL, "/") + RedirectPath + alias
+}
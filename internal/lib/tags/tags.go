@@ -0,0 +1,36 @@
+package tags
+
+import (
+	"sort"
+	"strings"
+)
+
+// Normalize trims and lowercases tags, drops empty ones and duplicates
+// and returns the rest sorted, so "Work" and "work " become one tag.
+func Normalize(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) == 0 {
+		return nil
+	}
+
+	sort.Strings(normalized)
+
+	return normalized
+}
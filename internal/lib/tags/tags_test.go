@@ -0,0 +1,25 @@
+package tags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{name: "nil", in: nil, want: nil},
+		{name: "only blanks", in: []string{"", "  "}, want: nil},
+		{name: "case and spaces collapse", in: []string{"Work", "work ", " WORK"}, want: []string{"work"}},
+		{name: "sorted", in: []string{"travel", "Home", "work"}, want: []string{"home", "travel", "work"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.in))
+		})
+	}
+}
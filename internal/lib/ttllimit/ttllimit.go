@@ -0,0 +1,70 @@
+// Package ttllimit enforces the configured bounds on how long a link may live,
+// shared by saving a link and extending its expiry.
+package ttllimit
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrTooShort = errors.New("ttl is below the minimum")
+	ErrTooLong  = errors.New("ttl is above the maximum")
+	ErrNoExpiry = errors.New("links must expire")
+)
+
+// Limit bounds a link's time to live. The zero value allows any TTL,
+// including links that never expire.
+type Limit struct {
+	// Min is the shortest allowed TTL. Zero means no minimum.
+	Min time.Duration
+	// Max is the longest allowed TTL. Zero means no maximum.
+	Max time.Duration
+	// DenyNoExpiry rejects links that never expire.
+	DenyNoExpiry bool
+}
+
+// MaxSeconds is the longest TTL, in seconds, that fits in a time.Duration.
+const MaxSeconds = int64(math.MaxInt64 / int64(time.Second))
+
+// Seconds converts a TTL given in seconds to a Duration. Values above
+// MaxSeconds saturate instead of overflowing into a negative Duration,
+// which Check would read as a link that never expires.
+func Seconds(seconds int64) time.Duration {
+	if seconds > MaxSeconds {
+		return math.MaxInt64
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// Check validates a TTL. A zero or negative ttl means the link never expires.
+func (l Limit) Check(ttl time.Duration) error {
+	switch {
+	case ttl <= 0 && l.DenyNoExpiry:
+		return ErrNoExpiry
+	case ttl <= 0:
+		return nil
+	case l.Min > 0 && ttl < l.Min:
+		return ErrTooShort
+	case l.Max > 0 && ttl > l.Max:
+		return ErrTooLong
+	}
+
+	return nil
+}
+
+// Message describes why Check rejected a TTL in terms of the configured bounds.
+func (l Limit) Message(err error) string {
+	switch {
+	case errors.Is(err, ErrTooShort):
+		return "ttl must be at least " + l.Min.String()
+	case errors.Is(err, ErrTooLong):
+		return "ttl must be at most " + l.Max.String()
+	case errors.Is(err, ErrNoExpiry):
+		return "links must have an expiry"
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,59 @@
+package ttllimit
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeconds(t *testing.T) {
+	require.Equal(t, time.Hour, Seconds(3600))
+	require.Equal(t, time.Duration(MaxSeconds)*time.Second, Seconds(MaxSeconds))
+
+	// Larger values saturate and are still rejected by a maximum
+	huge := Seconds(math.MaxInt64)
+	require.Positive(t, huge)
+	require.ErrorIs(t, Limit{Max: 24 * time.Hour}.Check(huge), ErrTooLong)
+}
+
+func TestCheck(t *testing.T) {
+	limit := Limit{Min: time.Minute, Max: 24 * time.Hour}
+
+	cases := []struct {
+		name  string
+		limit Limit
+		ttl   time.Duration
+		want  error
+	}{
+		{name: "Below min", limit: limit, ttl: time.Second, want: ErrTooShort},
+		{name: "Above max", limit: limit, ttl: 48 * time.Hour, want: ErrTooLong},
+		{name: "In range", limit: limit, ttl: time.Hour},
+		{name: "Bounds are inclusive", limit: limit, ttl: time.Minute},
+		{name: "Never expire allowed", limit: limit, ttl: 0},
+		{name: "Never expire denied", limit: Limit{DenyNoExpiry: true}, ttl: 0, want: ErrNoExpiry},
+		{name: "Zero limit", ttl: 100 * 365 * 24 * time.Hour},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.limit.Check(tc.ttl)
+			if tc.want == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.want)
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	limit := Limit{Min: time.Minute, Max: time.Hour}
+
+	require.Equal(t, "ttl must be at least 1m0s", limit.Message(ErrTooShort))
+	require.Equal(t, "ttl must be at most 1h0m0s", limit.Message(ErrTooLong))
+	require.Equal(t, "links must have an expiry", limit.Message(ErrNoExpiry))
+}
@@ -0,0 +1,57 @@
+// Package urlnorm rewrites target URLs before they are stored, so that
+// trivially different spellings of one address become the same link.
+package urlnorm
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Options selects the rewrites Normalize applies. Each one is independent;
+// the zero value leaves URLs untouched.
+type Options struct {
+	// LowercaseHost lowercases the host name. Paths are never lowercased:
+	// unlike hosts, they are case-sensitive.
+	LowercaseHost bool
+	// StripWWW removes a leading "www." from the host.
+	StripWWW bool
+	// StripFragment removes the "#fragment" part.
+	StripFragment bool
+	// StripTrailingSlash removes trailing slashes from the path, including a bare "/".
+	StripTrailingSlash bool
+}
+
+// Enabled reports whether any rewrite is selected.
+func (o Options) Enabled() bool {
+	return o.LowercaseHost || o.StripWWW || o.StripFragment || o.StripTrailingSlash
+}
+
+// Normalize applies the selected rewrites to rawURL. URLs without a host and
+// URLs that fail to parse are returned as is, as is everything when no rewrite is selected.
+func (o Options) Normalize(rawURL string) string {
+	if !o.Enabled() {
+		return rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	if o.LowercaseHost {
+		u.Host = strings.ToLower(u.Host)
+	}
+	if o.StripWWW && len(u.Host) > len("www.") && strings.EqualFold(u.Host[:len("www.")], "www.") {
+		u.Host = u.Host[len("www."):]
+	}
+	if o.StripFragment {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+	if o.StripTrailingSlash {
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+	}
+
+	return u.String()
+}
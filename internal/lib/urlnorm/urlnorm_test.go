@@ -0,0 +1,43 @@
+package urlnorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	const raw = "https://WWW.Example.COM/Docs/Page/?q=A#Top"
+
+	cases := []struct {
+		name string
+		opts Options
+		raw  string
+		want string
+	}{
+		{name: "Disabled", raw: raw, want: raw},
+		{name: "Lowercase host", opts: Options{LowercaseHost: true}, raw: raw, want: "https://www.example.com/Docs/Page/?q=A#Top"},
+		{name: "Strip www", opts: Options{StripWWW: true}, raw: raw, want: "https://Example.COM/Docs/Page/?q=A#Top"},
+		{name: "Strip fragment", opts: Options{StripFragment: true}, raw: raw, want: "https://WWW.Example.COM/Docs/Page/?q=A"},
+		{name: "Strip trailing slash", opts: Options{StripTrailingSlash: true}, raw: raw, want: "https://WWW.Example.COM/Docs/Page?q=A#Top"},
+		{
+			name: "All",
+			opts: Options{LowercaseHost: true, StripWWW: true, StripFragment: true, StripTrailingSlash: true},
+			raw:  raw,
+			want: "https://example.com/Docs/Page?q=A",
+		},
+		{name: "Root slash", opts: Options{StripTrailingSlash: true}, raw: "https://example.com/", want: "https://example.com"},
+		{name: "Port kept", opts: Options{StripWWW: true}, raw: "http://www.example.com:8080/a", want: "http://example.com:8080/a"},
+		{name: "Bare www host", opts: Options{StripWWW: true}, raw: "http://www./a", want: "http://www./a"},
+		{name: "No host", opts: Options{LowercaseHost: true}, raw: "mailto:Someone@Example.com", want: "mailto:Someone@Example.com"},
+		{name: "Unparsable", opts: Options{LowercaseHost: true}, raw: "http://Exa mple.com/%zz", want: "http://Exa mple.com/%zz"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.opts.Normalize(tc.raw))
+		})
+	}
+}
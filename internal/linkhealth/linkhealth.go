@@ -0,0 +1,178 @@
+package linkhealth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/linkcheck"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// Store - хранилище, ссылки которого проверяет Scanner
+type Store interface {
+	GetURLsToCheck(ctx context.Context, log *slog.Logger, checkedBefore time.Time, limit int) ([]storage.URL, error)
+	SetURLHealth(ctx context.Context, log *slog.Logger, alias string, status *int, checkedAt time.Time) error
+}
+
+// Prober проверяет доступность цели
+type Prober interface {
+	Check(ctx context.Context, target string) linkcheck.Result
+}
+
+// RobotsPolicy сообщает, разрешает ли robots.txt сайта обращаться к цели
+type RobotsPolicy interface {
+	Allowed(ctx context.Context, target string) bool
+}
+
+// Options - параметры фоновой проверки
+type Options struct {
+	// Interval - пауза между пачками
+	Interval time.Duration
+	// RecheckAfter - через сколько ссылка проверяется повторно
+	RecheckAfter time.Duration
+	// BatchSize - сколько ссылок проверяется за один проход
+	BatchSize int
+	// Concurrency - сколько проверок выполняется одновременно
+	Concurrency int
+	// Delay - минимальная пауза между началом двух проверок
+	Delay time.Duration
+}
+
+// Scanner периодически проверяет цели ссылок всех пользователей и сохраняет
+// last_status/last_checked_at. Проверки идут небольшими пачками, с ограничением
+// частоты и числа одновременных запросов; цели, закрытые robots.txt, не запрашиваются.
+type Scanner struct {
+	log    *slog.Logger
+	store  Store
+	prober Prober
+	robots RobotsPolicy
+	opts   Options
+}
+
+func New(log *slog.Logger, store Store, prober Prober, robots RobotsPolicy, opts Options) *Scanner {
+	if opts.BatchSize < 1 {
+		opts.BatchSize = 1
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
+	return &Scanner{
+		log:    log.With(slog.String("component", "linkhealth")),
+		store:  store,
+		prober: prober,
+		robots: robots,
+		opts:   opts,
+	}
+}
+
+// Run проверяет по пачке ссылок каждые Interval, пока не будет отменён ctx
+func (s *Scanner) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Scan(ctx)
+		}
+	}
+}
+
+// Scan проверяет одну пачку ссылок и возвращает число ссылок, у которых обновлено состояние
+func (s *Scanner) Scan(ctx context.Context) int {
+	now := time.Now()
+
+	urls, err := s.store.GetURLsToCheck(ctx, s.log, now.Add(-s.opts.RecheckAfter), s.opts.BatchSize)
+	if err != nil {
+		s.log.Error("failed to get urls to check", sl.Err(err))
+		return 0
+	}
+	if len(urls) == 0 {
+		return 0
+	}
+
+	var limiter <-chan time.Time
+	if s.opts.Delay > 0 {
+		ticker := time.NewTicker(s.opts.Delay)
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		updated int
+	)
+	sem := make(chan struct{}, s.opts.Concurrency)
+
+	for i, u := range urls {
+		// Первая проверка начинается сразу, следующие - не чаще раза в Delay
+		if i > 0 && limiter != nil {
+			select {
+			case <-ctx.Done():
+				wg.Wait()
+				return updated
+			case <-limiter:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return updated
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(u storage.URL) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if s.check(ctx, u) {
+				mu.Lock()
+				updated++
+				mu.Unlock()
+			}
+		}(u)
+	}
+
+	wg.Wait()
+
+	return updated
+}
+
+// check проверяет одну ссылку и сохраняет результат. Истёкшие ссылки и цели,
+// закрытые robots.txt, не запрашиваются: у них обновляется только время проверки,
+// чтобы они не занимали следующие пачки.
+func (s *Scanner) check(ctx context.Context, u storage.URL) bool {
+	log := s.log.With(slog.String("alias", u.Alias))
+	now := time.Now()
+
+	var status *int
+	switch {
+	case storage.Expired(u.ExpiresAt, now):
+		log.Debug("url expired, skipping check")
+	case s.robots != nil && !s.robots.Allowed(ctx, u.URL):
+		log.Debug("check disallowed by robots.txt")
+	default:
+		res := s.prober.Check(ctx, u.URL)
+		status = &res.Status
+		if !res.Reachable {
+			log.Info("url target is broken", slog.Int("status", res.Status), slog.String("error", res.Error))
+		}
+	}
+
+	if err := s.store.SetURLHealth(ctx, log, u.Alias, status, now); err != nil {
+		log.Error("failed to save url health", sl.Err(err))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,116 @@
+package linkhealth_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/linkcheck"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/linkhealth"
+	"url-shortener/internal/storage"
+)
+
+// stubStore отдаёт заранее заданные ссылки и запоминает сохранённые статусы
+type stubStore struct {
+	urls []storage.URL
+
+	mu       sync.Mutex
+	statuses map[string]*int
+}
+
+func (s *stubStore) GetURLsToCheck(_ context.Context, _ *slog.Logger, _ time.Time, limit int) ([]storage.URL, error) {
+	if len(s.urls) > limit {
+		return s.urls[:limit], nil
+	}
+
+	return s.urls, nil
+}
+
+func (s *stubStore) SetURLHealth(_ context.Context, _ *slog.Logger, alias string, status *int, _ time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.statuses == nil {
+		s.statuses = make(map[string]*int)
+	}
+	s.statuses[alias] = status
+
+	return nil
+}
+
+// stubProber отвечает статусом по адресу цели
+type stubProber map[string]int
+
+func (p stubProber) Check(_ context.Context, target string) linkcheck.Result {
+	status := p[target]
+
+	return linkcheck.Result{Status: status, Reachable: status != 0 && status < http.StatusBadRequest}
+}
+
+// stubRobots запрещает перечисленные цели
+type stubRobots map[string]bool
+
+func (r stubRobots) Allowed(_ context.Context, target string) bool {
+	return !r[target]
+}
+
+func TestScanner_Scan(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	store := &stubStore{urls: []storage.URL{
+		{Alias: "ok", URL: "https://ok.example"},
+		{Alias: "missing", URL: "https://missing.example"},
+		{Alias: "down", URL: "https://down.example"},
+		{Alias: "robots", URL: "https://robots.example"},
+		{Alias: "expired", URL: "https://ok.example", ExpiresAt: &past},
+	}}
+	prober := stubProber{
+		"https://ok.example":      http.StatusOK,
+		"https://missing.example": http.StatusNotFound,
+	}
+	robots := stubRobots{"https://robots.example": true}
+
+	scanner := linkhealth.New(slogdiscard.NewDiscardLogger(), store, prober, robots, linkhealth.Options{
+		BatchSize:   10,
+		Concurrency: 2,
+	})
+
+	require.Equal(t, 5, scanner.Scan(context.Background()))
+
+	require.Equal(t, http.StatusOK, *store.statuses["ok"])
+	require.Equal(t, http.StatusNotFound, *store.statuses["missing"])
+	require.Equal(t, 0, *store.statuses["down"])
+
+	// Пропущенные ссылки отмечаются как проверенные без статуса
+	require.Contains(t, store.statuses, "robots")
+	require.Nil(t, store.statuses["robots"])
+	require.Contains(t, store.statuses, "expired")
+	require.Nil(t, store.statuses["expired"])
+}
+
+func TestScanner_RateLimited(t *testing.T) {
+	store := &stubStore{urls: []storage.URL{
+		{Alias: "a", URL: "https://a.example"},
+		{Alias: "b", URL: "https://b.example"},
+		{Alias: "c", URL: "https://c.example"},
+	}}
+
+	scanner := linkhealth.New(slogdiscard.NewDiscardLogger(), store, stubProber{}, nil, linkhealth.Options{
+		BatchSize:   2,
+		Concurrency: 1,
+		Delay:       20 * time.Millisecond,
+	})
+
+	start := time.Now()
+	require.Equal(t, 2, scanner.Scan(context.Background()))
+
+	// Вторая проверка ждёт Delay после первой
+	require.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	require.NotContains(t, store.statuses, "c")
+}
@@ -0,0 +1,125 @@
+package healthcheck
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// Pinger - хранилище, доступность которого проверяет Checker
+type Pinger interface {
+	Ping(ctx context.Context) error
+	Reconnect(ctx context.Context) error
+}
+
+// Checker периодически пингует хранилище. После failureThreshold неудачных
+// проверок подряд он считает хранилище недоступным, сообщает об этом через
+// onStateChange и пытается переподключиться.
+type Checker struct {
+	log              *slog.Logger
+	pinger           Pinger
+	interval         time.Duration
+	failureThreshold int
+	onStateChange    func(up bool)
+
+	failures int
+	up       bool
+}
+
+func New(
+	log *slog.Logger,
+	pinger Pinger,
+	interval time.Duration,
+	failureThreshold int,
+	onStateChange func(up bool),
+) *Checker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+
+	return &Checker{
+		log:              log.With(slog.String("component", "storage/healthcheck")),
+		pinger:           pinger,
+		interval:         interval,
+		failureThreshold: failureThreshold,
+		onStateChange:    onStateChange,
+		up:               true,
+	}
+}
+
+// Run выполняет проверки каждые interval, пока не будет отменён ctx
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Check(ctx)
+		}
+	}
+}
+
+// Check выполняет одну проверку и возвращает текущее состояние хранилища
+func (c *Checker) Check(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout())
+	defer cancel()
+
+	err := c.pinger.Ping(ctx)
+	if err == nil {
+		c.failures = 0
+		c.setUp(true)
+		return c.up
+	}
+
+	c.failures++
+	c.log.Warn("storage ping failed", slog.Int("failures", c.failures), sl.Err(err))
+
+	if c.failures < c.failureThreshold {
+		return c.up
+	}
+
+	c.setUp(false)
+
+	if err := c.pinger.Reconnect(ctx); err != nil {
+		c.log.Error("failed to reconnect to storage", sl.Err(err))
+		return c.up
+	}
+
+	c.log.Info("reconnected to storage")
+	c.failures = 0
+	c.setUp(true)
+
+	return c.up
+}
+
+func (c *Checker) setUp(up bool) {
+	if c.up == up {
+		return
+	}
+	c.up = up
+
+	if up {
+		c.log.Info("storage is up")
+	} else {
+		c.log.Error("storage is down")
+	}
+
+	if c.onStateChange != nil {
+		c.onStateChange(up)
+	}
+}
+
+// timeout ограничивает одну проверку, чтобы зависшее хранилище не блокировало цикл
+func (c *Checker) timeout() time.Duration {
+	if c.interval > 0 {
+		return c.interval
+	}
+
+	return 5 * time.Second
+}
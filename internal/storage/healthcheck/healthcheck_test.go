@@ -0,0 +1,77 @@
+package healthcheck_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage/healthcheck"
+)
+
+// stubPinger падает failPings раз подряд, а затем (после переподключения) снова отвечает
+type stubPinger struct {
+	failPings  int
+	pings      int
+	reconnects int
+}
+
+func (p *stubPinger) Ping(_ context.Context) error {
+	p.pings++
+	if p.reconnects == 0 && p.pings <= p.failPings {
+		return errors.New("connection refused")
+	}
+
+	return nil
+}
+
+func (p *stubPinger) Reconnect(_ context.Context) error {
+	p.reconnects++
+
+	return nil
+}
+
+func TestChecker_ReconnectsAfterFailures(t *testing.T) {
+	pinger := &stubPinger{failPings: 10}
+
+	var states []bool
+	checker := healthcheck.New(slogdiscard.NewDiscardLogger(), pinger, time.Second, 3, func(up bool) {
+		states = append(states, up)
+	})
+
+	ctx := context.Background()
+
+	// Первые проверки не превышают порог - состояние не меняется
+	require.True(t, checker.Check(ctx))
+	require.True(t, checker.Check(ctx))
+	require.Zero(t, pinger.reconnects)
+	require.Empty(t, states)
+
+	// Третья неудача подряд: хранилище помечается недоступным и переподключается
+	require.True(t, checker.Check(ctx))
+	require.Equal(t, 1, pinger.reconnects)
+	require.Equal(t, []bool{false, true}, states)
+
+	// После переподключения пинги проходят
+	require.True(t, checker.Check(ctx))
+	require.Equal(t, 1, pinger.reconnects)
+}
+
+type failingPinger struct{}
+
+func (failingPinger) Ping(_ context.Context) error      { return errors.New("down") }
+func (failingPinger) Reconnect(_ context.Context) error { return errors.New("still down") }
+
+func TestChecker_StaysDownWhenReconnectFails(t *testing.T) {
+	var states []bool
+	checker := healthcheck.New(slogdiscard.NewDiscardLogger(), failingPinger{}, time.Second, 1, func(up bool) {
+		states = append(states, up)
+	})
+
+	require.False(t, checker.Check(context.Background()))
+	require.False(t, checker.Check(context.Background()))
+	require.Equal(t, []bool{false}, states)
+}
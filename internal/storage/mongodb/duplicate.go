@@ -0,0 +1,142 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"url-shortener/internal/storage"
+)
+
+// Имена уникальных индексов, по которым различаются ошибки дубликата
+const (
+	aliasIndex    = "alias_unique"
+	nicknameIndex = "nickname_unique"
+	userIDIndex   = "user_id_unique"
+)
+
+// duplicateCode - код ошибки MongoDB при нарушении уникального индекса
+const duplicateCode = 11000
+
+// Сентинел по имени индекса и, если имя незнакомо, по полю ключа
+var (
+	duplicateByIndex = map[string]error{
+		aliasIndex:      storage.ErrURLExists,
+		aliasLowerIndex: storage.ErrURLExists,
+		nicknameIndex:   storage.ErrUserExists,
+	}
+	duplicateByField = map[string]error{
+		"alias":       storage.ErrURLExists,
+		"alias_lower": storage.ErrURLExists,
+		"nickname":    storage.ErrUserExists,
+	}
+)
+
+// duplicateMessage разбирает текст ошибки сервера:
+// E11000 duplicate key error collection: db.users index: nickname_unique dup key: { nickname: "bob" }
+var duplicateMessage = regexp.MustCompile(`index: (\S+) dup key: \{ ?"?([^":\s]*)`)
+
+// EnsureIndexes создаёт уникальные индексы по alias ссылок, nickname и user_id пользователей.
+// Проверки перед вставкой остаются, индексы закрывают гонку между проверкой и вставкой.
+// Индекс по user_id не даёт записать второго пользователя с уже выданным id.
+func (s *Storage) EnsureIndexes(ctx context.Context) error {
+	const op = "mongodb.EnsureIndexes"
+
+	indexes := []struct {
+		collection string
+		field      string
+		name       string
+	}{
+		{"urls", "alias", aliasIndex},
+		{"users", "nickname", nicknameIndex},
+		{"users", "user_id", userIDIndex},
+	}
+
+	for _, idx := range indexes {
+		_, err := s.database().Collection(idx.collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: idx.field, Value: 1}},
+			Options: options.Index().SetName(idx.name).SetUnique(true),
+		})
+		if err != nil {
+			return fmt.Errorf("%s: create index %s: %w", op, idx.name, err)
+		}
+	}
+
+	return nil
+}
+
+// duplicateError превращает нарушение уникального индекса в storage.ErrURLExists или
+// storage.ErrUserExists по имени индекса или полю ключа. Для остальных ошибок возвращает nil.
+func duplicateError(err error) error {
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		for _, we := range writeErr.WriteErrors {
+			if we.Code != duplicateCode {
+				continue
+			}
+			if sentinel := duplicateFromRaw(we.Raw); sentinel != nil {
+				return sentinel
+			}
+			if sentinel := duplicateFromMessage(we.Message); sentinel != nil {
+				return sentinel
+			}
+		}
+		return nil
+	}
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == duplicateCode {
+		return duplicateFromMessage(cmdErr.Message)
+	}
+
+	return nil
+}
+
+// duplicateFromRaw берёт поле из keyPattern, который сервер добавляет к ошибке
+func duplicateFromRaw(raw bson.Raw) error {
+	if raw == nil {
+		return nil
+	}
+
+	keyPattern, ok := raw.Lookup("keyPattern").DocumentOK()
+	if !ok {
+		return nil
+	}
+	elems, err := keyPattern.Elements()
+	if err != nil || len(elems) == 0 {
+		return nil
+	}
+
+	return duplicateByField[elems[0].Key()]
+}
+
+func duplicateFromMessage(msg string) error {
+	m := duplicateMessage.FindStringSubmatch(msg)
+	if m == nil {
+		return nil
+	}
+	if sentinel, ok := duplicateByIndex[m[1]]; ok {
+		return sentinel
+	}
+
+	return duplicateByField[m[2]]
+}
+
+// aliasConflict уточняет дубликат alias так же, как SQLite:
+// ErrURLExists - alias у самого пользователя, ErrAliasTaken - у другого
+func (s *Storage) aliasConflict(ctx context.Context, alias string, userID int64) error {
+	var existing struct {
+		UserID int64 `bson:"user_id"`
+	}
+	err := s.database().Collection("urls").FindOne(ctx, s.aliasFilter(alias)).Decode(&existing)
+	if err == nil && existing.UserID != userID {
+		return storage.ErrAliasTaken
+	}
+
+	return storage.ErrURLExists
+}
@@ -0,0 +1,90 @@
+package mongodb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"url-shortener/internal/storage"
+)
+
+func writeException(code int, msg string, raw bson.Raw) error {
+	return fmt.Errorf("insert document: %w", mongo.WriteException{
+		WriteErrors: []mongo.WriteError{{Code: code, Message: msg, Raw: raw}},
+	})
+}
+
+func TestDuplicateError(t *testing.T) {
+	keyPattern := func(field string) bson.Raw {
+		raw, err := bson.Marshal(bson.D{{Key: "keyPattern", Value: bson.D{{Key: field, Value: 1}}}})
+		require.NoError(t, err)
+		return raw
+	}
+
+	cases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "Alias index",
+			err:  writeException(duplicateCode, `E11000 duplicate key error collection: db.urls index: alias_unique dup key: { alias: "abc" }`, nil),
+			want: storage.ErrURLExists,
+		},
+		{
+			name: "Case-insensitive alias index",
+			err:  writeException(duplicateCode, `E11000 duplicate key error collection: db.urls index: alias_lower_unique dup key: { alias_lower: "abc" }`, nil),
+			want: storage.ErrURLExists,
+		},
+		{
+			name: "Nickname index",
+			err:  writeException(duplicateCode, `E11000 duplicate key error collection: db.users index: nickname_unique dup key: { nickname: "bob" }`, nil),
+			want: storage.ErrUserExists,
+		},
+		{
+			name: "Unknown index, known key field",
+			err:  writeException(duplicateCode, `E11000 duplicate key error collection: db.users index: nickname_1 dup key: { nickname: "bob" }`, nil),
+			want: storage.ErrUserExists,
+		},
+		{
+			name: "Key pattern from server response",
+			err:  writeException(duplicateCode, "E11000 duplicate key error", keyPattern("alias")),
+			want: storage.ErrURLExists,
+		},
+		{
+			name: "Command error",
+			err:  mongo.CommandError{Code: duplicateCode, Message: `E11000 duplicate key error collection: db.users index: nickname_unique dup key: { nickname: "bob" }`},
+			want: storage.ErrUserExists,
+		},
+		{
+			name: "Unrelated index",
+			err:  writeException(duplicateCode, `E11000 duplicate key error collection: db.counters index: _id_ dup key: { _id: "user_id" }`, nil),
+		},
+		{
+			name: "Other write error",
+			err:  writeException(121, "Document failed validation", nil),
+		},
+		{
+			name: "Not a mongo error",
+			err:  errors.New("connection refused"),
+		},
+		{
+			name: "No error",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := duplicateError(tc.err)
+			if tc.want == nil {
+				require.NoError(t, got)
+				return
+			}
+			require.ErrorIs(t, got, tc.want)
+		})
+	}
+}
@@ -2,16 +2,32 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"url-shortener/internal/lib/domain"
 	"url-shortener/internal/storage"
 )
 
 type Storage struct {
-	db *mongo.Database
+	// mu защищает db при переподключении из health-checker'а
+	mu            sync.RWMutex
+	db            *mongo.Database
+	clientOptions *options.ClientOptions
+	dbName        string
+	// caseInsensitive - alias уникальны и ищутся без учёта регистра (по alias_lower)
+	caseInsensitive bool
+	// nicknameHashKey - ключ хэширования никнеймов; пустой - никнеймы хранятся как есть
+	nicknameHashKey []byte
 }
 
 // NewClient создает новое хранилище MongoDB
@@ -49,32 +65,204 @@ func NewClient(ctx context.Context, host, port, username, password, database, au
 	if err = client.Ping(ctx, nil); err != nil {
 		return nil, fmt.Errorf("ping MongoDB: %w", err)
 	}
-	return &Storage{db: client.Database(database)}, nil
+	return &Storage{
+		db:            client.Database(database),
+		clientOptions: clientOptions,
+		dbName:        database,
+	}, nil
+}
+
+// database возвращает текущую базу данных (клиент может быть пересоздан при переподключении)
+func (s *Storage) database() *mongo.Database {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.db
+}
+
+// Ping проверяет доступность MongoDB
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "mongodb.Ping"
+
+	if err := s.database().Client().Ping(ctx, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Reconnect пересоздаёт клиента MongoDB с исходными настройками и заменяет им текущего
+func (s *Storage) Reconnect(ctx context.Context) error {
+	const op = "mongodb.Reconnect"
+
+	client, err := mongo.Connect(ctx, s.clientOptions)
+	if err != nil {
+		return fmt.Errorf("%s: connect: %w", op, err)
+	}
+
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(ctx)
+		return fmt.Errorf("%s: ping: %w", op, err)
+	}
+
+	s.mu.Lock()
+	old := s.db.Client()
+	s.db = client.Database(s.dbName)
+	s.mu.Unlock()
+
+	// Старый клиент больше не используется
+	_ = old.Disconnect(ctx)
+
+	return nil
+}
+
+// UseCaseInsensitiveAliases включает режим, в котором alias хранится в исходном регистре,
+// но уникален и ищется без учёта регистра по полю alias_lower
+func (s *Storage) UseCaseInsensitiveAliases(ctx context.Context, enabled bool) error {
+	const op = "mongodb.UseCaseInsensitiveAliases"
+
+	collection := s.database().Collection("urls")
+
+	if !enabled {
+		if _, err := collection.Indexes().DropOne(ctx, aliasLowerIndex); err != nil && !isIndexNotFound(err) {
+			return fmt.Errorf("%s: drop index: %w", op, err)
+		}
+		s.caseInsensitive = false
+		return nil
+	}
+
+	// Документы, сохранённые до появления alias_lower
+	_, err := collection.UpdateMany(ctx,
+		bson.M{"alias_lower": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"alias_lower": bson.M{"$toLower": "$alias"}}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("%s: fill alias_lower: %w", op, err)
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "alias_lower", Value: 1}},
+		Options: options.Index().SetName(aliasLowerIndex).SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: create index: %w", op, err)
+	}
+
+	s.caseInsensitive = true
+
+	return nil
+}
+
+const aliasLowerIndex = "alias_lower_unique"
+
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Name == "IndexNotFound"
+}
+
+// UseHashedNicknames включает режим, в котором в поле nickname хранится хэш никнейма
+// (см. storage.NicknameKey), а сам никнейм - только в display_name
+func (s *Storage) UseHashedNicknames(key []byte) {
+	s.nicknameHashKey = key
+}
+
+// nicknameKey - значение поля nickname для никнейма в текущем режиме
+func (s *Storage) nicknameKey(nickname string) string {
+	return storage.NicknameKey(s.nicknameHashKey, nickname)
+}
+
+// aliasField - поле, по которому ищется ссылка в текущем режиме регистра
+func (s *Storage) aliasField() string {
+	if s.caseInsensitive {
+		return "alias_lower"
+	}
+
+	return "alias"
+}
+
+// aliasKey приводит alias к виду, в котором он сравнивается в текущем режиме
+func (s *Storage) aliasKey(alias string) string {
+	if s.caseInsensitive {
+		return strings.ToLower(alias)
+	}
+
+	return alias
+}
+
+func (s *Storage) aliasFilter(alias string) bson.M {
+	return bson.M{s.aliasField(): s.aliasKey(alias)}
+}
+
+// liveFilter - aliasFilter без ссылок в корзине. Отсутствующее поле deleted_at
+// тоже совпадает с nil, поэтому старые документы считаются живыми.
+func (s *Storage) liveFilter(alias string) bson.M {
+	filter := s.aliasFilter(alias)
+	filter["deleted_at"] = nil
+
+	return filter
+}
+
+// linkedFilter - живая ссылка вместе с её дополнительными alias (POST /url/{alias}/link):
+// они разделяют с основной ссылкой срок, видимость и удаление. stored - alias основной
+// ссылки в том виде, в котором он сохранён.
+func (s *Storage) linkedFilter(alias, stored string, userID int64) bson.M {
+	return bson.M{
+		"$or":        bson.A{s.aliasFilter(alias), bson.M{"linked_to": stored}},
+		"user_id":    userID,
+		"deleted_at": nil,
+	}
 }
 
 // SaveURL сохраняет новый URL в MongoDB
-func (s *Storage) SaveURL(ctx context.Context, urlToSave, alias string, userID int64) (interface{}, error) {
+func (s *Storage) SaveURL(ctx context.Context, urlToSave, alias string, userID int64, opts storage.URLOptions) (interface{}, error) {
 	const op = "mongodb.SaveURL"
 
-	collection := s.db.Collection("urls")
+	collection := s.database().Collection("urls")
 
 	doc := bson.M{
-		"url":     urlToSave,
-		"alias":   alias,
-		"user_id": userID,
+		"url":             urlToSave,
+		"alias":           alias,
+		"alias_lower":     strings.ToLower(alias),
+		"user_id":         userID,
+		"created_at":      time.Now().UTC(),
+		"domain":          domain.Registrable(urlToSave),
+		"expires_at":      opts.ExpiresAt,
+		"is_public":       opts.Public,
+		"wildcard":        opts.Wildcard,
+		"forward_query":   opts.ForwardQuery,
+		"redirect_status": redirectStatus(opts.RedirectStatus),
+		"tags":            opts.Tags,
+	}
+	if opts.LinkedTo != "" {
+		doc["linked_to"] = opts.LinkedTo
+	}
+	if opts.Description != "" {
+		doc["description"] = opts.Description
 	}
 
-	// Проверка на существование alias
-	count, err := collection.CountDocuments(ctx, bson.M{"alias": alias})
-	if err != nil {
-		return nil, fmt.Errorf("%s: count documents: %w", op, err)
+	if err := s.prefixConflict(ctx, alias, userID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	if count > 0 {
+
+	// Проверка на существование alias и его владельца
+	var existing struct {
+		UserID int64 `bson:"user_id"`
+	}
+	err := collection.FindOne(ctx, s.aliasFilter(alias)).Decode(&existing)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrAliasTaken)
+		}
 		return nil, fmt.Errorf("%s: %w", op, storage.ErrURLExists)
+	} else if err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("%s: find document: %w", op, err)
 	}
 
-	// Вставка нового URL
+	// Вставка нового URL. Alias мог занять параллельный запрос после проверки.
 	res, err := collection.InsertOne(ctx, doc)
+	if errors.Is(duplicateError(err), storage.ErrURLExists) {
+		return nil, fmt.Errorf("%s: %w", op, s.aliasConflict(ctx, alias, userID))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%s: insert document: %w", op, err)
 	}
@@ -86,15 +274,16 @@ func (s *Storage) SaveURL(ctx context.Context, urlToSave, alias string, userID i
 func (s *Storage) GetURL(ctx context.Context, alias string, userID int64) (string, error) {
 	const op = "mongodb.GetURL"
 
-	collection := s.db.Collection("urls")
+	collection := s.database().Collection("urls")
 
 	// Сначала проверяем, существует ли alias в базе
 	var doc struct {
-		URL    string `bson:"url"`
-		UserID int64  `bson:"user_id"`
+		URL       string     `bson:"url"`
+		UserID    int64      `bson:"user_id"`
+		ExpiresAt *time.Time `bson:"expires_at"`
 	}
 
-	err := collection.FindOne(ctx, bson.M{"alias": alias}).Decode(&doc)
+	err := collection.FindOne(ctx, s.liveFilter(alias)).Decode(&doc)
 	if err == mongo.ErrNoDocuments {
 		return "", storage.ErrURLNotFound
 	} else if err != nil {
@@ -106,20 +295,75 @@ func (s *Storage) GetURL(ctx context.Context, alias string, userID int64) (strin
 		return "", storage.ErrUnauthorized
 	}
 
+	if storage.Expired(doc.ExpiresAt, time.Now()) {
+		return "", storage.ErrURLExpired
+	}
+
 	return doc.URL, nil
 }
 
-// DeleteURL удаляет URL по alias и проверяет владельца
+// AliasExists проверяет, занят ли alias (без учёта владельца).
+// Alias ссылки в корзине остаётся занятым до её окончательного удаления.
+func (s *Storage) AliasExists(ctx context.Context, alias string) (bool, error) {
+	const op = "mongodb.AliasExists"
+
+	collection := s.database().Collection("urls")
+
+	count, err := collection.CountDocuments(ctx, s.aliasFilter(alias))
+	if err != nil {
+		return false, fmt.Errorf("%s: count documents: %w", op, err)
+	}
+
+	return count > 0, nil
+}
+
+// DeleteURL переносит URL в корзину по alias и проверяет владельца.
+// Дополнительные alias ссылки уходят в корзину вместе с ней.
 func (s *Storage) DeleteURL(ctx context.Context, alias string, userID int64) error {
 	const op = "mongodb.DeleteURL"
 
-	collection := s.db.Collection("urls")
+	collection := s.database().Collection("urls")
 
 	// Проверка принадлежности alias пользователю
 	var doc struct {
-		UserID int64 `bson:"user_id"`
+		Alias  string `bson:"alias"`
+		UserID int64  `bson:"user_id"`
+	}
+	err := collection.FindOne(ctx, s.liveFilter(alias)).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return storage.ErrURLNotFound
+	} else if err != nil {
+		return fmt.Errorf("%s: find document: %w", op, err)
+	}
+
+	if doc.UserID != userID {
+		return storage.ErrUnauthorized
+	}
+
+	_, err = collection.UpdateMany(ctx, s.linkedFilter(alias, doc.Alias, userID), bson.M{"$set": bson.M{"deleted_at": time.Now().UTC()}})
+	if err != nil {
+		return fmt.Errorf("%s: update document: %w", op, err)
+	}
+
+	return nil
+}
+
+// RestoreURL возвращает ссылку пользователя из корзины вместе с дополнительными alias,
+// удалёнными одновременно с ней. Ошибки те же, что у SQLite: ErrURLNotFound - ссылки нет
+// в корзине или срок восстановления (deletedAfter) истёк, ErrUnauthorized - ссылка чужая.
+func (s *Storage) RestoreURL(ctx context.Context, alias string, userID int64, deletedAfter time.Time) error {
+	const op = "mongodb.RestoreURL"
+
+	collection := s.database().Collection("urls")
+
+	var doc struct {
+		Alias     string    `bson:"alias"`
+		UserID    int64     `bson:"user_id"`
+		DeletedAt time.Time `bson:"deleted_at"`
 	}
-	err := collection.FindOne(ctx, bson.M{"alias": alias}).Decode(&doc)
+	filter := s.aliasFilter(alias)
+	filter["deleted_at"] = bson.M{"$ne": nil}
+	err := collection.FindOne(ctx, filter).Decode(&doc)
 	if err == mongo.ErrNoDocuments {
 		return storage.ErrURLNotFound
 	} else if err != nil {
@@ -129,95 +373,1384 @@ func (s *Storage) DeleteURL(ctx context.Context, alias string, userID int64) err
 	if doc.UserID != userID {
 		return storage.ErrUnauthorized
 	}
+	if doc.DeletedAt.Before(deletedAfter) {
+		return storage.ErrURLNotFound
+	}
 
-	// Удаление документа
-	_, err = collection.DeleteOne(ctx, bson.M{"alias": alias})
+	restore := s.linkedFilter(alias, doc.Alias, userID)
+	restore["deleted_at"] = doc.DeletedAt
+	_, err = collection.UpdateMany(ctx, restore, bson.M{"$set": bson.M{"deleted_at": nil}})
 	if err != nil {
+		return fmt.Errorf("%s: update documents: %w", op, err)
+	}
+
+	return nil
+}
+
+// DiscardURL удаляет только что сохранённую ссылку, минуя корзину, когда её не приняла вторая база
+func (s *Storage) DiscardURL(ctx context.Context, alias string) error {
+	const op = "mongodb.DiscardURL"
+
+	if _, err := s.database().Collection("urls").DeleteOne(ctx, s.aliasFilter(alias)); err != nil {
 		return fmt.Errorf("%s: delete document: %w", op, err)
 	}
 
 	return nil
 }
 
-// SaveUser сохраняет нового пользователя в MongoDB
-func (s *Storage) SaveUser(ctx context.Context, nickname, passwordHash string, userID int64) (interface{}, error) {
-	const op = "mongodb.SaveUser"
+// PurgeDeletedURLs окончательно удаляет все ссылки пользователя из корзины
+// и возвращает их количество
+func (s *Storage) PurgeDeletedURLs(ctx context.Context, userID int64) (int64, error) {
+	const op = "mongodb.PurgeDeletedURLs"
 
-	collection := s.db.Collection("users")
+	purged, err := s.purgeDeleted(ctx, bson.M{"user_id": userID, "deleted_at": bson.M{"$ne": nil}})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
 
-	doc := bson.M{
-		"nickname":      nickname,
-		"password_hash": passwordHash,
-		"user_id":       userID,
+	return purged, nil
+}
+
+// PurgeExpiredURLs окончательно удаляет ссылки всех пользователей, перенесённые в корзину
+// раньше deletedBefore (срок восстановления истёк), и возвращает их количество
+func (s *Storage) PurgeExpiredURLs(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	const op = "mongodb.PurgeExpiredURLs"
+
+	purged, err := s.purgeDeleted(ctx, bson.M{"deleted_at": bson.M{"$ne": nil, "$lt": deletedBefore.UTC()}})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	// Проверка на существование пользователя
-	count, err := collection.CountDocuments(ctx, bson.M{"nickname": nickname})
+	return purged, nil
+}
+
+// purgeDeleted удаляет ссылки из корзины по filter вместе с их статистикой
+func (s *Storage) purgeDeleted(ctx context.Context, filter bson.M) (int64, error) {
+	collection := s.database().Collection("urls")
+
+	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("%s: count documents: %w", op, err)
+		return 0, fmt.Errorf("find documents: %w", err)
 	}
-	if count > 0 {
-		return nil, fmt.Errorf("%s: %w", op, storage.ErrUserExists)
+	defer cursor.Close(ctx)
+
+	keys := make([]string, 0)
+	for cursor.Next(ctx) {
+		var doc urlDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return 0, fmt.Errorf("decode document: %w", err)
+		}
+		keys = append(keys, s.aliasKey(doc.Alias))
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, fmt.Errorf("iterate cursor: %w", err)
+	}
+	if len(keys) == 0 {
+		return 0, nil
 	}
 
-	// Вставка нового пользователя
-	res, err := collection.InsertOne(ctx, doc)
+	// Статистика удалённой ссылки не должна достаться тому, кто займёт alias следующим
+	if _, err := s.database().Collection("clicks").DeleteMany(ctx, bson.M{"alias": bson.M{"$in": keys}}); err != nil {
+		return 0, fmt.Errorf("delete clicks: %w", err)
+	}
+
+	res, err := collection.DeleteMany(ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("%s: insert document: %w", op, err)
+		return 0, fmt.Errorf("delete documents: %w", err)
 	}
 
-	return res.InsertedID, nil
+	return res.DeletedCount, nil
 }
 
-// GetUserByNickname получает пользователя по никнейму
-func (s *Storage) GetUserByNickname(ctx context.Context, nickname string) (int64, string, error) {
-	const op = "mongodb.GetUserByNickname"
+// RenameAlias переименовывает ссылку пользователя; переходы переносятся на newAlias.
+// Ошибки те же, что у SQLite: ErrURLNotFound, ErrUnauthorized, ErrURLExists/ErrAliasTaken.
+func (s *Storage) RenameAlias(ctx context.Context, alias, newAlias string, userID int64) error {
+	const op = "mongodb.RenameAlias"
 
-	collection := s.db.Collection("users")
+	collection := s.database().Collection("urls")
 
-	var doc struct {
-		ID           primitive.ObjectID `bson:"_id"`
-		PasswordHash string             `bson:"password_hash"`
+	link, err := s.GetLink(ctx, alias)
+	if err != nil {
+		return err
+	}
+	if link.UserID != userID {
+		return storage.ErrUnauthorized
 	}
 
-	err := collection.FindOne(ctx, bson.M{"nickname": nickname}).Decode(&doc)
-	if err == mongo.ErrNoDocuments {
-		return 0, "", storage.ErrUserNotFound
-	} else if err != nil {
-		return 0, "", fmt.Errorf("%s: find document: %w", op, err)
+	if err := s.prefixConflict(ctx, newAlias, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var existing struct {
+		UserID int64 `bson:"user_id"`
+	}
+	err = collection.FindOne(ctx, s.aliasFilter(newAlias)).Decode(&existing)
+	if err == nil {
+		if existing.UserID != userID {
+			return fmt.Errorf("%s: %w", op, storage.ErrAliasTaken)
+		}
+		return fmt.Errorf("%s: %w", op, storage.ErrURLExists)
+	} else if err != mongo.ErrNoDocuments {
+		return fmt.Errorf("%s: find document: %w", op, err)
+	}
+
+	_, err = collection.UpdateOne(ctx, s.liveFilter(alias), bson.M{"$set": bson.M{
+		"alias":       newAlias,
+		"alias_lower": strings.ToLower(newAlias),
+	}})
+	if errors.Is(duplicateError(err), storage.ErrURLExists) {
+		return fmt.Errorf("%s: %w", op, s.aliasConflict(ctx, newAlias, userID))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: update document: %w", op, err)
+	}
+
+	_, err = s.database().Collection("clicks").UpdateMany(ctx,
+		bson.M{"alias": s.aliasKey(alias)},
+		bson.M{"$set": bson.M{"alias": s.aliasKey(newAlias)}},
+	)
+	if err != nil {
+		return fmt.Errorf("%s: move clicks: %w", op, err)
 	}
 
-	// Если необходимо преобразовать ObjectID в int64 (не рекомендуется, так как это может привести к потерям данных)
-	// userID := doc.ID.Hex() // Если нужно использовать в качестве строки
-	userID := int64(doc.ID.Timestamp().Unix()) // Пример получения значения времени создания ObjectID в качестве int64
+	// Дополнительные alias остаются привязанными к переименованной ссылке
+	_, err = collection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "linked_to": alias},
+		bson.M{"$set": bson.M{"linked_to": newAlias}},
+	)
+	if err != nil {
+		return fmt.Errorf("%s: move linked aliases: %w", op, err)
+	}
 
-	return userID, doc.PasswordHash, nil
+	return nil
 }
 
-// DeleteUserByNickname удаляет пользователя и все связанные URL
-func (s *Storage) DeleteUserByNickname(ctx context.Context, nickname string) error {
-	const op = "mongodb.DeleteUserByNickname"
+// RecordClick записывает переход по ссылке и обновляет last_accessed_at ссылки
+func (s *Storage) RecordClick(ctx context.Context, alias string, at time.Time) error {
+	const op = "mongodb.RecordClick"
 
-	// Начинаем транзакцию
-	session, err := s.db.Client().StartSession()
+	_, err := s.database().Collection("clicks").InsertOne(ctx, bson.M{
+		"alias":      s.aliasKey(alias),
+		"clicked_at": at.UTC(),
+	})
 	if err != nil {
-		return fmt.Errorf("%s: start session: %w", op, err)
+		return fmt.Errorf("%s: insert document: %w", op, err)
 	}
-	defer session.EndSession(ctx)
 
-	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
-		collectionUsers := s.db.Collection("users")
-		collectionURLs := s.db.Collection("urls")
+	_, err = s.database().Collection("urls").UpdateOne(ctx, s.liveFilter(alias), bson.M{"$set": bson.M{"last_accessed_at": at.UTC()}})
+	if err != nil {
+		return fmt.Errorf("%s: update document: %w", op, err)
+	}
 
-		// Находим пользователя
+	return nil
+}
+
+// CountClicks считает все переходы по ссылке
+func (s *Storage) CountClicks(ctx context.Context, alias string) (int64, error) {
+	const op = "mongodb.CountClicks"
+
+	count, err := s.database().Collection("clicks").CountDocuments(ctx, bson.M{"alias": s.aliasKey(alias)})
+	if err != nil {
+		return 0, fmt.Errorf("%s: count documents: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// ClickTimeseries считает переходы по интервалам bucket в диапазоне [from, to).
+// Интервалы без переходов в результат не попадают.
+func (s *Storage) ClickTimeseries(ctx context.Context, alias string, from, to time.Time, bucket time.Duration) ([]storage.ClickBucket, error) {
+	const op = "mongodb.ClickTimeseries"
+
+	size := bucket.Milliseconds()
+	clickedAt := bson.M{"$toLong": "$clicked_at"}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"alias":      s.aliasKey(alias),
+			"clicked_at": bson.M{"$gte": from.UTC(), "$lt": to.UTC()},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    bson.M{"$subtract": bson.A{clickedAt, bson.M{"$mod": bson.A{clickedAt, size}}}},
+			"clicks": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := s.database().Collection("clicks").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("%s: aggregate: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+
+	buckets := make([]storage.ClickBucket, 0)
+	for cursor.Next(ctx) {
 		var doc struct {
-			ID int64 `bson:"user_id"` // Извлекаем user_id как int64
+			Start  int64 `bson:"_id"`
+			Clicks int64 `bson:"clicks"`
 		}
-		err := collectionUsers.FindOne(sc, bson.M{"nickname": nickname}).Decode(&doc)
-		if err == mongo.ErrNoDocuments {
-			return storage.ErrUserNotFound
-		} else if err != nil {
-			return fmt.Errorf("%s: find user: %w", op, err)
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: decode document: %w", op, err)
+		}
+		buckets = append(buckets, storage.ClickBucket{Start: time.UnixMilli(doc.Start).UTC(), Clicks: doc.Clicks})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate cursor: %w", op, err)
+	}
+
+	return buckets, nil
+}
+
+// LinkStats возвращает статистику всех ссылок пользователя одной агрегацией.
+// sort - storage.StatsSortClicks (по убыванию переходов) или по умолчанию от новых к старым.
+func (s *Storage) LinkStats(ctx context.Context, userID int64, sort string, limit, offset int) ([]storage.LinkStats, error) {
+	const op = "mongodb.LinkStats"
+
+	order := bson.D{{Key: "created_at", Value: -1}, {Key: "alias", Value: 1}}
+	if sort == storage.StatsSortClicks {
+		order = bson.D{{Key: "clicks", Value: -1}, {Key: "alias", Value: 1}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "deleted_at": nil}}},
+		// Переходы сворачиваются в счётчик внутри $lookup, а не подтягиваются в документ целиком
+		{{Key: "$lookup", Value: bson.M{
+			"from": "clicks",
+			"let":  bson.M{"key": "$" + s.aliasField()},
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"$expr": bson.M{"$eq": bson.A{"$alias", "$$key"}}}}},
+				{{Key: "$group", Value: bson.M{
+					"_id":           nil,
+					"count":         bson.M{"$sum": 1},
+					"last_click_at": bson.M{"$max": "$clicked_at"},
+				}}},
+			},
+			"as": "summary",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"alias":         1,
+			"description":   1,
+			"created_at":    1,
+			"clicks":        bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$summary.count", 0}}, 0}},
+			"last_click_at": bson.M{"$arrayElemAt": bson.A{"$summary.last_click_at", 0}},
+		}}},
+		{{Key: "$sort", Value: order}},
+		{{Key: "$skip", Value: int64(offset)}},
+		{{Key: "$limit", Value: int64(limit)}},
+	}
+
+	cursor, err := s.database().Collection("urls").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("%s: aggregate: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+
+	stats := make([]storage.LinkStats, 0)
+	for cursor.Next(ctx) {
+		var doc struct {
+			Alias       string     `bson:"alias"`
+			Description string     `bson:"description"`
+			CreatedAt   time.Time  `bson:"created_at"`
+			Clicks      int64      `bson:"clicks"`
+			LastClickAt *time.Time `bson:"last_click_at"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: decode document: %w", op, err)
+		}
+		stats = append(stats, storage.LinkStats{
+			Alias:       doc.Alias,
+			Description: doc.Description,
+			Clicks:      doc.Clicks,
+			CreatedAt:   doc.CreatedAt.UTC(),
+			LastClickAt: doc.LastClickAt,
+		})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate cursor: %w", op, err)
+	}
+
+	return stats, nil
+}
+
+// GetTagCounts возвращает метки живых ссылок пользователя с числом ссылок, от частых к редким.
+// limit <= 0 - без ограничения.
+func (s *Storage) GetTagCounts(ctx context.Context, userID int64, limit int) ([]storage.TagCount, error) {
+	const op = "mongodb.GetTagCounts"
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "deleted_at": nil}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: int64(limit)}})
+	}
+
+	cursor, err := s.database().Collection("urls").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("%s: aggregate: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+
+	counts := make([]storage.TagCount, 0)
+	for cursor.Next(ctx) {
+		var doc struct {
+			Tag   string `bson:"_id"`
+			Count int64  `bson:"count"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: decode document: %w", op, err)
+		}
+		counts = append(counts, storage.TagCount{Tag: doc.Tag, Count: doc.Count})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate cursor: %w", op, err)
+	}
+
+	return counts, nil
+}
+
+// loginEventDocument - попытка входа пользователя
+type loginEventDocument struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    int64              `bson:"user_id"`
+	At        time.Time          `bson:"at"`
+	IP        string             `bson:"ip"`
+	UserAgent string             `bson:"user_agent"`
+	Result    string             `bson:"result"`
+}
+
+// RecordLoginEvent сохраняет попытку входа пользователя. У пользователя остаются
+// только keep последних событий; keep <= 0 - хранятся все.
+func (s *Storage) RecordLoginEvent(ctx context.Context, userID int64, event storage.LoginEvent, keep int) error {
+	const op = "mongodb.RecordLoginEvent"
+
+	collection := s.database().Collection("login_events")
+
+	_, err := collection.InsertOne(ctx, loginEventDocument{
+		UserID:    userID,
+		At:        event.At.UTC(),
+		IP:        event.IP,
+		UserAgent: event.UserAgent,
+		Result:    event.Result,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: insert document: %w", op, err)
+	}
+
+	if keep <= 0 {
+		return nil
+	}
+
+	// Всё, что старше keep последних событий, удаляется
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID}, options.Find().
+		SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(keep)).
+		SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: find old events: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+
+	var old []loginEventDocument
+	if err := cursor.All(ctx, &old); err != nil {
+		return fmt.Errorf("%s: decode old events: %w", op, err)
+	}
+	if len(old) == 0 {
+		return nil
+	}
+
+	ids := make(bson.A, 0, len(old))
+	for _, doc := range old {
+		ids = append(ids, doc.ID)
+	}
+	if _, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return fmt.Errorf("%s: delete old events: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetLoginEvents возвращает страницу попыток входа пользователя, от новых к старым
+func (s *Storage) GetLoginEvents(ctx context.Context, userID int64, limit, offset int) ([]storage.LoginEvent, error) {
+	const op = "mongodb.GetLoginEvents"
+
+	cursor, err := s.database().Collection("login_events").Find(ctx, bson.M{"user_id": userID}, options.Find().
+		SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: find documents: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+
+	events := make([]storage.LoginEvent, 0)
+	for cursor.Next(ctx) {
+		var doc loginEventDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: decode document: %w", op, err)
+		}
+		events = append(events, storage.LoginEvent{
+			At:        doc.At.UTC(),
+			IP:        doc.IP,
+			UserAgent: doc.UserAgent,
+			Result:    doc.Result,
+		})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate cursor: %w", op, err)
+	}
+
+	return events, nil
+}
+
+// ExtendURLExpiry продлевает срок действия ссылки пользователя и её дополнительных alias.
+// Ошибки те же, что у SQLite: ErrURLNotFound, ErrUnauthorized, ErrURLNoExpiry, ErrURLExpired.
+func (s *Storage) ExtendURLExpiry(ctx context.Context, alias string, userID int64, expiresAt time.Time) error {
+	const op = "mongodb.ExtendURLExpiry"
+
+	collection := s.database().Collection("urls")
+
+	var doc urlDocument
+	err := collection.FindOne(ctx, s.liveFilter(alias)).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return storage.ErrURLNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: find document: %w", op, err)
+	}
+	if doc.UserID != userID {
+		return storage.ErrUnauthorized
+	}
+
+	if doc.ExpiresAt == nil {
+		return storage.ErrURLNoExpiry
+	}
+	if storage.Expired(doc.ExpiresAt, time.Now()) {
+		return storage.ErrURLExpired
+	}
+
+	update := bson.M{"$set": bson.M{"expires_at": expiresAt.UTC()}}
+	if _, err := collection.UpdateMany(ctx, s.linkedFilter(alias, doc.Alias, userID), update); err != nil {
+		return fmt.Errorf("%s: update document: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetURLVisibility делает ссылку пользователя и её дополнительные alias публичными или приватными.
+// ErrURLNotFound - ссылки нет, ErrUnauthorized - она чужая.
+func (s *Storage) SetURLVisibility(ctx context.Context, alias string, isPublic bool, userID int64) error {
+	const op = "mongodb.SetURLVisibility"
+
+	collection := s.database().Collection("urls")
+
+	var doc struct {
+		Alias  string `bson:"alias"`
+		UserID int64  `bson:"user_id"`
+	}
+	err := collection.FindOne(ctx, s.liveFilter(alias)).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return storage.ErrURLNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: find document: %w", op, err)
+	}
+	if doc.UserID != userID {
+		return storage.ErrUnauthorized
+	}
+
+	update := bson.M{"$set": bson.M{"is_public": isPublic}}
+	if _, err := collection.UpdateMany(ctx, s.linkedFilter(alias, doc.Alias, userID), update); err != nil {
+		return fmt.Errorf("%s: update document: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateURLDescription меняет описание ссылки владельца; пустое описание удаляет его
+func (s *Storage) UpdateURLDescription(ctx context.Context, alias string, userID int64, description string) error {
+	const op = "mongodb.UpdateURLDescription"
+
+	collection := s.database().Collection("urls")
+
+	var doc struct {
+		UserID int64 `bson:"user_id"`
+	}
+	err := collection.FindOne(ctx, s.liveFilter(alias)).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return storage.ErrURLNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: find document: %w", op, err)
+	}
+	if doc.UserID != userID {
+		return storage.ErrUnauthorized
+	}
+
+	update := bson.M{"$set": bson.M{"description": description}}
+	if description == "" {
+		update = bson.M{"$unset": bson.M{"description": ""}}
+	}
+	if _, err := collection.UpdateOne(ctx, s.liveFilter(alias), update); err != nil {
+		return fmt.Errorf("%s: update document: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetURLPreview сохраняет метаданные превью ссылки владельца, заменяя прежние
+func (s *Storage) SetURLPreview(ctx context.Context, alias string, userID int64, preview storage.Preview) error {
+	const op = "mongodb.SetURLPreview"
+
+	collection := s.database().Collection("urls")
+
+	var doc struct {
+		UserID int64 `bson:"user_id"`
+	}
+	err := collection.FindOne(ctx, s.liveFilter(alias)).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return storage.ErrURLNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: find document: %w", op, err)
+	}
+	if doc.UserID != userID {
+		return storage.ErrUnauthorized
+	}
+
+	update := bson.M{"$set": bson.M{"preview": previewDocument{
+		Title:       preview.Title,
+		Description: preview.Description,
+		Image:       preview.Image,
+		FetchedAt:   preview.FetchedAt.UTC(),
+	}}}
+	if _, err := collection.UpdateOne(ctx, s.liveFilter(alias), update); err != nil {
+		return fmt.Errorf("%s: update document: %w", op, err)
+	}
+
+	return nil
+}
+
+// prefixDocument - префикс alias, закреплённый за пользователем
+type prefixDocument struct {
+	Prefix    string    `bson:"prefix"`
+	UserID    int64     `bson:"user_id"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// ClaimPrefix закрепляет префикс alias за пользователем.
+// Ошибки те же, что у SQLite: ErrPrefixTaken, ErrTooManyPrefixes.
+func (s *Storage) ClaimPrefix(ctx context.Context, prefix string, userID int64, maxPerUser int) error {
+	const op = "mongodb.ClaimPrefix"
+
+	collection := s.database().Collection("alias_prefixes")
+	prefix = storage.PrefixKey(prefix)
+
+	overlapping, err := s.overlappingPrefixes(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for _, doc := range overlapping {
+		if doc.UserID != userID {
+			return storage.ErrPrefixTaken
+		}
+		if doc.Prefix == prefix {
+			return nil
+		}
+	}
+
+	if maxPerUser > 0 {
+		owned, err := collection.CountDocuments(ctx, bson.M{"user_id": userID})
+		if err != nil {
+			return fmt.Errorf("%s: count user prefixes: %w", op, err)
+		}
+		if owned >= int64(maxPerUser) {
+			return storage.ErrTooManyPrefixes
+		}
+	}
+
+	res, err := collection.InsertOne(ctx, prefixDocument{Prefix: prefix, UserID: userID, CreatedAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("%s: insert prefix: %w", op, err)
+	}
+
+	// Транзакций нет, поэтому пересечение проверяется ещё раз после вставки. Из двух
+	// параллельных пересекающихся захватов хотя бы второй увидит первый и отменит свой:
+	// оба остаться не могут, хотя отказ могут получить оба.
+	overlapping, err = s.overlappingPrefixes(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for _, doc := range overlapping {
+		if doc.UserID == userID {
+			continue
+		}
+		if _, err := collection.DeleteOne(ctx, bson.M{"_id": res.InsertedID}); err != nil {
+			return fmt.Errorf("%s: withdraw prefix: %w", op, err)
+		}
+		return storage.ErrPrefixTaken
+	}
+
+	return nil
+}
+
+// overlappingPrefixes возвращает занятые префиксы, пересекающиеся с prefix: более короткие,
+// с которых он начинается, и более длинные, начинающиеся с него
+func (s *Storage) overlappingPrefixes(ctx context.Context, prefix string) ([]prefixDocument, error) {
+	cursor, err := s.database().Collection("alias_prefixes").Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"prefix": bson.M{"$in": storage.PrefixCandidates(prefix)}},
+		bson.M{"prefix": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}},
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("find prefixes: %w", err)
+	}
+
+	var overlapping []prefixDocument
+	if err := cursor.All(ctx, &overlapping); err != nil {
+		return nil, fmt.Errorf("decode prefixes: %w", err)
+	}
+
+	return overlapping, nil
+}
+
+// prefixConflict возвращает ErrPrefixTaken, если alias попадает под префикс другого пользователя
+func (s *Storage) prefixConflict(ctx context.Context, alias string, userID int64) error {
+	ownerID, err := s.GetPrefixOwner(ctx, alias)
+	if errors.Is(err, storage.ErrPrefixNotFound) || (err == nil && ownerID == userID) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return storage.ErrPrefixTaken
+}
+
+// GetPrefixOwner возвращает владельца префикса, под который попадает alias.
+// ErrPrefixNotFound - alias не начинается ни с одного занятого префикса.
+func (s *Storage) GetPrefixOwner(ctx context.Context, alias string) (int64, error) {
+	const op = "mongodb.GetPrefixOwner"
+
+	var doc prefixDocument
+	err := s.database().Collection("alias_prefixes").
+		FindOne(ctx, bson.M{"prefix": bson.M{"$in": storage.PrefixCandidates(alias)}}).
+		Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, storage.ErrPrefixNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: find prefix: %w", op, err)
+	}
+
+	return doc.UserID, nil
+}
+
+// SetURLTags заменяет метки ссылки. Итоговый набор вычисляет SQLite, сюда он приходит готовым.
+func (s *Storage) SetURLTags(ctx context.Context, alias string, tags []string) error {
+	const op = "mongodb.SetURLTags"
+
+	res, err := s.database().Collection("urls").UpdateOne(ctx, s.liveFilter(alias), bson.M{"$set": bson.M{"tags": tags}})
+	if err != nil {
+		return fmt.Errorf("%s: update document: %w", op, err)
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+	}
+
+	return nil
+}
+
+// UpdateURLTags меняет метки нескольких ссылок пользователя. Используется,
+// когда MongoDB - единственное хранилище; ссылки обновляются по одной, без транзакции.
+func (s *Storage) UpdateURLTags(ctx context.Context, aliases []string, userID int64, add, remove []string, maxTags int) (map[string]storage.TagUpdate, error) {
+	const op = "mongodb.UpdateURLTags"
+
+	results := make(map[string]storage.TagUpdate, len(aliases))
+	for _, alias := range aliases {
+		link, err := s.GetLink(ctx, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			results[alias] = storage.TagUpdate{Err: storage.ErrURLNotFound}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if link.UserID != userID {
+			results[alias] = storage.TagUpdate{Err: storage.ErrUnauthorized}
+			continue
+		}
+
+		merged := storage.MergeTags(link.Tags, add, remove)
+		if maxTags > 0 && len(merged) > maxTags {
+			results[alias] = storage.TagUpdate{Tags: link.Tags, Err: storage.ErrTooManyTags}
+			continue
+		}
+
+		if err := s.SetURLTags(ctx, alias, merged); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		results[alias] = storage.TagUpdate{Tags: merged}
+	}
+
+	return results, nil
+}
+
+// NextUserID выдаёт следующий идентификатор пользователя из счётчика в коллекции counters.
+// Нужен, когда MongoDB - единственное хранилище и id не приходит из SQLite.
+func (s *Storage) NextUserID(ctx context.Context) (int64, error) {
+	const op = "mongodb.NextUserID"
+
+	var doc struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := s.database().Collection("counters").FindOneAndUpdate(ctx,
+		bson.M{"_id": "user_id"},
+		bson.M{"$inc": bson.M{"seq": int64(1)}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		return 0, fmt.Errorf("%s: increment counter: %w", op, err)
+	}
+
+	return doc.Seq, nil
+}
+
+// SaveUser сохраняет нового пользователя в MongoDB
+func (s *Storage) SaveUser(ctx context.Context, nickname, passwordHash string, userID int64) (interface{}, error) {
+	const op = "mongodb.SaveUser"
+
+	collection := s.database().Collection("users")
+
+	doc := bson.M{
+		"nickname":      s.nicknameKey(nickname),
+		"display_name":  nickname,
+		"password_hash": passwordHash,
+		"user_id":       userID,
+		"created_at":    time.Now().UTC(),
+	}
+
+	// Проверка на существование пользователя
+	count, err := collection.CountDocuments(ctx, bson.M{"nickname": s.nicknameKey(nickname)})
+	if err != nil {
+		return nil, fmt.Errorf("%s: count documents: %w", op, err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrUserExists)
+	}
+
+	// Вставка нового пользователя
+	res, err := collection.InsertOne(ctx, doc)
+	if dup := duplicateError(err); dup != nil {
+		return nil, fmt.Errorf("%s: %w", op, dup)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: insert document: %w", op, err)
+	}
+
+	// id, выданный SQLite, не должен повториться, если основной базой станет MongoDB
+	_, err = s.database().Collection("counters").UpdateOne(ctx,
+		bson.M{"_id": "user_id"},
+		bson.M{"$max": bson.M{"seq": userID}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: update counter: %w", op, err)
+	}
+
+	return res.InsertedID, nil
+}
+
+// SeedUserID сдвигает счётчик id пользователей не ниже floor и наибольшего user_id в коллекции,
+// чтобы NextUserID не выдал id существующего пользователя. Вызывается при запуске:
+// пользователи, созданные до появления счётчика, его не двигали.
+func (s *Storage) SeedUserID(ctx context.Context, floor int64) error {
+	const op = "mongodb.SeedUserID"
+
+	var doc struct {
+		UserID int64 `bson:"user_id"`
+	}
+	err := s.database().Collection("users").FindOne(ctx, bson.M{},
+		options.FindOne().SetSort(bson.D{{Key: "user_id", Value: -1}}).SetProjection(bson.M{"user_id": 1}),
+	).Decode(&doc)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("%s: find max user id: %w", op, err)
+	}
+	if doc.UserID > floor {
+		floor = doc.UserID
+	}
+
+	_, err = s.database().Collection("counters").UpdateOne(ctx,
+		bson.M{"_id": "user_id"},
+		bson.M{"$max": bson.M{"seq": floor}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: update counter: %w", op, err)
+	}
+
+	return nil
+}
+
+// DiscardUser удаляет только что созданного пользователя, когда его не приняла вторая база.
+// Документ ищется по _id, который вернул SaveUser: по user_id можно задеть чужой документ.
+func (s *Storage) DiscardUser(ctx context.Context, insertedID interface{}) error {
+	const op = "mongodb.DiscardUser"
+
+	if _, err := s.database().Collection("users").DeleteOne(ctx, bson.M{"_id": insertedID}); err != nil {
+		return fmt.Errorf("%s: delete document: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetUserSettings получает настройки пользователя
+func (s *Storage) GetUserSettings(ctx context.Context, userID int64) (storage.UserSettings, error) {
+	const op = "mongodb.GetUserSettings"
+
+	collection := s.database().Collection("users")
+
+	var doc struct {
+		DefaultAliasLength    *int   `bson:"default_alias_length"`
+		DefaultRedirectStatus *int   `bson:"default_redirect_status"`
+		DefaultTTLSeconds     *int64 `bson:"default_ttl_seconds"`
+		AllowDuplicateURLs    *bool  `bson:"allow_duplicate_urls"`
+		RedirectTemplate      string `bson:"redirect_template"`
+	}
+
+	err := collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return storage.UserSettings{}, storage.ErrUserNotFound
+	} else if err != nil {
+		return storage.UserSettings{}, fmt.Errorf("%s: find document: %w", op, err)
+	}
+
+	return storage.UserSettings{
+		DefaultAliasLength:    doc.DefaultAliasLength,
+		DefaultRedirectStatus: doc.DefaultRedirectStatus,
+		DefaultTTLSeconds:     doc.DefaultTTLSeconds,
+		AllowDuplicateURLs:    doc.AllowDuplicateURLs,
+		RedirectTemplate:      doc.RedirectTemplate,
+	}, nil
+}
+
+// SaveUserSettings сохраняет настройки пользователя целиком
+func (s *Storage) SaveUserSettings(ctx context.Context, userID int64, settings storage.UserSettings) error {
+	const op = "mongodb.SaveUserSettings"
+
+	collection := s.database().Collection("users")
+
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"user_id": userID},
+		bson.M{"$set": bson.M{
+			"default_alias_length":    settings.DefaultAliasLength,
+			"default_redirect_status": settings.DefaultRedirectStatus,
+			"default_ttl_seconds":     settings.DefaultTTLSeconds,
+			"allow_duplicate_urls":    settings.AllowDuplicateURLs,
+			"redirect_template":       settings.RedirectTemplate,
+		}},
+	)
+	if err != nil {
+		return fmt.Errorf("%s: update document: %w", op, err)
+	}
+	if res.MatchedCount == 0 {
+		return storage.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// GetUserByNickname получает пользователя по никнейму
+func (s *Storage) GetUserByNickname(ctx context.Context, nickname string) (int64, string, error) {
+	const op = "mongodb.GetUserByNickname"
+
+	collection := s.database().Collection("users")
+
+	var doc struct {
+		UserID       int64  `bson:"user_id"`
+		PasswordHash string `bson:"password_hash"`
+	}
+
+	err := collection.FindOne(ctx, bson.M{"nickname": s.nicknameKey(nickname)}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return 0, "", storage.ErrUserNotFound
+	} else if err != nil {
+		return 0, "", fmt.Errorf("%s: find document: %w", op, err)
+	}
+
+	return doc.UserID, doc.PasswordHash, nil
+}
+
+// GetUser получает профиль пользователя (без хэша пароля)
+func (s *Storage) GetUser(ctx context.Context, nickname string) (storage.User, error) {
+	const op = "mongodb.GetUser"
+
+	collection := s.database().Collection("users")
+
+	var doc userDocument
+	err := collection.FindOne(ctx, bson.M{"nickname": s.nicknameKey(nickname)}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return storage.User{}, storage.ErrUserNotFound
+	} else if err != nil {
+		return storage.User{}, fmt.Errorf("%s: find document: %w", op, err)
+	}
+
+	return storage.User{
+		ID:        doc.UserID,
+		Nickname:  doc.displayName(),
+		CreatedAt: doc.CreatedAt,
+	}, nil
+}
+
+// userDocument - профиль из коллекции users
+type userDocument struct {
+	UserID      int64     `bson:"user_id"`
+	Nickname    string    `bson:"nickname"`
+	DisplayName string    `bson:"display_name"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+// displayName - никнейм для показа. У документов, сохранённых до появления
+// display_name, никнейм хранится только в nickname.
+func (d userDocument) displayName() string {
+	if d.DisplayName != "" {
+		return d.DisplayName
+	}
+
+	return d.Nickname
+}
+
+// redirectStatus подставляет 302 Found, если код ответа не задан
+// (в том числе для документов, сохранённых до появления поля)
+func redirectStatus(status int) int {
+	if status == 0 {
+		return http.StatusFound
+	}
+
+	return status
+}
+
+// urlDocument - документ коллекции urls
+type urlDocument struct {
+	Alias          string           `bson:"alias"`
+	URL            string           `bson:"url"`
+	UserID         int64            `bson:"user_id"`
+	Domain         string           `bson:"domain"`
+	CreatedAt      time.Time        `bson:"created_at"`
+	ExpiresAt      *time.Time       `bson:"expires_at"`
+	Public         bool             `bson:"is_public"`
+	Wildcard       bool             `bson:"wildcard"`
+	ForwardQuery   bool             `bson:"forward_query"`
+	RedirectStatus int              `bson:"redirect_status"`
+	Tags           []string         `bson:"tags"`
+	LastAccessedAt *time.Time       `bson:"last_accessed_at"`
+	DeletedAt      *time.Time       `bson:"deleted_at"`
+	LastStatus     *int             `bson:"last_status"`
+	LastCheckedAt  *time.Time       `bson:"last_checked_at"`
+	LinkedTo       string           `bson:"linked_to,omitempty"`
+	Preview        *previewDocument `bson:"preview,omitempty"`
+	Description    string           `bson:"description,omitempty"`
+}
+
+// previewDocument - метаданные превью ссылки
+type previewDocument struct {
+	Title       string    `bson:"title"`
+	Description string    `bson:"description"`
+	Image       string    `bson:"image"`
+	FetchedAt   time.Time `bson:"fetched_at"`
+}
+
+func (d urlDocument) toURL() storage.URL {
+	var preview *storage.Preview
+	if d.Preview != nil {
+		preview = &storage.Preview{
+			Title:       d.Preview.Title,
+			Description: d.Preview.Description,
+			Image:       d.Preview.Image,
+			FetchedAt:   d.Preview.FetchedAt,
+		}
+	}
+
+	return storage.URL{
+		Alias:          d.Alias,
+		URL:            d.URL,
+		Domain:         d.Domain,
+		CreatedAt:      d.CreatedAt,
+		ExpiresAt:      d.ExpiresAt,
+		Public:         d.Public,
+		Wildcard:       d.Wildcard,
+		ForwardQuery:   d.ForwardQuery,
+		RedirectStatus: redirectStatus(d.RedirectStatus),
+		Tags:           d.Tags,
+		LastAccessedAt: d.LastAccessedAt,
+		DeletedAt:      d.DeletedAt,
+		LastStatus:     d.LastStatus,
+		LastCheckedAt:  d.LastCheckedAt,
+		LinkedTo:       d.LinkedTo,
+		Preview:        preview,
+		Description:    d.Description,
+		UserID:         d.UserID,
+	}
+}
+
+// GetURLsByUser получает все URL пользователя
+func (s *Storage) GetURLsByUser(ctx context.Context, userID int64) ([]storage.URL, error) {
+	const op = "mongodb.GetURLsByUser"
+
+	collection := s.database().Collection("urls")
+
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID, "deleted_at": nil})
+	if err != nil {
+		return nil, fmt.Errorf("%s: find documents: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+
+	urls := make([]storage.URL, 0)
+	for cursor.Next(ctx) {
+		var doc urlDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: decode document: %w", op, err)
+		}
+		urls = append(urls, doc.toURL())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate cursor: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// GetAliasByURL ищет alias, под которым пользователь уже сократил адрес. Из нескольких
+// действующих ссылок возвращается самая ранняя; ссылки в корзине и истёкшие не учитываются.
+func (s *Storage) GetAliasByURL(ctx context.Context, url string, userID int64) (string, error) {
+	const op = "mongodb.GetAliasByURL"
+
+	cursor, err := s.database().Collection("urls").Find(ctx,
+		bson.M{"url": url, "user_id": userID, "deleted_at": nil},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return "", fmt.Errorf("%s: find documents: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+
+	now := time.Now()
+	for cursor.Next(ctx) {
+		var doc urlDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return "", fmt.Errorf("%s: decode document: %w", op, err)
+		}
+		if storage.Expired(doc.ExpiresAt, now) {
+			continue
+		}
+
+		return doc.Alias, nil
+	}
+	if err := cursor.Err(); err != nil {
+		return "", fmt.Errorf("%s: iterate cursor: %w", op, err)
+	}
+
+	return "", storage.ErrURLNotFound
+}
+
+// GetDeletedURLsByUser получает ссылки пользователя из корзины, от недавно удалённых к давним
+func (s *Storage) GetDeletedURLsByUser(ctx context.Context, userID int64) ([]storage.URL, error) {
+	const op = "mongodb.GetDeletedURLsByUser"
+
+	collection := s.database().Collection("urls")
+
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID, "deleted_at": bson.M{"$ne": nil}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: find documents: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+
+	urls := make([]storage.URL, 0)
+	for cursor.Next(ctx) {
+		var doc urlDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: decode document: %w", op, err)
+		}
+		urls = append(urls, doc.toURL())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate cursor: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// GetBrokenURLsByUser получает ссылки пользователя, цель которых не ответила или ответила
+// ошибкой при последней фоновой проверке. Сначала недавно проверенные.
+func (s *Storage) GetBrokenURLsByUser(ctx context.Context, userID int64) ([]storage.URL, error) {
+	const op = "mongodb.GetBrokenURLsByUser"
+
+	collection := s.database().Collection("urls")
+
+	filter := bson.M{
+		"user_id":    userID,
+		"deleted_at": nil,
+		"$or": bson.A{
+			bson.M{"last_status": 0},
+			bson.M{"last_status": bson.M{"$gte": 400}},
+		},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "last_checked_at", Value: -1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: find documents: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+
+	urls := make([]storage.URL, 0)
+	for cursor.Next(ctx) {
+		var doc urlDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: decode document: %w", op, err)
+		}
+		urls = append(urls, doc.toURL())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate cursor: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// GetURLsToCheck получает ссылки всех пользователей для фоновой проверки: не проверявшиеся
+// или проверенные раньше checkedBefore, начиная с давно не проверявшихся
+func (s *Storage) GetURLsToCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]storage.URL, error) {
+	const op = "mongodb.GetURLsToCheck"
+
+	collection := s.database().Collection("urls")
+
+	filter := bson.M{
+		"deleted_at": nil,
+		"$or": bson.A{
+			bson.M{"last_checked_at": nil},
+			bson.M{"last_checked_at": bson.M{"$lt": checkedBefore.UTC()}},
+		},
+	}
+
+	// null при сортировке по возрастанию идёт первым - непроверенные ссылки попадают в пачку раньше
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_checked_at", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: find documents: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+
+	urls := make([]storage.URL, 0)
+	for cursor.Next(ctx) {
+		var doc urlDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: decode document: %w", op, err)
+		}
+		urls = append(urls, doc.toURL())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate cursor: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// SetURLHealth сохраняет результат фоновой проверки ссылки. status == nil - проверка
+// пропущена: обновляется только время, статус остаётся прежним.
+func (s *Storage) SetURLHealth(ctx context.Context, alias string, status *int, checkedAt time.Time) error {
+	const op = "mongodb.SetURLHealth"
+
+	set := bson.M{"last_checked_at": checkedAt.UTC()}
+	if status != nil {
+		set["last_status"] = *status
+	}
+
+	_, err := s.database().Collection("urls").UpdateOne(ctx, s.liveFilter(alias), bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("%s: update document: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetURLOwner получает никнейм владельца ссылки
+func (s *Storage) GetURLOwner(ctx context.Context, alias string) (string, error) {
+	const op = "mongodb.GetURLOwner"
+
+	link, err := s.GetLink(ctx, alias)
+	if err != nil {
+		return "", err
+	}
+
+	var user userDocument
+	err = s.database().Collection("users").FindOne(ctx, bson.M{"user_id": link.UserID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return "", storage.ErrURLNotFound
+	} else if err != nil {
+		return "", fmt.Errorf("%s: find document: %w", op, err)
+	}
+
+	return user.displayName(), nil
+}
+
+// CountURLsByUser считает ссылки пользователя
+func (s *Storage) CountURLsByUser(ctx context.Context, userID int64) (int64, error) {
+	const op = "mongodb.CountURLsByUser"
+
+	count, err := s.database().Collection("urls").CountDocuments(ctx, bson.M{"user_id": userID, "deleted_at": nil})
+	if err != nil {
+		return 0, fmt.Errorf("%s: count documents: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// GetStaleURLs получает ссылки пользователя, по которым не переходили с момента olderThan.
+// Ссылка без переходов считается неиспользуемой, если создана раньше olderThan.
+func (s *Storage) GetStaleURLs(ctx context.Context, userID int64, olderThan time.Time) ([]storage.URL, error) {
+	const op = "mongodb.GetStaleURLs"
+
+	collection := s.database().Collection("urls")
+
+	filter := bson.M{
+		"user_id":    userID,
+		"deleted_at": nil,
+		"$or": bson.A{
+			bson.M{"last_accessed_at": bson.M{"$lt": olderThan.UTC()}},
+			bson.M{"last_accessed_at": nil, "created_at": bson.M{"$lt": olderThan.UTC()}},
+		},
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: find documents: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+
+	urls := make([]storage.URL, 0)
+	for cursor.Next(ctx) {
+		var doc urlDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: decode document: %w", op, err)
+		}
+		urls = append(urls, doc.toURL())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate cursor: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// GetURLs получает несколько ссылок пользователя одним запросом
+func (s *Storage) GetURLs(ctx context.Context, aliases []string, userID int64) ([]storage.URL, error) {
+	const op = "mongodb.GetURLs"
+
+	if len(aliases) == 0 {
+		return []storage.URL{}, nil
+	}
+
+	collection := s.database().Collection("urls")
+
+	keys := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		keys = append(keys, s.aliasKey(alias))
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{s.aliasField(): bson.M{"$in": keys}, "user_id": userID, "deleted_at": nil})
+	if err != nil {
+		return nil, fmt.Errorf("%s: find documents: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+
+	urls := make([]storage.URL, 0, len(aliases))
+	for cursor.Next(ctx) {
+		var doc urlDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: decode document: %w", op, err)
+		}
+		urls = append(urls, doc.toURL())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate cursor: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// GetLink получает ссылку по alias без проверки владельца
+func (s *Storage) GetLink(ctx context.Context, alias string) (storage.URL, error) {
+	const op = "mongodb.GetLink"
+
+	collection := s.database().Collection("urls")
+
+	var doc urlDocument
+	err := collection.FindOne(ctx, s.liveFilter(alias)).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return storage.URL{}, storage.ErrURLNotFound
+	} else if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: find document: %w", op, err)
+	}
+
+	return doc.toURL(), nil
+}
+
+// TransferURLs передаёт все ссылки пользователя, в том числе из корзины, другому пользователю.
+// Возвращает число переданных ссылок.
+func (s *Storage) TransferURLs(ctx context.Context, fromUserID, toUserID int64) (int64, error) {
+	const op = "mongodb.TransferURLs"
+
+	res, err := s.database().Collection("urls").UpdateMany(ctx,
+		bson.M{"user_id": fromUserID},
+		bson.M{"$set": bson.M{"user_id": toUserID}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: update documents: %w", op, err)
+	}
+
+	return res.ModifiedCount, nil
+}
+
+// DeleteUserByNickname удаляет пользователя и все связанные URL
+func (s *Storage) DeleteUserByNickname(ctx context.Context, nickname string) error {
+	const op = "mongodb.DeleteUserByNickname"
+
+	// Начинаем транзакцию
+	session, err := s.database().Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("%s: start session: %w", op, err)
+	}
+	defer session.EndSession(ctx)
+
+	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		collectionUsers := s.database().Collection("users")
+		collectionURLs := s.database().Collection("urls")
+
+		// Находим пользователя
+		var doc struct {
+			ID int64 `bson:"user_id"` // Извлекаем user_id как int64
+		}
+		err := collectionUsers.FindOne(sc, bson.M{"nickname": s.nicknameKey(nickname)}).Decode(&doc)
+		if err == mongo.ErrNoDocuments {
+			return storage.ErrUserNotFound
+		} else if err != nil {
+			return fmt.Errorf("%s: find user: %w", op, err)
+		}
+
+		// Освобождаем занятые пользователем префиксы
+		if _, err := s.database().Collection("alias_prefixes").DeleteMany(sc, bson.M{"user_id": doc.ID}); err != nil {
+			return fmt.Errorf("%s: delete prefixes: %w", op, err)
+		}
+
+		if _, err := s.database().Collection("login_events").DeleteMany(sc, bson.M{"user_id": doc.ID}); err != nil {
+			return fmt.Errorf("%s: delete login events: %w", op, err)
 		}
 
 		// Удаляем все URL, связанные с пользователем
@@ -5,169 +5,995 @@ import (
 	"errors"
 	"fmt"
 	"golang.org/x/exp/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
 	"url-shortener/internal/storage/mongodb"
 	"url-shortener/internal/storage/sqlite"
 )
 
+// sqliteStore - методы SQLite, которыми пользуется DualStorage
+type sqliteStore interface {
+	Ping(ctx context.Context) error
+	SaveURL(urlToSave, alias string, userID int64, opts storage.URLOptions) error
+	SaveURLWithSequenceAlias(urlToSave string, userID int64, opts storage.URLOptions, aliasFor func(id int64) (string, bool)) (string, error)
+	GetURL(alias string, userID int64) (string, error)
+	AliasExists(alias string) (bool, error)
+	DeleteURL(alias string, userID int64) error
+	RestoreURL(alias string, userID int64, deletedAfter time.Time) error
+	PurgeDeletedURLs(userID int64) (int64, error)
+	PurgeExpiredURLs(deletedBefore time.Time) (int64, error)
+	TransferURLs(fromUserID, toUserID int64) (int64, error)
+	RenameAlias(alias, newAlias string, userID int64) error
+	GetDeletedURLsByUser(userID int64) ([]storage.URL, error)
+	GetBrokenURLsByUser(userID int64) ([]storage.URL, error)
+	GetURLsToCheck(checkedBefore time.Time, limit int) ([]storage.URL, error)
+	SetURLHealth(alias string, status *int, checkedAt time.Time) error
+	SaveUser(nickname, passwordHash string) (int64, error)
+	SaveUserWithID(userID int64, nickname, passwordHash string) error
+	DiscardUser(userID int64) error
+	MaxUserID() (int64, error)
+	DiscardURL(alias string) error
+	GetUserByNickname(nickname string) (int64, string, error)
+	GetUser(nickname string) (storage.User, error)
+	GetUserSettings(userID int64) (storage.UserSettings, error)
+	SaveUserSettings(userID int64, settings storage.UserSettings) error
+	GetURLsByUser(userID int64) ([]storage.URL, error)
+	GetStaleURLs(userID int64, olderThan time.Time) ([]storage.URL, error)
+	CountURLsByUser(userID int64) (int64, error)
+	GetURLOwner(alias string) (string, error)
+	GetAliasByURL(url string, userID int64) (string, error)
+	GetLink(alias string) (storage.URL, error)
+	GetURLs(aliases []string, userID int64) ([]storage.URL, error)
+	RecordClick(alias string, at time.Time) error
+	CountClicks(alias string) (int64, error)
+	ClickTimeseries(alias string, from, to time.Time, bucket time.Duration) ([]storage.ClickBucket, error)
+	LinkStats(userID int64, sort string, limit, offset int) ([]storage.LinkStats, error)
+	GetTagCounts(userID int64, limit int) ([]storage.TagCount, error)
+	ExtendURLExpiry(alias string, userID int64, expiresAt time.Time) error
+	SetURLVisibility(alias string, isPublic bool, userID int64) error
+	UpdateURLDescription(alias string, userID int64, description string) error
+	SetURLPreview(alias string, userID int64, preview storage.Preview) error
+	ClaimPrefix(prefix string, userID int64, maxPerUser int) error
+	UpdateURLTags(aliases []string, userID int64, add, remove []string, maxTags int) (map[string]storage.TagUpdate, error)
+	RecordLoginEvent(userID int64, event storage.LoginEvent, keep int) error
+	GetLoginEvents(userID int64, limit, offset int) ([]storage.LoginEvent, error)
+	DeleteUserByNickname(nickname string) error
+}
+
+// mongoStore - методы MongoDB, которыми пользуется DualStorage
+type mongoStore interface {
+	Ping(ctx context.Context) error
+	SaveURL(ctx context.Context, urlToSave, alias string, userID int64, opts storage.URLOptions) (interface{}, error)
+	GetURL(ctx context.Context, alias string, userID int64) (string, error)
+	AliasExists(ctx context.Context, alias string) (bool, error)
+	DeleteURL(ctx context.Context, alias string, userID int64) error
+	RestoreURL(ctx context.Context, alias string, userID int64, deletedAfter time.Time) error
+	PurgeDeletedURLs(ctx context.Context, userID int64) (int64, error)
+	PurgeExpiredURLs(ctx context.Context, deletedBefore time.Time) (int64, error)
+	TransferURLs(ctx context.Context, fromUserID, toUserID int64) (int64, error)
+	RenameAlias(ctx context.Context, alias, newAlias string, userID int64) error
+	GetDeletedURLsByUser(ctx context.Context, userID int64) ([]storage.URL, error)
+	GetBrokenURLsByUser(ctx context.Context, userID int64) ([]storage.URL, error)
+	GetURLsToCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]storage.URL, error)
+	SetURLHealth(ctx context.Context, alias string, status *int, checkedAt time.Time) error
+	SaveUser(ctx context.Context, nickname, passwordHash string, userID int64) (interface{}, error)
+	GetUserByNickname(ctx context.Context, nickname string) (int64, string, error)
+	GetUser(ctx context.Context, nickname string) (storage.User, error)
+	GetUserSettings(ctx context.Context, userID int64) (storage.UserSettings, error)
+	SaveUserSettings(ctx context.Context, userID int64, settings storage.UserSettings) error
+	GetURLsByUser(ctx context.Context, userID int64) ([]storage.URL, error)
+	GetStaleURLs(ctx context.Context, userID int64, olderThan time.Time) ([]storage.URL, error)
+	CountURLsByUser(ctx context.Context, userID int64) (int64, error)
+	GetURLOwner(ctx context.Context, alias string) (string, error)
+	GetAliasByURL(ctx context.Context, url string, userID int64) (string, error)
+	GetLink(ctx context.Context, alias string) (storage.URL, error)
+	GetURLs(ctx context.Context, aliases []string, userID int64) ([]storage.URL, error)
+	RecordClick(ctx context.Context, alias string, at time.Time) error
+	CountClicks(ctx context.Context, alias string) (int64, error)
+	ClickTimeseries(ctx context.Context, alias string, from, to time.Time, bucket time.Duration) ([]storage.ClickBucket, error)
+	LinkStats(ctx context.Context, userID int64, sort string, limit, offset int) ([]storage.LinkStats, error)
+	GetTagCounts(ctx context.Context, userID int64, limit int) ([]storage.TagCount, error)
+	ExtendURLExpiry(ctx context.Context, alias string, userID int64, expiresAt time.Time) error
+	SetURLVisibility(ctx context.Context, alias string, isPublic bool, userID int64) error
+	UpdateURLDescription(ctx context.Context, alias string, userID int64, description string) error
+	SetURLPreview(ctx context.Context, alias string, userID int64, preview storage.Preview) error
+	ClaimPrefix(ctx context.Context, prefix string, userID int64, maxPerUser int) error
+	SetURLTags(ctx context.Context, alias string, tags []string) error
+	UpdateURLTags(ctx context.Context, aliases []string, userID int64, add, remove []string, maxTags int) (map[string]storage.TagUpdate, error)
+	NextUserID(ctx context.Context) (int64, error)
+	SeedUserID(ctx context.Context, floor int64) error
+	DiscardUser(ctx context.Context, insertedID interface{}) error
+	DiscardURL(ctx context.Context, alias string) error
+	RecordLoginEvent(ctx context.Context, userID int64, event storage.LoginEvent, keep int) error
+	GetLoginEvents(ctx context.Context, userID int64, limit, offset int) ([]storage.LoginEvent, error)
+	DeleteUserByNickname(ctx context.Context, nickname string) error
+}
+
+// statusTimeout ограничивает пинг одной базы при сборе статуса
+const statusTimeout = 2 * time.Second
+
+// DualStorage - хранилище приложения. В режиме dual пишет в обе базы, начиная с основной
+// (SQLite, если не задано SetPrimary), и читает с откатом на вторую; в режимах sqlite и mongo работает с одной базой, вторая не подключается.
+//
+// Методы пишут в лог, переданный обработчиком. Он уже содержит op и request_id запроса,
+// поэтому хранилище их не добавляет: так ошибка базы связывается с исходным запросом
+// без повторяющихся полей в записи.
 type DualStorage struct {
-	sqliteDB *sqlite.Storage
-	mongoDB  *mongodb.Storage
+	// mode - один из storage.ModeDual, storage.ModeSQLite, storage.ModeMongo
+	mode string
+	// primary - основная база режима dual: storage.ModeSQLite или storage.ModeMongo
+	primary  string
+	sqliteDB sqliteStore
+	mongoDB  mongoStore
+	// degraded выставляется, когда MongoDB недоступна: запись и чтение идут только через SQLite
+	degraded atomic.Bool
+	// lastMongoWrite - время (unix nano) последней успешной записи в MongoDB или создания хранилища
+	lastMongoWrite atomic.Int64
+	// onWrite получает итог каждой записи; задаётся до начала обслуживания запросов
+	onWrite func(storage.WriteResult)
+	// strictNotFound - чтение отдаёт «не найдено», только если с этим согласны обе базы
+	strictNotFound bool
 }
 
 // NewDualStorage создает экземпляр DualStorage для двух баз данных
 func NewDualStorage(sqliteDB *sqlite.Storage, mongoDB *mongodb.Storage) *DualStorage {
-	return &DualStorage{
-		sqliteDB: sqliteDB,
-		mongoDB:  mongoDB,
+	return newStorage(storage.ModeDual, sqliteDB, mongoDB)
+}
+
+// NewSQLiteStorage создает хранилище, работающее только с SQLite
+func NewSQLiteStorage(sqliteDB *sqlite.Storage) *DualStorage {
+	return newStorage(storage.ModeSQLite, sqliteDB, nil)
+}
+
+// NewMongoStorage создает хранилище, работающее только с MongoDB
+func NewMongoStorage(mongoDB *mongodb.Storage) *DualStorage {
+	return newStorage(storage.ModeMongo, nil, mongoDB)
+}
+
+// newStorage создаёт хранилище в режиме mode. Отсутствующая база передаётся как nil
+// интерфейс, а не как nil-указатель, чтобы случайное обращение к ней сразу падало.
+func newStorage(mode string, sqliteDB sqliteStore, mongoDB mongoStore) *DualStorage {
+	ds := &DualStorage{
+		mode:           mode,
+		primary:        storage.ModeSQLite,
+		sqliteDB:       sqliteDB,
+		mongoDB:        mongoDB,
+		strictNotFound: true,
 	}
+	ds.markMongoWrite()
+
+	return ds
 }
 
-// SaveURL сохраняет URL в обе базы данных
-func (ds *DualStorage) SaveURL(ctx context.Context, log *slog.Logger, urlToSave, alias string, userID int64) error {
-	log.Info("attempting to save URL", slog.String("alias", alias), slog.Int64("userID", userID))
+// markMongoWrite отмечает успешную запись в MongoDB
+func (ds *DualStorage) markMongoWrite() {
+	ds.lastMongoWrite.Store(time.Now().UnixNano())
+}
 
-	// Сначала записываем в SQLite
-	if err := ds.sqliteDB.SaveURL(urlToSave, alias, userID); err != nil {
-		log.Error("failed to save URL in SQLite", sl.Err(err))
-		return err
+// SinceLastMongoWrite - сколько времени прошло с последней успешной записи в MongoDB
+// (до первой записи - с создания хранилища). Растущее значение при выключенном
+// деградированном режиме означает, что записи в MongoDB молча не проходят.
+func (ds *DualStorage) SinceLastMongoWrite() time.Duration {
+	return time.Since(time.Unix(0, ds.lastMongoWrite.Load()))
+}
+
+func (ds *DualStorage) secondsSinceLastMongoWrite() *float64 {
+	seconds := ds.SinceLastMongoWrite().Seconds()
+	return &seconds
+}
+
+// Mode возвращает настроенный режим хранилища
+func (ds *DualStorage) Mode() string {
+	return ds.mode
+}
+
+// mongoOnly сообщает, что SQLite не используется и все запросы идут в MongoDB
+func (ds *DualStorage) mongoOnly() bool {
+	return ds.mode == storage.ModeMongo
+}
+
+// mongoSkipped сообщает, что после SQLite обращаться к MongoDB не нужно:
+// она не подключена (режим sqlite) или недоступна (деградированный режим)
+func (ds *DualStorage) mongoSkipped() bool {
+	return ds.mode == storage.ModeSQLite || ds.Degraded()
+}
+
+// warnDegraded предупреждает, что операция выполнена только в SQLite.
+// В режиме sqlite это штатное поведение, и предупреждение не пишется.
+func (ds *DualStorage) warnDegraded(log *slog.Logger, msg string, args ...any) {
+	if ds.mode == storage.ModeDual {
+		log.Warn("degraded mode: "+msg, args...)
 	}
+}
 
-	// Затем записываем в MongoDB
-	if _, err := ds.mongoDB.SaveURL(ctx, urlToSave, alias, userID); err != nil {
-		log.Error("failed to save URL in MongoDB", sl.Err(err))
-		return err
+// SetWriteObserver задаёт функцию, получающую итог каждой записи по базам,
+// например для счётчика частичных записей. Вызывать до начала обслуживания запросов.
+func (ds *DualStorage) SetWriteObserver(fn func(storage.WriteResult)) {
+	ds.onWrite = fn
+}
+
+// SetDegraded включает или выключает деградированный режим (работа только с SQLite)
+func (ds *DualStorage) SetDegraded(degraded bool) {
+	ds.degraded.Store(degraded)
+}
+
+// Degraded сообщает, работает ли хранилище в деградированном режиме
+func (ds *DualStorage) Degraded() bool {
+	return ds.degraded.Load()
+}
+
+// Status пингует обе базы и возвращает сводное состояние хранилища с задержкой каждой базы.
+// В деградированном режиме фактический режим - только SQLite.
+// В режимах sqlite и mongo проверяется только подключённая база.
+// Пинг ограничен statusTimeout и сроком ctx, базы пингуются параллельно.
+func (ds *DualStorage) Status(ctx context.Context, log *slog.Logger) storage.Status {
+	switch ds.mode {
+	case storage.ModeSQLite:
+		return storage.Status{
+			ConfiguredMode: storage.ModeSQLite,
+			EffectiveMode:  storage.ModeSQLite,
+			Backends: map[string]storage.BackendStatus{
+				storage.ModeSQLite: ping(ctx, log, storage.ModeSQLite, ds.sqliteDB.Ping),
+			},
+		}
+	case storage.ModeMongo:
+		mongo := ping(ctx, log, storage.ModeMongo, ds.mongoDB.Ping)
+		mongo.SecondsSinceLastWrite = ds.secondsSinceLastMongoWrite()
+
+		return storage.Status{
+			ConfiguredMode: storage.ModeMongo,
+			EffectiveMode:  storage.ModeMongo,
+			Backends: map[string]storage.BackendStatus{
+				storage.ModeMongo: mongo,
+			},
+		}
 	}
 
-	log.Info("URL successfully saved in both databases", slog.String("alias", alias))
-	return nil
+	var sqliteStatus storage.BackendStatus
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sqliteStatus = ping(ctx, log, storage.ModeSQLite, ds.sqliteDB.Ping)
+	}()
+	mongo := ping(ctx, log, storage.ModeMongo, ds.mongoDB.Ping)
+	wg.Wait()
+
+	status := storage.Status{
+		ConfiguredMode: storage.ModeDual,
+		EffectiveMode:  storage.ModeDual,
+		Degraded:       ds.Degraded(),
+		Backends: map[string]storage.BackendStatus{
+			storage.ModeSQLite: sqliteStatus,
+		},
+	}
+
+	mongo.SecondsSinceLastWrite = ds.secondsSinceLastMongoWrite()
+	mongo.Breaker = "closed"
+	if status.Degraded {
+		mongo.Breaker = "open"
+		status.EffectiveMode = storage.ModeSQLite
+	}
+	status.Backends[storage.ModeMongo] = mongo
+
+	return status
 }
 
-// GetURL получает URL по alias из MongoDB или SQLite
-func (ds *DualStorage) GetURL(ctx context.Context, log *slog.Logger, alias string, userID int64) (string, error) {
-	log.Info("attempting to retrieve URL", slog.String("alias", alias), slog.Int64("userID", userID))
+// ping проверяет базу и замеряет задержку. Ответ ждётся не дольше срока ctx, даже если
+// драйвер срок не соблюдает: зависшая база не задерживает проверку.
+func ping(ctx context.Context, log *slog.Logger, name string, pingFn func(context.Context) error) storage.BackendStatus {
+	ctx, cancel := context.WithTimeout(ctx, statusTimeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- pingFn(ctx) }()
 
-	// Попробуем получить URL из SQLite
-	url, err := ds.sqliteDB.GetURL(alias, userID)
-	if err == nil {
-		log.Info("URL found in SQLite", slog.String("alias", alias), slog.Int64("userID", userID))
-		return url, nil
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
 	}
-	log.Error("failed to get URL from SQLite", slog.String("alias", alias), sl.Err(err))
+	latencyMs := float64(time.Since(start).Microseconds()) / 1000
 
-	// Если в SQLite не нашлось, попробуем MongoDB
-	url, err = ds.mongoDB.GetURL(ctx, alias, userID)
 	if err != nil {
-		log.Error("failed to get URL from MongoDB", slog.String("alias", alias), sl.Err(err))
+		log.Warn("storage backend is down", slog.String("backend", name), sl.Err(err))
+		return storage.BackendStatus{
+			Up:        false,
+			Error:     err.Error(),
+			LatencyMs: &latencyMs,
+			TimedOut:  errors.Is(err, context.DeadlineExceeded),
+		}
+	}
+
+	return storage.BackendStatus{Up: true, LatencyMs: &latencyMs}
+}
+
+// SaveURL сохраняет URL в обе базы данных. Если вторая база не приняла ссылку,
+// она удаляется из основной, и alias остаётся свободным.
+func (ds *DualStorage) SaveURL(ctx context.Context, log *slog.Logger, urlToSave, alias string, userID int64, opts storage.URLOptions) error {
+	log.Info("attempting to save URL", slog.String("alias", alias), slog.Int64("userID", userID))
+
+	return ds.write(ctx, log, dualWrite{
+		what:  "save URL",
+		attrs: []any{slog.String("alias", alias)},
+		sqlite: func() error {
+			return ds.sqliteDB.SaveURL(urlToSave, alias, userID, opts)
+		},
+		mongo: func() error {
+			_, err := ds.mongoDB.SaveURL(ctx, urlToSave, alias, userID, opts)
+			return err
+		},
+		undoSQLite: func() error { return ds.sqliteDB.DiscardURL(alias) },
+		undoMongo:  func() error { return ds.mongoDB.DiscardURL(ctx, alias) },
+	})
+}
+
+// SaveURLWithSequenceAlias сохраняет URL под alias, вычисленным aliasFor из id строки SQLite,
+// и возвращает этот alias. SQLite пишется первой при любой основной базе: без неё alias неизвестен.
+// В режиме mongo возвращает storage.ErrSequenceUnsupported.
+func (ds *DualStorage) SaveURLWithSequenceAlias(
+	ctx context.Context,
+	log *slog.Logger,
+	urlToSave string,
+	userID int64,
+	opts storage.URLOptions,
+	aliasFor func(id int64) (string, bool),
+) (string, error) {
+	log.Info("attempting to save URL with sequence alias", slog.Int64("userID", userID))
+
+	if ds.mongoOnly() {
+		return "", storage.ErrSequenceUnsupported
+	}
+
+	var alias string
+	err := ds.write(ctx, log, dualWrite{
+		what:        "save URL with sequence alias",
+		sqliteFirst: true,
+		sqlite: func() (err error) {
+			alias, err = ds.sqliteDB.SaveURLWithSequenceAlias(urlToSave, userID, opts, aliasFor)
+			return err
+		},
+		mongo: func() error {
+			_, err := ds.mongoDB.SaveURL(ctx, urlToSave, alias, userID, opts)
+			return err
+		},
+		undoSQLite: func() error { return ds.sqliteDB.DiscardURL(alias) },
+	})
+	if err != nil {
 		return "", err
 	}
 
-	log.Info("URL found in MongoDB", slog.String("alias", alias), slog.Int64("userID", userID))
-	return url, nil
+	return alias, nil
 }
 
-// DeleteURL удаляет URL из обеих баз данных
+// GetURL получает URL по alias из основной базы, при ошибке - из второй
+func (ds *DualStorage) GetURL(ctx context.Context, log *slog.Logger, alias string, userID int64) (string, error) {
+	log.Info("attempting to retrieve URL", slog.String("alias", alias), slog.Int64("userID", userID))
+
+	return read(ds, log, "get URL",
+		func() (string, error) { return ds.sqliteDB.GetURL(alias, userID) },
+		func() (string, error) { return ds.mongoDB.GetURL(ctx, alias, userID) },
+		slog.String("alias", alias),
+	)
+}
+
+// AliasExists проверяет, занят ли alias, в основной базе, при ошибке - во второй
+func (ds *DualStorage) AliasExists(ctx context.Context, log *slog.Logger, alias string) (bool, error) {
+	return read(ds, log, "check alias",
+		func() (bool, error) { return ds.sqliteDB.AliasExists(alias) },
+		func() (bool, error) { return ds.mongoDB.AliasExists(ctx, alias) },
+		slog.String("alias", alias),
+	)
+}
+
+// DeleteURL переносит URL в корзину в обеих базах данных
 func (ds *DualStorage) DeleteURL(ctx context.Context, log *slog.Logger, alias string, userID int64) error {
 	log.Info("attempting to delete URL", slog.String("alias", alias), slog.Int64("userID", userID))
 
-	// Сначала удаляем из SQLite
-	if err := ds.sqliteDB.DeleteURL(alias, userID); err != nil {
-		log.Error("failed to delete URL from SQLite", slog.String("alias", alias), sl.Err(err))
-		return err
+	return ds.write(ctx, log, dualWrite{
+		what:   "delete URL",
+		attrs:  []any{slog.String("alias", alias)},
+		sqlite: func() error { return ds.sqliteDB.DeleteURL(alias, userID) },
+		mongo:  func() error { return ds.mongoDB.DeleteURL(ctx, alias, userID) },
+	})
+}
+
+// RestoreURL возвращает ссылку из корзины в обеих базах данных, если она попала туда
+// не раньше deletedAfter
+func (ds *DualStorage) RestoreURL(ctx context.Context, log *slog.Logger, alias string, userID int64, deletedAfter time.Time) error {
+	log.Info("attempting to restore URL", slog.String("alias", alias), slog.Int64("userID", userID))
+
+	return ds.write(ctx, log, dualWrite{
+		what:   "restore URL",
+		attrs:  []any{slog.String("alias", alias)},
+		sqlite: func() error { return ds.sqliteDB.RestoreURL(alias, userID, deletedAfter) },
+		mongo:  func() error { return ds.mongoDB.RestoreURL(ctx, alias, userID, deletedAfter) },
+	})
+}
+
+// PurgeExpiredURLs окончательно удаляет из корзины в обеих базах данных ссылки всех
+// пользователей, перенесённые туда раньше deletedBefore.
+// Количество удалённых ссылок берётся из основной базы.
+func (ds *DualStorage) PurgeExpiredURLs(ctx context.Context, log *slog.Logger, deletedBefore time.Time) (int64, error) {
+	// Основная база определяется до записи: деградированный режим может включиться по ходу
+	primary := ds.Primary()
+
+	var sqlitePurged, mongoPurged int64
+	err := ds.write(ctx, log, dualWrite{
+		what:  "purge expired URLs",
+		attrs: []any{slog.Time("deletedBefore", deletedBefore)},
+		sqlite: func() (err error) {
+			sqlitePurged, err = ds.sqliteDB.PurgeExpiredURLs(deletedBefore)
+			return err
+		},
+		mongo: func() (err error) {
+			mongoPurged, err = ds.mongoDB.PurgeExpiredURLs(ctx, deletedBefore)
+			return err
+		},
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	// Затем удаляем из MongoDB
-	if err := ds.mongoDB.DeleteURL(ctx, alias, userID); err != nil {
-		log.Error("failed to delete URL from MongoDB", slog.String("alias", alias), sl.Err(err))
-		return err
+	if primary == storage.ModeMongo {
+		return mongoPurged, nil
 	}
+	return sqlitePurged, nil
+}
 
-	log.Info("URL successfully deleted from both databases", slog.String("alias", alias))
-	return nil
+// PurgeDeletedURLs окончательно удаляет ссылки пользователя из корзины в обеих базах данных.
+// Количество удалённых ссылок берётся из основной базы.
+func (ds *DualStorage) PurgeDeletedURLs(ctx context.Context, log *slog.Logger, userID int64) (int64, error) {
+	log.Info("attempting to purge deleted URLs", slog.Int64("userID", userID))
+
+	// Основная база определяется до записи: деградированный режим может включиться по ходу
+	primary := ds.Primary()
+
+	var sqlitePurged, mongoPurged int64
+	err := ds.write(ctx, log, dualWrite{
+		what:  "purge deleted URLs",
+		attrs: []any{slog.Int64("userID", userID)},
+		sqlite: func() (err error) {
+			sqlitePurged, err = ds.sqliteDB.PurgeDeletedURLs(userID)
+			return err
+		},
+		mongo: func() (err error) {
+			mongoPurged, err = ds.mongoDB.PurgeDeletedURLs(ctx, userID)
+			return err
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if primary == storage.ModeMongo {
+		return mongoPurged, nil
+	}
+	return sqlitePurged, nil
+}
+
+// TransferURLs передаёт все ссылки пользователя другому пользователю в обеих базах данных.
+// Количество переданных ссылок берётся из основной базы.
+func (ds *DualStorage) TransferURLs(ctx context.Context, log *slog.Logger, fromUserID, toUserID int64) (int64, error) {
+	log.Info("attempting to transfer URLs", slog.Int64("fromUserID", fromUserID), slog.Int64("toUserID", toUserID))
+
+	// Основная база определяется до записи: деградированный режим может включиться по ходу
+	primary := ds.Primary()
+
+	var sqliteMoved, mongoMoved int64
+	err := ds.write(ctx, log, dualWrite{
+		what:  "transfer URLs",
+		attrs: []any{slog.Int64("fromUserID", fromUserID), slog.Int64("toUserID", toUserID)},
+		sqlite: func() (err error) {
+			sqliteMoved, err = ds.sqliteDB.TransferURLs(fromUserID, toUserID)
+			return err
+		},
+		mongo: func() (err error) {
+			mongoMoved, err = ds.mongoDB.TransferURLs(ctx, fromUserID, toUserID)
+			return err
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if primary == storage.ModeMongo {
+		return mongoMoved, nil
+	}
+	return sqliteMoved, nil
 }
 
-// SaveUser сохраняет пользователя в обе базы данных
+// SaveUser сохраняет пользователя в обе базы данных. id выдаёт основная база,
+// вторая сохраняет пользователя с тем же id. Если вторая база не приняла пользователя,
+// он удаляется из основной, и никнейм можно зарегистрировать повторно.
 func (ds *DualStorage) SaveUser(ctx context.Context, log *slog.Logger, nickname, passwordHash string) error {
 	log.Info("attempting to save user", slog.String("nickname", nickname))
 
-	// Сначала сохраняем пользователя в SQLite
-	userID, err := ds.sqliteDB.SaveUser(nickname, passwordHash)
+	// userID == 0 - id ещё не выдан: база, в которую пишем первой, выдаёт его сама.
+	// mongoID - _id документа MongoDB, по нему откатывается только что вставленный пользователь.
+	var (
+		userID  int64
+		mongoID interface{}
+	)
+	err := ds.write(ctx, log, dualWrite{
+		what:  "save user",
+		attrs: []any{slog.String("nickname", nickname)},
+		sqlite: func() (err error) {
+			if userID != 0 {
+				return ds.sqliteDB.SaveUserWithID(userID, nickname, passwordHash)
+			}
+			userID, err = ds.sqliteDB.SaveUser(nickname, passwordHash)
+			return err
+		},
+		mongo: func() (err error) {
+			if userID == 0 {
+				if userID, err = ds.mongoDB.NextUserID(ctx); err != nil {
+					return err
+				}
+			}
+			mongoID, err = ds.mongoDB.SaveUser(ctx, nickname, passwordHash, userID)
+			return err
+		},
+		undoSQLite: func() error { return ds.sqliteDB.DiscardUser(userID) },
+		undoMongo:  func() error { return ds.mongoDB.DiscardUser(ctx, mongoID) },
+	})
 	if err != nil {
-		log.Error("failed to save user in SQLite", slog.String("nickname", nickname), sl.Err(err))
 		return err
 	}
 
-	// Затем сохраняем пользователя в MongoDB
-	if _, err := ds.mongoDB.SaveUser(ctx, nickname, passwordHash, userID); err != nil {
-		log.Error("failed to save user in MongoDB", slog.String("nickname", nickname), sl.Err(err))
+	log.Info("user saved", slog.String("nickname", nickname), slog.Int64("userID", userID))
+	return nil
+}
+
+// SeedUserIDs сдвигает счётчик id пользователей MongoDB выше всех уже выданных id, в том числе
+// в SQLite. Без этого MongoDB, ставшая основной базой, выдала бы id существующего пользователя.
+// Вызывается при запуске; в режиме sqlite ничего не делает.
+func (ds *DualStorage) SeedUserIDs(ctx context.Context, log *slog.Logger) error {
+	if ds.mode == storage.ModeSQLite {
+		return nil
+	}
+
+	var floor int64
+	if !ds.mongoOnly() {
+		maxID, err := ds.sqliteDB.MaxUserID()
+		if err != nil {
+			return err
+		}
+		floor = maxID
+	}
+
+	if err := ds.mongoDB.SeedUserID(ctx, floor); err != nil {
 		return err
 	}
 
-	log.Info("user successfully saved in both databases", slog.String("nickname", nickname), slog.Int64("userID", userID))
+	log.Info("MongoDB user id counter seeded", slog.Int64("floor", floor))
 	return nil
 }
 
-// GetUserByNickname получает пользователя из любой базы
+// GetUserByNickname получает пользователя из обеих баз. id и хэш пароля берутся из основной базы.
 func (ds *DualStorage) GetUserByNickname(ctx context.Context, log *slog.Logger, nickname string) (int64, string, error) {
-	var userID int64
-
 	log.Info("attempting to retrieve user", slog.String("nickname", nickname))
 
+	if ds.mongoOnly() {
+		return ds.mongoDB.GetUserByNickname(ctx, nickname)
+	}
+
 	// Сначала ищем пользователя в SQLite
-	sqliteUserID, hash, errSqliteGetUser := ds.sqliteDB.GetUserByNickname(nickname)
+	sqliteUserID, sqliteHash, errSqliteGetUser := ds.sqliteDB.GetUserByNickname(nickname)
 	if errSqliteGetUser != nil {
 		log.Error("failed to get user from SQLite", slog.String("nickname", nickname), sl.Err(errSqliteGetUser))
 	}
 
-	// Параллельно ищем пользователя в MongoDB
-	mongoUserID, _, errMongoGetUser := ds.mongoDB.GetUserByNickname(ctx, nickname)
+	// В деградированном режиме MongoDB не опрашиваем
+	if ds.mongoSkipped() {
+		return sqliteUserID, sqliteHash, errSqliteGetUser
+	}
+
+	// Затем ищем пользователя в MongoDB
+	mongoUserID, mongoHash, errMongoGetUser := ds.mongoDB.GetUserByNickname(ctx, nickname)
 	if errMongoGetUser != nil {
 		log.Error("failed to get user from MongoDB", slog.String("nickname", nickname), sl.Err(errMongoGetUser))
 	}
 
+	userID, hash, err := sqliteUserID, sqliteHash, errSqliteGetUser
+	otherUserID, otherErr := mongoUserID, errMongoGetUser
+	name, otherName := nameSQLite, nameMongo
+	if ds.mongoPrimary() {
+		userID, hash, err = mongoUserID, mongoHash, errMongoGetUser
+		otherUserID, otherErr = sqliteUserID, errSqliteGetUser
+		name, otherName = nameMongo, nameSQLite
+	}
+
 	switch {
-	case errSqliteGetUser == nil && errMongoGetUser == nil:
+	case err == nil && otherErr == nil:
 		// Оба запроса успешны
-		userID = sqliteUserID
 		log.Info("user found", slog.Int64("userID", userID), slog.String("nickname", nickname))
 		return userID, hash, nil
-	case errSqliteGetUser != nil && errMongoGetUser != nil:
+	case err != nil && otherErr != nil:
 		// Оба запроса завершились с ошибками
 		log.Error("both databases returned errors", slog.String("nickname", nickname))
-		return 0, "", fmt.Errorf("SQLite error: %v, MongoDB error: %v", errSqliteGetUser, errMongoGetUser)
-	case errSqliteGetUser != nil:
-		// Ошибка в SQLite, но успех в MongoDB
-		userID = mongoUserID
-		log.Info("user found in MongoDB", slog.Int64("userID", userID), slog.String("nickname", nickname))
-		return userID, "", fmt.Errorf("SQLite error: %v", errSqliteGetUser)
-	case errMongoGetUser != nil:
-		// Ошибка в MongoDB, но успех в SQLite
-		userID = sqliteUserID
-		log.Info("user found in SQLite", slog.Int64("userID", userID), slog.String("nickname", nickname))
-		return userID, hash, fmt.Errorf("MongoDB error: %v", errMongoGetUser)
+		return 0, "", fmt.Errorf("%s error: %v, %s error: %v", name, err, otherName, otherErr)
+	case err != nil:
+		// Ошибка в основной базе, но успех во второй
+		log.Info("user found in "+otherName, slog.Int64("userID", otherUserID), slog.String("nickname", nickname))
+		return otherUserID, "", fmt.Errorf("%s error: %v", name, err)
 	default:
-		// Непредвиденная ситуация (теоретически не должна возникать)
-		log.Error("unexpected error occurred", slog.String("nickname", nickname))
-		return 0, "", errors.New("unexpected error")
+		// Ошибка во второй базе, но успех в основной
+		log.Info("user found in "+name, slog.Int64("userID", userID), slog.String("nickname", nickname))
+		return userID, hash, fmt.Errorf("%s error: %v", otherName, otherErr)
 	}
 }
 
-// DeleteUserByNickname удаляет пользователя из обеих баз данных
-func (ds *DualStorage) DeleteUserByNickname(ctx context.Context, log *slog.Logger, nickname string) error {
-	log.Info("attempting to delete user", slog.String("nickname", nickname))
+// GetUser получает профиль пользователя из основной базы, при ошибке - из второй
+func (ds *DualStorage) GetUser(ctx context.Context, log *slog.Logger, nickname string) (storage.User, error) {
+	log.Info("attempting to retrieve user profile", slog.String("nickname", nickname))
 
-	// Сначала удаляем пользователя из SQLite
-	if err := ds.sqliteDB.DeleteUserByNickname(nickname); err != nil {
-		log.Error("failed to delete user from SQLite", slog.String("nickname", nickname), sl.Err(err))
-		return err
+	return read(ds, log, "get user profile",
+		func() (storage.User, error) { return ds.sqliteDB.GetUser(nickname) },
+		func() (storage.User, error) { return ds.mongoDB.GetUser(ctx, nickname) },
+		slog.String("nickname", nickname),
+	)
+}
+
+// GetUserSettings получает настройки пользователя из основной базы, при ошибке - из второй
+func (ds *DualStorage) GetUserSettings(ctx context.Context, log *slog.Logger, userID int64) (storage.UserSettings, error) {
+	return read(ds, log, "get user settings",
+		func() (storage.UserSettings, error) { return ds.sqliteDB.GetUserSettings(userID) },
+		func() (storage.UserSettings, error) { return ds.mongoDB.GetUserSettings(ctx, userID) },
+		slog.Int64("userID", userID),
+	)
+}
+
+// SaveUserSettings сохраняет настройки пользователя в обе базы данных
+func (ds *DualStorage) SaveUserSettings(ctx context.Context, log *slog.Logger, userID int64, settings storage.UserSettings) error {
+	log.Info("attempting to save user settings", slog.Int64("userID", userID))
+
+	return ds.write(ctx, log, dualWrite{
+		what:   "save user settings",
+		attrs:  []any{slog.Int64("userID", userID)},
+		sqlite: func() error { return ds.sqliteDB.SaveUserSettings(userID, settings) },
+		mongo:  func() error { return ds.mongoDB.SaveUserSettings(ctx, userID, settings) },
+	})
+}
+
+// ExtendURLExpiry продлевает срок действия ссылки в обеих базах данных
+func (ds *DualStorage) ExtendURLExpiry(ctx context.Context, log *slog.Logger, alias string, userID int64, expiresAt time.Time) error {
+	log.Info("attempting to extend URL expiry", slog.String("alias", alias), slog.Time("expires_at", expiresAt))
+
+	return ds.write(ctx, log, dualWrite{
+		what:   "extend URL expiry",
+		attrs:  []any{slog.String("alias", alias)},
+		sqlite: func() error { return ds.sqliteDB.ExtendURLExpiry(alias, userID, expiresAt) },
+		mongo:  func() error { return ds.mongoDB.ExtendURLExpiry(ctx, alias, userID, expiresAt) },
+	})
+}
+
+// SetURLVisibility меняет видимость ссылки в обеих базах данных
+func (ds *DualStorage) SetURLVisibility(ctx context.Context, log *slog.Logger, alias string, isPublic bool, userID int64) error {
+	log.Info("attempting to set URL visibility", slog.String("alias", alias), slog.Bool("is_public", isPublic))
+
+	return ds.write(ctx, log, dualWrite{
+		what:   "set URL visibility",
+		attrs:  []any{slog.String("alias", alias)},
+		sqlite: func() error { return ds.sqliteDB.SetURLVisibility(alias, isPublic, userID) },
+		mongo:  func() error { return ds.mongoDB.SetURLVisibility(ctx, alias, isPublic, userID) },
+	})
+}
+
+// UpdateURLDescription меняет описание ссылки в обеих базах данных
+func (ds *DualStorage) UpdateURLDescription(ctx context.Context, log *slog.Logger, alias string, userID int64, description string) error {
+	log.Info("attempting to update URL description", slog.String("alias", alias))
+
+	return ds.write(ctx, log, dualWrite{
+		what:   "update URL description",
+		attrs:  []any{slog.String("alias", alias)},
+		sqlite: func() error { return ds.sqliteDB.UpdateURLDescription(alias, userID, description) },
+		mongo:  func() error { return ds.mongoDB.UpdateURLDescription(ctx, alias, userID, description) },
+	})
+}
+
+// SetURLPreview сохраняет метаданные превью ссылки в обеих базах данных
+func (ds *DualStorage) SetURLPreview(ctx context.Context, log *slog.Logger, alias string, userID int64, preview storage.Preview) error {
+	log.Info("attempting to set URL preview", slog.String("alias", alias))
+
+	return ds.write(ctx, log, dualWrite{
+		what:   "set URL preview",
+		attrs:  []any{slog.String("alias", alias)},
+		sqlite: func() error { return ds.sqliteDB.SetURLPreview(alias, userID, preview) },
+		mongo:  func() error { return ds.mongoDB.SetURLPreview(ctx, alias, userID, preview) },
+	})
+}
+
+// RenameAlias переименовывает ссылку в обеих базах данных. Если вторая база
+// не приняла новый alias, в основной возвращается прежний.
+func (ds *DualStorage) RenameAlias(ctx context.Context, log *slog.Logger, alias, newAlias string, userID int64) error {
+	log.Info("attempting to rename URL", slog.String("alias", alias), slog.String("new_alias", newAlias))
+
+	return ds.write(ctx, log, dualWrite{
+		what:       "rename URL",
+		attrs:      []any{slog.String("alias", alias), slog.String("new_alias", newAlias)},
+		sqlite:     func() error { return ds.sqliteDB.RenameAlias(alias, newAlias, userID) },
+		mongo:      func() error { return ds.mongoDB.RenameAlias(ctx, alias, newAlias, userID) },
+		undoSQLite: func() error { return ds.sqliteDB.RenameAlias(newAlias, alias, userID) },
+		undoMongo:  func() error { return ds.mongoDB.RenameAlias(ctx, newAlias, alias, userID) },
+	})
+}
+
+// UpdateURLTags меняет метки нескольких ссылок. Итоговые наборы считает основная база;
+// вторая получает изменения только для ссылок, которые основная база приняла.
+func (ds *DualStorage) UpdateURLTags(
+	ctx context.Context,
+	log *slog.Logger,
+	aliases []string,
+	userID int64,
+	add, remove []string,
+	maxTags int,
+) (map[string]storage.TagUpdate, error) {
+	log.Info("attempting to update URL tags", slog.Int("aliases", len(aliases)))
+
+	// results == nil - основная база ещё не ответила: первой выполняется она
+	var results map[string]storage.TagUpdate
+	err := ds.write(ctx, log, dualWrite{
+		what:  "update URL tags",
+		attrs: []any{slog.Int("aliases", len(aliases))},
+		sqlite: func() (err error) {
+			if results == nil {
+				results, err = ds.sqliteDB.UpdateURLTags(aliases, userID, add, remove, maxTags)
+				return err
+			}
+			_, err = ds.sqliteDB.UpdateURLTags(updatedAliases(results), userID, add, remove, maxTags)
+			return err
+		},
+		mongo: func() (err error) {
+			if results == nil {
+				results, err = ds.mongoDB.UpdateURLTags(ctx, aliases, userID, add, remove, maxTags)
+				return err
+			}
+			for alias, res := range results {
+				if res.Err != nil {
+					continue
+				}
+				if err := ds.mongoDB.SetURLTags(ctx, alias, res.Tags); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Затем удаляем пользователя из MongoDB
-	if err := ds.mongoDB.DeleteUserByNickname(ctx, nickname); err != nil {
-		log.Error("failed to delete user from MongoDB", slog.String("nickname", nickname), sl.Err(err))
-		return err
+	return results, nil
+}
+
+// updatedAliases возвращает ссылки, метки которых удалось изменить
+func updatedAliases(results map[string]storage.TagUpdate) []string {
+	aliases := make([]string, 0, len(results))
+	for alias, res := range results {
+		if res.Err == nil {
+			aliases = append(aliases, alias)
+		}
 	}
 
-	log.Info("user successfully deleted from both databases", slog.String("nickname", nickname))
-	return nil
+	return aliases
+}
+
+// GetURLsByUser получает все URL пользователя из основной базы, при ошибке - из второй
+func (ds *DualStorage) GetURLsByUser(ctx context.Context, log *slog.Logger, userID int64) ([]storage.URL, error) {
+	log.Info("attempting to list URLs", slog.Int64("userID", userID))
+
+	return read(ds, log, "list URLs",
+		func() ([]storage.URL, error) { return ds.sqliteDB.GetURLsByUser(userID) },
+		func() ([]storage.URL, error) { return ds.mongoDB.GetURLsByUser(ctx, userID) },
+		slog.Int64("userID", userID),
+	)
+}
+
+// GetDeletedURLsByUser получает ссылки пользователя из корзины из основной базы, при ошибке - из второй
+func (ds *DualStorage) GetDeletedURLsByUser(ctx context.Context, log *slog.Logger, userID int64) ([]storage.URL, error) {
+	return read(ds, log, "list deleted URLs",
+		func() ([]storage.URL, error) { return ds.sqliteDB.GetDeletedURLsByUser(userID) },
+		func() ([]storage.URL, error) { return ds.mongoDB.GetDeletedURLsByUser(ctx, userID) },
+		slog.Int64("userID", userID),
+	)
+}
+
+// GetURLOwner получает никнейм владельца ссылки из основной базы, при ошибке - из второй
+func (ds *DualStorage) GetURLOwner(ctx context.Context, log *slog.Logger, alias string) (string, error) {
+	return read(ds, log, "get URL owner",
+		func() (string, error) { return ds.sqliteDB.GetURLOwner(alias) },
+		func() (string, error) { return ds.mongoDB.GetURLOwner(ctx, alias) },
+		slog.String("alias", alias),
+	)
+}
+
+// GetAliasByURL ищет alias, под которым пользователь уже сократил адрес, в основной базе, при ошибке - во второй
+func (ds *DualStorage) GetAliasByURL(ctx context.Context, log *slog.Logger, url string, userID int64) (string, error) {
+	return read(ds, log, "get alias by URL",
+		func() (string, error) { return ds.sqliteDB.GetAliasByURL(url, userID) },
+		func() (string, error) { return ds.mongoDB.GetAliasByURL(ctx, url, userID) },
+		slog.String("url", url),
+		slog.Int64("userID", userID),
+	)
+}
+
+// CountURLsByUser считает ссылки пользователя в основной базе, при ошибке - во второй
+func (ds *DualStorage) CountURLsByUser(ctx context.Context, log *slog.Logger, userID int64) (int64, error) {
+	return read(ds, log, "count URLs",
+		func() (int64, error) { return ds.sqliteDB.CountURLsByUser(userID) },
+		func() (int64, error) { return ds.mongoDB.CountURLsByUser(ctx, userID) },
+		slog.Int64("userID", userID),
+	)
+}
+
+// GetBrokenURLsByUser получает ссылки пользователя с недоступной целью из основной базы, при ошибке - из второй
+func (ds *DualStorage) GetBrokenURLsByUser(ctx context.Context, log *slog.Logger, userID int64) ([]storage.URL, error) {
+	return read(ds, log, "list broken URLs",
+		func() ([]storage.URL, error) { return ds.sqliteDB.GetBrokenURLsByUser(userID) },
+		func() ([]storage.URL, error) { return ds.mongoDB.GetBrokenURLsByUser(ctx, userID) },
+		slog.Int64("userID", userID),
+	)
+}
+
+// GetURLsToCheck получает пачку ссылок для фоновой проверки из основной базы, при ошибке - из второй
+func (ds *DualStorage) GetURLsToCheck(ctx context.Context, log *slog.Logger, checkedBefore time.Time, limit int) ([]storage.URL, error) {
+	return read(ds, log, "list URLs to check",
+		func() ([]storage.URL, error) { return ds.sqliteDB.GetURLsToCheck(checkedBefore, limit) },
+		func() ([]storage.URL, error) { return ds.mongoDB.GetURLsToCheck(ctx, checkedBefore, limit) },
+	)
+}
+
+// SetURLHealth сохраняет результат фоновой проверки ссылки в обеих базах данных
+func (ds *DualStorage) SetURLHealth(ctx context.Context, log *slog.Logger, alias string, status *int, checkedAt time.Time) error {
+	return ds.write(ctx, log, dualWrite{
+		what:   "save URL health",
+		attrs:  []any{slog.String("alias", alias)},
+		sqlite: func() error { return ds.sqliteDB.SetURLHealth(alias, status, checkedAt) },
+		mongo:  func() error { return ds.mongoDB.SetURLHealth(ctx, alias, status, checkedAt) },
+		quiet:  true,
+	})
+}
+
+// GetStaleURLs получает неиспользуемые с момента olderThan ссылки пользователя из основной базы, при ошибке - из второй
+func (ds *DualStorage) GetStaleURLs(ctx context.Context, log *slog.Logger, userID int64, olderThan time.Time) ([]storage.URL, error) {
+	log.Info("attempting to list stale URLs", slog.Int64("userID", userID), slog.Time("older_than", olderThan))
+
+	return read(ds, log, "list stale URLs",
+		func() ([]storage.URL, error) { return ds.sqliteDB.GetStaleURLs(userID, olderThan) },
+		func() ([]storage.URL, error) { return ds.mongoDB.GetStaleURLs(ctx, userID, olderThan) },
+		slog.Int64("userID", userID),
+	)
+}
+
+// GetLink получает ссылку по alias без проверки владельца из основной базы, при ошибке - из второй
+func (ds *DualStorage) GetLink(ctx context.Context, log *slog.Logger, alias string) (storage.URL, error) {
+	return read(ds, log, "get link",
+		func() (storage.URL, error) { return ds.sqliteDB.GetLink(alias) },
+		func() (storage.URL, error) { return ds.mongoDB.GetLink(ctx, alias) },
+		slog.String("alias", alias),
+	)
+}
+
+// GetURLs получает несколько ссылок пользователя из основной базы, при ошибке - из второй
+func (ds *DualStorage) GetURLs(ctx context.Context, log *slog.Logger, aliases []string, userID int64) ([]storage.URL, error) {
+	log.Info("attempting to resolve URLs", slog.Int("count", len(aliases)), slog.Int64("userID", userID))
+
+	return read(ds, log, "resolve URLs",
+		func() ([]storage.URL, error) { return ds.sqliteDB.GetURLs(aliases, userID) },
+		func() ([]storage.URL, error) { return ds.mongoDB.GetURLs(ctx, aliases, userID) },
+		slog.Int64("userID", userID),
+	)
+}
+
+// RecordClick записывает переход по ссылке в обе базы данных
+func (ds *DualStorage) RecordClick(ctx context.Context, log *slog.Logger, alias string) error {
+	now := time.Now().UTC()
+
+	return ds.write(ctx, log, dualWrite{
+		what:   "record click",
+		attrs:  []any{slog.String("alias", alias)},
+		sqlite: func() error { return ds.sqliteDB.RecordClick(alias, now) },
+		mongo:  func() error { return ds.mongoDB.RecordClick(ctx, alias, now) },
+		quiet:  true,
+	})
+}
+
+// CountClicks считает все переходы по ссылке в основной базе, при ошибке - во второй
+func (ds *DualStorage) CountClicks(ctx context.Context, log *slog.Logger, alias string) (int64, error) {
+	return read(ds, log, "count clicks",
+		func() (int64, error) { return ds.sqliteDB.CountClicks(alias) },
+		func() (int64, error) { return ds.mongoDB.CountClicks(ctx, alias) },
+		slog.String("alias", alias),
+	)
+}
+
+// ClaimPrefix закрепляет префикс alias за пользователем в обеих базах данных
+func (ds *DualStorage) ClaimPrefix(ctx context.Context, log *slog.Logger, prefix string, userID int64, maxPerUser int) error {
+	log.Info("attempting to claim prefix", slog.String("prefix", prefix), slog.Int64("userID", userID))
+
+	return ds.write(ctx, log, dualWrite{
+		what:   "claim prefix",
+		attrs:  []any{slog.String("prefix", prefix)},
+		sqlite: func() error { return ds.sqliteDB.ClaimPrefix(prefix, userID, maxPerUser) },
+		mongo:  func() error { return ds.mongoDB.ClaimPrefix(ctx, prefix, userID, maxPerUser) },
+	})
+}
+
+// ClickTimeseries считает переходы по интервалам в основной базе, при ошибке - во второй
+func (ds *DualStorage) ClickTimeseries(
+	ctx context.Context,
+	log *slog.Logger,
+	alias string,
+	from, to time.Time,
+	bucket time.Duration,
+) ([]storage.ClickBucket, error) {
+	return read(ds, log, "count clicks",
+		func() ([]storage.ClickBucket, error) { return ds.sqliteDB.ClickTimeseries(alias, from, to, bucket) },
+		func() ([]storage.ClickBucket, error) { return ds.mongoDB.ClickTimeseries(ctx, alias, from, to, bucket) },
+		slog.String("alias", alias),
+	)
+}
+
+// LinkStats возвращает страницу статистики ссылок пользователя
+func (ds *DualStorage) LinkStats(
+	ctx context.Context,
+	log *slog.Logger,
+	userID int64,
+	sort string,
+	limit, offset int,
+) ([]storage.LinkStats, error) {
+	return read(ds, log, "get link stats",
+		func() ([]storage.LinkStats, error) { return ds.sqliteDB.LinkStats(userID, sort, limit, offset) },
+		func() ([]storage.LinkStats, error) { return ds.mongoDB.LinkStats(ctx, userID, sort, limit, offset) },
+		slog.Int64("user_id", userID),
+	)
+}
+
+// GetTagCounts возвращает метки пользователя с числом ссылок, от частых к редким;
+// limit - сколько меток вернуть, 0 - все
+func (ds *DualStorage) GetTagCounts(ctx context.Context, log *slog.Logger, userID int64, limit int) ([]storage.TagCount, error) {
+	return read(ds, log, "count tags",
+		func() ([]storage.TagCount, error) { return ds.sqliteDB.GetTagCounts(userID, limit) },
+		func() ([]storage.TagCount, error) { return ds.mongoDB.GetTagCounts(ctx, userID, limit) },
+		slog.Int64("user_id", userID),
+	)
+}
+
+// RecordLoginEvent сохраняет попытку входа пользователя в обеих базах данных,
+// оставляя keep последних событий
+func (ds *DualStorage) RecordLoginEvent(ctx context.Context, log *slog.Logger, userID int64, event storage.LoginEvent, keep int) error {
+	return ds.write(ctx, log, dualWrite{
+		what:   "record login event",
+		attrs:  []any{slog.Int64("userID", userID)},
+		sqlite: func() error { return ds.sqliteDB.RecordLoginEvent(userID, event, keep) },
+		mongo:  func() error { return ds.mongoDB.RecordLoginEvent(ctx, userID, event, keep) },
+		quiet:  true,
+	})
+}
+
+// GetLoginEvents возвращает страницу попыток входа пользователя из основной базы, при ошибке - из второй
+func (ds *DualStorage) GetLoginEvents(ctx context.Context, log *slog.Logger, userID int64, limit, offset int) ([]storage.LoginEvent, error) {
+	return read(ds, log, "list login events",
+		func() ([]storage.LoginEvent, error) { return ds.sqliteDB.GetLoginEvents(userID, limit, offset) },
+		func() ([]storage.LoginEvent, error) { return ds.mongoDB.GetLoginEvents(ctx, userID, limit, offset) },
+		slog.Int64("userID", userID),
+	)
+}
+
+// DeleteUserByNickname удаляет пользователя из обеих баз данных
+func (ds *DualStorage) DeleteUserByNickname(ctx context.Context, log *slog.Logger, nickname string) error {
+	log.Info("attempting to delete user", slog.String("nickname", nickname))
+
+	return ds.write(ctx, log, dualWrite{
+		what:   "delete user",
+		attrs:  []any{slog.String("nickname", nickname)},
+		sqlite: func() error { return ds.sqliteDB.DeleteUserByNickname(nickname) },
+		mongo:  func() error { return ds.mongoDB.DeleteUserByNickname(ctx, nickname) },
+	})
 }
@@ -0,0 +1,571 @@
+package multiStorage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/base62"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/sqlite"
+)
+
+// fakeMongo подменяет MongoDB в тестах. Не переопределённые методы паникуют.
+type fakeMongo struct {
+	mongoStore
+	pingErr error
+	// pingDelay - пинг отвечает с задержкой, не глядя на срок контекста, как зависший драйвер
+	pingDelay time.Duration
+}
+
+func (f *fakeMongo) Ping(_ context.Context) error {
+	time.Sleep(f.pingDelay)
+	return f.pingErr
+}
+
+func newTestStorage(t *testing.T, mongo *fakeMongo) *DualStorage {
+	t.Helper()
+
+	sqliteDB, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	return &DualStorage{mode: storage.ModeDual, sqliteDB: sqliteDB, mongoDB: mongo}
+}
+
+func TestStatus(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+
+	t.Run("Both backends up", func(t *testing.T) {
+		ds := newTestStorage(t, &fakeMongo{})
+
+		status := ds.Status(context.Background(), log)
+
+		require.Equal(t, storage.ModeDual, status.ConfiguredMode)
+		require.Equal(t, storage.ModeDual, status.EffectiveMode)
+		require.False(t, status.Degraded)
+		require.True(t, status.Backends[storage.ModeSQLite].Up)
+		require.True(t, status.Backends[storage.ModeMongo].Up)
+		require.Equal(t, "closed", status.Backends[storage.ModeMongo].Breaker)
+		for name, backend := range status.Backends {
+			require.NotNil(t, backend.LatencyMs, name)
+			require.GreaterOrEqual(t, *backend.LatencyMs, 0.0, name)
+			require.False(t, backend.TimedOut, name)
+		}
+	})
+
+	t.Run("Mongo hangs", func(t *testing.T) {
+		ds := newTestStorage(t, &fakeMongo{pingDelay: time.Second})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		status := ds.Status(ctx, log)
+
+		// Проверка не ждёт зависшую базу дольше срока
+		require.Less(t, time.Since(start), 500*time.Millisecond)
+		require.True(t, status.Backends[storage.ModeSQLite].Up)
+		require.False(t, status.Backends[storage.ModeSQLite].TimedOut)
+
+		mongo := status.Backends[storage.ModeMongo]
+		require.False(t, mongo.Up)
+		require.True(t, mongo.TimedOut)
+		require.NotNil(t, mongo.LatencyMs)
+		require.GreaterOrEqual(t, *mongo.LatencyMs, 50.0)
+	})
+
+	t.Run("Mongo down", func(t *testing.T) {
+		ds := newTestStorage(t, &fakeMongo{pingErr: errors.New("connection refused")})
+		// Так делает healthcheck после нескольких неудачных пингов
+		ds.SetDegraded(true)
+
+		status := ds.Status(context.Background(), log)
+
+		require.Equal(t, storage.ModeDual, status.ConfiguredMode)
+		require.Equal(t, storage.ModeSQLite, status.EffectiveMode)
+		require.True(t, status.Degraded)
+		require.True(t, status.Backends[storage.ModeSQLite].Up)
+		require.False(t, status.Backends[storage.ModeMongo].Up)
+		require.Equal(t, "connection refused", status.Backends[storage.ModeMongo].Error)
+		require.Equal(t, "open", status.Backends[storage.ModeMongo].Breaker)
+	})
+}
+
+// memoryMongo - MongoDB в памяти для проверки режима mongo
+type memoryMongo struct {
+	fakeMongo
+	nextID int64
+	users  map[string]int64
+	urls   map[string]string
+}
+
+func newMemoryMongo() *memoryMongo {
+	return &memoryMongo{users: map[string]int64{}, urls: map[string]string{}}
+}
+
+func (m *memoryMongo) NextUserID(_ context.Context) (int64, error) {
+	m.nextID++
+	return m.nextID, nil
+}
+
+func (m *memoryMongo) SaveUser(_ context.Context, nickname, _ string, userID int64) (interface{}, error) {
+	if _, ok := m.users[nickname]; ok {
+		return nil, storage.ErrUserExists
+	}
+	m.users[nickname] = userID
+	// Как _id документа: другой, чем user_id
+	return nickname, nil
+}
+
+func (m *memoryMongo) SeedUserID(_ context.Context, floor int64) error {
+	for _, id := range m.users {
+		if id > floor {
+			floor = id
+		}
+	}
+	if floor > m.nextID {
+		m.nextID = floor
+	}
+	return nil
+}
+
+func (m *memoryMongo) GetUserByNickname(_ context.Context, nickname string) (int64, string, error) {
+	userID, ok := m.users[nickname]
+	if !ok {
+		return 0, "", storage.ErrUserNotFound
+	}
+	return userID, "hash", nil
+}
+
+func (m *memoryMongo) DiscardUser(_ context.Context, insertedID interface{}) error {
+	delete(m.users, insertedID.(string))
+	return nil
+}
+
+func (m *memoryMongo) SaveURL(_ context.Context, urlToSave, alias string, _ int64, _ storage.URLOptions) (interface{}, error) {
+	if _, ok := m.urls[alias]; ok {
+		return nil, storage.ErrURLExists
+	}
+	m.urls[alias] = urlToSave
+	return alias, nil
+}
+
+func (m *memoryMongo) GetURL(_ context.Context, alias string, _ int64) (string, error) {
+	url, ok := m.urls[alias]
+	if !ok {
+		return "", storage.ErrURLNotFound
+	}
+	return url, nil
+}
+
+func TestStorageModes(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	ctx := context.Background()
+
+	t.Run("SQLite only", func(t *testing.T) {
+		sqliteDB, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+		require.NoError(t, err)
+
+		// MongoDB не подключена: любое обращение к ней было бы паникой на nil
+		ds := NewSQLiteStorage(sqliteDB)
+		require.Equal(t, storage.ModeSQLite, ds.Mode())
+
+		require.NoError(t, ds.SaveUser(ctx, log, "alice", "hash"))
+		userID, hash, err := ds.GetUserByNickname(ctx, log, "alice")
+		require.NoError(t, err)
+		require.Equal(t, "hash", hash)
+
+		require.NoError(t, ds.SaveURL(ctx, log, "https://example.com", "abc", userID, storage.URLOptions{}))
+		url, err := ds.GetURL(ctx, log, "abc", userID)
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com", url)
+
+		_, err = ds.GetURL(ctx, log, "missing", userID)
+		require.ErrorIs(t, err, storage.ErrURLNotFound)
+
+		require.NoError(t, ds.DeleteURL(ctx, log, "abc", userID))
+
+		status := ds.Status(ctx, log)
+		require.Equal(t, storage.ModeSQLite, status.ConfiguredMode)
+		require.Equal(t, storage.ModeSQLite, status.EffectiveMode)
+		require.False(t, status.Degraded)
+		require.True(t, status.Backends[storage.ModeSQLite].Up)
+		require.NotContains(t, status.Backends, storage.ModeMongo)
+	})
+
+	t.Run("Mongo only", func(t *testing.T) {
+		mongo := newMemoryMongo()
+		// SQLite не подключена: любое обращение к ней было бы паникой на nil
+		ds := &DualStorage{mode: storage.ModeMongo, mongoDB: mongo}
+		require.Equal(t, storage.ModeMongo, ds.Mode())
+
+		require.NoError(t, ds.SaveUser(ctx, log, "alice", "hash"))
+		require.NoError(t, ds.SaveUser(ctx, log, "bob", "hash"))
+		require.ErrorIs(t, ds.SaveUser(ctx, log, "bob", "hash"), storage.ErrUserExists)
+
+		userID, _, err := ds.GetUserByNickname(ctx, log, "bob")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), userID)
+
+		require.NoError(t, ds.SaveURL(ctx, log, "https://example.com", "abc", userID, storage.URLOptions{}))
+		require.ErrorIs(t, ds.SaveURL(ctx, log, "https://example.org", "abc", userID, storage.URLOptions{}), storage.ErrURLExists)
+
+		url, err := ds.GetURL(ctx, log, "abc", userID)
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com", url)
+
+		status := ds.Status(ctx, log)
+		require.Equal(t, storage.ModeMongo, status.ConfiguredMode)
+		require.Equal(t, storage.ModeMongo, status.EffectiveMode)
+		require.True(t, status.Backends[storage.ModeMongo].Up)
+		require.NotContains(t, status.Backends, storage.ModeSQLite)
+	})
+}
+
+func (m *memoryMongo) DiscardURL(_ context.Context, alias string) error {
+	delete(m.urls, alias)
+	return nil
+}
+
+func TestWriteOrdering(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	ctx := context.Background()
+
+	newDualStorage := func(t *testing.T, primary string) (*DualStorage, *memoryMongo) {
+		mongo := newMemoryMongo()
+		ds := newTestStorage(t, &fakeMongo{})
+		ds.mongoDB = mongo
+		ds.SetPrimary(primary)
+		return ds, mongo
+	}
+
+	t.Run("SQLite first: user rolled back when MongoDB rejects it", func(t *testing.T) {
+		ds, mongo := newDualStorage(t, storage.ModeSQLite)
+		require.Equal(t, storage.ModeSQLite, ds.Primary())
+		// В MongoDB остался пользователь с тем же никнеймом
+		mongo.users["alice"] = 7
+
+		require.ErrorIs(t, ds.SaveUser(ctx, log, "alice", "hash"), storage.ErrUserExists)
+
+		_, _, err := ds.sqliteDB.GetUserByNickname("alice")
+		require.ErrorIs(t, err, storage.ErrUserNotFound)
+	})
+
+	t.Run("SQLite first: URL rolled back when MongoDB rejects it", func(t *testing.T) {
+		ds, mongo := newDualStorage(t, storage.ModeSQLite)
+		mongo.urls["abc"] = "https://example.org"
+
+		require.ErrorIs(t, ds.SaveURL(ctx, log, "https://example.com", "abc", 1, storage.URLOptions{}), storage.ErrURLExists)
+
+		exists, err := ds.sqliteDB.AliasExists("abc")
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		// Когда MongoDB освобождает alias, его можно сохранить заново
+		delete(mongo.urls, "abc")
+		require.NoError(t, ds.SaveURL(ctx, log, "https://example.com", "abc", 1, storage.URLOptions{}))
+	})
+
+	t.Run("MongoDB first: id comes from MongoDB", func(t *testing.T) {
+		ds, mongo := newDualStorage(t, storage.ModeMongo)
+		require.Equal(t, storage.ModeMongo, ds.Primary())
+		mongo.nextID = 41
+
+		require.NoError(t, ds.SaveUser(ctx, log, "alice", "hash"))
+
+		require.Equal(t, int64(42), mongo.users["alice"])
+		sqliteUserID, _, err := ds.sqliteDB.GetUserByNickname("alice")
+		require.NoError(t, err)
+		require.Equal(t, int64(42), sqliteUserID)
+
+		userID, hash, err := ds.GetUserByNickname(ctx, log, "alice")
+		require.NoError(t, err)
+		require.Equal(t, int64(42), userID)
+		require.Equal(t, "hash", hash)
+	})
+
+	t.Run("MongoDB first: user rolled back when SQLite rejects it", func(t *testing.T) {
+		ds, mongo := newDualStorage(t, storage.ModeMongo)
+		mongo.nextID = 41
+		_, err := ds.sqliteDB.SaveUser("alice", "hash")
+		require.NoError(t, err)
+
+		require.ErrorIs(t, ds.SaveUser(ctx, log, "alice", "hash"), storage.ErrUserExists)
+		require.NotContains(t, mongo.users, "alice")
+	})
+
+	t.Run("Switch to MongoDB with existing users", func(t *testing.T) {
+		// Пользователи зарегистрированы, пока основной была SQLite; счётчик MongoDB не заведён
+		ds, mongo := newDualStorage(t, storage.ModeSQLite)
+		require.NoError(t, ds.SaveUser(ctx, log, "alice", "hash"))
+		require.NoError(t, ds.SaveUser(ctx, log, "bob", "hash"))
+		mongo.nextID = 0
+
+		ds.SetPrimary(storage.ModeMongo)
+		require.NoError(t, ds.SeedUserIDs(ctx, log))
+
+		require.NoError(t, ds.SaveUser(ctx, log, "carol", "hash"))
+		require.Equal(t, int64(3), mongo.users["carol"])
+		require.Equal(t, int64(1), mongo.users["alice"])
+		require.Equal(t, int64(2), mongo.users["bob"])
+	})
+
+	t.Run("MongoDB first: rollback keeps the user with the same id", func(t *testing.T) {
+		// Без засева счётчик выдаёт id существующего пользователя
+		ds, mongo := newDualStorage(t, storage.ModeSQLite)
+		require.NoError(t, ds.SaveUser(ctx, log, "alice", "hash"))
+		mongo.nextID = 0
+		ds.SetPrimary(storage.ModeMongo)
+
+		require.Error(t, ds.SaveUser(ctx, log, "carol", "hash"))
+
+		require.NotContains(t, mongo.users, "carol")
+		require.Equal(t, int64(1), mongo.users["alice"])
+	})
+
+	t.Run("MongoDB first: URL rolled back when SQLite rejects it", func(t *testing.T) {
+		ds, mongo := newDualStorage(t, storage.ModeMongo)
+		require.NoError(t, ds.sqliteDB.SaveURL("https://example.org", "abc", 1, storage.URLOptions{}))
+
+		require.ErrorIs(t, ds.SaveURL(ctx, log, "https://example.com", "abc", 1, storage.URLOptions{}), storage.ErrURLExists)
+		require.NotContains(t, mongo.urls, "abc")
+	})
+
+	t.Run("MongoDB first: degraded mode falls back to SQLite", func(t *testing.T) {
+		ds, mongo := newDualStorage(t, storage.ModeMongo)
+		ds.SetDegraded(true)
+		require.Equal(t, storage.ModeSQLite, ds.Primary())
+
+		require.NoError(t, ds.SaveURL(ctx, log, "https://example.com", "abc", 1, storage.URLOptions{}))
+		require.NotContains(t, mongo.urls, "abc")
+
+		url, err := ds.GetURL(ctx, log, "abc", 1)
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com", url)
+	})
+}
+
+// writingMongo принимает запись ссылки без ошибок
+type writingMongo struct {
+	fakeMongo
+}
+
+func (m *writingMongo) SaveURL(_ context.Context, _, alias string, _ int64, _ storage.URLOptions) (interface{}, error) {
+	return alias, nil
+}
+
+func TestSinceLastMongoWrite(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	ds := newTestStorage(t, &fakeMongo{})
+	ds.mongoDB = &writingMongo{}
+
+	// Последняя успешная запись была час назад
+	ds.lastMongoWrite.Store(time.Now().Add(-time.Hour).UnixNano())
+	require.InDelta(t, time.Hour.Seconds(), ds.SinceLastMongoWrite().Seconds(), 5)
+
+	status := ds.Status(context.Background(), log)
+	require.InDelta(t, time.Hour.Seconds(), *status.Backends[storage.ModeMongo].SecondsSinceLastWrite, 5)
+
+	userID, err := ds.sqliteDB.SaveUser("user", "hash")
+	require.NoError(t, err)
+	require.NoError(t, ds.SaveURL(context.Background(), log, "https://example.com", "abc", userID, storage.URLOptions{}))
+
+	require.Less(t, ds.SinceLastMongoWrite(), time.Second)
+	status = ds.Status(context.Background(), log)
+	require.Less(t, *status.Backends[storage.ModeMongo].SecondsSinceLastWrite, 1.0)
+}
+
+func TestWriteResults(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+
+	newStorage := func(t *testing.T) (*DualStorage, *memoryMongo, *[]storage.WriteResult) {
+		mongo := newMemoryMongo()
+		ds := newTestStorage(t, &fakeMongo{})
+		ds.mongoDB = mongo
+
+		observed := &[]storage.WriteResult{}
+		ds.SetWriteObserver(func(r storage.WriteResult) {
+			*observed = append(*observed, r)
+		})
+		return ds, mongo, observed
+	}
+
+	t.Run("Degraded: saved to SQLite, MongoDB skipped", func(t *testing.T) {
+		ds, mongo, observed := newStorage(t)
+		ds.SetDegraded(true)
+		ctx, results := storage.WithWriteResults(context.Background())
+
+		require.NoError(t, ds.SaveURL(ctx, log, "https://example.com", "abc", 1, storage.URLOptions{}))
+		require.NotContains(t, mongo.urls, "abc")
+
+		want := storage.WriteResult{
+			Op:       "save URL",
+			SQLite:   storage.WriteOK,
+			Mongo:    storage.WriteSkipped,
+			Degraded: true,
+		}
+		require.Equal(t, []storage.WriteResult{want}, results.All())
+		require.True(t, results.Partial())
+		require.Equal(t, []storage.WriteResult{want}, *observed)
+	})
+
+	t.Run("Both databases", func(t *testing.T) {
+		ds, _, observed := newStorage(t)
+		ctx, results := storage.WithWriteResults(context.Background())
+
+		require.NoError(t, ds.SaveURL(ctx, log, "https://example.com", "abc", 1, storage.URLOptions{}))
+
+		all := results.All()
+		require.Len(t, all, 1)
+		require.Equal(t, storage.WriteOK, all[0].SQLite)
+		require.Equal(t, storage.WriteOK, all[0].Mongo)
+		require.False(t, all[0].Degraded)
+		require.False(t, results.Partial())
+		require.Len(t, *observed, 1)
+	})
+
+	t.Run("MongoDB rejects: SQLite rolled back", func(t *testing.T) {
+		ds, mongo, _ := newStorage(t)
+		mongo.urls["abc"] = "https://example.org"
+		ctx, results := storage.WithWriteResults(context.Background())
+
+		require.ErrorIs(t, ds.SaveURL(ctx, log, "https://example.com", "abc", 1, storage.URLOptions{}), storage.ErrURLExists)
+
+		all := results.All()
+		require.Len(t, all, 1)
+		require.Equal(t, storage.WriteRolledBack, all[0].SQLite)
+		require.Equal(t, storage.WriteFailed, all[0].Mongo)
+		require.False(t, results.Partial())
+	})
+
+	t.Run("No recorder in context", func(t *testing.T) {
+		ds, _, observed := newStorage(t)
+
+		require.NoError(t, ds.SaveURL(context.Background(), log, "https://example.com", "abc", 1, storage.URLOptions{}))
+		require.Len(t, *observed, 1)
+	})
+}
+
+func TestWriteResults_SingleBackend(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	ds := newTestStorage(t, &fakeMongo{})
+	ds.mode = storage.ModeSQLite
+	ctx, results := storage.WithWriteResults(context.Background())
+
+	require.NoError(t, ds.SaveURL(ctx, log, "https://example.com", "abc", 1, storage.URLOptions{}))
+
+	all := results.All()
+	require.Len(t, all, 1)
+	require.Equal(t, storage.WriteOK, all[0].SQLite)
+	require.Equal(t, storage.WriteSkipped, all[0].Mongo)
+	// MongoDB не подключена: запись полная
+	require.False(t, all[0].Degraded)
+	require.False(t, results.Partial())
+}
+
+func TestSaveURLWithSequenceAlias(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	ctx := context.Background()
+	sequence := func(id int64) (string, bool) { return base62.Encode(id), true }
+
+	t.Run("MongoDB primary: SQLite still goes first", func(t *testing.T) {
+		mongo := newMemoryMongo()
+		ds := newTestStorage(t, &fakeMongo{})
+		ds.mongoDB = mongo
+		ds.SetPrimary(storage.ModeMongo)
+
+		alias, err := ds.SaveURLWithSequenceAlias(ctx, log, "https://example.com", 1, storage.URLOptions{}, sequence)
+		require.NoError(t, err)
+		require.Equal(t, "1", alias)
+		require.Equal(t, "https://example.com", mongo.urls["1"])
+
+		url, err := ds.sqliteDB.GetURL("1", 1)
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com", url)
+	})
+
+	t.Run("MongoDB rejects: SQLite rolled back", func(t *testing.T) {
+		mongo := newMemoryMongo()
+		mongo.urls["1"] = "https://example.org"
+		ds := newTestStorage(t, &fakeMongo{})
+		ds.mongoDB = mongo
+
+		_, err := ds.SaveURLWithSequenceAlias(ctx, log, "https://example.com", 1, storage.URLOptions{}, sequence)
+		require.ErrorIs(t, err, storage.ErrURLExists)
+
+		exists, err := ds.sqliteDB.AliasExists("1")
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("Mongo mode", func(t *testing.T) {
+		ds := &DualStorage{mode: storage.ModeMongo, mongoDB: newMemoryMongo()}
+
+		_, err := ds.SaveURLWithSequenceAlias(ctx, log, "https://example.com", 1, storage.URLOptions{}, sequence)
+		require.ErrorIs(t, err, storage.ErrSequenceUnsupported)
+	})
+}
+
+// stubSQLite подменяет SQLite в тестах чтения: GetURL возвращает заданную ошибку
+type stubSQLite struct {
+	sqliteStore
+	getURLErr error
+}
+
+func (s *stubSQLite) GetURL(_ string, _ int64) (string, error) {
+	return "", s.getURLErr
+}
+
+func TestGetURL_NotFound(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	ctx := context.Background()
+
+	withSQLiteErr := func(sqliteErr error) *DualStorage {
+		return newStorage(storage.ModeDual, &stubSQLite{getURLErr: sqliteErr}, newMemoryMongo())
+	}
+
+	t.Run("Both not found", func(t *testing.T) {
+		ds := withSQLiteErr(storage.ErrURLNotFound)
+
+		_, err := ds.GetURL(ctx, log, "missing", 1)
+		require.ErrorIs(t, err, storage.ErrURLNotFound)
+		require.NotErrorIs(t, err, storage.ErrUnavailable)
+	})
+
+	t.Run("Transient error then not found", func(t *testing.T) {
+		ds := withSQLiteErr(errors.New("database is locked"))
+
+		_, err := ds.GetURL(ctx, log, "missing", 1)
+		require.ErrorIs(t, err, storage.ErrUnavailable)
+		require.NotErrorIs(t, err, storage.ErrURLNotFound)
+	})
+
+	t.Run("Transient error, not strict", func(t *testing.T) {
+		ds := withSQLiteErr(errors.New("database is locked"))
+		ds.SetStrictNotFound(false)
+
+		_, err := ds.GetURL(ctx, log, "missing", 1)
+		require.ErrorIs(t, err, storage.ErrURLNotFound)
+	})
+
+	t.Run("Expired then not found", func(t *testing.T) {
+		// Истёкшая ссылка - ответ о данных, а не сбой: отдаётся ответ второй базы
+		ds := withSQLiteErr(storage.ErrURLExpired)
+
+		_, err := ds.GetURL(ctx, log, "missing", 1)
+		require.ErrorIs(t, err, storage.ErrURLNotFound)
+	})
+
+	t.Run("Degraded", func(t *testing.T) {
+		ds := withSQLiteErr(errors.New("database is locked"))
+		ds.SetDegraded(true)
+
+		_, err := ds.GetURL(ctx, log, "missing", 1)
+		require.NotErrorIs(t, err, storage.ErrUnavailable)
+	})
+}
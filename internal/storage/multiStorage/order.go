@@ -0,0 +1,220 @@
+package multiStorage
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/exp/slog"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// Названия баз в логах
+const (
+	nameSQLite = "SQLite"
+	nameMongo  = "MongoDB"
+)
+
+// SetPrimary задаёт основную базу режима dual: storage.ModeSQLite (по умолчанию) или storage.ModeMongo.
+// В основную базу запись идёт первой, из неё первым идёт чтение, она выдаёт id пользователей.
+func (ds *DualStorage) SetPrimary(primary string) {
+	ds.primary = primary
+}
+
+// Primary возвращает основную базу, с которой хранилище работает сейчас. В деградированном
+// режиме это SQLite, даже если основной настроена MongoDB.
+func (ds *DualStorage) Primary() string {
+	switch {
+	case ds.mongoOnly() || ds.mongoPrimary():
+		return storage.ModeMongo
+	default:
+		return storage.ModeSQLite
+	}
+}
+
+// mongoPrimary сообщает, что в режиме dual основная база - MongoDB.
+// Пока MongoDB недоступна, основной остаётся SQLite.
+func (ds *DualStorage) mongoPrimary() bool {
+	return ds.mode == storage.ModeDual && ds.primary == storage.ModeMongo && !ds.Degraded()
+}
+
+// dualWrite - запись, которая выполняется в обеих базах
+type dualWrite struct {
+	// what - описание операции для лога, например "save URL"
+	what  string
+	attrs []any
+	// sqlite и mongo выполняют запись в соответствующей базе
+	sqlite func() error
+	mongo  func() error
+	// undoSQLite и undoMongo откатывают запись, если вторая база её не приняла.
+	// Задаются для создания записей; nil - откат не нужен (повтор изменения безопасен).
+	undoSQLite func() error
+	undoMongo  func() error
+	// quiet - частая фоновая операция: успех и запись только в SQLite не логируются
+	quiet bool
+	// sqliteFirst - запись для второй базы зависит от результата SQLite, поэтому SQLite
+	// остаётся первой, даже если основная база - MongoDB
+	sqliteFirst bool
+}
+
+// write выполняет запись сначала в основной базе, затем во второй. Ошибка основной базы
+// возвращается сразу, вторая база не трогается. Если запись не приняла вторая база,
+// она откатывается в основной, чтобы базы не разошлись.
+// Итог по базам передаётся в контекст (storage.WithWriteResults) и наблюдателю SetWriteObserver.
+func (ds *DualStorage) write(ctx context.Context, log *slog.Logger, w dualWrite) error {
+	result := storage.WriteResult{Op: w.what, SQLite: storage.WriteSkipped, Mongo: storage.WriteSkipped}
+
+	err := ds.writeBackends(log, w, &result)
+
+	storage.RecordWrite(ctx, result)
+	if ds.onWrite != nil {
+		ds.onWrite(result)
+	}
+
+	return err
+}
+
+// writeBackends выполняет запись и отмечает в result итог каждой базы
+func (ds *DualStorage) writeBackends(log *slog.Logger, w dualWrite, result *storage.WriteResult) error {
+	if ds.mongoOnly() {
+		if err := w.mongo(); err != nil {
+			result.Mongo = storage.WriteFailed
+			log.Error("failed to "+w.what+" in "+nameMongo, append(w.attrs, sl.Err(err))...)
+			return err
+		}
+		result.Mongo = storage.WriteOK
+		ds.markMongoWrite()
+		return nil
+	}
+
+	if ds.mongoSkipped() {
+		result.Degraded = ds.mode == storage.ModeDual
+		if err := w.sqlite(); err != nil {
+			result.SQLite = storage.WriteFailed
+			log.Error("failed to "+w.what+" in "+nameSQLite, append(w.attrs, sl.Err(err))...)
+			return err
+		}
+		result.SQLite = storage.WriteOK
+		if !w.quiet {
+			ds.warnDegraded(log, w.what+" in SQLite only", w.attrs...)
+		}
+		return nil
+	}
+
+	first, second, undo := w.sqlite, w.mongo, w.undoSQLite
+	firstName, secondName := nameSQLite, nameMongo
+	firstOutcome, secondOutcome := &result.SQLite, &result.Mongo
+	if ds.mongoPrimary() && !w.sqliteFirst {
+		first, second, undo = w.mongo, w.sqlite, w.undoMongo
+		firstName, secondName = nameMongo, nameSQLite
+		firstOutcome, secondOutcome = &result.Mongo, &result.SQLite
+	}
+
+	if err := first(); err != nil {
+		*firstOutcome = storage.WriteFailed
+		log.Error("failed to "+w.what+" in "+firstName, append(w.attrs, sl.Err(err))...)
+		return err
+	}
+	*firstOutcome = storage.WriteOK
+
+	if err := second(); err != nil {
+		*secondOutcome = storage.WriteFailed
+		log.Error("failed to "+w.what+" in "+secondName, append(w.attrs, sl.Err(err))...)
+
+		if undo != nil {
+			if undoErr := undo(); undoErr != nil {
+				log.Error("failed to roll back "+w.what+" in "+firstName, append(w.attrs, sl.Err(undoErr))...)
+			} else {
+				*firstOutcome = storage.WriteRolledBack
+				log.Warn("rolled back "+w.what+" in "+firstName, w.attrs...)
+			}
+		}
+
+		return err
+	}
+	*secondOutcome = storage.WriteOK
+	ds.markMongoWrite()
+
+	if !w.quiet {
+		log.Info(w.what+" done in both databases", w.attrs...)
+	}
+	return nil
+}
+
+// SetStrictNotFound задаёт ответ чтения, когда основная база вернула сбой, а вторая не нашла запись.
+// strict (по умолчанию) - storage.ErrUnavailable: запись могла остаться только в сбойной базе.
+// Иначе - ошибка «не найдено» второй базы, как до появления настройки.
+func (ds *DualStorage) SetStrictNotFound(strict bool) {
+	ds.strictNotFound = strict
+}
+
+// notFoundErrs - ошибки, которыми база сообщает об отсутствии записи
+var notFoundErrs = []error{storage.ErrURLNotFound, storage.ErrUserNotFound, storage.ErrPrefixNotFound}
+
+// knownErrs - ответы базы о состоянии данных. Остальные ошибки считаются сбоем самой базы.
+var knownErrs = append([]error{
+	storage.ErrURLExists,
+	storage.ErrURLExpired,
+	storage.ErrURLNoExpiry,
+	storage.ErrAliasTaken,
+	storage.ErrUserExists,
+	storage.ErrUnauthorized,
+	storage.ErrTooManyTags,
+	storage.ErrPrefixTaken,
+	storage.ErrTooManyPrefixes,
+}, notFoundErrs...)
+
+func isAny(err error, targets []error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// read читает из основной базы, а при ошибке - из второй, если она доступна.
+// what - описание операции для лога, attrs - её параметры.
+// Если основная база дала сбой, а вторая запись не нашла, при SetStrictNotFound(true)
+// возвращается storage.ErrUnavailable: «не найдено» отдаётся, только когда согласны обе базы.
+func read[T any](
+	ds *DualStorage,
+	log *slog.Logger,
+	what string,
+	fromSQLite func() (T, error),
+	fromMongo func() (T, error),
+	attrs ...any,
+) (T, error) {
+	if ds.mongoOnly() {
+		return fromMongo()
+	}
+
+	first, second := fromSQLite, fromMongo
+	firstName, secondName := nameSQLite, nameMongo
+	if ds.mongoPrimary() {
+		first, second = fromMongo, fromSQLite
+		firstName, secondName = nameMongo, nameSQLite
+	}
+
+	v, firstErr := first()
+	if firstErr == nil {
+		return v, nil
+	}
+	log.Error("failed to "+what+" in "+firstName, append(attrs, sl.Err(firstErr))...)
+
+	if ds.mongoSkipped() {
+		return v, firstErr
+	}
+
+	v, err := second()
+	if err != nil {
+		log.Error("failed to "+what+" in "+secondName, append(attrs, sl.Err(err))...)
+	}
+
+	if ds.strictNotFound && isAny(err, notFoundErrs) && !isAny(firstErr, knownErrs) {
+		log.Warn("ambiguous "+what+": "+firstName+" failed, "+secondName+" found nothing", attrs...)
+		return v, errors.Join(storage.ErrUnavailable, firstErr)
+	}
+
+	return v, err
+}
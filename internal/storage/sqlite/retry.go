@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// RetryConfig - повтор записей, наткнувшихся на блокировку базы
+type RetryConfig struct {
+	// Attempts - число попыток записи, включая первую; 0 или 1 - без повторов
+	Attempts int
+	// Backoff - пауза перед первым повтором, перед каждым следующим она растёт на столько же
+	Backoff time.Duration
+}
+
+// SetRetry задаёт повтор записей при SQLITE_BUSY и SQLITE_LOCKED
+func (s *Storage) SetRetry(cfg RetryConfig) {
+	s.retry = cfg
+}
+
+// isBusy сообщает, что запись не прошла из-за блокировки базы. Сравнивается основной код,
+// поэтому сюда попадают и все расширенные коды вроде SQLITE_BUSY_SNAPSHOT.
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+}
+
+// withRetry выполняет запись fn и повторяет её только при блокировке базы.
+// Остальные ошибки возвращаются сразу и без изменений.
+func (s *Storage) withRetry(fn func() error) error {
+	err := fn()
+	for attempt := 1; attempt < s.retry.Attempts && isBusy(err); attempt++ {
+		time.Sleep(time.Duration(attempt) * s.retry.Backoff)
+		err = fn()
+	}
+
+	return err
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func newRetryStorage(t *testing.T, attempts int) *Storage {
+	t.Helper()
+
+	s, err := New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	s.SetRetry(RetryConfig{Attempts: attempts, Backoff: time.Millisecond})
+
+	return s
+}
+
+func TestWithRetry_LockedThenSuccess(t *testing.T) {
+	s := newRetryStorage(t, 3)
+
+	calls := 0
+	err := s.withRetry(func() error {
+		calls++
+		if calls == 1 {
+			return sqlite3.Error{Code: sqlite3.ErrLocked}
+		}
+		_, err := s.db.Exec("INSERT INTO clicks (alias, clicked_at) VALUES (?, ?)", "alias", 1)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+
+	var count int
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM clicks WHERE alias = ?", "alias").Scan(&count))
+	require.Equal(t, 1, count)
+}
+
+func TestWithRetry_GivesUp(t *testing.T) {
+	s := newRetryStorage(t, 3)
+
+	calls := 0
+	err := s.withRetry(func() error {
+		calls++
+		return sqlite3.Error{Code: sqlite3.ErrBusy, ExtendedCode: sqlite3.ErrBusySnapshot}
+	})
+	require.True(t, isBusy(err))
+	require.Equal(t, 3, calls)
+}
+
+func TestWithRetry_OtherErrorsNotRetried(t *testing.T) {
+	s := newRetryStorage(t, 3)
+
+	constraint := sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique}
+	calls := 0
+	err := s.withRetry(func() error {
+		calls++
+		return constraint
+	})
+	require.True(t, errors.Is(err, constraint))
+	require.Equal(t, 1, calls)
+}
@@ -1,16 +1,32 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/mattn/go-sqlite3"
+	"url-shortener/internal/lib/domain"
 	"url-shortener/internal/storage"
 )
 
+// SchemaVersion - версия схемы, которую создаёт New. Записывается в PRAGMA user_version;
+// увеличивается при каждом изменении таблиц, колонок или индексов.
+const SchemaVersion = 2
+
 type Storage struct {
 	db *sql.DB
+	// caseInsensitive - alias уникальны и ищутся без учёта регистра (по alias_lower)
+	caseInsensitive bool
+	// retry - повтор записей при блокировке базы
+	retry RetryConfig
+	// nicknameHashKey - ключ хэширования никнеймов; пустой - никнеймы хранятся как есть
+	nicknameHashKey []byte
 }
 
 func New(storagePath string) (*Storage, error) {
@@ -54,69 +70,522 @@ func New(storagePath string) (*Storage, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	// Переходы по ссылкам. Время хранится в unix-секундах, чтобы группировать его арифметикой.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS clicks(
+			id INTEGER PRIMARY KEY,
+			alias TEXT NOT NULL,
+			clicked_at INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_clicks_alias_time ON clicks(alias, clicked_at);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Метки ссылок
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS url_tags(
+			url_id INTEGER NOT NULL,
+			tag TEXT NOT NULL,
+			PRIMARY KEY(url_id, tag),
+			FOREIGN KEY(url_id) REFERENCES urls(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Префиксы alias, занятые пользователями. Префикс хранится в нижнем регистре.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS alias_prefixes(
+			prefix TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Попытки входа пользователей; хранятся только последние, см. RecordLoginEvent
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS login_events(
+			id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			at DATETIME NOT NULL,
+			ip TEXT NOT NULL,
+			user_agent TEXT NOT NULL,
+			result TEXT NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+		CREATE INDEX IF NOT EXISTS idx_login_events_user ON login_events(user_id, at);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Добавление колонок, появившихся в схеме позже
+	for _, c := range columns {
+		if err := addColumnIfNotExists(db, c.table, c.name, c.definition); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// alias_lower заполняется для всех ссылок, независимо от режима
+	if _, err := db.Exec("UPDATE urls SET alias_lower = lower(alias) WHERE alias_lower IS NULL"); err != nil {
+		return nil, fmt.Errorf("%s: fill alias_lower: %w", op, err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)); err != nil {
+		return nil, fmt.Errorf("%s: set schema version: %w", op, err)
+	}
+
 	return &Storage{db: db}, nil
 }
 
+// Version возвращает версию схемы, записанную в базе (PRAGMA user_version)
+func (s *Storage) Version() (int, error) {
+	const op = "storage.sqlite.Version"
+
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version, nil
+}
+
+// Close закрывает соединение с базой
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// UseCaseInsensitiveAliases включает режим, в котором alias хранится в исходном регистре,
+// но уникален и ищется без учёта регистра. Уникальность обеспечивает индекс по alias_lower,
+// поэтому включить режим не получится, если в базе уже есть alias, различающиеся только регистром.
+func (s *Storage) UseCaseInsensitiveAliases(enabled bool) error {
+	const op = "storage.sqlite.UseCaseInsensitiveAliases"
+
+	query := "DROP INDEX IF EXISTS idx_alias_lower"
+	if enabled {
+		query = "CREATE UNIQUE INDEX IF NOT EXISTS idx_alias_lower ON urls(alias_lower)"
+	}
+
+	if _, err := s.db.Exec(query); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.caseInsensitive = enabled
+
+	return nil
+}
+
+// UseHashedNicknames включает режим, в котором в users.nickname хранится хэш никнейма
+// (см. storage.NicknameKey), а сам никнейм - только в display_name. Пользователи,
+// сохранённые в другом режиме, после переключения не находятся.
+func (s *Storage) UseHashedNicknames(key []byte) {
+	s.nicknameHashKey = key
+}
+
+// nicknameKey - значение users.nickname для никнейма в текущем режиме
+func (s *Storage) nicknameKey(nickname string) string {
+	return storage.NicknameKey(s.nicknameHashKey, nickname)
+}
+
+// aliasColumn - колонка, по которой ищется ссылка в текущем режиме регистра
+func (s *Storage) aliasColumn() string {
+	if s.caseInsensitive {
+		return "alias_lower"
+	}
+
+	return "alias"
+}
+
+// aliasMatch - условие поиска ссылки по alias
+func (s *Storage) aliasMatch() string {
+	return s.aliasColumn() + " = ?"
+}
+
+// linkedMatch - условие на ссылку вместе с её дополнительными alias (POST /url/{alias}/link):
+// они разделяют с основной ссылкой срок, видимость и удаление. Параметры - aliasKey(alias)
+// и alias основной ссылки в том виде, в котором он сохранён.
+func (s *Storage) linkedMatch() string {
+	return "(" + s.aliasMatch() + " OR linked_to = ?)"
+}
+
+// aliasKey приводит alias к виду, в котором он сравнивается в текущем режиме
+func (s *Storage) aliasKey(alias string) string {
+	if s.caseInsensitive {
+		return strings.ToLower(alias)
+	}
+
+	return alias
+}
+
+// notDeleted - условие, отсекающее ссылки в корзине
+const notDeleted = "deleted_at IS NULL"
+
+// redirectStatus подставляет 302 Found, если код ответа не задан
+func redirectStatus(status int) int {
+	if status == 0 {
+		return http.StatusFound
+	}
+
+	return status
+}
+
+// columns - колонки, добавленные после создания таблиц. Для новых и старых баз
+// они создаются одинаково, через ALTER TABLE.
+var columns = []struct {
+	table      string
+	name       string
+	definition string
+}{
+	{"users", "created_at", "DATETIME"},
+	{"users", "default_alias_length", "INTEGER"},
+	{"users", "default_redirect_status", "INTEGER"},
+	{"users", "default_ttl_seconds", "INTEGER"},
+	{"users", "display_name", "TEXT"},
+	{"users", "allow_duplicate_urls", "INTEGER"},
+	{"users", "redirect_template", "TEXT"},
+	{"urls", "created_at", "DATETIME"},
+	{"urls", "domain", "TEXT"},
+	{"urls", "expires_at", "DATETIME"},
+	{"urls", "is_public", "INTEGER NOT NULL DEFAULT 0"},
+	{"urls", "wildcard", "INTEGER NOT NULL DEFAULT 0"},
+	{"urls", "forward_query", "INTEGER NOT NULL DEFAULT 0"},
+	{"urls", "alias_lower", "TEXT"},
+	{"urls", "redirect_status", "INTEGER NOT NULL DEFAULT 302"},
+	{"urls", "last_accessed_at", "DATETIME"},
+	{"urls", "deleted_at", "DATETIME"},
+	{"urls", "last_status", "INTEGER"},
+	{"urls", "last_checked_at", "DATETIME"},
+	{"urls", "linked_to", "TEXT"},
+	{"urls", "preview_title", "TEXT"},
+	{"urls", "preview_description", "TEXT"},
+	{"urls", "preview_image", "TEXT"},
+	{"urls", "preview_fetched_at", "DATETIME"},
+	{"urls", "description", "TEXT"},
+}
+
+func addColumnIfNotExists(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("table info %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return fmt.Errorf("scan table info %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("table info %s: %w", table, err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
+	}
+
+	return nil
+}
+
+// Ping проверяет доступность базы
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "storage.sqlite.Ping"
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
 // Метод для сохранения URL с проверкой существования пользователя
-func (s *Storage) SaveURL(urlToSave, alias string, userID int64) error {
+func (s *Storage) SaveURL(urlToSave, alias string, userID int64, opts storage.URLOptions) error {
 	const op = "storage.sqlite.SaveURL"
 
-	stmt, err := s.db.Prepare(`
-		INSERT INTO urls (url, alias, user_id)
-		VALUES (?, ?, ?)
-	`)
+	// Ссылка и её метки сохраняются в одной транзакции
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if err := prefixConflict(tx, alias, userID); err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(`
+			INSERT INTO urls (url, alias, alias_lower, user_id, created_at, domain, expires_at, is_public, wildcard, forward_query, redirect_status, linked_to, description)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, urlToSave, alias, strings.ToLower(alias), userID, time.Now().UTC(), domain.Registrable(urlToSave), opts.ExpiresAt, opts.Public, opts.Wildcard, opts.ForwardQuery, redirectStatus(opts.RedirectStatus), sql.NullString{String: opts.LinkedTo, Valid: opts.LinkedTo != ""}, sql.NullString{String: opts.Description, Valid: opts.Description != ""})
+		if err != nil {
+			return err
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		if err := addTags(tx, id, opts.Tags); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
 	if err != nil {
-		return fmt.Errorf("%s: prepare statement: %w", op, err)
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return fmt.Errorf("%s: %w", op, s.aliasConflict(alias, userID))
+		}
+		if errors.Is(err, storage.ErrPrefixTaken) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return fmt.Errorf("%s: exec statement: %w", op, err)
 	}
-	defer stmt.Close()
 
-	res, err := stmt.Exec(urlToSave, alias, userID)
+	return nil
+}
+
+// prefixConflict возвращает ErrPrefixTaken, если alias попадает под префикс другого
+// пользователя. Проверка идёт в транзакции записи alias, поэтому префикс, занятый
+// параллельно, не пропустит чужой alias.
+func prefixConflict(tx *sql.Tx, alias string, userID int64) error {
+	var ownerID int64
+	err := tx.QueryRow(
+		"SELECT user_id FROM alias_prefixes WHERE substr(?, 1, length(prefix)) = prefix AND user_id != ? LIMIT 1",
+		storage.PrefixKey(alias), userID,
+	).Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
 	if err != nil {
-		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
-			return fmt.Errorf("%s: %w", op, storage.ErrURLExists)
+		return fmt.Errorf("check prefix: %w", err)
+	}
+
+	return storage.ErrPrefixTaken
+}
+
+// sequencePlaceholder - временный alias строки до того, как стал известен её id.
+// Живёт только внутри транзакции и не может совпасть с alias из запроса.
+const sequencePlaceholder = "\x00sequence"
+
+// Метод для сохранения ссылки под alias, вычисленным из id строки. Вставка и замена
+// временного alias на aliasFor(id) идут в одной транзакции, поэтому другие запросы
+// не видят строку без alias. Если aliasFor отклоняет alias, alias уже занят или попадает
+// под чужой префикс, запись отменяется с ErrURLExists: вызывающий берёт alias другим способом.
+func (s *Storage) SaveURLWithSequenceAlias(urlToSave string, userID int64, opts storage.URLOptions, aliasFor func(id int64) (string, bool)) (string, error) {
+	const op = "storage.sqlite.SaveURLWithSequenceAlias"
+
+	var alias string
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
 		}
-		return fmt.Errorf("%s: exec statement: %w", op, err)
+		defer tx.Rollback()
+
+		res, err := tx.Exec(`
+			INSERT INTO urls (url, alias, alias_lower, user_id, created_at, domain, expires_at, is_public, wildcard, forward_query, redirect_status, linked_to, description)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, urlToSave, sequencePlaceholder, sequencePlaceholder, userID, time.Now().UTC(), domain.Registrable(urlToSave), opts.ExpiresAt, opts.Public, opts.Wildcard, opts.ForwardQuery, redirectStatus(opts.RedirectStatus), sql.NullString{String: opts.LinkedTo, Valid: opts.LinkedTo != ""}, sql.NullString{String: opts.Description, Valid: opts.Description != ""})
+		if err != nil {
+			return err
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		var ok bool
+		alias, ok = aliasFor(id)
+		if !ok {
+			return storage.ErrURLExists
+		}
+
+		if err := prefixConflict(tx, alias, userID); errors.Is(err, storage.ErrPrefixTaken) {
+			return storage.ErrURLExists
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(
+			"UPDATE urls SET alias = ?, alias_lower = ? WHERE id = ?",
+			alias, strings.ToLower(alias), id,
+		); err != nil {
+			return err
+		}
+
+		if err := addTags(tx, id, opts.Tags); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
+	if errors.Is(err, storage.ErrURLExists) {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return "", fmt.Errorf("%s: %w", op, storage.ErrURLExists)
+		}
+		return "", fmt.Errorf("%s: exec statement: %w", op, err)
 	}
 
-	id, err := res.LastInsertId()
+	return alias, nil
+}
+
+// Метод для массового изменения меток ссылок пользователя в одной транзакции.
+// Метки add и remove должны быть уже нормализованы. Отказ по отдельной ссылке
+// (чужая, не найдена, больше maxTags меток) попадает в её TagUpdate и не отменяет остальные;
+// maxTags = 0 - без ограничения.
+func (s *Storage) UpdateURLTags(aliases []string, userID int64, add, remove []string, maxTags int) (map[string]storage.TagUpdate, error) {
+	const op = "storage.sqlite.UpdateURLTags"
+
+	var results map[string]storage.TagUpdate
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		results = make(map[string]storage.TagUpdate, len(aliases))
+		for _, alias := range aliases {
+			update, err := s.updateURLTags(tx, alias, userID, add, remove, maxTags)
+			if err != nil {
+				return err
+			}
+			results[alias] = update
+		}
+
+		return tx.Commit()
+	})
 	if err != nil {
-		return fmt.Errorf("%s: failed to get last insert id: %d. %w", op, id, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	return results, nil
 }
 
-// Метод для получения URL по алиасу с проверкой принадлежности alias указанному пользователю
-func (s *Storage) GetURL(alias string, userID int64) (string, error) {
-	const op = "storage.sqlite.GetURL"
+// updateURLTags меняет метки одной ссылки внутри транзакции
+func (s *Storage) updateURLTags(tx *sql.Tx, alias string, userID int64, add, remove []string, maxTags int) (storage.TagUpdate, error) {
+	var urlID, ownerID int64
+	err := tx.QueryRow("SELECT id, user_id FROM urls WHERE "+s.aliasMatch()+" AND "+notDeleted, s.aliasKey(alias)).Scan(&urlID, &ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.TagUpdate{Err: storage.ErrURLNotFound}, nil
+	}
+	if err != nil {
+		return storage.TagUpdate{}, fmt.Errorf("get url %q: %w", alias, err)
+	}
+	if ownerID != userID {
+		return storage.TagUpdate{Err: storage.ErrUnauthorized}, nil
+	}
 
-	// Сначала проверяем, существует ли alias в базе
-	stmtCheckExistence, err := s.db.Prepare("SELECT 1 FROM urls WHERE alias = ?")
+	current, err := urlTags(tx, urlID)
 	if err != nil {
-		return "", fmt.Errorf("%s: prepare existence check statement: %w", op, err)
+		return storage.TagUpdate{}, err
 	}
-	defer stmtCheckExistence.Close()
 
-	var exists int
-	err = stmtCheckExistence.QueryRow(alias).Scan(&exists)
+	merged := storage.MergeTags(current, add, remove)
+	if maxTags > 0 && len(merged) > maxTags {
+		return storage.TagUpdate{Tags: current, Err: storage.ErrTooManyTags}, nil
+	}
+
+	// Порядок тот же, что в storage.MergeTags: сначала add, потом remove
+	if err := addTags(tx, urlID, add); err != nil {
+		return storage.TagUpdate{}, err
+	}
+	for _, tag := range remove {
+		if _, err := tx.Exec("DELETE FROM url_tags WHERE url_id = ? AND tag = ?", urlID, tag); err != nil {
+			return storage.TagUpdate{}, fmt.Errorf("remove tag %q: %w", tag, err)
+		}
+	}
+
+	return storage.TagUpdate{Tags: merged}, nil
+}
+
+// urlTags возвращает метки ссылки в алфавитном порядке
+func urlTags(tx *sql.Tx, urlID int64) ([]string, error) {
+	rows, err := tx.Query("SELECT tag FROM url_tags WHERE url_id = ? ORDER BY tag", urlID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			// Если alias вообще не существует в базе
-			return "", storage.ErrURLNotFound
+		return nil, fmt.Errorf("get tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// addTags добавляет метки к ссылке; уже существующие пропускаются
+func addTags(tx *sql.Tx, urlID int64, tags []string) error {
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO url_tags (url_id, tag) VALUES (?, ?)", urlID, tag); err != nil {
+			return fmt.Errorf("add tag %q: %w", tag, err)
 		}
-		return "", fmt.Errorf("%s: execute existence check statement: %w", op, err)
 	}
 
-	// Если alias существует, проверяем принадлежность alias пользователю
-	stmtCheckOwnership, err := s.db.Prepare("SELECT user_id FROM urls WHERE alias = ?")
+	return nil
+}
+
+// aliasConflict определяет, кому принадлежит уже занятый alias:
+// ErrURLExists - самому пользователю, ErrAliasTaken - другому пользователю
+func (s *Storage) aliasConflict(alias string, userID int64) error {
+	var ownerID int64
+	err := s.db.QueryRow("SELECT user_id FROM urls WHERE "+s.aliasMatch(), s.aliasKey(alias)).Scan(&ownerID)
+	if err != nil || ownerID == userID {
+		return storage.ErrURLExists
+	}
+
+	return storage.ErrAliasTaken
+}
+
+// Метод для получения URL по алиасу с проверкой принадлежности alias указанному пользователю
+func (s *Storage) GetURL(alias string, userID int64) (string, error) {
+	const op = "storage.sqlite.GetURL"
+
+	// Существование и владельца проверяем одним запросом: чужой и несуществующий alias
+	// обходятся одинаково дёшево, и по времени ответа их не различить
+	stmtCheckOwnership, err := s.db.Prepare("SELECT user_id FROM urls WHERE " + s.aliasMatch() + " AND " + notDeleted)
 	if err != nil {
 		return "", fmt.Errorf("%s: prepare ownership check statement: %w", op, err)
 	}
 	defer stmtCheckOwnership.Close()
 
 	var dbUserID int64
-	err = stmtCheckOwnership.QueryRow(alias).Scan(&dbUserID)
+	err = stmtCheckOwnership.QueryRow(s.aliasKey(alias)).Scan(&dbUserID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Если alias вообще не существует в базе
+			return "", storage.ErrURLNotFound
+		}
 		return "", fmt.Errorf("%s: execute ownership check statement: %w", op, err)
 	}
 
@@ -126,27 +595,93 @@ func (s *Storage) GetURL(alias string, userID int64) (string, error) {
 	}
 
 	// Получаем URL, если alias принадлежит указанному пользователю
-	stmtGetURL, err := s.db.Prepare("SELECT url FROM urls WHERE alias = ? AND user_id = ?")
+	stmtGetURL, err := s.db.Prepare("SELECT url, expires_at FROM urls WHERE " + s.aliasMatch() + " AND user_id = ? AND " + notDeleted)
 	if err != nil {
 		return "", fmt.Errorf("%s: prepare get URL statement: %w", op, err)
 	}
 	defer stmtGetURL.Close()
 
-	var resURL string
-	err = stmtGetURL.QueryRow(alias, userID).Scan(&resURL)
+	var (
+		resURL    string
+		expiresAt sql.NullTime
+	)
+	err = stmtGetURL.QueryRow(s.aliasKey(alias), userID).Scan(&resURL, &expiresAt)
 	if err != nil {
 		return "", fmt.Errorf("%s: execute get URL statement: %w", op, err)
 	}
 
+	if expiresAt.Valid && storage.Expired(&expiresAt.Time, time.Now()) {
+		return "", storage.ErrURLExpired
+	}
+
 	return resURL, nil
 }
 
-// Метод для удаления URL по алиасу и проверке владельца (user_id)
+// Метод для поиска alias, под которым пользователь уже сократил адрес. Из нескольких
+// действующих ссылок на адрес возвращается самая ранняя; ссылки в корзине и истёкшие не учитываются.
+func (s *Storage) GetAliasByURL(url string, userID int64) (string, error) {
+	const op = "storage.sqlite.GetAliasByURL"
+
+	rows, err := s.db.Query(
+		"SELECT alias, expires_at FROM urls WHERE url = ? AND user_id = ? AND "+notDeleted+" ORDER BY id",
+		url, userID,
+	)
+	if err != nil {
+		return "", fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	for rows.Next() {
+		var (
+			alias     string
+			expiresAt sql.NullTime
+		)
+		if err := rows.Scan(&alias, &expiresAt); err != nil {
+			return "", fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		if expiresAt.Valid && storage.Expired(&expiresAt.Time, now) {
+			continue
+		}
+
+		return alias, nil
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("%s: iterate rows: %w", op, err)
+	}
+
+	return "", storage.ErrURLNotFound
+}
+
+// Метод для проверки, занят ли alias (без учёта владельца).
+// Alias ссылки в корзине остаётся занятым до её окончательного удаления.
+func (s *Storage) AliasExists(alias string) (bool, error) {
+	const op = "storage.sqlite.AliasExists"
+
+	var exists int
+	err := s.db.QueryRow("SELECT 1 FROM urls WHERE "+s.aliasMatch(), s.aliasKey(alias)).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return true, nil
+}
+
+// Метод для удаления URL по алиасу и проверке владельца (user_id).
+// Ссылка не удаляется, а попадает в корзину: метки и статистика сохраняются
+// до окончательного удаления через PurgeDeletedURLs. Дополнительные alias ссылки
+// попадают в корзину вместе с ней.
 func (s *Storage) DeleteURL(alias string, userID int64) error {
 	const op = "storage.sqlite.DeleteURL"
 
-	var dbUserID int64
-	err := s.db.QueryRow("SELECT user_id FROM urls WHERE alias = ?", alias).Scan(&dbUserID)
+	var (
+		dbUserID int64
+		stored   string
+	)
+	err := s.db.QueryRow("SELECT user_id, alias FROM urls WHERE "+s.aliasMatch()+" AND "+notDeleted, s.aliasKey(alias)).Scan(&dbUserID, &stored)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return fmt.Errorf("%s: url not found: %w", op, storage.ErrURLNotFound)
@@ -158,12 +693,13 @@ func (s *Storage) DeleteURL(alias string, userID int64) error {
 		return fmt.Errorf("%s: unauthorized: %w", op, storage.ErrUnauthorized)
 	}
 
-	stmt, err := s.db.Prepare("DELETE FROM urls WHERE alias = ?")
-	if err != nil {
-		return fmt.Errorf("%s: prepare statement: %w", op, err)
-	}
-
-	_, err = stmt.Exec(alias)
+	err = s.withRetry(func() error {
+		_, err := s.db.Exec(
+			"UPDATE urls SET deleted_at = ? WHERE "+s.linkedMatch()+" AND user_id = ? AND "+notDeleted,
+			time.Now().UTC(), s.aliasKey(alias), stored, userID,
+		)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("%s: execute statement: %w", op, err)
 	}
@@ -171,48 +707,834 @@ func (s *Storage) DeleteURL(alias string, userID int64) error {
 	return nil
 }
 
-// Метод для сохранения пользователя
-func (s *Storage) SaveUser(nickname, passwordHash string) (int64, error) {
-	const op = "storage.sqlite.SaveUser"
+// Метод для восстановления ссылки пользователя из корзины. Дополнительные alias, удалённые
+// вместе с ней, восстанавливаются тоже. ErrURLNotFound - ссылки нет в корзине или она попала
+// туда раньше deletedAfter (срок восстановления истёк), ErrUnauthorized - ссылка чужая.
+func (s *Storage) RestoreURL(alias string, userID int64, deletedAfter time.Time) error {
+	const op = "storage.sqlite.RestoreURL"
 
-	stmt, err := s.db.Prepare("INSERT INTO users(nickname, password_hash) VALUES(?, ?)")
+	var (
+		dbUserID  int64
+		stored    string
+		deletedAt time.Time
+	)
+	err := s.db.QueryRow(
+		"SELECT user_id, alias, deleted_at FROM urls WHERE "+s.aliasMatch()+" AND deleted_at IS NOT NULL",
+		s.aliasKey(alias),
+	).Scan(&dbUserID, &stored, &deletedAt)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: url not found: %w", op, storage.ErrURLNotFound)
+		}
+		return fmt.Errorf("%s: query error: %w", op, err)
 	}
-	defer stmt.Close()
 
-	// Выполняем запрос
-	res, err := stmt.Exec(nickname, passwordHash)
-	if err != nil {
-		// Проверяем на уникальное ограничение
-		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
-			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserExists)
-		}
-		return 0, fmt.Errorf("%s: %w", op, err)
+	if dbUserID != userID {
+		return fmt.Errorf("%s: unauthorized: %w", op, storage.ErrUnauthorized)
+	}
+	if deletedAt.Before(deletedAfter) {
+		return fmt.Errorf("%s: restore window expired: %w", op, storage.ErrURLNotFound)
 	}
 
-	// Получаем ID последней вставленной записи
-	id, err := res.LastInsertId()
+	// Дополнительные alias, удалённые отдельно и раньше, остаются в корзине
+	err = s.withRetry(func() error {
+		_, err := s.db.Exec(
+			"UPDATE urls SET deleted_at = NULL WHERE "+s.linkedMatch()+" AND user_id = ?"+
+				" AND deleted_at = (SELECT deleted_at FROM urls WHERE "+s.aliasMatch()+")",
+			s.aliasKey(alias), stored, userID, s.aliasKey(alias),
+		)
+		return err
+	})
 	if err != nil {
-		return 0, fmt.Errorf("%s: failed to get last insert id: %w", op, err)
+		return fmt.Errorf("%s: execute statement: %w", op, err)
 	}
 
-	return id, nil
+	return nil
 }
 
-// Метод для получения пользователя по никнейму
-func (s *Storage) GetUserByNickname(nickname string) (int64, string, error) {
-	const op = "storage.sqlite.GetUserByNickname"
+// Метод для отката только что сохранённой ссылки, когда её не приняла вторая база.
+// Ссылка удаляется сразу, минуя корзину, чтобы alias снова был свободен.
+func (s *Storage) DiscardURL(alias string) error {
+	const op = "storage.sqlite.DiscardURL"
 
-	stmt, err := s.db.Prepare("SELECT id, password_hash FROM users WHERE nickname = ?")
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(
+			"DELETE FROM url_tags WHERE url_id IN (SELECT id FROM urls WHERE "+s.aliasMatch()+")",
+			s.aliasKey(alias),
+		); err != nil {
+			return fmt.Errorf("delete tags: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM urls WHERE "+s.aliasMatch(), s.aliasKey(alias)); err != nil {
+			return fmt.Errorf("delete url: %w", err)
+		}
+
+		return tx.Commit()
+	})
 	if err != nil {
-		return 0, "", fmt.Errorf("%s: prepare statement: %w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	var id int64
+	return nil
+}
+
+// Метод для окончательного удаления всех ссылок пользователя из корзины.
+// Возвращает количество удалённых ссылок.
+func (s *Storage) PurgeDeletedURLs(userID int64) (int64, error) {
+	const op = "storage.sqlite.PurgeDeletedURLs"
+
+	purged, err := s.purgeDeleted("user_id = ? AND deleted_at IS NOT NULL", userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return purged, nil
+}
+
+// Метод для окончательного удаления ссылок всех пользователей, перенесённых в корзину
+// раньше deletedBefore, то есть с истёкшим сроком восстановления.
+// Возвращает количество удалённых ссылок.
+func (s *Storage) PurgeExpiredURLs(deletedBefore time.Time) (int64, error) {
+	const op = "storage.sqlite.PurgeExpiredURLs"
+
+	purged, err := s.purgeDeleted("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return purged, nil
+}
+
+// purgeDeleted удаляет ссылки из корзины по условию where вместе с их метками и статистикой
+func (s *Storage) purgeDeleted(where string, args ...any) (int64, error) {
+	var purged int64
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		// Статистика удалённой ссылки не должна достаться тому, кто займёт alias следующим
+		_, err = tx.Exec("DELETE FROM clicks WHERE alias IN (SELECT "+s.aliasColumn()+" FROM urls WHERE "+where+")", args...)
+		if err != nil {
+			return fmt.Errorf("delete clicks: %w", err)
+		}
+
+		_, err = tx.Exec("DELETE FROM url_tags WHERE url_id IN (SELECT id FROM urls WHERE "+where+")", args...)
+		if err != nil {
+			return fmt.Errorf("delete tags: %w", err)
+		}
+
+		res, err := tx.Exec("DELETE FROM urls WHERE "+where, args...)
+		if err != nil {
+			return fmt.Errorf("delete urls: %w", err)
+		}
+
+		purged, err = res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+
+		return tx.Commit()
+	})
+
+	return purged, err
+}
+
+// Метод для переименования ссылки пользователя. Адрес, метки и статистика переходов
+// переходят к newAlias. ErrURLNotFound - ссылки нет, ErrUnauthorized - она чужая,
+// ErrURLExists/ErrAliasTaken - newAlias уже занят.
+func (s *Storage) RenameAlias(alias, newAlias string, userID int64) error {
+	const op = "storage.sqlite.RenameAlias"
+
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		var ownerID int64
+		err = tx.QueryRow("SELECT user_id FROM urls WHERE "+s.aliasMatch()+" AND "+notDeleted, s.aliasKey(alias)).Scan(&ownerID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrURLNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("get url: %w", err)
+		}
+		if ownerID != userID {
+			return storage.ErrUnauthorized
+		}
+
+		if err := prefixConflict(tx, newAlias, userID); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			"UPDATE urls SET alias = ?, alias_lower = ? WHERE "+s.aliasMatch(),
+			newAlias, strings.ToLower(newAlias), s.aliasKey(alias),
+		)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("UPDATE clicks SET alias = ? WHERE alias = ?", s.aliasKey(newAlias), s.aliasKey(alias)); err != nil {
+			return fmt.Errorf("move clicks: %w", err)
+		}
+
+		// Дополнительные alias остаются привязанными к переименованной ссылке
+		if _, err := tx.Exec("UPDATE urls SET linked_to = ? WHERE user_id = ? AND linked_to = ?", newAlias, userID, alias); err != nil {
+			return fmt.Errorf("move linked aliases: %w", err)
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return fmt.Errorf("%s: %w", op, s.aliasConflict(newAlias, userID))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Метод для продления срока действия ссылки пользователя; срок дополнительных alias
+// ссылки меняется вместе с ним. ErrURLNotFound - ссылки нет, ErrUnauthorized - она чужая, ErrURLNoExpiry - ссылка бессрочная,
+// ErrURLExpired - срок уже истёк и ссылку нужно восстанавливать, а не продлевать.
+func (s *Storage) ExtendURLExpiry(alias string, userID int64, expiresAt time.Time) error {
+	const op = "storage.sqlite.ExtendURLExpiry"
+
+	var (
+		current sql.NullTime
+		stored  string
+		ownerID int64
+	)
+	err := s.db.QueryRow(
+		"SELECT expires_at, alias, user_id FROM urls WHERE "+s.aliasMatch()+" AND "+notDeleted,
+		s.aliasKey(alias),
+	).Scan(&current, &stored, &ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrURLNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: get expiry: %w", op, err)
+	}
+	if ownerID != userID {
+		return storage.ErrUnauthorized
+	}
+
+	if !current.Valid {
+		return storage.ErrURLNoExpiry
+	}
+	if storage.Expired(&current.Time, time.Now()) {
+		return storage.ErrURLExpired
+	}
+
+	err = s.withRetry(func() error {
+		_, err := s.db.Exec(
+			"UPDATE urls SET expires_at = ? WHERE "+s.linkedMatch()+" AND user_id = ? AND "+notDeleted,
+			expiresAt.UTC(), s.aliasKey(alias), stored, userID,
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// Метод для смены видимости ссылки и её дополнительных alias: публичная ссылка открывается
+// без авторизации через /r/{alias}.
+// ErrURLNotFound - ссылки нет, ErrUnauthorized - она чужая.
+func (s *Storage) SetURLVisibility(alias string, isPublic bool, userID int64) error {
+	const op = "storage.sqlite.SetURLVisibility"
+
+	var (
+		ownerID int64
+		stored  string
+	)
+	err := s.db.QueryRow("SELECT user_id, alias FROM urls WHERE "+s.aliasMatch()+" AND "+notDeleted, s.aliasKey(alias)).Scan(&ownerID, &stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: query error: %w", op, err)
+	}
+	if ownerID != userID {
+		return fmt.Errorf("%s: %w", op, storage.ErrUnauthorized)
+	}
+
+	err = s.withRetry(func() error {
+		_, err := s.db.Exec(
+			"UPDATE urls SET is_public = ? WHERE "+s.linkedMatch()+" AND user_id = ? AND "+notDeleted,
+			isPublic, s.aliasKey(alias), stored, userID,
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// Метод для изменения описания ссылки владельцем. Пустое описание удаляет его.
+func (s *Storage) UpdateURLDescription(alias string, userID int64, description string) error {
+	const op = "storage.sqlite.UpdateURLDescription"
+
+	var ownerID int64
+	err := s.db.QueryRow("SELECT user_id FROM urls WHERE "+s.aliasMatch()+" AND "+notDeleted, s.aliasKey(alias)).Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: query error: %w", op, err)
+	}
+	if ownerID != userID {
+		return fmt.Errorf("%s: %w", op, storage.ErrUnauthorized)
+	}
+
+	err = s.withRetry(func() error {
+		_, err := s.db.Exec(
+			"UPDATE urls SET description = ? WHERE "+s.aliasMatch()+" AND user_id = ? AND "+notDeleted,
+			sql.NullString{String: description, Valid: description != ""}, s.aliasKey(alias), userID,
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// Метод для сохранения метаданных превью ссылки. Прежние метаданные заменяются целиком.
+func (s *Storage) SetURLPreview(alias string, userID int64, preview storage.Preview) error {
+	const op = "storage.sqlite.SetURLPreview"
+
+	var ownerID int64
+	err := s.db.QueryRow("SELECT user_id FROM urls WHERE "+s.aliasMatch()+" AND "+notDeleted, s.aliasKey(alias)).Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: query error: %w", op, err)
+	}
+	if ownerID != userID {
+		return fmt.Errorf("%s: %w", op, storage.ErrUnauthorized)
+	}
+
+	err = s.withRetry(func() error {
+		_, err := s.db.Exec(
+			"UPDATE urls SET preview_title = ?, preview_description = ?, preview_image = ?, preview_fetched_at = ? WHERE "+s.aliasMatch()+" AND user_id = ? AND "+notDeleted,
+			preview.Title, preview.Description, preview.Image, preview.FetchedAt.UTC(), s.aliasKey(alias), userID,
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// Метод для закрепления префикса alias за пользователем: после этого alias, начинающиеся
+// с префикса, может создавать только он. Уже существующие ссылки других пользователей не затрагиваются.
+// Повторный захват своего префикса ничего не меняет. ErrPrefixTaken - префикс пересекается
+// с чужим (один из них начинается с другого), ErrTooManyPrefixes - у пользователя уже maxPerUser
+// префиксов (0 - без ограничения).
+func (s *Storage) ClaimPrefix(prefix string, userID int64, maxPerUser int) error {
+	const op = "storage.sqlite.ClaimPrefix"
+
+	prefix = storage.PrefixKey(prefix)
+
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		var ownerID int64
+		err = tx.QueryRow("SELECT user_id FROM alias_prefixes WHERE prefix = ?", prefix).Scan(&ownerID)
+		switch {
+		case err == nil && ownerID == userID:
+			return nil
+		case err == nil:
+			return storage.ErrPrefixTaken
+		case !errors.Is(err, sql.ErrNoRows):
+			return fmt.Errorf("get prefix: %w", err)
+		}
+
+		if maxPerUser > 0 {
+			var owned int
+			if err := tx.QueryRow("SELECT COUNT(*) FROM alias_prefixes WHERE user_id = ?", userID).Scan(&owned); err != nil {
+				return fmt.Errorf("count user prefixes: %w", err)
+			}
+			if owned >= maxPerUser {
+				return storage.ErrTooManyPrefixes
+			}
+		}
+
+		// Пересечение с чужими префиксами (один начинается с другого) проверяется
+		// в самой вставке, а не отдельным запросом перед ней
+		res, err := tx.Exec(`
+			INSERT INTO alias_prefixes (prefix, user_id, created_at)
+			SELECT ?, ?, ? WHERE NOT EXISTS (
+				SELECT 1 FROM alias_prefixes
+				WHERE user_id != ? AND (substr(?, 1, length(prefix)) = prefix OR substr(prefix, 1, length(?)) = ?)
+			)
+		`, prefix, userID, time.Now().UTC(), userID, prefix, prefix, prefix)
+		if err != nil {
+			return fmt.Errorf("insert prefix: %w", err)
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("insert prefix: %w", err)
+		}
+		if inserted == 0 {
+			return storage.ErrPrefixTaken
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey {
+			return fmt.Errorf("%s: %w", op, storage.ErrPrefixTaken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Метод для поиска владельца префикса, под который попадает alias.
+// ErrPrefixNotFound - alias не начинается ни с одного занятого префикса.
+func (s *Storage) GetPrefixOwner(alias string) (int64, error) {
+	const op = "storage.sqlite.GetPrefixOwner"
+
+	var ownerID int64
+	err := s.db.QueryRow(
+		"SELECT user_id FROM alias_prefixes WHERE substr(?, 1, length(prefix)) = prefix LIMIT 1",
+		storage.PrefixKey(alias),
+	).Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.ErrPrefixNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ownerID, nil
+}
+
+// Метод для записи перехода по ссылке. Вместе с переходом обновляется last_accessed_at ссылки.
+func (s *Storage) RecordClick(alias string, at time.Time) error {
+	const op = "storage.sqlite.RecordClick"
+
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec("INSERT INTO clicks (alias, clicked_at) VALUES (?, ?)", s.aliasKey(alias), at.Unix()); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE urls SET last_accessed_at = ? WHERE "+s.aliasMatch(), at.UTC(), s.aliasKey(alias)); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// Метод для подсчёта всех переходов по ссылке
+func (s *Storage) CountClicks(alias string) (int64, error) {
+	const op = "storage.sqlite.CountClicks"
+
+	var count int64
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM clicks WHERE alias = ?", s.aliasKey(alias)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// Метод для подсчёта переходов по интервалам bucket в диапазоне [from, to).
+// Интервалы без переходов в результат не попадают.
+func (s *Storage) ClickTimeseries(alias string, from, to time.Time, bucket time.Duration) ([]storage.ClickBucket, error) {
+	const op = "storage.sqlite.ClickTimeseries"
+
+	size := int64(bucket / time.Second)
+
+	rows, err := s.db.Query(`
+		SELECT clicked_at - clicked_at % ? AS bucket_start, COUNT(*)
+		FROM clicks
+		WHERE alias = ? AND clicked_at >= ? AND clicked_at < ?
+		GROUP BY bucket_start
+		ORDER BY bucket_start
+	`, size, s.aliasKey(alias), from.Unix(), to.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	buckets := make([]storage.ClickBucket, 0)
+	for rows.Next() {
+		var start, clicks int64
+		if err := rows.Scan(&start, &clicks); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		buckets = append(buckets, storage.ClickBucket{Start: time.Unix(start, 0).UTC(), Clicks: clicks})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", op, err)
+	}
+
+	return buckets, nil
+}
+
+// Метод для получения статистики всех ссылок пользователя одним запросом.
+// sort - storage.StatsSortClicks (по убыванию переходов) или по умолчанию от новых к старым.
+func (s *Storage) LinkStats(userID int64, sort string, limit, offset int) ([]storage.LinkStats, error) {
+	const op = "storage.sqlite.LinkStats"
+
+	order := "u.created_at DESC"
+	if sort == storage.StatsSortClicks {
+		order = "clicks DESC"
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT u.alias, u.description, u.created_at, COUNT(c.id) AS clicks, MAX(c.clicked_at)
+		FROM urls u
+		LEFT JOIN clicks c ON c.alias = u.%s
+		WHERE u.user_id = ? AND u.deleted_at IS NULL
+		GROUP BY u.id
+		ORDER BY %s, u.alias
+		LIMIT ? OFFSET ?
+	`, s.aliasColumn(), order), userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	stats := make([]storage.LinkStats, 0)
+	for rows.Next() {
+		var (
+			st          storage.LinkStats
+			description sql.NullString
+			createdAt   sql.NullTime
+			lastClickAt sql.NullInt64
+		)
+		if err := rows.Scan(&st.Alias, &description, &createdAt, &st.Clicks, &lastClickAt); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		st.Description = description.String
+		st.CreatedAt = createdAt.Time
+		if lastClickAt.Valid {
+			t := time.Unix(lastClickAt.Int64, 0).UTC()
+			st.LastClickAt = &t
+		}
+		stats = append(stats, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", op, err)
+	}
+
+	return stats, nil
+}
+
+// GetTagCounts возвращает метки живых ссылок пользователя с числом ссылок, от частых к редким.
+// limit <= 0 - без ограничения.
+func (s *Storage) GetTagCounts(userID int64, limit int) ([]storage.TagCount, error) {
+	const op = "storage.sqlite.GetTagCounts"
+
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := s.db.Query(`
+		SELECT t.tag, COUNT(*) AS cnt
+		FROM url_tags t
+		JOIN urls u ON u.id = t.url_id
+		WHERE u.user_id = ? AND u.deleted_at IS NULL
+		GROUP BY t.tag
+		ORDER BY cnt DESC, t.tag
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	counts := make([]storage.TagCount, 0)
+	for rows.Next() {
+		var tc storage.TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		counts = append(counts, tc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", op, err)
+	}
+
+	return counts, nil
+}
+
+// RecordLoginEvent сохраняет попытку входа пользователя. У пользователя остаются
+// только keep последних событий; keep <= 0 - хранятся все.
+func (s *Storage) RecordLoginEvent(userID int64, event storage.LoginEvent, keep int) error {
+	const op = "storage.sqlite.RecordLoginEvent"
+
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(
+			"INSERT INTO login_events (user_id, at, ip, user_agent, result) VALUES (?, ?, ?, ?, ?)",
+			userID, event.At.UTC(), event.IP, event.UserAgent, event.Result,
+		); err != nil {
+			return err
+		}
+
+		if keep > 0 {
+			if _, err := tx.Exec(`
+				DELETE FROM login_events WHERE user_id = ? AND id NOT IN (
+					SELECT id FROM login_events WHERE user_id = ? ORDER BY at DESC, id DESC LIMIT ?
+				)
+			`, userID, userID, keep); err != nil {
+				return err
+			}
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetLoginEvents возвращает страницу попыток входа пользователя, от новых к старым
+func (s *Storage) GetLoginEvents(userID int64, limit, offset int) ([]storage.LoginEvent, error) {
+	const op = "storage.sqlite.GetLoginEvents"
+
+	rows, err := s.db.Query(`
+		SELECT at, ip, user_agent, result FROM login_events
+		WHERE user_id = ?
+		ORDER BY at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	events := make([]storage.LoginEvent, 0)
+	for rows.Next() {
+		var event storage.LoginEvent
+		if err := rows.Scan(&event.At, &event.IP, &event.UserAgent, &event.Result); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		event.At = event.At.UTC()
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", op, err)
+	}
+
+	return events, nil
+}
+
+// Метод для сохранения пользователя
+func (s *Storage) SaveUser(nickname, passwordHash string) (int64, error) {
+	const op = "storage.sqlite.SaveUser"
+
+	stmt, err := s.db.Prepare("INSERT INTO users(nickname, display_name, password_hash, created_at) VALUES(?, ?, ?, ?)")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	// Выполняем запрос
+	var res sql.Result
+	err = s.withRetry(func() error {
+		var err error
+		res, err = stmt.Exec(s.nicknameKey(nickname), nickname, passwordHash, time.Now().UTC())
+		return err
+	})
+	if err != nil {
+		// Проверяем на уникальное ограничение
+		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Получаем ID последней вставленной записи
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get last insert id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// Метод для сохранения пользователя с заранее выданным id. Нужен, когда id выдаёт MongoDB
+// как основная база.
+func (s *Storage) SaveUserWithID(userID int64, nickname, passwordHash string) error {
+	const op = "storage.sqlite.SaveUserWithID"
+
+	err := s.withRetry(func() error {
+		_, err := s.db.Exec(
+			"INSERT INTO users(id, nickname, display_name, password_hash, created_at) VALUES(?, ?, ?, ?, ?)",
+			userID, s.nicknameKey(nickname), nickname, passwordHash, time.Now().UTC(),
+		)
+		return err
+	})
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return fmt.Errorf("%s: %w", op, storage.ErrUserExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Метод для получения наибольшего id пользователя; 0 - пользователей нет.
+// По нему MongoDB сдвигает свой счётчик id, когда становится основной базой.
+func (s *Storage) MaxUserID() (int64, error) {
+	const op = "storage.sqlite.MaxUserID"
+
+	var maxID int64
+	if err := s.db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM users").Scan(&maxID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return maxID, nil
+}
+
+// Метод для отката только что созданного пользователя, когда его не приняла вторая база.
+// В отличие от DeleteUserByNickname не требует наличия ссылок.
+func (s *Storage) DiscardUser(userID int64) error {
+	const op = "storage.sqlite.DiscardUser"
+
+	err := s.withRetry(func() error {
+		_, err := s.db.Exec("DELETE FROM users WHERE id = ?", userID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Метод для получения настроек пользователя
+func (s *Storage) GetUserSettings(userID int64) (storage.UserSettings, error) {
+	const op = "storage.sqlite.GetUserSettings"
+
+	var aliasLength, redirectCode, ttlSeconds sql.NullInt64
+	var allowDuplicates sql.NullBool
+	var redirectTemplate sql.NullString
+
+	err := s.db.QueryRow(
+		"SELECT default_alias_length, default_redirect_status, default_ttl_seconds, allow_duplicate_urls, redirect_template FROM users WHERE id = ?",
+		userID,
+	).Scan(&aliasLength, &redirectCode, &ttlSeconds, &allowDuplicates, &redirectTemplate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.UserSettings{}, storage.ErrUserNotFound
+		}
+		return storage.UserSettings{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	var settings storage.UserSettings
+	if aliasLength.Valid {
+		length := int(aliasLength.Int64)
+		settings.DefaultAliasLength = &length
+	}
+	if redirectCode.Valid {
+		status := int(redirectCode.Int64)
+		settings.DefaultRedirectStatus = &status
+	}
+	if ttlSeconds.Valid {
+		settings.DefaultTTLSeconds = &ttlSeconds.Int64
+	}
+	if allowDuplicates.Valid {
+		settings.AllowDuplicateURLs = &allowDuplicates.Bool
+	}
+	settings.RedirectTemplate = redirectTemplate.String
+
+	return settings, nil
+}
+
+// Метод для сохранения настроек пользователя целиком
+func (s *Storage) SaveUserSettings(userID int64, settings storage.UserSettings) error {
+	const op = "storage.sqlite.SaveUserSettings"
+
+	var res sql.Result
+	err := s.withRetry(func() error {
+		var err error
+		res, err = s.db.Exec(
+			"UPDATE users SET default_alias_length = ?, default_redirect_status = ?, default_ttl_seconds = ?, allow_duplicate_urls = ?, redirect_template = ? WHERE id = ?",
+			settings.DefaultAliasLength, settings.DefaultRedirectStatus, settings.DefaultTTLSeconds, settings.AllowDuplicateURLs,
+			sql.NullString{String: settings.RedirectTemplate, Valid: settings.RedirectTemplate != ""}, userID,
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// Метод для получения пользователя по никнейму
+func (s *Storage) GetUserByNickname(nickname string) (int64, string, error) {
+	const op = "storage.sqlite.GetUserByNickname"
+
+	stmt, err := s.db.Prepare("SELECT id, password_hash FROM users WHERE nickname = ?")
+	if err != nil {
+		return 0, "", fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+
+	var id int64
 	var passwordHash string
 
-	err = stmt.QueryRow(nickname).Scan(&id, &passwordHash)
+	err = stmt.QueryRow(s.nicknameKey(nickname)).Scan(&id, &passwordHash)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return 0, "", storage.ErrUserNotFound
@@ -223,8 +1545,382 @@ func (s *Storage) GetUserByNickname(nickname string) (int64, string, error) {
 	return id, passwordHash, nil
 }
 
-// Метод для удаления пользователя и связанных URL по user_id
+// Метод для получения профиля пользователя (без хэша пароля)
+func (s *Storage) GetUser(nickname string) (storage.User, error) {
+	const op = "storage.sqlite.GetUser"
+
+	var (
+		user      storage.User
+		createdAt sql.NullTime
+	)
+
+	err := s.db.QueryRow("SELECT id, COALESCE(display_name, nickname), created_at FROM users WHERE nickname = ?", s.nicknameKey(nickname)).
+		Scan(&user.ID, &user.Nickname, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.User{}, storage.ErrUserNotFound
+		}
+		return storage.User{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	user.CreatedAt = createdAt.Time
+
+	return user, nil
+}
+
+// urlColumns - колонки urls, из которых собирается storage.URL (см. scanURL)
+const urlColumns = "alias, url, domain, created_at, expires_at, is_public, wildcard, forward_query, redirect_status, last_accessed_at, deleted_at, last_status, last_checked_at, linked_to, " +
+	"preview_title, preview_description, preview_image, preview_fetched_at, user_id, description, " +
+	"(SELECT group_concat(tag, char(31)) FROM url_tags WHERE url_tags.url_id = urls.id)"
+
+// tagSeparator разделяет метки в group_concat из urlColumns
+const tagSeparator = "\x1f"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanURL(row rowScanner) (storage.URL, error) {
+	var (
+		u              storage.URL
+		urlDomain      sql.NullString
+		createdAt      sql.NullTime
+		expiresAt      sql.NullTime
+		lastAccessedAt sql.NullTime
+		deletedAt      sql.NullTime
+		lastStatus     sql.NullInt64
+		lastCheckedAt  sql.NullTime
+		linkedTo       sql.NullString
+		previewTitle   sql.NullString
+		previewDesc    sql.NullString
+		previewImage   sql.NullString
+		previewAt      sql.NullTime
+		userID         sql.NullInt64
+		description    sql.NullString
+		tags           sql.NullString
+	)
+	if err := row.Scan(&u.Alias, &u.URL, &urlDomain, &createdAt, &expiresAt, &u.Public, &u.Wildcard, &u.ForwardQuery, &u.RedirectStatus, &lastAccessedAt, &deletedAt, &lastStatus, &lastCheckedAt, &linkedTo,
+		&previewTitle, &previewDesc, &previewImage, &previewAt, &userID, &description, &tags); err != nil {
+		return storage.URL{}, err
+	}
+	if tags.Valid {
+		u.Tags = strings.Split(tags.String, tagSeparator)
+		sort.Strings(u.Tags)
+	}
+	u.Domain = urlDomain.String
+	u.CreatedAt = createdAt.Time
+	if expiresAt.Valid {
+		u.ExpiresAt = &expiresAt.Time
+	}
+	if lastAccessedAt.Valid {
+		u.LastAccessedAt = &lastAccessedAt.Time
+	}
+	if deletedAt.Valid {
+		u.DeletedAt = &deletedAt.Time
+	}
+	if lastStatus.Valid {
+		status := int(lastStatus.Int64)
+		u.LastStatus = &status
+	}
+	if lastCheckedAt.Valid {
+		u.LastCheckedAt = &lastCheckedAt.Time
+	}
+	u.LinkedTo = linkedTo.String
+	if previewAt.Valid {
+		u.Preview = &storage.Preview{
+			Title:       previewTitle.String,
+			Description: previewDesc.String,
+			Image:       previewImage.String,
+			FetchedAt:   previewAt.Time,
+		}
+	}
+	u.Description = description.String
+	u.UserID = userID.Int64
+
+	return u, nil
+}
+
+// Метод для получения всех URL пользователя
+func (s *Storage) GetURLsByUser(userID int64) ([]storage.URL, error) {
+	const op = "storage.sqlite.GetURLsByUser"
+
+	rows, err := s.db.Query("SELECT "+urlColumns+" FROM urls WHERE user_id = ? AND "+notDeleted+" ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	urls := make([]storage.URL, 0)
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		urls = append(urls, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// Метод для получения ссылок пользователя из корзины, от недавно удалённых к давним
+func (s *Storage) GetDeletedURLsByUser(userID int64) ([]storage.URL, error) {
+	const op = "storage.sqlite.GetDeletedURLsByUser"
+
+	rows, err := s.db.Query("SELECT "+urlColumns+" FROM urls WHERE user_id = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	urls := make([]storage.URL, 0)
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		urls = append(urls, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// Метод для получения ссылок пользователя, цель которых не ответила или ответила ошибкой
+// при последней фоновой проверке. Сначала недавно проверенные.
+func (s *Storage) GetBrokenURLsByUser(userID int64) ([]storage.URL, error) {
+	const op = "storage.sqlite.GetBrokenURLsByUser"
+
+	rows, err := s.db.Query(
+		"SELECT "+urlColumns+" FROM urls WHERE user_id = ? AND "+notDeleted+
+			" AND last_status IS NOT NULL AND (last_status = 0 OR last_status >= 400) ORDER BY last_checked_at DESC, id",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	urls := make([]storage.URL, 0)
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		urls = append(urls, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// Метод для получения ссылок всех пользователей для фоновой проверки: не проверявшиеся
+// или проверенные раньше checkedBefore, начиная с давно не проверявшихся
+func (s *Storage) GetURLsToCheck(checkedBefore time.Time, limit int) ([]storage.URL, error) {
+	const op = "storage.sqlite.GetURLsToCheck"
+
+	rows, err := s.db.Query(
+		"SELECT "+urlColumns+" FROM urls WHERE "+notDeleted+" AND (last_checked_at IS NULL OR last_checked_at < ?)"+
+			" ORDER BY last_checked_at IS NOT NULL, last_checked_at, id LIMIT ?",
+		checkedBefore.UTC(), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	urls := make([]storage.URL, 0)
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		urls = append(urls, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// Метод для сохранения результата фоновой проверки ссылки. status == nil - проверка
+// пропущена (например, запрещена robots.txt): обновляется только время, статус остаётся прежним.
+func (s *Storage) SetURLHealth(alias string, status *int, checkedAt time.Time) error {
+	const op = "storage.sqlite.SetURLHealth"
+
+	var lastStatus sql.NullInt64
+	if status != nil {
+		lastStatus = sql.NullInt64{Int64: int64(*status), Valid: true}
+	}
+
+	err := s.withRetry(func() error {
+		_, err := s.db.Exec(
+			"UPDATE urls SET last_status = COALESCE(?, last_status), last_checked_at = ? WHERE "+s.aliasMatch()+" AND "+notDeleted,
+			lastStatus, checkedAt.UTC(), s.aliasKey(alias),
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// Метод для получения никнейма владельца ссылки
+func (s *Storage) GetURLOwner(alias string) (string, error) {
+	const op = "storage.sqlite.GetURLOwner"
+
+	var nickname string
+	err := s.db.QueryRow(
+		"SELECT COALESCE(users.display_name, users.nickname) FROM urls JOIN users ON users.id = urls.user_id WHERE urls."+s.aliasMatch()+" AND urls."+notDeleted,
+		s.aliasKey(alias),
+	).Scan(&nickname)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.ErrURLNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nickname, nil
+}
+
+// Метод для подсчёта ссылок пользователя
+func (s *Storage) CountURLsByUser(userID int64) (int64, error) {
+	const op = "storage.sqlite.CountURLsByUser"
+
+	var count int64
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM urls WHERE user_id = ? AND "+notDeleted, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// Метод для получения ссылок пользователя, по которым не переходили с момента olderThan.
+// Ссылка без переходов считается неиспользуемой, если создана раньше olderThan.
+func (s *Storage) GetStaleURLs(userID int64, olderThan time.Time) ([]storage.URL, error) {
+	const op = "storage.sqlite.GetStaleURLs"
+
+	rows, err := s.db.Query(
+		"SELECT "+urlColumns+" FROM urls WHERE user_id = ? AND "+notDeleted+" AND COALESCE(last_accessed_at, created_at, 0) < ? ORDER BY id",
+		userID, olderThan.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	urls := make([]storage.URL, 0)
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		urls = append(urls, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// Метод для получения нескольких ссылок пользователя одним запросом.
+// Чужие и несуществующие alias в результат не попадают.
+func (s *Storage) GetURLs(aliases []string, userID int64) ([]storage.URL, error) {
+	const op = "storage.sqlite.GetURLs"
+
+	if len(aliases) == 0 {
+		return []storage.URL{}, nil
+	}
+
+	args := make([]any, 0, len(aliases)+1)
+	for _, alias := range aliases {
+		args = append(args, s.aliasKey(alias))
+	}
+	args = append(args, userID)
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(aliases)), ", ")
+	rows, err := s.db.Query(
+		"SELECT "+urlColumns+" FROM urls WHERE "+s.aliasColumn()+" IN ("+placeholders+") AND user_id = ? AND "+notDeleted,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	urls := make([]storage.URL, 0, len(aliases))
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		urls = append(urls, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// Метод для получения ссылки по alias без проверки владельца
+func (s *Storage) GetLink(alias string) (storage.URL, error) {
+	const op = "storage.sqlite.GetLink"
+
+	u, err := scanURL(s.db.QueryRow("SELECT "+urlColumns+" FROM urls WHERE "+s.aliasMatch()+" AND "+notDeleted, s.aliasKey(alias)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.URL{}, storage.ErrURLNotFound
+		}
+		return storage.URL{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return u, nil
+}
+
+// Метод для передачи всех ссылок пользователя, в том числе из корзины, другому пользователю.
+// Метки и статистика переходов остаются при ссылках. Возвращает число переданных ссылок.
+func (s *Storage) TransferURLs(fromUserID, toUserID int64) (int64, error) {
+	const op = "storage.sqlite.TransferURLs"
+
+	var moved int64
+	err := s.withRetry(func() error {
+		res, err := s.db.Exec("UPDATE urls SET user_id = ? WHERE user_id = ?", toUserID, fromUserID)
+		if err != nil {
+			return fmt.Errorf("execute statement: %w", err)
+		}
+
+		moved, err = res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return moved, nil
+}
+
+// Метод для удаления пользователя и связанных URL по user_id.
+// При блокировке базы транзакция повторяется целиком.
 func (s *Storage) DeleteUserByNickname(nickname string) error {
+	return s.withRetry(func() error { return s.deleteUserByNickname(nickname) })
+}
+
+func (s *Storage) deleteUserByNickname(nickname string) error {
 	const op = "storage.sqlite.DeleteUserByNickname"
 
 	// Начало транзакции
@@ -236,7 +1932,7 @@ func (s *Storage) DeleteUserByNickname(nickname string) error {
 
 	// Получение userID по nickname
 	var userID int64
-	err = tx.QueryRow("SELECT id FROM users WHERE nickname = ?", nickname).Scan(&userID)
+	err = tx.QueryRow("SELECT id FROM users WHERE nickname = ?", s.nicknameKey(nickname)).Scan(&userID)
 	if err != nil {
 		return fmt.Errorf("%s: execute get user ID statement: %w", op, err)
 	}
@@ -252,7 +1948,20 @@ func (s *Storage) DeleteUserByNickname(nickname string) error {
 		return fmt.Errorf("%s: no URLs found for user", op)
 	}
 
-	// Удаление всех URL, связанных с пользователем
+	// Занятые пользователем префиксы освобождаются
+	if _, err := tx.Exec("DELETE FROM alias_prefixes WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("%s: delete prefixes: %w", op, err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM login_events WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("%s: delete login events: %w", op, err)
+	}
+
+	// Удаление меток и всех URL, связанных с пользователем
+	if _, err := tx.Exec("DELETE FROM url_tags WHERE url_id IN (SELECT id FROM urls WHERE user_id = ?)", userID); err != nil {
+		return fmt.Errorf("%s: delete tags: %w", op, err)
+	}
+
 	stmtDeleteURLs, err := tx.Prepare("DELETE FROM urls WHERE user_id = ?")
 	if err != nil {
 		return fmt.Errorf("%s: prepare delete URLs statement: %w", op, err)
@@ -0,0 +1,1149 @@
+package sqlite_test
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/base62"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/sqlite"
+)
+
+func newStorage(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestSaveURL_AliasConflict(t *testing.T) {
+	s := newStorage(t)
+
+	ownerID, err := s.SaveUser("owner", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://google.com", "taken", ownerID, storage.URLOptions{}))
+
+	t.Run("Own duplicate", func(t *testing.T) {
+		err := s.SaveURL("https://google.com", "taken", ownerID, storage.URLOptions{})
+		require.ErrorIs(t, err, storage.ErrURLExists)
+	})
+
+	t.Run("Foreign conflict", func(t *testing.T) {
+		err := s.SaveURL("https://example.com", "taken", otherID, storage.URLOptions{})
+		require.ErrorIs(t, err, storage.ErrAliasTaken)
+	})
+}
+
+func TestGetURLsByUser_Domain(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://www.example.co.uk/path", "uk", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("http://127.0.0.1:8080/", "ip", userID, storage.URLOptions{}))
+
+	urls, err := s.GetURLsByUser(userID)
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+
+	require.Equal(t, "example.co.uk", urls[0].Domain)
+	require.Equal(t, "", urls[1].Domain)
+	require.False(t, urls[0].CreatedAt.IsZero())
+}
+
+func TestGetURL_Expired(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+
+	past := time.Now().Add(-time.Minute).UTC()
+	future := time.Now().Add(time.Hour).UTC()
+
+	require.NoError(t, s.SaveURL("https://google.com", "expired", userID, storage.URLOptions{ExpiresAt: &past}))
+	require.NoError(t, s.SaveURL("https://google.com", "active", userID, storage.URLOptions{ExpiresAt: &future}))
+
+	_, err = s.GetURL("expired", userID)
+	require.ErrorIs(t, err, storage.ErrURLExpired)
+
+	url, err := s.GetURL("active", userID)
+	require.NoError(t, err)
+	require.Equal(t, "https://google.com", url)
+}
+
+func TestGetURLs(t *testing.T) {
+	s := newStorage(t)
+
+	ownerID, err := s.SaveUser("owner", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://google.com", "owned", ownerID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://example.com", "foreign", otherID, storage.URLOptions{}))
+
+	urls, err := s.GetURLs([]string{"owned", "foreign", "missing"}, ownerID)
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	require.Equal(t, "owned", urls[0].Alias)
+	require.Equal(t, "https://google.com", urls[0].URL)
+}
+
+func TestClickTimeseries(t *testing.T) {
+	s := newStorage(t)
+
+	at := func(d, h, m int) time.Time {
+		return time.Date(2024, time.January, d, h, m, 0, 0, time.UTC)
+	}
+
+	for _, clickedAt := range []time.Time{
+		at(1, 0, 0), at(1, 10, 30), at(1, 23, 59),
+		at(3, 12, 0),
+		at(5, 0, 0), // за пределами диапазона
+	} {
+		require.NoError(t, s.RecordClick("alias", clickedAt))
+	}
+	require.NoError(t, s.RecordClick("other", at(1, 10, 0)))
+
+	t.Run("Day", func(t *testing.T) {
+		buckets, err := s.ClickTimeseries("alias", at(1, 0, 0), at(5, 0, 0), 24*time.Hour)
+		require.NoError(t, err)
+
+		require.Equal(t, []storage.ClickBucket{
+			{Start: at(1, 0, 0), Clicks: 3},
+			{Start: at(3, 0, 0), Clicks: 1},
+		}, buckets)
+	})
+
+	t.Run("Hour", func(t *testing.T) {
+		buckets, err := s.ClickTimeseries("alias", at(1, 0, 0), at(2, 0, 0), time.Hour)
+		require.NoError(t, err)
+
+		require.Equal(t, []storage.ClickBucket{
+			{Start: at(1, 0, 0), Clicks: 1},
+			{Start: at(1, 10, 0), Clicks: 1},
+			{Start: at(1, 23, 0), Clicks: 1},
+		}, buckets)
+	})
+}
+
+func TestCaseInsensitiveAliases(t *testing.T) {
+	s := newStorage(t)
+	require.NoError(t, s.UseCaseInsensitiveAliases(true))
+
+	ownerID, err := s.SaveUser("owner", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://google.com", "MyLink", ownerID, storage.URLOptions{}))
+
+	t.Run("Claiming another case fails", func(t *testing.T) {
+		err := s.SaveURL("https://example.com", "mylink", otherID, storage.URLOptions{})
+		require.ErrorIs(t, err, storage.ErrAliasTaken)
+	})
+
+	t.Run("Resolves in any case, keeps original case", func(t *testing.T) {
+		link, err := s.GetLink("MYLINK")
+		require.NoError(t, err)
+		require.Equal(t, "MyLink", link.Alias)
+		require.Equal(t, "https://google.com", link.URL)
+
+		url, err := s.GetURL("mylink", ownerID)
+		require.NoError(t, err)
+		require.Equal(t, "https://google.com", url)
+	})
+
+	t.Run("Case-sensitive mode", func(t *testing.T) {
+		require.NoError(t, s.UseCaseInsensitiveAliases(false))
+		t.Cleanup(func() { require.NoError(t, s.UseCaseInsensitiveAliases(true)) })
+
+		_, err := s.GetLink("MYLINK")
+		require.ErrorIs(t, err, storage.ErrURLNotFound)
+	})
+}
+
+func TestLinkStats(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://google.com", "first", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://example.com", "second", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://example.org", "third", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://example.net", "foreign", otherID, storage.URLOptions{}))
+
+	last := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{last.Add(-time.Hour), last} {
+		require.NoError(t, s.RecordClick("second", at))
+	}
+	require.NoError(t, s.RecordClick("third", last.Add(-2*time.Hour)))
+	require.NoError(t, s.RecordClick("foreign", last))
+
+	t.Run("Aggregated by clicks", func(t *testing.T) {
+		stats, err := s.LinkStats(userID, storage.StatsSortClicks, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, stats, 3)
+
+		require.Equal(t, "second", stats[0].Alias)
+		require.Equal(t, int64(2), stats[0].Clicks)
+		require.NotNil(t, stats[0].LastClickAt)
+		require.True(t, last.Equal(*stats[0].LastClickAt))
+		require.False(t, stats[0].CreatedAt.IsZero())
+
+		require.Equal(t, "third", stats[1].Alias)
+		require.Equal(t, int64(1), stats[1].Clicks)
+
+		require.Equal(t, "first", stats[2].Alias)
+		require.Equal(t, int64(0), stats[2].Clicks)
+		require.Nil(t, stats[2].LastClickAt)
+	})
+
+	t.Run("Paginated", func(t *testing.T) {
+		stats, err := s.LinkStats(userID, storage.StatsSortClicks, 2, 1)
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+		require.Equal(t, "third", stats[0].Alias)
+		require.Equal(t, "first", stats[1].Alias)
+	})
+}
+
+func TestGetTagCounts(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://a.com", "a", userID, storage.URLOptions{Tags: []string{"work", "go"}}))
+	require.NoError(t, s.SaveURL("https://b.com", "b", userID, storage.URLOptions{Tags: []string{"work", "home"}}))
+	require.NoError(t, s.SaveURL("https://c.com", "c", userID, storage.URLOptions{Tags: []string{"work", "go", "home"}}))
+	require.NoError(t, s.SaveURL("https://d.com", "d", userID, storage.URLOptions{Tags: []string{"go", "misc"}}))
+	require.NoError(t, s.SaveURL("https://e.com", "e", userID, storage.URLOptions{Tags: []string{"work"}}))
+	require.NoError(t, s.DeleteURL("e", userID))
+	require.NoError(t, s.SaveURL("https://f.com", "f", otherID, storage.URLOptions{Tags: []string{"work", "misc"}}))
+
+	t.Run("All tags", func(t *testing.T) {
+		counts, err := s.GetTagCounts(userID, 0)
+		require.NoError(t, err)
+		// Удалённые и чужие ссылки не учитываются, при равенстве - по алфавиту
+		require.Equal(t, []storage.TagCount{
+			{Tag: "go", Count: 3},
+			{Tag: "work", Count: 3},
+			{Tag: "home", Count: 2},
+			{Tag: "misc", Count: 1},
+		}, counts)
+	})
+
+	t.Run("Limited", func(t *testing.T) {
+		counts, err := s.GetTagCounts(userID, 2)
+		require.NoError(t, err)
+		require.Equal(t, []storage.TagCount{{Tag: "go", Count: 3}, {Tag: "work", Count: 3}}, counts)
+	})
+
+	t.Run("No tags", func(t *testing.T) {
+		noneID, err := s.SaveUser("none", "hash")
+		require.NoError(t, err)
+
+		counts, err := s.GetTagCounts(noneID, 0)
+		require.NoError(t, err)
+		require.Empty(t, counts)
+	})
+}
+
+func TestUpdateURLDescription(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://example.com", "noted", userID, storage.URLOptions{Description: "landing page"}))
+
+	link, err := s.GetLink("noted")
+	require.NoError(t, err)
+	require.Equal(t, "landing page", link.Description)
+
+	require.NoError(t, s.UpdateURLDescription("noted", userID, "campaign"))
+	links, err := s.GetURLsByUser(userID)
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	require.Equal(t, "campaign", links[0].Description)
+
+	stats, err := s.LinkStats(userID, storage.StatsSortCreated, 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, "campaign", stats[0].Description)
+
+	require.ErrorIs(t, s.UpdateURLDescription("noted", otherID, "mine"), storage.ErrUnauthorized)
+	require.ErrorIs(t, s.UpdateURLDescription("missing", userID, "mine"), storage.ErrURLNotFound)
+
+	// Пустое описание удаляет его
+	require.NoError(t, s.UpdateURLDescription("noted", userID, ""))
+	link, err = s.GetLink("noted")
+	require.NoError(t, err)
+	require.Empty(t, link.Description)
+}
+
+func TestExtendURLExpiry(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	past := time.Now().Add(-time.Minute).UTC()
+	future := time.Now().Add(time.Hour).UTC()
+	extended := time.Now().Add(48 * time.Hour).UTC()
+
+	require.NoError(t, s.SaveURL("https://google.com", "expired", userID, storage.URLOptions{ExpiresAt: &past}))
+	require.NoError(t, s.SaveURL("https://google.com", "active", userID, storage.URLOptions{ExpiresAt: &future}))
+	require.NoError(t, s.SaveURL("https://google.com", "permanent", userID, storage.URLOptions{}))
+
+	require.NoError(t, s.ExtendURLExpiry("active", userID, extended))
+	urls, err := s.GetURLs([]string{"active"}, userID)
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	require.True(t, extended.Equal(*urls[0].ExpiresAt))
+
+	require.ErrorIs(t, s.ExtendURLExpiry("expired", userID, extended), storage.ErrURLExpired)
+	require.ErrorIs(t, s.ExtendURLExpiry("permanent", userID, extended), storage.ErrURLNoExpiry)
+	require.ErrorIs(t, s.ExtendURLExpiry("active", otherID, extended), storage.ErrUnauthorized)
+	require.ErrorIs(t, s.ExtendURLExpiry("missing", userID, extended), storage.ErrURLNotFound)
+}
+
+func TestTags(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://google.com", "tagged", userID, storage.URLOptions{Tags: []string{"work", "home"}}))
+	require.NoError(t, s.SaveURL("https://google.com", "plain", userID, storage.URLOptions{}))
+
+	urls, err := s.GetURLs([]string{"tagged", "plain"}, userID)
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+
+	byAlias := map[string]storage.URL{}
+	for _, u := range urls {
+		byAlias[u.Alias] = u
+	}
+	require.Equal(t, []string{"home", "work"}, byAlias["tagged"].Tags)
+	require.Nil(t, byAlias["plain"].Tags)
+
+	// Метки удаляются вместе со ссылкой и не достаются новой ссылке с тем же alias
+	require.NoError(t, s.DeleteURL("tagged", userID))
+	_, err = s.PurgeDeletedURLs(userID)
+	require.NoError(t, err)
+	require.NoError(t, s.SaveURL("https://google.com", "tagged", userID, storage.URLOptions{}))
+
+	link, err := s.GetLink("tagged")
+	require.NoError(t, err)
+	require.Nil(t, link.Tags)
+}
+
+func TestUpdateURLTags(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://google.com", "first", userID, storage.URLOptions{Tags: []string{"old", "home"}}))
+	require.NoError(t, s.SaveURL("https://google.com", "second", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://google.com", "full", userID, storage.URLOptions{Tags: []string{"a", "b"}}))
+	require.NoError(t, s.SaveURL("https://google.com", "foreign", otherID, storage.URLOptions{Tags: []string{"old"}}))
+
+	results, err := s.UpdateURLTags(
+		[]string{"first", "second", "full", "foreign", "missing"},
+		userID, []string{"work"}, []string{"old"}, 2,
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, storage.TagUpdate{Tags: []string{"home", "work"}}, results["first"])
+	require.Equal(t, storage.TagUpdate{Tags: []string{"work"}}, results["second"])
+	require.ErrorIs(t, results["full"].Err, storage.ErrTooManyTags)
+	require.ErrorIs(t, results["foreign"].Err, storage.ErrUnauthorized)
+	require.ErrorIs(t, results["missing"].Err, storage.ErrURLNotFound)
+
+	urls, err := s.GetURLs([]string{"first", "second", "full"}, userID)
+	require.NoError(t, err)
+	tags := map[string][]string{}
+	for _, u := range urls {
+		tags[u.Alias] = u.Tags
+	}
+	require.Equal(t, []string{"home", "work"}, tags["first"])
+	require.Equal(t, []string{"work"}, tags["second"])
+	require.Equal(t, []string{"a", "b"}, tags["full"])
+
+	// Чужая ссылка не изменилась
+	link, err := s.GetLink("foreign")
+	require.NoError(t, err)
+	require.Equal(t, []string{"old"}, link.Tags)
+}
+
+func TestGetStaleURLs(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	for _, alias := range []string{"old", "recent", "unused"} {
+		require.NoError(t, s.SaveURL("https://example.com/"+alias, alias, userID, storage.URLOptions{}))
+	}
+	require.NoError(t, s.SaveURL("https://example.com/foreign", "foreign", otherID, storage.URLOptions{}))
+
+	now := time.Now().UTC()
+	require.NoError(t, s.RecordClick("old", now.Add(-200*24*time.Hour)))
+	require.NoError(t, s.RecordClick("recent", now.Add(-24*time.Hour)))
+	require.NoError(t, s.RecordClick("foreign", now.Add(-200*24*time.Hour)))
+
+	stale, err := s.GetStaleURLs(userID, now.Add(-90*24*time.Hour))
+	require.NoError(t, err)
+
+	// "unused" создана только что, поэтому ещё не считается заброшенной
+	require.Len(t, stale, 1)
+	require.Equal(t, "old", stale[0].Alias)
+	require.NotNil(t, stale[0].LastAccessedAt)
+	require.WithinDuration(t, now.Add(-200*24*time.Hour), *stale[0].LastAccessedAt, time.Second)
+
+	recent, err := s.GetLink("recent")
+	require.NoError(t, err)
+	require.WithinDuration(t, now.Add(-24*time.Hour), *recent.LastAccessedAt, time.Second)
+
+	unused, err := s.GetLink("unused")
+	require.NoError(t, err)
+	require.Nil(t, unused.LastAccessedAt)
+}
+
+func TestCountURLsByUser(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	count, err := s.CountURLsByUser(userID)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), count)
+
+	for _, alias := range []string{"first", "second", "third"} {
+		require.NoError(t, s.SaveURL("https://example.com/"+alias, alias, userID, storage.URLOptions{}))
+	}
+	require.NoError(t, s.SaveURL("https://example.com/foreign", "foreign", otherID, storage.URLOptions{}))
+
+	count, err = s.CountURLsByUser(userID)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+
+	require.NoError(t, s.DeleteURL("second", userID))
+
+	count, err = s.CountURLsByUser(userID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+}
+
+func TestGetURLOwner(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("owner", "hash")
+	require.NoError(t, err)
+	require.NoError(t, s.SaveURL("https://example.com", "owned", userID, storage.URLOptions{}))
+
+	nickname, err := s.GetURLOwner("owned")
+	require.NoError(t, err)
+	require.Equal(t, "owner", nickname)
+
+	_, err = s.GetURLOwner("missing")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestPurgeDeletedURLs(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://example.com/live", "live", userID, storage.URLOptions{Tags: []string{"keep"}}))
+	require.NoError(t, s.SaveURL("https://example.com/first", "first", userID, storage.URLOptions{Tags: []string{"old"}}))
+	require.NoError(t, s.SaveURL("https://example.com/second", "second", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://example.com/foreign", "foreign", otherID, storage.URLOptions{}))
+	require.NoError(t, s.RecordClick("first", time.Now()))
+
+	require.NoError(t, s.DeleteURL("first", userID))
+	require.NoError(t, s.DeleteURL("second", userID))
+	require.NoError(t, s.DeleteURL("foreign", otherID))
+
+	// Ссылка в корзине не видна, но alias остаётся занятым
+	_, err = s.GetLink("first")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+	exists, err := s.AliasExists("first")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.ErrorIs(t, s.DeleteURL("first", userID), storage.ErrURLNotFound)
+
+	purged, err := s.PurgeDeletedURLs(userID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), purged)
+
+	exists, err = s.AliasExists("first")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// Живые ссылки и корзина другого пользователя не затронуты
+	link, err := s.GetLink("live")
+	require.NoError(t, err)
+	require.Equal(t, []string{"keep"}, link.Tags)
+	exists, err = s.AliasExists("foreign")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// Alias освобождается вместе со статистикой
+	require.NoError(t, s.SaveURL("https://example.com/new", "first", otherID, storage.URLOptions{}))
+	buckets, err := s.ClickTimeseries("first", time.Now().Add(-time.Hour), time.Now().Add(time.Hour), time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, buckets)
+
+	purged, err = s.PurgeDeletedURLs(userID)
+	require.NoError(t, err)
+	require.Zero(t, purged)
+}
+
+func TestPurgeExpiredURLs(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://example.com/live", "live", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://example.com/old", "old", userID, storage.URLOptions{Tags: []string{"old"}}))
+	require.NoError(t, s.SaveURL("https://example.com/foreign", "foreign", otherID, storage.URLOptions{}))
+	require.NoError(t, s.RecordClick("old", time.Now()))
+	require.NoError(t, s.DeleteURL("old", userID))
+	require.NoError(t, s.DeleteURL("foreign", otherID))
+
+	// Срок восстановления ещё не истёк - корзина не трогается
+	purged, err := s.PurgeExpiredURLs(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, purged)
+
+	// Истёк у обеих ссылок в корзине независимо от владельца
+	purged, err = s.PurgeExpiredURLs(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), purged)
+
+	for _, alias := range []string{"old", "foreign"} {
+		exists, err := s.AliasExists(alias)
+		require.NoError(t, err)
+		require.False(t, exists, alias)
+	}
+	_, err = s.GetLink("live")
+	require.NoError(t, err)
+
+	// Alias освобождается вместе со статистикой
+	require.NoError(t, s.SaveURL("https://example.com/new", "old", otherID, storage.URLOptions{}))
+	buckets, err := s.ClickTimeseries("old", time.Now().Add(-time.Hour), time.Now().Add(time.Hour), time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, buckets)
+}
+
+func TestRestoreURL(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://example.com/target", "main", userID, storage.URLOptions{Tags: []string{"keep"}}))
+	require.NoError(t, s.SaveURL("https://example.com/target", "extra", userID, storage.URLOptions{LinkedTo: "main"}))
+	require.NoError(t, s.SaveURL("https://example.com/live", "live", userID, storage.URLOptions{}))
+	require.NoError(t, s.DeleteURL("main", userID))
+
+	window := time.Now().Add(-time.Hour)
+
+	require.ErrorIs(t, s.RestoreURL("live", userID, window), storage.ErrURLNotFound)
+	require.ErrorIs(t, s.RestoreURL("missing", userID, window), storage.ErrURLNotFound)
+	require.ErrorIs(t, s.RestoreURL("main", otherID, window), storage.ErrUnauthorized)
+	// Ссылку, удалённую раньше начала окна, восстановить нельзя
+	require.ErrorIs(t, s.RestoreURL("main", userID, time.Now().Add(time.Hour)), storage.ErrURLNotFound)
+
+	require.NoError(t, s.RestoreURL("main", userID, window))
+
+	// Ссылка возвращается с метками, дополнительный alias - вместе с ней
+	link, err := s.GetLink("main")
+	require.NoError(t, err)
+	require.Nil(t, link.DeletedAt)
+	require.Equal(t, []string{"keep"}, link.Tags)
+	_, err = s.GetLink("extra")
+	require.NoError(t, err)
+
+	deleted, err := s.GetDeletedURLsByUser(userID)
+	require.NoError(t, err)
+	require.Empty(t, deleted)
+	require.ErrorIs(t, s.RestoreURL("main", userID, window), storage.ErrURLNotFound)
+}
+
+func TestGetAliasByURL(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, s.SaveURL("https://example.com", "expired", userID, storage.URLOptions{ExpiresAt: &past}))
+	require.NoError(t, s.SaveURL("https://example.com", "trashed", userID, storage.URLOptions{}))
+	require.NoError(t, s.DeleteURL("trashed", userID))
+
+	// Истёкшие и удалённые ссылки не считаются сокращённым адресом
+	_, err = s.GetAliasByURL("https://example.com", userID)
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	require.NoError(t, s.SaveURL("https://example.com", "first", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://example.com", "second", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://example.com", "foreign", otherID, storage.URLOptions{}))
+
+	alias, err := s.GetAliasByURL("https://example.com", userID)
+	require.NoError(t, err)
+	require.Equal(t, "first", alias)
+
+	alias, err = s.GetAliasByURL("https://example.com", otherID)
+	require.NoError(t, err)
+	require.Equal(t, "foreign", alias)
+}
+
+func TestSaveURLWithSequenceAlias(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+
+	sequence := func(id int64) (string, bool) { return base62.Encode(id), true }
+
+	// 70 ссылок переходят через границу 62: alias удлиняется с одного символа до двух
+	seen := make(map[string]bool)
+	prev := ""
+	for i := 0; i < 70; i++ {
+		target := fmt.Sprintf("https://example.com/%d", i)
+
+		alias, err := s.SaveURLWithSequenceAlias(target, userID, storage.URLOptions{Tags: []string{"seq"}}, sequence)
+		require.NoError(t, err)
+		require.False(t, seen[alias], "duplicate alias %q", alias)
+		require.GreaterOrEqual(t, len(alias), len(prev))
+		seen[alias] = true
+		prev = alias
+
+		got, err := s.GetURL(alias, userID)
+		require.NoError(t, err)
+		require.Equal(t, target, got)
+	}
+	require.Len(t, prev, 2)
+
+	link, err := s.GetLink(prev)
+	require.NoError(t, err)
+	require.Equal(t, []string{"seq"}, link.Tags)
+
+	t.Run("Alias already taken", func(t *testing.T) {
+		// Свой alias "1a" получает id 71, и следующий id 72 даёт тот же alias
+		require.NoError(t, s.SaveURL("https://example.com/custom", "1a", userID, storage.URLOptions{}))
+
+		_, err := s.SaveURLWithSequenceAlias("https://example.com/next", userID, storage.URLOptions{}, sequence)
+		require.ErrorIs(t, err, storage.ErrURLExists)
+
+		// Откат не оставил строку с временным alias
+		count, err := s.CountURLsByUser(userID)
+		require.NoError(t, err)
+		require.Equal(t, int64(71), count)
+	})
+
+	t.Run("Alias rejected", func(t *testing.T) {
+		_, err := s.SaveURLWithSequenceAlias("https://example.com/next", userID, storage.URLOptions{}, func(int64) (string, bool) {
+			return "", false
+		})
+		require.ErrorIs(t, err, storage.ErrURLExists)
+	})
+
+	t.Run("Prefix claimed by another user", func(t *testing.T) {
+		otherID, err := s.SaveUser("other", "hash")
+		require.NoError(t, err)
+		require.NoError(t, s.ClaimPrefix("zz", otherID, 1))
+
+		claimed := func(int64) (string, bool) { return "zz1", true }
+
+		_, err = s.SaveURLWithSequenceAlias("https://example.com/next", userID, storage.URLOptions{}, claimed)
+		require.ErrorIs(t, err, storage.ErrURLExists)
+
+		alias, err := s.SaveURLWithSequenceAlias("https://example.com/next", otherID, storage.URLOptions{}, claimed)
+		require.NoError(t, err)
+		require.Equal(t, "zz1", alias)
+	})
+}
+
+func TestTransferURLs(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	newOwnerID, err := s.SaveUser("new_owner", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://example.com/first", "first", userID, storage.URLOptions{Tags: []string{"docs"}}))
+	require.NoError(t, s.SaveURL("https://example.com/second", "second", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://example.com/own", "own", newOwnerID, storage.URLOptions{}))
+	require.NoError(t, s.DeleteURL("second", userID))
+
+	moved, err := s.TransferURLs(userID, newOwnerID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), moved)
+
+	// У прежнего владельца не осталось ссылок, даже в корзине
+	count, err := s.CountURLsByUser(userID)
+	require.NoError(t, err)
+	require.Zero(t, count)
+	trash, err := s.GetDeletedURLsByUser(userID)
+	require.NoError(t, err)
+	require.Empty(t, trash)
+
+	// Ссылки открываются у нового владельца вместе с метками
+	target, err := s.GetURL("first", newOwnerID)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/first", target)
+	link, err := s.GetLink("first")
+	require.NoError(t, err)
+	require.Equal(t, []string{"docs"}, link.Tags)
+
+	count, err = s.CountURLsByUser(newOwnerID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+	trash, err = s.GetDeletedURLsByUser(newOwnerID)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+
+	moved, err = s.TransferURLs(userID, newOwnerID)
+	require.NoError(t, err)
+	require.Zero(t, moved)
+}
+
+func TestGetDeletedURLsByUser(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://example.com/live", "live", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://example.com/deleted", "deleted", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://example.com/foreign", "foreign", otherID, storage.URLOptions{}))
+	require.NoError(t, s.DeleteURL("deleted", userID))
+	require.NoError(t, s.DeleteURL("foreign", otherID))
+
+	urls, err := s.GetDeletedURLsByUser(userID)
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	require.Equal(t, "deleted", urls[0].Alias)
+	require.NotNil(t, urls[0].DeletedAt)
+
+	live, err := s.GetURLsByUser(userID)
+	require.NoError(t, err)
+	require.Len(t, live, 1)
+	require.Equal(t, "live", live[0].Alias)
+	require.Nil(t, live[0].DeletedAt)
+}
+
+func TestGetBrokenURLsByUser(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	for _, alias := range []string{"ok", "redirected", "not_found", "server_error", "unreachable", "unchecked", "skipped", "deleted"} {
+		require.NoError(t, s.SaveURL("https://example.com/"+alias, alias, userID, storage.URLOptions{}))
+	}
+	require.NoError(t, s.SaveURL("https://example.com/foreign", "foreign", otherID, storage.URLOptions{}))
+
+	checkedAt := time.Now().Add(-time.Hour)
+	statuses := map[string]int{
+		"ok":           http.StatusOK,
+		"redirected":   http.StatusMovedPermanently,
+		"not_found":    http.StatusNotFound,
+		"server_error": http.StatusBadGateway,
+		"unreachable":  0,
+		"deleted":      http.StatusNotFound,
+		"foreign":      http.StatusNotFound,
+	}
+	for alias, status := range statuses {
+		status := status
+		require.NoError(t, s.SetURLHealth(alias, &status, checkedAt))
+	}
+	// Пропущенная проверка не даёт статуса
+	require.NoError(t, s.SetURLHealth("skipped", nil, checkedAt))
+	require.NoError(t, s.DeleteURL("deleted", userID))
+
+	urls, err := s.GetBrokenURLsByUser(userID)
+	require.NoError(t, err)
+
+	aliases := make([]string, 0, len(urls))
+	for _, u := range urls {
+		aliases = append(aliases, u.Alias)
+		require.NotNil(t, u.LastStatus)
+		require.NotNil(t, u.LastCheckedAt)
+	}
+	require.ElementsMatch(t, []string{"not_found", "server_error", "unreachable"}, aliases)
+}
+
+func TestGetURLsToCheck(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+
+	for _, alias := range []string{"fresh", "old", "never"} {
+		require.NoError(t, s.SaveURL("https://example.com/"+alias, alias, userID, storage.URLOptions{}))
+	}
+
+	ok := http.StatusOK
+	now := time.Now()
+	require.NoError(t, s.SetURLHealth("fresh", &ok, now))
+	require.NoError(t, s.SetURLHealth("old", &ok, now.Add(-48*time.Hour)))
+
+	urls, err := s.GetURLsToCheck(now.Add(-24*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+
+	// Сначала ссылки, которые ещё не проверялись
+	require.Equal(t, "never", urls[0].Alias)
+	require.Equal(t, "old", urls[1].Alias)
+
+	// Пропущенная проверка сохраняет прежний статус
+	require.NoError(t, s.SetURLHealth("old", nil, now))
+	link, err := s.GetLink("old")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, *link.LastStatus)
+	require.WithinDuration(t, now, *link.LastCheckedAt, time.Second)
+}
+
+func TestHashedNicknames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	s, err := sqlite.New(path)
+	require.NoError(t, err)
+	s.UseHashedNicknames([]byte("secret"))
+
+	userID, err := s.SaveUser("Alice", "hash")
+	require.NoError(t, err)
+	require.NoError(t, s.SaveURL("https://example.com", "owned", userID, storage.URLOptions{}))
+
+	// Поиск работает по нормализованному никнейму
+	id, passwordHash, err := s.GetUserByNickname("alice")
+	require.NoError(t, err)
+	require.Equal(t, userID, id)
+	require.Equal(t, "hash", passwordHash)
+
+	_, err = s.SaveUser(" ALICE ", "hash")
+	require.ErrorIs(t, err, storage.ErrUserExists)
+
+	// Для показа возвращается исходный никнейм
+	user, err := s.GetUser("Alice")
+	require.NoError(t, err)
+	require.Equal(t, "Alice", user.Nickname)
+
+	owner, err := s.GetURLOwner("owned")
+	require.NoError(t, err)
+	require.Equal(t, "Alice", owner)
+
+	// Открытый никнейм не является ключом поиска
+	plain, err := sqlite.New(path)
+	require.NoError(t, err)
+
+	_, _, err = plain.GetUserByNickname("Alice")
+	require.ErrorIs(t, err, storage.ErrUserNotFound)
+	_, _, err = plain.GetUserByNickname(storage.NicknameKey([]byte("secret"), "Alice"))
+	require.NoError(t, err)
+}
+
+func TestRenameAlias(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://example.com/target", "old", userID, storage.URLOptions{Tags: []string{"work"}}))
+	require.NoError(t, s.SaveURL("https://example.com/taken", "taken", otherID, storage.URLOptions{}))
+	now := time.Now()
+	require.NoError(t, s.RecordClick("old", now))
+	require.NoError(t, s.RecordClick("old", now))
+
+	require.ErrorIs(t, s.RenameAlias("old", "new", otherID), storage.ErrUnauthorized)
+	require.ErrorIs(t, s.RenameAlias("missing", "new", userID), storage.ErrURLNotFound)
+	require.ErrorIs(t, s.RenameAlias("old", "taken", userID), storage.ErrAliasTaken)
+
+	require.NoError(t, s.RenameAlias("old", "new", userID))
+
+	_, err = s.GetLink("old")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	link, err := s.GetLink("new")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/target", link.URL)
+	require.Equal(t, []string{"work"}, link.Tags)
+
+	// Переходы переезжают вместе со ссылкой
+	buckets, err := s.ClickTimeseries("new", now.Add(-time.Hour), now.Add(time.Hour), 2*time.Hour)
+	require.NoError(t, err)
+	var clicks int64
+	for _, b := range buckets {
+		clicks += b.Clicks
+	}
+	require.Equal(t, int64(2), clicks)
+}
+
+func TestLinkedAlias(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://example.com/target", "main", userID, storage.URLOptions{}))
+	require.NoError(t, s.SaveURL("https://example.com/target", "extra", userID, storage.URLOptions{LinkedTo: "main"}))
+
+	// Оба alias открывают один адрес
+	for _, alias := range []string{"main", "extra"} {
+		target, err := s.GetURL(alias, userID)
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/target", target)
+	}
+
+	link, err := s.GetLink("extra")
+	require.NoError(t, err)
+	require.Equal(t, "main", link.LinkedTo)
+
+	link, err = s.GetLink("main")
+	require.NoError(t, err)
+	require.Empty(t, link.LinkedTo)
+
+	// Переходы считаются по каждому alias отдельно
+	require.NoError(t, s.RecordClick("extra", time.Now()))
+	clicks, err := s.CountClicks("main")
+	require.NoError(t, err)
+	require.Zero(t, clicks)
+
+	// Переименование основного alias сохраняет привязку
+	require.NoError(t, s.RenameAlias("main", "renamed", userID))
+	link, err = s.GetLink("extra")
+	require.NoError(t, err)
+	require.Equal(t, "renamed", link.LinkedTo)
+}
+
+func TestLinkedAliasFollowsMain(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour).UTC()
+	opts := storage.URLOptions{ExpiresAt: &expiresAt}
+	require.NoError(t, s.SaveURL("https://example.com/target", "main", userID, opts))
+	opts.LinkedTo = "main"
+	require.NoError(t, s.SaveURL("https://example.com/target", "extra", userID, opts))
+	require.NoError(t, s.SaveURL("https://example.com/target", "other", userID, storage.URLOptions{ExpiresAt: &expiresAt}))
+
+	// Срок и видимость основной ссылки распространяются на дополнительные alias
+	extended := expiresAt.Add(24 * time.Hour)
+	require.NoError(t, s.ExtendURLExpiry("main", userID, extended))
+	require.NoError(t, s.SetURLVisibility("main", true, userID))
+
+	link, err := s.GetLink("extra")
+	require.NoError(t, err)
+	require.True(t, link.Public)
+	require.WithinDuration(t, extended, *link.ExpiresAt, time.Second)
+
+	// Ссылки, не привязанные к основной, не меняются
+	link, err = s.GetLink("other")
+	require.NoError(t, err)
+	require.False(t, link.Public)
+	require.WithinDuration(t, expiresAt, *link.ExpiresAt, time.Second)
+
+	// Изменение дополнительного alias не трогает основной
+	require.NoError(t, s.SetURLVisibility("extra", false, userID))
+	link, err = s.GetLink("main")
+	require.NoError(t, err)
+	require.True(t, link.Public)
+
+	// Удалённая ссылка уносит дополнительные alias в корзину
+	require.NoError(t, s.DeleteURL("main", userID))
+	_, err = s.GetLink("extra")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+	_, err = s.GetLink("other")
+	require.NoError(t, err)
+}
+
+func TestSetURLVisibility(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://example.com", "link", userID, storage.URLOptions{}))
+
+	require.NoError(t, s.SetURLVisibility("link", true, userID))
+	link, err := s.GetLink("link")
+	require.NoError(t, err)
+	require.True(t, link.Public)
+
+	require.NoError(t, s.SetURLVisibility("link", false, userID))
+	link, err = s.GetLink("link")
+	require.NoError(t, err)
+	require.False(t, link.Public)
+
+	require.ErrorIs(t, s.SetURLVisibility("link", true, otherID), storage.ErrUnauthorized)
+	require.ErrorIs(t, s.SetURLVisibility("missing", true, userID), storage.ErrURLNotFound)
+}
+
+func TestSetURLPreview(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SaveURL("https://example.com", "link", userID, storage.URLOptions{}))
+
+	// Превью ещё не загружалось
+	link, err := s.GetLink("link")
+	require.NoError(t, err)
+	require.Nil(t, link.Preview)
+
+	fetchedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, s.SetURLPreview("link", userID, storage.Preview{Title: "Old", Description: "Page", Image: "https://example.com/a.png", FetchedAt: fetchedAt}))
+	require.NoError(t, s.SetURLPreview("link", userID, storage.Preview{Title: "New", FetchedAt: fetchedAt.Add(time.Hour)}))
+
+	link, err = s.GetLink("link")
+	require.NoError(t, err)
+	require.Equal(t, &storage.Preview{Title: "New", FetchedAt: fetchedAt.Add(time.Hour)}, link.Preview)
+
+	require.ErrorIs(t, s.SetURLPreview("link", otherID, storage.Preview{FetchedAt: fetchedAt}), storage.ErrUnauthorized)
+	require.ErrorIs(t, s.SetURLPreview("missing", userID, storage.Preview{FetchedAt: fetchedAt}), storage.ErrURLNotFound)
+}
+
+func TestClaimPrefix(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.ClaimPrefix("acme", userID, 2))
+	// Повторный захват своего префикса не считается новым
+	require.NoError(t, s.ClaimPrefix("ACME", userID, 2))
+
+	ownerID, err := s.GetPrefixOwner("Acme-docs")
+	require.NoError(t, err)
+	require.Equal(t, userID, ownerID)
+
+	_, err = s.GetPrefixOwner("other-docs")
+	require.ErrorIs(t, err, storage.ErrPrefixNotFound)
+
+	// Пересечение с чужим префиксом в обе стороны
+	require.ErrorIs(t, s.ClaimPrefix("acme", otherID, 2), storage.ErrPrefixTaken)
+	require.ErrorIs(t, s.ClaimPrefix("acme-team", otherID, 2), storage.ErrPrefixTaken)
+	require.ErrorIs(t, s.ClaimPrefix("ac", otherID, 2), storage.ErrPrefixTaken)
+	require.NoError(t, s.ClaimPrefix("initech", otherID, 2))
+
+	require.NoError(t, s.ClaimPrefix("acme-team", userID, 2))
+	require.ErrorIs(t, s.ClaimPrefix("globex", userID, 2), storage.ErrTooManyPrefixes)
+}
+
+func TestPrefixEnforcedOnWrite(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, s.ClaimPrefix("acme", userID, 1))
+
+	// Владелец префикса пишет под ним, другие - нет, каким бы путём ни появился alias
+	require.NoError(t, s.SaveURL("https://example.com", "acme-docs", userID, storage.URLOptions{}))
+	require.ErrorIs(t, s.SaveURL("https://example.com", "Acme-blog", otherID, storage.URLOptions{}), storage.ErrPrefixTaken)
+
+	require.NoError(t, s.SaveURL("https://example.com", "other", otherID, storage.URLOptions{}))
+	require.ErrorIs(t, s.RenameAlias("other", "acme-other", otherID), storage.ErrPrefixTaken)
+	require.NoError(t, s.RenameAlias("acme-docs", "acme-guide", userID))
+}
+
+func TestLoginEvents(t *testing.T) {
+	s := newStorage(t)
+
+	userID, err := s.SaveUser("user", "hash")
+	require.NoError(t, err)
+	otherID, err := s.SaveUser("other", "hash")
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		event := storage.LoginEvent{
+			At:        start.Add(time.Duration(i) * time.Minute),
+			IP:        "10.0.0.1",
+			UserAgent: "curl/8.4.0",
+			Result:    storage.LoginSuccess,
+		}
+		require.NoError(t, s.RecordLoginEvent(userID, event, 3))
+	}
+	require.NoError(t, s.RecordLoginEvent(otherID, storage.LoginEvent{At: start, Result: storage.LoginFailure}, 3))
+
+	// Хранятся только 3 последних события, от новых к старым
+	events, err := s.GetLoginEvents(userID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.Equal(t, start.Add(3*time.Minute), events[0].At)
+	require.Equal(t, start.Add(time.Minute), events[2].At)
+	require.Equal(t, "10.0.0.1", events[0].IP)
+	require.Equal(t, storage.LoginSuccess, events[0].Result)
+
+	page, err := s.GetLoginEvents(userID, 2, 2)
+	require.NoError(t, err)
+	require.Equal(t, events[2:], page)
+
+	// События другого пользователя не затронуты ограничением
+	events, err = s.GetLoginEvents(otherID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, storage.LoginFailure, events[0].Result)
+}
@@ -1,11 +1,285 @@
 package storage
 
-import "errors"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
 
 var (
 	ErrURLNotFound  = errors.New("Url not found")
 	ErrURLExists    = errors.New("Url exists")
+	ErrURLExpired   = errors.New("Url expired")
+	ErrURLNoExpiry  = errors.New("Url does not expire")
+	ErrAliasTaken   = errors.New("Alias is taken by another user")
 	ErrUserExists   = errors.New("User exists")
 	ErrUserNotFound = errors.New("User not found")
 	ErrUnauthorized = errors.New("Unauthorized")
+	ErrTooManyTags  = errors.New("Too many tags")
+
+	ErrPrefixNotFound  = errors.New("Prefix not claimed")
+	ErrPrefixTaken     = errors.New("Prefix is claimed by another user")
+	ErrTooManyPrefixes = errors.New("Too many prefixes")
+
+	// ErrSequenceUnsupported - alias из id строки берётся у SQLite, а она не подключена
+	ErrSequenceUnsupported = errors.New("Sequence aliases require SQLite")
+
+	// ErrUnavailable - ответ неоднозначен: одна база вернула сбой, другая не нашла запись.
+	// Запись может существовать, поэтому запрос стоит повторить, а не считать её отсутствующей.
+	ErrUnavailable = errors.New("Storage temporarily unavailable")
+)
+
+// URL - сохранённая короткая ссылка
+type URL struct {
+	Alias     string     `json:"alias"`
+	URL       string     `json:"url"`
+	Domain    string     `json:"domain"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Public - ссылка доступна без авторизации через /r/{alias}
+	Public bool `json:"public"`
+	// Wildcard - к адресу добавляется остаток пути после alias: /r/{alias}/a/b -> {url}/a/b
+	Wildcard bool `json:"wildcard"`
+	// ForwardQuery - параметры запроса к короткой ссылке добавляются к адресу
+	ForwardQuery bool `json:"forward_query"`
+	// RedirectStatus - код ответа при переходе по ссылке
+	RedirectStatus int `json:"redirect_status"`
+	// Tags - нормализованные метки ссылки
+	Tags []string `json:"tags,omitempty"`
+	// LastAccessedAt - время последнего перехода по ссылке; nil - переходов не было
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	// DeletedAt - время переноса ссылки в корзину; nil - ссылка не удалена
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// LastStatus - HTTP-статус цели при последней фоновой проверке; 0 - цель не ответила
+	LastStatus *int `json:"last_status,omitempty"`
+	// LastCheckedAt - время последней фоновой проверки; nil - ссылка не проверялась
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+	// LinkedTo - основной alias, к которому эта ссылка создана дополнительным (POST /url/{alias}/link);
+	// пусто - самостоятельная ссылка
+	LinkedTo string `json:"linked_to,omitempty"`
+	// Preview - сохранённые метаданные страницы-цели; nil - ещё не загружались
+	Preview *Preview `json:"preview,omitempty"`
+	// Description - заметка владельца о том, зачем нужна ссылка
+	Description string `json:"description,omitempty"`
+	UserID      int64  `json:"-"`
+}
+
+// Preview - метаданные страницы-цели для превью ссылки. Устаревают, когда меняется
+// страница, и обновляются через POST /url/{alias}/refresh-preview.
+type Preview struct {
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Image       string    `json:"image,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// URLOptions - необязательные параметры сохраняемой ссылки
+type URLOptions struct {
+	// ExpiresAt - момент, после которого ссылка перестаёт работать. nil - бессрочно.
+	ExpiresAt    *time.Time
+	Public       bool
+	Wildcard     bool
+	ForwardQuery bool
+	// RedirectStatus - код ответа при переходе; 0 - 302 Found
+	RedirectStatus int
+	// Tags - метки ссылки, уже нормализованные
+	Tags []string
+	// LinkedTo - основной alias, если ссылка создаётся дополнительным alias к нему
+	LinkedTo string
+	// Description - заметка владельца о ссылке
+	Description string
+}
+
+// Expired сообщает, истёк ли срок действия ссылки к моменту now
+func Expired(expiresAt *time.Time, now time.Time) bool {
+	return expiresAt != nil && !now.Before(*expiresAt)
+}
+
+// NicknameKey возвращает значение, по которому пользователь ищется в базе. Без ключа
+// это сам никнейм, с ключом - HMAC-SHA256 никнейма, приведённого к нижнему регистру
+// и очищенного от пробелов по краям.
+func NicknameKey(hashKey []byte, nickname string) string {
+	if len(hashKey) == 0 {
+		return nickname
+	}
+
+	mac := hmac.New(sha256.New, hashKey)
+	mac.Write([]byte(CanonicalNickname(nickname)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CanonicalNickname - никнейм в том виде, в котором он хэшируется. При хэшировании все
+// его варианты ведут к одному пользователю, поэтому токены, сессии и список
+// администраторов должны использовать эту форму, а не никнейм из запроса.
+func CanonicalNickname(nickname string) string {
+	return strings.ToLower(strings.TrimSpace(nickname))
+}
+
+// PrefixKey приводит префикс alias к виду, в котором он хранится и сравнивается:
+// префиксы не зависят от регистра, иначе чужой префикс легко обойти
+func PrefixKey(prefix string) string {
+	return strings.ToLower(strings.TrimSpace(prefix))
+}
+
+// AliasUnavailable сообщает, что сгенерированный alias записать нельзя и нужно пробовать
+// другой: он уже занят или попадает под префикс другого пользователя
+func AliasUnavailable(err error) bool {
+	return errors.Is(err, ErrURLExists) || errors.Is(err, ErrAliasTaken) || errors.Is(err, ErrPrefixTaken)
+}
+
+// PrefixCandidates возвращает все начала alias в виде PrefixKey, от самого короткого:
+// занятый префикс, под который попадает alias, - один из них
+func PrefixCandidates(alias string) []string {
+	key := []rune(PrefixKey(alias))
+
+	candidates := make([]string, 0, len(key))
+	for i := 1; i <= len(key); i++ {
+		candidates = append(candidates, string(key[:i]))
+	}
+
+	return candidates
+}
+
+// Интервалы группировки переходов
+const (
+	BucketHour = "hour"
+	BucketDay  = "day"
 )
+
+// BucketSizes - длительность интервала для каждого варианта группировки
+var BucketSizes = map[string]time.Duration{
+	BucketHour: time.Hour,
+	BucketDay:  24 * time.Hour,
+}
+
+// ClickBucket - число переходов по ссылке за интервал, начинающийся в Start (UTC)
+type ClickBucket struct {
+	Start  time.Time `json:"start"`
+	Clicks int64     `json:"clicks"`
+}
+
+// TagUpdate - итог изменения меток одной ссылки: новые метки или причина отказа
+// (ErrURLNotFound, ErrUnauthorized, ErrTooManyTags)
+type TagUpdate struct {
+	Tags []string
+	Err  error
+}
+
+// MergeTags добавляет к current метки add и убирает remove. Результат отсортирован.
+func MergeTags(current, add, remove []string) []string {
+	set := make(map[string]struct{}, len(current)+len(add))
+	for _, tag := range current {
+		set[tag] = struct{}{}
+	}
+	for _, tag := range add {
+		set[tag] = struct{}{}
+	}
+	for _, tag := range remove {
+		delete(set, tag)
+	}
+
+	if len(set) == 0 {
+		return nil
+	}
+
+	merged := make([]string, 0, len(set))
+	for tag := range set {
+		merged = append(merged, tag)
+	}
+	sort.Strings(merged)
+
+	return merged
+}
+
+// Варианты сортировки статистики ссылок
+const (
+	StatsSortCreated = "created_at"
+	StatsSortClicks  = "clicks"
+)
+
+// LinkStats - сводная статистика одной ссылки
+type LinkStats struct {
+	Alias       string     `json:"alias"`
+	Description string     `json:"description,omitempty"`
+	Clicks      int64      `json:"clicks"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastClickAt *time.Time `json:"last_click_at,omitempty"`
+}
+
+// TagCount - метка и число живых ссылок пользователя с ней
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// UserSettings - персональные настройки пользователя. nil - используется глобальное значение.
+type UserSettings struct {
+	// DefaultAliasLength - длина случайного alias для ссылок пользователя
+	DefaultAliasLength *int `json:"default_alias_length,omitempty"`
+	// DefaultRedirectStatus - код ответа при переходе по новым ссылкам (301, 302, 307, 308)
+	DefaultRedirectStatus *int `json:"default_redirect_status,omitempty"`
+	// DefaultTTLSeconds - срок жизни новых ссылок, если он не указан при сохранении
+	DefaultTTLSeconds *int64 `json:"default_ttl_seconds,omitempty"`
+	// AllowDuplicateURLs - можно ли сократить адрес, который у пользователя уже есть;
+	// nil - глобальная настройка allow_duplicate_urls
+	AllowDuplicateURLs *bool `json:"allow_duplicate_urls,omitempty"`
+	// RedirectTemplate - своя промежуточная страница публичных ссылок (?preview=1);
+	// пусто - страница по умолчанию
+	RedirectTemplate string `json:"redirect_template,omitempty"`
+}
+
+// User - профиль пользователя. Хэш пароля сюда намеренно не входит.
+type User struct {
+	ID        int64     `json:"-"`
+	Nickname  string    `json:"nickname"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Итог попытки входа
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+// LoginEvent - попытка входа в аккаунт пользователя
+type LoginEvent struct {
+	At        time.Time `json:"at"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	// Result - storage.LoginSuccess или storage.LoginFailure
+	Result string `json:"result"`
+}
+
+// Режимы работы хранилища
+const (
+	ModeDual   = "dual"
+	ModeSQLite = "sqlite"
+	ModeMongo  = "mongo"
+)
+
+// BackendStatus - состояние одной базы данных
+type BackendStatus struct {
+	Up    bool   `json:"up"`
+	Error string `json:"error,omitempty"`
+	// LatencyMs - длительность пинга в миллисекундах; у не ответившей базы - время до таймаута
+	LatencyMs *float64 `json:"latency_ms,omitempty"`
+	// TimedOut - база не ответила на пинг за отведённое время
+	TimedOut bool `json:"timed_out,omitempty"`
+	// Breaker - состояние переключателя на резервную базу: closed (запросы идут) или open (база отключена)
+	Breaker string `json:"breaker,omitempty"`
+	// SecondsSinceLastWrite - секунды с последней успешной записи (только для MongoDB)
+	SecondsSinceLastWrite *float64 `json:"seconds_since_last_write,omitempty"`
+}
+
+// Status - сводное состояние хранилища для операторов
+type Status struct {
+	ConfiguredMode string                   `json:"configured_mode"`
+	EffectiveMode  string                   `json:"effective_mode"`
+	Degraded       bool                     `json:"degraded"`
+	Backends       map[string]BackendStatus `json:"backends"`
+}
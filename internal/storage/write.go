@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// WriteOutcome - итог записи в одну базу
+type WriteOutcome string
+
+const (
+	// WriteSkipped - база в записи не участвовала: не подключена, недоступна
+	// или запись остановилась на ошибке первой базы
+	WriteSkipped WriteOutcome = "skipped"
+	WriteOK      WriteOutcome = "ok"
+	WriteFailed  WriteOutcome = "failed"
+	// WriteRolledBack - запись прошла, но отменена, потому что её не приняла вторая база
+	WriteRolledBack WriteOutcome = "rolled_back"
+)
+
+// WriteResult - итог записи хранилища по базам. Ошибка метода хранилища по-прежнему
+// означает, что запись не выполнена; WriteResult показывает, какие базы её приняли,
+// в том числе когда запись удалась только в SQLite из-за недоступной MongoDB.
+type WriteResult struct {
+	// Op - описание операции, например "save URL"
+	Op     string       `json:"op"`
+	SQLite WriteOutcome `json:"sqlite"`
+	Mongo  WriteOutcome `json:"mongo"`
+	// Degraded - запись шла в деградированном режиме, MongoDB пропущена
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+// Partial сообщает, что запись сохранена только в одной из двух баз режима dual:
+// MongoDB пропущена из-за деградированного режима или вторая база отказала, а откат не удался.
+// В режимах sqlite и mongo вторая база не подключена, и такая запись частичной не считается.
+func (r WriteResult) Partial() bool {
+	if (r.SQLite == WriteOK) == (r.Mongo == WriteOK) {
+		return false
+	}
+
+	return r.Degraded || r.SQLite == WriteFailed || r.Mongo == WriteFailed
+}
+
+// WriteResults собирает итоги записей, выполненных в рамках одного запроса
+type WriteResults struct {
+	mu      sync.Mutex
+	results []WriteResult
+}
+
+// All возвращает итоги в порядке записей
+func (w *WriteResults) All() []WriteResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]WriteResult(nil), w.results...)
+}
+
+// Partial сообщает, что хотя бы одна запись сохранена только в одной базе
+func (w *WriteResults) Partial() bool {
+	for _, r := range w.All() {
+		if r.Partial() {
+			return true
+		}
+	}
+
+	return false
+}
+
+type writeResultsKey struct{}
+
+// WithWriteResults возвращает контекст, в который хранилище складывает итоги записей.
+// Обработчик передаёт этот контекст в методы хранилища и после них читает итоги.
+func WithWriteResults(ctx context.Context) (context.Context, *WriteResults) {
+	results := &WriteResults{}
+
+	return context.WithValue(ctx, writeResultsKey{}, results), results
+}
+
+// RecordWrite добавляет итог записи в контекст, если он создан WithWriteResults
+func RecordWrite(ctx context.Context, result WriteResult) {
+	results, ok := ctx.Value(writeResultsKey{}).(*WriteResults)
+	if !ok {
+		return
+	}
+
+	results.mu.Lock()
+	results.results = append(results.results, result)
+	results.mu.Unlock()
+}
@@ -0,0 +1,66 @@
+package trashpurge
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// Store - хранилище, корзину которого очищает Purger
+type Store interface {
+	PurgeExpiredURLs(ctx context.Context, log *slog.Logger, deletedBefore time.Time) (int64, error)
+}
+
+// Purger периодически окончательно удаляет ссылки, у которых истёк срок восстановления
+// из корзины: их alias освобождается, а метки и статистика удаляются.
+type Purger struct {
+	log      *slog.Logger
+	store    Store
+	window   time.Duration
+	interval time.Duration
+}
+
+// New создаёт Purger: ссылки старше window удаляются каждые interval
+func New(log *slog.Logger, store Store, window, interval time.Duration) *Purger {
+	return &Purger{
+		log:      log.With(slog.String("component", "trashpurge")),
+		store:    store,
+		window:   window,
+		interval: interval,
+	}
+}
+
+// Run очищает корзину сразу и затем каждые interval, пока не будет отменён ctx
+func (p *Purger) Run(ctx context.Context) {
+	p.Purge(ctx, time.Now())
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			p.Purge(ctx, now)
+		}
+	}
+}
+
+// Purge удаляет ссылки, срок восстановления которых истёк к моменту now,
+// и возвращает их количество
+func (p *Purger) Purge(ctx context.Context, now time.Time) int64 {
+	purged, err := p.store.PurgeExpiredURLs(ctx, p.log, now.Add(-p.window))
+	if err != nil {
+		p.log.Error("failed to purge expired urls", sl.Err(err))
+		return 0
+	}
+	if purged > 0 {
+		p.log.Info("purged expired urls", slog.Int64("count", purged))
+	}
+
+	return purged
+}
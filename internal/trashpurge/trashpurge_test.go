@@ -0,0 +1,46 @@
+package trashpurge_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/trashpurge"
+)
+
+// stubStore запоминает границу очистки и отдаёт заранее заданный результат
+type stubStore struct {
+	deletedBefore time.Time
+	purged        int64
+	err           error
+}
+
+func (s *stubStore) PurgeExpiredURLs(_ context.Context, _ *slog.Logger, deletedBefore time.Time) (int64, error) {
+	s.deletedBefore = deletedBefore
+
+	return s.purged, s.err
+}
+
+func TestPurger_Purge(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Purges links past the restore window", func(t *testing.T) {
+		store := &stubStore{purged: 3}
+		p := trashpurge.New(slogdiscard.NewDiscardLogger(), store, 72*time.Hour, time.Hour)
+
+		require.Equal(t, int64(3), p.Purge(context.Background(), now))
+		require.Equal(t, now.Add(-72*time.Hour), store.deletedBefore)
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		store := &stubStore{purged: 3, err: errors.New("db is down")}
+		p := trashpurge.New(slogdiscard.NewDiscardLogger(), store, time.Hour, time.Hour)
+
+		require.Zero(t, p.Purge(context.Background(), now))
+	})
+}